@@ -0,0 +1,216 @@
+// Command eval runs the retrieval evaluation over the labeled question/entry pairs against the configured
+// database, vector store and AI providers, prints the metrics and persists the run with the others for
+// regression tracking. It exits with status 1 when a metric is below its threshold or regressed since the
+// previous run, so that it can gate deployments of retrieval changes.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+
+	"tic-knowledge-system/internal/api"
+	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/db"
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// metric is one line of the report
+type metric struct {
+	name     string
+	value    float64
+	previous *float64
+	min      float64
+}
+
+func main() {
+	answers := flag.Bool("answers", false, "Also answer every question through the chat path and score faithfulness with an LLM judge")
+	minRecall := flag.Float64("min-recall-at-5", 0, "Fail when recall@5 is below this")
+	minMRR := flag.Float64("min-mrr", 0, "Fail when MRR is below this")
+	minFaithfulness := flag.Float64("min-faithfulness", 0, "Fail when the mean faithfulness is below this (with -answers)")
+	maxDrop := flag.Float64("max-drop", 0.05, "Fail when a metric dropped by more than this since the previous comparable run; negative disables it")
+	verbose := flag.Bool("v", false, "List the pairs whose expected entry was not retrieved in the top 5, and unfaithful answers")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: eval [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nEvaluates retrieval over the labeled pairs of /api/v1/retrieval-eval/pairs with the configuration of")
+		fmt.Fprintln(flag.CommandLine.Output(), "the environment and records the run. -answers calls the AI provider twice per pair.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	database, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	evalService := newEvalService(cfg, database)
+	previous := previousRun(evalService, *answers)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	run, err := evalService.RunEvaluation(ctx, services.RetrievalEvalTriggerCLI, *answers)
+	if err != nil {
+		log.Fatal("Evaluation failed:", err)
+	}
+	if run.PairCount == 0 {
+		log.Fatal("No labeled pairs to evaluate, create some through POST /api/v1/retrieval-eval/pairs")
+	}
+
+	metrics := []metric{
+		{name: "recall@1", value: run.RecallAt1},
+		{name: "recall@3", value: run.RecallAt3},
+		{name: "recall@5", value: run.RecallAt5, min: *minRecall},
+		{name: "recall@10", value: run.RecallAt10},
+		{name: "mrr", value: run.MRR, min: *minMRR},
+		{name: "mean top score", value: run.MeanTopScore},
+	}
+	if *answers {
+		metrics = append(metrics, metric{name: "faithfulness", value: run.MeanFaithfulness, min: *minFaithfulness})
+	}
+	if previous != nil {
+		previousValues := []float64{previous.RecallAt1, previous.RecallAt3, previous.RecallAt5, previous.RecallAt10,
+			previous.MRR, previous.MeanTopScore, previous.MeanFaithfulness}
+		for i := range metrics {
+			metrics[i].previous = &previousValues[i]
+		}
+	}
+
+	fmt.Printf("Run %s: %d pairs", run.ID, run.PairCount)
+	if *answers {
+		fmt.Printf(", %d answers judged", run.AnswerCount)
+	}
+	if previous != nil {
+		fmt.Printf(", compared with run %s of %s", previous.ID, previous.StartedAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Println()
+	fmt.Println()
+
+	failed := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "metric\tvalue\tprevious\tchange\tstatus\t")
+	for _, m := range metrics {
+		previousValue, change, status := "-", "-", "ok"
+		if m.previous != nil {
+			previousValue = strconv.FormatFloat(*m.previous, 'f', 3, 64)
+			change = fmt.Sprintf("%+.3f", m.value-*m.previous)
+			if *maxDrop >= 0 && *m.previous-m.value > *maxDrop {
+				status = "REGRESSED"
+			}
+		}
+		if m.value < m.min {
+			status = "BELOW MIN"
+		}
+		if status != "ok" {
+			failed = true
+		}
+		fmt.Fprintf(w, "%s\t%.3f\t%s\t%s\t%s\t\n", m.name, m.value, previousValue, change, status)
+	}
+	w.Flush()
+
+	if *verbose {
+		printDetails(evalService, run)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// newEvalService wires the services retrieval depends on the way the API server does
+func newEvalService(cfg *config.Config, database *gorm.DB) *services.RetrievalEvalService {
+	maxTokens, _ := strconv.Atoi(cfg.MaxTokens)
+	temperature, _ := strconv.ParseFloat(cfg.Temperature, 32)
+
+	var openAIService *services.OpenAIService
+	if cfg.AzureOpenAIEndpoint != "" {
+		openAIService = services.NewAzureOpenAIService(cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIAPIKey, cfg.AzureOpenAIAPIVersion,
+			cfg.AzureOpenAIDeployment, cfg.AzureOpenAIEmbeddingDeployment, maxTokens, float32(temperature))
+	} else {
+		openAIService = services.NewOpenAIService(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIEmbeddingModel, maxTokens, float32(temperature))
+	}
+	geminiService, err := services.NewGeminiService(cfg.GeminiAPIKey, cfg.GeminiModel, maxTokens, float32(temperature))
+	if err != nil {
+		log.Printf("[WARNING] Failed to initialize Gemini service: %v", err)
+	}
+	unifiedAIService := services.NewUnifiedAIService(openAIService, geminiService, services.AIProvider(cfg.PrimaryAIProvider))
+	var ollamaService *services.OllamaService
+	if cfg.OllamaBaseURL != "" {
+		ollamaService = services.NewOllamaService(cfg.OllamaBaseURL, cfg.OllamaAPIKey, cfg.OllamaModel, cfg.OllamaEmbeddingModel, maxTokens, float32(temperature))
+		unifiedAIService.SetOllamaService(ollamaService)
+	}
+
+	// Answers use the same system prompt as the chat
+	promptService := services.NewPromptService(database)
+	openAIService.SetPromptSource(promptService)
+	if geminiService != nil {
+		geminiService.SetPromptSource(promptService)
+	}
+	if ollamaService != nil {
+		ollamaService.SetPromptSource(promptService)
+	}
+
+	vectorStore, err := api.NewVectorStore(context.Background(), cfg, database)
+	if err != nil {
+		log.Fatal("Failed to initialize the vector database:", err)
+	}
+	knowledgeService := services.NewKnowledgeService(database, openAIService, vectorStore, nil)
+	embeddingService, err := api.NewEmbeddingService(cfg, unifiedAIService)
+	if err != nil {
+		log.Fatal("Failed to initialize embeddings:", err)
+	}
+	knowledgeService.SetEmbedder(embeddingService)
+	chunkMaxTokens, _ := strconv.Atoi(cfg.ChunkMaxTokens)
+	chunkOverlapTokens, _ := strconv.Atoi(cfg.ChunkOverlapTokens)
+	knowledgeService.SetChunkOptions(services.ChunkOptions{MaxTokens: chunkMaxTokens, OverlapTokens: chunkOverlapTokens})
+	services.NewRetrievalPresetService(database, knowledgeService)
+
+	evalService := services.NewRetrievalEvalService(database, knowledgeService, nil, 0)
+	evalService.SetAnswerEvaluation(unifiedAIService, false)
+	return evalService
+}
+
+// previousRun returns the latest completed run to compare with: one that evaluated answers when answers are
+// evaluated, so that faithfulness is comparable
+func previousRun(evalService *services.RetrievalEvalService, answers bool) *models.RetrievalEvalRun {
+	runs, err := evalService.ListRuns(50)
+	if err != nil {
+		log.Printf("[WARNING] Failed to load previous runs, not checking for regressions: %v", err)
+		return nil
+	}
+	for i := range runs {
+		if runs[i].Status == models.RetrievalEvalCompleted && runs[i].PairCount > 0 && (!answers || runs[i].AnswerCount > 0) {
+			return &runs[i]
+		}
+	}
+	return nil
+}
+
+// printDetails lists the pairs retrieval missed and the answers the judge found unfaithful
+func printDetails(evalService *services.RetrievalEvalService, run *models.RetrievalEvalRun) {
+	detailed, err := evalService.GetRun(run.ID)
+	if err != nil {
+		log.Printf("[WARNING] Failed to load the results of run %s: %v", run.ID, err)
+		return
+	}
+	fmt.Println()
+	for _, result := range detailed.Results {
+		if result.Rank == 0 || result.Rank > 5 {
+			fmt.Printf("missed (rank %d): %s\n  expected %s\n", result.Rank, result.Question, result.ExpectedEntryID)
+		}
+		if result.Faithfulness != nil && *result.Faithfulness < 0.5 {
+			fmt.Printf("unfaithful (%.2f): %s\n  %s\n", *result.Faithfulness, result.Question, result.JudgeNotes)
+		}
+	}
+}
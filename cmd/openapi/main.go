@@ -0,0 +1,71 @@
+// Command openapi generates the OpenAPI 3 description of the REST API from the swag annotations of the
+// handlers, along with the typed Go client of pkg/client. It fails when the description does not match the
+// routes the server registers, so that neither can drift from the router. Run it from the module root; with
+// -check it only reports whether the generated files are up to date, e.g. in CI.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"tic-knowledge-system/internal/api"
+	"tic-knowledge-system/internal/openapi"
+)
+
+// searchDirs hold the annotated handlers; the general API info is in main.go of the first
+var searchDirs = []string{"cmd/server", "internal/api", "internal/api/handlers"}
+
+func main() {
+	out := flag.String("out", "docs/openapi.json", "Where to write the OpenAPI document")
+	clientOut := flag.String("client", "pkg/client/client.gen.go", "Where to write the generated client code")
+	check := flag.Bool("check", false, "Do not write anything; fail when the generated files are out of date")
+	flag.Parse()
+
+	doc, err := openapi.Generate(".", searchDirs, "main.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var routes []openapi.Route
+	for _, route := range api.Routes() {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+	if err := openapi.CheckRoutes(doc, routes, "/swagger/*", "/openapi.json"); err != nil {
+		log.Fatal(err)
+	}
+
+	document, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	document = append(document, '\n')
+	client, err := openapi.GenerateClient(doc, "client")
+	if err != nil {
+		log.Fatal("Failed to generate the client: ", err)
+	}
+
+	outdated := false
+	for _, file := range []struct {
+		path    string
+		content []byte
+	}{{*out, document}, {*clientOut, client}} {
+		if *check {
+			current, err := os.ReadFile(file.path)
+			if err != nil || !bytes.Equal(current, file.content) {
+				fmt.Fprintf(os.Stderr, "%s is out of date; run make openapi\n", file.path)
+				outdated = true
+			}
+			continue
+		}
+		if err := os.WriteFile(file.path, file.content, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Wrote %s\n", file.path)
+	}
+	if outdated {
+		os.Exit(1)
+	}
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"tic-knowledge-system/internal/api"
 	"tic-knowledge-system/internal/config"
 	"tic-knowledge-system/internal/db"
+	"tic-knowledge-system/internal/services"
+	"time"
 )
 
 // @title Tic Knowledge Management API
@@ -25,15 +33,80 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Size the connection pool of the primary; read replicas get the same settings
+	maxOpenConns, _ := strconv.Atoi(cfg.DBMaxOpenConns)
+	maxIdleConns, _ := strconv.Atoi(cfg.DBMaxIdleConns)
+	connLifetimeMinutes, _ := strconv.Atoi(cfg.DBConnMaxLifetimeMinutes)
+	connIdleMinutes, _ := strconv.Atoi(cfg.DBConnMaxIdleTimeMinutes)
+	pool := db.PoolConfig{
+		MaxOpenConns:    maxOpenConns,
+		MaxIdleConns:    maxIdleConns,
+		ConnMaxLifetime: time.Duration(connLifetimeMinutes) * time.Minute,
+		ConnMaxIdleTime: time.Duration(connIdleMinutes) * time.Minute,
+	}
+	if err := db.ConfigurePool(database, pool); err != nil {
+		log.Fatal("Failed to configure database connection pool:", err)
+	}
+
 	// Run migrations
 	if err := db.RunMigrations(cfg.DatabaseURL); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	// Background checks run until shutdown
+	background, stopBackground := context.WithCancel(context.Background())
+
+	// Route heavy read endpoints to read replicas when enabled
+	var reads services.ReadReplicaRouter
+	if enabled, _ := strconv.ParseBool(cfg.ReadReplicaEnabled); enabled && cfg.ReadReplicaURLs != "" {
+		maxLagSeconds, _ := strconv.Atoi(cfg.ReadReplicaMaxLagSeconds)
+		checkSeconds, _ := strconv.Atoi(cfg.ReadReplicaCheckIntervalSeconds)
+		readRouter := db.NewReadRouter(database, strings.Split(cfg.ReadReplicaURLs, ","), time.Duration(maxLagSeconds)*time.Second, pool)
+		readRouter.StartHealthChecks(background, time.Duration(checkSeconds)*time.Second)
+		reads = readRouter
+	}
+
 	// Start server
-	server := api.NewServer(cfg, database)
-	log.Printf("Server starting on port %s", cfg.Port)
-	if err := server.Listen(":" + cfg.Port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := api.NewServer(cfg, database, reads)
+	serveErr := make(chan error, 2)
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		serveErr <- server.Listen(":" + cfg.Port)
+	}()
+	if cfg.GRPCPort != "" {
+		go func() {
+			log.Printf("gRPC server starting on port %s", cfg.GRPCPort)
+			serveErr <- server.ServeGRPC(":" + cfg.GRPCPort)
+		}()
+	}
+
+	// Stop on SIGINT/SIGTERM, or when a listener fails
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down", sig)
+	case err := <-serveErr:
+		log.Printf("[ERROR] Server stopped: %v", err)
+		exitCode = 1
+	}
+
+	timeoutSeconds, _ := strconv.Atoi(cfg.ShutdownTimeoutSeconds)
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("[ERROR] Graceful shutdown incomplete: %v", err)
+		exitCode = 1
+	}
+	cancel()
+	stopBackground()
+
+	if sqlDB, err := database.DB(); err == nil {
+		sqlDB.Close()
 	}
+	log.Printf("Server stopped")
+	os.Exit(exitCode)
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+)
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	out := flags.String("o", "", "File to write the bundle to (default: standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl export [flags]")
+		fmt.Fprintln(flags.Output(), "\nWrites the configuration bundle of the environment, like GET /api/v1/admin/config/export: retrieval")
+		fmt.Fprintln(flags.Output(), "presets, templates, prompts, retention policies, the org quota and help screens.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	b, err := connect(false)
+	if err != nil {
+		return err
+	}
+	bundle, err := b.configBundle.ExportConfig()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", *out)
+	return nil
+}
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	as := flags.String("as", "", "Email of the admin importing the bundle")
+	dryRun := flags.Bool("dry-run", false, "Only report what the import would change")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl import -as <admin email> [flags] <bundle.json>")
+		fmt.Fprintln(flags.Output(), "\nImports a configuration bundle written by export, creating and updating records by name.")
+		fmt.Fprintln(flags.Output(), "Nothing missing from the bundle is deleted.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected one bundle file")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var bundle services.ConfigBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	b, err := connect(false)
+	if err != nil {
+		return err
+	}
+	admin, err := b.user(ctx, *as)
+	if err != nil {
+		return err
+	}
+	report, err := b.configBundle.ImportConfig(ctx, &bundle, admin.ID, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, change := range report.Changes {
+		fmt.Printf("%s %s %s\n", change.Action, strings.ReplaceAll(change.Kind, "_", " "), change.Name)
+	}
+	fmt.Printf("%d changed, %d unchanged\n", len(report.Changes), report.Unchanged)
+	if report.DryRun {
+		fmt.Println("Dry run: nothing was changed")
+	}
+	return nil
+}
+
+func runUsers(args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, "Usage: ticctl users create [flags]")
+		return errors.New("unknown users command")
+	}
+	flags := flag.NewFlagSet("users create", flag.ExitOnError)
+	email := flags.String("email", "", "Email of the user")
+	name := flags.String("name", "", "Display name (default: the email)")
+	role := flags.String("role", string(models.RegularUser), "Role: user, support, editor or admin")
+	teams := flags.String("teams", "", "Comma-separated teams, which restrict the entries the user may see")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl users create -email <email> [flags]")
+		fmt.Fprintln(flags.Output(), "\nCreates an active account, e.g. for a service or before SSO is set up.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+
+	b, err := connect(false)
+	if err != nil {
+		return err
+	}
+	user, err := b.users.CreateUser(context.Background(), services.UserSpec{
+		Email: *email,
+		Name:  *name,
+		Role:  models.UserRole(*role),
+		Teams: strings.Split(*teams, ","),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created user %s (%s) with role %s\n", user.Email, user.ID, user.Role)
+	return nil
+}
+
+func runProvider(args []string) error {
+	if len(args) == 0 || args[0] != "set" {
+		fmt.Fprintln(os.Stderr, "Usage: ticctl provider set -as <admin email> <provider>")
+		return errors.New("unknown provider command")
+	}
+	flags := flag.NewFlagSet("provider set", flag.ExitOnError)
+	as := flags.String("as", "", "Email of the admin changing the setting")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl provider set -as <admin email> <provider>")
+		fmt.Fprintln(flags.Output(), "\nMakes a provider (openai, gemini or ollama) answer the chats, like the primary_provider runtime")
+		fmt.Fprintln(flags.Output(), "setting; running servers pick it up within 30 seconds. \"default\" restores PRIMARY_AI_PROVIDER.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected one provider")
+	}
+
+	ctx := context.Background()
+	b, err := connect(false)
+	if err != nil {
+		return err
+	}
+	admin, err := b.user(ctx, *as)
+	if err != nil {
+		return err
+	}
+	var value *string
+	if provider := flags.Arg(0); provider != "default" {
+		value = &provider
+	}
+	settings, err := b.settings.Update(ctx, admin.ID, map[string]*string{services.SettingPrimaryProvider: value})
+	if err != nil {
+		return err
+	}
+	for _, setting := range settings {
+		if setting.Key == services.SettingPrimaryProvider {
+			fmt.Printf("Primary provider: %s\n", setting.Value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"tic-knowledge-system/internal/api"
+	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/db"
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// backend is the service layer of the API server, wired from the same configuration, for the commands that
+// work on the database directly instead of through the API
+type backend struct {
+	db           *gorm.DB
+	unifiedAI    *services.UnifiedAIService
+	knowledge    *services.KnowledgeService
+	settings     *services.SettingsService
+	configBundle *services.ConfigBundleService
+	users        *services.UserService
+	cfg          *config.Config
+}
+
+// connect wires the services the way the API server does. Embeddings are generated inline unless queue is
+// set, in which case they are queued for the job workers of a running server.
+func connect(queue bool) (*backend, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	database, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	maxTokens, _ := strconv.Atoi(cfg.MaxTokens)
+	temperature, _ := strconv.ParseFloat(cfg.Temperature, 32)
+	var openAIService *services.OpenAIService
+	if cfg.AzureOpenAIEndpoint != "" {
+		openAIService = services.NewAzureOpenAIService(cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIAPIKey, cfg.AzureOpenAIAPIVersion,
+			cfg.AzureOpenAIDeployment, cfg.AzureOpenAIEmbeddingDeployment, maxTokens, float32(temperature))
+	} else {
+		openAIService = services.NewOpenAIService(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIEmbeddingModel, maxTokens, float32(temperature))
+	}
+	geminiService, err := services.NewGeminiService(cfg.GeminiAPIKey, cfg.GeminiModel, maxTokens, float32(temperature))
+	if err != nil {
+		log.Printf("[WARNING] Failed to initialize Gemini service: %v", err)
+	}
+	unifiedAIService := services.NewUnifiedAIService(openAIService, geminiService, services.AIProvider(cfg.PrimaryAIProvider))
+	var ollamaService *services.OllamaService
+	if cfg.OllamaBaseURL != "" {
+		ollamaService = services.NewOllamaService(cfg.OllamaBaseURL, cfg.OllamaAPIKey, cfg.OllamaModel, cfg.OllamaEmbeddingModel, maxTokens, float32(temperature))
+		unifiedAIService.SetOllamaService(ollamaService)
+	}
+
+	var jobQueue *services.JobQueue
+	if queue {
+		pollSeconds, _ := strconv.Atoi(cfg.JobPollIntervalSeconds)
+		jobQueue = services.NewJobQueue(database, time.Duration(pollSeconds)*time.Second)
+	}
+	vectorStore, err := api.NewVectorStore(context.Background(), cfg, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the vector database: %w", err)
+	}
+	knowledgeService := services.NewKnowledgeService(database, openAIService, vectorStore, jobQueue)
+	embeddingService, err := api.NewEmbeddingService(cfg, unifiedAIService)
+	if err != nil {
+		return nil, err
+	}
+	knowledgeService.SetEmbedder(embeddingService)
+	chunkMaxTokens, _ := strconv.Atoi(cfg.ChunkMaxTokens)
+	chunkOverlapTokens, _ := strconv.Atoi(cfg.ChunkOverlapTokens)
+	knowledgeService.SetChunkOptions(services.ChunkOptions{MaxTokens: chunkMaxTokens, OverlapTokens: chunkOverlapTokens})
+
+	// Settings changed by admins apply here as they do in the server
+	settingsService := services.NewSettingsService(database, unifiedAIService, map[string]string{
+		services.SettingPrimaryProvider:    cfg.PrimaryAIProvider,
+		services.SettingOpenAIModel:        cfg.OpenAIModel,
+		services.SettingGeminiModel:        cfg.GeminiModel,
+		services.SettingTemperature:        cfg.Temperature,
+		services.SettingMaxTokens:          cfg.MaxTokens,
+		services.SettingChunkMaxTokens:     cfg.ChunkMaxTokens,
+		services.SettingChunkOverlapTokens: cfg.ChunkOverlapTokens,
+		services.SettingImportSections:     cfg.ImportSections,
+	})
+	settingsService.Load(context.Background())
+	unifiedAIService.SetSettings(settingsService)
+	knowledgeService.SetSettings(settingsService)
+	presetService := services.NewRetrievalPresetService(database, knowledgeService)
+
+	return &backend{
+		db:           database,
+		unifiedAI:    unifiedAIService,
+		knowledge:    knowledgeService,
+		settings:     settingsService,
+		configBundle: services.NewConfigBundleService(database, presetService, services.NewPromptService(database)),
+		users:        services.NewUserService(database),
+		cfg:          cfg,
+	}, nil
+}
+
+// ingestion returns the document ingestion pipeline, storing documents where the server does
+func (b *backend) ingestion() (*services.IngestionService, error) {
+	fileStorage, err := api.NewFileStorage(b.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize file storage: %w", err)
+	}
+	ingestionService := services.NewIngestionService(b.db, b.cfg.OpenAIKey, "", fileStorage, nil)
+	ingestionService.SetKnowledgeBase(b.knowledge, b.unifiedAI)
+	ingestionService.SetSettings(b.settings)
+	spreadsheetChunkRows, _ := strconv.Atoi(b.cfg.SpreadsheetChunkRows)
+	ingestionService.SetRowsPerChunk(spreadsheetChunkRows)
+	if enrich, _ := strconv.ParseBool(b.cfg.ImportEnrichment); enrich {
+		ingestionService.SetMetadataEnricher(services.NewMetadataEnricher(b.db, b.unifiedAI))
+	}
+	return ingestionService, nil
+}
+
+// user returns the user a command acts as, given by email with -as
+func (b *backend) user(ctx context.Context, email string) (*models.User, error) {
+	if email == "" {
+		return nil, errors.New("-as is required: the email of the user to act as")
+	}
+	return b.users.FindByEmail(ctx, email)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// experimentRun is the part of an experiment run ticctl reports
+type experimentRun struct {
+	ID              string             `json:"id"`
+	PromptName      string             `json:"prompt_name"`
+	PromptVersion   int                `json:"prompt_version"`
+	Provider        string             `json:"provider"`
+	Model           string             `json:"model"`
+	BaselineRunID   *string            `json:"baseline_run_id"`
+	Threshold       float64            `json:"threshold"`
+	Status          string             `json:"status"`
+	QuestionCount   int                `json:"question_count"`
+	ComparedCount   int                `json:"compared_count"`
+	MeanSimilarity  float64            `json:"mean_similarity"`
+	MinSimilarity   float64            `json:"min_similarity"`
+	RegressionCount int                `json:"regression_count"`
+	Passed          bool               `json:"passed"`
+	Error           string             `json:"error"`
+	Answers         []experimentAnswer `json:"answers"`
+}
+
+type experimentAnswer struct {
+	Question       string   `json:"question"`
+	Answer         string   `json:"answer"`
+	BaselineAnswer string   `json:"baseline_answer"`
+	Similarity     *float64 `json:"similarity"`
+	Regressed      bool     `json:"regressed"`
+	Error          string   `json:"error"`
+}
+
+// apiEnvelope is the response envelope of the API
+type apiEnvelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   *struct {
+		Message string `json:"message"`
+		Details string `json:"details"`
+	} `json:"error"`
+}
+
+func runExperiment(args []string) error {
+	fs := flag.NewFlagSet("experiment", flag.ExitOnError)
+	baseURL := fs.String("base-url", "http://localhost:8080/api/v1", "Base URL of the API")
+	promptID := fs.String("prompt-id", "", "Prompt template version to try instead of the active prompts")
+	provider := fs.String("provider", "", "AI provider answering the questions (default: the primary provider)")
+	model := fs.String("model", "", "Model override for the provider")
+	baseline := fs.String("baseline", "", "Run to compare with (default: the golden run of the provider)")
+	threshold := fs.Float64("threshold", 0, "Similarity below which an answer regresses (default: the server's)")
+	promote := fs.Bool("promote", false, "Make the run the golden run of the provider when it passes")
+	verbose := fs.Bool("v", false, "Print every answer next to its golden answer, not only regressions")
+	wait := fs.Duration("timeout", 30*time.Minute, "How long to wait for the run to complete")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: ticctl experiment [flags]")
+		fmt.Fprintln(fs.Output(), "\nAnswers the golden questions with a prompt version and provider, compares the answers with")
+		fmt.Fprintln(fs.Output(), "the golden run and exits with status 1 when an answer regressed, so that prompt changes can be gated.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	api := strings.TrimRight(*baseURL, "/")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *wait)
+	defer cancel()
+
+	var started struct {
+		Run experimentRun `json:"run"`
+	}
+	err := apiCall(ctx, http.MethodPost, api+"/experiments/runs", map[string]interface{}{
+		"prompt_id":       *promptID,
+		"provider":        *provider,
+		"model":           *model,
+		"baseline_run_id": *baseline,
+		"threshold":       *threshold,
+	}, &started)
+	if err != nil {
+		return err
+	}
+	run := started.Run
+	fmt.Printf("Run %s queued: provider %s", run.ID, run.Provider)
+	if run.PromptName != "" {
+		fmt.Printf(", prompt %s version %d", run.PromptName, run.PromptVersion)
+	}
+	if run.BaselineRunID != nil {
+		fmt.Printf(", compared with run %s", *run.BaselineRunID)
+	} else {
+		fmt.Print(", no golden run to compare with")
+	}
+	fmt.Println()
+
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+	for run.Status != "completed" && run.Status != "failed" {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("run %s is still %s: %w", run.ID, run.Status, ctx.Err())
+		case <-ticker.C:
+		}
+		if err := apiCall(ctx, http.MethodGet, api+"/experiments/runs/"+run.ID, nil, &run); err != nil {
+			return err
+		}
+	}
+	if run.Status == "failed" {
+		return fmt.Errorf("run %s failed: %s", run.ID, run.Error)
+	}
+
+	printExperimentReport(&run, *verbose)
+	if !run.Passed {
+		return fmt.Errorf("%d of %d answers regressed", run.RegressionCount, run.ComparedCount)
+	}
+	if *promote {
+		if err := apiCall(ctx, http.MethodPost, api+"/experiments/runs/"+run.ID+"/golden", nil, nil); err != nil {
+			return err
+		}
+		fmt.Printf("\nRun %s is now the golden run of provider %s\n", run.ID, run.Provider)
+	}
+	return nil
+}
+
+// printExperimentReport prints the metrics of a completed run and its regressed answers
+func printExperimentReport(run *experimentRun, verbose bool) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "questions\t%d\n", run.QuestionCount)
+	fmt.Fprintf(w, "compared\t%d\n", run.ComparedCount)
+	fmt.Fprintf(w, "mean similarity\t%.3f\n", run.MeanSimilarity)
+	fmt.Fprintf(w, "min similarity\t%.3f\n", run.MinSimilarity)
+	fmt.Fprintf(w, "threshold\t%.3f\n", run.Threshold)
+	fmt.Fprintf(w, "regressions\t%d\n", run.RegressionCount)
+	w.Flush()
+
+	for _, answer := range run.Answers {
+		if !answer.Regressed && !verbose {
+			continue
+		}
+		similarity := "-"
+		if answer.Similarity != nil {
+			similarity = fmt.Sprintf("%.3f", *answer.Similarity)
+		}
+		label := "ok"
+		if answer.Regressed {
+			label = "REGRESSED"
+		}
+		fmt.Printf("\n%s (%s): %s\n", label, similarity, answer.Question)
+		if answer.Error != "" {
+			fmt.Printf("  error:  %s\n", answer.Error)
+		}
+		fmt.Printf("  golden: %s\n  new:    %s\n", oneLine(answer.BaselineAnswer), oneLine(answer.Answer))
+	}
+}
+
+// oneLine collapses an answer onto a single line for the report
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// apiCall sends a JSON request and decodes the data of the response envelope into out
+func apiCall(ctx context.Context, method, url string, body interface{}, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope apiEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("%s %s: %s: %w", method, url, resp.Status, err)
+	}
+	if !envelope.Success {
+		if envelope.Error != nil {
+			return fmt.Errorf("%s %s: %s", method, url, envelope.Error.Message)
+		}
+		return errors.New(method + " " + url + ": " + resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+)
+
+// ingestResult is the outcome of ingesting one file
+type ingestResult struct {
+	file        string
+	entries     int
+	document    string
+	diff        *models.SectionDiff // Of a new version of an ingested file
+	skipped     bool                // Already ingested
+	quarantined bool
+	err         error
+}
+
+func runIngest(args []string) error {
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	category := flags.String("category", "", "Category of the created entries (default: the server's import category)")
+	as := flags.String("as", "", "Email of the user the entries are created by")
+	workers := flags.Int("workers", 4, "Number of files ingested at the same time")
+	force := flags.Bool("force", false, "Also ingest files whose content was already ingested")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl ingest -as <email> [flags] <file, directory or glob>...")
+		fmt.Fprintln(flags.Output(), "\nImports documents into the knowledge base as published entries, one per section, like an upload with")
+		fmt.Fprintln(flags.Output(), "target knowledge_base. Directories are walked for the file types the server can extract, and files")
+		fmt.Fprintln(flags.Output(), "whose content was already ingested are skipped, so an interrupted run can simply be restarted.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no file, directory or glob given")
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	files, err := ingestFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no supported files found")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	b, err := connect(false)
+	if err != nil {
+		return err
+	}
+	user, err := b.user(ctx, *as)
+	if err != nil {
+		return err
+	}
+	ingestion, err := b.ingestion()
+	if err != nil {
+		return err
+	}
+
+	// Files with the same content in this run are ingested once, by whichever worker gets there first
+	var mu sync.Mutex
+	claimed := map[string]bool{}
+	ingestFile := func(file string) ingestResult {
+		result := ingestResult{file: file}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		checksum := services.ContentChecksum(content)
+		if !*force {
+			mu.Lock()
+			duplicate := claimed[checksum]
+			claimed[checksum] = true
+			mu.Unlock()
+			if duplicate {
+				result.skipped = true
+				return result
+			}
+			existing, err := ingestion.IngestedDocument(ctx, checksum, models.IngestKnowledgeBase)
+			if err != nil {
+				result.err = err
+				return result
+			}
+			if existing != nil {
+				result.skipped, result.document = true, existing.ID.String()
+				return result
+			}
+		}
+
+		document, parsed, err := ingestion.ImportFile(ctx, file, *category, user.ID, false)
+		switch {
+		case err != nil:
+			result.err = err
+		case parsed == nil:
+			result.quarantined, result.document = true, document.ID.String()
+		default:
+			result.entries, result.document = len(parsed.KnowledgeIDs), document.ID.String()
+			if document.SupersedesID != nil {
+				result.diff = &document.Sections
+			}
+		}
+		return result
+	}
+
+	jobs := make(chan string)
+	results := make(chan ingestResult)
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				results <- ingestFile(file)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, file := range files {
+			select {
+			case jobs <- file:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var imported, skipped, quarantined, entries int
+	var failures []ingestResult
+	done := 0
+	for result := range results {
+		done++
+		progress := fmt.Sprintf("[%d/%d]", done, len(files))
+		switch {
+		case result.err != nil:
+			fmt.Printf("%s FAILED %s: %v\n", progress, result.file, result.err)
+			failures = append(failures, result)
+		case result.skipped:
+			fmt.Printf("%s skipped %s: already ingested\n", progress, result.file)
+			skipped++
+		case result.quarantined:
+			fmt.Printf("%s QUARANTINED %s: document %s waits for an admin to review it\n", progress, result.file, result.document)
+			quarantined++
+		case result.diff != nil:
+			fmt.Printf("%s %s: new version, %d added, %d changed, %d unchanged, %d removed\n", progress, result.file,
+				result.diff.Added, result.diff.Changed, result.diff.Unchanged, result.diff.Removed)
+			imported++
+			entries += result.entries
+		default:
+			fmt.Printf("%s %s: %d entries\n", progress, result.file, result.entries)
+			imported++
+			entries += result.entries
+		}
+	}
+
+	fmt.Printf("\n%d imported (%d entries), %d skipped, %d quarantined, %d failed", imported, entries, skipped, quarantined, len(failures))
+	if missed := len(files) - done; missed > 0 {
+		fmt.Printf(", %d not started", missed)
+	}
+	fmt.Println()
+	if len(failures) > 0 {
+		fmt.Println("\nFailures:")
+		for _, failure := range failures {
+			fmt.Printf("  %s: %v\n", failure.file, failure.err)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d files failed", len(failures), len(files))
+	}
+	return nil
+}
+
+// ingestFiles expands the globs and directories among paths into files. Directories are walked for the files the
+// server can extract text from; files named explicitly are ingested whatever their type.
+func ingestFiles(paths []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, pattern := range paths {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("invalid glob %s: %w", pattern, err)
+			}
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(path)
+				continue
+			}
+			err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if entry.IsDir() && file != path && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if !entry.IsDir() && services.CanExtract(filepath.Ext(file)) {
+					add(file)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+func runSearch(args []string) error {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	category := flags.String("category", "", "Only entries of this category")
+	tag := flags.String("tag", "", "Only entries with this tag")
+	limit := flags.Int("limit", 10, "Number of hits to list")
+	as := flags.String("as", "", "Only entries this user (by email) may see (default: every entry)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl search [flags] <query>")
+		fmt.Fprintln(flags.Output(), "\nFull-text search of the published entries, with the syntax of GET /api/v1/knowledge/search:")
+		fmt.Fprintln(flags.Output(), "quoted phrases, OR, and -excluded terms.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no query given")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	b, err := connect(false)
+	if err != nil {
+		return err
+	}
+	scope := services.RetrievalScope{Role: models.AdminRole}
+	if *as != "" {
+		user, err := b.user(ctx, *as)
+		if err != nil {
+			return err
+		}
+		scope = b.knowledge.ScopeForUser(user.ID)
+	}
+
+	result, err := b.knowledge.FullTextSearch(ctx, services.FullTextQuery{
+		Query:    strings.Join(flags.Args(), " "),
+		Category: *category,
+		Tag:      *tag,
+		Scope:    scope,
+		Limit:    *limit,
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Hits) == 0 {
+		fmt.Println("No entries found")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "rank\tid\tcategory\ttitle")
+	for _, hit := range result.Hits {
+		fmt.Fprintf(w, "%.3f\t%s\t%s\t%s\n", hit.Rank, hit.Entry.ID, hit.Entry.Category, hit.Entry.Title)
+	}
+	w.Flush()
+	fmt.Printf("\n%d of %d entries\n", len(result.Hits), result.Total)
+	return nil
+}
+
+func runReindex(args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	categories := flags.String("categories", "", "Comma-separated categories to re-embed (default: every published entry)")
+	queue := flags.Bool("queue", false, "Queue the embeddings for the job workers of the server instead of generating them here")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ticctl reindex [flags]")
+		fmt.Fprintln(flags.Output(), "\nRegenerates the embeddings of the published entries, e.g. after changing the embedding model")
+		fmt.Fprintln(flags.Output(), "or the chunk settings, and replaces them in the vector index.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	b, err := connect(*queue)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if *categories == "" {
+		count, err = b.knowledge.ReembedAllEntries(ctx)
+	} else {
+		var names []string
+		for _, name := range strings.Split(*categories, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		count, err = b.knowledge.ReembedEntries(ctx, names, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if *queue {
+		fmt.Printf("Queued the embeddings of %d entries\n", count)
+	} else {
+		fmt.Printf("Re-embedded %d entries\n", count)
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Load test operations
+const (
+	opChat   = "chat"
+	opSearch = "search"
+	opUpload = "upload"
+)
+
+// syntheticQuestions are realistic support questions used for chat and search traffic
+var syntheticQuestions = []string{
+	"How do I reset a customer's password?",
+	"Why do I get error E1023 when confirming an order?",
+	"Which role is required to approve refunds?",
+	"Where is the screen for processing returned orders?",
+	"How can I export the daily order report to Excel?",
+	"What does the status 'pending review' mean on an order?",
+	"How do I assign a task to another team member?",
+	"The payment page shows a timeout, what should I do?",
+	"Làm thế nào để hủy đơn hàng đã xác nhận?",
+	"Tôi không có quyền truy cập màn hình báo cáo, phải làm sao?",
+	"Quy trình xử lý khiếu nại của khách hàng là gì?",
+	"Cách cập nhật địa chỉ giao hàng cho đơn hàng?",
+}
+
+// syntheticSearchTerms are short keyword queries used for search traffic
+var syntheticSearchTerms = []string{
+	"refund", "password", "order status", "export report", "permission", "timeout",
+	"đơn hàng", "báo cáo", "khiếu nại", "giao hàng",
+}
+
+// loadTestConfig holds the parsed loadtest flags
+type loadTestConfig struct {
+	baseURL     string
+	rps         float64
+	duration    time.Duration
+	mix         map[string]int
+	userID      string
+	concurrency int
+	timeout     time.Duration
+}
+
+// loadTestResult is the outcome of a single request
+type loadTestResult struct {
+	op       string
+	latency  time.Duration
+	status   int
+	err      error
+	rejected bool // Dropped because all workers were busy
+}
+
+func runLoadTest(args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	baseURL := fs.String("base-url", "http://localhost:8080/api/v1", "Base URL of the API under test")
+	rps := fs.Float64("rps", 5, "Target requests per second")
+	duration := fs.Duration("duration", 30*time.Second, "How long to generate traffic")
+	mix := fs.String("mix", "chat=70,search=25,upload=5", "Traffic mix as op=weight pairs (ops: chat, search, upload)")
+	userID := fs.String("user-id", "4566215d-9957-4765-9ac5-a9395879945e", "User ID sent with chat requests")
+	concurrency := fs.Int("concurrency", 50, "Maximum number of in-flight requests")
+	timeout := fs.Duration("timeout", 60*time.Second, "Per-request timeout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: ticctl loadtest [flags]")
+		fmt.Fprintln(fs.Output(), "\nGenerates synthetic traffic against an environment. Uploads create real documents,")
+		fmt.Fprintln(fs.Output(), "and chat requests call the configured AI providers, so do not run it against production.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	weights, err := parseMix(*mix)
+	if err != nil {
+		return err
+	}
+	if *rps <= 0 {
+		return fmt.Errorf("rps must be positive")
+	}
+	if *concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive")
+	}
+
+	cfg := loadTestConfig{
+		baseURL:     strings.TrimRight(*baseURL, "/"),
+		rps:         *rps,
+		duration:    *duration,
+		mix:         weights,
+		userID:      *userID,
+		concurrency: *concurrency,
+		timeout:     *timeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Load testing %s at %.1f rps for %s (mix: %s)\n", cfg.baseURL, cfg.rps, cfg.duration, *mix)
+	started := time.Now()
+	results := generateTraffic(ctx, cfg)
+	printLoadTestReport(results, time.Since(started))
+	return nil
+}
+
+// parseMix parses "chat=70,search=25,upload=5" into weights
+func parseMix(mix string) (map[string]int, error) {
+	weights := make(map[string]int)
+	total := 0
+	for _, part := range strings.Split(mix, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		op, weightStr, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		op = strings.TrimSpace(op)
+		if op != opChat && op != opSearch && op != opUpload {
+			return nil, fmt.Errorf("unknown operation %q in mix", op)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %q", op, weightStr)
+		}
+		weights[op] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("mix must contain at least one operation with a positive weight")
+	}
+	return weights, nil
+}
+
+// pickOperation chooses an operation according to the weights
+func pickOperation(rng *rand.Rand, weights map[string]int) string {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	n := rng.Intn(total)
+	for _, op := range []string{opChat, opSearch, opUpload} {
+		if n < weights[op] {
+			return op
+		}
+		n -= weights[op]
+	}
+	return opChat
+}
+
+// generateTraffic issues requests at a fixed rate (open model) until the duration elapses
+func generateTraffic(ctx context.Context, cfg loadTestConfig) []loadTestResult {
+	ctx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	client := &http.Client{Timeout: cfg.timeout}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.rps))
+	defer ticker.Stop()
+
+	var (
+		mu      sync.Mutex
+		results []loadTestResult
+		wg      sync.WaitGroup
+	)
+	slots := make(chan struct{}, cfg.concurrency)
+	record := func(result loadTestResult) {
+		mu.Lock()
+		results = append(results, result)
+		mu.Unlock()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return results
+		case <-ticker.C:
+		}
+
+		op := pickOperation(rng, cfg.mix)
+		seed := rng.Int63()
+		select {
+		case slots <- struct{}{}:
+		default:
+			// Keep the arrival rate constant; a saturated target shows up as rejected requests
+			record(loadTestResult{op: op, rejected: true})
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			record(runOperation(client, cfg, op, rand.New(rand.NewSource(seed))))
+		}()
+	}
+}
+
+// runOperation performs a single synthetic request and measures its latency
+func runOperation(client *http.Client, cfg loadTestConfig, op string, rng *rand.Rand) loadTestResult {
+	req, err := buildRequest(cfg, op, rng)
+	if err != nil {
+		return loadTestResult{op: op, err: err}
+	}
+
+	started := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return loadTestResult{op: op, latency: time.Since(started), err: err}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	result := loadTestResult{op: op, latency: time.Since(started), status: resp.StatusCode}
+	if resp.StatusCode >= 400 {
+		result.err = fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return result
+}
+
+// buildRequest creates the HTTP request for an operation
+func buildRequest(cfg loadTestConfig, op string, rng *rand.Rand) (*http.Request, error) {
+	switch op {
+	case opChat:
+		body, _ := json.Marshal(map[string]interface{}{
+			"message": syntheticQuestions[rng.Intn(len(syntheticQuestions))],
+			"user_id": cfg.userID,
+		})
+		req, err := http.NewRequest(http.MethodPost, cfg.baseURL+"/ai/chat", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+
+	case opSearch:
+		query := syntheticSearchTerms[rng.Intn(len(syntheticSearchTerms))]
+		return http.NewRequest(http.MethodGet, cfg.baseURL+"/knowledge/search?q="+url.QueryEscape(query)+"&limit=5", nil)
+
+	case opUpload:
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		fileName := fmt.Sprintf("loadtest-%d.txt", rng.Int63())
+		writer.WriteField("file_name", fileName)
+		part, err := writer.CreateFormFile("file", fileName)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(part, "Q: %s\nA: Synthetic answer generated by ticctl loadtest.\n\n", syntheticQuestions[rng.Intn(len(syntheticQuestions))])
+		}
+		writer.Close()
+
+		req, err := http.NewRequest(http.MethodPost, cfg.baseURL+"/documents/upload", &body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req, nil
+	}
+	return nil, fmt.Errorf("unknown operation %s", op)
+}
+
+// printLoadTestReport prints counts, error rates and latency percentiles per operation
+func printLoadTestReport(results []loadTestResult, elapsed time.Duration) {
+	byOp := make(map[string][]loadTestResult)
+	for _, result := range results {
+		byOp[result.op] = append(byOp[result.op], result)
+		byOp["total"] = append(byOp["total"], result)
+	}
+
+	fmt.Printf("\nCompleted %d requests in %s (%.2f rps achieved)\n\n", len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "op\trequests\terrors\trejected\tp50\tp90\tp95\tp99\tmax\t")
+	for _, op := range []string{opChat, opSearch, opUpload, "total"} {
+		opResults := byOp[op]
+		if len(opResults) == 0 {
+			continue
+		}
+
+		var latencies []time.Duration
+		errors, rejected := 0, 0
+		for _, result := range opResults {
+			switch {
+			case result.rejected:
+				rejected++
+			case result.err != nil:
+				errors++
+			}
+			if !result.rejected && result.err == nil {
+				latencies = append(latencies, result.latency)
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n", op, len(opResults), errors, rejected,
+			percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 95), percentile(latencies, 99),
+			percentile(latencies, 100))
+	}
+	w.Flush()
+
+	printErrorSummary(results)
+}
+
+// printErrorSummary prints the most frequent errors
+func printErrorSummary(results []loadTestResult) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if result.err != nil {
+			counts[result.op+": "+result.err.Error()]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	type errorCount struct {
+		message string
+		count   int
+	}
+	var sorted []errorCount
+	for message, count := range counts {
+		sorted = append(sorted, errorCount{message, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].count > sorted[j].count })
+	if len(sorted) > 10 {
+		sorted = sorted[:10]
+	}
+
+	fmt.Println("\nTop errors:")
+	for _, entry := range sorted {
+		fmt.Printf("  %5d  %s\n", entry.count, entry.message)
+	}
+}
+
+// percentile returns the p-th percentile of sorted latencies using the nearest-rank method
+func percentile(sorted []time.Duration, p int) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].Round(time.Millisecond).String()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a ticctl subcommand
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{name: "loadtest", description: "Generate synthetic chat/search/upload traffic and report latency percentiles", run: runLoadTest},
+	{name: "experiment", description: "Answer the golden questions with a prompt version and fail when answers regressed", run: runExperiment},
+	{name: "ingest", description: "Import files or directories into the knowledge base", run: runIngest},
+	{name: "search", description: "Full-text search the published knowledge entries", run: runSearch},
+	{name: "reindex", description: "Regenerate the embeddings of the published entries", run: runReindex},
+	{name: "export", description: "Write the configuration bundle of the environment", run: runExport},
+	{name: "import", description: "Import a configuration bundle", run: runImport},
+	{name: "users", description: "Create users (users create)", run: runUsers},
+	{name: "provider", description: "Change the primary AI provider (provider set)", run: runProvider},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		printUsage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "ticctl %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "ticctl: unknown command %q\n\n", os.Args[1])
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Println("Usage: ticctl <command> [flags]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-12s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Println()
+	fmt.Println("loadtest and experiment call the API; the other commands use the database and services directly,")
+	fmt.Println("configured like the server by .env and the environment.")
+	fmt.Println("Run 'ticctl <command> -h' for the flags of a command.")
+}
@@ -0,0 +1,10 @@
+// Package docs embeds the OpenAPI 3 description of the REST API, generated by `make openapi` (cmd/openapi)
+// and served at /openapi.json.
+package docs
+
+import _ "embed"
+
+// OpenAPI is the API description as JSON
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/api/handlers"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const authLocal = handlers.AuthLocal
+
+// authentication identifies the user of requests carrying a session token, as a bearer token or the session
+// cookie set by SSO login. Requests without a token pass through unauthenticated; invalid tokens are rejected.
+// With sessionsRequired, admin endpoints reject requests without a session.
+func authentication(tokens *services.AuthTokenService, sessionsRequired bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if sessionsRequired {
+			c.Locals(handlers.SessionRequiredLocal, true)
+		}
+		token := c.Cookies(handlers.SessionCookieName)
+		if header := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(header, "Bearer ") {
+			token = strings.TrimPrefix(header, "Bearer ")
+		}
+		if token == "" {
+			return c.Next()
+		}
+
+		claims, err := tokens.Verify(token)
+		if errors.Is(err, services.ErrAuthTokenInvalid) {
+			log.Printf("[WARNING] Rejected session token on %s %s from %s", c.Method(), c.Path(), c.IP())
+			return utils.SendError(c, fiber.StatusUnauthorized, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+
+		c.Locals(authLocal, claims)
+		return c.Next()
+	}
+}
+
+// authenticatedUserID returns the signed-in user of this request
+func authenticatedUserID(c *fiber.Ctx) (uuid.UUID, bool) {
+	claims, ok := c.Locals(authLocal).(*services.AuthClaims)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return claims.Subject, true
+}
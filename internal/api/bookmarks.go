@@ -0,0 +1,75 @@
+package api
+
+import (
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// @Summary List bookmarks
+// @Description Answers and knowledge entries the current user saved, most recent first. Bookmarked entries rank higher in the user's searches.
+// @Tags bookmarks
+// @Produce json
+// @Param type query string false "messages or entries"
+// @Success 200 {object} utils.APIResponse{data=[]models.Bookmark}
+// @Failure 400 {object} utils.APIResponse
+// @Router /me/bookmarks [get]
+func (s *Server) listBookmarks(c *fiber.Ctx) error {
+	bookmarks, err := s.bookmarkService.ListBookmarks(c.UserContext(), bookmarkUserID(c), c.Query("type"))
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, bookmarks)
+}
+
+// @Summary Bookmark an answer or entry
+// @Description Save a message of one of the user's chat sessions or a knowledge entry. Saving it again updates the note.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param request body services.BookmarkRequest true "Message or entry to save"
+// @Success 201 {object} utils.APIResponse{data=models.Bookmark}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /me/bookmarks [post]
+func (s *Server) createBookmark(c *fiber.Ctx) error {
+	var req services.BookmarkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, 400, "Invalid request body")
+	}
+
+	bookmark, err := s.bookmarkService.CreateBookmark(c.UserContext(), bookmarkUserID(c), req)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, 201, utils.SuccessResponse(bookmark))
+}
+
+// @Summary Delete a bookmark
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 204
+// @Failure 404 {object} utils.APIResponse
+// @Router /me/bookmarks/{id} [delete]
+func (s *Server) deleteBookmark(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid bookmark ID")
+	}
+
+	if err := s.bookmarkService.DeleteBookmark(c.UserContext(), bookmarkUserID(c), id); err != nil {
+		return err
+	}
+	return c.SendStatus(204)
+}
+
+// bookmarkUserID is the user whose bookmarks a request manages; admins impersonating a user see theirs
+func bookmarkUserID(c *fiber.Ctx) uuid.UUID {
+	userID := chatUserID(c)
+	if impersonated, ok := impersonatedUserID(c); ok {
+		userID = impersonated
+	}
+	return userID
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"tic-knowledge-system/internal/models"
 	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,24 +16,28 @@ import (
 // @Accept json
 // @Produce json
 // @Param request body services.ChatRequest true "Chat request"
-// @Success 200 {object} services.ChatResponse
+// @Param lang query string false "Language of the answer (ISO 639-1 code or tag); overrides the request body"
+// @Success 200 {object} utils.APIResponse{data=services.ChatResponse}
 // @Router /chat [post]
 func (s *Server) processChat(c *fiber.Ctx) error {
 	var req services.ChatRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
-	// TODO: Get user ID from JWT token  
-	// Using existing user from database for demo purposes
+	// Anonymous requests use the demo user
 	req.UserID = uuid.MustParse("4566215d-9957-4765-9ac5-a9395879945e")
+	if authenticated, ok := authenticatedUserID(c); ok {
+		req.UserID = authenticated
+	}
+	req.Language = c.Query("lang", req.Language)
 
 	response, err := s.chatService.ProcessChat(c.Context(), req)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to process chat"})
+		return err
 	}
 
-	return c.JSON(response)
+	return utils.SendSuccess(c, response)
 }
 
 // @Summary Get chat sessions
@@ -40,19 +45,24 @@ func (s *Server) processChat(c *fiber.Ctx) error {
 // @Tags chat
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.ChatSession
+// @Success 200 {object} utils.APIResponse{data=[]models.ChatSession}
 // @Router /chat/sessions [get]
 func (s *Server) getChatSessions(c *fiber.Ctx) error {
-	// TODO: Get user ID from JWT token
-	// Using existing user from database for demo purposes
+	// Anonymous requests use the demo user
 	userID := uuid.MustParse("4566215d-9957-4765-9ac5-a9395879945e")
+	if authenticated, ok := authenticatedUserID(c); ok {
+		userID = authenticated
+	}
+	if impersonated, ok := impersonatedUserID(c); ok {
+		userID = impersonated
+	}
 
 	sessions, err := s.chatService.GetChatSessions(userID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch chat sessions"})
+		return utils.SendError(c, 500, "Failed to fetch chat sessions")
 	}
 
-	return c.JSON(sessions)
+	return utils.SendSuccess(c, sessions)
 }
 
 // @Summary Get chat session
@@ -61,49 +71,327 @@ func (s *Server) getChatSessions(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Session ID"
-// @Success 200 {object} models.ChatSession
+// @Success 200 {object} utils.APIResponse{data=models.ChatSession}
 // @Router /chat/sessions/{id} [get]
 func (s *Server) getChatSession(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	sessionID, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid session ID"})
+		return utils.SendError(c, 400, "Invalid session ID")
 	}
 
-	// TODO: Get user ID from JWT token
-	userID := uuid.New() // Placeholder
+	userID := uuid.New() // Anonymous requests see no session
+	if authenticated, ok := authenticatedUserID(c); ok {
+		userID = authenticated
+	}
+	if impersonated, ok := impersonatedUserID(c); ok {
+		userID = impersonated
+	}
 
 	session, err := s.chatService.GetChatSession(sessionID, userID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Chat session not found"})
+		return err
 	}
 
-	return c.JSON(session)
+	return utils.SendSuccess(c, session)
+}
+
+// @Summary Get chat session usage
+// @Description Summarize tokens, estimated cost, providers used, and retrieval counts for a chat session
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} utils.APIResponse{data=services.SessionUsage}
+// @Router /chat/sessions/{id}/usage [get]
+func (s *Server) getChatSessionUsage(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	sessionID, err := uuid.Parse(idStr)
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid session ID")
+	}
+
+	usage, err := s.chatService.GetSessionUsage(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return utils.SendSuccess(c, usage)
+}
+
+// @Summary Export chat session
+// @Description Download the full conversation with timestamps, sources, and feedback
+// @Tags chat
+// @Produce json,text/markdown,application/pdf
+// @Param id path string true "Session ID"
+// @Param format query string false "Export format: json, markdown, or pdf" default(json)
+// @Success 200 {object} utils.APIResponse{data=services.SessionTranscript}
+// @Router /chat/sessions/{id}/export [get]
+func (s *Server) exportChatSession(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	sessionID, err := uuid.Parse(idStr)
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid session ID")
+	}
+
+	format := c.Query("format", services.ExportFormatJSON)
+	if format != services.ExportFormatJSON && format != services.ExportFormatMarkdown && format != services.ExportFormatPDF {
+		return utils.SendError(c, 400, "Invalid format, expected json, markdown, or pdf")
+	}
+
+	transcript, err := s.chatService.ExportSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	filename := "session-" + sessionID.String()
+	switch format {
+	case services.ExportFormatMarkdown:
+		c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+		c.Attachment(filename + ".md")
+		return c.SendString(services.RenderTranscriptMarkdown(transcript))
+	case services.ExportFormatPDF:
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Attachment(filename + ".pdf")
+		return c.Send(services.RenderTranscriptPDF(transcript))
+	default:
+		c.Attachment(filename + ".json")
+		return utils.SendSuccess(c, transcript)
+	}
 }
 
 // @Summary Delete chat session
-// @Description Delete a chat session
+// @Description Delete a chat session with its messages. The session can be restored until undo_until;
+// @Description after that it stays in the admin trash until purged.
 // @Tags chat
 // @Accept json
 // @Produce json
 // @Param id path string true "Session ID"
-// @Success 204
+// @Success 200 {object} utils.APIResponse{data=services.DeletedChatSession}
+// @Failure 404 {object} utils.APIResponse
 // @Router /chat/sessions/{id} [delete]
 func (s *Server) deleteChatSession(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	sessionID, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid session ID"})
+		return utils.SendError(c, 400, "Invalid session ID")
+	}
+
+	userID := uuid.New() // Anonymous requests delete no session
+	if authenticated, ok := authenticatedUserID(c); ok {
+		userID = authenticated
+	}
+
+	deleted, err := s.chatService.DeleteChatSession(c.UserContext(), sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.SendSuccess(c, deleted)
+}
+
+// @Summary Restore deleted chat session
+// @Description Undo the deletion of a chat session within 10 minutes, bringing back its messages
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} utils.APIResponse{data=models.ChatSession}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /chat/sessions/{id}/restore [post]
+func (s *Server) restoreChatSession(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid session ID")
+	}
+
+	userID := uuid.New() // Anonymous requests restore no session
+	if authenticated, ok := authenticatedUserID(c); ok {
+		userID = authenticated
+	}
+
+	session, err := s.chatService.RestoreChatSession(c.UserContext(), sessionID, userID)
+	if err != nil {
+		return err
 	}
 
-	// TODO: Get user ID from JWT token
-	userID := uuid.New() // Placeholder
+	return utils.SendSuccess(c, session)
+}
+
+// SessionPersonaRequest picks the persona preset of a chat session; a null persona_id removes it
+type SessionPersonaRequest struct {
+	PersonaID *uuid.UUID `json:"persona_id" example:"0b6f1a52-3c1e-4b8e-9f7e-2d1f0c9a8b7d"`
+}
 
-	if err := s.chatService.DeleteChatSession(sessionID, userID); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete chat session"})
+// @Summary Set chat session persona
+// @Description Every later answer of the session is given as the persona, e.g. "answer as a warehouse supervisor".
+// @Description A system_prompt sent with a message takes precedence for that message.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Param request body SessionPersonaRequest true "Persona preset from GET /chat/personas"
+// @Success 200 {object} utils.APIResponse{data=models.ChatSession}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /chat/sessions/{id}/persona [put]
+func (s *Server) setChatSessionPersona(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid session ID")
+	}
+	var req SessionPersonaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
-	return c.SendStatus(204)
+	session, err := s.personaService.SetSessionPersona(c.UserContext(), chatUserID(c), sessionID, req.PersonaID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, session)
+}
+
+// EditMessageRequest replaces the last question of a session
+type EditMessageRequest struct {
+	Content  string              `json:"content" example:"How do I reset my VPN password?"`
+	Provider services.AIProvider `json:"provider,omitempty" example:"gemini"` // Provider of the new answer; empty uses the primary one
+}
+
+// RegenerateAnswerRequest asks for a new answer to the last question of a session
+type RegenerateAnswerRequest struct {
+	Provider services.AIProvider `json:"provider,omitempty" example:"openai"` // Provider of the new answer; empty uses the primary one
+}
+
+// @Summary Edit chat question
+// @Description Replace the last question of a session and answer it again. The previous question and answer are kept
+// @Description as revisions, listed by GET /chat/messages/{id}/versions.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Message ID of the question"
+// @Param request body EditMessageRequest true "New question"
+// @Success 200 {object} utils.APIResponse{data=services.EnhancedChatResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /chat/messages/{id} [patch]
+func (s *Server) editChatMessage(c *fiber.Ctx) error {
+	messageID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid message ID")
+	}
+	var req EditMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, 400, "Invalid request body")
+	}
+
+	response, err := s.enhancedChatService.EditMessage(c.UserContext(), chatUserID(c), messageID, req.Content, req.Provider)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, response)
+}
+
+// @Summary Regenerate chat answer
+// @Description Answer the last question of a session again, optionally with another provider. The previous answer is
+// @Description kept as a revision of the message, listed by GET /chat/messages/{id}/versions.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Message ID of the answer"
+// @Param request body RegenerateAnswerRequest false "Provider"
+// @Success 200 {object} utils.APIResponse{data=services.EnhancedChatResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /chat/messages/{id}/regenerate [post]
+func (s *Server) regenerateChatAnswer(c *fiber.Ctx) error {
+	messageID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid message ID")
+	}
+	var req RegenerateAnswerRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.SendError(c, 400, "Invalid request body")
+		}
+	}
+
+	response, err := s.enhancedChatService.RegenerateAnswer(c.UserContext(), chatUserID(c), messageID, req.Provider)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, response)
+}
+
+// @Summary List chat message versions
+// @Description Superseded versions of an edited question or regenerated answer, oldest first
+// @Tags chat
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.ChatMessageRevision}
+// @Failure 404 {object} utils.APIResponse
+// @Router /chat/messages/{id}/versions [get]
+func (s *Server) getChatMessageVersions(c *fiber.Ctx) error {
+	messageID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid message ID")
+	}
+
+	revisions, err := s.enhancedChatService.MessageRevisions(c.UserContext(), chatUserID(c), messageID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, revisions)
+}
+
+// chatUserID is the user chatting: the authenticated user, else the demo user anonymous chats use
+func chatUserID(c *fiber.Ctx) uuid.UUID {
+	if authenticated, ok := authenticatedUserID(c); ok {
+		return authenticated
+	}
+	return uuid.MustParse("4566215d-9957-4765-9ac5-a9395879945e")
+}
+
+// EscalateChatRequest asks for a support agent to take over a chat session
+type EscalateChatRequest struct {
+	Reason string `json:"reason,omitempty" example:"The answer did not solve my problem"`
+}
+
+// @Summary Escalate chat session
+// @Description Ask for a support agent to take over the session, typically after an answer with suggest_escalation.
+// @Description Until the agent hands the session back, chat messages are answered by the agent, whose messages have role "support".
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Param request body EscalateChatRequest false "Reason"
+// @Success 201 {object} utils.APIResponse{data=models.ChatEscalation}
+// @Failure 404 {object} utils.APIResponse
+// @Router /chat/sessions/{id}/escalate [post]
+func (s *Server) escalateChatSession(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid session ID")
+	}
+	var req EscalateChatRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.SendError(c, 400, "Invalid request body")
+		}
+	}
+
+	// Anonymous requests use the demo user
+	userID := uuid.MustParse("4566215d-9957-4765-9ac5-a9395879945e")
+	if authenticated, ok := authenticatedUserID(c); ok {
+		userID = authenticated
+	}
+
+	escalation, err := s.handoffService.Escalate(c.UserContext(), sessionID, userID, req.Reason)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, 201, utils.SuccessResponse(escalation))
 }
 
 // @Summary Submit feedback
@@ -112,22 +400,25 @@ func (s *Server) deleteChatSession(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param feedback body models.Feedback true "Feedback data"
-// @Success 201 {object} models.Feedback
+// @Success 201 {object} utils.APIResponse{data=models.Feedback}
 // @Router /feedback [post]
 func (s *Server) submitFeedback(c *fiber.Ctx) error {
 	var feedback models.Feedback
 	if err := c.BodyParser(&feedback); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
-	// TODO: Get user ID from JWT token
-	feedback.UserID = uuid.New() // Placeholder
+	feedback.UserID = uuid.New() // Placeholder for anonymous feedback
+	if authenticated, ok := authenticatedUserID(c); ok {
+		feedback.UserID = authenticated
+	}
 
 	if err := s.chatService.SubmitFeedback(&feedback); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to submit feedback"})
+		return utils.SendError(c, 500, "Failed to submit feedback")
 	}
+	s.entryAnalytics.RecordFeedback(&feedback)
 
-	return c.Status(201).JSON(feedback)
+	return utils.SendJSON(c, 201, utils.SuccessResponse(feedback))
 }
 
 // @Summary Get feedback
@@ -139,7 +430,7 @@ func (s *Server) submitFeedback(c *fiber.Ctx) error {
 // @Param user_id query string false "Filter by user ID"
 // @Param limit query int false "Limit number of results" default(20)
 // @Param offset query int false "Offset for pagination" default(0)
-// @Success 200 {array} models.Feedback
+// @Success 200 {object} utils.APIResponse{data=[]models.Feedback}
 // @Router /feedback [get]
 func (s *Server) getFeedback(c *fiber.Ctx) error {
 	messageIDStr := c.Query("message_id")
@@ -153,7 +444,7 @@ func (s *Server) getFeedback(c *fiber.Ctx) error {
 	if messageIDStr != "" {
 		id, err := uuid.Parse(messageIDStr)
 		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid message_id parameter"})
+			return utils.SendError(c, 400, "Invalid message_id parameter")
 		}
 		messageID = &id
 	}
@@ -161,10 +452,13 @@ func (s *Server) getFeedback(c *fiber.Ctx) error {
 	if userIDStr != "" {
 		id, err := uuid.Parse(userIDStr)
 		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid user_id parameter"})
+			return utils.SendError(c, 400, "Invalid user_id parameter")
 		}
 		userID = &id
 	}
+	if impersonated, ok := impersonatedUserID(c); ok {
+		userID = &impersonated
+	}
 
 	limit := 20
 	offset := 0
@@ -179,10 +473,10 @@ func (s *Server) getFeedback(c *fiber.Ctx) error {
 
 	feedback, err := s.chatService.GetFeedback(messageID, userID, limit, offset)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch feedback"})
+		return utils.SendError(c, 500, "Failed to fetch feedback")
 	}
 
-	return c.JSON(feedback)
+	return utils.SendSuccess(c, feedback)
 }
 
 // @Summary Get current user
@@ -190,11 +484,22 @@ func (s *Server) getFeedback(c *fiber.Ctx) error {
 // @Tags users
 // @Accept json
 // @Produce json
-// @Success 200 {object} models.User
+// @Success 200 {object} utils.APIResponse{data=models.User}
 // @Router /users/me [get]
 func (s *Server) getCurrentUser(c *fiber.Ctx) error {
-	// TODO: Implement JWT authentication and get real user
-	// For now, return a placeholder user
+	userID, ok := impersonatedUserID(c)
+	if !ok {
+		userID, ok = authenticatedUserID(c)
+	}
+	if ok {
+		var user models.User
+		if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		return utils.SendSuccess(c, user)
+	}
+
+	// Anonymous requests get a placeholder user
 	user := models.User{
 		ID:    uuid.New(),
 		Email: "user@example.com",
@@ -203,5 +508,5 @@ func (s *Server) getCurrentUser(c *fiber.Ctx) error {
 		IsActive: true,
 	}
 
-	return c.JSON(user)
+	return utils.SendSuccess(c, user)
 }
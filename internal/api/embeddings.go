@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/services"
+)
+
+// NewEmbeddingService builds the embedder of the knowledge base from EMBEDDING_PROVIDER, with the embedding model
+// configured for that provider and VECTOR_DIMENSION dimensions. ticctl and the evaluation use it too.
+func NewEmbeddingService(cfg *config.Config, ai *services.UnifiedAIService) (*services.EmbeddingService, error) {
+	dimension, err := strconv.Atoi(cfg.VectorDimension)
+	if err != nil || dimension <= 0 {
+		return nil, fmt.Errorf("invalid VECTOR_DIMENSION %q", cfg.VectorDimension)
+	}
+
+	provider := services.AIProvider(cfg.EmbeddingProvider)
+	if provider == services.OllamaProvider && cfg.OllamaBaseURL == "" {
+		log.Printf("[WARNING] EMBEDDING_PROVIDER is ollama but OLLAMA_BASE_URL is not set, using OpenAI embeddings")
+		provider = services.OpenAIProvider
+	}
+	var model string
+	switch provider {
+	case services.OpenAIProvider, "":
+		provider = services.OpenAIProvider
+		model = cfg.OpenAIEmbeddingModel
+		if cfg.AzureOpenAIEndpoint != "" {
+			model = cfg.AzureOpenAIEmbeddingDeployment
+		}
+	case services.GeminiProvider:
+		model = services.GeminiEmbeddingModel
+	case services.OllamaProvider:
+		model = cfg.OllamaEmbeddingModel
+	default:
+		return nil, fmt.Errorf("unknown EMBEDDING_PROVIDER %q, expected openai, gemini or ollama", cfg.EmbeddingProvider)
+	}
+	log.Printf("[INFO] Embedding with %s (model=%s, dimension=%d)", provider, model, dimension)
+	return services.NewEmbeddingService(ai, provider, model, dimension), nil
+}
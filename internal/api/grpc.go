@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	ticv1 "tic-knowledge-system/proto/tic/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// grpcClaimsKey carries the session token claims of a gRPC call in its context
+type grpcClaimsKey struct{}
+
+// newGRPCServer creates the gRPC API, which serves the knowledge and chat services of the REST API to
+// internal services. Calls are authenticated like REST requests, with the session token as bearer token.
+func newGRPCServer(s *Server, tokens *services.AuthTokenService) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcAuthentication(tokens), grpcErrors))
+	ticv1.RegisterKnowledgeServiceServer(server, &knowledgeGRPC{knowledge: s.knowledgeService})
+	ticv1.RegisterChatServiceServer(server, &chatGRPC{chat: s.enhancedChatService, sessions: s.chatService})
+	return server
+}
+
+// ServeGRPC serves the gRPC API on addr until Shutdown is called
+func (s *Server) ServeGRPC(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.grpc.Serve(listener)
+}
+
+// stopGRPC stops the gRPC API, letting in-flight calls finish until ctx expires
+func (s *Server) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+// grpcAuthentication identifies the user of calls carrying a session token in their authorization metadata.
+// Calls without a token pass through unauthenticated; invalid tokens are rejected.
+func grpcAuthentication(tokens *services.AuthTokenService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var token string
+		if values := md.Get("authorization"); len(values) > 0 && strings.HasPrefix(values[0], "Bearer ") {
+			token = strings.TrimPrefix(values[0], "Bearer ")
+		}
+		if token == "" {
+			return handler(ctx, req)
+		}
+
+		claims, err := tokens.Verify(token)
+		if errors.Is(err, services.ErrAuthTokenInvalid) {
+			log.Printf("[WARNING] Rejected session token on gRPC call %s", info.FullMethod)
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		return handler(context.WithValue(ctx, grpcClaimsKey{}, claims), req)
+	}
+}
+
+// grpcErrors maps service errors to gRPC status codes, like errorHandler maps them to HTTP statuses
+func grpcErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return nil, err
+	}
+
+	var quotaErr *services.QuotaExceededError
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, status.Error(codes.NotFound, "Not found")
+	case errors.Is(err, services.ErrValidation):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &quotaErr):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, services.ErrProviderUnavailable):
+		log.Printf("[ERROR] gRPC %s: %v", info.FullMethod, err)
+		return nil, status.Error(codes.Unavailable, "AI provider unavailable, please try again later")
+	case errors.Is(err, services.ErrInvalidResponse):
+		log.Printf("[ERROR] gRPC %s: %v", info.FullMethod, err)
+		return nil, status.Error(codes.Unavailable, "The AI provider did not return a usable answer, please try again")
+	default:
+		log.Printf("[ERROR] gRPC %s: %v", info.FullMethod, err)
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+}
+
+// grpcUserID returns the signed-in user of a call, or the demo user for anonymous calls like the REST chat
+func grpcUserID(ctx context.Context) uuid.UUID {
+	if claims, ok := ctx.Value(grpcClaimsKey{}).(*services.AuthClaims); ok {
+		return claims.Subject
+	}
+	return uuid.MustParse("4566215d-9957-4765-9ac5-a9395879945e")
+}
+
+// parseGRPCID parses a required ID field of a request
+func parseGRPCID(value, field string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s", field)
+	}
+	return id, nil
+}
+
+// parseOptionalGRPCID parses an optional ID field of a request; empty is nil
+func parseOptionalGRPCID(value, field string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := parseGRPCID(value, field)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// knowledgeGRPC implements tic.v1.KnowledgeService
+type knowledgeGRPC struct {
+	ticv1.UnimplementedKnowledgeServiceServer
+	knowledge *services.KnowledgeService
+}
+
+func (k *knowledgeGRPC) SearchKnowledge(ctx context.Context, req *ticv1.SearchKnowledgeRequest) (*ticv1.SearchKnowledgeResponse, error) {
+	if strings.TrimSpace(req.GetQuery()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 5
+	}
+
+	entries, citations, err := k.knowledge.SearchKnowledgeWithCitations(ctx, req.GetQuery(), limit, k.knowledge.ScopeForUser(grpcUserID(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	resp := &ticv1.SearchKnowledgeResponse{Hits: make([]*ticv1.SearchHit, 0, len(entries))}
+	for i := range entries {
+		hit := &ticv1.SearchHit{Entry: knowledgeEntryProto(&entries[i])}
+		if i < len(citations) {
+			hit.Citation = citationProto(citations[i])
+		}
+		resp.Hits = append(resp.Hits, hit)
+	}
+	return resp, nil
+}
+
+func (k *knowledgeGRPC) GetKnowledgeEntry(ctx context.Context, req *ticv1.GetKnowledgeEntryRequest) (*ticv1.KnowledgeEntry, error) {
+	id, err := parseGRPCID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+	entry, err := k.knowledge.GetKnowledgeEntryByID(id, k.knowledge.ScopeForUser(grpcUserID(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	return knowledgeEntryProto(entry), nil
+}
+
+func (k *knowledgeGRPC) ListKnowledgeEntries(ctx context.Context, req *ticv1.ListKnowledgeEntriesRequest) (*ticv1.ListKnowledgeEntriesResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 20
+	}
+	offset := int(req.GetOffset())
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := k.knowledge.GetKnowledgeEntries(req.GetCategory(), req.Published, services.ReadingFilter{}, k.knowledge.ScopeForUser(grpcUserID(ctx)), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ticv1.ListKnowledgeEntriesResponse{Entries: make([]*ticv1.KnowledgeEntry, 0, len(entries))}
+	for i := range entries {
+		resp.Entries = append(resp.Entries, knowledgeEntryProto(&entries[i]))
+	}
+	return resp, nil
+}
+
+// chatGRPC implements tic.v1.ChatService
+type chatGRPC struct {
+	ticv1.UnimplementedChatServiceServer
+	chat     *services.EnhancedChatService
+	sessions *services.ChatService
+}
+
+func (h *chatGRPC) Chat(ctx context.Context, req *ticv1.ChatRequest) (*ticv1.ChatResponse, error) {
+	if strings.TrimSpace(req.GetMessage()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "message is required")
+	}
+	sessionID, err := parseOptionalGRPCID(req.GetSessionId(), "session_id")
+	if err != nil {
+		return nil, err
+	}
+	personaID, err := parseOptionalGRPCID(req.GetPersonaId(), "persona_id")
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.chat.ProcessChat(ctx, services.EnhancedChatRequest{
+		Message:           req.GetMessage(),
+		SessionID:         sessionID,
+		UserID:            grpcUserID(ctx),
+		PreferredProvider: services.AIProvider(req.GetPreferredProvider()),
+		Language:          req.GetLanguage(),
+		PersonaID:         personaID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ticv1.ChatResponse{
+		Response:          response.Response,
+		SessionId:         response.SessionID.String(),
+		Provider:          string(response.Provider),
+		Model:             response.Model,
+		Language:          response.Language,
+		Confidence:        response.Confidence,
+		SuggestEscalation: response.SuggestEscalation,
+		Cached:            response.Cached,
+		Degraded:          response.Degraded,
+	}
+	if response.MessageID != uuid.Nil {
+		resp.MessageId = response.MessageID.String()
+	}
+	for _, citation := range response.Citations {
+		resp.Citations = append(resp.Citations, citationProto(citation))
+	}
+	return resp, nil
+}
+
+func (h *chatGRPC) ListSessions(ctx context.Context, req *ticv1.ListSessionsRequest) (*ticv1.ListSessionsResponse, error) {
+	sessions, err := h.chat.GetChatSessions(grpcUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	resp := &ticv1.ListSessionsResponse{Sessions: make([]*ticv1.ChatSession, 0, len(sessions))}
+	for i := range sessions {
+		resp.Sessions = append(resp.Sessions, chatSessionProto(&sessions[i]))
+	}
+	return resp, nil
+}
+
+func (h *chatGRPC) GetSession(ctx context.Context, req *ticv1.GetSessionRequest) (*ticv1.ChatSession, error) {
+	sessionID, err := parseGRPCID(req.GetSessionId(), "session_id")
+	if err != nil {
+		return nil, err
+	}
+	session, err := h.sessions.GetChatSession(sessionID, grpcUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return chatSessionProto(session), nil
+}
+
+func knowledgeEntryProto(entry *models.KnowledgeEntry) *ticv1.KnowledgeEntry {
+	var tags []string
+	if entry.Tags != "" {
+		if err := json.Unmarshal([]byte(entry.Tags), &tags); err != nil {
+			log.Printf("[WARNING] Knowledge entry %s has malformed tags: %v", entry.ID, err)
+		}
+	}
+	return &ticv1.KnowledgeEntry{
+		Id:          entry.ID.String(),
+		Title:       entry.Title,
+		Content:     entry.Content,
+		Summary:     entry.Summary,
+		Category:    entry.Category,
+		Tags:        tags,
+		IsPublished: entry.IsPublished,
+		Priority:    int32(entry.Priority),
+		Language:    entry.Language,
+		CreatedAt:   timestamppb.New(entry.CreatedAt),
+		UpdatedAt:   timestamppb.New(entry.UpdatedAt),
+	}
+}
+
+func citationProto(citation services.Citation) *ticv1.Citation {
+	return &ticv1.Citation{
+		Index:            int32(citation.Index),
+		KnowledgeEntryId: citation.KnowledgeEntryID.String(),
+		Title:            citation.Title,
+		ChunkText:        citation.ChunkText,
+		Score:            citation.Score,
+	}
+}
+
+func chatSessionProto(session *models.ChatSession) *ticv1.ChatSession {
+	resp := &ticv1.ChatSession{
+		Id:        session.ID.String(),
+		Title:     session.Title,
+		CreatedAt: timestamppb.New(session.CreatedAt),
+		UpdatedAt: timestamppb.New(session.UpdatedAt),
+	}
+	if session.PersonaID != nil {
+		resp.PersonaId = session.PersonaID.String()
+	}
+	for _, message := range session.Messages {
+		resp.Messages = append(resp.Messages, &ticv1.ChatMessage{
+			Id:        message.ID.String(),
+			Role:      string(message.Role),
+			Content:   message.Content,
+			Version:   int32(message.Version),
+			CreatedAt: timestamppb.New(message.CreatedAt),
+		})
+	}
+	return resp
+}
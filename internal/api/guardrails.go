@@ -0,0 +1,39 @@
+package api
+
+import (
+	"log"
+	"strconv"
+
+	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// newGuardrailService builds the chat guardrail pipeline from the GUARDRAILS_* settings
+func newGuardrailService(cfg *config.Config, db *gorm.DB, openAIService *services.OpenAIService) *services.GuardrailService {
+	guardrails := services.NewGuardrailService(db)
+
+	if redact, err := strconv.ParseBool(cfg.GuardrailsPIIRedaction); err != nil || redact {
+		guardrails.AddInput(services.PIIRedactor{})
+	}
+
+	mode := cfg.GuardrailsModeration
+	if mode == "off" {
+		return guardrails
+	}
+	// Azure OpenAI has no moderation endpoint, it filters content itself
+	hasOpenAI := cfg.OpenAIKey != "" && cfg.AzureOpenAIEndpoint == ""
+	if mode == "openai" || (mode == "auto" && hasOpenAI) {
+		guardrails.AddOutput(services.NewOpenAIModerator(openAIService))
+	}
+	if cfg.GuardrailsBlockedPattern != "" {
+		moderator, err := services.NewRegexModerator(cfg.GuardrailsBlockedPattern)
+		if err != nil {
+			log.Printf("[WARNING] GUARDRAILS_BLOCKED_PATTERN ignored: %v", err)
+		} else {
+			guardrails.AddOutput(moderator)
+		}
+	}
+	return guardrails
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminStatusHandler exposes the system status to the ops dashboard
+type AdminStatusHandler struct {
+	statusService *services.AdminStatusService
+	logger        *log.Logger
+}
+
+// NewAdminStatusHandler creates a new admin status handler
+func NewAdminStatusHandler(statusService *services.AdminStatusService, logger *log.Logger) *AdminStatusHandler {
+	return &AdminStatusHandler{
+		statusService: statusService,
+		logger:        logger,
+	}
+}
+
+// GetStatus returns the health of the whole system
+// @Summary System status
+// @Description Database, Qdrant and AI provider reachability, Qdrant collection stats, job queue totals,
+// @Description the document processing backlog and the last sync of each connector in one report.
+// @Description Sections that could not be loaded carry an error instead of failing the request.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.SystemStatus}
+// @ID getAdminStatus
+// @Router /admin/status [get]
+func (h *AdminStatusHandler) GetStatus(c *fiber.Ctx) error {
+	return utils.SendSuccess(c, h.statusService.Status(c.UserContext()))
+}
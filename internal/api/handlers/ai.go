@@ -8,18 +8,13 @@ import (
 
 	"tic-knowledge-system/internal/models"
 	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
-
 type AIHandler struct {
 	enhancedChatService *services.EnhancedChatService
 }
@@ -32,14 +27,19 @@ func NewAIHandler(enhancedChatService *services.EnhancedChatService) *AIHandler
 
 // ProcessChatWithAI handles chat requests with AI provider selection
 // @Summary Process chat message with AI provider selection
-// @Description Send a message to the AI chatbot with the ability to choose provider
+// @Description Send a message to the AI chatbot with the ability to choose provider.
+// @Description The message is sent as the signed-in user, or as the demo user without a session; a different user_id is rejected.
 // @Tags ai-chat
 // @Accept json
 // @Produce json
 // @Param request body services.EnhancedChatRequest true "Chat request"
-// @Success 200 {object} services.EnhancedChatResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Param lang query string false "Language of the answer (ISO 639-1 code or tag); overrides the request body"
+// @Success 200 {object} utils.APIResponse{data=services.EnhancedChatResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 402 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Failure 503 {object} utils.APIResponse
 // @Router /ai/chat [post]
 func (h *AIHandler) ProcessChatWithAI(c *fiber.Ctx) error {
 	log.Printf("[INFO] Received enhanced chat request")
@@ -47,26 +47,20 @@ func (h *AIHandler) ProcessChatWithAI(c *fiber.Ctx) error {
 	var req services.EnhancedChatRequest
 	if err := c.BodyParser(&req); err != nil {
 		log.Printf("[ERROR] Failed to parse chat request: %v", err)
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Invalid request body",
-			Message: err.Error(),
-		})
+		return utils.SendError(c, 400, "Invalid request body", err.Error())
 	}
 
 	// Validate required fields
 	if req.Message == "" {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Missing required field",
-			Message: "message is required",
-		})
+		return utils.SendError(c, 400, "Missing required field", "message is required")
 	}
 
-	if req.UserID == uuid.Nil {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Missing required field",
-			Message: "user_id is required",
-		})
+	userID, err := chatUserID(c, req.UserID)
+	if err != nil {
+		return err
 	}
+	req.UserID = userID
+	req.Language = c.Query("lang", req.Language)
 
 	log.Printf("[INFO] Processing enhanced chat for user: %s, provider: %s", req.UserID, req.PreferredProvider)
 
@@ -114,19 +108,14 @@ func (h *AIHandler) ProcessChatWithAI(c *fiber.Ctx) error {
 	// Process the chat request
 	response, err := h.enhancedChatService.ProcessChat(c.Context(), req)
 	if err != nil {
+		// Mapped to 400/402/403/503 by the API error handler
 		log.Printf("[ERROR] Chat processing failed: %v", err)
-		return c.Status(500).JSON(ErrorResponse{
-			Error:   "Chat processing failed",
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	log.Printf("[INFO] Chat processed successfully using provider: %s", response.Provider)
 
-	resp := fiber.Map{
-		"success": true,
-		"data":    response,
-	}
+	resp := utils.SuccessResponse(response)
 	responseJSON, _ := json.Marshal(resp)
 	responseTime := time.Since(start).Milliseconds()
 	if db != nil {
@@ -137,7 +126,7 @@ func (h *AIHandler) ProcessChatWithAI(c *fiber.Ctx) error {
 			ResponseTime:  responseTime,
 		})
 	}
-	return c.Status(200).JSON(resp)
+	return utils.SendJSON(c, fiber.StatusOK, resp)
 }
 
 // GetAvailableProviders returns the list of available AI providers
@@ -145,7 +134,7 @@ func (h *AIHandler) ProcessChatWithAI(c *fiber.Ctx) error {
 // @Description Get the list of AI providers that are currently available
 // @Tags ai-providers
 // @Produce json
-// @Success 200 {object} object{providers=[]string}
+// @Success 200 {object} utils.APIResponse{data=object{providers=[]string}}
 // @Router /ai/providers [get]
 func (h *AIHandler) GetAvailableProviders(c *fiber.Ctx) error {
 	log.Printf("[INFO] Getting available AI providers")
@@ -159,13 +148,28 @@ func (h *AIHandler) GetAvailableProviders(c *fiber.Ctx) error {
 
 	log.Printf("[INFO] Available providers: %v", providerStrings)
 
-	return c.Status(200).JSON(fiber.Map{
-		"success":   true,
+	return utils.SendSuccess(c, fiber.Map{
 		"providers": providerStrings,
 		"primary":   string(h.enhancedChatService.GetPrimaryProvider()),
 	})
 }
 
+// GetProviderHealth reports the health of each AI provider
+// @Summary Get AI provider health
+// @Description Get the circuit breaker state, consecutive failures, and last latency of each available AI provider
+// @Tags ai-providers
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{providers=[]services.ProviderHealth}}
+// @Router /ai/providers/health [get]
+func (h *AIHandler) GetProviderHealth(c *fiber.Ctx) error {
+	health := h.enhancedChatService.GetProviderHealth()
+
+	return utils.SendSuccess(c, fiber.Map{
+		"providers": health,
+		"primary":   string(h.enhancedChatService.GetPrimaryProvider()),
+	})
+}
+
 // SetPrimaryProvider sets the primary AI provider
 // @Summary Set primary AI provider
 // @Description Change the primary AI provider for chat requests
@@ -173,9 +177,9 @@ func (h *AIHandler) GetAvailableProviders(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param request body object{provider=string} true "Provider selection"
-// @Success 200 {object} object{success=bool,message=string}
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Success 200 {object} utils.APIResponse{data=object{success=bool,message=string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /ai/providers/primary [post]
 func (h *AIHandler) SetPrimaryProvider(c *fiber.Ctx) error {
 	log.Printf("[INFO] Setting primary AI provider")
@@ -186,37 +190,87 @@ func (h *AIHandler) SetPrimaryProvider(c *fiber.Ctx) error {
 
 	if err := c.BodyParser(&req); err != nil {
 		log.Printf("[ERROR] Failed to parse provider request: %v", err)
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Invalid request body",
-			Message: err.Error(),
-		})
+		return utils.SendError(c, 400, "Invalid request body", err.Error())
 	}
 
 	if req.Provider == "" {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Missing required field",
-			Message: "provider is required",
-		})
+		return utils.SendError(c, 400, "Missing required field", "provider is required")
 	}
 
 	provider := services.AIProvider(req.Provider)
 
 	if err := h.enhancedChatService.SetPrimaryProvider(provider); err != nil {
 		log.Printf("[ERROR] Failed to set primary provider: %v", err)
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Invalid provider",
-			Message: err.Error(),
-		})
+		return utils.SendError(c, 400, "Invalid provider", err.Error())
 	}
 
 	log.Printf("[INFO] Primary provider set to: %s", provider)
 
-	return c.Status(200).JSON(fiber.Map{
-		"success": true,
+	return utils.SendSuccess(c, fiber.Map{
 		"message": fmt.Sprintf("Primary provider set to %s", provider),
 	})
 }
 
+// QueueQuestion queues a question for a long-running answer delivered later by email
+// @Summary Queue question for a later answer
+// @Description Queue a question to be researched in the background; the answer is added to the chat session and emailed to the user
+// @Tags ai-chat
+// @Accept json
+// @Produce json
+// @Param request body services.EnhancedChatRequest true "Question to queue"
+// @Success 202 {object} utils.APIResponse{data=models.QueuedQuestion}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /ai/queued-questions [post]
+func (h *AIHandler) QueueQuestion(c *fiber.Ctx) error {
+	var req services.EnhancedChatRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, 400, "Invalid request body", err.Error())
+	}
+
+	if req.Message == "" {
+		return utils.SendError(c, 400, "Missing required field", "message is required")
+	}
+	userID, err := chatUserID(c, req.UserID)
+	if err != nil {
+		return err
+	}
+	req.UserID = userID
+
+	queued, err := h.enhancedChatService.QueueQuestion(c.Context(), req)
+	if err != nil {
+		log.Printf("[ERROR] Failed to queue question: %v", err)
+		return utils.SendError(c, 500, "Failed to queue question", err.Error())
+	}
+
+	return utils.SendJSON(c, 202, utils.SuccessResponse(queued))
+}
+
+// GetQueuedQuestion returns the status of a question queued while the AI providers were unavailable
+// @Summary Get queued question
+// @Description Get the status of a question that was queued for a later answer in degraded mode
+// @Tags ai-chat
+// @Produce json
+// @Param id path string true "Queued question ID"
+// @Success 200 {object} utils.APIResponse{data=models.QueuedQuestion}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /ai/queued-questions/{id} [get]
+func (h *AIHandler) GetQueuedQuestion(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid ID", "id must be a valid UUID")
+	}
+
+	queued, err := h.enhancedChatService.GetQueuedQuestion(id)
+	if err != nil {
+		return utils.SendError(c, 404, "Not found", "queued question not found")
+	}
+
+	return utils.SendSuccess(c, queued)
+}
+
 // CompareProviders tests the same message with different AI providers
 // @Summary Compare AI provider responses
 // @Description Send the same message to multiple AI providers for comparison
@@ -224,40 +278,40 @@ func (h *AIHandler) SetPrimaryProvider(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param request body object{message=string,user_id=string,providers=[]string} true "Comparison request"
-// @Success 200 {object} object{responses=map[string]services.EnhancedChatResponse}
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Success 200 {object} utils.APIResponse{data=object{responses=map[string]services.EnhancedChatResponse}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /ai/compare [post]
 func (h *AIHandler) CompareProviders(c *fiber.Ctx) error {
 	log.Printf("[INFO] Received provider comparison request")
 
 	var req struct {
 		Message   string   `json:"message" validate:"required"`
-		UserID    string   `json:"user_id" validate:"required"`
+		UserID    string   `json:"user_id"`
 		Providers []string `json:"providers"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		log.Printf("[ERROR] Failed to parse comparison request: %v", err)
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Invalid request body",
-			Message: err.Error(),
-		})
+		return utils.SendError(c, 400, "Invalid request body", err.Error())
 	}
 
-	if req.Message == "" || req.UserID == "" {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Missing required fields",
-			Message: "message and user_id are required",
-		})
+	if req.Message == "" {
+		return utils.SendError(c, 400, "Missing required fields", "message is required")
 	}
 
-	userID, err := uuid.Parse(req.UserID)
+	var bodyUserID uuid.UUID
+	if req.UserID != "" {
+		id, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return utils.SendError(c, 400, "Invalid user_id", "user_id must be a valid UUID")
+		}
+		bodyUserID = id
+	}
+	userID, err := chatUserID(c, bodyUserID)
 	if err != nil {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "Invalid user_id",
-			Message: "user_id must be a valid UUID",
-		})
+		return err
 	}
 
 	// If no providers specified, use all available
@@ -281,6 +335,7 @@ func (h *AIHandler) CompareProviders(c *fiber.Ctx) error {
 			Message:           req.Message,
 			UserID:            userID,
 			PreferredProvider: provider,
+			Ephemeral:         true, // Comparisons stay out of the user's chat history
 		}
 
 		response, err := h.enhancedChatService.ProcessChat(c.Context(), chatReq)
@@ -293,8 +348,7 @@ func (h *AIHandler) CompareProviders(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"success":   true,
+	return utils.SendSuccess(c, fiber.Map{
 		"responses": responses,
 		"errors":    errors,
 		"message":   fmt.Sprintf("Compared %d providers", len(req.Providers)),
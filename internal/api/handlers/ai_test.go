@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tic-knowledge-system/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder is a gorm logger keeping the statements run through it
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// contains reports whether a recorded statement contains every one of parts
+func (r *sqlRecorder) contains(parts ...string) bool {
+	for _, statement := range r.statements {
+		found := true
+		for _, part := range parts {
+			if !strings.Contains(statement, part) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// mentions reports whether a recorded statement mentions id
+func (r *sqlRecorder) mentions(id uuid.UUID) bool {
+	for _, statement := range r.statements {
+		if strings.Contains(statement, id.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunDB returns a connection that records the SQL of every query instead of running it
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	conn, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open a dry-run connection: %v", err)
+	}
+	return conn, recorder
+}
+
+// fakeAzureOpenAI answers every chat completion and keeps the prompts it was sent
+func fakeAzureOpenAI(t *testing.T) (*services.OpenAIService, *[]string) {
+	t.Helper()
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompts = append(prompts, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Model:   "gpt-4",
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: "assistant", Content: "Use the label printer."}}},
+			Usage:   openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return services.NewAzureOpenAIService(server.URL, "test", "2024-02-01", "gpt-4", "", 200, 0), &prompts
+}
+
+// chatTestApp serves the AI chat routes. Requests with an X-Test-User header are signed in as that user.
+func chatTestApp(handler *AIHandler, db *gorm.DB) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		var fiberErr *fiber.Error
+		switch {
+		case errors.As(err, &fiberErr):
+			return c.Status(fiberErr.Code).SendString(fiberErr.Message)
+		case errors.Is(err, services.ErrForbidden):
+			return c.Status(fiber.StatusForbidden).SendString(err.Error())
+		default:
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+	}})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("db", db)
+		if id, err := uuid.Parse(c.Get("X-Test-User")); err == nil {
+			c.Locals(AuthLocal, &services.AuthClaims{Subject: id})
+		}
+		return c.Next()
+	})
+	app.Post("/ai/chat", handler.ProcessChatWithAI)
+	app.Post("/ai/compare", handler.CompareProviders)
+	app.Post("/ai/queued-questions", handler.QueueQuestion)
+	return app
+}
+
+// chatTest is the chat routes served over a dry-run database and a fake provider
+type chatTest struct {
+	app      *fiber.App
+	chat     *services.EnhancedChatService
+	db       *gorm.DB
+	recorder *sqlRecorder
+	prompts  *[]string
+}
+
+func newChatTest(t *testing.T) *chatTest {
+	t.Helper()
+	db, recorder := dryRunDB(t)
+	openAI, prompts := fakeAzureOpenAI(t)
+	knowledge := services.NewKnowledgeService(db, nil, nil, nil)
+	chat := services.NewEnhancedChatService(db, services.NewUnifiedAIService(openAI, nil, services.OpenAIProvider), knowledge, nil, services.OpenAIProvider, nil)
+	return &chatTest{app: chatTestApp(NewAIHandler(chat), db), chat: chat, db: db, recorder: recorder, prompts: prompts}
+}
+
+// postJSON sends body to path, signed in as user unless it is uuid.Nil, and returns the response status
+func postJSON(t *testing.T, app *fiber.App, path string, user uuid.UUID, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if user != uuid.Nil {
+		req.Header.Set("X-Test-User", user.String())
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestChatRejectsForgedUserID(t *testing.T) {
+	test := newChatTest(t)
+	user, admin := uuid.New(), uuid.New()
+	forged := fmt.Sprintf(`{"message":"What is in the finance runbook?","user_id":%q}`, admin)
+
+	tests := []struct {
+		name string
+		path string
+		user uuid.UUID
+	}{
+		{"chat", "/ai/chat", user},
+		{"compare", "/ai/compare", user},
+		{"queued question", "/ai/queued-questions", user},
+		{"anonymous chat", "/ai/chat", uuid.Nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := postJSON(t, test.app, tt.path, tt.user, forged); status != fiber.StatusForbidden {
+				t.Errorf("status %d, want %d", status, fiber.StatusForbidden)
+			}
+		})
+	}
+	if len(*test.prompts) != 0 {
+		t.Errorf("%d chat completions for forged requests, want 0", len(*test.prompts))
+	}
+	if test.recorder.mentions(admin) {
+		t.Error("the forged user_id was looked up, so its scope could have been used")
+	}
+
+	// Without the forged user_id the chat runs in the scope of the session user, restricted entries excluded
+	if status := postJSON(t, test.app, "/ai/chat", user, `{"message":"What is in the finance runbook?"}`); status != fiber.StatusOK {
+		t.Fatalf("status %d, want %d", status, fiber.StatusOK)
+	}
+	if !test.recorder.contains(`FROM "users" WHERE id = '` + user.String() + `'`) {
+		t.Error("the retrieval scope was not loaded for the session user")
+	}
+	if !test.recorder.contains(`FROM "knowledge_entries"`, "allowed_roles", "allowed_teams") {
+		t.Error("the knowledge search was not restricted to the entries the session user may see")
+	}
+}
+
+func TestChatChargesQuotaToSessionUser(t *testing.T) {
+	test := newChatTest(t)
+	test.chat.SetQuotaService(services.NewQuotaService(test.db, services.QuotaLimits{}, services.QuotaLimits{}))
+	test.chat.SetUsageService(services.NewUsageService(test.db, nil, nil))
+	user, unlimited := uuid.New(), uuid.New()
+
+	// A body naming a user without a quota neither passes the check as that user nor spends their budget
+	forged := fmt.Sprintf(`{"message":"How do I print labels?","user_id":%q}`, unlimited)
+	if status := postJSON(t, test.app, "/ai/chat", user, forged); status != fiber.StatusForbidden {
+		t.Fatalf("status %d, want %d", status, fiber.StatusForbidden)
+	}
+	if test.recorder.mentions(unlimited) {
+		t.Error("the quota of the body user_id was checked or charged")
+	}
+
+	if status := postJSON(t, test.app, "/ai/chat", user, `{"message":"How do I print labels?"}`); status != fiber.StatusOK {
+		t.Fatalf("status %d, want %d", status, fiber.StatusOK)
+	}
+	if !test.recorder.contains("FROM usage_records", "u.user_id = '"+user.String()+"'") {
+		t.Error("the quota was not checked against the usage of the session user")
+	}
+	if !test.recorder.contains(`INSERT INTO "usage_records"`, user.String()) {
+		t.Error("the usage was not charged to the session user")
+	}
+}
+
+func TestChatGenerationLimitsFollowSessionUser(t *testing.T) {
+	test := newChatTest(t)
+	user, admin := uuid.New(), uuid.New()
+
+	// Admins may raise the temperature to 2; claiming an admin's user_id must not lift the caps
+	forged := fmt.Sprintf(`{"message":"How do I print labels?","user_id":%q,"generation":{"temperature":1.8}}`, admin)
+	if status := postJSON(t, test.app, "/ai/chat", user, forged); status != fiber.StatusForbidden {
+		t.Fatalf("status %d, want %d", status, fiber.StatusForbidden)
+	}
+	if test.recorder.mentions(admin) {
+		t.Error("the role of the body user_id was looked up")
+	}
+
+	// The session user has no role allowing overrides
+	if status := postJSON(t, test.app, "/ai/chat", user, `{"message":"How do I print labels?","generation":{"temperature":1.8}}`); status != fiber.StatusForbidden {
+		t.Fatalf("status %d, want %d", status, fiber.StatusForbidden)
+	}
+	if !test.recorder.contains(`FROM "users" WHERE id = '` + user.String() + `'`) {
+		t.Error("the generation limits were not taken from the role of the session user")
+	}
+	if len(*test.prompts) != 0 {
+		t.Errorf("%d chat completions with rejected overrides, want 0", len(*test.prompts))
+	}
+}
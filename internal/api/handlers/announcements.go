@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AnnouncementHandler lets support managers publish org-wide announcements that chat answers take into account
+type AnnouncementHandler struct {
+	announcementService *services.AnnouncementService
+	logger              *log.Logger
+}
+
+// NewAnnouncementHandler creates a new announcement handler
+func NewAnnouncementHandler(announcementService *services.AnnouncementService, logger *log.Logger) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+		logger:              logger,
+	}
+}
+
+// AnnouncementRequest creates or updates an announcement
+type AnnouncementRequest struct {
+	ManagerID string `json:"manager_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	services.AnnouncementSpec
+}
+
+// ListAnnouncements lists the announcements
+// @Summary List announcements
+// @Tags announcements
+// @Produce json
+// @Param active query bool false "Only the announcements active now"
+// @Success 200 {object} utils.APIResponse{data=[]models.Announcement}
+// @Failure 500 {object} utils.APIResponse
+// @Router /announcements [get]
+func (h *AnnouncementHandler) ListAnnouncements(c *fiber.Ctx) error {
+	announcements, err := h.announcementService.ListAnnouncements(c.UserContext(), c.QueryBool("active"))
+	if err != nil {
+		h.logger.Printf("Error listing announcements: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list announcements")
+	}
+	return utils.SendSuccess(c, announcements)
+}
+
+// CreateAnnouncement publishes an announcement
+// @Summary Create an announcement
+// @Description Between starts_at and ends_at the announcement is added to the system prompt of every chat answer,
+// @Description highest priority first (at most 5 at a time). Only support agents and admins can manage announcements.
+// @Tags announcements
+// @Accept json
+// @Produce json
+// @Param request body AnnouncementRequest true "Announcement and support manager"
+// @Success 201 {object} utils.APIResponse{data=models.Announcement}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /announcements [post]
+func (h *AnnouncementHandler) CreateAnnouncement(c *fiber.Ctx) error {
+	req, managerID, err := parseAnnouncementRequest(c)
+	if err != nil {
+		return err
+	}
+	announcement, err := h.announcementService.CreateAnnouncement(c.UserContext(), managerID, req.AnnouncementSpec)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(announcement))
+}
+
+// UpdateAnnouncement changes an announcement
+// @Summary Update an announcement
+// @Description Replaces the announcement; an empty starts_at keeps the current start. Set ends_at to now to end it early.
+// @Tags announcements
+// @Accept json
+// @Produce json
+// @Param id path string true "Announcement ID"
+// @Param request body AnnouncementRequest true "Announcement and support manager"
+// @Success 200 {object} utils.APIResponse{data=models.Announcement}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /announcements/{id} [put]
+func (h *AnnouncementHandler) UpdateAnnouncement(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid announcement ID")
+	}
+	req, managerID, err := parseAnnouncementRequest(c)
+	if err != nil {
+		return err
+	}
+	announcement, err := h.announcementService.UpdateAnnouncement(c.UserContext(), managerID, id, req.AnnouncementSpec)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, announcement)
+}
+
+// DeleteAnnouncement deletes an announcement
+// @Summary Delete an announcement
+// @Tags announcements
+// @Param id path string true "Announcement ID"
+// @Param manager_id query string true "Support agent or admin"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /announcements/{id} [delete]
+func (h *AnnouncementHandler) DeleteAnnouncement(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid announcement ID")
+	}
+	managerID, err := uuid.Parse(c.Query("manager_id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid manager_id")
+	}
+	if err := h.announcementService.DeleteAnnouncement(c.UserContext(), managerID, id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func parseAnnouncementRequest(c *fiber.Ctx) (*AnnouncementRequest, uuid.UUID, error) {
+	var req AnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	managerID, err := uuid.Parse(req.ManagerID)
+	if err != nil {
+		return nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid manager_id")
+	}
+	return &req, managerID, nil
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AssistantAdminHandler lets admins create and update OpenAI assistants from the knowledge system
+type AssistantAdminHandler struct {
+	assistantService *services.AssistantAdminService
+	logger           *log.Logger
+}
+
+// NewAssistantAdminHandler creates a new assistant admin handler
+func NewAssistantAdminHandler(assistantService *services.AssistantAdminService, logger *log.Logger) *AssistantAdminHandler {
+	return &AssistantAdminHandler{
+		assistantService: assistantService,
+		logger:           logger,
+	}
+}
+
+// AssistantRequest creates or updates a managed assistant
+type AssistantRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	services.AssistantSpec
+}
+
+// ListAssistants returns the managed assistants
+// @Summary List managed assistants
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]models.ManagedAssistant}
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/assistants [get]
+func (h *AssistantAdminHandler) ListAssistants(c *fiber.Ctx) error {
+	assistants, err := h.assistantService.ListAssistants(c.UserContext())
+	if err != nil {
+		h.logger.Printf("Error listing assistants: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list assistants")
+	}
+	return utils.SendSuccess(c, assistants)
+}
+
+// GetAssistant returns a managed assistant
+// @Summary Get a managed assistant
+// @Tags admin
+// @Produce json
+// @Param id path string true "Managed assistant ID"
+// @Success 200 {object} utils.APIResponse{data=models.ManagedAssistant}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/assistants/{id} [get]
+func (h *AssistantAdminHandler) GetAssistant(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid assistant ID")
+	}
+	assistant, err := h.assistantService.GetAssistant(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, assistant)
+}
+
+// CreateAssistant creates an OpenAI assistant
+// @Summary Create a managed assistant
+// @Description Creates an OpenAI assistant with file search over the knowledge vector store (or the given vector stores)
+// @Description and instructions rendered from the active version of a prompt template (support_assistant by default).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body AssistantRequest true "Assistant configuration and admin"
+// @Success 201 {object} utils.APIResponse{data=models.ManagedAssistant}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/assistants [post]
+func (h *AssistantAdminHandler) CreateAssistant(c *fiber.Ctx) error {
+	req, adminID, err := parseAssistantRequest(c)
+	if err != nil {
+		return err
+	}
+	assistant, err := h.assistantService.CreateAssistant(c.UserContext(), req.AssistantSpec, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(assistant))
+}
+
+// UpdateAssistant updates an OpenAI assistant
+// @Summary Update a managed assistant
+// @Description Changes the given fields and renders the instructions from the active version of the prompt template again,
+// @Description so an update without fields publishes a new prompt version to the assistant.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Managed assistant ID"
+// @Param request body AssistantRequest true "Changed fields and admin"
+// @Success 200 {object} utils.APIResponse{data=models.ManagedAssistant}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/assistants/{id} [put]
+func (h *AssistantAdminHandler) UpdateAssistant(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid assistant ID")
+	}
+	req, adminID, err := parseAssistantRequest(c)
+	if err != nil {
+		return err
+	}
+	assistant, err := h.assistantService.UpdateAssistant(c.UserContext(), id, req.AssistantSpec, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, assistant)
+}
+
+func parseAssistantRequest(c *fiber.Ctx) (*AssistantRequest, uuid.UUID, error) {
+	var req AssistantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return &req, adminID, nil
+}
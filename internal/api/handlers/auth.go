@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"tic-knowledge-system/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AuthLocal is the request local the authentication middleware keeps the *services.AuthClaims of a session in
+const AuthLocal = "auth"
+
+// SessionRequiredLocal is the request local set on deployments with SSO or a JWT secret, whose admin endpoints
+// only accept signed-in admins rather than an admin ID in the body
+const SessionRequiredLocal = "session_required"
+
+// sessionRequired reports whether admin endpoints require a session on this deployment
+func sessionRequired(c *fiber.Ctx) bool {
+	required, _ := c.Locals(SessionRequiredLocal).(bool)
+	return required
+}
+
+// authenticatedUserID returns the signed-in user of this request
+func authenticatedUserID(c *fiber.Ctx) (uuid.UUID, bool) {
+	claims, ok := c.Locals(AuthLocal).(*services.AuthClaims)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return claims.Subject, true
+}
+
+// requestAdminID returns the admin making a request: the signed-in user when the request has a session, else
+// the ID in the body field named field. A body ID naming anyone but the signed-in user is rejected, and requests
+// without a session are when SSO or a JWT secret is configured.
+func requestAdminID(c *fiber.Ctx, bodyID, field string) (uuid.UUID, error) {
+	authenticated, ok := authenticatedUserID(c)
+	if !ok {
+		if sessionRequired(c) {
+			return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Sign in as an admin")
+		}
+		id, err := uuid.Parse(bodyID)
+		if err != nil {
+			return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid "+field)
+		}
+		return id, nil
+	}
+	if bodyID != "" {
+		if id, err := uuid.Parse(bodyID); err != nil || id != authenticated {
+			return uuid.Nil, fiber.NewError(fiber.StatusForbidden, field+" does not match the signed-in user")
+		}
+	}
+	return authenticated, nil
+}
+
+// chatUserID returns the user a chat request is made as: the signed-in user, or the demo user for anonymous
+// requests. Retrieval scope, generation limits and quotas follow this user, so a body user_id naming anyone
+// else is rejected.
+func chatUserID(c *fiber.Ctx, bodyID uuid.UUID) (uuid.UUID, error) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		userID = uuid.MustParse(demoUserID)
+	}
+	if bodyID != uuid.Nil && bodyID != userID {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "user_id does not match the signed-in user")
+	}
+	return userID, nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"tic-knowledge-system/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestRequestAdminID(t *testing.T) {
+	admin, other := uuid.New(), uuid.New()
+	tests := []struct {
+		name             string
+		session          uuid.UUID
+		sessionsRequired bool
+		bodyID           string
+		want             uuid.UUID
+		wantStatus       int
+	}{
+		{name: "session", session: admin, sessionsRequired: true, want: admin},
+		{name: "session with its own ID", session: admin, sessionsRequired: true, bodyID: admin.String(), want: admin},
+		{name: "session with another ID", session: admin, sessionsRequired: true, bodyID: other.String(), wantStatus: fiber.StatusForbidden},
+		{name: "body ID without sign-in", bodyID: admin.String(), want: admin},
+		{name: "invalid body ID without sign-in", bodyID: "admin", wantStatus: fiber.StatusBadRequest},
+		{name: "body ID where sign-in is required", sessionsRequired: true, bodyID: admin.String(), wantStatus: fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uuid.UUID
+			var gotErr error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				c.Locals(SessionRequiredLocal, tt.sessionsRequired)
+				if tt.session != uuid.Nil {
+					c.Locals(AuthLocal, &services.AuthClaims{Subject: tt.session})
+				}
+				got, gotErr = requestAdminID(c, tt.bodyID, "admin_id")
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantStatus != 0 {
+				fiberErr, ok := gotErr.(*fiber.Error)
+				if !ok || fiberErr.Code != tt.wantStatus {
+					t.Fatalf("error %v, want status %d", gotErr, tt.wantStatus)
+				}
+				return
+			}
+			if gotErr != nil || got != tt.want {
+				t.Errorf("got %s, %v, want %s", got, gotErr, tt.want)
+			}
+		})
+	}
+}
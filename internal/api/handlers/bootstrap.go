@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// demoUserID is the demo user that owns created content when no user ID is given
+const demoUserID = "4566215d-9957-4765-9ac5-a9395879945e"
+
+// BootstrapHandler exposes the cold-start knowledge bootstrap wizard
+type BootstrapHandler struct {
+	bootstrapService *services.BootstrapService
+	uploadDir        string
+	logger           *log.Logger
+}
+
+// NewBootstrapHandler creates a new bootstrap handler. Uploaded seed files are stored under uploadDir.
+func NewBootstrapHandler(bootstrapService *services.BootstrapService, uploadDir string, logger *log.Logger) *BootstrapHandler {
+	return &BootstrapHandler{
+		bootstrapService: bootstrapService,
+		uploadDir:        uploadDir,
+		logger:           logger,
+	}
+}
+
+// BootstrapDocument is an inline seed document
+type BootstrapDocument struct {
+	Title    string `json:"title" example:"Refund policy"`
+	Content  string `json:"content" example:"Customers can request a refund within 30 days..."`
+	Category string `json:"category,omitempty" example:"Billing"`
+}
+
+// BootstrapRequestBody is the JSON form of a bootstrap request
+type BootstrapRequestBody struct {
+	URLs              []string            `json:"urls" example:"https://example.com/faq"`
+	Documents         []BootstrapDocument `json:"documents"`
+	UserID            string              `json:"user_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	ActivateTemplates bool                `json:"activate_templates" example:"false"`
+}
+
+// Bootstrap ingests a batch of seed documents and URLs into a blank instance
+// @Summary Bootstrap the knowledge base
+// @Description Ingest seed documents and URLs, generate categories and topics, propose templates, and return a readiness report.
+// @Description Send multipart/form-data with "files" (.docx, .txt, .md, .html), "urls" (newline or comma separated), "user_id" and "activate_templates",
+// @Description or JSON with urls and inline documents.
+// @Tags bootstrap
+// @Accept json,mpfd
+// @Produce json
+// @Param request body BootstrapRequestBody false "Bootstrap request (JSON form)"
+// @Success 200 {object} utils.APIResponse{data=services.BootstrapResult}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /bootstrap [post]
+func (h *BootstrapHandler) Bootstrap(c *fiber.Ctx) error {
+	var req services.BootstrapRequest
+	userID := demoUserID
+
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid multipart form")
+		}
+
+		batchDir := filepath.Join(h.uploadDir, "bootstrap", uuid.New().String())
+		if len(form.File["files"]) > 0 {
+			if err := os.MkdirAll(batchDir, 0755); err != nil {
+				h.logger.Printf("Error creating bootstrap upload directory: %v", err)
+				return utils.SendError(c, fiber.StatusInternalServerError, "Failed to store uploaded files")
+			}
+		}
+		for _, fileHeader := range form.File["files"] {
+			destPath := filepath.Join(batchDir, filepath.Base(fileHeader.Filename))
+			if err := c.SaveFile(fileHeader, destPath); err != nil {
+				h.logger.Printf("Error saving bootstrap file %s: %v", fileHeader.Filename, err)
+				return utils.SendError(c, fiber.StatusInternalServerError, "Failed to store uploaded files")
+			}
+			req.Seeds = append(req.Seeds, services.BootstrapSeed{Kind: services.BootstrapSeedFile, Source: destPath})
+		}
+
+		urls := strings.FieldsFunc(c.FormValue("urls"), func(r rune) bool {
+			return r == '\n' || r == ',' || r == ' '
+		})
+		for _, url := range urls {
+			req.Seeds = append(req.Seeds, services.BootstrapSeed{Kind: services.BootstrapSeedURL, Source: strings.TrimSpace(url)})
+		}
+
+		if value := c.FormValue("user_id"); value != "" {
+			userID = value
+		}
+		req.ActivateTemplates = c.FormValue("activate_templates") == "true"
+	} else {
+		var body BootstrapRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+
+		for _, url := range body.URLs {
+			req.Seeds = append(req.Seeds, services.BootstrapSeed{Kind: services.BootstrapSeedURL, Source: strings.TrimSpace(url)})
+		}
+		for i, doc := range body.Documents {
+			req.Seeds = append(req.Seeds, services.BootstrapSeed{
+				Kind:     services.BootstrapSeedText,
+				Source:   fmt.Sprintf("document-%d", i+1),
+				Title:    doc.Title,
+				Content:  doc.Content,
+				Category: doc.Category,
+			})
+		}
+
+		if body.UserID != "" {
+			userID = body.UserID
+		}
+		req.ActivateTemplates = body.ActivateTemplates
+	}
+
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+	req.UserID = parsedUserID
+
+	if len(req.Seeds) == 0 {
+		return utils.SendError(c, fiber.StatusBadRequest, "Provide at least one file, URL, or document")
+	}
+
+	h.logger.Printf("Starting knowledge bootstrap with %d seeds", len(req.Seeds))
+	result, err := h.bootstrapService.Bootstrap(c.Context(), req)
+	if err != nil {
+		h.logger.Printf("Error bootstrapping knowledge base: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to bootstrap knowledge base", err.Error())
+	}
+
+	return utils.SendSuccess(c, result)
+}
+
+// GetReadiness reports whether the knowledge base is ready to answer questions
+// @Summary Get knowledge base readiness
+// @Description Check indexed content, embedding coverage, categories, templates, and AI providers
+// @Tags bootstrap
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.ReadinessReport}
+// @Failure 500 {object} utils.APIResponse
+// @Router /bootstrap/readiness [get]
+func (h *BootstrapHandler) GetReadiness(c *fiber.Ctx) error {
+	report, err := h.bootstrapService.GetReadinessReport()
+	if err != nil {
+		h.logger.Printf("Error building readiness report: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to build readiness report")
+	}
+	return utils.SendSuccess(c, report)
+}
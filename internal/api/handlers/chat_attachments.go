@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"io"
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ChatAttachmentHandler uploads and serves the images users attach to chat messages
+type ChatAttachmentHandler struct {
+	attachmentService *services.ChatAttachmentService
+	logger            *log.Logger
+}
+
+// NewChatAttachmentHandler creates a new chat attachment handler
+func NewChatAttachmentHandler(attachmentService *services.ChatAttachmentService, logger *log.Logger) *ChatAttachmentHandler {
+	return &ChatAttachmentHandler{
+		attachmentService: attachmentService,
+		logger:            logger,
+	}
+}
+
+// UploadAttachment stores an image to attach to a chat message
+// @Summary Upload a chat image
+// @Description Upload an image, e.g. a screenshot of an error, then send its ID as attachment_id with a message to /ai/chat.
+// @Description The image is sent to the provider with the message, so the provider's model must support images (e.g. gpt-4o, Gemini).
+// @Tags ai-chat
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "PNG, JPEG, WebP or GIF image"
+// @Param user_id formData string true "User attaching the image"
+// @Success 201 {object} utils.APIResponse{data=models.ChatAttachment}
+// @Failure 400 {object} utils.APIResponse
+// @Router /ai/attachments [post]
+func (h *ChatAttachmentHandler) UploadAttachment(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.FormValue("user_id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user_id")
+	}
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "image is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.logger.Printf("Error opening chat attachment: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to process image")
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		h.logger.Printf("Error reading chat attachment: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to read image")
+	}
+
+	attachment, err := h.attachmentService.Upload(c.UserContext(), userID, fileHeader.Filename, data)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(attachment))
+}
+
+// GetAttachment returns an attached image
+// @Summary Download a chat image
+// @Tags ai-chat
+// @Produce image/png,image/jpeg,image/webp,image/gif
+// @Param id path string true "Attachment ID"
+// @Param user_id query string true "User who uploaded the image"
+// @Success 200 {file} binary
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /ai/attachments/{id} [get]
+func (h *ChatAttachmentHandler) GetAttachment(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid attachment ID")
+	}
+	userID, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user_id")
+	}
+
+	_, image, err := h.attachmentService.Image(c.UserContext(), id, userID)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, image.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	return c.Send(image.Data)
+}
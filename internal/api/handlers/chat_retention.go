@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ChatRetentionHandler lets admins manage and run the chat retention policies
+type ChatRetentionHandler struct {
+	retentionService *services.ChatRetentionService
+	logger           *log.Logger
+}
+
+// NewChatRetentionHandler creates a new chat retention handler
+func NewChatRetentionHandler(retentionService *services.ChatRetentionService, logger *log.Logger) *ChatRetentionHandler {
+	return &ChatRetentionHandler{
+		retentionService: retentionService,
+		logger:           logger,
+	}
+}
+
+// CreateRetentionPolicyRequest creates a chat retention policy
+type CreateRetentionPolicyRequest struct {
+	Name               string                 `json:"name" example:"Delete transcripts after 12 months"`
+	Team               string                 `json:"team,omitempty" example:"support"`
+	MaxAgeMonths       int                    `json:"max_age_months" example:"12"`
+	Action             models.RetentionAction `json:"action" example:"delete"`
+	ExportBeforeDelete bool                   `json:"export_before_delete" example:"true"`
+	AdminID            string                 `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// RetentionAdminRequest identifies the admin acting on a retention policy
+type RetentionAdminRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// ListPolicies lists the chat retention policies
+// @Summary List chat retention policies
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{policies=[]models.ChatRetentionPolicy}}
+// @Failure 500 {object} utils.APIResponse
+// @ID listRetentionPolicies
+// @Router /admin/retention/policies [get]
+func (h *ChatRetentionHandler) ListPolicies(c *fiber.Ctx) error {
+	policies, err := h.retentionService.ListPolicies()
+	if err != nil {
+		h.logger.Printf("Error listing chat retention policies: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list retention policies")
+	}
+	return utils.SendSuccess(c, fiber.Map{"policies": policies})
+}
+
+// CreatePolicy creates a chat retention policy
+// @Summary Create a chat retention policy
+// @Description Archive (soft delete) or permanently delete chat sessions without a message in the last max_age_months,
+// @Description for every user or the members of a team. Policies for every user that delete also remove tracked chat logs
+// @Description and idle OpenAI assistant threads. Active policies are applied on a schedule.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateRetentionPolicyRequest true "Retention policy"
+// @Success 201 {object} utils.APIResponse{data=models.ChatRetentionPolicy}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/retention/policies [post]
+func (h *ChatRetentionHandler) CreatePolicy(c *fiber.Ctx) error {
+	var req CreateRetentionPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+
+	policy := &models.ChatRetentionPolicy{
+		Name:               req.Name,
+		Team:               req.Team,
+		MaxAgeMonths:       req.MaxAgeMonths,
+		Action:             req.Action,
+		ExportBeforeDelete: req.ExportBeforeDelete,
+		IsActive:           true,
+	}
+	if err := h.retentionService.CreatePolicy(policy, adminID); err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(policy))
+}
+
+// DeletePolicy deletes a chat retention policy
+// @Summary Delete a chat retention policy
+// @Tags admin
+// @Accept json
+// @Param id path string true "Policy ID"
+// @Param request body RetentionAdminRequest true "Admin"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/retention/policies/{id} [delete]
+func (h *ChatRetentionHandler) DeletePolicy(c *fiber.Ctx) error {
+	id, adminID, err := parseRetentionAdminRequest(c)
+	if err != nil {
+		return err
+	}
+	if err := h.retentionService.DeletePolicy(id, adminID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RunPolicy applies a chat retention policy
+// @Summary Run a chat retention policy
+// @Description A dry run (the default) returns what the policy would remove; dry_run=false queues a job that applies it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Param dry_run query bool false "Only report what would be removed" default(true)
+// @Param request body RetentionAdminRequest true "Admin"
+// @Success 200 {object} utils.APIResponse{data=services.RetentionReport}
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/retention/policies/{id}/run [post]
+func (h *ChatRetentionHandler) RunPolicy(c *fiber.Ctx) error {
+	id, adminID, err := parseRetentionAdminRequest(c)
+	if err != nil {
+		return err
+	}
+
+	if c.QueryBool("dry_run", true) {
+		report, err := h.retentionService.PreviewPolicy(c.Context(), id, adminID)
+		if err != nil {
+			return err
+		}
+		return utils.SendSuccess(c, report)
+	}
+
+	job, err := h.retentionService.ScheduleRun(c.Context(), id, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Chat retention run queued", JobID: job.ID}))
+}
+
+func parseRetentionAdminRequest(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid policy ID")
+	}
+	var req RetentionAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return id, adminID, nil
+}
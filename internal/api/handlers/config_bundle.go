@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConfigBundleHandler exports and imports the configuration of an environment
+type ConfigBundleHandler struct {
+	configService *services.ConfigBundleService
+	logger        *log.Logger
+}
+
+// NewConfigBundleHandler creates a new configuration bundle handler
+func NewConfigBundleHandler(configService *services.ConfigBundleService, logger *log.Logger) *ConfigBundleHandler {
+	return &ConfigBundleHandler{
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// ImportConfigRequest imports a configuration bundle
+type ImportConfigRequest struct {
+	AdminID string                 `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	Bundle  *services.ConfigBundle `json:"bundle"`
+}
+
+// ExportConfig exports the configuration as a bundle
+// @Summary Export the configuration
+// @Description Retrieval presets, category presets, templates, active prompts, chat retention policies, the organization
+// @Description quota and help screen texts, identified by name so the bundle can be imported into another environment.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.ConfigBundle}
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/config/export [get]
+func (h *ConfigBundleHandler) ExportConfig(c *fiber.Ctx) error {
+	bundle, err := h.configService.ExportConfig()
+	if err != nil {
+		h.logger.Printf("Error exporting configuration: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to export configuration")
+	}
+	c.Attachment("config-bundle-" + bundle.ExportedAt.Format("20060102-150405") + ".json")
+	return utils.SendSuccess(c, bundle)
+}
+
+// ImportConfig imports a configuration bundle
+// @Summary Import a configuration bundle
+// @Description Creates and updates the records of a bundle exported from another environment, in one transaction.
+// @Description Nothing missing from the bundle is deleted. A dry run (the default) reports the changes without keeping them.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Only report what would change" default(true)
+// @Param request body ImportConfigRequest true "Bundle and admin"
+// @Success 200 {object} utils.APIResponse{data=services.ConfigImportReport}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/config/import [post]
+func (h *ConfigBundleHandler) ImportConfig(c *fiber.Ctx) error {
+	var req ImportConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+	if req.Bundle == nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "bundle is required")
+	}
+
+	report, err := h.configService.ImportConfig(c.Context(), req.Bundle, adminID, c.QueryBool("dry_run", true))
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, report)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConfluenceHandler exposes the Confluence space connector
+type ConfluenceHandler struct {
+	confluenceService *services.ConfluenceService
+	logger            *log.Logger
+}
+
+// NewConfluenceHandler creates a new Confluence integration handler
+func NewConfluenceHandler(confluenceService *services.ConfluenceService, logger *log.Logger) *ConfluenceHandler {
+	return &ConfluenceHandler{
+		confluenceService: confluenceService,
+		logger:            logger,
+	}
+}
+
+// GetStatus reports the Confluence integration settings and sync progress
+// @Summary Get Confluence sync status
+// @Description The configured space and schedule, how many of its pages are synced into knowledge entries, and how many failed on their last sync
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.ConfluenceStatus}
+// @Failure 500 {object} utils.APIResponse
+// @ID getConfluenceStatus
+// @Router /integrations/confluence [get]
+func (h *ConfluenceHandler) GetStatus(c *fiber.Ctx) error {
+	status, err := h.confluenceService.Status(c.UserContext())
+	if err != nil {
+		h.logger.Printf("Error getting Confluence sync status: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to get Confluence sync status")
+	}
+	return utils.SendSuccess(c, status)
+}
+
+// Sync queues a sync of the Confluence space
+// @Summary Sync the Confluence space
+// @Description Queue a job that creates entries for new pages, replaces the entries of pages with a newer version, and deletes the entries of removed pages
+// @Tags integrations
+// @Produce json
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @ID syncConfluence
+// @Router /integrations/confluence/sync [post]
+func (h *ConfluenceHandler) Sync(c *fiber.Ctx) error {
+	job, err := h.confluenceService.ScheduleSync(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Confluence sync queued", JobID: job.ID}))
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"time"
 	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -12,9 +14,22 @@ func LogAPICall(db *gorm.DB, apiName string) {
 	db.Create(&models.APICallLog{APIName: apiName, CalledAt: time.Now()})
 }
 
-func GetContextDashboard(db *gorm.DB) fiber.Handler {
+// GetContextDashboard serves the dashboard; its read queries go to reads when read routing is enabled
+// @Summary Context dashboard
+// @Description Totals, topic trends, question distribution by time and the context files
+// @Tags dashboard
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object}
+// @Router /context-dashboard [get]
+func GetContextDashboard(primary *gorm.DB, reads services.ReadReplicaRouter) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		LogAPICall(db, "GetContextDashboard")
+		LogAPICall(primary, "GetContextDashboard")
+		db := primary
+		if reads != nil {
+			if replica := reads.Reader(); replica != nil {
+				db = replica
+			}
+		}
 
 		// Total Context Files
 		var totalFiles int64
@@ -68,7 +83,7 @@ func GetContextDashboard(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		return c.JSON(fiber.Map{
+		return utils.SendSuccess(c, fiber.Map{
 			"total_files": totalFiles,
 			"total_topics": totalTopics,
 			"most_attractive_topic": mostAttractiveTopic.Name,
@@ -7,20 +7,22 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"tic-knowledge-system/internal/models"
 	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 )
 
-// DocumentHandler handles document-related API endpoints
+// DocumentHandler imports documents on the server's disk into the knowledge base
 type DocumentHandler struct {
-	documentService *services.DocumentService
-	logger          *log.Logger
+	ingestion *services.IngestionService
+	logger    *log.Logger
 }
 
 // NewDocumentHandler creates a new document handler
-func NewDocumentHandler(documentService *services.DocumentService, logger *log.Logger) *DocumentHandler {
+func NewDocumentHandler(ingestion *services.IngestionService, logger *log.Logger) *DocumentHandler {
 	return &DocumentHandler{
-		documentService: documentService,
-		logger:          logger,
+		ingestion: ingestion,
+		logger:    logger,
 	}
 }
 
@@ -29,55 +31,51 @@ type ProcessDocumentRequest struct {
 	FilePath     string `json:"file_path" example:"./file/WB.docx"`
 	CategoryName string `json:"category_name" example:"Work Procedures"`
 	UserID       string `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Async        bool   `json:"async" example:"false"` // Process in the background job queue; follow it with GET /documents/{id}/status
+	DryRun       bool   `json:"dry_run" example:"false"` // Only return the entries the import would create, to confirm with POST /documents/process/confirm
 }
 
 // ProcessDocumentResponse represents the response for document processing
 type ProcessDocumentResponse struct {
-	Success      bool                             `json:"success"`
 	Message      string                           `json:"message"`
 	Result       *services.DocumentParseResult    `json:"result,omitempty"`
-	Error        string                           `json:"error,omitempty"`
+	DocumentID   string                           `json:"document_id,omitempty"`
+	Status       string                           `json:"status,omitempty"`
+	Preview      *services.DocumentPreview        `json:"preview,omitempty"`
 }
 
 // ParseDocumentResponse represents the response for document parsing only
 type ParseDocumentResponse struct {
-	Success bool                             `json:"success"`
 	Message string                           `json:"message"`
 	Result  *services.DocumentParseResult    `json:"result,omitempty"`
-	Error   string                           `json:"error,omitempty"`
 }
 
 // ProcessDocument processes a document (parse + save to knowledge base)
 // @Summary Process a document file
-// @Description Parse a DOCX document and save it to the knowledge base
+// @Description Parse a document (DOCX, text, Markdown or HTML) and save its sections to the knowledge base.
+// @Description Documents with instruction-like content are quarantined for admin review instead.
+// @Description With dry_run set nothing is saved: the proposed entries are returned as a preview, which can be edited and saved with POST /documents/process/confirm.
 // @Tags documents
 // @Accept json
 // @Produce json
 // @Param request body ProcessDocumentRequest true "Document processing request"
-// @Success 200 {object} ProcessDocumentResponse
-// @Failure 400 {object} ProcessDocumentResponse
-// @Failure 500 {object} ProcessDocumentResponse
-// @Router /api/documents/process [post]
+// @Success 200 {object} utils.APIResponse{data=ProcessDocumentResponse}
+// @Success 202 {object} utils.APIResponse{data=ProcessDocumentResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/process [post]
 func (dh *DocumentHandler) ProcessDocument(c *fiber.Ctx) error {
 	dh.logger.Printf("Received document processing request")
 	
 	var req ProcessDocumentRequest
 	if err := c.BodyParser(&req); err != nil {
 		dh.logger.Printf("Error parsing request body: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(ProcessDocumentResponse{
-			Success: false,
-			Message: "Invalid request format",
-			Error:   err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request format", err.Error())
 	}
 	
 	// Validate required fields
 	if req.FilePath == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ProcessDocumentResponse{
-			Success: false,
-			Message: "File path is required",
-			Error:   "file_path cannot be empty",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "File path is required", "file_path cannot be empty")
 	}
 	
 	if req.CategoryName == "" {
@@ -89,74 +87,128 @@ func (dh *DocumentHandler) ProcessDocument(c *fiber.Ctx) error {
 	}
 	
 	// Validate user ID format
-	if _, err := uuid.Parse(req.UserID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ProcessDocumentResponse{
-			Success: false,
-			Message: "Invalid user ID format",
-			Error:   "user_id must be a valid UUID",
-		})
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID format", "user_id must be a valid UUID")
 	}
 	
+	if req.DryRun {
+		preview, err := dh.ingestion.PreviewFile(c.Context(), req.FilePath, req.CategoryName)
+		if err != nil {
+			dh.logger.Printf("Error previewing document: %v", err)
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to preview document", err.Error())
+		}
+		return utils.SendSuccess(c, ProcessDocumentResponse{
+			Message: fmt.Sprintf("Dry run: %d knowledge entries would be created in category '%s'", len(preview.Entries), preview.Category),
+			Preview: preview,
+		})
+	}
+
 	dh.logger.Printf("Processing document: %s, Category: %s, User: %s", req.FilePath, req.CategoryName, req.UserID)
 	
-	// Process the document
-	result, err := dh.documentService.ProcessDocument(req.FilePath, req.CategoryName, req.UserID)
+	document, result, err := dh.ingestion.ImportFile(c.Context(), req.FilePath, req.CategoryName, userID, req.Async)
 	if err != nil {
 		dh.logger.Printf("Error processing document: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ProcessDocumentResponse{
-			Success: false,
-			Message: "Failed to process document",
-			Error:   err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to process document", err.Error())
 	}
 	
-	dh.logger.Printf("Document processed successfully: %s", req.FilePath)
-	
-	return c.Status(fiber.StatusOK).JSON(ProcessDocumentResponse{
-		Success: true,
-		Message: fmt.Sprintf("Document processed successfully. Created %d knowledge entries.", len(result.KnowledgeIDs)),
-		Result:  result,
-	})
+	return documentImportResponse(c, document, result, "Document processed successfully")
+}
+
+// ConfirmDocumentRequest is a preview returned by a dry run of POST /documents/process, with any edits to its entries
+type ConfirmDocumentRequest struct {
+	Preview services.DocumentPreview `json:"preview"`
+	UserID  string                   `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// ConfirmDocument saves a previewed document to the knowledge base
+// @Summary Confirm a document preview
+// @Description Save the entries of a preview returned by POST /documents/process with dry_run, as edited by the caller, to the knowledge base.
+// @Description Entries may be merged, split, retitled, recategorized or retagged; the file must not have changed since the preview.
+// @Description Documents with instruction-like content are quarantined for admin review instead.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body ConfirmDocumentRequest true "Previewed document"
+// @Success 200 {object} utils.APIResponse{data=ProcessDocumentResponse}
+// @Success 202 {object} utils.APIResponse{data=ProcessDocumentResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/process/confirm [post]
+func (dh *DocumentHandler) ConfirmDocument(c *fiber.Ctx) error {
+	var req ConfirmDocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request format", err.Error())
+	}
+	if req.UserID == "" {
+		req.UserID = uuid.New().String()
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID format", "user_id must be a valid UUID")
+	}
+
+	document, result, err := dh.ingestion.ConfirmPreview(c.Context(), &req.Preview, userID)
+	if err != nil {
+		dh.logger.Printf("Error confirming document preview: %v", err)
+		return err
+	}
+	return documentImportResponse(c, document, result, "Document processed successfully")
+}
+
+// documentImportResponse reports an import that was scheduled, quarantined or completed
+func documentImportResponse(c *fiber.Ctx, document *models.UploadedDocument, result *services.DocumentParseResult, completed string) error {
+	response := ProcessDocumentResponse{
+		DocumentID: document.ID.String(),
+		Status:     string(document.Status),
+	}
+	switch {
+	case document.Status == models.DocumentQuarantined:
+		response.Message = "Document quarantined: it contains instruction-like content and needs admin approval before it is imported"
+		return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(response))
+	case result == nil:
+		response.Message = "Document processing scheduled"
+		return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(response))
+	}
+	response.Message = fmt.Sprintf("%s. Created %d knowledge entries in category '%s'.", completed, len(result.KnowledgeIDs), document.Category)
+	if document.SupersedesID != nil {
+		diff := document.Sections
+		response.Message = fmt.Sprintf("%s. Updated the entries of the earlier version in category '%s': %d sections added, %d changed, %d unchanged, %d removed.",
+			completed, document.Category, diff.Added, diff.Changed, diff.Unchanged, diff.Removed)
+	}
+	response.Result = result
+	return utils.SendSuccess(c, response)
 }
 
 // ParseDocument parses a document without saving to knowledge base
 // @Summary Parse a document file
-// @Description Parse a DOCX document and return structured content without saving
+// @Description Parse a document (DOCX, text, Markdown or HTML) and return its sections without saving
 // @Tags documents
 // @Accept json
 // @Produce json
 // @Param file_path query string true "Path to the document file"
-// @Success 200 {object} ParseDocumentResponse
-// @Failure 400 {object} ParseDocumentResponse
-// @Failure 500 {object} ParseDocumentResponse
-// @Router /api/documents/parse [get]
+// @Success 200 {object} utils.APIResponse{data=ParseDocumentResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/parse [get]
 func (dh *DocumentHandler) ParseDocument(c *fiber.Ctx) error {
 	filePath := c.Query("file_path")
 	if filePath == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ParseDocumentResponse{
-			Success: false,
-			Message: "File path is required",
-			Error:   "file_path parameter is missing",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "File path is required", "file_path parameter is missing")
 	}
 	
 	dh.logger.Printf("Parsing document: %s", filePath)
 	
 	// Parse the document
-	result, err := dh.documentService.ParseDOCXFile(filePath)
+	result, err := dh.ingestion.ParseFile(c.Context(), filePath)
 	if err != nil {
 		dh.logger.Printf("Error parsing document: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ParseDocumentResponse{
-			Success: false,
-			Message: "Failed to parse document",
-			Error:   err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to parse document", err.Error())
 	}
 	
 	dh.logger.Printf("Document parsed successfully: %s", filePath)
 	
-	return c.Status(fiber.StatusOK).JSON(ParseDocumentResponse{
-		Success: true,
+	return utils.SendSuccess(c, ParseDocumentResponse{
 		Message: fmt.Sprintf("Document parsed successfully. Found %d sections.", len(result.Sections)),
 		Result:  result,
 	})
@@ -170,10 +222,11 @@ func (dh *DocumentHandler) ParseDocument(c *fiber.Ctx) error {
 // @Produce json
 // @Param category_name query string false "Category name for the document" default:"Work Procedures"
 // @Param user_id query string false "User ID (UUID format)"
-// @Success 200 {object} ProcessDocumentResponse
-// @Failure 400 {object} ProcessDocumentResponse
-// @Failure 500 {object} ProcessDocumentResponse
-// @Router /api/documents/process-wb [post]
+// @Success 200 {object} utils.APIResponse{data=ProcessDocumentResponse}
+// @Success 202 {object} utils.APIResponse{data=ProcessDocumentResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/process-wb [post]
 func (dh *DocumentHandler) ProcessWBDocument(c *fiber.Ctx) error {
 	dh.logger.Printf("Processing WB.docx document")
 	
@@ -187,12 +240,9 @@ func (dh *DocumentHandler) ProcessWBDocument(c *fiber.Ctx) error {
 	}
 	
 	// Validate user ID format
-	if _, err := uuid.Parse(userID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ProcessDocumentResponse{
-			Success: false,
-			Message: "Invalid user ID format",
-			Error:   "user_id must be a valid UUID",
-		})
+	uploadedBy, err := uuid.Parse(userID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID format", "user_id must be a valid UUID")
 	}
 	
 	// Convert to absolute path
@@ -205,21 +255,89 @@ func (dh *DocumentHandler) ProcessWBDocument(c *fiber.Ctx) error {
 	dh.logger.Printf("Processing WB.docx at: %s", absPath)
 	
 	// Process the document
-	result, err := dh.documentService.ProcessDocument(absPath, categoryName, userID)
+	document, result, err := dh.ingestion.ImportFile(c.Context(), absPath, categoryName, uploadedBy, false)
 	if err != nil {
 		dh.logger.Printf("Error processing WB.docx: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ProcessDocumentResponse{
-			Success: false,
-			Message: "Failed to process WB.docx document",
-			Error:   err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to process WB.docx document", err.Error())
 	}
 	
 	dh.logger.Printf("WB.docx processed successfully")
 	
-	return c.Status(fiber.StatusOK).JSON(ProcessDocumentResponse{
-		Success: true,
-		Message: fmt.Sprintf("WB.docx processed successfully. Created %d knowledge entries in category '%s'.", len(result.KnowledgeIDs), categoryName),
-		Result:  result,
+	return documentImportResponse(c, document, result, "WB.docx processed successfully")
+}
+
+// IngestURLRequest is a web page or sitemap to crawl into the knowledge base
+type IngestURLRequest struct {
+	URL          string `json:"url" example:"https://docs.example.com/sitemap.xml"`
+	Sitemap      bool   `json:"sitemap" example:"true"` // Crawl the pages the sitemap lists on the same host
+	CategoryName string `json:"category_name" example:"Product Docs"`
+	UserID       string `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RecrawlHours int    `json:"recrawl_hours" example:"24"` // Re-crawl on this schedule to keep the entries fresh; 0 crawls once
+}
+
+// IngestURL crawls a web page or sitemap into the knowledge base
+// @Summary Ingest a web page or sitemap
+// @Description Fetch a URL, or every page of a sitemap, strip navigation and other page chrome, and save the text as knowledge entries.
+// @Description Each entry stores its source URL in field_data. The crawl runs in the background; follow it with GET /documents/web-sources/{id}.
+// @Description With recrawl_hours set the pages are fetched again on that schedule and entries of changed pages replaced. Submitting a URL again updates its settings.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body IngestURLRequest true "URL ingestion request"
+// @Success 202 {object} utils.APIResponse{data=models.WebSource}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/ingest-url [post]
+func (dh *DocumentHandler) IngestURL(c *fiber.Ctx) error {
+	var req IngestURLRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request format")
+	}
+	if req.URL == "" {
+		return utils.SendError(c, fiber.StatusBadRequest, "url is required")
+	}
+
+	userID := uuid.New()
+	if req.UserID != "" {
+		var err error
+		if userID, err = uuid.Parse(req.UserID); err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "user_id must be a valid UUID")
+		}
+	}
+
+	source, err := dh.ingestion.IngestURL(c.UserContext(), services.WebIngestRequest{
+		URL:          req.URL,
+		Sitemap:      req.Sitemap,
+		Category:     req.CategoryName,
+		RecrawlHours: req.RecrawlHours,
+		CreatedBy:    userID,
 	})
-}
\ No newline at end of file
+	if err != nil {
+		return err
+	}
+	dh.logger.Printf("Scheduled crawl of %s (web source %s)", source.URL, source.ID)
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(source))
+}
+
+// GetWebSource returns the crawl status of a web source
+// @Summary Get web source crawl status
+// @Description The status of the last crawl and every crawled page with its knowledge entries and any error
+// @Tags documents
+// @Produce json
+// @Param id path string true "Web source ID"
+// @Success 200 {object} utils.APIResponse{data=models.WebSource}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /documents/web-sources/{id} [get]
+func (dh *DocumentHandler) GetWebSource(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid web source ID")
+	}
+
+	source, err := dh.ingestion.GetWebSource(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, source)
+}
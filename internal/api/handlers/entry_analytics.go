@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultAnalyticsDays is the period reported when no since date is given
+const defaultAnalyticsDays = 30
+
+// EntryAnalyticsHandler reports how knowledge entries are found and used
+type EntryAnalyticsHandler struct {
+	analyticsService *services.EntryAnalyticsService
+	logger           *log.Logger
+}
+
+// NewEntryAnalyticsHandler creates a new entry analytics handler
+func NewEntryAnalyticsHandler(analyticsService *services.EntryAnalyticsService, logger *log.Logger) *EntryAnalyticsHandler {
+	return &EntryAnalyticsHandler{
+		analyticsService: analyticsService,
+		logger:           logger,
+	}
+}
+
+// GetTopEntries returns the most viewed knowledge entries
+// @Summary Get most viewed entries
+// @Description Rank knowledge entries by views, with the views from search, chat citations and direct links
+// @Tags analytics
+// @Produce json
+// @Param since query string false "Only count views since this date (YYYY-MM-DD)" default(30 days ago)
+// @Param source query string false "Only count views from search, chat or direct"
+// @Param limit query int false "Limit number of results" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]services.EntryViewStats}
+// @Failure 400 {object} utils.APIResponse
+// @Router /analytics/entries/top [get]
+func (h *EntryAnalyticsHandler) GetTopEntries(c *fiber.Ctx) error {
+	since, limit, err := analyticsWindow(c)
+	if err != nil {
+		return err
+	}
+	source, err := viewSourceFilter(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.analyticsService.TopEntries(c.UserContext(), since, source, limit)
+	if err != nil {
+		h.logger.Printf("Error ranking knowledge entries by views: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to rank knowledge entries")
+	}
+	return utils.SendSuccess(c, entries)
+}
+
+// GetUnclickedEntries returns the entries chat answers are based on that nobody opens
+// @Summary Get entries retrieved in chat but never opened
+// @Description List the knowledge entries that were sources of chat answers since a date without being opened from chat, most retrieved first
+// @Tags analytics
+// @Produce json
+// @Param since query string false "Only count chat answers and views since this date (YYYY-MM-DD)" default(30 days ago)
+// @Param limit query int false "Limit number of results" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]services.UnclickedEntry}
+// @Failure 400 {object} utils.APIResponse
+// @Router /analytics/entries/unclicked [get]
+func (h *EntryAnalyticsHandler) GetUnclickedEntries(c *fiber.Ctx) error {
+	since, limit, err := analyticsWindow(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.analyticsService.UnclickedChatEntries(c.UserContext(), since, limit)
+	if err != nil {
+		h.logger.Printf("Error finding unclicked knowledge entries: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to find unclicked knowledge entries")
+	}
+	return utils.SendSuccess(c, entries)
+}
+
+// GetZeroResultSearches returns the searches that found no knowledge entries
+// @Summary Get zero-result searches
+// @Description List the searches and chat questions that found no knowledge entries, most frequent first
+// @Tags analytics
+// @Produce json
+// @Param since query string false "Only count searches since this date (YYYY-MM-DD)" default(30 days ago)
+// @Param source query string false "Only count searches from search or chat"
+// @Param limit query int false "Limit number of results" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]services.ZeroResultQuery}
+// @Failure 400 {object} utils.APIResponse
+// @Router /analytics/searches/zero-results [get]
+func (h *EntryAnalyticsHandler) GetZeroResultSearches(c *fiber.Ctx) error {
+	since, limit, err := analyticsWindow(c)
+	if err != nil {
+		return err
+	}
+	source, err := viewSourceFilter(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := h.analyticsService.ZeroResultQueries(c.UserContext(), since, source, limit)
+	if err != nil {
+		h.logger.Printf("Error listing zero-result searches: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list zero-result searches")
+	}
+	return utils.SendSuccess(c, queries)
+}
+
+// GetUnansweredQuestions returns the questions the knowledge base did not answer, grouped by similarity
+// @Summary Get top unanswered questions
+// @Description Group the searches and chat questions that found no entries or whose answers were rated as unhelpful into clusters of similar questions, most asked first, for planning new content
+// @Tags analytics
+// @Produce json
+// @Param since query string false "Only count questions since this date (YYYY-MM-DD)" default(30 days ago)
+// @Param similarity query number false "Embedding similarity from which questions count as the same question" default(0.85)
+// @Param limit query int false "Limit number of clusters" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]services.UnansweredCluster}
+// @Failure 400 {object} utils.APIResponse
+// @Router /analytics/unanswered [get]
+func (h *EntryAnalyticsHandler) GetUnansweredQuestions(c *fiber.Ctx) error {
+	since, limit, err := analyticsWindow(c)
+	if err != nil {
+		return err
+	}
+	similarity, err := strconv.ParseFloat(c.Query("similarity", "0"), 64)
+	if err != nil || similarity < 0 || similarity > 1 {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid similarity parameter, expected a number from 0 to 1")
+	}
+	if similarity == 0 {
+		similarity = services.DefaultUnansweredSimilarity
+	}
+
+	clusters, err := h.analyticsService.UnansweredQuestions(c.UserContext(), since, similarity, limit)
+	if err != nil {
+		h.logger.Printf("Error clustering unanswered questions: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to cluster unanswered questions")
+	}
+	return utils.SendSuccess(c, clusters)
+}
+
+// analyticsWindow parses the since and limit query parameters of the analytics reports
+func analyticsWindow(c *fiber.Ctx) (time.Time, int, error) {
+	since := time.Now().AddDate(0, 0, -defaultAnalyticsDays)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			return time.Time{}, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid since parameter, expected YYYY-MM-DD")
+		}
+		since = parsed
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return since, limit, nil
+}
+
+// viewSourceFilter parses the optional source query parameter of the analytics reports
+func viewSourceFilter(c *fiber.Ctx) (models.ViewSource, error) {
+	source := models.ViewSource(c.Query("source"))
+	switch source {
+	case "", models.ViewFromSearch, models.ViewFromChat, models.ViewDirect:
+		return source, nil
+	}
+	return "", fiber.NewError(fiber.StatusBadRequest, "Invalid source parameter, expected search, chat or direct")
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ExperimentHandler exposes the golden question set and the prompt experiment runs compared against it
+type ExperimentHandler struct {
+	experimentService *services.ExperimentService
+	logger            *log.Logger
+}
+
+// NewExperimentHandler creates a new prompt experiment handler
+func NewExperimentHandler(experimentService *services.ExperimentService, logger *log.Logger) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+		logger:            logger,
+	}
+}
+
+// CreateGoldenQuestionRequest adds a question to the golden set
+type CreateGoldenQuestionRequest struct {
+	Question  string `json:"question" example:"How do I reset a customer's password?"`
+	Notes     string `json:"notes,omitempty"`
+	CreatedBy string `json:"created_by,omitempty" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// StartExperimentRequest selects what an experiment run tries out
+type StartExperimentRequest struct {
+	PromptID      string  `json:"prompt_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Provider      string  `json:"provider,omitempty" example:"openai"`
+	Model         string  `json:"model,omitempty" example:"gpt-4o-mini"`
+	BaselineRunID string  `json:"baseline_run_id,omitempty"`
+	Threshold     float64 `json:"threshold,omitempty" example:"0.85"`
+	CreatedBy     string  `json:"created_by,omitempty" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// ListQuestions lists the golden questions
+// @Summary List golden questions
+// @Description The fixed question set every experiment run answers
+// @Tags experiments
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]models.GoldenQuestion}
+// @Failure 500 {object} utils.APIResponse
+// @Router /experiments/questions [get]
+func (h *ExperimentHandler) ListQuestions(c *fiber.Ctx) error {
+	questions, err := h.experimentService.ListQuestions()
+	if err != nil {
+		h.logger.Printf("Error listing golden questions: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list questions")
+	}
+	return utils.SendSuccess(c, questions)
+}
+
+// CreateQuestion adds a golden question
+// @Summary Create a golden question
+// @Description Runs answer it from then on; it is compared once a golden run has answered it
+// @Tags experiments
+// @Accept json
+// @Produce json
+// @Param request body CreateGoldenQuestionRequest true "Question"
+// @Success 201 {object} utils.APIResponse{data=models.GoldenQuestion}
+// @Failure 400 {object} utils.APIResponse
+// @Router /experiments/questions [post]
+func (h *ExperimentHandler) CreateQuestion(c *fiber.Ctx) error {
+	var req CreateGoldenQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	createdBy, err := parseOptionalUUID(req.CreatedBy)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid created_by")
+	}
+
+	question := &models.GoldenQuestion{
+		Question:  req.Question,
+		Notes:     req.Notes,
+		CreatedBy: createdBy,
+	}
+	if err := h.experimentService.CreateQuestion(question); err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(question))
+}
+
+// DeleteQuestion removes a golden question
+// @Summary Delete a golden question
+// @Tags experiments
+// @Param id path string true "Question ID"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /experiments/questions/{id} [delete]
+func (h *ExperimentHandler) DeleteQuestion(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid question ID")
+	}
+	if err := h.experimentService.DeleteQuestion(id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// StartRun queues an experiment run
+// @Summary Run a prompt experiment
+// @Description Answer every golden question with a prompt version (the active prompts by default) and provider at temperature 0,
+// @Description and compare each answer by embedding similarity with the answer of the baseline run, by default the golden run of
+// @Description the provider. Poll the run until it is completed: it passed when no answer is less similar than the threshold.
+// @Tags experiments
+// @Accept json
+// @Produce json
+// @Param request body StartExperimentRequest true "Experiment"
+// @Success 202 {object} utils.APIResponse{data=object{message=string,run=models.ExperimentRun,job_id=string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @ID startExperimentRun
+// @Router /experiments/runs [post]
+func (h *ExperimentHandler) StartRun(c *fiber.Ctx) error {
+	var req StartExperimentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	spec := services.ExperimentSpec{
+		Provider:  services.AIProvider(req.Provider),
+		Model:     req.Model,
+		Threshold: req.Threshold,
+	}
+	var err error
+	if spec.PromptID, err = parseOptionalUUID(req.PromptID); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid prompt_id")
+	}
+	if spec.BaselineRunID, err = parseOptionalUUID(req.BaselineRunID); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid baseline_run_id")
+	}
+	if spec.CreatedBy, err = parseOptionalUUID(req.CreatedBy); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid created_by")
+	}
+
+	run, job, err := h.experimentService.StartRun(c.UserContext(), spec)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(fiber.Map{
+		"message": "Experiment queued",
+		"run":     run,
+		"job_id":  job.ID,
+	}))
+}
+
+// ListRuns lists recent experiment runs
+// @Summary List experiment runs
+// @Tags experiments
+// @Produce json
+// @Param limit query int false "Limit number of runs" default(30)
+// @Success 200 {object} utils.APIResponse{data=object{runs=[]models.ExperimentRun,count=int}}
+// @Failure 500 {object} utils.APIResponse
+// @ID listExperimentRuns
+// @Router /experiments/runs [get]
+func (h *ExperimentHandler) ListRuns(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 30)
+	if limit <= 0 || limit > 365 {
+		limit = 30
+	}
+
+	runs, err := h.experimentService.ListRuns(limit)
+	if err != nil {
+		h.logger.Printf("Error listing experiment runs: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list runs")
+	}
+	return utils.SendSuccess(c, fiber.Map{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// GetRun returns an experiment run with its answers
+// @Summary Get an experiment run
+// @Description The run with every answer next to its baseline answer, regressions first
+// @Tags experiments
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} utils.APIResponse{data=models.ExperimentRun}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @ID getExperimentRun
+// @Router /experiments/runs/{id} [get]
+func (h *ExperimentHandler) GetRun(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid run ID")
+	}
+	run, err := h.experimentService.GetRun(id)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, run)
+}
+
+// PromoteRun makes a run the golden snapshot of its provider
+// @Summary Promote a run to golden
+// @Description Later runs of the provider are compared with the answers of this run. Promote the run of a prompt change once it is accepted.
+// @Tags experiments
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} utils.APIResponse{data=models.ExperimentRun}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /experiments/runs/{id}/golden [post]
+func (h *ExperimentHandler) PromoteRun(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid run ID")
+	}
+	run, err := h.experimentService.PromoteRun(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, run)
+}
+
+// parseOptionalUUID parses an ID that may be left empty
+func parseOptionalUUID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -1,23 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"tic-knowledge-system/internal/models"
 	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 )
 
 type FileUploadHandler struct {
-	uploadService *services.FileUploadService
+	uploadService *services.IngestionService
 	db            *gorm.DB
 	logger        *log.Logger
 }
 
-func NewFileUploadHandler(uploadService *services.FileUploadService, db *gorm.DB, logger *log.Logger) *FileUploadHandler {
+func NewFileUploadHandler(uploadService *services.IngestionService, db *gorm.DB, logger *log.Logger) *FileUploadHandler {
 	return &FileUploadHandler{
 		uploadService: uploadService,
 		db:            db,
@@ -27,41 +30,39 @@ func NewFileUploadHandler(uploadService *services.FileUploadService, db *gorm.DB
 
 // UploadDocument handles file upload to OpenAI and vector store
 // @Summary Upload document file
-// @Description Upload a document file, store it locally, then upload to OpenAI and add to vector store
+// @Description Upload a document file, store it locally, then upload to OpenAI and add to vector store. Documents containing instruction-like content are quarantined until an admin approves them.
+// @Description With target knowledge_base the document is split into knowledge entries instead. Spreadsheets (.xlsx, .csv) default to
+// @Description the knowledge base and get one entry per table, or per block of rows with the header row repeated.
 // @Tags documents
 // @Accept multipart/form-data
 // @Produce json
 // @Param file_name formData string true "File name"
 // @Param file formData file true "Document file"
-// @Success 200 {object} services.DocumentUploadResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param target formData string false "vector_store or knowledge_base"
+// @Param category formData string false "Category of the knowledge entries of a knowledge base import"
+// @Success 200 {object} utils.APIResponse{data=services.DocumentUploadResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /documents/upload [post]
 func (h *FileUploadHandler) UploadDocument(c *fiber.Ctx) error {
 	// Get file name from form
 	fileName := c.FormValue("file_name")
 	if fileName == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "file_name is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "file_name is required")
 	}
 
 	// Get file from form
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		h.logger.Printf("Error getting file from form: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "file is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "file is required")
 	}
 
 	// Open and read file content
 	file, err := fileHeader.Open()
 	if err != nil {
 		h.logger.Printf("Error opening file: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process file",
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to process file")
 	}
 	defer file.Close()
 
@@ -70,23 +71,21 @@ func (h *FileUploadHandler) UploadDocument(c *fiber.Ctx) error {
 	_, err = file.Read(fileContent)
 	if err != nil {
 		h.logger.Printf("Error reading file content: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to read file content",
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to read file content")
 	}
 
 	// Create or get default user for uploads
 	uploadedBy, err := h.getOrCreateDefaultUser()
 	if err != nil {
 		h.logger.Printf("Error getting/creating default user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to set up user for upload",
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to set up user for upload")
 	}
 
 	// Create upload request
 	req := services.DocumentUploadRequest{
 		FileName: fileName,
+		Target:   models.IngestionTarget(c.FormValue("target")),
+		Category: c.FormValue("category"),
 	}
 
 	// Upload document
@@ -100,46 +99,110 @@ func (h *FileUploadHandler) UploadDocument(c *fiber.Ctx) error {
 	)
 
 	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			return err
+		}
 		h.logger.Printf("Error uploading document: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to upload document",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to upload document", err.Error())
 	}
 
-	return c.JSON(response)
+	return utils.SendSuccess(c, response)
+}
+
+// DocumentStatusResponse is an uploaded document with a temporary link to download its file
+type DocumentStatusResponse struct {
+	models.UploadedDocument
+	DownloadURL       string     `json:"download_url,omitempty"` // Presigned, only with object storage
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
 }
 
 // GetDocumentStatus gets the status of an uploaded document
 // @Summary Get document upload status
-// @Description Get the status of a document upload including OpenAI and vector store processing
+// @Description Get the status of a document upload including OpenAI and vector store processing.
+// @Description With object storage the response includes a presigned download URL.
 // @Tags documents
 // @Produce json
 // @Param id path string true "Document ID"
-// @Success 200 {object} models.UploadedDocument
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Success 200 {object} utils.APIResponse{data=DocumentStatusResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
 // @Router /documents/{id}/status [get]
 func (h *FileUploadHandler) GetDocumentStatus(c *fiber.Ctx) error {
 	// Parse document ID
 	idStr := c.Params("id")
 	documentID, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid document ID",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid document ID")
 	}
 
 	// Get document status
 	document, err := h.uploadService.GetDocumentStatus(c.Context(), documentID)
 	if err != nil {
 		h.logger.Printf("Error getting document status: %v", err)
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Document not found",
-		})
+		return utils.SendError(c, fiber.StatusNotFound, "Document not found")
+	}
+
+	response := DocumentStatusResponse{UploadedDocument: *document}
+	downloadURL, expiresAt, err := h.uploadService.DownloadURL(c.Context(), document)
+	if err == nil {
+		response.DownloadURL = downloadURL
+		response.DownloadExpiresAt = &expiresAt
+	} else if !errors.Is(err, services.ErrPresignUnsupported) {
+		h.logger.Printf("Error creating download URL for document %s: %v", documentID, err)
+	}
+
+	return utils.SendSuccess(c, response)
+}
+
+// DeleteDocument deletes an uploaded document
+// @Summary Delete an uploaded document
+// @Description Remove the document from the OpenAI vector store, delete its OpenAI file and stored file, then delete the record
+// @Tags documents
+// @Param id path string true "Document ID"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/{id} [delete]
+func (h *FileUploadHandler) DeleteDocument(c *fiber.Ctx) error {
+	documentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid document ID")
+	}
+
+	if err := h.uploadService.DeleteDocument(c.Context(), documentID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ResyncDocument uploads a document to OpenAI again
+// @Summary Resync a document with the vector store
+// @Description Replace the OpenAI file of a document and attach it to the vector store again, e.g. after processing failed
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 202 {object} utils.APIResponse{data=object{message=string,id=string,file_name=string,status=models.DocumentStatus}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents/{id}/resync [post]
+func (h *FileUploadHandler) ResyncDocument(c *fiber.Ctx) error {
+	documentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid document ID")
 	}
 
-	return c.JSON(document)
+	document, err := h.uploadService.ResyncDocument(c.Context(), documentID)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(fiber.Map{
+		"message":   "Document resync queued",
+		"id":        document.ID,
+		"file_name": document.FileName,
+		"status":    document.Status,
+	}))
 }
 
 // ListDocuments lists uploaded documents
@@ -148,10 +211,10 @@ func (h *FileUploadHandler) GetDocumentStatus(c *fiber.Ctx) error {
 // @Tags documents
 // @Accept json
 // @Produce json
-// @Param request body map[string]interface{} false "Request body with limit, offset, uploaded_by"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param request body object{limit=int,offset=int,uploaded_by=string} false "Pagination and uploader filter"
+// @Success 200 {object} utils.APIResponse{data=object{documents=[]models.UploadedDocument,total=int,limit=int,offset=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /documents [post]
 func (h *FileUploadHandler) ListDocuments(c *fiber.Ctx) error {
 	// Default values
@@ -195,15 +258,18 @@ func (h *FileUploadHandler) ListDocuments(c *fiber.Ctx) error {
 	}
 
 	// List documents
-	documents, total, err := h.uploadService.ListDocuments(c.Context(), uploadedBy, limit, offset)
+	documents, total, err := h.uploadService.ListDocuments(c.Context(), services.DocumentQuery{
+		UploadedBy: uploadedBy,
+		Desc:       true,
+		Limit:      limit,
+		Offset:     offset,
+	})
 	if err != nil {
 		h.logger.Printf("Error listing documents: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to list documents",
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list documents")
 	}
 
-	return c.JSON(fiber.Map{
+	return utils.SendSuccess(c, fiber.Map{
 		"documents": documents,
 		"total":     total,
 		"limit":     limit,
@@ -211,6 +277,63 @@ func (h *FileUploadHandler) ListDocuments(c *fiber.Ctx) error {
 	})
 }
 
+// SearchDocuments filters, sorts and pages the uploaded documents
+// @Summary Search uploaded documents
+// @Description Uploaded documents matching every given filter, newest first unless sorted otherwise.
+// @Tags documents
+// @Produce json
+// @Param q query string false "Substring of the file name, case-insensitive"
+// @Param status query string false "Document status, e.g. imported or quarantined"
+// @Param target query string false "Ingestion target: vector_store or knowledge_base"
+// @Param category query string false "Category of knowledge base imports"
+// @Param uploaded_by query string false "Uploader ID"
+// @Param since query string false "Uploaded on or after this date (YYYY-MM-DD)"
+// @Param until query string false "Uploaded on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "created_at, updated_at, file_name, file_size or status" default(created_at)
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Documents per page, at most 100" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]models.UploadedDocument,meta=utils.Meta}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /documents [get]
+func (h *FileUploadHandler) SearchDocuments(c *fiber.Ctx) error {
+	page, limit := utils.ParsePagination(c)
+	since, until, err := utils.ParseDateRange(c)
+	if err != nil {
+		return err
+	}
+	sort, desc, err := utils.ParseSort(c, "created_at")
+	if err != nil {
+		return err
+	}
+	query := services.DocumentQuery{
+		Status:   models.DocumentStatus(c.Query("status")),
+		Target:   models.IngestionTarget(c.Query("target")),
+		Category: c.Query("category"),
+		FileName: c.Query("q"),
+		Since:    since,
+		Until:    until,
+		Sort:     sort,
+		Desc:     desc,
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+	if uploadedBy := c.Query("uploaded_by"); uploadedBy != "" {
+		id, err := uuid.Parse(uploadedBy)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid uploaded_by parameter")
+		}
+		query.UploadedBy = &id
+	}
+
+	documents, total, err := h.uploadService.ListDocuments(c.Context(), query)
+	if err != nil {
+		return err
+	}
+	return utils.SendPaginated(c, documents, page, limit, int(total))
+}
+
 // getOrCreateDefaultUser creates or returns the default user for file uploads
 func (h *FileUploadHandler) getOrCreateDefaultUser() (uuid.UUID, error) {
 	defaultUserID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GoogleDriveHandler exposes the Google Drive folder connector
+type GoogleDriveHandler struct {
+	driveService *services.GoogleDriveService
+	logger       *log.Logger
+}
+
+// NewGoogleDriveHandler creates a new Google Drive integration handler
+func NewGoogleDriveHandler(driveService *services.GoogleDriveService, logger *log.Logger) *GoogleDriveHandler {
+	return &GoogleDriveHandler{
+		driveService: driveService,
+		logger:       logger,
+	}
+}
+
+// GetStatus reports the Google Drive integration settings and sync progress
+// @Summary Get Google Drive sync status
+// @Description The configured folder and schedule, how many of its files are ingested, and how many failed on their last sync
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.DriveStatus}
+// @Failure 500 {object} utils.APIResponse
+// @ID getGoogleDriveStatus
+// @Router /integrations/google-drive [get]
+func (h *GoogleDriveHandler) GetStatus(c *fiber.Ctx) error {
+	status, err := h.driveService.Status(c.UserContext())
+	if err != nil {
+		h.logger.Printf("Error getting Google Drive sync status: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to get Google Drive sync status")
+	}
+	return utils.SendSuccess(c, status)
+}
+
+// Sync queues a sync of the Google Drive folder
+// @Summary Sync the Google Drive folder
+// @Description Queue a job that ingests new PDF, DOCX and Google Docs files, re-ingests changed files in place of their previous document, and deletes the documents of files removed from the folder
+// @Tags integrations
+// @Produce json
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @ID syncGoogleDrive
+// @Router /integrations/google-drive/sync [post]
+func (h *GoogleDriveHandler) Sync(c *fiber.Ctx) error {
+	job, err := h.driveService.ScheduleSync(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Google Drive sync queued", JobID: job.ID}))
+}
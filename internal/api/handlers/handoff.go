@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HandoffHandler lets support agents take over chat sessions escalated by their users
+type HandoffHandler struct {
+	handoffService *services.HandoffService
+	logger         *log.Logger
+}
+
+// NewHandoffHandler creates a new conversation handoff handler
+func NewHandoffHandler(handoffService *services.HandoffService, logger *log.Logger) *HandoffHandler {
+	return &HandoffHandler{
+		handoffService: handoffService,
+		logger:         logger,
+	}
+}
+
+// HandoffAgentRequest identifies the support agent claiming or resolving an escalation
+type HandoffAgentRequest struct {
+	AgentID string `json:"agent_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// HandoffMessageRequest is a message of a support agent to the user of an escalated session
+type HandoffMessageRequest struct {
+	AgentID string `json:"agent_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	Content string `json:"content" example:"Hi, I'm taking over from the assistant. Could you send me your order number?"`
+}
+
+// ListEscalations lists escalated chat sessions
+// @Summary List escalations
+// @Description Chat sessions whose users asked for a support agent, longest waiting first
+// @Tags support
+// @Produce json
+// @Param agent_id query string true "Support agent or admin listing the escalations"
+// @Param status query string false "Filter by status (open, claimed, resolved)"
+// @Param mine query bool false "Only escalations claimed by the agent"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{escalations=[]models.ChatEscalation,total=int,limit=int,offset=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /support/escalations [get]
+func (h *HandoffHandler) ListEscalations(c *fiber.Ctx) error {
+	agentID, err := uuid.Parse(c.Query("agent_id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid agent_id")
+	}
+	filter := services.EscalationFilter{
+		Status: models.EscalationStatus(c.Query("status")),
+		Limit:  c.QueryInt("limit", 50),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	switch filter.Status {
+	case "", models.EscalationOpen, models.EscalationClaimed, models.EscalationResolved:
+	default:
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid status")
+	}
+	if c.QueryBool("mine") {
+		filter.AgentID = &agentID
+	}
+
+	escalations, total, err := h.handoffService.List(c.UserContext(), agentID, filter)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{
+		"escalations": escalations,
+		"total":       total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+	})
+}
+
+// GetEscalation returns an escalation with the transcript of its session
+// @Summary Get an escalation
+// @Description The escalation with every message of its session, including the assistant's answers before the handoff
+// @Tags support
+// @Produce json
+// @Param id path string true "Escalation ID"
+// @Param agent_id query string true "Support agent or admin"
+// @Success 200 {object} utils.APIResponse{data=models.ChatEscalation}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /support/escalations/{id} [get]
+func (h *HandoffHandler) GetEscalation(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid escalation ID")
+	}
+	agentID, err := uuid.Parse(c.Query("agent_id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid agent_id")
+	}
+
+	escalation, err := h.handoffService.Get(c.UserContext(), id, agentID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, escalation)
+}
+
+// ClaimEscalation assigns an open escalation to the agent
+// @Summary Claim an escalation
+// @Description The assistant stops answering the session and the agent answers the user instead. Only one agent can claim an escalation.
+// @Tags support
+// @Accept json
+// @Produce json
+// @Param id path string true "Escalation ID"
+// @Param request body HandoffAgentRequest true "Agent"
+// @Success 200 {object} utils.APIResponse{data=models.ChatEscalation}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /support/escalations/{id}/claim [post]
+func (h *HandoffHandler) ClaimEscalation(c *fiber.Ctx) error {
+	id, agentID, err := parseHandoffAgent(c)
+	if err != nil {
+		return err
+	}
+
+	escalation, err := h.handoffService.Claim(c.UserContext(), id, agentID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, escalation)
+}
+
+// PostAgentMessage posts a message of the agent into the escalated session
+// @Summary Post an agent message
+// @Description Adds a message with role "support" to the user's chat session. The escalation must be claimed by the agent.
+// @Tags support
+// @Accept json
+// @Produce json
+// @Param id path string true "Escalation ID"
+// @Param request body HandoffMessageRequest true "Message"
+// @Success 201 {object} utils.APIResponse{data=models.ChatMessage}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /support/escalations/{id}/messages [post]
+func (h *HandoffHandler) PostAgentMessage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid escalation ID")
+	}
+	var req HandoffMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	agentID, err := uuid.Parse(req.AgentID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid agent_id")
+	}
+
+	message, err := h.handoffService.PostMessage(c.UserContext(), id, agentID, req.Content)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(message))
+}
+
+// ResolveEscalation hands the session back to the assistant
+// @Summary Resolve an escalation
+// @Description The assistant answers the session again, with the agent's messages as part of the conversation history
+// @Tags support
+// @Accept json
+// @Produce json
+// @Param id path string true "Escalation ID"
+// @Param request body HandoffAgentRequest true "Agent"
+// @Success 200 {object} utils.APIResponse{data=models.ChatEscalation}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /support/escalations/{id}/resolve [post]
+func (h *HandoffHandler) ResolveEscalation(c *fiber.Ctx) error {
+	id, agentID, err := parseHandoffAgent(c)
+	if err != nil {
+		return err
+	}
+
+	escalation, err := h.handoffService.Resolve(c.UserContext(), id, agentID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, escalation)
+}
+
+// parseHandoffAgent reads the escalation ID and the agent handling it
+func parseHandoffAgent(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid escalation ID")
+	}
+	var req HandoffAgentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	agentID, err := uuid.Parse(req.AgentID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid agent_id")
+	}
+	return id, agentID, nil
+}
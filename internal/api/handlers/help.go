@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HelpHandler serves screen-aware help and manages which knowledge entries each screen shows
+type HelpHandler struct {
+	helpService *services.HelpService
+	logger      *log.Logger
+}
+
+// NewHelpHandler creates a new contextual help handler
+func NewHelpHandler(helpService *services.HelpService, logger *log.Logger) *HelpHandler {
+	return &HelpHandler{
+		helpService: helpService,
+		logger:      logger,
+	}
+}
+
+// SetHelpScreenRequest replaces the curated help of a screen
+type SetHelpScreenRequest struct {
+	services.HelpScreenSpec
+	UpdatedBy string `json:"updated_by,omitempty" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// GetContext returns the help of an application screen
+// @Summary Get contextual help for a screen
+// @Description Curated knowledge entries and AI-generated quick tips for a screen ID such as orders.pending. Screens without their own help fall back to their closest parent (orders).
+// @Tags help
+// @Produce json
+// @Param screen query string true "Screen ID"
+// @Param user_id query string false "Only return entries this user may see"
+// @Success 200 {object} utils.APIResponse{data=services.HelpContext}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /help/context [get]
+func (h *HelpHandler) GetContext(c *fiber.Ctx) error {
+	screen := c.Query("screen")
+	if screen == "" {
+		return utils.SendError(c, fiber.StatusBadRequest, "Query parameter 'screen' is required")
+	}
+
+	var userID *uuid.UUID
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		id, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid user_id parameter")
+		}
+		userID = &id
+	}
+
+	help, err := h.helpService.GetContext(c.UserContext(), screen, userID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, help)
+}
+
+// ListScreens lists the screens that have help
+// @Summary List help screens
+// @Description Screen to knowledge entry mappings used by the contextual help
+// @Tags help
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]models.HelpScreen}
+// @Failure 500 {object} utils.APIResponse
+// @Router /help/screens [get]
+func (h *HelpHandler) ListScreens(c *fiber.Ctx) error {
+	screens, err := h.helpService.ListScreens()
+	if err != nil {
+		h.logger.Printf("Error listing help screens: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list help screens")
+	}
+	return utils.SendSuccess(c, screens)
+}
+
+// SetScreen replaces the help of a screen
+// @Summary Set the help of a screen
+// @Description Map a screen ID to knowledge entries in display order. The screen's quick tips are regenerated on the next request.
+// @Tags help
+// @Accept json
+// @Produce json
+// @Param screen path string true "Screen ID, e.g. orders.pending"
+// @Param request body SetHelpScreenRequest true "Screen help"
+// @Success 200 {object} utils.APIResponse{data=models.HelpScreen}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Router /help/screens/{screen} [put]
+func (h *HelpHandler) SetScreen(c *fiber.Ctx) error {
+	var req SetHelpScreenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	var updatedBy *uuid.UUID
+	if _, ok := authenticatedUserID(c); ok || req.UpdatedBy != "" || sessionRequired(c) {
+		id, err := requestAdminID(c, req.UpdatedBy, "updated_by")
+		if err != nil {
+			return err
+		}
+		updatedBy = &id
+	}
+
+	screen, err := h.helpService.SetScreen(c.Params("screen"), req.HelpScreenSpec, updatedBy)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, screen)
+}
+
+// DeleteScreen removes the help of a screen
+// @Summary Delete the help of a screen
+// @Tags help
+// @Param screen path string true "Screen ID"
+// @Success 204
+// @Failure 404 {object} utils.APIResponse
+// @Router /help/screens/{screen} [delete]
+func (h *HelpHandler) DeleteScreen(c *fiber.Ctx) error {
+	if err := h.helpService.DeleteScreen(c.Params("screen")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImpersonationHandler lets admins act as another user, read-only, and exposes the audit trail
+type ImpersonationHandler struct {
+	impersonationService *services.ImpersonationService
+	logger               *log.Logger
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(impersonationService *services.ImpersonationService, logger *log.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+		logger:               logger,
+	}
+}
+
+// StartImpersonationRequest asks for a token to act as another user
+type StartImpersonationRequest struct {
+	TargetUserID string `json:"target_user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Reason       string `json:"reason" example:"Reproducing ticket #4821: user cannot see the onboarding guide"`
+}
+
+// StartImpersonation issues an impersonation token
+// @Summary Start impersonating a user
+// @Description Issue a short-lived token that acts as the target user when sent in the X-Impersonation-Token header. Impersonated requests are read-only (GET only) and every request is recorded in the audit trail. Only signed-in admins may impersonate, and admins cannot be impersonated.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body StartImpersonationRequest true "Impersonation request"
+// @Success 201 {object} utils.APIResponse{data=services.ImpersonationGrant}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/impersonations [post]
+func (h *ImpersonationHandler) StartImpersonation(c *fiber.Ctx) error {
+	adminID, ok := authenticatedUserID(c)
+	if !ok {
+		return utils.SendError(c, fiber.StatusUnauthorized, "Sign in as an admin to impersonate users")
+	}
+	var req StartImpersonationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	targetUserID, err := uuid.Parse(req.TargetUserID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid target_user_id")
+	}
+
+	grant, err := h.impersonationService.Start(c.UserContext(), adminID, targetUserID, req.Reason)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(grant))
+}
+
+// StopImpersonation revokes an impersonation session
+// @Summary Stop an impersonation session
+// @Description Revoke the token of an impersonation session before it expires. Only signed-in admins may stop sessions.
+// @Tags admin
+// @Param id path string true "Impersonation session ID"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/impersonations/{id} [delete]
+func (h *ImpersonationHandler) StopImpersonation(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid impersonation session ID")
+	}
+	adminID, ok := authenticatedUserID(c)
+	if !ok {
+		return utils.SendError(c, fiber.StatusUnauthorized, "Sign in as an admin to stop impersonation sessions")
+	}
+
+	if err := h.impersonationService.Stop(c.UserContext(), sessionID, adminID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListAudit lists the impersonation audit trail
+// @Summary List the impersonation audit trail
+// @Description Impersonation sessions started and stopped, and every request made while impersonating, newest first
+// @Tags admin
+// @Produce json
+// @Param session_id query string false "Filter by impersonation session ID"
+// @Param admin_id query string false "Filter by admin"
+// @Param target_user_id query string false "Filter by impersonated user"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{entries=[]models.ImpersonationAuditLog,total=int,limit=int,offset=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/impersonations/audit [get]
+func (h *ImpersonationHandler) ListAudit(c *fiber.Ctx) error {
+	filter := services.ImpersonationAuditFilter{
+		Limit:  c.QueryInt("limit", 50),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	for param, target := range map[string]**uuid.UUID{
+		"session_id":     &filter.SessionID,
+		"admin_id":       &filter.AdminID,
+		"target_user_id": &filter.TargetUserID,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid "+param)
+		}
+		*target = &id
+	}
+
+	entries, total, err := h.impersonationService.ListAudit(filter)
+	if err != nil {
+		h.logger.Printf("Error listing impersonation audit trail: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list audit trail")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// JobDashboardHandler exposes job queue health to admins and lets them pause queues
+type JobDashboardHandler struct {
+	dashboardService *services.JobDashboardService
+	logger           *log.Logger
+}
+
+// NewJobDashboardHandler creates a new job dashboard handler
+func NewJobDashboardHandler(dashboardService *services.JobDashboardService, logger *log.Logger) *JobDashboardHandler {
+	return &JobDashboardHandler{
+		dashboardService: dashboardService,
+		logger:           logger,
+	}
+}
+
+// QueueActionRequest identifies the admin pausing or resuming a queue
+type QueueActionRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	Reason  string `json:"reason,omitempty" example:"OpenAI outage"`
+}
+
+// GetDashboard returns job queue health
+// @Summary Job queue health dashboard
+// @Description Per-queue depth, age of the oldest due job, failure rate over the window, paused queues, and worker heartbeats
+// @Tags admin
+// @Produce json
+// @Param window_hours query int false "Window for failure rates in hours" default(24)
+// @Success 200 {object} utils.APIResponse{data=services.JobDashboard}
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/jobs/dashboard [get]
+func (h *JobDashboardHandler) GetDashboard(c *fiber.Ctx) error {
+	windowHours := c.QueryInt("window_hours", 24)
+	if windowHours <= 0 || windowHours > 24*30 {
+		windowHours = 24
+	}
+
+	dashboard, err := h.dashboardService.Dashboard(c.Context(), time.Duration(windowHours)*time.Hour)
+	if err != nil {
+		h.logger.Printf("Error building job dashboard: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to load job dashboard")
+	}
+	return utils.SendSuccess(c, dashboard)
+}
+
+// PauseQueue pauses a job queue
+// @Summary Pause a job queue
+// @Description Workers stop claiming jobs from the queue; running jobs finish and new jobs are still accepted
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param queue path string true "Queue name"
+// @Param request body QueueActionRequest true "Admin and reason"
+// @Success 200 {object} utils.APIResponse{data=models.JobQueuePause}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/jobs/queues/{queue}/pause [post]
+func (h *JobDashboardHandler) PauseQueue(c *fiber.Ctx) error {
+	req, adminID, err := parseQueueActionRequest(c)
+	if err != nil {
+		return err
+	}
+	pause, err := h.dashboardService.PauseQueue(c.Context(), c.Params("queue"), adminID, req.Reason)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, pause)
+}
+
+// ResumeQueue resumes a paused job queue
+// @Summary Resume a job queue
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param queue path string true "Queue name"
+// @Param request body QueueActionRequest true "Admin"
+// @Success 200 {object} utils.APIResponse{data=object{message=string,queue=string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/jobs/queues/{queue}/resume [post]
+func (h *JobDashboardHandler) ResumeQueue(c *fiber.Ctx) error {
+	_, adminID, err := parseQueueActionRequest(c)
+	if err != nil {
+		return err
+	}
+	queue := c.Params("queue")
+	if err := h.dashboardService.ResumeQueue(c.Context(), queue, adminID); err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{"message": "Queue resumed", "queue": queue})
+}
+
+func parseQueueActionRequest(c *fiber.Ctx) (*QueueActionRequest, uuid.UUID, error) {
+	var req QueueActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return &req, adminID, nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// JobsHandler exposes the background job queue for inspection
+type JobsHandler struct {
+	jobQueue *services.JobQueue
+	logger   *log.Logger
+}
+
+// NewJobsHandler creates a new jobs handler
+func NewJobsHandler(jobQueue *services.JobQueue, logger *log.Logger) *JobsHandler {
+	return &JobsHandler{
+		jobQueue: jobQueue,
+		logger:   logger,
+	}
+}
+
+// JobQueuedResponse reports work queued as a background job, to follow with GET /jobs/{id}
+type JobQueuedResponse struct {
+	Message string    `json:"message" example:"Confluence sync queued"`
+	JobID   uuid.UUID `json:"job_id"`
+}
+
+// ListJobs lists background jobs
+// @Summary List background jobs
+// @Description List background jobs with optional queue, type, and status filters
+// @Tags jobs
+// @Produce json
+// @Param queue query string false "Filter by queue"
+// @Param type query string false "Filter by job type"
+// @Param status query string false "Filter by status (pending, running, completed, failed, dead)"
+// @Param limit query int false "Limit number of results" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{jobs=[]models.Job,total=int,limit=int,offset=int}}
+// @Failure 500 {object} utils.APIResponse
+// @Router /jobs [get]
+func (h *JobsHandler) ListJobs(c *fiber.Ctx) error {
+	filter := services.JobFilter{
+		Queue:  c.Query("queue"),
+		Type:   c.Query("type"),
+		Status: models.JobStatus(c.Query("status")),
+		Limit:  c.QueryInt("limit", 20),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	jobs, total, err := h.jobQueue.ListJobs(c.Context(), filter)
+	if err != nil {
+		h.logger.Printf("Error listing jobs: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list jobs")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"jobs":   jobs,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GetJob returns a single background job
+// @Summary Get background job
+// @Description Get the status, attempts, and last error of a background job
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} utils.APIResponse{data=models.Job}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /jobs/{id} [get]
+func (h *JobsHandler) GetJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobQueue.GetJob(c.Context(), jobID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusNotFound, "Job not found")
+	}
+
+	return utils.SendSuccess(c, job)
+}
+
+// RetryJob requeues a failed or dead-lettered job
+// @Summary Retry background job
+// @Description Requeue a failed or dead-lettered job with a fresh attempt budget
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} utils.APIResponse{data=models.Job}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /jobs/{id}/retry [post]
+func (h *JobsHandler) RetryJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobQueue.RetryJob(c.Context(), jobID)
+	if err != nil {
+		h.logger.Printf("Error retrying job %s: %v", jobID, err)
+		return err
+	}
+
+	h.logger.Printf("Job %s requeued", jobID)
+	return utils.SendSuccess(c, job)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// KnowledgeCurationHandler merges and splits knowledge entries
+type KnowledgeCurationHandler struct {
+	curationService *services.KnowledgeCurationService
+	logger          *log.Logger
+}
+
+// NewKnowledgeCurationHandler creates a new knowledge curation handler
+func NewKnowledgeCurationHandler(curationService *services.KnowledgeCurationService, logger *log.Logger) *KnowledgeCurationHandler {
+	return &KnowledgeCurationHandler{
+		curationService: curationService,
+		logger:          logger,
+	}
+}
+
+// SplitEntryRequest splits a knowledge entry
+type SplitEntryRequest struct {
+	UserID   string                  `json:"user_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	Sections []services.SplitSection `json:"sections,omitempty"` // Sections to create; empty uses the AI's proposal
+}
+
+// MergeEntries combines knowledge entries into one
+// @Summary Merge knowledge entries
+// @Description Appends the content of the other entries to the target under their titles, unifies their tags and deletes them.
+// @Description Their IDs redirect to the target. Entries must have the same allowed roles and teams.
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param request body services.MergeEntriesRequest true "Entries to merge"
+// @Success 200 {object} utils.APIResponse{data=services.MergeResult}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /knowledge/merge [post]
+func (h *KnowledgeCurationHandler) MergeEntries(c *fiber.Ctx) error {
+	var req services.MergeEntriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.UserID == uuid.Nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "user_id is required")
+	}
+
+	result, err := h.curationService.MergeEntries(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, result)
+}
+
+// SplitEntry breaks a knowledge entry into several
+// @Summary Split a knowledge entry
+// @Description A dry run (the default) returns the sections the AI proposes, made of whole paragraphs of the entry.
+// @Description dry_run=false creates the given sections, or the proposed ones when none are given: the entry keeps its ID
+// @Description as the first section and the others become new entries with the same category, tags and access.
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge entry ID"
+// @Param dry_run query bool false "Only propose sections" default(true)
+// @Param request body SplitEntryRequest true "Sections"
+// @Success 200 {object} utils.APIResponse{data=object{dry_run=bool,sections=[]services.SplitSection,entries=[]models.KnowledgeEntry}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /knowledge/{id}/split [post]
+func (h *KnowledgeCurationHandler) SplitEntry(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid knowledge entry ID")
+	}
+	var req SplitEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	dryRun := c.QueryBool("dry_run", true)
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil && !dryRun {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user_id")
+	}
+
+	sections := req.Sections
+	if len(sections) == 0 {
+		if sections, err = h.curationService.ProposeSplit(c.UserContext(), id); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		return utils.SendSuccess(c, fiber.Map{"dry_run": true, "sections": sections})
+	}
+
+	entries, err := h.curationService.SplitEntry(c.UserContext(), id, sections, userID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{"dry_run": false, "entries": entries})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LeaderboardHandler exposes knowledge contribution statistics
+type LeaderboardHandler struct {
+	leaderboardService *services.LeaderboardService
+	logger             *log.Logger
+}
+
+// NewLeaderboardHandler creates a new leaderboard handler
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService, logger *log.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: leaderboardService,
+		logger:             logger,
+	}
+}
+
+// GetLeaderboard returns authors ranked by their knowledge contributions
+// @Summary Get contribution leaderboard
+// @Description Rank authors by entries published, views generated, or helpful-feedback rate
+// @Tags analytics
+// @Produce json
+// @Param sort query string false "Sort by entries, views, or helpful_rate" default(entries)
+// @Param since query string false "Only count contributions since this date (YYYY-MM-DD)"
+// @Param limit query int false "Limit number of results" default(10)
+// @Param badges query boolean false "Include badges" default(true)
+// @Success 200 {object} utils.APIResponse{data=object{leaderboard=[]services.ContributorStats,sort=string,count=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /analytics/leaderboard [get]
+func (h *LeaderboardHandler) GetLeaderboard(c *fiber.Ctx) error {
+	query := services.LeaderboardQuery{
+		SortBy:        c.Query("sort", services.LeaderboardSortEntries),
+		Limit:         c.QueryInt("limit", 10),
+		IncludeBadges: c.QueryBool("badges", true),
+	}
+
+	switch query.SortBy {
+	case services.LeaderboardSortEntries, services.LeaderboardSortViews, services.LeaderboardSortHelpfulRate:
+	default:
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid sort parameter, expected entries, views, or helpful_rate")
+	}
+
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid since parameter, expected YYYY-MM-DD")
+		}
+		query.Since = &since
+	}
+
+	if query.Limit <= 0 || query.Limit > 100 {
+		query.Limit = 10
+	}
+
+	leaderboard, err := h.leaderboardService.GetLeaderboard(query)
+	if err != nil {
+		h.logger.Printf("Error building leaderboard: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to build leaderboard")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"leaderboard": leaderboard,
+		"sort":        query.SortBy,
+		"count":       len(leaderboard),
+	})
+}
+
+// GetContributor returns the contribution statistics of a single author
+// @Summary Get contributor statistics
+// @Description Get entries published, views generated, helpful-feedback rate, and badges for an author
+// @Tags analytics
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} utils.APIResponse{data=services.ContributorStats}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /analytics/contributors/{id} [get]
+func (h *LeaderboardHandler) GetContributor(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	stats, err := h.leaderboardService.GetContributorStats(userID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusNotFound, "Contributor not found")
+	}
+
+	return utils.SendSuccess(c, stats)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ModerationHandler exposes the content redacted or blocked by the chat guardrails
+type ModerationHandler struct {
+	guardrailService *services.GuardrailService
+	logger           *log.Logger
+}
+
+// NewModerationHandler creates a new moderation handler
+func NewModerationHandler(guardrailService *services.GuardrailService, logger *log.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		guardrailService: guardrailService,
+		logger:           logger,
+	}
+}
+
+// ListEvents lists moderation events
+// @Summary List moderation events
+// @Description PII redacted from user messages and AI responses blocked by moderation, newest first. The offending text is never stored.
+// @Tags moderation
+// @Produce json
+// @Param user_id query string false "Filter by user"
+// @Param stage query string false "Filter by stage (input or output)"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{events=[]models.ModerationEvent,total=int,limit=int,offset=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /moderation/events [get]
+func (h *ModerationHandler) ListEvents(c *fiber.Ctx) error {
+	filter := services.ModerationEventFilter{
+		Stage:  models.ModerationStage(c.Query("stage")),
+		Limit:  c.QueryInt("limit", 50),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.Stage != "" && filter.Stage != models.ModerationInput && filter.Stage != models.ModerationOutput {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid stage")
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid user_id")
+		}
+		filter.UserID = &userID
+	}
+
+	events, total, err := h.guardrailService.ListEvents(filter)
+	if err != nil {
+		h.logger.Printf("Error listing moderation events: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list moderation events")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"events": events,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
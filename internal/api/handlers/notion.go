@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotionHandler exposes the Notion connector
+type NotionHandler struct {
+	notionService *services.NotionSyncService
+	logger        *log.Logger
+}
+
+// NewNotionHandler creates a new Notion integration handler
+func NewNotionHandler(notionService *services.NotionSyncService, logger *log.Logger) *NotionHandler {
+	return &NotionHandler{
+		notionService: notionService,
+		logger:        logger,
+	}
+}
+
+// GetStatus reports the Notion integration settings and sync progress
+// @Summary Get Notion sync status
+// @Description The configured databases and schedule, how many pages are synced into knowledge entries, and how many failed on their last sync
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.NotionStatus}
+// @Failure 500 {object} utils.APIResponse
+// @ID getNotionStatus
+// @Router /integrations/notion [get]
+func (h *NotionHandler) GetStatus(c *fiber.Ctx) error {
+	status, err := h.notionService.Status(c.UserContext())
+	if err != nil {
+		h.logger.Printf("Error getting Notion sync status: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to get Notion sync status")
+	}
+	return utils.SendSuccess(c, status)
+}
+
+// Sync queues a sync of the Notion pages
+// @Summary Sync Notion pages
+// @Description Queue a job that creates entries for new pages, replaces the entries of pages edited since their last sync, and deletes the entries of pages archived or no longer shared
+// @Tags integrations
+// @Produce json
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @ID syncNotion
+// @Router /integrations/notion/sync [post]
+func (h *NotionHandler) Sync(c *fiber.Ctx) error {
+	job, err := h.notionService.ScheduleSync(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Notion sync queued", JobID: job.ID}))
+}
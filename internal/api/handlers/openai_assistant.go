@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"strconv"
 	"time"
 
 	"tic-knowledge-system/internal/models"
 	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 
 	"encoding/json"
 
@@ -14,14 +16,14 @@ import (
 	"gorm.io/gorm"
 )
 
-// OpenAIAssistantHandler handles OpenAI Assistant API requests
+// OpenAIAssistantHandler handles OpenAI Assistant API requests on the configured assistant engine
 type OpenAIAssistantHandler struct {
-	assistantService *services.OpenAIAssistantService
+	assistantService services.AssistantEngine
 	logger           *log.Logger
 }
 
 // NewOpenAIAssistantHandler creates a new OpenAI Assistant handler
-func NewOpenAIAssistantHandler(assistantService *services.OpenAIAssistantService, logger *log.Logger) *OpenAIAssistantHandler {
+func NewOpenAIAssistantHandler(assistantService services.AssistantEngine, logger *log.Logger) *OpenAIAssistantHandler {
 	return &OpenAIAssistantHandler{
 		assistantService: assistantService,
 		logger:           logger,
@@ -30,14 +32,15 @@ func NewOpenAIAssistantHandler(assistantService *services.OpenAIAssistantService
 
 // ChatWithAssistant handles chat requests to OpenAI Assistant
 // @Summary Chat with OpenAI Assistant
-// @Description Implements the 4-step workflow: add message, create run, wait 5s, get messages
+// @Description Adds the message to the thread and runs the assistant, returning as soon as the run finishes. A run still going
+// @Description after timeout_seconds is returned with workflow_completed false; poll /assistant/runs/{run_id}/wait for its answer.
 // @Tags assistant
 // @Accept json
 // @Produce json
 // @Param request body services.ChatAssistantRequest true "Chat request"
-// @Success 200 {object} services.ChatAssistantResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} utils.APIResponse{data=services.ChatAssistantResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /assistant/chat [post]
 func (h *OpenAIAssistantHandler) ChatWithAssistant(c *fiber.Ctx) error {
 	h.logger.Printf("Received OpenAI Assistant chat request")
@@ -45,23 +48,16 @@ func (h *OpenAIAssistantHandler) ChatWithAssistant(c *fiber.Ctx) error {
 	var req services.ChatAssistantRequest
 	if err := c.BodyParser(&req); err != nil {
 		h.logger.Printf("Error parsing request body: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
 	// Validate required fields
 	if req.Message == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Message is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Message is required")
 	}
 
 	if req.AssistantID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Assistant ID is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Assistant ID is required")
 	}
 
 	h.logger.Printf("Processing chat request for assistant %s", req.AssistantID)
@@ -112,10 +108,7 @@ func (h *OpenAIAssistantHandler) ChatWithAssistant(c *fiber.Ctx) error {
 	response, err := h.assistantService.ChatWithAssistant(ctx, req)
 	if err != nil {
 		h.logger.Printf("Error in chat workflow: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to process chat request",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to process chat request", err.Error())
 	}
 	responseJSON, _ := json.Marshal(response)
 	responseTime := time.Since(start).Milliseconds()
@@ -129,44 +122,64 @@ func (h *OpenAIAssistantHandler) ChatWithAssistant(c *fiber.Ctx) error {
 	}
 
 	h.logger.Printf("Chat workflow completed successfully. Run ID: %s", response.RunID)
-	return c.JSON(response)
+	return utils.SendSuccess(c, response)
 }
 
-// GetThreadMessages gets all messages from a thread
+// GetThreadMessages gets a page of messages from a thread
 // @Summary Get thread messages
-// @Description Retrieve all messages from a specific thread
+// @Description Retrieve a page of the messages of a thread, newest first by default. Pass next_cursor as after to get
+// @Description the next page and prev_cursor as before to get the previous one. The role and run_id filters apply to
+// @Description the fetched page, so a page may hold fewer messages than the limit while has_more is true.
 // @Tags assistant
 // @Produce json
 // @Param thread_id path string true "Thread ID"
-// @Success 200 {array} services.AssistantMessage
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param limit query int false "Messages per page, 1 to 100" default(50)
+// @Param order query string false "asc or desc" default(desc)
+// @Param after query string false "List the messages after this message ID"
+// @Param before query string false "List the messages before this message ID"
+// @Param role query string false "Only messages of this role (user or assistant)"
+// @Param run_id query string false "Only messages created by this run"
+// @Success 200 {object} utils.APIResponse{data=object{thread_id=string,messages=[]services.AssistantMessage,count=int,has_more=bool,next_cursor=string,prev_cursor=string,retrieved_at=string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /assistant/threads/{thread_id}/messages [get]
 func (h *OpenAIAssistantHandler) GetThreadMessages(c *fiber.Ctx) error {
 	threadID := c.Params("thread_id")
 	if threadID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Thread ID is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Thread ID is required")
+	}
+	query := services.ThreadMessagesQuery{
+		Limit:  c.QueryInt("limit", 0),
+		Order:  c.Query("order"),
+		After:  c.Query("after"),
+		Before: c.Query("before"),
+		Role:   c.Query("role"),
+		RunID:  c.Query("run_id"),
+	}
+	if query.Role != "" && query.Role != "user" && query.Role != "assistant" {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid role, expected user or assistant")
 	}
 
 	h.logger.Printf("Getting messages for thread: %s", threadID)
 
 	ctx := c.Context()
-	messages, err := h.assistantService.GetThreadMessages(ctx, threadID)
+	page, err := h.assistantService.GetThreadMessages(ctx, threadID, query)
 	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			return err
+		}
 		h.logger.Printf("Error getting thread messages: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to get thread messages",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to get thread messages", err.Error())
 	}
 
-	h.logger.Printf("Retrieved %d messages from thread %s", len(messages), threadID)
-	return c.JSON(fiber.Map{
+	h.logger.Printf("Retrieved %d messages from thread %s", len(page.Messages), threadID)
+	return utils.SendSuccess(c, fiber.Map{
 		"thread_id":    threadID,
-		"messages":     messages,
-		"count":        len(messages),
+		"messages":     page.Messages,
+		"count":        len(page.Messages),
+		"has_more":     page.HasMore,
+		"next_cursor":  page.NextCursor,
+		"prev_cursor":  page.PrevCursor,
 		"retrieved_at": time.Now(),
 	})
 }
@@ -176,8 +189,8 @@ func (h *OpenAIAssistantHandler) GetThreadMessages(c *fiber.Ctx) error {
 // @Description Create a new OpenAI Assistant thread
 // @Tags assistant
 // @Produce json
-// @Success 200 {object} map[string]interface{}
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} utils.APIResponse{data=object{thread_id=string,created_at=int,metadata=object}}
+// @Failure 500 {object} utils.APIResponse
 // @Router /assistant/threads [post]
 func (h *OpenAIAssistantHandler) CreateThread(c *fiber.Ctx) error {
 	h.logger.Printf("Creating new OpenAI thread")
@@ -186,14 +199,11 @@ func (h *OpenAIAssistantHandler) CreateThread(c *fiber.Ctx) error {
 	thread, err := h.assistantService.CreateThread(ctx)
 	if err != nil {
 		h.logger.Printf("Error creating thread: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to create thread",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to create thread", err.Error())
 	}
 
 	h.logger.Printf("Created new thread: %s", thread.ID)
-	return c.JSON(fiber.Map{
+	return utils.SendSuccess(c, fiber.Map{
 		"thread_id":  thread.ID,
 		"created_at": thread.CreatedAt,
 		"metadata":   thread.Metadata,
@@ -206,10 +216,10 @@ func (h *OpenAIAssistantHandler) CreateThread(c *fiber.Ctx) error {
 // @Tags assistant
 // @Accept json
 // @Produce json
-// @Param request body map[string]interface{} true "Custom chat request"
-// @Success 200 {object} services.ChatAssistantResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param request body object{message=string,assistant_id=string,thread_id=string,timeout_seconds=int,wait_time=string,wait_for_completion=bool} true "Custom chat request; timeout_seconds takes precedence over wait_time"
+// @Success 200 {object} utils.APIResponse{data=services.ChatAssistantResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /assistant/chat/custom [post]
 func (h *OpenAIAssistantHandler) ChatWithCustomWorkflow(c *fiber.Ctx) error {
 	h.logger.Printf("Received custom workflow chat request")
@@ -217,25 +227,18 @@ func (h *OpenAIAssistantHandler) ChatWithCustomWorkflow(c *fiber.Ctx) error {
 	var reqData map[string]interface{}
 	if err := c.BodyParser(&reqData); err != nil {
 		h.logger.Printf("Error parsing request body: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
 	// Extract required fields
 	message, ok := reqData["message"].(string)
 	if !ok || message == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Message is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Message is required")
 	}
 
 	assistantID, ok := reqData["assistant_id"].(string)
 	if !ok || assistantID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Assistant ID is required",
-		})
+		return utils.SendError(c, fiber.StatusBadRequest, "Assistant ID is required")
 	}
 
 	// Optional fields
@@ -320,7 +323,7 @@ func (h *OpenAIAssistantHandler) ChatWithCustomWorkflow(c *fiber.Ctx) error {
 				ResponseTime:  responseTime,
 			})
 		}
-		return c.JSON(response)
+		return utils.SendSuccess(c, response)
 	}
 	response := h.chatWithCustomWait(c, req, waitTime, timeoutSeconds)
 	responseJSON, _ := json.Marshal(response)
@@ -333,7 +336,7 @@ func (h *OpenAIAssistantHandler) ChatWithCustomWorkflow(c *fiber.Ctx) error {
 			ResponseTime:  responseTime,
 		})
 	}
-	return c.JSON(response)
+	return utils.SendSuccess(c, response)
 }
 
 // chatWithCustomWait executes chat with custom wait time
@@ -374,10 +377,7 @@ func (h *OpenAIAssistantHandler) chatWithCompletionWait(c *fiber.Ctx, req servic
 	// First, execute the standard workflow
 	response, err := h.assistantService.ChatWithAssistant(ctx, req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to process chat request",
-			"details": err.Error(),
-		})
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to process chat request", err.Error())
 	}
 
 	// Then wait for completion
@@ -394,9 +394,9 @@ func (h *OpenAIAssistantHandler) chatWithCompletionWait(c *fiber.Ctx, req servic
 
 		// Get updated messages after completion
 		if finalStatus == "completed" {
-			updatedMessages, err := h.assistantService.GetThreadMessages(ctx, response.ThreadID)
+			page, err := h.assistantService.GetThreadMessages(ctx, response.ThreadID, services.ThreadMessagesQuery{})
 			if err == nil {
-				response.Messages = updatedMessages
+				response.Messages = page.Messages
 				response.Metadata["messages_updated"] = true
 			}
 		}
@@ -404,7 +404,61 @@ func (h *OpenAIAssistantHandler) chatWithCompletionWait(c *fiber.Ctx, req servic
 
 	response.Metadata["max_wait_time"] = maxWaitTime.String()
 
-	return c.JSON(response)
+	return utils.SendSuccess(c, response)
+}
+
+// Bounds of the run long-polling timeout
+const (
+	defaultRunWaitTimeout = 25 * time.Second
+	maxRunWaitTimeout     = 60 * time.Second
+)
+
+// WaitForRun long-polls the status of a run
+// @Summary Wait for a run to finish
+// @Description Long-polls a run for clients that cannot use streaming. Returns as soon as the run is completed,
+// @Description failed, cancelled, expired, or requires action, with the run's messages when it completed. When the
+// @Description timeout elapses first the response has done=false and the client should call again.
+// @Tags assistant
+// @Produce json
+// @Param id path string true "Run ID"
+// @Param thread_id query string false "Thread of the run (defaults to the default thread)"
+// @Param timeout query string false "How long to wait, e.g. 25s or 25 (seconds); at most 60s" default(25s)
+// @Success 200 {object} utils.APIResponse{data=services.RunWaitResult}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /assistant/runs/{id}/wait [get]
+func (h *OpenAIAssistantHandler) WaitForRun(c *fiber.Ctx) error {
+	runID := c.Params("id")
+	if runID == "" {
+		return utils.SendError(c, fiber.StatusBadRequest, "Run ID is required")
+	}
+
+	timeout := defaultRunWaitTimeout
+	if timeoutStr := c.Query("timeout"); timeoutStr != "" {
+		parsed, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			seconds, convErr := strconv.Atoi(timeoutStr)
+			if convErr != nil {
+				return utils.SendError(c, fiber.StatusBadRequest, "Invalid timeout parameter")
+			}
+			parsed = time.Duration(seconds) * time.Second
+		}
+		if parsed <= 0 || parsed > maxRunWaitTimeout {
+			return utils.SendError(c, fiber.StatusBadRequest, "timeout must be greater than 0 and at most "+maxRunWaitTimeout.String())
+		}
+		timeout = parsed
+	}
+
+	result, err := h.assistantService.WaitForRun(c.Context(), c.Query("thread_id"), runID, timeout)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return err
+		}
+		h.logger.Printf("Error waiting for run %s: %v", runID, err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to get run status", err.Error())
+	}
+	return utils.SendSuccess(c, result)
 }
 
 // HealthCheck checks if the assistant service is working
@@ -412,12 +466,13 @@ func (h *OpenAIAssistantHandler) chatWithCompletionWait(c *fiber.Ctx, req servic
 // @Description Check if OpenAI Assistant service is working
 // @Tags assistant
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} utils.APIResponse{data=object{status=string,service=string,engine=string,timestamp=string,version=string}}
 // @Router /assistant/health [get]
 func (h *OpenAIAssistantHandler) HealthCheck(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
+	return utils.SendSuccess(c, fiber.Map{
 		"status":    "healthy",
 		"service":   "openai-assistant",
+		"engine":    h.assistantService.Name(),
 		"timestamp": time.Now(),
 		"version":   "1.0.0",
 	})
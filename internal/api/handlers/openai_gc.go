@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OpenAIGCHandler exposes garbage collection of orphaned OpenAI resources
+type OpenAIGCHandler struct {
+	gcService *services.OpenAIGCService
+	logger    *log.Logger
+}
+
+// NewOpenAIGCHandler creates a new OpenAI garbage collection handler
+func NewOpenAIGCHandler(gcService *services.OpenAIGCService, logger *log.Logger) *OpenAIGCHandler {
+	return &OpenAIGCHandler{
+		gcService: gcService,
+		logger:    logger,
+	}
+}
+
+// CollectGarbage reports or deletes orphaned OpenAI files, vector-store files, and threads
+// @Summary Collect orphaned OpenAI resources
+// @Description Find uploaded files, vector-store files, and idle assistant threads that no database record refers to.
+// @Description A dry run (the default) returns the report immediately; dry_run=false queues a job that deletes them.
+// @Tags maintenance
+// @Produce json
+// @Param dry_run query bool false "Only report orphans" default(true)
+// @Success 200 {object} utils.APIResponse{data=services.OpenAIGCReport}
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 500 {object} utils.APIResponse
+// @Router /maintenance/openai-gc [post]
+func (h *OpenAIGCHandler) CollectGarbage(c *fiber.Ctx) error {
+	if c.QueryBool("dry_run", true) {
+		report, err := h.gcService.Collect(c.Context(), true)
+		if err != nil {
+			h.logger.Printf("Error running OpenAI garbage collection dry run: %v", err)
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to scan OpenAI resources", err.Error())
+		}
+		return utils.SendSuccess(c, report)
+	}
+
+	job, err := h.gcService.ScheduleCollection(c.Context())
+	if err != nil {
+		h.logger.Printf("Error scheduling OpenAI garbage collection: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to schedule garbage collection")
+	}
+
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "OpenAI garbage collection queued", JobID: job.ID}))
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PersonaHandler serves the persona presets users pick for their chat sessions and lets admins manage them
+type PersonaHandler struct {
+	personaService *services.PersonaService
+	logger         *log.Logger
+}
+
+// NewPersonaHandler creates a new persona handler
+func NewPersonaHandler(personaService *services.PersonaService, logger *log.Logger) *PersonaHandler {
+	return &PersonaHandler{
+		personaService: personaService,
+		logger:         logger,
+	}
+}
+
+// PersonaRequest creates or updates a persona preset
+type PersonaRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	services.PersonaSpec
+}
+
+// ListPersonas lists the persona presets users can pick
+// @Summary List persona presets
+// @Description Active presets, which a chat session answers as once picked with persona_id or PUT /chat/sessions/{id}/persona
+// @Tags chat
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]models.PersonaPreset}
+// @Failure 500 {object} utils.APIResponse
+// @Router /chat/personas [get]
+func (h *PersonaHandler) ListPersonas(c *fiber.Ctx) error {
+	return h.listPersonas(c, false)
+}
+
+// ListAllPersonas lists every persona preset, including deactivated ones
+// @Summary List all persona presets
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]models.PersonaPreset}
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/personas [get]
+func (h *PersonaHandler) ListAllPersonas(c *fiber.Ctx) error {
+	return h.listPersonas(c, true)
+}
+
+func (h *PersonaHandler) listPersonas(c *fiber.Ctx, includeInactive bool) error {
+	personas, err := h.personaService.ListPersonas(c.UserContext(), includeInactive)
+	if err != nil {
+		h.logger.Printf("Error listing persona presets: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list persona presets")
+	}
+	return utils.SendSuccess(c, personas)
+}
+
+// CreatePersona creates a persona preset
+// @Summary Create a persona preset
+// @Description The system prompt is added to the support prompt of every answer in the sessions using the preset
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body PersonaRequest true "Persona preset and admin"
+// @Success 201 {object} utils.APIResponse{data=models.PersonaPreset}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/personas [post]
+func (h *PersonaHandler) CreatePersona(c *fiber.Ctx) error {
+	req, adminID, err := parsePersonaRequest(c)
+	if err != nil {
+		return err
+	}
+	persona, err := h.personaService.CreatePersona(c.UserContext(), adminID, req.PersonaSpec)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(persona))
+}
+
+// UpdatePersona updates a persona preset
+// @Summary Update a persona preset
+// @Description Sessions using the preset answer with the new prompt from their next message. Set is_active to false
+// @Description to retire a preset: it can no longer be picked and its sessions answer without a persona.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Persona preset ID"
+// @Param request body PersonaRequest true "Persona preset and admin"
+// @Success 200 {object} utils.APIResponse{data=models.PersonaPreset}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/personas/{id} [put]
+func (h *PersonaHandler) UpdatePersona(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid persona ID")
+	}
+	req, adminID, err := parsePersonaRequest(c)
+	if err != nil {
+		return err
+	}
+	persona, err := h.personaService.UpdatePersona(c.UserContext(), adminID, id, req.PersonaSpec)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, persona)
+}
+
+func parsePersonaRequest(c *fiber.Ctx) (*PersonaRequest, uuid.UUID, error) {
+	var req PersonaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return &req, adminID, nil
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PromptHandler manages the versioned system prompts used by the chat providers
+type PromptHandler struct {
+	promptService *services.PromptService
+	logger        *log.Logger
+}
+
+// NewPromptHandler creates a new prompt template handler
+func NewPromptHandler(promptService *services.PromptService, logger *log.Logger) *PromptHandler {
+	return &PromptHandler{
+		promptService: promptService,
+		logger:        logger,
+	}
+}
+
+// CreatePromptRequest adds a new version of a prompt template
+type CreatePromptRequest struct {
+	Name string `json:"name" example:"support_assistant"`
+	services.PromptTemplateSpec
+	CreatedBy string `json:"created_by,omitempty" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// ListPrompts lists prompt templates
+// @Summary List prompt templates
+// @Description List prompt template versions, newest first. Built-in names are support_assistant (OpenAI, Ollama) and knowledge_assistant (Gemini).
+// @Tags prompts
+// @Produce json
+// @Param name query string false "Filter by prompt name"
+// @Param active query boolean false "Only return active versions"
+// @Success 200 {object} utils.APIResponse{data=[]models.PromptTemplate}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /prompts [get]
+func (h *PromptHandler) ListPrompts(c *fiber.Ctx) error {
+	activeOnly := false
+	if activeStr := c.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid active parameter")
+		}
+		activeOnly = active
+	}
+
+	prompts, err := h.promptService.ListPrompts(c.Query("name"), activeOnly)
+	if err != nil {
+		h.logger.Printf("Error listing prompt templates: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list prompt templates")
+	}
+	return utils.SendSuccess(c, prompts)
+}
+
+// CreatePrompt adds a prompt template version
+// @Summary Create a prompt template version
+// @Description Store a prompt as the next version of its name. Content may use {{variable}} placeholders declared in variables with default values, plus the built-in {{date}} and {{provider}}. Activating the version deactivates the others.
+// @Tags prompts
+// @Accept json
+// @Produce json
+// @Param request body CreatePromptRequest true "Prompt template"
+// @Success 201 {object} utils.APIResponse{data=models.PromptTemplate}
+// @Failure 400 {object} utils.APIResponse
+// @Router /prompts [post]
+func (h *PromptHandler) CreatePrompt(c *fiber.Ctx) error {
+	var req CreatePromptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	var createdBy *uuid.UUID
+	if req.CreatedBy != "" {
+		id, err := uuid.Parse(req.CreatedBy)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid created_by")
+		}
+		createdBy = &id
+	}
+
+	prompt, err := h.promptService.CreatePrompt(req.Name, req.PromptTemplateSpec, createdBy)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(prompt))
+}
+
+// GetPrompt returns a prompt template version
+// @Summary Get a prompt template version
+// @Tags prompts
+// @Produce json
+// @Param id path string true "Prompt template ID"
+// @Success 200 {object} utils.APIResponse{data=models.PromptTemplate}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /prompts/{id} [get]
+func (h *PromptHandler) GetPrompt(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid prompt template ID")
+	}
+
+	prompt, err := h.promptService.GetPrompt(id)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, prompt)
+}
+
+// UpdatePrompt edits a prompt template version
+// @Summary Update a prompt template version
+// @Description Replace the content, variables, and active flag of a version. Activating it deactivates the other versions of the same name.
+// @Tags prompts
+// @Accept json
+// @Produce json
+// @Param id path string true "Prompt template ID"
+// @Param request body services.PromptTemplateSpec true "Prompt template"
+// @Success 200 {object} utils.APIResponse{data=models.PromptTemplate}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /prompts/{id} [put]
+func (h *PromptHandler) UpdatePrompt(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid prompt template ID")
+	}
+
+	var spec services.PromptTemplateSpec
+	if err := c.BodyParser(&spec); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	prompt, err := h.promptService.UpdatePrompt(id, spec)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, prompt)
+}
+
+// DeletePrompt deletes a prompt template version
+// @Summary Delete a prompt template version
+// @Description Providers fall back to their built-in prompt when a name has no active version left
+// @Tags prompts
+// @Param id path string true "Prompt template ID"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /prompts/{id} [delete]
+func (h *PromptHandler) DeletePrompt(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid prompt template ID")
+	}
+
+	if err := h.promptService.DeletePrompt(id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
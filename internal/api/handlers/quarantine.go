@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// QuarantineHandler lets admins review ingested documents flagged by the prompt injection scanner
+type QuarantineHandler struct {
+	quarantineService *services.QuarantineService
+	logger            *log.Logger
+}
+
+// NewQuarantineHandler creates a new document quarantine handler
+func NewQuarantineHandler(quarantineService *services.QuarantineService, logger *log.Logger) *QuarantineHandler {
+	return &QuarantineHandler{
+		quarantineService: quarantineService,
+		logger:            logger,
+	}
+}
+
+// ReviewQuarantineRequest approves or rejects a quarantined document
+type ReviewQuarantineRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	Note    string `json:"note,omitempty" example:"Security training material quoting an attack, safe to use"`
+}
+
+// ListQuarantine lists quarantined documents
+// @Summary List quarantined documents
+// @Description Uploaded and imported documents held back because they contain instruction-like content that could hijack the assistant, newest first
+// @Tags admin
+// @Produce json
+// @Param status query string false "Filter by status (pending, approved, rejected)"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{documents=[]models.DocumentQuarantine,total=int,limit=int,offset=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/quarantine [get]
+func (h *QuarantineHandler) ListQuarantine(c *fiber.Ctx) error {
+	filter := services.QuarantineFilter{
+		Status: models.QuarantineStatus(c.Query("status")),
+		Limit:  c.QueryInt("limit", 50),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	switch filter.Status {
+	case "", models.QuarantinePending, models.QuarantineApproved, models.QuarantineRejected:
+	default:
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid status")
+	}
+
+	quarantines, total, err := h.quarantineService.List(filter)
+	if err != nil {
+		h.logger.Printf("Error listing quarantined documents: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list quarantined documents")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"documents": quarantines,
+		"total":     total,
+		"limit":     filter.Limit,
+		"offset":    filter.Offset,
+	})
+}
+
+// ApproveQuarantine releases a quarantined document
+// @Summary Approve a quarantined document
+// @Description Use the document as context: an upload is sent on to the vector store and the entries of an import are published
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Quarantine ID"
+// @Param request body ReviewQuarantineRequest true "Review"
+// @Success 200 {object} utils.APIResponse{data=models.DocumentQuarantine}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/quarantine/{id}/approve [post]
+func (h *QuarantineHandler) ApproveQuarantine(c *fiber.Ctx) error {
+	id, adminID, note, err := parseQuarantineReview(c)
+	if err != nil {
+		return err
+	}
+
+	quarantine, err := h.quarantineService.Approve(c.UserContext(), id, adminID, note)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, quarantine)
+}
+
+// RejectQuarantine discards a quarantined document
+// @Summary Reject a quarantined document
+// @Description Never use the document: an upload is not sent to the vector store and the entries of an import are deleted
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Quarantine ID"
+// @Param request body ReviewQuarantineRequest true "Review"
+// @Success 200 {object} utils.APIResponse{data=models.DocumentQuarantine}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/quarantine/{id}/reject [post]
+func (h *QuarantineHandler) RejectQuarantine(c *fiber.Ctx) error {
+	id, adminID, note, err := parseQuarantineReview(c)
+	if err != nil {
+		return err
+	}
+
+	quarantine, err := h.quarantineService.Reject(c.UserContext(), id, adminID, note)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, quarantine)
+}
+
+// parseQuarantineReview reads the quarantine ID, the reviewing admin, and the review note
+func parseQuarantineReview(c *fiber.Ctx) (uuid.UUID, uuid.UUID, string, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid quarantine ID")
+	}
+	var req ReviewQuarantineRequest
+	if err := c.BodyParser(&req); err != nil {
+		return uuid.Nil, uuid.Nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+	return id, adminID, req.Note, nil
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// QuotaHandler exposes monthly usage quotas and their admin overrides
+type QuotaHandler struct {
+	quotaService *services.QuotaService
+	logger       *log.Logger
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(quotaService *services.QuotaService, logger *log.Logger) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+		logger:       logger,
+	}
+}
+
+// QuotaOverrideRequest sets monthly limits; 0 means unlimited
+type QuotaOverrideRequest struct {
+	MonthlyTokens  int64   `json:"monthly_tokens" example:"2000000"`
+	MonthlyCostUSD float64 `json:"monthly_cost_usd" example:"50"`
+	Note           string  `json:"note,omitempty" example:"Raised for the Q3 onboarding project"`
+	UpdatedBy      string  `json:"updated_by" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// QuotaOverrideDeleteRequest identifies the admin removing an override
+type QuotaOverrideDeleteRequest struct {
+	UpdatedBy string `json:"updated_by" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// GetUserQuota returns a user's quota usage for the current month
+// @Summary Get a user's quota
+// @Description Monthly token and cost usage of the user and of the organization, with remaining quota
+// @Tags quotas
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} utils.APIResponse{data=services.QuotaStatus}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /quotas/users/{id} [get]
+func (h *QuotaHandler) GetUserQuota(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	status, err := h.quotaService.GetStatus(userID)
+	if err != nil {
+		h.logger.Printf("Error getting quota status for user %s: %v", userID, err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to get quota")
+	}
+	return utils.SendSuccess(c, status)
+}
+
+// SetUserQuota overrides a user's monthly quota
+// @Summary Override a user's quota
+// @Description Replace the configured monthly limits of a user. Only admins may override quotas.
+// @Tags quotas
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body QuotaOverrideRequest true "Monthly limits"
+// @Success 200 {object} utils.APIResponse{data=models.UsageQuota}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /quotas/users/{id} [put]
+func (h *QuotaHandler) SetUserQuota(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+	return h.setOverride(c, &userID)
+}
+
+// DeleteUserQuota removes a user's quota override
+// @Summary Remove a user's quota override
+// @Description Restore the configured default monthly limits of a user. Only admins may override quotas.
+// @Tags quotas
+// @Accept json
+// @Param id path string true "User ID"
+// @Param request body QuotaOverrideDeleteRequest true "Admin"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /quotas/users/{id} [delete]
+func (h *QuotaHandler) DeleteUserQuota(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+	return h.deleteOverride(c, &userID)
+}
+
+// SetOrgQuota overrides the organization's monthly quota
+// @Summary Override the organization quota
+// @Description Replace the configured monthly limits shared by all users. Only admins may override quotas.
+// @Tags quotas
+// @Accept json
+// @Produce json
+// @Param request body QuotaOverrideRequest true "Monthly limits"
+// @Success 200 {object} utils.APIResponse{data=models.UsageQuota}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /quotas/org [put]
+func (h *QuotaHandler) SetOrgQuota(c *fiber.Ctx) error {
+	return h.setOverride(c, nil)
+}
+
+// DeleteOrgQuota removes the organization's quota override
+// @Summary Remove the organization quota override
+// @Tags quotas
+// @Accept json
+// @Param request body QuotaOverrideDeleteRequest true "Admin"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /quotas/org [delete]
+func (h *QuotaHandler) DeleteOrgQuota(c *fiber.Ctx) error {
+	return h.deleteOverride(c, nil)
+}
+
+func (h *QuotaHandler) setOverride(c *fiber.Ctx, userID *uuid.UUID) error {
+	var req QuotaOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.UpdatedBy, "updated_by")
+	if err != nil {
+		return err
+	}
+
+	limits := services.QuotaLimits{MonthlyTokens: req.MonthlyTokens, MonthlyCostUSD: req.MonthlyCostUSD}
+	override, err := h.quotaService.SetOverride(userID, limits, req.Note, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, override)
+}
+
+func (h *QuotaHandler) deleteOverride(c *fiber.Ctx, userID *uuid.UUID) error {
+	var req QuotaOverrideDeleteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.UpdatedBy, "updated_by")
+	if err != nil {
+		return err
+	}
+
+	if err := h.quotaService.DeleteOverride(userID, adminID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
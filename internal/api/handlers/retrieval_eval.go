@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RetrievalEvalHandler exposes labeled retrieval pairs and evaluation metrics
+type RetrievalEvalHandler struct {
+	evalService *services.RetrievalEvalService
+	logger      *log.Logger
+}
+
+// NewRetrievalEvalHandler creates a new retrieval evaluation handler
+func NewRetrievalEvalHandler(evalService *services.RetrievalEvalService, logger *log.Logger) *RetrievalEvalHandler {
+	return &RetrievalEvalHandler{
+		evalService: evalService,
+		logger:      logger,
+	}
+}
+
+// CreateEvalPairRequest labels a question with the entry that should answer it
+type CreateEvalPairRequest struct {
+	Question        string `json:"question" example:"How do I reset a customer's password?"`
+	ExpectedEntryID string `json:"expected_entry_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Notes           string `json:"notes,omitempty"`
+	CreatedBy       string `json:"created_by,omitempty" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// CreatePair labels a question → correct entry pair
+// @Summary Create a labeled retrieval pair
+// @Description Label a question with the knowledge entry retrieval should return for it
+// @Tags retrieval-eval
+// @Accept json
+// @Produce json
+// @Param request body CreateEvalPairRequest true "Labeled pair"
+// @Success 201 {object} utils.APIResponse{data=models.RetrievalEvalPair}
+// @Failure 400 {object} utils.APIResponse
+// @Router /retrieval-eval/pairs [post]
+func (h *RetrievalEvalHandler) CreatePair(c *fiber.Ctx) error {
+	var req CreateEvalPairRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Question == "" {
+		return utils.SendError(c, fiber.StatusBadRequest, "question is required")
+	}
+
+	entryID, err := uuid.Parse(req.ExpectedEntryID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid expected_entry_id")
+	}
+
+	createdBy := uuid.MustParse(demoUserID)
+	if req.CreatedBy != "" {
+		if createdBy, err = uuid.Parse(req.CreatedBy); err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid created_by")
+		}
+	}
+
+	pair := &models.RetrievalEvalPair{
+		Question:        req.Question,
+		ExpectedEntryID: entryID,
+		Notes:           req.Notes,
+		CreatedBy:       createdBy,
+	}
+	if err := h.evalService.CreatePair(pair); err != nil {
+		h.logger.Printf("Error creating retrieval eval pair: %v", err)
+		return err
+	}
+
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(pair))
+}
+
+// ListPairs lists labeled pairs
+// @Summary List labeled retrieval pairs
+// @Tags retrieval-eval
+// @Produce json
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{pairs=[]models.RetrievalEvalPair,total=int,limit=int,offset=int}}
+// @Failure 500 {object} utils.APIResponse
+// @Router /retrieval-eval/pairs [get]
+func (h *RetrievalEvalHandler) ListPairs(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	offset := c.QueryInt("offset", 0)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	pairs, total, err := h.evalService.ListPairs(limit, offset)
+	if err != nil {
+		h.logger.Printf("Error listing retrieval eval pairs: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list pairs")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"pairs":  pairs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// DeletePair removes a labeled pair
+// @Summary Delete a labeled retrieval pair
+// @Tags retrieval-eval
+// @Param id path string true "Pair ID"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /retrieval-eval/pairs/{id} [delete]
+func (h *RetrievalEvalHandler) DeletePair(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid pair ID")
+	}
+
+	if err := h.evalService.DeletePair(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.SendError(c, fiber.StatusNotFound, "Pair not found")
+		}
+		h.logger.Printf("Error deleting retrieval eval pair: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to delete pair")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// StartRun queues an evaluation run
+// @Summary Run a retrieval evaluation
+// @Description Queue an evaluation of the current retrieval configuration against all labeled pairs. With answers=true every
+// @Description question is also answered through the chat path and the answers' faithfulness is scored by an LLM judge.
+// @Tags retrieval-eval
+// @Produce json
+// @Param answers query bool false "Also evaluate answers" default(false)
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @ID startRetrievalEvalRun
+// @Router /retrieval-eval/runs [post]
+func (h *RetrievalEvalHandler) StartRun(c *fiber.Ctx) error {
+	job, err := h.evalService.ScheduleEvaluation(c.Context(), c.QueryBool("answers"))
+	if errors.Is(err, services.ErrValidation) {
+		return err
+	}
+	if err != nil {
+		h.logger.Printf("Error scheduling retrieval evaluation: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to schedule evaluation")
+	}
+
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Retrieval evaluation queued", JobID: job.ID}))
+}
+
+// ListRuns returns the metric trend of recent evaluation runs
+// @Summary List retrieval evaluation runs
+// @Description Recall@k, MRR, score distributions and answer faithfulness of recent runs, newest first
+// @Tags retrieval-eval
+// @Produce json
+// @Param limit query int false "Limit number of runs" default(30)
+// @Success 200 {object} utils.APIResponse{data=object{runs=[]models.RetrievalEvalRun,count=int}}
+// @Failure 500 {object} utils.APIResponse
+// @ID listRetrievalEvalRuns
+// @Router /retrieval-eval/runs [get]
+func (h *RetrievalEvalHandler) ListRuns(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 30)
+	if limit <= 0 || limit > 365 {
+		limit = 30
+	}
+
+	runs, err := h.evalService.ListRuns(limit)
+	if err != nil {
+		h.logger.Printf("Error listing retrieval evaluation runs: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list runs")
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// GetRun returns an evaluation run with per-pair results
+// @Summary Get a retrieval evaluation run
+// @Tags retrieval-eval
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} utils.APIResponse{data=models.RetrievalEvalRun}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @ID getRetrievalEvalRun
+// @Router /retrieval-eval/runs/{id} [get]
+func (h *RetrievalEvalHandler) GetRun(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid run ID")
+	}
+
+	run, err := h.evalService.GetRun(id)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusNotFound, "Run not found")
+	}
+
+	return utils.SendSuccess(c, run)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RetrievalPresetHandler manages the chunking and retrieval presets and their assignment to categories and templates
+type RetrievalPresetHandler struct {
+	presetService *services.RetrievalPresetService
+	logger        *log.Logger
+}
+
+// NewRetrievalPresetHandler creates a new retrieval preset handler
+func NewRetrievalPresetHandler(presetService *services.RetrievalPresetService, logger *log.Logger) *RetrievalPresetHandler {
+	return &RetrievalPresetHandler{
+		presetService: presetService,
+		logger:        logger,
+	}
+}
+
+// CreateRetrievalPresetRequest creates a retrieval preset
+type CreateRetrievalPresetRequest struct {
+	services.RetrievalPresetSpec
+	CreatedBy string `json:"created_by,omitempty" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// AssignRetrievalPresetRequest assigns a preset; an empty preset_id removes the assignment
+type AssignRetrievalPresetRequest struct {
+	PresetID string `json:"preset_id" example:"0b6f1a52-3c1e-4b8e-9f7e-2d1f0c9a8b7d"`
+}
+
+// ListPresets lists the retrieval presets
+// @Summary List retrieval presets
+// @Tags retrieval-presets
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]models.RetrievalPreset}
+// @Failure 500 {object} utils.APIResponse
+// @Router /retrieval-presets [get]
+func (h *RetrievalPresetHandler) ListPresets(c *fiber.Ctx) error {
+	presets, err := h.presetService.ListPresets()
+	if err != nil {
+		h.logger.Printf("Error listing retrieval presets: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list retrieval presets")
+	}
+	return utils.SendSuccess(c, presets)
+}
+
+// CreatePreset creates a retrieval preset
+// @Summary Create a retrieval preset
+// @Description A preset sets the chunk size and overlap used when embedding entries (0 uses the configured defaults),
+// @Description how many entries of a category one search returns (top_k, 0 for no cap), and the lowest vector score kept.
+// @Tags retrieval-presets
+// @Accept json
+// @Produce json
+// @Param request body CreateRetrievalPresetRequest true "Retrieval preset"
+// @Success 201 {object} utils.APIResponse{data=models.RetrievalPreset}
+// @Failure 400 {object} utils.APIResponse
+// @Router /retrieval-presets [post]
+func (h *RetrievalPresetHandler) CreatePreset(c *fiber.Ctx) error {
+	var req CreateRetrievalPresetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	var createdBy *uuid.UUID
+	if req.CreatedBy != "" {
+		id, err := uuid.Parse(req.CreatedBy)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid created_by")
+		}
+		createdBy = &id
+	}
+
+	preset, err := h.presetService.CreatePreset(req.RetrievalPresetSpec, createdBy)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(preset))
+}
+
+// GetPreset returns a retrieval preset
+// @Summary Get a retrieval preset
+// @Tags retrieval-presets
+// @Produce json
+// @Param id path string true "Preset ID"
+// @Success 200 {object} utils.APIResponse{data=models.RetrievalPreset}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /retrieval-presets/{id} [get]
+func (h *RetrievalPresetHandler) GetPreset(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid preset ID")
+	}
+
+	preset, err := h.presetService.GetPreset(id)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, preset)
+}
+
+// UpdatePreset edits a retrieval preset
+// @Summary Update a retrieval preset
+// @Description Changing the chunk settings re-embeds the entries of the categories and templates using the preset
+// @Tags retrieval-presets
+// @Accept json
+// @Produce json
+// @Param id path string true "Preset ID"
+// @Param request body services.RetrievalPresetSpec true "Retrieval preset"
+// @Success 200 {object} utils.APIResponse{data=models.RetrievalPreset}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /retrieval-presets/{id} [put]
+func (h *RetrievalPresetHandler) UpdatePreset(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid preset ID")
+	}
+	var spec services.RetrievalPresetSpec
+	if err := c.BodyParser(&spec); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	preset, err := h.presetService.UpdatePreset(c.Context(), id, spec)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, preset)
+}
+
+// DeletePreset deletes a retrieval preset
+// @Summary Delete a retrieval preset
+// @Description Categories and templates using the preset go back to the defaults and their entries are re-embedded
+// @Tags retrieval-presets
+// @Param id path string true "Preset ID"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /retrieval-presets/{id} [delete]
+func (h *RetrievalPresetHandler) DeletePreset(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid preset ID")
+	}
+
+	if err := h.presetService.DeletePreset(c.Context(), id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListAssignments lists the categories and templates that use a preset
+// @Summary List retrieval preset assignments
+// @Tags retrieval-presets
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.RetrievalPresetAssignments}
+// @Failure 500 {object} utils.APIResponse
+// @Router /retrieval-presets/assignments [get]
+func (h *RetrievalPresetHandler) ListAssignments(c *fiber.Ctx) error {
+	assignments, err := h.presetService.ListAssignments()
+	if err != nil {
+		h.logger.Printf("Error listing retrieval preset assignments: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list retrieval preset assignments")
+	}
+	return utils.SendSuccess(c, assignments)
+}
+
+// AssignCategory sets the preset of a category
+// @Summary Assign a retrieval preset to a category
+// @Description The category's entries are re-embedded with the preset's chunk settings. An empty preset_id restores the defaults.
+// @Tags retrieval-presets
+// @Accept json
+// @Produce json
+// @Param category path string true "Knowledge category"
+// @Param request body AssignRetrievalPresetRequest true "Preset"
+// @Success 200 {object} utils.APIResponse{data=object{category=string,preset_id=string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /retrieval-presets/categories/{category} [put]
+func (h *RetrievalPresetHandler) AssignCategory(c *fiber.Ctx) error {
+	presetID, err := parsePresetAssignment(c)
+	if err != nil {
+		return err
+	}
+	category := c.Params("category")
+	if err := h.presetService.AssignCategory(c.Context(), category, presetID); err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{"category": category, "preset_id": presetID})
+}
+
+// AssignTemplate sets the preset of a template
+// @Summary Assign a retrieval preset to a template
+// @Description Overrides the category's preset for entries created from the template, which are re-embedded.
+// @Description An empty preset_id falls back to the category's preset.
+// @Tags retrieval-presets
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param request body AssignRetrievalPresetRequest true "Preset"
+// @Success 200 {object} utils.APIResponse{data=object{template_id=string,preset_id=string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /retrieval-presets/templates/{id} [put]
+func (h *RetrievalPresetHandler) AssignTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid template ID")
+	}
+	presetID, err := parsePresetAssignment(c)
+	if err != nil {
+		return err
+	}
+	if err := h.presetService.AssignTemplate(c.Context(), templateID, presetID); err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{"template_id": templateID, "preset_id": presetID})
+}
+
+func parsePresetAssignment(c *fiber.Ctx) (*uuid.UUID, error) {
+	var req AssignRetrievalPresetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.PresetID == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(req.PresetID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid preset_id")
+	}
+	return &id, nil
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SchedulesHandler lets admins manage the scheduled jobs
+type SchedulesHandler struct {
+	schedulerService *services.SchedulerService
+	logger           *log.Logger
+}
+
+// NewSchedulesHandler creates a new scheduled jobs handler
+func NewSchedulesHandler(schedulerService *services.SchedulerService, logger *log.Logger) *SchedulesHandler {
+	return &SchedulesHandler{
+		schedulerService: schedulerService,
+		logger:           logger,
+	}
+}
+
+// ScheduleRequest creates or updates a scheduled job
+type ScheduleRequest struct {
+	services.ScheduleSpec
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// ScheduleAdminRequest identifies the admin acting on a scheduled job
+type ScheduleAdminRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// ListSchedules lists the scheduled jobs
+// @Summary List scheduled jobs
+// @Description Each job with its cron expression, next run and the status, error, result and duration of its last run
+// @Tags schedules
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{schedules=[]models.ScheduledJob}}
+// @Failure 500 {object} utils.APIResponse
+// @Router /schedules [get]
+func (h *SchedulesHandler) ListSchedules(c *fiber.Ctx) error {
+	schedules, err := h.schedulerService.ListSchedules()
+	if err != nil {
+		h.logger.Printf("Error listing scheduled jobs: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list scheduled jobs")
+	}
+	return utils.SendSuccess(c, fiber.Map{"schedules": schedules})
+}
+
+// ListTasks lists the tasks jobs can be scheduled for
+// @Summary List schedulable tasks
+// @Description Connector sync tasks are only available when their connector is configured
+// @Tags schedules
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{tasks=[]services.ScheduledTask}}
+// @Router /schedules/tasks [get]
+func (h *SchedulesHandler) ListTasks(c *fiber.Ctx) error {
+	return utils.SendSuccess(c, fiber.Map{"tasks": h.schedulerService.Tasks()})
+}
+
+// CreateSchedule creates a scheduled job
+// @Summary Create a scheduled job
+// @Description Run a task on a standard five-field cron expression (minute hour day-of-month month day-of-week) in UTC,
+// @Description or in another zone with a CRON_TZ= prefix. Descriptors such as @daily and @every 6h are accepted too.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param request body ScheduleRequest true "Scheduled job"
+// @Success 201 {object} utils.APIResponse{data=models.ScheduledJob}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /schedules [post]
+func (h *SchedulesHandler) CreateSchedule(c *fiber.Ctx) error {
+	var req ScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+
+	schedule, err := h.schedulerService.CreateSchedule(req.ScheduleSpec, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusCreated, utils.SuccessResponse(schedule))
+}
+
+// UpdateSchedule updates a scheduled job
+// @Summary Update a scheduled job
+// @Description Replaces the name, task, cron expression and active flag, and moves the job to its next time
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Scheduled job ID"
+// @Param request body ScheduleRequest true "Scheduled job"
+// @Success 200 {object} utils.APIResponse{data=models.ScheduledJob}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /schedules/{id} [put]
+func (h *SchedulesHandler) UpdateSchedule(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid scheduled job ID")
+	}
+	var req ScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+
+	schedule, err := h.schedulerService.UpdateSchedule(id, req.ScheduleSpec, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, schedule)
+}
+
+// DeleteSchedule deletes a scheduled job
+// @Summary Delete a scheduled job
+// @Tags schedules
+// @Accept json
+// @Param id path string true "Scheduled job ID"
+// @Param request body ScheduleAdminRequest true "Admin"
+// @Success 204
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /schedules/{id} [delete]
+func (h *SchedulesHandler) DeleteSchedule(c *fiber.Ctx) error {
+	id, adminID, err := parseScheduleAdminRequest(c)
+	if err != nil {
+		return err
+	}
+	if err := h.schedulerService.DeleteSchedule(id, adminID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RunSchedule queues a run of a scheduled job now
+// @Summary Run a scheduled job now
+// @Description Queue a run of the job's task without changing its next scheduled time; the outcome is recorded as its last run
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Scheduled job ID"
+// @Param request body ScheduleAdminRequest true "Admin"
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /schedules/{id}/run [post]
+func (h *SchedulesHandler) RunSchedule(c *fiber.Ctx) error {
+	id, adminID, err := parseScheduleAdminRequest(c)
+	if err != nil {
+		return err
+	}
+	job, err := h.schedulerService.RunSchedule(c.UserContext(), id, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Scheduled job run queued", JobID: job.ID}))
+}
+
+func parseScheduleAdminRequest(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid scheduled job ID")
+	}
+	var req ScheduleAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return id, adminID, nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SettingsHandler lets admins change tunable parameters at runtime
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+	logger          *log.Logger
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsService *services.SettingsService, logger *log.Logger) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+		logger:          logger,
+	}
+}
+
+// UpdateSettingsRequest changes runtime settings
+type UpdateSettingsRequest struct {
+	AdminID  string             `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+	Settings map[string]*string `json:"settings"` // null resets a setting to its environment default
+}
+
+// GetSettings returns the runtime settings
+// @Summary List runtime settings
+// @Description Primary provider, models, temperature, max tokens and chunk sizes with the value in effect and its environment default
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]services.RuntimeSetting}
+// @Router /admin/settings [get]
+func (h *SettingsHandler) GetSettings(c *fiber.Ctx) error {
+	return utils.SendSuccess(c, h.settingsService.List(c.UserContext()))
+}
+
+// UpdateSettings changes runtime settings
+// @Summary Change runtime settings
+// @Description Settings take effect without a restart and take precedence over the environment until reset with null.
+// @Description Other instances pick the change up within 30 seconds. Chunk sizes apply to entries embedded from then on.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body UpdateSettingsRequest true "Settings and admin"
+// @Success 200 {object} utils.APIResponse{data=[]services.RuntimeSetting}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/settings [put]
+func (h *SettingsHandler) UpdateSettings(c *fiber.Ctx) error {
+	var req UpdateSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+
+	settings, err := h.settingsService.Update(c.UserContext(), adminID, req.Settings)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, settings)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Cookies of the SSO login
+const (
+	SessionCookieName  = "tic_session"
+	ssoStateCookieName = "tic_sso_state"
+)
+
+// SSOHandler signs users in through the configured OIDC provider
+type SSOHandler struct {
+	ssoService    *services.SSOService
+	postLoginURL  string
+	secureCookies bool
+	logger        *log.Logger
+}
+
+// NewSSOHandler creates a new SSO handler. After login the browser is redirected to postLoginURL, or the
+// callback answers with the session token when it is empty.
+func NewSSOHandler(ssoService *services.SSOService, postLoginURL string, secureCookies bool, logger *log.Logger) *SSOHandler {
+	return &SSOHandler{
+		ssoService:    ssoService,
+		postLoginURL:  postLoginURL,
+		secureCookies: secureCookies,
+		logger:        logger,
+	}
+}
+
+// Login redirects the browser to the identity provider
+// @Summary Start SSO login
+// @Description Redirects to the configured OIDC provider (Google Workspace, Entra ID). The provider sends the user back
+// @Description to /auth/sso/callback.
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} utils.APIResponse
+// @Router /auth/sso/login [get]
+func (h *SSOHandler) Login(c *fiber.Ctx) error {
+	login, err := h.ssoService.Login(c.UserContext())
+	if errors.Is(err, services.ErrSSODisabled) {
+		return utils.SendError(c, fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		h.logger.Printf("Error starting SSO login: %v", err)
+		return utils.SendError(c, fiber.StatusBadGateway, "Failed to reach the identity provider")
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookieName,
+		Value:    login.State,
+		Path:     "/",
+		Expires:  time.Now().Add(10 * time.Minute),
+		HTTPOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(login.URL, fiber.StatusFound)
+}
+
+// Callback completes the login started by Login
+// @Summary Complete SSO login
+// @Description Exchanges the authorization code, provisions unknown users with the role mapped from their IdP groups,
+// @Description and sets the session cookie. The session token is also accepted as a bearer token.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} utils.APIResponse{data=services.SSOLogin}
+// @Success 302
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /auth/sso/callback [get]
+func (h *SSOHandler) Callback(c *fiber.Ctx) error {
+	if providerError := c.Query("error"); providerError != "" {
+		return utils.SendError(c, fiber.StatusUnauthorized, "Identity provider refused the login: "+providerError, c.Query("error_description"))
+	}
+
+	login, err := h.ssoService.Callback(c.UserContext(), c.Query("code"), c.Query("state"), c.Cookies(ssoStateCookieName))
+	c.ClearCookie(ssoStateCookieName)
+	switch {
+	case errors.Is(err, services.ErrSSODisabled):
+		return utils.SendError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSSOInvalidState), errors.Is(err, services.ErrSSOIDToken):
+		h.logger.Printf("Rejected SSO callback from %s: %v", c.IP(), err)
+		return utils.SendError(c, fiber.StatusUnauthorized, err.Error())
+	case err != nil:
+		return err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     SessionCookieName,
+		Value:    login.Token,
+		Path:     "/",
+		Expires:  login.ExpiresAt,
+		HTTPOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	if h.postLoginURL != "" {
+		return c.Redirect(h.postLoginURL, fiber.StatusFound)
+	}
+	return utils.SendSuccess(c, login)
+}
+
+// Logout clears the session cookie
+// @Summary Log out
+// @Description Clears the session cookie. Bearer tokens stay valid until they expire.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{message=string}}
+// @Router /auth/logout [post]
+func (h *SSOHandler) Logout(c *fiber.Ctx) error {
+	c.ClearCookie(SessionCookieName)
+	return utils.SendSuccess(c, fiber.Map{"message": "Logged out"})
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// StaleReviewHandler lists the knowledge entries flagged for review and manages the review ages of categories
+type StaleReviewHandler struct {
+	reviewService *services.StaleReviewService
+	logger        *log.Logger
+}
+
+// NewStaleReviewHandler creates a new stale review handler
+func NewStaleReviewHandler(reviewService *services.StaleReviewService, logger *log.Logger) *StaleReviewHandler {
+	return &StaleReviewHandler{
+		reviewService: reviewService,
+		logger:        logger,
+	}
+}
+
+// ConfirmReviewRequest confirms a stale entry is still correct
+type ConfirmReviewRequest struct {
+	UserID string `json:"user_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// SetReviewPolicyRequest sets the review age of a category
+type SetReviewPolicyRequest struct {
+	MaxAgeDays *int `json:"max_age_days" example:"90"` // 0 never flags the category; null restores the default
+}
+
+// ListStaleEntries lists the entries flagged for review
+// @Summary List stale knowledge entries
+// @Description Published entries not updated within the review age of their category, longest unchanged first.
+// @Description Updating an entry or confirming it is still correct removes it from the list.
+// @Tags knowledge
+// @Produce json
+// @Param category query string false "Filter by category"
+// @Param created_by query string false "Filter by creator ID"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{entries=[]models.KnowledgeEntry,total=int,limit=int,offset=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /knowledge/stale [get]
+func (h *StaleReviewHandler) ListStaleEntries(c *fiber.Ctx) error {
+	filter := services.StaleEntryFilter{
+		Category: c.Query("category"),
+		Limit:    c.QueryInt("limit", 50),
+		Offset:   c.QueryInt("offset", 0),
+	}
+	if createdBy := c.Query("created_by"); createdBy != "" {
+		id, err := uuid.Parse(createdBy)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid created_by")
+		}
+		filter.CreatedBy = &id
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	entries, total, err := h.reviewService.ListStaleEntries(filter)
+	if err != nil {
+		h.logger.Printf("Error listing stale knowledge entries: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list stale knowledge entries")
+	}
+	return utils.SendSuccess(c, fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// ConfirmEntry confirms a stale entry is still correct
+// @Summary Confirm a knowledge entry is current
+// @Description Clears the review flag and restarts the entry's age without changing its content
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge entry ID"
+// @Param request body ConfirmReviewRequest true "Reviewer"
+// @Success 200 {object} utils.APIResponse{data=models.KnowledgeEntry}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /knowledge/{id}/confirm-review [post]
+func (h *StaleReviewHandler) ConfirmEntry(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid knowledge entry ID")
+	}
+	var req ConfirmReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid user_id")
+	}
+
+	entry, err := h.reviewService.ConfirmEntry(c.UserContext(), id, userID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, entry)
+}
+
+// ListPolicies lists the review ages of categories
+// @Summary List category review ages
+// @Tags knowledge
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{default_max_age_days=int,policies=[]models.CategoryReviewPolicy}}
+// @Failure 500 {object} utils.APIResponse
+// @ID listReviewPolicies
+// @Router /knowledge/stale/policies [get]
+func (h *StaleReviewHandler) ListPolicies(c *fiber.Ctx) error {
+	policies, err := h.reviewService.ListPolicies()
+	if err != nil {
+		h.logger.Printf("Error listing review policies: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to list review policies")
+	}
+	return utils.SendSuccess(c, fiber.Map{
+		"default_max_age_days": int(h.reviewService.DefaultMaxAge().Hours() / 24),
+		"policies":             policies,
+	})
+}
+
+// SetPolicy sets the review age of a category
+// @Summary Set the review age of a category
+// @Description Entries of the category not updated for max_age_days are flagged by the next detection run
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param category path string true "Knowledge category"
+// @Param request body SetReviewPolicyRequest true "Review age"
+// @Success 200 {object} utils.APIResponse{data=object{policy=models.CategoryReviewPolicy}}
+// @Failure 400 {object} utils.APIResponse
+// @Router /knowledge/stale/policies/{category} [put]
+func (h *StaleReviewHandler) SetPolicy(c *fiber.Ctx) error {
+	var req SetReviewPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	policy, err := h.reviewService.SetPolicy(c.Params("category"), req.MaxAgeDays)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{"policy": policy})
+}
+
+// RunDetection queues a stale-entry detection run
+// @Summary Detect stale knowledge entries
+// @Description Queue a job that flags stale entries and reminds their creators
+// @Tags knowledge
+// @Produce json
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 500 {object} utils.APIResponse
+// @Router /knowledge/stale/detect [post]
+func (h *StaleReviewHandler) RunDetection(c *fiber.Ctx) error {
+	job, err := h.reviewService.ScheduleRun(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Stale-entry detection queued", JobID: job.ID}))
+}
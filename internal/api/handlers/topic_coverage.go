@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TopicCoverageHandler exposes the per-topic knowledge coverage report
+type TopicCoverageHandler struct {
+	coverageService *services.TopicCoverageService
+	logger          *log.Logger
+}
+
+// NewTopicCoverageHandler creates a new topic coverage handler
+func NewTopicCoverageHandler(coverageService *services.TopicCoverageService, logger *log.Logger) *TopicCoverageHandler {
+	return &TopicCoverageHandler{
+		coverageService: coverageService,
+		logger:          logger,
+	}
+}
+
+// GetTopicCoverage reports demand and knowledge coverage per topic
+// @Summary Get per-topic knowledge coverage
+// @Description For each topic, count chat questions, relevant published entries, and how often answers cite knowledge.
+// @Description Topics are ordered by coverage gap so high-demand topics with thin coverage come first.
+// @Tags analytics
+// @Produce json
+// @Param since query string false "Only count questions since this date (YYYY-MM-DD)"
+// @Param reclassify query boolean false "Classify questions again, e.g. after topics changed" default(false)
+// @Success 200 {object} utils.APIResponse{data=services.TopicCoverageReport}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /analytics/topic-coverage [get]
+func (h *TopicCoverageHandler) GetTopicCoverage(c *fiber.Ctx) error {
+	var since *time.Time
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid since parameter, expected YYYY-MM-DD")
+		}
+		since = &parsed
+	}
+
+	report, err := h.coverageService.GetCoverageReport(since, c.QueryBool("reclassify", false))
+	if err != nil {
+		h.logger.Printf("Error building topic coverage report: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to build topic coverage report")
+	}
+
+	return utils.SendSuccess(c, report)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TrashHandler lets admins review, restore and purge soft-deleted records
+type TrashHandler struct {
+	trashService *services.TrashService
+	logger       *log.Logger
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler(trashService *services.TrashService, logger *log.Logger) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+		logger:       logger,
+	}
+}
+
+// TrashAdminRequest identifies the admin restoring or purging deleted records
+type TrashAdminRequest struct {
+	AdminID string `json:"admin_id" example:"4566215d-9957-4765-9ac5-a9395879945e"`
+}
+
+// ListTrash lists the soft-deleted records
+// @Summary List deleted records
+// @Description Soft-deleted knowledge entries, templates, chat sessions, feedback, documents and retrieval evaluation pairs,
+// @Description most recently deleted first. purge_at is when the record is permanently deleted; archived chat sessions are kept.
+// @Tags admin
+// @Produce json
+// @Param type query string false "Filter by type" Enums(knowledge_entry, template, chat_session, feedback, document, retrieval_eval_pair)
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} utils.APIResponse{data=object{items=[]services.TrashItem,total=int,limit=int,offset=int,retention_days=int}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/trash [get]
+func (h *TrashHandler) ListTrash(c *fiber.Ctx) error {
+	filter := services.TrashFilter{
+		Type:   c.Query("type"),
+		Limit:  c.QueryInt("limit", 50),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := h.trashService.ListTrash(filter)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, fiber.Map{
+		"items":          items,
+		"total":          total,
+		"limit":          filter.Limit,
+		"offset":         filter.Offset,
+		"retention_days": int(h.trashService.Retention().Hours() / 24),
+	})
+}
+
+// RestoreItem restores a soft-deleted record
+// @Summary Restore a deleted record
+// @Description Restored knowledge entries are embedded again and no longer redirect to the entry they were merged into.
+// @Description Restored chat sessions get back the messages deleted with them. Deleted documents cannot be restored.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param type path string true "Record type" Enums(knowledge_entry, template, chat_session, feedback, retrieval_eval_pair)
+// @Param id path string true "Record ID"
+// @Param request body TrashAdminRequest true "Admin"
+// @Success 200 {object} utils.APIResponse{data=services.TrashItem}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /admin/trash/{type}/{id}/restore [post]
+func (h *TrashHandler) RestoreItem(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid record ID")
+	}
+	var req TrashAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+
+	item, err := h.trashService.Restore(c.UserContext(), c.Params("type"), id, adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendSuccess(c, item)
+}
+
+// PurgeTrash queues a purge of the records deleted before the retention period
+// @Summary Purge deleted records
+// @Description Queue a job permanently deleting the records deleted more than the retention period ago,
+// @Description along with the Qdrant vectors of knowledge entries. Purges also run on a schedule.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body TrashAdminRequest true "Admin"
+// @Success 202 {object} utils.APIResponse{data=JobQueuedResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/trash/purge [post]
+func (h *TrashHandler) PurgeTrash(c *fiber.Ctx) error {
+	var req TrashAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	adminID, err := requestAdminID(c, req.AdminID, "admin_id")
+	if err != nil {
+		return err
+	}
+
+	job, err := h.trashService.ScheduleRun(c.UserContext(), adminID)
+	if err != nil {
+		return err
+	}
+	return utils.SendJSON(c, fiber.StatusAccepted, utils.SuccessResponse(JobQueuedResponse{Message: "Trash purge queued", JobID: job.ID}))
+}
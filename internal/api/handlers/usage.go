@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UsageHandler exposes token usage and cost analytics
+type UsageHandler struct {
+	usageService *services.UsageService
+	logger       *log.Logger
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(usageService *services.UsageService, logger *log.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+		logger:       logger,
+	}
+}
+
+// GetUsage reports token usage and estimated cost
+// @Summary Get token usage and cost
+// @Description Aggregate the recorded prompt and completion tokens and estimated cost of chat answers per user, per topic, and per day
+// @Tags analytics
+// @Produce json
+// @Param since query string false "Only count usage since this date (YYYY-MM-DD)"
+// @Param until query string false "Only count usage up to and including this date (YYYY-MM-DD)"
+// @Success 200 {object} utils.APIResponse{data=services.UsageReport}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /analytics/usage [get]
+func (h *UsageHandler) GetUsage(c *fiber.Ctx) error {
+	var since, until *time.Time
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid since parameter, expected YYYY-MM-DD")
+		}
+		since = &parsed
+	}
+	if untilStr := c.Query("until"); untilStr != "" {
+		parsed, err := time.Parse("2006-01-02", untilStr)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid until parameter, expected YYYY-MM-DD")
+		}
+		// Include the whole day
+		parsed = parsed.AddDate(0, 0, 1)
+		until = &parsed
+	}
+
+	report, err := h.usageService.GetUsageReport(since, until)
+	if err != nil {
+		h.logger.Printf("Error building usage report: %v", err)
+		return utils.SendError(c, fiber.StatusInternalServerError, "Failed to build usage report")
+	}
+
+	return utils.SendSuccess(c, report)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Impersonation headers. Responses to impersonated requests name the user being impersonated.
+const (
+	impersonationTokenHeader = "X-Impersonation-Token"
+	impersonatingUserHeader  = "X-Impersonating-User"
+)
+
+const impersonationLocal = "impersonation"
+
+// impersonation lets requests carrying an impersonation token act as the target user.
+// Impersonated requests are read-only and each one is recorded in the audit trail.
+func impersonation(impersonationService *services.ImpersonationService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get(impersonationTokenHeader)
+		if token == "" {
+			return c.Next()
+		}
+
+		session, err := impersonationService.Resolve(c.UserContext(), token)
+		if errors.Is(err, services.ErrImpersonationInvalid) {
+			log.Printf("[WARNING] Rejected impersonation token on %s %s from %s", c.Method(), c.Path(), c.IP())
+			return utils.SendError(c, fiber.StatusUnauthorized, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+
+		c.Set(impersonatingUserHeader, session.TargetUserID.String())
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			impersonationService.RecordRequest(session, models.ImpersonationBlocked, c.Method(), c.Path(), fiber.StatusForbidden)
+			return utils.SendError(c, fiber.StatusForbidden, "Impersonation is read-only")
+		}
+
+		c.Locals(impersonationLocal, session)
+		if err := c.Next(); err != nil {
+			// Resolve the status now so the audit trail records what the admin saw
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+		impersonationService.RecordRequest(session, models.ImpersonationRequest, c.Method(), c.Path(), c.Response().StatusCode())
+		return nil
+	}
+}
+
+// impersonatedUserID returns the user an admin is acting as on this request
+func impersonatedUserID(c *fiber.Ctx) (uuid.UUID, bool) {
+	session, ok := c.Locals(impersonationLocal).(*models.ImpersonationSession)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return session.TargetUserID, true
+}
@@ -1,23 +1,29 @@
 package api
 
 import (
+	"errors"
 	"strconv"
 	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 // @Summary Get knowledge entries
-// @Description Get knowledge entries with optional filtering and pagination
+// @Description Get knowledge entries with optional filtering and pagination. Only the entries the signed-in user may see
+// @Description are listed, and only editors and admins see unpublished entries.
 // @Tags knowledge
 // @Accept json
 // @Produce json
 // @Param category query string false "Filter by category"
 // @Param published query boolean false "Filter by published status"
+// @Param max_reading_minutes query number false "Only entries that take at most this many minutes to read"
+// @Param complexity query string false "Filter by complexity (easy, moderate, advanced)"
 // @Param limit query int false "Limit number of results" default(20)
 // @Param offset query int false "Offset for pagination" default(0)
-// @Success 200 {array} models.KnowledgeEntry
+// @Success 200 {object} utils.APIResponse{data=[]models.KnowledgeEntry}
 // @Router /knowledge [get]
 func (s *Server) getKnowledgeEntries(c *fiber.Ctx) error {
 	category := c.Query("category")
@@ -29,11 +35,16 @@ func (s *Server) getKnowledgeEntries(c *fiber.Ctx) error {
 	if publishedStr != "" {
 		published, err := strconv.ParseBool(publishedStr)
 		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid published parameter"})
+			return utils.SendError(c, 400, "Invalid published parameter")
 		}
 		isPublished = &published
 	}
 
+	reading, err := parseReadingFilter(c)
+	if err != nil {
+		return err
+	}
+
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 {
 		limit = 20
@@ -44,88 +55,190 @@ func (s *Server) getKnowledgeEntries(c *fiber.Ctx) error {
 		offset = 0
 	}
 
-	entries, err := s.knowledgeService.GetKnowledgeEntries(category, isPublished, limit, offset)
+	entries, err := s.knowledgeService.GetKnowledgeEntries(category, isPublished, reading, s.retrievalScope(c), limit, offset)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch knowledge entries"})
+		return utils.SendError(c, 500, "Failed to fetch knowledge entries")
 	}
 
-	return c.JSON(entries)
+	return utils.SendSuccess(c, entries)
 }
 
 // @Summary Create knowledge entry
-// @Description Create a new knowledge entry
+// @Description Create a new knowledge entry. With publish_at in the future the entry stays unpublished until then;
+// @Description with unpublish_at it is unpublished at that time, e.g. for a holiday workflow.
 // @Tags knowledge
 // @Accept json
 // @Produce json
 // @Param entry body models.KnowledgeEntry true "Knowledge entry data"
-// @Success 201 {object} models.KnowledgeEntry
+// @Success 201 {object} utils.APIResponse{data=models.KnowledgeEntry}
 // @Router /knowledge [post]
 func (s *Server) createKnowledgeEntry(c *fiber.Ctx) error {
 	var entry models.KnowledgeEntry
 	if err := c.BodyParser(&entry); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
-	// TODO: Get user ID from JWT token
-	entry.CreatedBy = uuid.New() // Placeholder
+	entry.CreatedBy = uuid.New() // Placeholder for anonymous requests
+	if authenticated, ok := authenticatedUserID(c); ok {
+		entry.CreatedBy = authenticated
+	}
 
 	if err := s.knowledgeService.CreateKnowledgeEntry(c.Context(), &entry); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create knowledge entry"})
+		if errors.Is(err, services.ErrValidation) {
+			return err
+		}
+		return utils.SendError(c, 500, "Failed to create knowledge entry")
 	}
 
-	return c.Status(201).JSON(entry)
+	return utils.SendJSON(c, 201, utils.SuccessResponse(entry))
 }
 
 // @Summary Search knowledge entries
-// @Description Search knowledge entries by query
+// @Description Full-text search of the published knowledge entries, best matches first. The query accepts web search
+// @Description syntax: quoted phrases, OR, and -excluded terms. The response carries the hits of the page, the hit counts
+// @Description per category, tag and template across all pages, and the total hit count in meta.
 // @Tags knowledge
 // @Accept json
 // @Produce json
 // @Param q query string true "Search query"
-// @Param limit query int false "Limit number of results" default(10)
-// @Success 200 {array} models.KnowledgeEntry
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param template_id query string false "Filter by template ID"
+// @Param highlight query boolean false "Return a snippet of each hit with the matched terms wrapped in <mark>" default(false)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Hits per page" default(20)
+// @Param max_reading_minutes query number false "Only entries that take at most this many minutes to read"
+// @Param complexity query string false "Filter by complexity (easy, moderate, advanced)"
+// @Success 200 {object} utils.APIResponse{data=services.FullTextResult,meta=utils.Meta}
 // @Router /knowledge/search [get]
 func (s *Server) searchKnowledgeEntries(c *fiber.Ctx) error {
 	query := c.Query("q")
 	if query == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'q' is required"})
+		return utils.SendError(c, 400, "Query parameter 'q' is required")
 	}
 
-	limitStr := c.Query("limit", "10")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+	reading, err := parseReadingFilter(c)
+	if err != nil {
+		return err
 	}
 
-	entries, err := s.knowledgeService.SearchKnowledgeEntries(c.Context(), query, limit)
+	page, limit := utils.ParsePagination(c)
+	search := services.FullTextQuery{
+		Query:     query,
+		Category:  c.Query("category"),
+		Tag:       c.Query("tag"),
+		Reading:   reading,
+		Highlight: c.QueryBool("highlight", false),
+		Limit:     limit,
+		Offset:    (page - 1) * limit,
+	}
+	if templateIDStr := c.Query("template_id"); templateIDStr != "" {
+		templateID, err := uuid.Parse(templateIDStr)
+		if err != nil {
+			return utils.SendError(c, 400, "Invalid template_id parameter")
+		}
+		search.TemplateID = &templateID
+	}
+
+	search.Scope = s.retrievalScope(c)
+
+	result, err := s.knowledgeService.FullTextSearch(c.Context(), search)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to search knowledge entries"})
+		return err
+	}
+	// Further pages of the same search are not searches of their own
+	if userID, ok := analyticsUser(c); ok && search.Offset == 0 {
+		s.entryAnalytics.RecordSearch(query, models.ViewFromSearch, userID, int(result.Total))
 	}
 
-	return c.JSON(entries)
+	return utils.SendPaginated(c, result, page, limit, int(result.Total))
 }
 
 // @Summary Get knowledge entry
-// @Description Get a knowledge entry by ID
+// @Description Get a knowledge entry by ID. The view is recorded for the entry analytics, for the signed-in user, with where the entry was opened from.
+// @Description Entries the signed-in user may not see, and unpublished entries for users other than editors and admins, are not found.
 // @Tags knowledge
 // @Accept json
 // @Produce json
 // @Param id path string true "Knowledge entry ID"
-// @Success 200 {object} models.KnowledgeEntry
+// @Param source query string false "Where the entry was opened from: search, chat (a citation of an answer) or direct" default(direct)
+// @Success 200 {object} utils.APIResponse{data=models.KnowledgeEntry}
 // @Router /knowledge/{id} [get]
 func (s *Server) getKnowledgeEntry(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid knowledge entry ID"})
+		return utils.SendError(c, 400, "Invalid knowledge entry ID")
 	}
 
-	entry, err := s.knowledgeService.GetKnowledgeEntryByID(id)
+	source := models.ViewSource(c.Query("source", string(models.ViewDirect)))
+	switch source {
+	case models.ViewFromSearch, models.ViewFromChat, models.ViewDirect:
+	default:
+		return utils.SendError(c, 400, "Invalid source parameter, expected search, chat or direct")
+	}
+
+	entry, err := s.knowledgeService.GetKnowledgeEntryByID(id, s.retrievalScope(c))
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Knowledge entry not found"})
+		return err
+	}
+	if userID, ok := analyticsUser(c); ok {
+		s.entryAnalytics.RecordView(entry.ID, userID, source)
 	}
 
-	return c.JSON(entry)
+	return utils.SendSuccess(c, entry)
+}
+
+// analyticsUser returns the user a search or view is recorded for in the entry analytics: the signed-in
+// user, nil when anonymous. Requests of an admin impersonating a user are not recorded.
+func analyticsUser(c *fiber.Ctx) (*uuid.UUID, bool) {
+	if _, ok := impersonatedUserID(c); ok {
+		return nil, false
+	}
+	if authenticated, ok := authenticatedUserID(c); ok {
+		return &authenticated, true
+	}
+	return nil, true
+}
+
+// retrievalScope is the retrieval scope of the user of a request: the impersonated user, else the signed-in
+// user. The scope is never taken from the request itself; anonymous requests only see entries without an ACL.
+func (s *Server) retrievalScope(c *fiber.Ctx) services.RetrievalScope {
+	if impersonated, ok := impersonatedUserID(c); ok {
+		return s.knowledgeService.ScopeForUser(impersonated)
+	}
+	if authenticated, ok := authenticatedUserID(c); ok {
+		return s.knowledgeService.ScopeForUser(authenticated)
+	}
+	return services.RetrievalScope{}
+}
+
+// @Summary Get related knowledge entries
+// @Description Get the published entries most similar to a knowledge entry by the vector similarity of their embeddings
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge entry ID"
+// @Param limit query int false "Maximum number of entries" default(5)
+// @Success 200 {object} utils.APIResponse{data=[]services.RelatedEntry}
+// @Router /knowledge/{id}/related [get]
+func (s *Server) getRelatedEntries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.SendError(c, 400, "Invalid knowledge entry ID")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "5"))
+	if err != nil || limit <= 0 || limit > 20 {
+		limit = 5
+	}
+
+	related, err := s.knowledgeService.RelatedEntries(c.Context(), id, limit, s.retrievalScope(c))
+	if err != nil {
+		return err
+	}
+
+	return utils.SendSuccess(c, related)
 }
 
 // @Summary Update knowledge entry
@@ -135,30 +248,35 @@ func (s *Server) getKnowledgeEntry(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Knowledge entry ID"
 // @Param entry body models.KnowledgeEntry true "Knowledge entry data"
-// @Success 200 {object} models.KnowledgeEntry
+// @Success 200 {object} utils.APIResponse{data=models.KnowledgeEntry}
 // @Router /knowledge/{id} [put]
 func (s *Server) updateKnowledgeEntry(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid knowledge entry ID"})
+		return utils.SendError(c, 400, "Invalid knowledge entry ID")
 	}
 
 	var entry models.KnowledgeEntry
 	if err := c.BodyParser(&entry); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
 	entry.ID = id
-	// TODO: Get user ID from JWT token
-	updatedBy := uuid.New() // Placeholder
+	updatedBy := uuid.New() // Placeholder for anonymous requests
+	if authenticated, ok := authenticatedUserID(c); ok {
+		updatedBy = authenticated
+	}
 	entry.UpdatedBy = &updatedBy
 
 	if err := s.knowledgeService.UpdateKnowledgeEntry(c.Context(), &entry); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update knowledge entry"})
+		if errors.Is(err, services.ErrValidation) {
+			return err
+		}
+		return utils.SendError(c, 500, "Failed to update knowledge entry")
 	}
 
-	return c.JSON(entry)
+	return utils.SendSuccess(c, entry)
 }
 
 // @Summary Delete knowledge entry
@@ -173,12 +291,31 @@ func (s *Server) deleteKnowledgeEntry(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid knowledge entry ID"})
+		return utils.SendError(c, 400, "Invalid knowledge entry ID")
 	}
 
 	if err := s.knowledgeService.DeleteKnowledgeEntry(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete knowledge entry"})
+		return err
 	}
 
 	return c.SendStatus(204)
 }
+
+// parseReadingFilter reads the max_reading_minutes and complexity query parameters
+func parseReadingFilter(c *fiber.Ctx) (services.ReadingFilter, error) {
+	var filter services.ReadingFilter
+	if minutesStr := c.Query("max_reading_minutes"); minutesStr != "" {
+		minutes, err := strconv.ParseFloat(minutesStr, 64)
+		if err != nil || minutes <= 0 {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid max_reading_minutes parameter")
+		}
+		filter.MaxReadingSeconds = int(minutes * 60)
+	}
+	if complexity := models.ComplexityLevel(c.Query("complexity")); complexity != "" {
+		if !services.ValidComplexity(complexity) {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid complexity parameter, expected easy, moderate, or advanced")
+		}
+		filter.Complexity = complexity
+	}
+	return filter, nil
+}
@@ -1,96 +1,605 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"tic-knowledge-system/docs"
 	"tic-knowledge-system/internal/api/handlers"
 	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/models"
 	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 type Server struct {
-	app                 *fiber.App
-	cfg                 *config.Config
-	db                  *gorm.DB
-	knowledgeService    *services.KnowledgeService
-	chatService         *services.ChatService
-	openAIService       *services.OpenAIService
-	geminiService       *services.GeminiService
-	unifiedAIService    *services.UnifiedAIService
-	enhancedChatService *services.EnhancedChatService
-	vectorService       *services.VectorService
-	documentService     *services.DocumentService
-	fileUploadService   *services.FileUploadService
-	assistantService    *services.OpenAIAssistantService
-	aiHandler           *handlers.AIHandler
-	documentHandler     *handlers.DocumentHandler
-	fileUploadHandler   *handlers.FileUploadHandler
-	assistantHandler    *handlers.OpenAIAssistantHandler
+	app                  *fiber.App
+	cfg                  *config.Config
+	db                   *gorm.DB
+	knowledgeService     *services.KnowledgeService
+	chatService          *services.ChatService
+	openAIService        *services.OpenAIService
+	geminiService        *services.GeminiService
+	unifiedAIService     *services.UnifiedAIService
+	enhancedChatService  *services.EnhancedChatService
+	handoffService       *services.HandoffService
+	bookmarkService      *services.BookmarkService
+	personaService       *services.PersonaService
+	entryAnalytics       *services.EntryAnalyticsService
+	vectorService        services.VectorStore
+	ingestionService     *services.IngestionService
+	assistantService     services.AssistantEngine
+	jobQueue             *services.JobQueue
+	aiHandler            *handlers.AIHandler
+	documentHandler      *handlers.DocumentHandler
+	fileUploadHandler    *handlers.FileUploadHandler
+	assistantHandler     *handlers.OpenAIAssistantHandler
+	jobsHandler          *handlers.JobsHandler
+	jobDashboardHandler  *handlers.JobDashboardHandler
+	adminStatusHandler   *handlers.AdminStatusHandler
+	settingsHandler      *handlers.SettingsHandler
+	assistantAdmin       *handlers.AssistantAdminHandler
+	leaderboardHandler   *handlers.LeaderboardHandler
+	analyticsHandler     *handlers.EntryAnalyticsHandler
+	bootstrapHandler     *handlers.BootstrapHandler
+	retrievalEvalHandler *handlers.RetrievalEvalHandler
+	experimentHandler    *handlers.ExperimentHandler
+	topicCoverageHandler *handlers.TopicCoverageHandler
+	openAIGCHandler      *handlers.OpenAIGCHandler
+	usageHandler         *handlers.UsageHandler
+	quotaHandler         *handlers.QuotaHandler
+	promptHandler        *handlers.PromptHandler
+	presetHandler        *handlers.RetrievalPresetHandler
+	personaHandler       *handlers.PersonaHandler
+	announcementHandler  *handlers.AnnouncementHandler
+	impersonationHandler *handlers.ImpersonationHandler
+	helpHandler          *handlers.HelpHandler
+	moderationHandler    *handlers.ModerationHandler
+	quarantineHandler    *handlers.QuarantineHandler
+	chatRetentionHandler *handlers.ChatRetentionHandler
+	confluenceHandler    *handlers.ConfluenceHandler
+	notionHandler        *handlers.NotionHandler
+	googleDriveHandler   *handlers.GoogleDriveHandler
+	schedulesHandler     *handlers.SchedulesHandler
+	staleReviewHandler   *handlers.StaleReviewHandler
+	trashHandler         *handlers.TrashHandler
+	handoffHandler       *handlers.HandoffHandler
+	attachmentHandler    *handlers.ChatAttachmentHandler
+	ssoHandler           *handlers.SSOHandler
+	curationHandler      *handlers.KnowledgeCurationHandler
+	configBundleHandler  *handlers.ConfigBundleHandler
+	widgetSigner         *services.RequestSigner
+	webhookSigner        *services.RequestSigner
+	dependencies         *services.DependencyMonitor
+	grpc                 *grpc.Server
+	stopBackground       context.CancelFunc
 }
 
-func NewServer(cfg *config.Config, db *gorm.DB) *fiber.App {
+// NewServer builds the API server and starts its background workers. reads may be nil to serve every query
+// from the primary.
+func NewServer(cfg *config.Config, db *gorm.DB, reads services.ReadReplicaRouter) *Server {
+	uploadMaxSizeMB, _ := strconv.Atoi(cfg.UploadMaxSizeMB)
+	uploadPolicy := NewUploadPolicy(uploadMaxSizeMB, cfg.UploadAllowedExtensions)
+	// Leave room for the multipart framing and other form fields around the largest upload
+	bodyLimit := int(uploadPolicy.MaxBytes) + 1<<20
+	if bodyLimit < fiber.DefaultBodyLimit {
+		bodyLimit = fiber.DefaultBodyLimit
+	}
+
 	app := fiber.New(fiber.Config{
 		ErrorHandler: errorHandler,
+		BodyLimit:    bodyLimit,
 	})
 
+	// Background workers run until Shutdown
+	background, stopBackground := context.WithCancel(context.Background())
+
 	// Initialize services
 	maxTokens, _ := strconv.Atoi(cfg.MaxTokens)
 	temperature64, _ := strconv.ParseFloat(cfg.Temperature, 32)
 	temperature := float32(temperature64)
 
-	openAIService := services.NewOpenAIService(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIEmbeddingModel, maxTokens, temperature)
+	var openAIService *services.OpenAIService
+	if cfg.AzureOpenAIEndpoint != "" {
+		openAIService = services.NewAzureOpenAIService(cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIAPIKey, cfg.AzureOpenAIAPIVersion,
+			cfg.AzureOpenAIDeployment, cfg.AzureOpenAIEmbeddingDeployment, maxTokens, temperature)
+		log.Printf("[INFO] Using Azure OpenAI at %s (deployment=%s)", cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIDeployment)
+	} else {
+		openAIService = services.NewOpenAIService(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIEmbeddingModel, maxTokens, temperature)
+	}
 	geminiService, err := services.NewGeminiService(cfg.GeminiAPIKey, cfg.GeminiModel, maxTokens, temperature)
 	if err != nil {
 		log.Printf("[WARNING] Failed to initialize Gemini service: %v", err)
 		// Continue without Gemini service
 	}
 	unifiedAIService := services.NewUnifiedAIService(openAIService, geminiService, services.AIProvider(cfg.PrimaryAIProvider))
-	vectorService := services.NewVectorService(cfg.VectorDBURL, cfg.QdrantCollectionName)
-	knowledgeService := services.NewKnowledgeService(db, openAIService, vectorService)
+	var ollamaService *services.OllamaService
+	if cfg.OllamaBaseURL != "" {
+		ollamaService = services.NewOllamaService(cfg.OllamaBaseURL, cfg.OllamaAPIKey, cfg.OllamaModel, cfg.OllamaEmbeddingModel, maxTokens, temperature)
+		unifiedAIService.SetOllamaService(ollamaService)
+		log.Printf("[INFO] Ollama provider enabled at %s (model=%s)", cfg.OllamaBaseURL, cfg.OllamaModel)
+	}
+	circuitThreshold, _ := strconv.Atoi(cfg.AICircuitFailureThreshold)
+	circuitCooldown, _ := strconv.Atoi(cfg.AICircuitCooldownSeconds)
+	validateResponses, _ := strconv.ParseBool(cfg.AIResponseValidation)
+	unifiedAIService.SetResponseValidation(validateResponses)
+	unifiedAIService.SetHealthMonitor(services.NewProviderHealthMonitor(circuitThreshold, time.Duration(circuitCooldown)*time.Second))
+	if healthInterval, _ := strconv.Atoi(cfg.AIHealthCheckIntervalSeconds); healthInterval > 0 {
+		unifiedAIService.StartHealthChecks(background, time.Duration(healthInterval)*time.Second)
+	}
+	promptService := services.NewPromptService(db)
+	openAIContextWindow, _ := strconv.Atoi(cfg.OpenAIContextWindow)
+	openAIService.SetPromptSource(promptService)
+	openAIService.SetContextWindow(openAIContextWindow)
+	if geminiService != nil {
+		geminiContextWindow, _ := strconv.Atoi(cfg.GeminiContextWindow)
+		geminiService.SetPromptSource(promptService)
+		geminiService.SetContextWindow(geminiContextWindow)
+	}
+	if ollamaService != nil {
+		ollamaContextWindow, _ := strconv.Atoi(cfg.OllamaContextWindow)
+		ollamaService.SetPromptSource(promptService)
+		ollamaService.SetContextWindow(ollamaContextWindow)
+	}
+	vectorService, err := NewVectorStore(context.Background(), cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize the vector database: %v", err)
+	}
+	pollSeconds, _ := strconv.Atoi(cfg.JobPollIntervalSeconds)
+	jobQueue := services.NewJobQueue(db, time.Duration(pollSeconds)*time.Second)
+	knowledgeService := services.NewKnowledgeService(db, openAIService, vectorService, jobQueue)
+	if reads != nil {
+		knowledgeService.SetReadRouter(reads)
+	}
+	embeddingService, err := NewEmbeddingService(cfg, unifiedAIService)
+	if err != nil {
+		log.Fatalf("Failed to initialize embeddings: %v", err)
+	}
+	knowledgeService.SetEmbedder(embeddingService)
+	chunkMaxTokens, _ := strconv.Atoi(cfg.ChunkMaxTokens)
+	chunkOverlapTokens, _ := strconv.Atoi(cfg.ChunkOverlapTokens)
+	knowledgeService.SetChunkOptions(services.ChunkOptions{MaxTokens: chunkMaxTokens, OverlapTokens: chunkOverlapTokens})
+	// Runtime settings changed by admins take precedence over the environment
+	settingsService := services.NewSettingsService(db, unifiedAIService, map[string]string{
+		services.SettingPrimaryProvider:    cfg.PrimaryAIProvider,
+		services.SettingOpenAIModel:        cfg.OpenAIModel,
+		services.SettingGeminiModel:        cfg.GeminiModel,
+		services.SettingTemperature:        cfg.Temperature,
+		services.SettingMaxTokens:          cfg.MaxTokens,
+		services.SettingChunkMaxTokens:     cfg.ChunkMaxTokens,
+		services.SettingChunkOverlapTokens: cfg.ChunkOverlapTokens,
+		services.SettingImportSections:     cfg.ImportSections,
+	})
+	settingsService.Load(context.Background())
+	unifiedAIService.SetSettings(settingsService)
+	knowledgeService.SetSettings(settingsService)
+	presetService := services.NewRetrievalPresetService(db, knowledgeService)
+	if enabled, _ := strconv.ParseBool(cfg.RelatedQuestionsEnabled); enabled {
+		knowledgeService.SetQuestionGenerator(services.NewRelatedQuestionGenerator(unifiedAIService))
+	}
+	seeAlsoLimit, _ := strconv.Atoi(cfg.SeeAlsoLimit)
+	seeAlsoMinScore, _ := strconv.ParseFloat(cfg.SeeAlsoMinScore, 64)
+	knowledgeService.SetSeeAlso(services.SeeAlsoOptions{Limit: seeAlsoLimit, MinScore: seeAlsoMinScore})
 	chatService := services.NewChatService(db, openAIService, knowledgeService)
-	enhancedChatService := services.NewEnhancedChatService(db, unifiedAIService, knowledgeService)
-	documentService := services.NewDocumentService(db, unifiedAIService, log.Default())
+	var answerCache *services.SemanticCache
+	if enabled, _ := strconv.ParseBool(cfg.SemanticCacheEnabled); enabled {
+		threshold, err := strconv.ParseFloat(cfg.SemanticCacheThreshold, 64)
+		if err != nil {
+			threshold = 0.97
+		}
+		ttlMinutes, _ := strconv.Atoi(cfg.SemanticCacheTTLMinutes)
+		maxEntries, _ := strconv.Atoi(cfg.SemanticCacheMaxEntries)
+		answerCache = services.NewSemanticCache(threshold, time.Duration(ttlMinutes)*time.Minute, maxEntries)
+	}
+	var notifier services.Notifier = services.LogNotifier{}
+	if cfg.SMTPHost != "" {
+		notifier = services.NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	} else {
+		log.Printf("[WARNING] SMTP_HOST not set, email notifications will only be logged")
+	}
+	deferredAnswerService := services.NewDeferredAnswerService(db, unifiedAIService, knowledgeService, jobQueue, notifier)
+	enhancedChatService := services.NewEnhancedChatService(db, unifiedAIService, knowledgeService, answerCache, services.AIProvider(cfg.EmbeddingProvider), deferredAnswerService)
+	if dedupSeconds, _ := strconv.Atoi(cfg.ChatDedupWindowSeconds); dedupSeconds > 0 {
+		dedupWindow := time.Duration(dedupSeconds) * time.Second
+		chatService.SetDeduplicator(services.NewChatDeduplicator(dedupWindow))
+		enhancedChatService.SetDeduplicator(services.NewChatDeduplicator(dedupWindow))
+	}
+	topicClassifier := services.NewTopicClassifier(db)
+	usageService := services.NewUsageService(db, reads, topicClassifier)
+	chatService.SetUsageService(usageService)
+	enhancedChatService.SetUsageService(usageService)
+	deferredAnswerService.SetUsageService(usageService)
+	var userQuota, orgQuota services.QuotaLimits
+	userQuota.MonthlyTokens, _ = strconv.ParseInt(cfg.QuotaUserMonthlyTokens, 10, 64)
+	userQuota.MonthlyCostUSD, _ = strconv.ParseFloat(cfg.QuotaUserMonthlyCostUSD, 64)
+	orgQuota.MonthlyTokens, _ = strconv.ParseInt(cfg.QuotaOrgMonthlyTokens, 10, 64)
+	orgQuota.MonthlyCostUSD, _ = strconv.ParseFloat(cfg.QuotaOrgMonthlyCostUSD, 64)
+	quotaService := services.NewQuotaService(db, userQuota, orgQuota)
+	enhancedChatService.SetQuotaService(quotaService)
+	confidenceThreshold, _ := strconv.ParseFloat(cfg.AnswerConfidenceThreshold, 64)
+	chatService.SetConfidence(services.ConfidenceOptions{Threshold: confidenceThreshold})
+	enhancedChatService.SetConfidence(services.ConfidenceOptions{Threshold: confidenceThreshold})
+	handoffService := services.NewHandoffService(db)
+	chatService.SetHandoff(handoffService)
+	enhancedChatService.SetHandoff(handoffService)
+	var queryRewrite services.QueryRewriteOptions
+	queryRewrite.Enabled, _ = strconv.ParseBool(cfg.QueryRewriteEnabled)
+	queryRewrite.Paraphrases, _ = strconv.Atoi(cfg.QueryRewriteParaphrases)
+	queryRewriter := services.NewQueryRewriter(db, unifiedAIService, queryRewrite)
+	chatService.SetQueryRewriter(queryRewriter)
+	enhancedChatService.SetQueryRewriter(queryRewriter)
+	entryAnalytics := services.NewEntryAnalyticsService(db, reads)
+	entryAnalytics.SetEmbeddings(unifiedAIService, services.AIProvider(cfg.EmbeddingProvider))
+	chatService.SetAnalytics(entryAnalytics)
+	enhancedChatService.SetAnalytics(entryAnalytics)
+	announcementService := services.NewAnnouncementService(db)
+	chatService.SetAnnouncements(announcementService)
+	enhancedChatService.SetAnnouncements(announcementService)
+	deferredAnswerService.SetAnnouncements(announcementService)
+	guardrailService := newGuardrailService(cfg, db, openAIService)
+	enhancedChatService.SetGuardrails(guardrailService)
+	if enabled, _ := strconv.ParseBool(cfg.TranslationEnabled); enabled {
+		translator := services.NewTranslationService(unifiedAIService)
+		chatService.SetTranslator(translator)
+		enhancedChatService.SetTranslator(translator)
+	}
 
-	// Initialize file upload service
-	uploadDir := "./uploads"                               // You can configure this
+	// Initialize the document ingestion pipeline
+	uploadDir := cfg.StorageLocalDir
 	vectorStoreID := "vs_6873699daedc8191bb505a14254eeab3" // Fixed vector store ID
-	fileUploadService := services.NewFileUploadService(db, cfg.OpenAIKey, vectorStoreID, uploadDir)
+	fileStorage, err := NewFileStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+	chatAttachmentMaxMB, _ := strconv.Atoi(cfg.ChatAttachmentMaxMB)
+	chatAttachmentService := services.NewChatAttachmentService(db, fileStorage, int64(chatAttachmentMaxMB)<<20)
+	enhancedChatService.SetAttachments(chatAttachmentService)
+	ingestionService := services.NewIngestionService(db, cfg.OpenAIKey, vectorStoreID, fileStorage, jobQueue)
+	ingestionService.SetKnowledgeBase(knowledgeService, unifiedAIService)
+	ingestionService.SetSettings(settingsService)
+	spreadsheetChunkRows, _ := strconv.Atoi(cfg.SpreadsheetChunkRows)
+	ingestionService.SetRowsPerChunk(spreadsheetChunkRows)
+	webCrawlMaxPages, _ := strconv.Atoi(cfg.WebCrawlMaxPages)
+	ingestionService.SetCrawlMaxPages(webCrawlMaxPages)
+	if enrich, _ := strconv.ParseBool(cfg.ImportEnrichment); enrich {
+		ingestionService.SetMetadataEnricher(services.NewMetadataEnricher(db, unifiedAIService))
+	}
+	downloadURLMinutes, _ := strconv.Atoi(cfg.StorageDownloadURLMinutes)
+	ingestionService.SetDownloadURLExpiry(time.Duration(downloadURLMinutes) * time.Minute)
+	if notifyUploader, _ := strconv.ParseBool(cfg.DocumentNotifyUploader); notifyUploader {
+		ingestionService.SetNotifier(notifier)
+	}
+	if cfg.DocumentWebhookURL != "" {
+		ingestionService.SetWebhook(services.NewDocumentWebhook(cfg.DocumentWebhookURL, cfg.DocumentWebhookSecret))
+	}
 
 	// Initialize OpenAI Assistant service with default thread ID
 	defaultThreadID := "thread_5GyQSnIxNy8uwMN2liLPuphc" // Your example thread ID
-	assistantService := services.NewOpenAIAssistantService(cfg.OpenAIKey, defaultThreadID, log.Default())
+	var assistantService services.AssistantEngine
+	switch {
+	case cfg.AssistantEngine == services.AssistantEngineResponses && cfg.AzureOpenAIEndpoint == "":
+		responsesModel := cfg.ResponsesModel
+		if responsesModel == "" {
+			responsesModel = cfg.OpenAIModel
+		}
+		responsesService := services.NewOpenAIResponsesService(cfg.OpenAIKey, responsesModel, cfg.ResponsesInstructions, []string{vectorStoreID}, log.Default())
+		responsesService.SetThreadTracking(db)
+		assistantService = responsesService
+		log.Printf("[INFO] Assistant endpoints use the Responses API (model=%s)", responsesModel)
+	case cfg.AzureOpenAIEndpoint != "":
+		if cfg.AssistantEngine == services.AssistantEngineResponses {
+			log.Printf("[WARNING] ASSISTANT_ENGINE=responses is not supported with Azure OpenAI, using the Assistants API")
+		}
+		azureAssistants := services.NewAzureOpenAIAssistantService(cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIAPIKey, cfg.AzureOpenAIAssistantsAPIVersion, defaultThreadID, log.Default())
+		azureAssistants.SetThreadTracking(db)
+		assistantService = azureAssistants
+	default:
+		if cfg.AssistantEngine != services.AssistantEngineAssistants {
+			log.Printf("[WARNING] Unknown ASSISTANT_ENGINE %q, using the Assistants API", cfg.AssistantEngine)
+		}
+		assistants := services.NewOpenAIAssistantService(cfg.OpenAIKey, defaultThreadID, log.Default())
+		assistants.SetThreadTracking(db)
+		assistantService = assistants
+	}
+
+	// Assistants managed from this system, with instructions rendered from prompt templates
+	var assistantAdminService *services.AssistantAdminService
+	if cfg.AzureOpenAIEndpoint != "" {
+		assistantAdminService = services.NewAzureAssistantAdminService(db, promptService, cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIAPIKey,
+			cfg.AzureOpenAIAssistantsAPIVersion, cfg.AzureOpenAIDeployment, []string{vectorStoreID})
+	} else {
+		assistantAdminService = services.NewAssistantAdminService(db, promptService, cfg.OpenAIKey, cfg.OpenAIModel, []string{vectorStoreID})
+	}
+
+	// Register background job handlers and start the workers
+	knowledgeService.RegisterJobHandlers(jobQueue)
+	ingestionService.RegisterJobHandlers(jobQueue)
+	deferredAnswerService.RegisterJobHandlers(jobQueue)
+	retrievalEvalHour, _ := strconv.Atoi(cfg.RetrievalEvalHour)
+	retrievalEvalService := services.NewRetrievalEvalService(db, knowledgeService, jobQueue, retrievalEvalHour)
+	evalAnswers, _ := strconv.ParseBool(cfg.RetrievalEvalAnswers)
+	retrievalEvalService.SetAnswerEvaluation(unifiedAIService, evalAnswers)
+	retrievalEvalService.RegisterJobHandlers(jobQueue)
+	if err := retrievalEvalService.EnsureNightlySchedule(context.Background()); err != nil {
+		log.Printf("[WARNING] Failed to schedule nightly retrieval evaluation: %v", err)
+	}
+	experimentThreshold, _ := strconv.ParseFloat(cfg.ExperimentSimilarityThreshold, 64)
+	experimentService := services.NewExperimentService(db, knowledgeService, unifiedAIService, promptService, jobQueue,
+		services.AIProvider(cfg.EmbeddingProvider), experimentThreshold)
+	experimentService.RegisterJobHandlers(jobQueue)
+	gcMaxAgeHours, _ := strconv.Atoi(cfg.OpenAIGCMaxAgeHours)
+	openAIGCService := services.NewOpenAIGCService(db, cfg.OpenAIKey, vectorStoreID, time.Duration(gcMaxAgeHours)*time.Hour,
+		[]string{defaultThreadID}, jobQueue)
+	openAIGCService.RegisterJobHandlers(jobQueue)
+	chatRetentionService := services.NewChatRetentionService(db, chatService, openAIGCService, jobQueue, cfg.RetentionExportDir)
+	chatRetentionService.SetStorage(fileStorage)
+	chatRetentionService.RegisterJobHandlers(jobQueue)
+	confluenceService := services.NewConfluenceService(db, knowledgeService, jobQueue, services.ConfluenceConfig{
+		BaseURL:  cfg.ConfluenceBaseURL,
+		Email:    cfg.ConfluenceEmail,
+		APIToken: cfg.ConfluenceAPIToken,
+		SpaceKey: cfg.ConfluenceSpaceKey,
+		Category: cfg.ConfluenceCategory,
+	})
+	confluenceService.RegisterJobHandlers(jobQueue)
+	notionService := services.NewNotionSyncService(db, knowledgeService, jobQueue, services.NotionConfig{
+		APIToken:    cfg.NotionAPIToken,
+		DatabaseIDs: strings.Split(cfg.NotionDatabaseIDs, ","),
+		Category:    cfg.NotionCategory,
+	})
+	notionService.RegisterJobHandlers(jobQueue)
+	driveService := services.NewGoogleDriveService(db, ingestionService, jobQueue, services.GoogleDriveConfig{
+		CredentialsFile: cfg.GoogleDriveCredentialsFile,
+		FolderID:        cfg.GoogleDriveFolderID,
+		Target:          models.IngestionTarget(cfg.GoogleDriveTarget),
+		Category:        cfg.GoogleDriveCategory,
+		MaxBytes:        uploadPolicy.MaxBytes,
+	})
+	driveService.RegisterJobHandlers(jobQueue)
+	topicCoverageService := services.NewTopicCoverageService(db, reads, topicClassifier)
+	staleEntryDays, _ := strconv.Atoi(cfg.StaleEntryDays)
+	staleReminderDays, _ := strconv.Atoi(cfg.StaleReviewReminderDays)
+	staleReviewService := services.NewStaleReviewService(db, jobQueue, notifier, time.Duration(staleEntryDays)*24*time.Hour,
+		time.Duration(staleReminderDays)*24*time.Hour)
+	staleReviewService.RegisterJobHandlers(jobQueue)
+	trashRetentionDays, _ := strconv.Atoi(cfg.TrashRetentionDays)
+	trashService := services.NewTrashService(db, knowledgeService, vectorService, jobQueue, time.Duration(trashRetentionDays)*24*time.Hour)
+	trashService.SetStorage(fileStorage)
+	trashService.RegisterJobHandlers(jobQueue)
+	schedulerService := services.NewSchedulerService(db, jobQueue)
+	schedulerService.RegisterTask(services.TaskStaleEntries, "Flag entries not updated within the review age of their category and remind their creators",
+		func(ctx context.Context) (interface{}, error) { return staleReviewService.DetectStaleEntries(ctx) })
+	if confluenceService.Enabled() {
+		schedulerService.RegisterTask(services.TaskConfluenceSync, "Sync the pages of the Confluence space",
+			func(ctx context.Context) (interface{}, error) { return confluenceService.Sync(ctx) })
+	}
+	if notionService.Enabled() {
+		schedulerService.RegisterTask(services.TaskNotionSync, "Sync the Notion pages shared with the integration",
+			func(ctx context.Context) (interface{}, error) { return notionService.Sync(ctx) })
+	}
+	if driveService.Enabled() {
+		schedulerService.RegisterTask(services.TaskGoogleDriveSync, "Sync the files of the Google Drive folder",
+			func(ctx context.Context) (interface{}, error) { return driveService.Sync(ctx) })
+	}
+	schedulerService.RegisterTask(services.TaskAnalyticsRollup, "Classify pending questions and recompute topic and time-of-day shares",
+		func(ctx context.Context) (interface{}, error) { return topicCoverageService.RollupQuestionStats() })
+	schedulerService.RegisterTask(services.TaskVectorReindex, "Regenerate the embeddings of every published entry",
+		func(ctx context.Context) (interface{}, error) {
+			// Checked again so that a store rebuilt since startup takes embeddings without a restart
+			if err := embeddingService.Verify(ctx, db, vectorService); err != nil {
+				return nil, err
+			}
+			queued, err := knowledgeService.ReembedAllEntries(ctx)
+			return map[string]int{"queued": queued}, err
+		})
+	schedulerService.RegisterTask(services.TaskTrashPurge, "Permanently delete records deleted more than the trash retention period ago",
+		func(ctx context.Context) (interface{}, error) { return trashService.Purge(ctx) })
+	schedulerService.RegisterTask(services.TaskUnansweredEmbed, "Embed the logged unanswered questions for the clustered report",
+		func(ctx context.Context) (interface{}, error) {
+			embedded, err := entryAnalytics.EmbedPendingQueries(ctx, 5000)
+			return map[string]int{"embedded": embedded}, err
+		})
+	schedulerService.RegisterTask(services.TaskReplayUnanswered, "Answer in the background the chat questions left without an answer by a failed chat",
+		func(ctx context.Context) (interface{}, error) { return deferredAnswerService.ReplayUnanswered(ctx) })
+	schedulerService.RegisterTask(services.TaskPublishSchedule, "Publish and unpublish the entries whose publish_at or unpublish_at time has come",
+		func(ctx context.Context) (interface{}, error) { return knowledgeService.ApplyPublicationSchedule(ctx) })
+	schedulerService.RegisterTask(services.TaskChatRetention, "Archive or delete the chat sessions older than the active retention policies allow",
+		func(ctx context.Context) (interface{}, error) { return chatRetentionService.ApplyActivePolicies(ctx) })
+	if cfg.OpenAIKey != "" {
+		schedulerService.RegisterTask(services.TaskOpenAIGC, "Delete OpenAI files, vector-store files and assistant threads no record refers to",
+			func(ctx context.Context) (interface{}, error) { return openAIGCService.CollectOrphans(ctx) })
+	}
+
+	// Schedules of the tasks the system relies on, created unless one exists; admins manage them through /schedules
+	type defaultSchedule struct{ name, task, cron string }
+	defaultSchedules := []defaultSchedule{
+		{"Entry publication schedule", services.TaskPublishSchedule, "* * * * *"},
+		{"Daily stale-entry review", services.TaskStaleEntries, "0 3 * * *"},
+		{"Daily chat retention", services.TaskChatRetention, "0 2 * * *"},
+		{"Unanswered chat replay", services.TaskReplayUnanswered, "*/10 * * * *"},
+	}
+	if trashService.Retention() > 0 {
+		defaultSchedules = append(defaultSchedules, defaultSchedule{"Daily trash purge", services.TaskTrashPurge, "30 3 * * *"})
+	}
+	if cfg.OpenAIKey != "" {
+		defaultSchedules = append(defaultSchedules, defaultSchedule{"Daily OpenAI garbage collection", services.TaskOpenAIGC, "0 4 * * *"})
+	}
+	if confluenceService.Enabled() {
+		defaultSchedules = append(defaultSchedules, defaultSchedule{"Confluence sync", services.TaskConfluenceSync, "0 */6 * * *"})
+	}
+	if notionService.Enabled() {
+		defaultSchedules = append(defaultSchedules, defaultSchedule{"Notion sync", services.TaskNotionSync, "15 */6 * * *"})
+	}
+	if driveService.Enabled() {
+		defaultSchedules = append(defaultSchedules, defaultSchedule{"Google Drive sync", services.TaskGoogleDriveSync, "*/30 * * * *"})
+	}
+	for _, schedule := range defaultSchedules {
+		if err := schedulerService.EnsureSchedule(context.Background(), schedule.name, schedule.task, schedule.cron); err != nil {
+			log.Printf("[WARNING] Failed to schedule %s: %v", schedule.task, err)
+		}
+	}
+	schedulerService.RegisterJobHandlers(jobQueue)
+	if _, err := knowledgeService.BackfillReadingStats(context.Background()); err != nil {
+		log.Printf("[WARNING] Failed to compute reading stats of existing knowledge entries: %v", err)
+	}
+	if _, err := knowledgeService.BackfillLanguages(context.Background()); err != nil {
+		log.Printf("[WARNING] Failed to detect the language of existing knowledge entries: %v", err)
+	}
+	if _, err := knowledgeService.BackfillSearchText(context.Background()); err != nil {
+		log.Printf("[WARNING] Failed to compute the search text of existing knowledge entries: %v", err)
+	}
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := vectorService.EnsurePayloadIndexes(indexCtx); err != nil {
+		log.Printf("[WARNING] Failed to create the payload indexes of the vector collection, filtered searches scan payloads: %v", err)
+	}
+	if err := embeddingService.Verify(indexCtx, db, vectorService); err != nil {
+		log.Printf("[ERROR] Embedding is disabled until the vector store matches the embedding model and the vector_reindex task runs: %v", err)
+	}
+	cancelIndex()
+	jobWorkers, _ := strconv.Atoi(cfg.JobWorkers)
+	jobQueue.Start(background, jobWorkers)
+	schedulerService.Start(background)
+	readinessInterval, _ := strconv.Atoi(cfg.ReadinessCheckIntervalSeconds)
+	dependencies := services.NewDependencyMonitor(db, vectorService, unifiedAIService)
+	dependencies.Start(background, time.Duration(readinessInterval)*time.Second)
 
 	// Initialize handlers
 	aiHandler := handlers.NewAIHandler(enhancedChatService)
-	documentHandler := handlers.NewDocumentHandler(documentService, log.Default())
-	fileUploadHandler := handlers.NewFileUploadHandler(fileUploadService, db, log.Default())
+	documentHandler := handlers.NewDocumentHandler(ingestionService, log.Default())
+	fileUploadHandler := handlers.NewFileUploadHandler(ingestionService, db, log.Default())
 	assistantHandler := handlers.NewOpenAIAssistantHandler(assistantService, log.Default())
+	jobsHandler := handlers.NewJobsHandler(jobQueue, log.Default())
+	settingsHandler := handlers.NewSettingsHandler(settingsService, log.Default())
+	assistantAdminHandler := handlers.NewAssistantAdminHandler(assistantAdminService, log.Default())
+	jobDashboardService := services.NewJobDashboardService(db)
+	jobDashboardHandler := handlers.NewJobDashboardHandler(jobDashboardService, log.Default())
+	adminStatusHandler := handlers.NewAdminStatusHandler(services.NewAdminStatusService(db, dependencies, vectorService, unifiedAIService,
+		jobDashboardService, confluenceService, notionService, driveService), log.Default())
+	leaderboardHandler := handlers.NewLeaderboardHandler(services.NewLeaderboardService(db, reads), log.Default())
+	entryAnalyticsHandler := handlers.NewEntryAnalyticsHandler(entryAnalytics, log.Default())
+	retrievalEvalHandler := handlers.NewRetrievalEvalHandler(retrievalEvalService, log.Default())
+	experimentHandler := handlers.NewExperimentHandler(experimentService, log.Default())
+	topicCoverageHandler := handlers.NewTopicCoverageHandler(topicCoverageService, log.Default())
+	quotaHandler := handlers.NewQuotaHandler(quotaService, log.Default())
+	promptHandler := handlers.NewPromptHandler(promptService, log.Default())
+	presetHandler := handlers.NewRetrievalPresetHandler(presetService, log.Default())
+	personaService := services.NewPersonaService(db)
+	personaHandler := handlers.NewPersonaHandler(personaService, log.Default())
+	announcementHandler := handlers.NewAnnouncementHandler(announcementService, log.Default())
+	impersonationTTL, _ := strconv.Atoi(cfg.ImpersonationTTLMinutes)
+	impersonationService := services.NewImpersonationService(db, time.Duration(impersonationTTL)*time.Minute)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService, log.Default())
+	sessionTTL, _ := strconv.Atoi(cfg.SessionTTLHours)
+	authTokens := services.NewAuthTokenService(cfg.JWTSecret, time.Duration(sessionTTL)*time.Hour)
+	ssoService := services.NewSSOService(db, ssoConfig(cfg), authTokens)
+	secureCookies, _ := strconv.ParseBool(cfg.SessionCookieSecure)
+	ssoHandler := handlers.NewSSOHandler(ssoService, cfg.SSOPostLoginRedirect, secureCookies, log.Default())
+	helpTipsTTL, _ := strconv.Atoi(cfg.HelpTipsTTLHours)
+	moderationHandler := handlers.NewModerationHandler(guardrailService, log.Default())
+	chatRetentionHandler := handlers.NewChatRetentionHandler(chatRetentionService, log.Default())
+	confluenceHandler := handlers.NewConfluenceHandler(confluenceService, log.Default())
+	notionHandler := handlers.NewNotionHandler(notionService, log.Default())
+	googleDriveHandler := handlers.NewGoogleDriveHandler(driveService, log.Default())
+	schedulesHandler := handlers.NewSchedulesHandler(schedulerService, log.Default())
+	staleReviewHandler := handlers.NewStaleReviewHandler(staleReviewService, log.Default())
+	trashHandler := handlers.NewTrashHandler(trashService, log.Default())
+	handoffHandler := handlers.NewHandoffHandler(handoffService, log.Default())
+	attachmentHandler := handlers.NewChatAttachmentHandler(chatAttachmentService, log.Default())
+	curationHandler := handlers.NewKnowledgeCurationHandler(services.NewKnowledgeCurationService(db, knowledgeService, unifiedAIService), log.Default())
+	configBundleHandler := handlers.NewConfigBundleHandler(services.NewConfigBundleService(db, presetService, promptService), log.Default())
+	quarantineHandler := handlers.NewQuarantineHandler(services.NewQuarantineService(db, knowledgeService, ingestionService), log.Default())
+	helpHandler := handlers.NewHelpHandler(services.NewHelpService(db, knowledgeService, unifiedAIService, time.Duration(helpTipsTTL)*time.Hour), log.Default())
+	usageHandler := handlers.NewUsageHandler(usageService, log.Default())
+	openAIGCHandler := handlers.NewOpenAIGCHandler(openAIGCService, log.Default())
+	bootstrapHandler := handlers.NewBootstrapHandler(services.NewBootstrapService(db, knowledgeService, unifiedAIService), uploadDir, log.Default())
+
+	// Request signers for the public widget and inbound webhooks; routes are disabled without a secret
+	toleranceSeconds, _ := strconv.Atoi(cfg.SignatureToleranceSeconds)
+	signatureTolerance := time.Duration(toleranceSeconds) * time.Second
+	var widgetSigner, webhookSigner *services.RequestSigner
+	if cfg.WidgetSigningSecret != "" {
+		widgetSigner = services.NewRequestSigner(db, "widget", cfg.WidgetSigningSecret, signatureTolerance)
+	} else {
+		log.Printf("[WARNING] WIDGET_SIGNING_SECRET not set, widget routes are disabled")
+	}
+	if cfg.WebhookSigningSecret != "" {
+		webhookSigner = services.NewRequestSigner(db, "webhook", cfg.WebhookSigningSecret, signatureTolerance)
+	} else {
+		log.Printf("[WARNING] WEBHOOK_SIGNING_SECRET not set, webhook routes are disabled")
+	}
 
 	server := &Server{
-		app:                 app,
-		cfg:                 cfg,
-		db:                  db,
-		knowledgeService:    knowledgeService,
-		chatService:         chatService,
-		openAIService:       openAIService,
-		geminiService:       geminiService,
-		unifiedAIService:    unifiedAIService,
-		enhancedChatService: enhancedChatService,
-		vectorService:       vectorService,
-		documentService:     documentService,
-		fileUploadService:   fileUploadService,
-		assistantService:    assistantService,
-		aiHandler:           aiHandler,
-		documentHandler:     documentHandler,
-		fileUploadHandler:   fileUploadHandler,
-		assistantHandler:    assistantHandler,
+		app:                  app,
+		cfg:                  cfg,
+		db:                   db,
+		knowledgeService:     knowledgeService,
+		chatService:          chatService,
+		openAIService:        openAIService,
+		geminiService:        geminiService,
+		unifiedAIService:     unifiedAIService,
+		enhancedChatService:  enhancedChatService,
+		handoffService:       handoffService,
+		bookmarkService:      services.NewBookmarkService(db, knowledgeService),
+		personaService:       personaService,
+		entryAnalytics:       entryAnalytics,
+		vectorService:        vectorService,
+		ingestionService:     ingestionService,
+		assistantService:     assistantService,
+		jobQueue:             jobQueue,
+		aiHandler:            aiHandler,
+		documentHandler:      documentHandler,
+		fileUploadHandler:    fileUploadHandler,
+		assistantHandler:     assistantHandler,
+		jobsHandler:          jobsHandler,
+		jobDashboardHandler:  jobDashboardHandler,
+		adminStatusHandler:   adminStatusHandler,
+		settingsHandler:      settingsHandler,
+		assistantAdmin:       assistantAdminHandler,
+		leaderboardHandler:   leaderboardHandler,
+		analyticsHandler:     entryAnalyticsHandler,
+		bootstrapHandler:     bootstrapHandler,
+		retrievalEvalHandler: retrievalEvalHandler,
+		experimentHandler:    experimentHandler,
+		topicCoverageHandler: topicCoverageHandler,
+		openAIGCHandler:      openAIGCHandler,
+		usageHandler:         usageHandler,
+		quotaHandler:         quotaHandler,
+		promptHandler:        promptHandler,
+		presetHandler:        presetHandler,
+		personaHandler:       personaHandler,
+		announcementHandler:  announcementHandler,
+		impersonationHandler: impersonationHandler,
+		helpHandler:          helpHandler,
+		moderationHandler:    moderationHandler,
+		quarantineHandler:    quarantineHandler,
+		chatRetentionHandler: chatRetentionHandler,
+		confluenceHandler:    confluenceHandler,
+		notionHandler:        notionHandler,
+		googleDriveHandler:   googleDriveHandler,
+		schedulesHandler:     schedulesHandler,
+		staleReviewHandler:   staleReviewHandler,
+		trashHandler:         trashHandler,
+		handoffHandler:       handoffHandler,
+		attachmentHandler:    attachmentHandler,
+		ssoHandler:           ssoHandler,
+		curationHandler:      curationHandler,
+		configBundleHandler:  configBundleHandler,
+		widgetSigner:         widgetSigner,
+		webhookSigner:        webhookSigner,
+		dependencies:         dependencies,
+		stopBackground:       stopBackground,
 	}
 
 	// Middleware
@@ -101,36 +610,138 @@ func NewServer(cfg *config.Config, db *gorm.DB) *fiber.App {
 	app.Use(logger.New())
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: cfg.CORSOrigins,
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowOrigins:  cfg.CORSOrigins,
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,X-TIC-Timestamp,X-TIC-Nonce,X-TIC-Signature," + impersonationTokenHeader,
+		ExposeHeaders: impersonatingUserHeader,
 	}))
 
-	// Swagger documentation
-	app.Get("/swagger/*", swagger.HandlerDefault)
+	// Deployments that sign users in only accept admin actions from signed-in admins
+	sessionsRequired := cfg.JWTSecret != "" || ssoService.Enabled()
+	server.registerRoutes(app, authTokens, sessionsRequired, impersonationService, uploadPolicy, reads)
+	server.grpc = newGRPCServer(server, authTokens)
+
+	return server
+}
+
+// registerRoutes mounts the probes, the API description and the API on app
+func (s *Server) registerRoutes(app *fiber.App, authTokens *services.AuthTokenService, sessionsRequired bool, impersonationService *services.ImpersonationService, uploadPolicy UploadPolicy, reads services.ReadReplicaRouter) {
+	// API description, generated from the handler annotations by cmd/openapi
+	app.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(docs.OpenAPI)
+	})
+	app.Get("/swagger/*", swagger.New(swagger.Config{URL: "/openapi.json"}))
 
 	// API routes
-	api := app.Group("/api/v1")
-	server.setupRoutes(api)
+	api := app.Group("/api/v1", authentication(authTokens, sessionsRequired), impersonation(impersonationService))
+	s.setupRoutes(api)
 
 	// Register upload routes
-	RegisterUploadRoutes(api, db)
+	RegisterUploadRoutes(api, s.db, uploadPolicy, s.ingestionService)
 
 	// Register context dashboard route
-	api.Get("/context-dashboard", handlers.GetContextDashboard(db))
+	api.Get("/context-dashboard", handlers.GetContextDashboard(s.db, reads))
 
 	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"version": "1.0.0",
-		})
+	app.Get("/health", s.health)
+	app.Get("/readyz", s.readyz)
+}
+
+// Routes lists the routes of the API without connecting to anything, to check the API description against them
+func Routes() []fiber.Route {
+	server := &Server{widgetSigner: &services.RequestSigner{}, webhookSigner: &services.RequestSigner{}}
+	app := fiber.New()
+	server.registerRoutes(app, nil, false, nil, UploadPolicy{}, nil)
+	return app.GetRoutes(true)
+}
+
+// Listen serves HTTP requests on addr until Shutdown is called
+func (s *Server) Listen(addr string) error {
+	return s.app.Listen(addr)
+}
+
+// Shutdown stops the server gracefully: /readyz turns not ready, new connections are refused and in-flight
+// requests finish, then background workers stop and in-flight jobs drain. It gives up when ctx expires.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.dependencies.SetShuttingDown()
+
+	var shutdownErr error
+	if err := s.app.ShutdownWithContext(ctx); err != nil {
+		shutdownErr = fmt.Errorf("failed to stop HTTP server: %w", err)
+	}
+	s.stopGRPC(ctx)
+
+	s.stopBackground()
+	if err := s.jobQueue.Drain(ctx); err != nil && shutdownErr == nil {
+		shutdownErr = err
+	}
+	return shutdownErr
+}
+
+// ssoConfig reads the OIDC provider settings
+func ssoConfig(cfg *config.Config) services.SSOConfig {
+	roleMapping := map[string]models.UserRole{}
+	for _, pair := range strings.Split(cfg.SSORoleMapping, ",") {
+		group, role, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		roleMapping[strings.TrimSpace(group)] = models.UserRole(strings.TrimSpace(role))
+	}
+	return services.SSOConfig{
+		IssuerURL:      cfg.SSOIssuerURL,
+		ClientID:       cfg.SSOClientID,
+		ClientSecret:   cfg.SSOClientSecret,
+		RedirectURL:    cfg.SSORedirectURL,
+		Scopes:         strings.Split(cfg.SSOScopes, ","),
+		GroupsClaim:    cfg.SSOGroupsClaim,
+		RoleMapping:    roleMapping,
+		DefaultRole:    models.UserRole(cfg.SSODefaultRole),
+		AllowedDomains: strings.Split(cfg.SSOAllowedDomains, ","),
+	}
+}
+
+// @Summary Liveness probe
+// @Description Reports that the process is up
+// @Tags health
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object}
+// @Router /health [get]
+// @x-servers [{"url": "/"}]
+func (s *Server) health(c *fiber.Ctx) error {
+	return utils.SendSuccess(c, fiber.Map{
+		"status":  "healthy",
+		"version": "1.0.0",
 	})
+}
 
-	return app
+// @Summary Readiness probe
+// @Description Reports ready once the database, Qdrant, and at least one AI provider are reachable.
+// @Description Returns 503 while a dependency is down and once shutdown has started.
+// @Tags health
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=services.DependencyStatus}
+// @Failure 503 {object} utils.APIResponse{data=services.DependencyStatus}
+// @Router /readyz [get]
+// @x-servers [{"url": "/"}]
+func (s *Server) readyz(c *fiber.Ctx) error {
+	status := s.dependencies.Status()
+	if !status.Ready {
+		response := utils.ErrorResponse(fiber.StatusServiceUnavailable, "Not ready")
+		response.Data = status
+		return utils.SendJSON(c, fiber.StatusServiceUnavailable, response)
+	}
+	return utils.SendSuccess(c, status)
 }
 
 func (s *Server) setupRoutes(api fiber.Router) {
+	// Authentication routes
+	auth := api.Group("/auth")
+	auth.Get("/sso/login", s.ssoHandler.Login)
+	auth.Get("/sso/callback", s.ssoHandler.Callback)
+	auth.Post("/logout", s.ssoHandler.Logout)
+
 	// Template routes
 	templates := api.Group("/templates")
 	templates.Get("/", s.getTemplates)
@@ -144,16 +755,41 @@ func (s *Server) setupRoutes(api fiber.Router) {
 	knowledge.Get("/", s.getKnowledgeEntries)
 	knowledge.Post("/", s.createKnowledgeEntry)
 	knowledge.Get("/search", s.searchKnowledgeEntries)
+	knowledge.Post("/merge", s.curationHandler.MergeEntries)
+	knowledge.Get("/stale", s.staleReviewHandler.ListStaleEntries)
+	knowledge.Post("/stale/detect", s.staleReviewHandler.RunDetection)
+	knowledge.Get("/stale/policies", s.staleReviewHandler.ListPolicies)
+	knowledge.Put("/stale/policies/:category", s.staleReviewHandler.SetPolicy)
 	knowledge.Get("/:id", s.getKnowledgeEntry)
+	knowledge.Get("/:id/related", s.getRelatedEntries)
 	knowledge.Put("/:id", s.updateKnowledgeEntry)
 	knowledge.Delete("/:id", s.deleteKnowledgeEntry)
+	knowledge.Post("/:id/confirm-review", s.staleReviewHandler.ConfirmEntry)
+	knowledge.Post("/:id/split", s.curationHandler.SplitEntry)
 
 	// Chat routes
 	chat := api.Group("/chat")
 	chat.Post("/", s.processChat)
 	chat.Get("/sessions", s.getChatSessions)
 	chat.Get("/sessions/:id", s.getChatSession)
+	chat.Get("/sessions/:id/usage", s.getChatSessionUsage)
+	chat.Get("/sessions/:id/export", s.exportChatSession)
 	chat.Delete("/sessions/:id", s.deleteChatSession)
+	chat.Post("/sessions/:id/restore", s.restoreChatSession)
+	chat.Post("/sessions/:id/escalate", s.escalateChatSession)
+	chat.Put("/sessions/:id/persona", s.setChatSessionPersona)
+	chat.Get("/personas", s.personaHandler.ListPersonas)
+	chat.Patch("/messages/:id", s.editChatMessage)
+	chat.Post("/messages/:id/regenerate", s.regenerateChatAnswer)
+	chat.Get("/messages/:id/versions", s.getChatMessageVersions)
+
+	// Support agent handoff routes
+	escalations := api.Group("/support/escalations")
+	escalations.Get("/", s.handoffHandler.ListEscalations)
+	escalations.Get("/:id", s.handoffHandler.GetEscalation)
+	escalations.Post("/:id/claim", s.handoffHandler.ClaimEscalation)
+	escalations.Post("/:id/messages", s.handoffHandler.PostAgentMessage)
+	escalations.Post("/:id/resolve", s.handoffHandler.ResolveEscalation)
 
 	// Feedback routes
 	feedback := api.Group("/feedback")
@@ -164,23 +800,40 @@ func (s *Server) setupRoutes(api fiber.Router) {
 	users := api.Group("/users")
 	users.Get("/me", s.getCurrentUser)
 
+	// Routes of the requesting user
+	me := api.Group("/me")
+	me.Get("/bookmarks", s.listBookmarks)
+	me.Post("/bookmarks", s.createBookmark)
+	me.Delete("/bookmarks/:id", s.deleteBookmark)
+
 	// AI routes (new Gemini integration)
 	ai := api.Group("/ai")
 	ai.Post("/chat", s.aiHandler.ProcessChatWithAI)
+	ai.Post("/attachments", s.attachmentHandler.UploadAttachment)
+	ai.Get("/attachments/:id", s.attachmentHandler.GetAttachment)
 	ai.Get("/providers", s.aiHandler.GetAvailableProviders)
+	ai.Get("/providers/health", s.aiHandler.GetProviderHealth)
 	ai.Post("/providers/primary", s.aiHandler.SetPrimaryProvider)
 	ai.Post("/compare", s.aiHandler.CompareProviders)
+	ai.Post("/queued-questions", s.aiHandler.QueueQuestion)
+	ai.Get("/queued-questions/:id", s.aiHandler.GetQueuedQuestion)
 
 	// Document processing routes
 	documents := api.Group("/documents")
 	documents.Post("/process", s.documentHandler.ProcessDocument)
+	documents.Post("/process/confirm", s.documentHandler.ConfirmDocument)
 	documents.Get("/parse", s.documentHandler.ParseDocument)
 	documents.Post("/process-wb", s.documentHandler.ProcessWBDocument)
+	documents.Post("/ingest-url", s.documentHandler.IngestURL)
+	documents.Get("/web-sources/:id", s.documentHandler.GetWebSource)
 
 	// File upload routes
 	documents.Post("/upload", s.fileUploadHandler.UploadDocument)
 	documents.Get("/:id/status", s.fileUploadHandler.GetDocumentStatus)
+	documents.Delete("/:id", s.fileUploadHandler.DeleteDocument)
+	documents.Post("/:id/resync", s.fileUploadHandler.ResyncDocument)
 	documents.Post("/", s.fileUploadHandler.ListDocuments)
+	documents.Get("/", s.fileUploadHandler.SearchDocuments)
 
 	// OpenAI Assistant routes
 	assistant := api.Group("/assistant")
@@ -189,19 +842,228 @@ func (s *Server) setupRoutes(api fiber.Router) {
 	assistant.Post("/chat/custom", s.assistantHandler.ChatWithCustomWorkflow)
 	assistant.Post("/threads", s.assistantHandler.CreateThread)
 	assistant.Get("/threads/:thread_id/messages", s.assistantHandler.GetThreadMessages)
+	assistant.Get("/runs/:id/wait", s.assistantHandler.WaitForRun)
+
+	// Managed assistant routes
+	assistants := api.Group("/admin/assistants")
+	assistants.Get("/", s.assistantAdmin.ListAssistants)
+	assistants.Post("/", s.assistantAdmin.CreateAssistant)
+	assistants.Get("/:id", s.assistantAdmin.GetAssistant)
+	assistants.Put("/:id", s.assistantAdmin.UpdateAssistant)
+
+	// Chat persona preset routes
+	personas := api.Group("/admin/personas")
+	personas.Get("/", s.personaHandler.ListAllPersonas)
+	personas.Post("/", s.personaHandler.CreatePersona)
+	personas.Put("/:id", s.personaHandler.UpdatePersona)
+
+	// Org-wide announcement routes, managed by support agents and admins
+	announcements := api.Group("/announcements")
+	announcements.Get("/", s.announcementHandler.ListAnnouncements)
+	announcements.Post("/", s.announcementHandler.CreateAnnouncement)
+	announcements.Put("/:id", s.announcementHandler.UpdateAnnouncement)
+	announcements.Delete("/:id", s.announcementHandler.DeleteAnnouncement)
+
+	// Background job routes
+	jobs := api.Group("/jobs")
+	jobs.Get("/", s.jobsHandler.ListJobs)
+	jobs.Get("/:id", s.jobsHandler.GetJob)
+	jobs.Post("/:id/retry", s.jobsHandler.RetryJob)
+
+	// Job queue health routes
+	adminJobs := api.Group("/admin/jobs")
+	adminJobs.Get("/dashboard", s.jobDashboardHandler.GetDashboard)
+	adminJobs.Post("/queues/:queue/pause", s.jobDashboardHandler.PauseQueue)
+	adminJobs.Post("/queues/:queue/resume", s.jobDashboardHandler.ResumeQueue)
+
+	// System status route for the ops dashboard
+	api.Get("/admin/status", s.adminStatusHandler.GetStatus)
+
+	// Runtime settings routes
+	api.Get("/admin/settings", s.settingsHandler.GetSettings)
+	api.Put("/admin/settings", s.settingsHandler.UpdateSettings)
+
+	// Contribution analytics routes
+	analytics := api.Group("/analytics")
+	analytics.Get("/leaderboard", s.leaderboardHandler.GetLeaderboard)
+	analytics.Get("/contributors/:id", s.leaderboardHandler.GetContributor)
+	analytics.Get("/topic-coverage", s.topicCoverageHandler.GetTopicCoverage)
+	analytics.Get("/usage", s.usageHandler.GetUsage)
+	analytics.Get("/entries/top", s.analyticsHandler.GetTopEntries)
+	analytics.Get("/entries/unclicked", s.analyticsHandler.GetUnclickedEntries)
+	analytics.Get("/searches/zero-results", s.analyticsHandler.GetZeroResultSearches)
+	analytics.Get("/unanswered", s.analyticsHandler.GetUnansweredQuestions)
+
+	// Usage quota routes
+	quotas := api.Group("/quotas")
+	quotas.Get("/users/:id", s.quotaHandler.GetUserQuota)
+	quotas.Put("/users/:id", s.quotaHandler.SetUserQuota)
+	quotas.Delete("/users/:id", s.quotaHandler.DeleteUserQuota)
+	quotas.Put("/org", s.quotaHandler.SetOrgQuota)
+	quotas.Delete("/org", s.quotaHandler.DeleteOrgQuota)
+
+	// Prompt template routes
+	prompts := api.Group("/prompts")
+	prompts.Get("/", s.promptHandler.ListPrompts)
+	prompts.Post("/", s.promptHandler.CreatePrompt)
+	prompts.Get("/:id", s.promptHandler.GetPrompt)
+	prompts.Put("/:id", s.promptHandler.UpdatePrompt)
+	prompts.Delete("/:id", s.promptHandler.DeletePrompt)
+
+	// Chunking and retrieval preset routes
+	presets := api.Group("/retrieval-presets")
+	presets.Get("/", s.presetHandler.ListPresets)
+	presets.Post("/", s.presetHandler.CreatePreset)
+	presets.Get("/assignments", s.presetHandler.ListAssignments)
+	presets.Put("/categories/:category", s.presetHandler.AssignCategory)
+	presets.Put("/templates/:id", s.presetHandler.AssignTemplate)
+	presets.Get("/:id", s.presetHandler.GetPreset)
+	presets.Put("/:id", s.presetHandler.UpdatePreset)
+	presets.Delete("/:id", s.presetHandler.DeletePreset)
+
+	// Contextual help routes
+	help := api.Group("/help")
+	help.Get("/context", s.helpHandler.GetContext)
+	help.Get("/screens", s.helpHandler.ListScreens)
+	help.Put("/screens/:screen", s.helpHandler.SetScreen)
+	help.Delete("/screens/:screen", s.helpHandler.DeleteScreen)
+
+	// Moderation routes
+	api.Get("/moderation/events", s.moderationHandler.ListEvents)
+
+	// Admin impersonation routes
+	impersonations := api.Group("/admin/impersonations")
+	impersonations.Post("/", s.impersonationHandler.StartImpersonation)
+	impersonations.Delete("/:id", s.impersonationHandler.StopImpersonation)
+	impersonations.Get("/audit", s.impersonationHandler.ListAudit)
+
+	// Document quarantine routes
+	quarantine := api.Group("/admin/quarantine")
+	quarantine.Get("/", s.quarantineHandler.ListQuarantine)
+	quarantine.Post("/:id/approve", s.quarantineHandler.ApproveQuarantine)
+	quarantine.Post("/:id/reject", s.quarantineHandler.RejectQuarantine)
+
+	// Chat retention policy routes
+	retention := api.Group("/admin/retention/policies")
+	retention.Get("/", s.chatRetentionHandler.ListPolicies)
+	retention.Post("/", s.chatRetentionHandler.CreatePolicy)
+	retention.Delete("/:id", s.chatRetentionHandler.DeletePolicy)
+	retention.Post("/:id/run", s.chatRetentionHandler.RunPolicy)
+
+	// Deleted record routes
+	trash := api.Group("/admin/trash")
+	trash.Get("/", s.trashHandler.ListTrash)
+	trash.Post("/purge", s.trashHandler.PurgeTrash)
+	trash.Post("/:type/:id/restore", s.trashHandler.RestoreItem)
+
+	// Configuration promotion routes
+	configBundle := api.Group("/admin/config")
+	configBundle.Get("/export", s.configBundleHandler.ExportConfig)
+	configBundle.Post("/import", s.configBundleHandler.ImportConfig)
+
+	// Integration routes
+	confluence := api.Group("/integrations/confluence")
+	confluence.Get("/", s.confluenceHandler.GetStatus)
+	confluence.Post("/sync", s.confluenceHandler.Sync)
+	notion := api.Group("/integrations/notion")
+	notion.Get("/", s.notionHandler.GetStatus)
+	notion.Post("/sync", s.notionHandler.Sync)
+	googleDrive := api.Group("/integrations/google-drive")
+	googleDrive.Get("/", s.googleDriveHandler.GetStatus)
+	googleDrive.Post("/sync", s.googleDriveHandler.Sync)
+
+	// Scheduled jobs
+	schedules := api.Group("/schedules")
+	schedules.Get("/", s.schedulesHandler.ListSchedules)
+	schedules.Get("/tasks", s.schedulesHandler.ListTasks)
+	schedules.Post("/", s.schedulesHandler.CreateSchedule)
+	schedules.Put("/:id", s.schedulesHandler.UpdateSchedule)
+	schedules.Delete("/:id", s.schedulesHandler.DeleteSchedule)
+	schedules.Post("/:id/run", s.schedulesHandler.RunSchedule)
+
+	// Maintenance routes
+	maintenance := api.Group("/maintenance")
+	maintenance.Post("/openai-gc", s.openAIGCHandler.CollectGarbage)
+
+	// Cold-start bootstrap wizard routes
+	bootstrap := api.Group("/bootstrap")
+	bootstrap.Post("/", s.bootstrapHandler.Bootstrap)
+	bootstrap.Get("/readiness", s.bootstrapHandler.GetReadiness)
+
+	// Retrieval evaluation routes
+	retrievalEval := api.Group("/retrieval-eval")
+	retrievalEval.Get("/pairs", s.retrievalEvalHandler.ListPairs)
+	retrievalEval.Post("/pairs", s.retrievalEvalHandler.CreatePair)
+	retrievalEval.Delete("/pairs/:id", s.retrievalEvalHandler.DeletePair)
+	retrievalEval.Get("/runs", s.retrievalEvalHandler.ListRuns)
+	retrievalEval.Post("/runs", s.retrievalEvalHandler.StartRun)
+	retrievalEval.Get("/runs/:id", s.retrievalEvalHandler.GetRun)
+
+	// Prompt experiment routes: golden questions and runs compared with the golden answers
+	experiments := api.Group("/experiments")
+	experiments.Get("/questions", s.experimentHandler.ListQuestions)
+	experiments.Post("/questions", s.experimentHandler.CreateQuestion)
+	experiments.Delete("/questions/:id", s.experimentHandler.DeleteQuestion)
+	experiments.Get("/runs", s.experimentHandler.ListRuns)
+	experiments.Post("/runs", s.experimentHandler.StartRun)
+	experiments.Get("/runs/:id", s.experimentHandler.GetRun)
+	experiments.Post("/runs/:id/golden", s.experimentHandler.PromoteRun)
+
+	// Public widget routes, HMAC signed with replay protection
+	if s.widgetSigner != nil {
+		widget := api.Group("/widget", requireSignature(s.widgetSigner))
+		widget.Post("/chat", s.widgetChat)
+	}
+
+	// Inbound webhook routes, HMAC signed with replay protection
+	if s.webhookSigner != nil {
+		webhooks := api.Group("/webhooks", requireSignature(s.webhookSigner))
+		webhooks.Post("/ping", s.webhookPing)
+	}
 }
 
+// errorHandler maps errors returned by handlers to HTTP statuses and the error envelope. Service errors carry
+// their kind (not found, validation, quota, provider outage) so handlers can return them as is.
 func errorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 	message := "Internal Server Error"
+	var data interface{}
 
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-		message = e.Message
+	var fiberErr *fiber.Error
+	var quotaErr *services.QuotaExceededError
+	switch {
+	case errors.As(err, &fiberErr):
+		code = fiberErr.Code
+		message = fiberErr.Message
+	case errors.Is(err, services.ErrNotFound):
+		code = fiber.StatusNotFound
+		message = err.Error()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = fiber.StatusNotFound
+		message = "Not found"
+	case errors.Is(err, services.ErrValidation):
+		code = fiber.StatusBadRequest
+		message = err.Error()
+	case errors.Is(err, services.ErrForbidden):
+		code = fiber.StatusForbidden
+		message = err.Error()
+	case errors.As(err, &quotaErr):
+		code = fiber.StatusPaymentRequired
+		message = err.Error()
+		data = fiber.Map{"scope": quotaErr.Scope, "quota": quotaErr.Status}
+	case errors.Is(err, services.ErrProviderUnavailable):
+		code = fiber.StatusServiceUnavailable
+		message = "AI provider unavailable, please try again later"
+		log.Printf("[ERROR] %s %s: %v", c.Method(), c.Path(), err)
+	case errors.Is(err, services.ErrInvalidResponse):
+		code = fiber.StatusBadGateway
+		message = "The AI provider did not return a usable answer, please try again"
+		log.Printf("[ERROR] %s %s: %v", c.Method(), c.Path(), err)
+	default:
+		log.Printf("[ERROR] %s %s: %v", c.Method(), c.Path(), err)
 	}
 
-	return c.Status(code).JSON(fiber.Map{
-		"error": message,
-		"code":  code,
-	})
+	response := utils.ErrorResponse(code, message)
+	response.Data = data
+	return utils.SendJSON(c, code, response)
 }
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers carrying the request signature
+const (
+	signatureTimestampHeader = "X-TIC-Timestamp"
+	signatureNonceHeader     = "X-TIC-Nonce"
+	signatureHeader          = "X-TIC-Signature"
+)
+
+// requireSignature rejects requests that are not signed by the given signer, or that replay a previous request
+func requireSignature(signer *services.RequestSigner) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := signer.Verify(c.UserContext(), services.SignedRequest{
+			Timestamp: c.Get(signatureTimestampHeader),
+			Nonce:     c.Get(signatureNonceHeader),
+			Signature: c.Get(signatureHeader),
+			Method:    c.Method(),
+			Path:      c.Path(),
+			Body:      c.Body(),
+		})
+		if err == nil {
+			return c.Next()
+		}
+
+		switch {
+		case errors.Is(err, services.ErrSignatureMissing),
+			errors.Is(err, services.ErrSignatureExpired),
+			errors.Is(err, services.ErrSignatureInvalid),
+			errors.Is(err, services.ErrRequestReplayed):
+			log.Printf("[WARNING] Rejected signed request to %s from %s: %v", c.Path(), c.IP(), err)
+			return utils.SendError(c, 401, err.Error())
+		default:
+			log.Printf("[ERROR] Failed to verify signed request to %s: %v", c.Path(), err)
+			return utils.SendError(c, 500, "Failed to verify request signature")
+		}
+	}
+}
+
+// @Summary Chat from the embedded widget
+// @Description Same as POST /ai/chat for the embedded widget, whose requests are signed with the widget secret
+// @Tags ai-chat
+// @Accept json
+// @Produce json
+// @Param X-TIC-Timestamp header string true "Unix timestamp of the request"
+// @Param X-TIC-Nonce header string true "Unique request nonce"
+// @Param X-TIC-Signature header string true "Hex HMAC-SHA256 signature"
+// @Param request body services.EnhancedChatRequest true "Chat request"
+// @Param lang query string false "Language of the answer (ISO 639-1 code or tag); overrides the request body"
+// @Success 200 {object} utils.APIResponse{data=services.EnhancedChatResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 503 {object} utils.APIResponse
+// @Router /widget/chat [post]
+func (s *Server) widgetChat(c *fiber.Ctx) error {
+	return s.aiHandler.ProcessChatWithAI(c)
+}
+
+// @Summary Webhook ping
+// @Description Verify that inbound webhook signing is configured correctly
+// @Tags webhooks
+// @Produce json
+// @Param X-TIC-Timestamp header string true "Unix timestamp of the request"
+// @Param X-TIC-Nonce header string true "Unique request nonce"
+// @Param X-TIC-Signature header string true "Hex HMAC-SHA256 signature"
+// @Success 200 {object} utils.APIResponse{data=object{status=string}}
+// @Failure 401 {object} utils.APIResponse
+// @Router /webhooks/ping [post]
+func (s *Server) webhookPing(c *fiber.Ctx) error {
+	return utils.SendSuccess(c, fiber.Map{"status": "ok"})
+}
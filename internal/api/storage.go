@@ -0,0 +1,36 @@
+package api
+
+import (
+	"log"
+	"strconv"
+
+	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/services"
+)
+
+// NewFileStorage builds the storage backend for uploaded documents from the STORAGE_* settings; ticctl
+// stores the documents it ingests in it too
+func NewFileStorage(cfg *config.Config) (services.FileStorage, error) {
+	switch cfg.StorageBackend {
+	case services.StorageBackendS3:
+		pathStyle, _ := strconv.ParseBool(cfg.StorageS3PathStyle)
+		storage, err := services.NewS3Storage(services.S3Config{
+			Endpoint:  cfg.StorageS3Endpoint,
+			Region:    cfg.StorageS3Region,
+			Bucket:    cfg.StorageS3Bucket,
+			AccessKey: cfg.StorageS3AccessKey,
+			SecretKey: cfg.StorageS3SecretKey,
+			PathStyle: pathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[INFO] Storing uploaded documents in bucket %s", cfg.StorageS3Bucket)
+		return storage, nil
+	case services.StorageBackendLocal, "":
+		return services.NewLocalStorage(cfg.StorageLocalDir)
+	default:
+		log.Printf("[WARNING] Unknown STORAGE_BACKEND %q, storing uploaded documents in %s", cfg.StorageBackend, cfg.StorageLocalDir)
+		return services.NewLocalStorage(cfg.StorageLocalDir)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"strconv"
 	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,7 +16,7 @@ import (
 // @Produce json
 // @Param category query string false "Filter by category"
 // @Param active query boolean false "Filter by active status"
-// @Success 200 {array} models.Template
+// @Success 200 {object} utils.APIResponse{data=[]models.Template}
 // @Router /templates [get]
 func (s *Server) getTemplates(c *fiber.Ctx) error {
 	category := c.Query("category")
@@ -25,17 +26,17 @@ func (s *Server) getTemplates(c *fiber.Ctx) error {
 	if activeStr != "" {
 		active, err := strconv.ParseBool(activeStr)
 		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid active parameter"})
+			return utils.SendError(c, 400, "Invalid active parameter")
 		}
 		isActive = &active
 	}
 
 	templates, err := s.knowledgeService.GetTemplates(category, isActive)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch templates"})
+		return utils.SendError(c, 500, "Failed to fetch templates")
 	}
 
-	return c.JSON(templates)
+	return utils.SendSuccess(c, templates)
 }
 
 // @Summary Create template
@@ -44,22 +45,24 @@ func (s *Server) getTemplates(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param template body models.Template true "Template data"
-// @Success 201 {object} models.Template
+// @Success 201 {object} utils.APIResponse{data=models.Template}
 // @Router /templates [post]
 func (s *Server) createTemplate(c *fiber.Ctx) error {
 	var template models.Template
 	if err := c.BodyParser(&template); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
-	// TODO: Get user ID from JWT token
-	template.CreatedBy = uuid.New() // Placeholder
+	template.CreatedBy = uuid.New() // Placeholder for anonymous requests
+	if authenticated, ok := authenticatedUserID(c); ok {
+		template.CreatedBy = authenticated
+	}
 
 	if err := s.knowledgeService.CreateTemplate(&template); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create template"})
+		return utils.SendError(c, 500, "Failed to create template")
 	}
 
-	return c.Status(201).JSON(template)
+	return utils.SendJSON(c, 201, utils.SuccessResponse(template))
 }
 
 // @Summary Get template
@@ -68,21 +71,21 @@ func (s *Server) createTemplate(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Template ID"
-// @Success 200 {object} models.Template
+// @Success 200 {object} utils.APIResponse{data=models.Template}
 // @Router /templates/{id} [get]
 func (s *Server) getTemplate(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid template ID"})
+		return utils.SendError(c, 400, "Invalid template ID")
 	}
 
 	template, err := s.knowledgeService.GetTemplateByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Template not found"})
+		return err
 	}
 
-	return c.JSON(template)
+	return utils.SendSuccess(c, template)
 }
 
 // @Summary Update template
@@ -92,26 +95,26 @@ func (s *Server) getTemplate(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Template ID"
 // @Param template body models.Template true "Template data"
-// @Success 200 {object} models.Template
+// @Success 200 {object} utils.APIResponse{data=models.Template}
 // @Router /templates/{id} [put]
 func (s *Server) updateTemplate(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid template ID"})
+		return utils.SendError(c, 400, "Invalid template ID")
 	}
 
 	var template models.Template
 	if err := c.BodyParser(&template); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return utils.SendError(c, 400, "Invalid request body")
 	}
 
 	template.ID = id
 	if err := s.knowledgeService.UpdateTemplate(&template); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update template"})
+		return utils.SendError(c, 500, "Failed to update template")
 	}
 
-	return c.JSON(template)
+	return utils.SendSuccess(c, template)
 }
 
 // @Summary Delete template
@@ -126,11 +129,11 @@ func (s *Server) deleteTemplate(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid template ID"})
+		return utils.SendError(c, 400, "Invalid template ID")
 	}
 
 	if err := s.knowledgeService.DeleteTemplate(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete template"})
+		return err
 	}
 
 	return c.SendStatus(204)
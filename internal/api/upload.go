@@ -2,79 +2,181 @@ package api
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/services"
+	"tic-knowledge-system/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func RegisterUploadRoutes(app fiber.Router, db *gorm.DB) {
-	app.Post("/upload", func(c *fiber.Ctx) error {
+// uploadDir is where the upload routes store files
+const uploadDir = "file"
+
+// RegisterUploadRoutes registers the file upload routes. Uploads are checked against policy
+// before anything is written to disk. Active context files are imported into the knowledge base
+// through ingestion, when set.
+func RegisterUploadRoutes(app fiber.Router, db *gorm.DB, policy UploadPolicy, ingestion *services.IngestionService) {
+	app.Post("/upload", uploadFiles(db, policy))
+	app.Post("/context-file", uploadContextFile(db, policy, ingestion))
+	app.Get("/upload/count", countUploads(db))
+	app.Get("/upload/files", listUploadedFiles(db))
+	app.Get("/context-files", listContextFiles(db))
+	app.Get("/tracked-chat-logs", listTrackedChatLogs(db))
+}
+
+// uploadFiles stores uploaded files, skipping files already uploaded with the same content
+// @Summary Upload files
+// @Description Stores the files of the multipart form. Files whose content was already uploaded are reported as duplicates.
+// @Tags uploads
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Files to upload; the field may be repeated"
+// @Success 200 {object} utils.APIResponse{data=object{message=string,count=int,duplicates=[]string}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /upload [post]
+func uploadFiles(db *gorm.DB, policy UploadPolicy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		form, err := c.MultipartForm()
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid multipart form"})
+			return utils.SendError(c, fiber.StatusBadRequest, "Invalid multipart form")
 		}
 		files := form.File["file"]
 		if len(files) == 0 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No file uploaded"})
+			return utils.SendError(c, fiber.StatusBadRequest, "No file uploaded")
 		}
 
-		uploadedCount := 0
+		// Validate every file first so a rejected file does not leave the others half saved
+		uploads := make([]*validatedUpload, 0, len(files))
 		for _, fileHeader := range files {
-			filename := fileHeader.Filename
-			destPath := filepath.Join("file", filename)
+			upload, err := policy.validate(fileHeader)
+			if err != nil {
+				return err
+			}
+			uploads = append(uploads, upload)
+		}
+
+		uploadedCount := 0
+		duplicates := []string{}
+		seen := map[string]bool{}
+		for _, upload := range uploads {
+			var existing int64
+			if err := db.Model(&models.UploadedFile{}).Where("checksum = ?", upload.Checksum).Count(&existing).Error; err != nil {
+				return utils.SendError(c, fiber.StatusInternalServerError, "Failed to check for duplicate files")
+			}
+			if existing > 0 || seen[upload.Checksum] {
+				duplicates = append(duplicates, upload.Name)
+				continue
+			}
+			seen[upload.Checksum] = true
 
-			if err := c.SaveFile(fileHeader, destPath); err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save file"})
+			destPath, err := writeUpload(upload)
+			if err != nil {
+				return utils.SendError(c, fiber.StatusInternalServerError, "Failed to save file")
 			}
 
 			record := models.UploadedFile{
-				FileName:   filename,
+				FileName:   upload.Name,
 				FilePath:   destPath,
+				Size:       int64(len(upload.Content)),
+				MimeType:   upload.MimeType,
+				Checksum:   upload.Checksum,
 				UploadTime: time.Now(),
 			}
 			if err := db.Create(&record).Error; err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to insert file record"})
+				return utils.SendError(c, fiber.StatusInternalServerError, "Failed to insert file record")
 			}
 			uploadedCount++
 		}
 
-		return c.JSON(fiber.Map{
-			"message": fmt.Sprintf("%d file(s) uploaded successfully", uploadedCount),
-			"count":   uploadedCount,
+		return utils.SendSuccess(c, fiber.Map{
+			"message":    fmt.Sprintf("%d file(s) uploaded successfully", uploadedCount),
+			"count":      uploadedCount,
+			"duplicates": duplicates,
 		})
-	})
+	}
+}
 
-	app.Post("/context-file", func(c *fiber.Ctx) error {
+// uploadContextFile stores a context file shown on the context dashboard
+// @Summary Upload a context file
+// @Tags uploads
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Context file"
+// @Param labels formData string false "Labels"
+// @Param description formData string false "Description"
+// @Description Active files are imported into the knowledge base in the background: the status is Processing until the import ends as Active, Failed or Quarantined. The entries are tagged with the labels, which chat requests can narrow retrieval to.
+// @Param status formData string false "Status; files with another status than Active are only recorded" default(Active)
+// @Success 200 {object} utils.APIResponse{data=object{message=string,file=object{name=string,labels=string,description=string,status=string,updated=string,document_id=string}}}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /context-file [post]
+func uploadContextFile(db *gorm.DB, policy UploadPolicy, ingestion *services.IngestionService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		fileHeader, err := c.FormFile("file")
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No file uploaded"})
+			return utils.SendError(c, fiber.StatusBadRequest, "No file uploaded")
+		}
+		upload, err := policy.validate(fileHeader)
+		if err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := db.Model(&models.ContextFile{}).Where("file_name = ?", upload.Name).Count(&existing).Error; err != nil {
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to check context files")
+		}
+		if existing > 0 {
+			return utils.SendError(c, fiber.StatusConflict, fmt.Sprintf("Context file %s already exists", upload.Name))
 		}
-		filename := fileHeader.Filename
-		destPath := filepath.Join("file", filename)
-		if err := c.SaveFile(fileHeader, destPath); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save file"})
+
+		destPath := filepath.Join(uploadDir, upload.Name)
+		if err := os.WriteFile(destPath, upload.Content, 0644); err != nil {
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to save file")
 		}
 
 		labels := c.FormValue("labels", "")
 		description := c.FormValue("description", "")
-		status := c.FormValue("status", "Active")
+		status := c.FormValue("status", models.ContextFileActive)
+		ingest := ingestion != nil && strings.EqualFold(status, models.ContextFileActive)
+		if ingest {
+			status = models.ContextFileProcessing
+		}
 
 		record := models.ContextFile{
-			FileName:    filename,
+			FileName:    upload.Name,
 			Labels:      labels,
 			Description: description,
 			Status:      status,
 			UpdatedAt:   time.Now(),
 		}
 		if err := db.Create(&record).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to insert context file record"})
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to insert context file record")
+		}
+		if ingest {
+			var uploadedBy *uuid.UUID
+			if userID, ok := authenticatedUserID(c); ok {
+				uploadedBy = &userID
+			}
+			if err := ingestion.IngestContextFile(c.Context(), &record, upload.Content, upload.MimeType, uploadedBy); err != nil {
+				db.Model(&record).Update("status", models.ContextFileFailed)
+				return err
+			}
 		}
 
-		return c.JSON(fiber.Map{
+		return utils.SendSuccess(c, fiber.Map{
 			"message": "Context file uploaded successfully",
 			"file": fiber.Map{
 				"name":        record.FileName,
@@ -82,39 +184,207 @@ func RegisterUploadRoutes(app fiber.Router, db *gorm.DB) {
 				"description": record.Description,
 				"status":      record.Status,
 				"updated":     record.UpdatedAt,
+				"document_id": record.DocumentID,
 			},
 		})
-	})
+	}
+}
 
-	app.Get("/upload/count", func(c *fiber.Ctx) error {
+// countUploads counts the uploaded files
+// @Summary Count uploaded files
+// @Tags uploads
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{count=int}}
+// @Failure 500 {object} utils.APIResponse
+// @Router /upload/count [get]
+func countUploads(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		var count int64
 		if err := db.Model(&models.UploadedFile{}).Count(&count).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count uploaded files"})
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to count uploaded files")
+		}
+		return utils.SendSuccess(c, fiber.Map{"count": count})
+	}
+}
+
+// listUploadedFiles lists a page of the uploaded files matching the filters
+// @Summary List uploaded files
+// @Tags uploads
+// @Produce json
+// @Param q query string false "Substring of the file name, case-insensitive"
+// @Param mime_type query string false "MIME type"
+// @Param since query string false "Uploaded on or after this date (YYYY-MM-DD)"
+// @Param until query string false "Uploaded on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "name, size or uploaded_at" default(uploaded_at)
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Files per page, at most 100" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{files=[]object{name=string,path=string,size=int,mime_type=string,checksum=string,uploaded_at=string}},meta=utils.Meta}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /upload/files [get]
+func listUploadedFiles(db *gorm.DB) fiber.Handler {
+	columns := map[string]string{"name": "lower(file_name)", "size": "size", "uploaded_at": "upload_time"}
+	return func(c *fiber.Ctx) error {
+		page, limit := utils.ParsePagination(c)
+		order, err := sortOrder(c, columns, "uploaded_at")
+		if err != nil {
+			return err
+		}
+		query, err := filterByDate(c, db.Model(&models.UploadedFile{}), "upload_time")
+		if err != nil {
+			return err
+		}
+		if name := strings.TrimSpace(c.Query("q")); name != "" {
+			query = query.Where("file_name ILIKE ?", utils.LikePattern(name))
+		}
+		if mimeType := c.Query("mime_type"); mimeType != "" {
+			query = query.Where("mime_type = ?", mimeType)
 		}
-		return c.JSON(fiber.Map{"count": count})
-	})
 
-	app.Get("/upload/files", func(c *fiber.Ctx) error {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to count uploaded files")
+		}
 		var files []models.UploadedFile
-		if err := db.Find(&files).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch uploaded files"})
+		if err := query.Order(order).Limit(limit).Offset((page - 1) * limit).Find(&files).Error; err != nil {
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to fetch uploaded files")
 		}
 		result := make([]fiber.Map, 0, len(files))
 		for _, f := range files {
 			result = append(result, fiber.Map{
 				"name":        f.FileName,
 				"path":        f.FilePath,
+				"size":        f.Size,
+				"mime_type":   f.MimeType,
+				"checksum":    f.Checksum,
 				"uploaded_at": f.UploadTime,
 			})
 		}
-		return c.JSON(fiber.Map{"files": result})
-	})
+		return utils.SendPaginated(c, fiber.Map{"files": result}, page, limit, int(total))
+	}
+}
+
+// listContextFiles lists a page of the context files matching the filters
+// @Summary List context files
+// @Tags uploads
+// @Produce json
+// @Param q query string false "Substring of the file name, case-insensitive"
+// @Param label query string false "Substring of the labels of the file, case-insensitive"
+// @Param status query string false "Status, e.g. Active"
+// @Param since query string false "Updated on or after this date (YYYY-MM-DD)"
+// @Param until query string false "Updated on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "name, status or updated_at" default(updated_at)
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Files per page, at most 100" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{files=[]object{name=string,labels=string,description=string,status=string,updated=string,document_id=string}},meta=utils.Meta}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /context-files [get]
+func listContextFiles(db *gorm.DB) fiber.Handler {
+	columns := map[string]string{"name": "lower(file_name)", "status": "status", "updated_at": "updated_at"}
+	return func(c *fiber.Ctx) error {
+		page, limit := utils.ParsePagination(c)
+		order, err := sortOrder(c, columns, "updated_at")
+		if err != nil {
+			return err
+		}
+		query, err := filterByDate(c, db.Model(&models.ContextFile{}), "updated_at")
+		if err != nil {
+			return err
+		}
+		if name := strings.TrimSpace(c.Query("q")); name != "" {
+			query = query.Where("file_name ILIKE ?", utils.LikePattern(name))
+		}
+		if label := strings.TrimSpace(c.Query("label")); label != "" {
+			query = query.Where("labels ILIKE ?", utils.LikePattern(label))
+		}
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to count context files")
+		}
+		var files []models.ContextFile
+		if err := query.Order(order).Limit(limit).Offset((page - 1) * limit).Find(&files).Error; err != nil {
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to fetch context files")
+		}
+		result := make([]fiber.Map, 0, len(files))
+		for _, f := range files {
+			result = append(result, fiber.Map{
+				"name":        f.FileName,
+				"labels":      f.Labels,
+				"description": f.Description,
+				"status":      f.Status,
+				"updated":     f.UpdatedAt,
+				"document_id": f.DocumentID,
+			})
+		}
+		return utils.SendPaginated(c, fiber.Map{"files": result}, page, limit, int(total))
+	}
+}
+
+// sortOrder returns the ORDER BY clause of the sort and order query parameters, where columns maps the
+// fields a list can be sorted by to their column
+func sortOrder(c *fiber.Ctx, columns map[string]string, defaultSort string) (string, error) {
+	sort, desc, err := utils.ParseSort(c, defaultSort)
+	if err != nil {
+		return "", err
+	}
+	column, ok := columns[sort]
+	if !ok {
+		return "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid sort parameter %q", sort))
+	}
+	if desc {
+		return column + " DESC, id", nil
+	}
+	return column + " ASC, id", nil
+}
 
-	app.Get("/tracked-chat-logs", func(c *fiber.Ctx) error {
+// filterByDate narrows a query to the since and until query parameters on column
+func filterByDate(c *fiber.Ctx, query *gorm.DB, column string) (*gorm.DB, error) {
+	since, until, err := utils.ParseDateRange(c)
+	if err != nil {
+		return nil, err
+	}
+	if since != nil {
+		query = query.Where(column+" >= ?", *since)
+	}
+	if until != nil {
+		query = query.Where(column+" < ?", *until)
+	}
+	return query, nil
+}
+
+// listTrackedChatLogs lists the tracked chat logs, most recent first
+// @Summary List tracked chat logs
+// @Tags uploads
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{logs=[]models.TrackedChatLog}}
+// @Failure 500 {object} utils.APIResponse
+// @Router /tracked-chat-logs [get]
+func listTrackedChatLogs(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		var logs []models.TrackedChatLog
 		if err := db.Order("created_at desc").Find(&logs).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch tracked chat logs"})
+			return utils.SendError(c, fiber.StatusInternalServerError, "Failed to fetch tracked chat logs")
 		}
-		return c.JSON(fiber.Map{"logs": logs})
-	})
+		return utils.SendSuccess(c, fiber.Map{"logs": logs})
+	}
+}
+
+// writeUpload saves an upload in the upload directory. A different file already stored under
+// the same name is kept, and the new one is prefixed with the start of its checksum.
+func writeUpload(upload *validatedUpload) (string, error) {
+	destPath := filepath.Join(uploadDir, upload.Name)
+	if _, err := os.Stat(destPath); err == nil {
+		destPath = filepath.Join(uploadDir, upload.Checksum[:8]+"_"+upload.Name)
+	}
+	if err := os.WriteFile(destPath, upload.Content, 0644); err != nil {
+		return "", err
+	}
+	return destPath, nil
 }
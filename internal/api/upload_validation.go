@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uploadContentTypes lists, per supported extension, the content types sniffed from the first
+// bytes of a genuine file. Office formats are ZIP archives.
+var uploadContentTypes = map[string][]string{
+	".pdf":  {"application/pdf"},
+	".docx": {"application/zip"},
+	".xlsx": {"application/zip"},
+	".pptx": {"application/zip"},
+	".txt":  {"text/plain"},
+	".md":   {"text/plain"},
+	".csv":  {"text/plain"},
+	".json": {"text/plain"},
+	".png":  {"image/png"},
+	".jpg":  {"image/jpeg"},
+	".jpeg": {"image/jpeg"},
+}
+
+// UploadPolicy limits the files accepted by the upload routes
+type UploadPolicy struct {
+	MaxBytes   int64
+	Extensions map[string]bool
+}
+
+// NewUploadPolicy builds the upload policy from a size limit in megabytes and a comma-separated
+// extension whitelist. Extensions without a known content type are ignored.
+func NewUploadPolicy(maxSizeMB int, extensions string) UploadPolicy {
+	if maxSizeMB <= 0 {
+		maxSizeMB = 20
+	}
+	policy := UploadPolicy{MaxBytes: int64(maxSizeMB) << 20, Extensions: map[string]bool{}}
+	for _, ext := range strings.Split(extensions, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if _, ok := uploadContentTypes[ext]; !ok {
+			log.Printf("[WARNING] Ignoring upload extension %s: its content type cannot be verified", ext)
+			continue
+		}
+		policy.Extensions[ext] = true
+	}
+	return policy
+}
+
+// validatedUpload is an uploaded file that passed the upload policy
+type validatedUpload struct {
+	Name     string // Sanitized file name
+	Content  []byte
+	MimeType string
+	Checksum string // Hex SHA-256 of the content
+}
+
+// validate reads an uploaded file and checks its name, size, extension, and sniffed content type
+func (p UploadPolicy) validate(fileHeader *multipart.FileHeader) (*validatedUpload, error) {
+	name := utils.SanitizeFileName(fileHeader.Filename)
+	if name == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid file name")
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if !p.Extensions[ext] {
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("File type %q is not allowed", ext))
+	}
+	if fileHeader.Size > p.MaxBytes {
+		return nil, p.tooLarge(name)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, p.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if int64(len(content)) > p.MaxBytes {
+		return nil, p.tooLarge(name)
+	}
+	if len(content) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s is empty", name))
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	if !contentTypeAllowed(ext, mimeType) {
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType,
+			fmt.Sprintf("Content of %s (%s) does not match its extension", name, mimeType))
+	}
+
+	sum := sha256.Sum256(content)
+	return &validatedUpload{
+		Name:     name,
+		Content:  content,
+		MimeType: mimeType,
+		Checksum: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+func (p UploadPolicy) tooLarge(name string) error {
+	return fiber.NewError(fiber.StatusRequestEntityTooLarge,
+		fmt.Sprintf("%s exceeds the maximum upload size of %d MB", name, p.MaxBytes>>20))
+}
+
+func contentTypeAllowed(ext, mimeType string) bool {
+	for _, allowed := range uploadContentTypes[ext] {
+		if mimeType == allowed {
+			return true
+		}
+	}
+	return false
+}
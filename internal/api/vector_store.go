@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"tic-knowledge-system/internal/config"
+	"tic-knowledge-system/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// NewVectorStore builds the vector database of the knowledge entries from the VECTOR_BACKEND settings:
+// Qdrant, or pgvector in db, whose extension it creates. ticctl and the evaluation use it too.
+func NewVectorStore(ctx context.Context, cfg *config.Config, db *gorm.DB) (services.VectorStore, error) {
+	switch cfg.VectorBackend {
+	case services.VectorBackendQdrant, "":
+		return services.NewVectorService(cfg.VectorDBURL, cfg.QdrantCollectionName), nil
+	case services.VectorBackendPgvector:
+		dimension, err := strconv.Atoi(cfg.VectorDimension)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VECTOR_DIMENSION %q", cfg.VectorDimension)
+		}
+		store := services.NewPgVectorService(db, dimension)
+		if err := store.SetIndexType(cfg.PgvectorIndex); err != nil {
+			return nil, err
+		}
+		if err := store.EnsureExtension(ctx); err != nil {
+			return nil, fmt.Errorf("failed to set up pgvector: %w", err)
+		}
+		log.Printf("[INFO] Storing vectors in PostgreSQL with pgvector (%s index)", cfg.PgvectorIndex)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown VECTOR_BACKEND %q, expected qdrant or pgvector", cfg.VectorBackend)
+	}
+}
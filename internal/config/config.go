@@ -8,6 +8,7 @@ import (
 
 type Config struct {
 	Port        string
+	GRPCPort    string // Port of the gRPC API; empty disables it
 	DatabaseURL string
 	OpenAIKey   string
 	JWTSecret   string
@@ -22,21 +23,214 @@ type Config struct {
 	DBPassword string
 	DBSSLMode  string
 
+	// Database connection pool config, applied to the primary and every read replica
+	DBMaxOpenConns           string
+	DBMaxIdleConns           string
+	DBConnMaxLifetimeMinutes string
+	DBConnMaxIdleTimeMinutes string
+
 	// OpenAI config
 	OpenAIModel          string
 	OpenAIEmbeddingModel string
 	MaxTokens            string
 	Temperature          string
+	OpenAIContextWindow  string // Tokens; 0 looks it up from the model name
+
+	// Azure OpenAI config; when AzureOpenAIEndpoint is set it replaces api.openai.com
+	AzureOpenAIEndpoint             string
+	AzureOpenAIAPIKey               string
+	AzureOpenAIAPIVersion           string
+	AzureOpenAIAssistantsAPIVersion string
+	AzureOpenAIDeployment           string
+	AzureOpenAIEmbeddingDeployment  string
+
+	// Assistant engine behind the /assistant endpoints
+	AssistantEngine       string // assistants (beta Assistants API) or responses (Responses API)
+	ResponsesModel        string // Model of the responses engine; empty uses OpenAIModel
+	ResponsesInstructions string // Instructions of the responses engine; empty uses the default support prompt
 
 	// Gemini config
-	GeminiAPIKey string
-	GeminiModel  string
+	GeminiAPIKey        string
+	GeminiModel         string
+	GeminiContextWindow string
+
+	// Ollama / OpenAI-compatible self-hosted model config
+	OllamaBaseURL        string
+	OllamaAPIKey         string
+	OllamaModel          string
+	OllamaEmbeddingModel string
+	OllamaContextWindow  string
 
 	// AI Provider config
 	PrimaryAIProvider string
 	EmbeddingProvider string
 
+	// Provider health checks and circuit breaker
+	AICircuitFailureThreshold    string
+	AICircuitCooldownSeconds     string
+	AIHealthCheckIntervalSeconds string
+
+	// Provider output validation: empty answers, boilerplate refusals, answers in another script and
+	// system prompt leaks are retried once, on the fallback provider when there is one
+	AIResponseValidation string
+
+	// Semantic answer cache config
+	SemanticCacheEnabled    string
+	SemanticCacheThreshold  string
+	SemanticCacheTTLMinutes string
+	SemanticCacheMaxEntries string
+
+	// Monthly usage quotas, 0 means unlimited
+	QuotaUserMonthlyTokens  string
+	QuotaUserMonthlyCostUSD string
+	QuotaOrgMonthlyTokens   string
+	QuotaOrgMonthlyCostUSD  string
+
+	// Chat deduplication config
+	ChatDedupWindowSeconds string // 0 disables deduplication
+
+	// Admin impersonation config
+	ImpersonationTTLMinutes string
+
+	// Contextual help config
+	HelpTipsTTLHours string // Quick tips are regenerated after this many hours
+
+	// Chat guardrails config
+	GuardrailsPIIRedaction   string // Redact emails, phone numbers, and card numbers from user messages
+	GuardrailsModeration     string // Response moderation: auto, openai, regex, or off
+	GuardrailsBlockedPattern string // Responses matching this regular expression are blocked
+
+	// Job queue config
+	JobWorkers             string
+	JobPollIntervalSeconds string
+
+	// Retrieval evaluation config
+	RetrievalEvalHour    string // Local hour of day for the nightly evaluation
+	RetrievalEvalAnswers string // Nightly evaluations also answer every question and score faithfulness with an LLM judge
+
+	// Prompt experiment config
+	ExperimentSimilarityThreshold string // Experiment answers less similar than this to their golden answer regress
+
+	// Uploaded document storage config
+	StorageBackend            string // local or s3 (S3, MinIO, or GCS through its S3-compatible XML API)
+	StorageLocalDir           string
+	StorageS3Endpoint         string
+	StorageS3Region           string
+	StorageS3Bucket           string
+	StorageS3AccessKey        string
+	StorageS3SecretKey        string
+	StorageS3PathStyle        string // Required by MinIO
+	StorageDownloadURLMinutes string // Lifetime of presigned download URLs
+
+	// Document processing notifications
+	DocumentNotifyUploader string // Email uploaders when their document is indexed or fails to process
+	DocumentWebhookURL     string // Receives document.indexed, document.imported and document.failed events; empty disables it
+	DocumentWebhookSecret  string // Signs webhook requests; unsigned when empty
+	SpreadsheetChunkRows   string // Table rows per knowledge entry of imported spreadsheets
+	WebCrawlMaxPages       string // Most pages of a sitemap visited by one crawl
+	ImportEnrichment       string // Have the AI tag, summarize and suggest a category for each imported section, one request per section
+	ImportSections         string // JSON section options of imported documents by default, file type and category; see the import_sections setting
+
+	// OpenAI resource garbage collection config
+	OpenAIGCMaxAgeHours string // Orphans younger than this are kept
+
+	// Chat retention config
+	RetentionExportDir string // Transcripts exported before removal are written here
+
+	// Confluence space connector; disabled unless the base URL, API token and space key are set
+	ConfluenceBaseURL  string
+	ConfluenceEmail    string // Cloud accounts; empty sends the token as a Server/Data Center personal access token
+	ConfluenceAPIToken string
+	ConfluenceSpaceKey string
+	ConfluenceCategory string // Category of the synced entries
+
+	// Notion connector; disabled unless the API token is set
+	NotionAPIToken    string
+	NotionDatabaseIDs string // Comma-separated; empty syncs every page shared with the integration
+	NotionCategory    string // Category of the synced entries
+
+	// Google Drive folder connector; disabled unless the credentials file and folder ID are set
+	GoogleDriveCredentialsFile string // Service account JSON key; share the folder with the service account
+	GoogleDriveFolderID        string
+	GoogleDriveTarget          string // vector_store or knowledge_base; empty uses the pipeline's default
+	GoogleDriveCategory        string // Category of the entries of knowledge base imports
+
+	// Stale knowledge review
+	StaleEntryDays          string // Published entries not updated for this many days are flagged for review; categories can override it
+	StaleReviewReminderDays string // Creators are reminded again after this many days while their entries wait for review
+
+	// Soft-deleted records are permanently deleted this many days after their deletion; 0 keeps them
+	TrashRetentionDays string
+
+	// Chunking config
+	ChunkMaxTokens     string
+	ChunkOverlapTokens string
+
+	// Related questions are written by the AI on publish and embedded with the content
+	RelatedQuestionsEnabled string
+
+	// Chat answers are given in the language of the question or of ?lang=; retrieved knowledge and answers in
+	// other languages are translated by the AI provider
+	TranslationEnabled string
+
+	// "See also" suggestions of related entries with low-confidence chat answers
+	SeeAlsoLimit    string // 0 disables suggestions
+	SeeAlsoMinScore string // Answers whose best citation scores below this get suggestions
+
+	// Chat answers whose confidence is below this say the bot does not know and suggest contacting support; 0 disables it
+	AnswerConfidenceThreshold string
+
+	// Retrieval query rewriting of chat messages
+	QueryRewriteEnabled     string // Rewrite follow-up messages into standalone queries using the conversation
+	QueryRewriteParaphrases string // Paraphrased queries also searched, with the results merged; 0 disables it
+
+	// Largest image users can attach to a chat message, in MB
+	ChatAttachmentMaxMB string
+
+	// File upload limits for /upload and /context-file
+	UploadMaxSizeMB         string
+	UploadAllowedExtensions string // Comma-separated, e.g. .pdf,.docx
+
+	// Request signing config for the public widget and inbound webhooks
+	WidgetSigningSecret       string
+	WebhookSigningSecret      string
+	SignatureToleranceSeconds string
+
+	// Read replica config
+	ReadReplicaEnabled              string
+	ReadReplicaURLs                 string // comma-separated DSNs
+	ReadReplicaMaxLagSeconds        string
+	ReadReplicaCheckIntervalSeconds string
+
+	// Single sign-on (OIDC) config; disabled unless the issuer and client ID are set. Signed-in users get a session
+	// token signed with JWTSecret, as a cookie and in the callback response.
+	SSOIssuerURL         string // e.g. https://accounts.google.com or https://login.microsoftonline.com/{tenant}/v2.0
+	SSOClientID          string
+	SSOClientSecret      string
+	SSORedirectURL       string // Public URL of /api/v1/auth/sso/callback registered with the provider
+	SSOScopes            string // Comma-separated scopes added to openid, email and profile
+	SSOGroupsClaim       string // ID token claim listing the user's groups
+	SSORoleMapping       string // Comma-separated group=role pairs, e.g. tic-admins=admin,tic-editors=editor
+	SSODefaultRole       string // Role of provisioned users in none of the mapped groups
+	SSOAllowedDomains    string // Comma-separated email domains allowed to sign in; empty allows every domain
+	SSOPostLoginRedirect string // Where the browser is sent after login; empty answers the callback with JSON
+	SessionTTLHours      string
+	SessionCookieSecure  string
+
+	// Lifecycle config
+	ShutdownTimeoutSeconds        string // Time given to in-flight requests and jobs to finish on SIGINT/SIGTERM
+	ReadinessCheckIntervalSeconds string // How often /readyz re-checks the database, Qdrant, and the AI providers
+
+	// Email (SMTP) config for user notifications
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
 	// Vector DB config
+	VectorBackend        string // qdrant, or pgvector to keep vectors in the PostgreSQL database
+	PgvectorIndex        string // hnsw or ivfflat
 	QdrantHost           string
 	QdrantPort           string
 	QdrantCollectionName string
@@ -49,6 +243,7 @@ func Load() (*Config, error) {
 
 	return &Config{
 		Port:        getEnv("PORT", "8080"),
+		GRPCPort:    getEnv("GRPC_PORT", ""),
 		DatabaseURL: getEnv("DATABASE_URL", ""),
 		OpenAIKey:   getEnv("OPENAI_API_KEY", ""),
 		JWTSecret:   getEnv("JWT_SECRET", ""),
@@ -62,17 +257,169 @@ func Load() (*Config, error) {
 		DBPassword: getEnv("DB_PASSWORD", "password"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
+		DBMaxOpenConns:           getEnv("DB_MAX_OPEN_CONNS", "25"),
+		DBMaxIdleConns:           getEnv("DB_MAX_IDLE_CONNS", "10"),
+		DBConnMaxLifetimeMinutes: getEnv("DB_CONN_MAX_LIFETIME_MINUTES", "30"),
+		DBConnMaxIdleTimeMinutes: getEnv("DB_CONN_MAX_IDLE_TIME_MINUTES", "5"),
+
 		OpenAIModel:          getEnv("OPENAI_MODEL", "gpt-4"),
 		OpenAIEmbeddingModel: getEnv("OPENAI_EMBEDDING_MODEL", "text-embedding-ada-002"),
 		MaxTokens:            getEnv("MAX_TOKENS", "1000"),
 		Temperature:          getEnv("TEMPERATURE", "0.7"),
+		OpenAIContextWindow:  getEnv("OPENAI_CONTEXT_WINDOW", "0"),
+
+		AzureOpenAIEndpoint:             getEnv("AZURE_OPENAI_ENDPOINT", ""),
+		AzureOpenAIAPIKey:               getEnv("AZURE_OPENAI_API_KEY", ""),
+		AzureOpenAIAPIVersion:           getEnv("AZURE_OPENAI_API_VERSION", "2024-06-01"),
+		AzureOpenAIAssistantsAPIVersion: getEnv("AZURE_OPENAI_ASSISTANTS_API_VERSION", "2024-05-01-preview"),
+		AzureOpenAIDeployment:           getEnv("AZURE_OPENAI_DEPLOYMENT", ""),
+		AzureOpenAIEmbeddingDeployment:  getEnv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT", ""),
+
+		AssistantEngine:       getEnv("ASSISTANT_ENGINE", "assistants"),
+		ResponsesModel:        getEnv("RESPONSES_MODEL", ""),
+		ResponsesInstructions: getEnv("RESPONSES_INSTRUCTIONS", ""),
 
-		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
-		GeminiModel:  getEnv("GEMINI_MODEL", "gemini-1.5-pro"),
+		GeminiAPIKey:        getEnv("GEMINI_API_KEY", ""),
+		GeminiModel:         getEnv("GEMINI_MODEL", "gemini-1.5-pro"),
+		GeminiContextWindow: getEnv("GEMINI_CONTEXT_WINDOW", "0"),
+
+		OllamaBaseURL:        getEnv("OLLAMA_BASE_URL", ""),
+		OllamaAPIKey:         getEnv("OLLAMA_API_KEY", ""),
+		OllamaModel:          getEnv("OLLAMA_MODEL", "llama3.1"),
+		OllamaEmbeddingModel: getEnv("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text"),
+		OllamaContextWindow:  getEnv("OLLAMA_CONTEXT_WINDOW", "0"),
 
 		PrimaryAIProvider: getEnv("PRIMARY_AI_PROVIDER", "openai"),
 		EmbeddingProvider: getEnv("EMBEDDING_PROVIDER", "openai"),
 
+		AICircuitFailureThreshold:    getEnv("AI_CIRCUIT_FAILURE_THRESHOLD", "3"),
+		AICircuitCooldownSeconds:     getEnv("AI_CIRCUIT_COOLDOWN_SECONDS", "30"),
+		AIHealthCheckIntervalSeconds: getEnv("AI_HEALTH_CHECK_INTERVAL_SECONDS", "60"),
+		AIResponseValidation:         getEnv("AI_RESPONSE_VALIDATION", "true"),
+
+		SemanticCacheEnabled:    getEnv("SEMANTIC_CACHE_ENABLED", "true"),
+		SemanticCacheThreshold:  getEnv("SEMANTIC_CACHE_THRESHOLD", "0.97"),
+		SemanticCacheTTLMinutes: getEnv("SEMANTIC_CACHE_TTL_MINUTES", "60"),
+		SemanticCacheMaxEntries: getEnv("SEMANTIC_CACHE_MAX_ENTRIES", "500"),
+
+		QuotaUserMonthlyTokens:  getEnv("QUOTA_USER_MONTHLY_TOKENS", "0"),
+		QuotaUserMonthlyCostUSD: getEnv("QUOTA_USER_MONTHLY_COST_USD", "0"),
+		QuotaOrgMonthlyTokens:   getEnv("QUOTA_ORG_MONTHLY_TOKENS", "0"),
+		QuotaOrgMonthlyCostUSD:  getEnv("QUOTA_ORG_MONTHLY_COST_USD", "0"),
+
+		ChatDedupWindowSeconds: getEnv("CHAT_DEDUP_WINDOW_SECONDS", "10"),
+
+		ImpersonationTTLMinutes: getEnv("IMPERSONATION_TTL_MINUTES", "30"),
+
+		HelpTipsTTLHours: getEnv("HELP_TIPS_TTL_HOURS", "24"),
+
+		GuardrailsPIIRedaction:   getEnv("GUARDRAILS_PII_REDACTION", "true"),
+		GuardrailsModeration:     getEnv("GUARDRAILS_MODERATION", "auto"),
+		GuardrailsBlockedPattern: getEnv("GUARDRAILS_BLOCKED_PATTERN", ""),
+
+		JobWorkers:             getEnv("JOB_WORKERS", "4"),
+		JobPollIntervalSeconds: getEnv("JOB_POLL_INTERVAL_SECONDS", "2"),
+
+		RetrievalEvalHour:    getEnv("RETRIEVAL_EVAL_HOUR", "2"),
+		RetrievalEvalAnswers: getEnv("RETRIEVAL_EVAL_ANSWERS", "false"),
+
+		ExperimentSimilarityThreshold: getEnv("EXPERIMENT_SIMILARITY_THRESHOLD", "0.85"),
+
+		StorageBackend:            getEnv("STORAGE_BACKEND", "local"),
+		StorageLocalDir:           getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+		StorageS3Endpoint:         getEnv("STORAGE_S3_ENDPOINT", ""),
+		StorageS3Region:           getEnv("STORAGE_S3_REGION", "us-east-1"),
+		StorageS3Bucket:           getEnv("STORAGE_S3_BUCKET", ""),
+		StorageS3AccessKey:        getEnv("STORAGE_S3_ACCESS_KEY", ""),
+		StorageS3SecretKey:        getEnv("STORAGE_S3_SECRET_KEY", ""),
+		StorageS3PathStyle:        getEnv("STORAGE_S3_PATH_STYLE", "false"),
+		StorageDownloadURLMinutes: getEnv("STORAGE_DOWNLOAD_URL_MINUTES", "15"),
+
+		DocumentNotifyUploader: getEnv("DOCUMENT_NOTIFY_UPLOADER", "false"),
+		DocumentWebhookURL:     getEnv("DOCUMENT_WEBHOOK_URL", ""),
+		DocumentWebhookSecret:  getEnv("DOCUMENT_WEBHOOK_SECRET", ""),
+		SpreadsheetChunkRows:   getEnv("SPREADSHEET_CHUNK_ROWS", "50"),
+		WebCrawlMaxPages:       getEnv("WEB_CRAWL_MAX_PAGES", "100"),
+		ImportEnrichment:       getEnv("IMPORT_ENRICHMENT", "true"),
+		ImportSections:         getEnv("IMPORT_SECTIONS", ""),
+
+		OpenAIGCMaxAgeHours: getEnv("OPENAI_GC_MAX_AGE_HOURS", "168"),
+
+		RetentionExportDir: getEnv("RETENTION_EXPORT_DIR", "exports/chat-retention"),
+
+		ConfluenceBaseURL:  getEnv("CONFLUENCE_BASE_URL", ""),
+		ConfluenceEmail:    getEnv("CONFLUENCE_EMAIL", ""),
+		ConfluenceAPIToken: getEnv("CONFLUENCE_API_TOKEN", ""),
+		ConfluenceSpaceKey: getEnv("CONFLUENCE_SPACE_KEY", ""),
+		ConfluenceCategory: getEnv("CONFLUENCE_CATEGORY", "Confluence"),
+
+		NotionAPIToken:    getEnv("NOTION_API_TOKEN", ""),
+		NotionDatabaseIDs: getEnv("NOTION_DATABASE_IDS", ""),
+		NotionCategory:    getEnv("NOTION_CATEGORY", "Notion"),
+
+		GoogleDriveCredentialsFile: getEnv("GOOGLE_DRIVE_CREDENTIALS_FILE", ""),
+		GoogleDriveFolderID:        getEnv("GOOGLE_DRIVE_FOLDER_ID", ""),
+		GoogleDriveTarget:          getEnv("GOOGLE_DRIVE_TARGET", ""),
+		GoogleDriveCategory:        getEnv("GOOGLE_DRIVE_CATEGORY", ""),
+
+		StaleEntryDays:          getEnv("STALE_ENTRY_DAYS", "180"),
+		StaleReviewReminderDays: getEnv("STALE_REVIEW_REMINDER_DAYS", "7"),
+
+		TrashRetentionDays: getEnv("TRASH_RETENTION_DAYS", "30"),
+
+		ChunkMaxTokens:     getEnv("CHUNK_MAX_TOKENS", "400"),
+		ChunkOverlapTokens: getEnv("CHUNK_OVERLAP_TOKENS", "50"),
+
+		RelatedQuestionsEnabled: getEnv("RELATED_QUESTIONS_ENABLED", "true"),
+
+		TranslationEnabled: getEnv("TRANSLATION_ENABLED", "true"),
+
+		SeeAlsoLimit:    getEnv("SEE_ALSO_LIMIT", "3"),
+		SeeAlsoMinScore: getEnv("SEE_ALSO_MIN_SCORE", "0.75"),
+
+		AnswerConfidenceThreshold: getEnv("ANSWER_CONFIDENCE_THRESHOLD", "0.45"),
+
+		QueryRewriteEnabled:     getEnv("QUERY_REWRITE_ENABLED", "false"),
+		QueryRewriteParaphrases: getEnv("QUERY_REWRITE_PARAPHRASES", "0"),
+
+		ChatAttachmentMaxMB: getEnv("CHAT_ATTACHMENT_MAX_MB", "5"),
+
+		UploadMaxSizeMB:         getEnv("UPLOAD_MAX_SIZE_MB", "20"),
+		UploadAllowedExtensions: getEnv("UPLOAD_ALLOWED_EXTENSIONS", ".pdf,.docx,.txt,.md,.csv,.xlsx"),
+
+		WidgetSigningSecret:       getEnv("WIDGET_SIGNING_SECRET", ""),
+		WebhookSigningSecret:      getEnv("WEBHOOK_SIGNING_SECRET", ""),
+		SignatureToleranceSeconds: getEnv("SIGNATURE_TOLERANCE_SECONDS", "300"),
+
+		ReadReplicaEnabled:              getEnv("READ_REPLICA_ENABLED", "false"),
+		ReadReplicaURLs:                 getEnv("READ_REPLICA_URLS", ""),
+		ReadReplicaMaxLagSeconds:        getEnv("READ_REPLICA_MAX_LAG_SECONDS", "10"),
+		ReadReplicaCheckIntervalSeconds: getEnv("READ_REPLICA_CHECK_INTERVAL_SECONDS", "15"),
+
+		SSOIssuerURL:         getEnv("SSO_ISSUER_URL", ""),
+		SSOClientID:          getEnv("SSO_CLIENT_ID", ""),
+		SSOClientSecret:      getEnv("SSO_CLIENT_SECRET", ""),
+		SSORedirectURL:       getEnv("SSO_REDIRECT_URL", "http://localhost:8080/api/v1/auth/sso/callback"),
+		SSOScopes:            getEnv("SSO_SCOPES", ""),
+		SSOGroupsClaim:       getEnv("SSO_GROUPS_CLAIM", "groups"),
+		SSORoleMapping:       getEnv("SSO_ROLE_MAPPING", ""),
+		SSODefaultRole:       getEnv("SSO_DEFAULT_ROLE", "user"),
+		SSOAllowedDomains:    getEnv("SSO_ALLOWED_DOMAINS", ""),
+		SSOPostLoginRedirect: getEnv("SSO_POST_LOGIN_REDIRECT", ""),
+		SessionTTLHours:      getEnv("SESSION_TTL_HOURS", "12"),
+		SessionCookieSecure:  getEnv("SESSION_COOKIE_SECURE", "true"),
+
+		ShutdownTimeoutSeconds:        getEnv("SHUTDOWN_TIMEOUT_SECONDS", "30"),
+		ReadinessCheckIntervalSeconds: getEnv("READINESS_CHECK_INTERVAL_SECONDS", "15"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "no-reply@tic.local"),
+
+		VectorBackend:        getEnv("VECTOR_BACKEND", "qdrant"),
+		PgvectorIndex:        getEnv("PGVECTOR_INDEX", "hnsw"),
 		QdrantHost:           getEnv("QDRANT_HOST", "localhost"),
 		QdrantPort:           getEnv("QDRANT_PORT", "6333"),
 		QdrantCollectionName: getEnv("QDRANT_COLLECTION_NAME", "knowledge_base"),
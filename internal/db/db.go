@@ -1,6 +1,8 @@
 package db
 
 import (
+	"log"
+
 	"tic-knowledge-system/internal/models"
 
 	"gorm.io/driver/postgres"
@@ -22,8 +24,12 @@ func Connect(databaseURL string) (*gorm.DB, error) {
 		&models.Template{},
 		&models.TemplateField{},
 		&models.KnowledgeEntry{},
+		&models.PersonaPreset{},
+		&models.Announcement{},
 		&models.ChatSession{},
 		&models.ChatMessage{},
+		&models.ChatMessageRevision{},
+		&models.Bookmark{},
 		&models.Feedback{},
 		&models.VectorEmbedding{},
 		&models.UploadedFile{},
@@ -34,11 +40,65 @@ func Connect(databaseURL string) (*gorm.DB, error) {
 		&models.TimeDistributionStat{},
 		&models.TrackedChatLog{},
 		&models.UploadedDocument{},
+		&models.AssistantThread{},
+		&models.Job{},
+		&models.RequestNonce{},
+		&models.QueuedQuestion{},
+		&models.RetrievalEvalPair{},
+		&models.RetrievalEvalRun{},
+		&models.RetrievalEvalResult{},
+		&models.GoldenQuestion{},
+		&models.ExperimentRun{},
+		&models.ExperimentAnswer{},
+		&models.UsageRecord{},
+		&models.UsageQuota{},
+		&models.PromptTemplate{},
+		&models.ImpersonationSession{},
+		&models.ImpersonationAuditLog{},
+		&models.HelpScreen{},
+		&models.HelpScreenEntry{},
+		&models.ModerationEvent{},
+		&models.DocumentQuarantine{},
+		&models.ChatRetentionPolicy{},
+		&models.JobWorkerHeartbeat{},
+		&models.JobQueuePause{},
+		&models.RetrievalPreset{},
+		&models.CategoryRetrievalPreset{},
+		&models.WebSource{},
+		&models.ConfluencePage{},
+		&models.NotionPage{},
+		&models.DriveFile{},
+		&models.ScheduledJob{},
+		&models.CategoryReviewPolicy{},
+		&models.KnowledgeEntryRedirect{},
+		&models.ChatEscalation{},
+		&models.ChatAttachment{},
+		&models.ViewEvent{},
+		&models.SearchQueryLog{},
+		&models.QueryLog{},
+		&models.Setting{},
+		&models.ManagedAssistant{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Full-text search index over the weighted entry document
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_knowledge_entries_search ON knowledge_entries USING GIN ((" + models.KnowledgeSearchDocument + "))").Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Trigram index for the accent-insensitive keyword fallback. pg_trgm is optional: without it the
+	// fallback scans the table, which is acceptable since it only runs when vector search is unavailable.
+	err = db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error
+	if err == nil {
+		err = db.Exec("CREATE INDEX IF NOT EXISTS idx_knowledge_entries_search_text ON knowledge_entries USING GIN (search_text gin_trgm_ops)").Error
+	}
+	if err != nil {
+		log.Printf("[WARNING] Keyword search fallback runs without a trigram index: %v", err)
+	}
+
 	return db, nil
 }
 
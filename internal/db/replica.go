@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// replicaLagQuery reports how far a replica is behind the primary in seconds. A replica that has
+// replayed everything it received is treated as up to date even if the primary has been idle.
+const replicaLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// ReadRouter routes read-only queries to healthy read replicas and falls back to the primary
+// when no replica is configured, reachable, or within the allowed replication lag
+type ReadRouter struct {
+	primary  *gorm.DB
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+	lag     atomic.Int64 // nanoseconds
+	lastErr atomic.Value // string
+}
+
+// ReplicaStatus reports the health of a read replica
+type ReplicaStatus struct {
+	Name       string  `json:"name"`
+	Healthy    bool    `json:"healthy"`
+	LagSeconds float64 `json:"lag_seconds"`
+	LastError  string  `json:"last_error,omitempty"`
+}
+
+// NewReadRouter connects to the read replicas with the given pool settings. Replicas that cannot be reached
+// are skipped, so a misconfigured replica never prevents the server from starting.
+func NewReadRouter(primary *gorm.DB, replicaURLs []string, maxLag time.Duration, pool PoolConfig) *ReadRouter {
+	router := &ReadRouter{primary: primary, maxLag: maxLag}
+
+	for i, url := range replicaURLs {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		conn, err := gorm.Open(postgres.Open(url), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Warn),
+		})
+		if err != nil {
+			log.Printf("[WARNING] Failed to connect to read replica %d, skipping: %v", i+1, err)
+			continue
+		}
+		if err := ConfigurePool(conn, pool); err != nil {
+			log.Printf("[WARNING] Failed to configure the connection pool of read replica %d: %v", i+1, err)
+		}
+		router.replicas = append(router.replicas, &replica{name: fmt.Sprintf("replica-%d", i+1), db: conn})
+	}
+
+	router.checkReplicas(context.Background())
+	log.Printf("[INFO] Read router initialized with %d replicas (max lag %s)", len(router.replicas), maxLag)
+	return router
+}
+
+// Reader returns the connection for a read-only query that tolerates replication lag
+func (r *ReadRouter) Reader() *gorm.DB {
+	if r == nil {
+		return nil
+	}
+	count := len(r.replicas)
+	start := int(r.next.Add(1))
+	for i := 0; i < count; i++ {
+		candidate := r.replicas[(start+i)%count]
+		if candidate.healthy.Load() {
+			return candidate.db
+		}
+	}
+	return r.primary
+}
+
+// Primary returns the primary connection
+func (r *ReadRouter) Primary() *gorm.DB {
+	return r.primary
+}
+
+// StartHealthChecks periodically re-checks replica reachability and lag until ctx is cancelled
+func (r *ReadRouter) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.checkReplicas(ctx)
+			}
+		}
+	}()
+}
+
+// checkReplicas marks replicas unhealthy when unreachable or lagging beyond maxLag
+func (r *ReadRouter) checkReplicas(ctx context.Context) {
+	for _, rep := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		var lagSeconds float64
+		err := rep.db.WithContext(checkCtx).Raw(replicaLagQuery).Scan(&lagSeconds).Error
+		cancel()
+
+		wasHealthy := rep.healthy.Load()
+		if err != nil {
+			rep.healthy.Store(false)
+			rep.lastErr.Store(err.Error())
+			if wasHealthy {
+				log.Printf("[WARNING] Read replica %s is unreachable, routing reads to primary: %v", rep.name, err)
+			}
+			continue
+		}
+
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		rep.lag.Store(int64(lag))
+		rep.lastErr.Store("")
+
+		healthy := r.maxLag <= 0 || lag <= r.maxLag
+		rep.healthy.Store(healthy)
+		if wasHealthy && !healthy {
+			log.Printf("[WARNING] Read replica %s lag %s exceeds %s, routing reads elsewhere", rep.name, lag, r.maxLag)
+		} else if !wasHealthy && healthy {
+			log.Printf("[INFO] Read replica %s is healthy (lag %s)", rep.name, lag)
+		}
+	}
+}
+
+// Status reports the health of every replica
+func (r *ReadRouter) Status() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		lastErr, _ := rep.lastErr.Load().(string)
+		statuses = append(statuses, ReplicaStatus{
+			Name:       rep.name,
+			Healthy:    rep.healthy.Load(),
+			LagSeconds: time.Duration(rep.lag.Load()).Seconds(),
+			LastError:  lastErr,
+		})
+	}
+	return statuses
+}
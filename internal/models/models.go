@@ -13,6 +13,7 @@ type User struct {
 	Email     string         `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
 	Name      string         `json:"name" gorm:"not null" validate:"required"`
 	Role      UserRole       `json:"role" gorm:"not null;default:'user'" validate:"required"`
+	Teams     string         `json:"teams"` // comma-separated team names
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -30,16 +31,17 @@ const (
 
 // Template represents a knowledge entry template
 type Template struct {
-	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name        string          `json:"name" gorm:"not null" validate:"required"`
-	Description string          `json:"description"`
-	Category    string          `json:"category" gorm:"not null" validate:"required"`
-	Fields      []TemplateField `json:"fields" gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"`
-	IsActive    bool            `json:"is_active" gorm:"default:true"`
-	CreatedBy   uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
+	ID                uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name              string          `json:"name" gorm:"not null" validate:"required"`
+	Description       string          `json:"description"`
+	Category          string          `json:"category" gorm:"not null" validate:"required"`
+	Fields            []TemplateField `json:"fields" gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"`
+	IsActive          bool            `json:"is_active" gorm:"default:true"`
+	RetrievalPresetID *uuid.UUID      `json:"retrieval_preset_id,omitempty" gorm:"type:uuid"` // Overrides the category's retrieval preset
+	CreatedBy         uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	// Relations
 	Creator User `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
@@ -78,54 +80,208 @@ const (
 
 // KnowledgeEntry represents a knowledge base entry
 type KnowledgeEntry struct {
-	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Title       string         `json:"title" gorm:"not null" validate:"required"`
-	Content     string         `json:"content" gorm:"type:text;not null" validate:"required"`
-	Summary     string         `json:"summary" gorm:"type:text"`
-	Category    string         `json:"category" gorm:"not null" validate:"required"`
-	Tags        string         `json:"tags"` // JSON array of tags
-	TemplateID  *uuid.UUID     `json:"template_id" gorm:"type:uuid"`
-	FieldData   string         `json:"field_data" gorm:"type:jsonb"` // JSON data for template fields
-	IsPublished bool           `json:"is_published" gorm:"default:false"`
-	Priority    int            `json:"priority" gorm:"default:0"`
-	ViewCount   int            `json:"view_count" gorm:"default:0"`
-	CreatedBy   uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"`
-	UpdatedBy   *uuid.UUID     `json:"updated_by" gorm:"type:uuid"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                   uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Title                string          `json:"title" gorm:"not null" validate:"required"`
+	Content              string          `json:"content" gorm:"type:text;not null" validate:"required"`
+	Summary              string          `json:"summary" gorm:"type:text"`
+	Category             string          `json:"category" gorm:"not null" validate:"required"`
+	SuggestedCategory    string          `json:"suggested_category,omitempty"`                        // Category the AI suggested for an imported section, when it differs
+	SectionOptions       string          `json:"section_options,omitempty" gorm:"type:text"`          // JSON of the section options an imported section was split with, for tuning them
+	SourceDocumentID     *uuid.UUID      `json:"source_document_id,omitempty" gorm:"type:uuid;index"` // Uploaded document the entry was imported from
+	Tags                 string          `json:"tags"`                                                // JSON array of tags
+	TemplateID           *uuid.UUID      `json:"template_id" gorm:"type:uuid"`
+	FieldData            string          `json:"field_data" gorm:"type:jsonb"` // JSON data for template fields
+	IsPublished          bool            `json:"is_published" gorm:"default:false"`
+	PublishAt            *time.Time      `json:"publish_at,omitempty" gorm:"index"`   // Publishes the entry at this time; it stays unpublished until then
+	UnpublishAt          *time.Time      `json:"unpublish_at,omitempty" gorm:"index"` // Unpublishes the entry at this time, e.g. the end of a holiday workflow
+	AllowedRoles         string          `json:"allowed_roles"`                       // comma-separated roles; empty means visible to every role
+	AllowedTeams         string          `json:"allowed_teams"`                       // comma-separated teams; empty means visible to every team
+	Priority             int             `json:"priority" gorm:"default:0"`
+	ViewCount            int             `json:"view_count" gorm:"default:0"`
+	ReadingTimeSeconds   int             `json:"reading_time_seconds" gorm:"default:0;index"`
+	ReadabilityScore     float64         `json:"readability_score"` // Flesch reading ease, 0 (hardest) to 100 (easiest)
+	Complexity           ComplexityLevel `json:"complexity" gorm:"index"`
+	Language             string          `json:"language" gorm:"size:8;index"`            // ISO 639-1 code; empty when it could not be detected
+	RelatedQuestions     string          `json:"related_questions" gorm:"type:text"`      // AI-written phrasings of the questions the entry answers, JSON array
+	RelatedQuestionsHash string          `json:"-"`                                       // Hash of the text the related questions were written from
+	SearchText           string          `json:"-" gorm:"type:text"`                      // Lowercased text without diacritics that keyword search matches
+	StaleAt              *time.Time      `json:"stale_at,omitempty" gorm:"index"`         // When stale-entry detection found the entry unchanged for too long
+	NeedsReview          bool            `json:"needs_review" gorm:"default:false;index"` // Stale and waiting for its creator to confirm or update it
+	ReviewRemindedAt     *time.Time      `json:"review_reminded_at,omitempty"`            // When the creator was last asked to review the entry
+	CreatedBy            uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	UpdatedBy            *uuid.UUID      `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	// Relations
-	Template *Template `json:"template,omitempty" gorm:"foreignKey:TemplateID"`
-	Creator  User      `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
-	Updater  *User     `json:"updater,omitempty" gorm:"foreignKey:UpdatedBy"`
+	Template       *Template         `json:"template,omitempty" gorm:"foreignKey:TemplateID"`
+	Creator        User              `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+	Updater        *User             `json:"updater,omitempty" gorm:"foreignKey:UpdatedBy"`
+	SourceDocument *UploadedDocument `json:"source_document,omitempty" gorm:"foreignKey:SourceDocumentID"`
 }
 
+// KnowledgeSearchDocument is the weighted full-text document of a knowledge entry: title, then summary,
+// content and related questions. Queries must use the same expression to hit its GIN index.
+const KnowledgeSearchDocument = "setweight(to_tsvector('english', coalesce(title, '')), 'A') || " +
+	"setweight(to_tsvector('english', coalesce(summary, '')), 'B') || " +
+	"setweight(to_tsvector('english', coalesce(content, '')), 'C') || " +
+	"setweight(to_tsvector('english', coalesce(related_questions, '')), 'D')"
+
+type ComplexityLevel string
+
+const (
+	ComplexityEasy     ComplexityLevel = "easy"
+	ComplexityModerate ComplexityLevel = "moderate"
+	ComplexityAdvanced ComplexityLevel = "advanced"
+)
+
+// ViewEvent records a user opening a knowledge entry and where they opened it from
+type ViewEvent struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	KnowledgeEntryID uuid.UUID  `json:"knowledge_entry_id" gorm:"type:uuid;not null;index"`
+	UserID           *uuid.UUID `json:"user_id" gorm:"type:uuid;index"` // Nil for anonymous views
+	Source           ViewSource `json:"source" gorm:"not null;index"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"index"`
+}
+
+type ViewSource string
+
+const (
+	ViewFromSearch ViewSource = "search" // Opened from search results
+	ViewFromChat   ViewSource = "chat"   // Opened from a citation of a chat answer
+	ViewDirect     ViewSource = "direct" // Opened by link or while browsing
+)
+
+// SearchQueryLog records a knowledge base search and how many entries it found, to surface the
+// questions the knowledge base has no answer for
+type SearchQueryLog struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Query       string     `json:"query" gorm:"type:text;not null"`
+	Source      ViewSource `json:"source" gorm:"not null"` // search for the search endpoint, chat for chat retrieval
+	UserID      *uuid.UUID `json:"user_id" gorm:"type:uuid"`
+	ResultCount int        `json:"result_count" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+}
+
+// QueryLog records a search or chat question the knowledge base did not answer: one that found no
+// entries, or whose answer got negative feedback
+type QueryLog struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Query     string         `json:"query" gorm:"type:text;not null"`
+	Source    ViewSource     `json:"source" gorm:"not null"`
+	Reason    QueryLogReason `json:"reason" gorm:"not null;index"`
+	UserID    *uuid.UUID     `json:"user_id" gorm:"type:uuid"`
+	MessageID *uuid.UUID     `json:"message_id" gorm:"type:uuid;index"` // Rated answer, for negative feedback
+	Embedding string         `json:"-" gorm:"type:text"`                // JSON array; empty until embedded
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
+}
+
+type QueryLogReason string
+
+const (
+	QueryNoResults        QueryLogReason = "no_results"        // The search found no knowledge entries
+	QueryNegativeFeedback QueryLogReason = "negative_feedback" // The user rated the answer as unhelpful
+)
+
 // ChatSession represents a chat session
 type ChatSession struct {
 	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
 	Title     string         `json:"title"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	PersonaID *uuid.UUID     `json:"persona_id,omitempty" gorm:"type:uuid;index"` // Persona preset applied to every answer of the session
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
-	User     User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Messages []ChatMessage `json:"messages,omitempty" gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+	User     User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Persona  *PersonaPreset `json:"persona,omitempty" gorm:"foreignKey:PersonaID"`
+	Messages []ChatMessage  `json:"messages,omitempty" gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+}
+
+// PersonaPreset is a managed system prompt users can pick for a chat session, e.g. "answer as a warehouse
+// supervisor". Presets are deactivated rather than deleted, since sessions keep referencing them.
+type PersonaPreset struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name         string    `json:"name" gorm:"not null;uniqueIndex"`
+	Description  string    `json:"description" gorm:"type:text"`
+	SystemPrompt string    `json:"system_prompt" gorm:"type:text;not null"`
+	IsActive     bool      `json:"is_active" gorm:"not null;default:true"`
+	CreatedBy    uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	UpdatedBy    uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Announcement is an org-wide notice added to the system prompt of every chat answer while it is active,
+// e.g. "the payment gateway is under maintenance today"
+type Announcement struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Title     string     `json:"title" gorm:"not null"`
+	Content   string     `json:"content" gorm:"type:text;not null"`
+	Priority  int        `json:"priority" gorm:"not null;default:0"` // Higher priorities come first and are kept when too many are active
+	StartsAt  time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt    *time.Time `json:"ends_at,omitempty" gorm:"index"` // Nil keeps the announcement active until it is ended or deleted
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid"`
+	UpdatedBy uuid.UUID  `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 // ChatMessage represents a message in a chat session
 type ChatMessage struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	SessionID uuid.UUID      `json:"session_id" gorm:"type:uuid;not null"`
-	Role      MessageRole    `json:"role" gorm:"not null" validate:"required"`
-	Content   string         `json:"content" gorm:"type:text;not null" validate:"required"`
-	Metadata  string         `json:"metadata" gorm:"type:jsonb"` // For storing additional data like sources
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SessionID    uuid.UUID      `json:"session_id" gorm:"type:uuid;not null"`
+	Role         MessageRole    `json:"role" gorm:"not null" validate:"required"`
+	Content      string         `json:"content" gorm:"type:text;not null" validate:"required"`
+	Metadata     string         `json:"metadata" gorm:"type:jsonb"`               // For storing additional data like sources
+	TopicID      *uint          `json:"topic_id,omitempty" gorm:"index"`          // Classified topic of a user question; 0 when no topic matched
+	AttachmentID *uuid.UUID     `json:"attachment_id,omitempty" gorm:"type:uuid"` // Image the user attached to the message
+	Status       MessageStatus  `json:"status,omitempty" gorm:"index"`            // Whether a user question was answered; see MessageStatus
+	Version      int            `json:"version" gorm:"not null;default:1"`        // Incremented when the message is edited or regenerated
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Attachment *ChatAttachment `json:"attachment,omitempty" gorm:"foreignKey:AttachmentID"`
+}
+
+// ChatMessageRevision keeps the content of a chat message before it was edited or, for an answer, regenerated
+type ChatMessageRevision struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MessageID uuid.UUID `json:"message_id" gorm:"type:uuid;not null;uniqueIndex:idx_chat_message_revision"`
+	Version   int       `json:"version" gorm:"not null;uniqueIndex:idx_chat_message_revision"`
+	Content   string    `json:"content" gorm:"type:text;not null"`
+	Metadata  string    `json:"metadata" gorm:"type:jsonb"`
+	CreatedAt time.Time `json:"created_at"` // When the version was superseded
+}
+
+// Bookmark is a chat answer or knowledge entry a user saved. Bookmarked entries rank higher in the user's searches.
+type Bookmark struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_bookmark_message;uniqueIndex:idx_bookmark_entry"`
+	MessageID        *uuid.UUID `json:"message_id,omitempty" gorm:"type:uuid;uniqueIndex:idx_bookmark_message"`
+	KnowledgeEntryID *uuid.UUID `json:"knowledge_entry_id,omitempty" gorm:"type:uuid;uniqueIndex:idx_bookmark_entry"`
+	Note             string     `json:"note,omitempty" gorm:"type:text"`
+	CreatedAt        time.Time  `json:"created_at"`
+
+	// Relations
+	Message        *ChatMessage    `json:"message,omitempty" gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+	KnowledgeEntry *KnowledgeEntry `json:"knowledge_entry,omitempty" gorm:"foreignKey:KnowledgeEntryID;constraint:OnDelete:CASCADE"`
+}
+
+// ChatAttachment is an image a user uploaded to attach to chat messages, e.g. a screenshot of an error
+type ChatAttachment struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type MessageRole string
@@ -134,6 +290,16 @@ const (
 	UserMessage      MessageRole = "user"
 	AssistantMessage MessageRole = "assistant"
 	SystemMessage    MessageRole = "system"
+	SupportMessage   MessageRole = "support" // Written by a support agent who took over an escalated session
+)
+
+// MessageStatus tracks whether the assistant answered a user question, so that questions left without an
+// answer by a chat that failed midway are replayed. Other messages have no status.
+type MessageStatus string
+
+const (
+	MessagePending  MessageStatus = "pending"  // Saved before the answer is generated
+	MessageAnswered MessageStatus = "answered" // The answer was saved with it, or it was queued for a later answer
 )
 
 // Feedback represents user feedback on chat responses
@@ -163,37 +329,76 @@ const (
 	IncompleFeedback   FeedbackType = "incomplete"
 )
 
-// UploadedDocument represents a document uploaded to the system and OpenAI
+// UploadedDocument is a document going through the ingestion pipeline, either into the OpenAI
+// vector store or parsed into knowledge entries
 type UploadedDocument struct {
-	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	FileName         string         `json:"file_name" gorm:"not null" validate:"required"`
-	OriginalFileName string         `json:"original_file_name" gorm:"not null"`
-	FilePath         string         `json:"file_path" gorm:"not null"` // Local file path
-	FileSize         int64          `json:"file_size" gorm:"not null"`
-	MimeType         string         `json:"mime_type" gorm:"not null"`
-	OpenAIFileID     string         `json:"openai_file_id"`  // OpenAI file ID from step 1
-	VectorStoreID    string         `json:"vector_store_id"` // Vector store ID (fixed: vs_6873699daedc8191bb505a14254eeab3)
-	VectorFileID     string         `json:"vector_file_id"`  // Vector file ID from step 2
-	Status           DocumentStatus `json:"status" gorm:"not null;default:'uploaded'"`
-	ErrorMessage     string         `json:"error_message"` // Error details if processing failed
-	UploadedBy       uuid.UUID      `json:"uploaded_by" gorm:"type:uuid;not null"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FileName          string          `json:"file_name" gorm:"not null" validate:"required"`
+	OriginalFileName  string          `json:"original_file_name" gorm:"not null"`
+	FilePath          string          `json:"file_path" gorm:"not null"` // Local path or object URL of the stored file
+	StorageKey        string          `json:"storage_key"`               // Key in the file storage; empty for files stored on local disk before storage backends
+	FileSize          int64           `json:"file_size" gorm:"not null"`
+	MimeType          string          `json:"mime_type" gorm:"not null"`
+	Checksum          string          `json:"checksum,omitempty" gorm:"size:64;index"` // SHA-256 of the content
+	Target            IngestionTarget `json:"target" gorm:"not null;default:'vector_store'"`
+	Category          string          `json:"category,omitempty"`                             // Category of the entries of a knowledge base import
+	KnowledgeEntryIDs string          `json:"knowledge_entry_ids,omitempty" gorm:"type:text"` // Entries created by a knowledge base import, JSON array
+	SupersedesID      *uuid.UUID      `json:"supersedes_id,omitempty" gorm:"type:uuid;index"` // Earlier version of the file whose entries the import updated
+	Sections          SectionDiff     `json:"sections" gorm:"embedded;embeddedPrefix:sections_"`
+	OpenAIFileID      string          `json:"openai_file_id"`  // OpenAI file ID from step 1
+	VectorStoreID     string          `json:"vector_store_id"` // Vector store ID (fixed: vs_6873699daedc8191bb505a14254eeab3)
+	VectorFileID      string          `json:"vector_file_id"`  // Vector file ID from step 2
+	Status            DocumentStatus  `json:"status" gorm:"not null;default:'uploaded'"`
+	ErrorMessage      string          `json:"error_message"` // Error details if processing failed
+	UploadedBy        uuid.UUID       `json:"uploaded_by" gorm:"type:uuid;not null"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	// Relations
 	Uploader User `json:"uploader,omitempty" gorm:"foreignKey:UploadedBy"`
 }
 
+// SectionDiff counts how the sections of a knowledge base import compare to the entries of the earlier
+// version of the file. Every section of a first version is added.
+type SectionDiff struct {
+	Added     int `json:"added"`
+	Changed   int `json:"changed"`   // Updated and embedded again
+	Unchanged int `json:"unchanged"` // Kept as they were, without embedding them again
+	Removed   int `json:"removed"`
+}
+
+// IngestionTarget is where the ingestion pipeline puts a document
+type IngestionTarget string
+
+const (
+	IngestVectorStore   IngestionTarget = "vector_store"   // Uploaded to the OpenAI vector store for file search
+	IngestKnowledgeBase IngestionTarget = "knowledge_base" // Text extracted and split into knowledge entries
+)
+
 type DocumentStatus string
 
 const (
 	DocumentUploaded         DocumentStatus = "uploaded"        // File uploaded to local storage
 	DocumentSentToOpenAI     DocumentStatus = "sent_to_openai"  // Step 1 completed
-	DocumentAddedToVector    DocumentStatus = "added_to_vector" // Step 2 completed
+	DocumentAddedToVector    DocumentStatus = "added_to_vector" // Step 2 completed, OpenAI is processing the file
+	DocumentIndexed          DocumentStatus = "indexed"         // The vector store finished processing the file
+	DocumentImported         DocumentStatus = "imported"        // Knowledge entries were created from the file
 	DocumentProcessingFailed DocumentStatus = "processing_failed"
+	DocumentQuarantined      DocumentStatus = "quarantined" // Held for admin review by the prompt injection scanner
+	DocumentRejected         DocumentStatus = "rejected"    // Quarantined and rejected by an admin
+	DocumentSuperseded       DocumentStatus = "superseded"  // Replaced by a newer version of the file, which took over its entries
 )
 
+// AssistantThread tracks an OpenAI assistant thread, or a conversation of the responses engine. OpenAI
+// cannot list threads, so this is the only record of which threads exist and when they were last used.
+type AssistantThread struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ThreadID   string    `json:"thread_id" gorm:"not null;uniqueIndex"`
+	LastUsedAt time.Time `json:"last_used_at" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // VectorEmbedding represents vector embeddings for semantic search
 type VectorEmbedding struct {
 	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -201,6 +406,13 @@ type VectorEmbedding struct {
 	VectorID         string         `json:"vector_id" gorm:"not null"` // ID in vector database
 	ChunkIndex       int            `json:"chunk_index" gorm:"default:0"`
 	ChunkText        string         `json:"chunk_text" gorm:"type:text"`
+	StartOffset      int            `json:"start_offset" gorm:"default:0"` // Character offset of the chunk in the embedded text
+	EndOffset        int            `json:"end_offset" gorm:"default:0"`
+	TokenCount       int            `json:"token_count" gorm:"default:0"`
+	EmbeddingModel   string         `json:"embedding_model" gorm:"size:100"` // Model the vector was created with
+	Dimension        int            `json:"dimension" gorm:"default:0"`
+	Embedding        Vector         `json:"-" gorm:"type:real[]"`             // The vector itself with the pgvector backend; empty with Qdrant
+	Payload          string         `json:"-" gorm:"type:jsonb;default:'{}'"` // Search payload of the pgvector backend, as on a Qdrant point
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
@@ -212,6 +424,9 @@ type UploadedFile struct {
 	ID         uint      `gorm:"primaryKey"`
 	FileName   string    `gorm:"size:255;not null"`
 	FilePath   string    `gorm:"size:255;not null"`
+	Size       int64     `gorm:"not null;default:0"`
+	MimeType   string    `gorm:"size:100"`
+	Checksum   string    `gorm:"size:64;index"` // SHA-256 of the content, duplicate uploads are skipped
 	UploadTime time.Time `gorm:"autoCreateTime"`
 }
 
@@ -221,15 +436,26 @@ type APICallLog struct {
 	CalledAt time.Time `gorm:"autoCreateTime"`
 }
 
+// ContextFile is a file uploaded for the assistant to answer from. Active files are imported into the
+// knowledge base, their labels tagging the entries, and their status follows the import.
 type ContextFile struct {
-	ID          uint      `gorm:"primaryKey"`
-	FileName    string    `gorm:"size:255;not null;uniqueIndex"`
-	Labels      string    `gorm:"size:255"` // comma-separated labels
-	Description string    `gorm:"size:255"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
-	Status      string    `gorm:"size:50"`
+	ID          uint       `gorm:"primaryKey"`
+	FileName    string     `gorm:"size:255;not null;uniqueIndex"`
+	Labels      string     `gorm:"size:255"` // comma-separated labels
+	Description string     `gorm:"size:255"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
+	Status      string     `gorm:"size:50"`
+	DocumentID  *uuid.UUID `gorm:"type:uuid;index"` // Import of the file into the knowledge base
 }
 
+// Statuses of a context file
+const (
+	ContextFileActive      = "Active"      // Imported, its entries are retrieved for answers
+	ContextFileProcessing  = "Processing"  // Being imported
+	ContextFileFailed      = "Failed"      // The import failed, see the error of its document
+	ContextFileQuarantined = "Quarantined" // Held for admin review by the prompt injection scanner
+)
+
 type Topic struct {
 	ID          uint      `gorm:"primaryKey"`
 	Name        string    `gorm:"size:255;not null;uniqueIndex"`
@@ -261,3 +487,595 @@ type TrackedChatLog struct {
 	ResponseTime  int64     `gorm:"not null"` // milliseconds
 	CreatedAt     time.Time `gorm:"autoCreateTime"`
 }
+
+// Job represents a unit of background work persisted in the database-backed job queue
+type Job struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Queue       string     `json:"queue" gorm:"not null;index"`
+	Type        string     `json:"type" gorm:"not null;index"`
+	Payload     string     `json:"payload" gorm:"type:jsonb"`
+	Status      JobStatus  `json:"status" gorm:"not null;default:'pending';index"`
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"default:5"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index"`
+	LockedAt    *time.Time `json:"locked_at"`
+	LockedBy    string     `json:"locked_by"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed" // Failed an attempt, will be retried
+	JobDead      JobStatus = "dead"   // Exhausted all attempts (dead-letter)
+)
+
+// JobWorkerHeartbeat is the last heartbeat reported by a job queue process
+type JobWorkerHeartbeat struct {
+	WorkerID      string    `json:"worker_id" gorm:"primaryKey;size:255"`
+	Hostname      string    `json:"hostname"`
+	PID           int       `json:"pid"`
+	Workers       int       `json:"workers"`
+	ActiveJobs    int64     `json:"active_jobs"`
+	JobsProcessed int64     `json:"jobs_processed"`
+	JobsFailed    int64     `json:"jobs_failed"`
+	StartedAt     time.Time `json:"started_at"`
+	LastSeenAt    time.Time `json:"last_seen_at" gorm:"not null;index"`
+}
+
+// JobQueuePause marks a queue as paused; workers do not claim jobs from paused queues
+type JobQueuePause struct {
+	Queue     string    `json:"queue" gorm:"primaryKey;size:100"`
+	PausedBy  uuid.UUID `json:"paused_by" gorm:"type:uuid;not null"`
+	Reason    string    `json:"reason" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RequestNonce records a nonce from a signed request so that replays can be rejected
+type RequestNonce struct {
+	Nonce     string    `json:"nonce" gorm:"primaryKey;size:128"`
+	Channel   string    `json:"channel" gorm:"primaryKey;size:50"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QueuedQuestion is a chat question that could not be answered immediately and is answered later
+type QueuedQuestion struct {
+	ID         uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SessionID  uuid.UUID            `json:"session_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID            `json:"user_id" gorm:"type:uuid;not null;index"`
+	MessageID  *uuid.UUID           `json:"message_id" gorm:"type:uuid"` // The user message that asked the question
+	Question   string               `json:"question" gorm:"type:text;not null"`
+	Reason     string               `json:"reason" gorm:"not null"` // Why the question was queued, e.g. providers_unavailable
+	Status     QueuedQuestionStatus `json:"status" gorm:"not null;default:'pending';index"`
+	LastError  string               `json:"last_error" gorm:"type:text"`
+	AnsweredAt *time.Time           `json:"answered_at"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+type QueuedQuestionStatus string
+
+const (
+	QueuedQuestionPending  QueuedQuestionStatus = "pending"
+	QueuedQuestionAnswered QueuedQuestionStatus = "answered"
+	QueuedQuestionFailed   QueuedQuestionStatus = "failed"
+)
+
+// RetrievalEvalPair is an editor-labeled question with the knowledge entry that should answer it
+type RetrievalEvalPair struct {
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Question        string         `json:"question" gorm:"type:text;not null" validate:"required"`
+	ExpectedEntryID uuid.UUID      `json:"expected_entry_id" gorm:"type:uuid;not null;index" validate:"required"`
+	Notes           string         `json:"notes" gorm:"type:text"`
+	CreatedBy       uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	ExpectedEntry *KnowledgeEntry `json:"expected_entry,omitempty" gorm:"foreignKey:ExpectedEntryID"`
+}
+
+// RetrievalEvalRun records the retrieval quality metrics of one evaluation over all labeled pairs
+type RetrievalEvalRun struct {
+	ID                uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Trigger           string              `json:"trigger" gorm:"not null"` // scheduled or manual
+	Status            RetrievalEvalStatus `json:"status" gorm:"not null;default:'running';index"`
+	PairCount         int                 `json:"pair_count"`
+	RecallAt1         float64             `json:"recall_at_1"`
+	RecallAt3         float64             `json:"recall_at_3"`
+	RecallAt5         float64             `json:"recall_at_5"`
+	RecallAt10        float64             `json:"recall_at_10"`
+	MRR               float64             `json:"mrr"`
+	MeanTopScore      float64             `json:"mean_top_score"`
+	MeanExpectedScore float64             `json:"mean_expected_score"` // Over pairs whose expected entry was retrieved
+	AnswerCount       int                 `json:"answer_count"`        // Pairs answered through the chat path and judged; 0 for retrieval-only runs
+	MeanFaithfulness  float64             `json:"mean_faithfulness"`   // LLM judge score from 0 to 1 of how well answers stick to the retrieved passages
+	ScoreDistribution string              `json:"score_distribution" gorm:"type:jsonb"`
+	Config            string              `json:"config" gorm:"type:jsonb"` // Retrieval settings in effect for the run
+	Error             string              `json:"error" gorm:"type:text"`
+	StartedAt         time.Time           `json:"started_at" gorm:"index"`
+	CompletedAt       *time.Time          `json:"completed_at"`
+	CreatedAt         time.Time           `json:"created_at"`
+
+	// Relations
+	Results []RetrievalEvalResult `json:"results,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+}
+
+type RetrievalEvalStatus string
+
+const (
+	RetrievalEvalRunning   RetrievalEvalStatus = "running"
+	RetrievalEvalCompleted RetrievalEvalStatus = "completed"
+	RetrievalEvalFailed    RetrievalEvalStatus = "failed"
+)
+
+// GoldenQuestion is a question of the fixed set every prompt experiment answers
+type GoldenQuestion struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Question  string         `json:"question" gorm:"type:text;not null" validate:"required"`
+	Notes     string         `json:"notes" gorm:"type:text"`
+	CreatedBy *uuid.UUID     `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// ExperimentRun answers the golden questions with one prompt version and provider. The golden run of a
+// provider is the snapshot the answers of later runs are compared with.
+type ExperimentRun struct {
+	ID              uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PromptID        *uuid.UUID       `json:"prompt_id,omitempty" gorm:"type:uuid"` // Prompt version tried out; nil uses the active prompts
+	PromptName      string           `json:"prompt_name"`
+	PromptVersion   int              `json:"prompt_version"`
+	Provider        string           `json:"provider" gorm:"not null;index"`
+	Model           string           `json:"model"`
+	BaselineRunID   *uuid.UUID       `json:"baseline_run_id,omitempty" gorm:"type:uuid"` // Run the answers are compared with; nil when there was none
+	Threshold       float64          `json:"threshold"`                                  // Answers less similar than this to the baseline answer regress
+	Status          ExperimentStatus `json:"status" gorm:"not null;default:'queued';index"`
+	IsGolden        bool             `json:"is_golden" gorm:"index"`
+	QuestionCount   int              `json:"question_count"`
+	ComparedCount   int              `json:"compared_count"` // Answers that had a baseline answer to compare with
+	MeanSimilarity  float64          `json:"mean_similarity"`
+	MinSimilarity   float64          `json:"min_similarity"`
+	RegressionCount int              `json:"regression_count"`
+	Passed          bool             `json:"passed"`
+	Error           string           `json:"error" gorm:"type:text"`
+	CreatedBy       *uuid.UUID       `json:"created_by,omitempty" gorm:"type:uuid"`
+	StartedAt       *time.Time       `json:"started_at"`
+	CompletedAt     *time.Time       `json:"completed_at"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+
+	// Relations
+	Answers []ExperimentAnswer `json:"answers,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+}
+
+type ExperimentStatus string
+
+const (
+	ExperimentQueued    ExperimentStatus = "queued"
+	ExperimentRunning   ExperimentStatus = "running"
+	ExperimentCompleted ExperimentStatus = "completed"
+	ExperimentFailed    ExperimentStatus = "failed"
+)
+
+// ExperimentAnswer is the answer of an experiment run to one golden question, compared with the baseline
+type ExperimentAnswer struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RunID          uuid.UUID `json:"run_id" gorm:"type:uuid;not null;index"`
+	QuestionID     uuid.UUID `json:"question_id" gorm:"type:uuid;not null;index"`
+	Question       string    `json:"question" gorm:"type:text"`
+	Answer         string    `json:"answer" gorm:"type:text"`
+	Provider       string    `json:"provider"` // Differs from the run's when the provider failed over
+	BaselineAnswer string    `json:"baseline_answer" gorm:"type:text"`
+	Similarity     *float64  `json:"similarity"` // Cosine similarity of the answer and baseline embeddings; nil without a baseline answer
+	Regressed      bool      `json:"regressed" gorm:"index"`
+	Error          string    `json:"error" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// UsageRecord is the token usage and estimated cost of one AI provider call made to answer a chat message
+type UsageRecord struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	SessionID        uuid.UUID  `json:"session_id" gorm:"type:uuid;not null;index"`
+	MessageID        *uuid.UUID `json:"message_id" gorm:"type:uuid"` // The assistant message that was generated
+	TopicID          uint       `json:"topic_id" gorm:"index"`       // 0 when the question matched no topic
+	Provider         string     `json:"provider" gorm:"not null"`
+	Model            string     `json:"model"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens"`
+	CostUSD          float64    `json:"cost_usd"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"index"`
+}
+
+// UsageQuota is an admin override of the configured monthly quota of a user,
+// or of the whole organization when UserID is nil. A limit of 0 means unlimited.
+type UsageQuota struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID              *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;uniqueIndex"`
+	MonthlyTokenLimit   int64      `json:"monthly_token_limit"`
+	MonthlyCostLimitUSD float64    `json:"monthly_cost_limit_usd"`
+	Note                string     `json:"note"`
+	UpdatedBy           uuid.UUID  `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// RetrievalEvalResult is the outcome of one labeled pair within an evaluation run
+type RetrievalEvalResult struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RunID           uuid.UUID  `json:"run_id" gorm:"type:uuid;not null;index"`
+	PairID          uuid.UUID  `json:"pair_id" gorm:"type:uuid;not null;index"`
+	Question        string     `json:"question" gorm:"type:text"`
+	ExpectedEntryID uuid.UUID  `json:"expected_entry_id" gorm:"type:uuid"`
+	Rank            int        `json:"rank"` // 1-based rank of the expected entry, 0 when not retrieved
+	ExpectedScore   float64    `json:"expected_score"`
+	TopEntryID      *uuid.UUID `json:"top_entry_id" gorm:"type:uuid"`
+	TopScore        float64    `json:"top_score"`
+	Answer          string     `json:"answer,omitempty" gorm:"type:text"`      // Chat answer, for runs that evaluate answers
+	Faithfulness    *float64   `json:"faithfulness,omitempty"`                 // LLM judge score of the answer, nil when not judged
+	JudgeNotes      string     `json:"judge_notes,omitempty" gorm:"type:text"` // Claims the judge found unsupported, or why judging failed
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// PromptTemplate is a versioned system prompt. At most one version of a name is active; chat
+// providers render the active version, replacing {{variable}} placeholders.
+type PromptTemplate struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string     `json:"name" gorm:"not null;uniqueIndex:idx_prompt_templates_name_version"`
+	Version     int        `json:"version" gorm:"not null;uniqueIndex:idx_prompt_templates_name_version"`
+	Description string     `json:"description"`
+	Content     string     `json:"content" gorm:"type:text;not null"`
+	Variables   string     `json:"variables" gorm:"type:jsonb"` // JSON object of variable names and their default values
+	IsActive    bool       `json:"is_active" gorm:"index"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ImpersonationSession lets an admin act as another user, read-only, until it expires or is revoked.
+// Only a hash of the token is stored.
+type ImpersonationSession struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	AdminID      uuid.UUID  `json:"admin_id" gorm:"type:uuid;not null;index"`
+	TargetUserID uuid.UUID  `json:"target_user_id" gorm:"type:uuid;not null;index"`
+	TokenHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	Reason       string     `json:"reason" gorm:"type:text;not null"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ImpersonationAuditLog records the start and end of an impersonation session and every request made with it
+type ImpersonationAuditLog struct {
+	ID           uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SessionID    uuid.UUID          `json:"session_id" gorm:"type:uuid;not null;index"`
+	AdminID      uuid.UUID          `json:"admin_id" gorm:"type:uuid;not null;index"`
+	TargetUserID uuid.UUID          `json:"target_user_id" gorm:"type:uuid;not null;index"`
+	Event        ImpersonationEvent `json:"event" gorm:"not null"`
+	Method       string             `json:"method,omitempty"`
+	Path         string             `json:"path,omitempty"`
+	Status       int                `json:"status,omitempty"`
+	CreatedAt    time.Time          `json:"created_at" gorm:"index"`
+}
+
+type ImpersonationEvent string
+
+const (
+	ImpersonationStarted ImpersonationEvent = "started"
+	ImpersonationRequest ImpersonationEvent = "request"
+	ImpersonationBlocked ImpersonationEvent = "blocked" // Write attempted while impersonating
+	ImpersonationStopped ImpersonationEvent = "stopped"
+)
+
+// HelpScreen is the contextual help of an application screen, identified by a dotted screen ID such as orders.pending
+type HelpScreen struct {
+	ScreenID        string     `json:"screen_id" gorm:"primaryKey"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description" gorm:"type:text"` // What the screen is for, given to the AI when writing tips
+	Tips            string     `json:"tips" gorm:"type:jsonb"`       // AI-generated quick tips, JSON array
+	TipsGeneratedAt *time.Time `json:"tips_generated_at"`
+	UpdatedBy       *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Entries []HelpScreenEntry `json:"entries,omitempty" gorm:"foreignKey:ScreenID;constraint:OnDelete:CASCADE"`
+}
+
+// HelpScreenEntry is a knowledge entry curated for a help screen
+type HelpScreenEntry struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ScreenID         string    `json:"screen_id" gorm:"not null;uniqueIndex:idx_help_screen_entries_screen_entry"`
+	KnowledgeEntryID uuid.UUID `json:"knowledge_entry_id" gorm:"type:uuid;not null;uniqueIndex:idx_help_screen_entries_screen_entry"`
+	Position         int       `json:"position"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ModerationEvent records content changed or blocked by a chat guardrail. The offending text itself is never stored.
+type ModerationEvent struct {
+	ID        uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	SessionID *uuid.UUID       `json:"session_id" gorm:"type:uuid;index"`
+	Stage     ModerationStage  `json:"stage" gorm:"not null"`
+	Guardrail string           `json:"guardrail" gorm:"not null"` // Guardrail that raised the event, e.g. pii_redaction
+	Category  string           `json:"category" gorm:"not null"`  // e.g. email, card_number, hate
+	Action    ModerationAction `json:"action" gorm:"not null"`
+	Count     int              `json:"count"` // Occurrences found in the message
+	CreatedAt time.Time        `json:"created_at" gorm:"index"`
+}
+
+type ModerationStage string
+
+const (
+	ModerationInput  ModerationStage = "input"  // User message, before it reaches a provider
+	ModerationOutput ModerationStage = "output" // AI response, before it reaches the user
+)
+
+type ModerationAction string
+
+const (
+	ModerationRedacted ModerationAction = "redacted"
+	ModerationBlocked  ModerationAction = "blocked"
+)
+
+// DocumentQuarantine holds an ingested document flagged by the prompt injection scanner.
+// Its content is not used as context until an admin approves it.
+type DocumentQuarantine struct {
+	ID                 uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Source             QuarantineSource `json:"source" gorm:"not null"`
+	UploadedDocumentID *uuid.UUID       `json:"uploaded_document_id" gorm:"type:uuid;index"` // Set for vector store uploads
+	FileName           string           `json:"file_name"`
+	KnowledgeEntryIDs  string           `json:"knowledge_entry_ids" gorm:"type:jsonb"` // Unpublished entries of a knowledge import, JSON array
+	Findings           string           `json:"findings" gorm:"type:jsonb"`            // Suspicious passages, JSON array
+	Status             QuarantineStatus `json:"status" gorm:"not null;default:'pending';index"`
+	ReviewedBy         *uuid.UUID       `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt         *time.Time       `json:"reviewed_at"`
+	ReviewNote         string           `json:"review_note" gorm:"type:text"`
+	CreatedAt          time.Time        `json:"created_at" gorm:"index"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+}
+
+type QuarantineSource string
+
+const (
+	QuarantineUpload          QuarantineSource = "upload"           // File uploaded to the OpenAI vector store
+	QuarantineKnowledgeImport QuarantineSource = "knowledge_import" // Entries parsed from a document before imports were scanned on upload
+)
+
+type QuarantineStatus string
+
+const (
+	QuarantinePending  QuarantineStatus = "pending"
+	QuarantineApproved QuarantineStatus = "approved"
+	QuarantineRejected QuarantineStatus = "rejected"
+)
+
+// ChatRetentionPolicy archives or deletes the chat transcripts of every user, or of the members of a team,
+// once they are older than a number of months
+type ChatRetentionPolicy struct {
+	ID                 uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name               string          `json:"name" gorm:"not null"`
+	Team               string          `json:"team" gorm:"index"` // Empty applies the policy to every user
+	MaxAgeMonths       int             `json:"max_age_months" gorm:"not null"`
+	Action             RetentionAction `json:"action" gorm:"not null"`
+	ExportBeforeDelete bool            `json:"export_before_delete" gorm:"default:false"` // Write JSON transcripts before removing sessions
+	IsActive           bool            `json:"is_active" gorm:"default:true"`
+	LastRunAt          *time.Time      `json:"last_run_at"`
+	CreatedBy          uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+type RetentionAction string
+
+const (
+	RetentionArchive RetentionAction = "archive" // Soft delete: hidden from users, kept in the database
+	RetentionDelete  RetentionAction = "delete"  // Permanently delete, including provider-side threads and tracked chat logs
+)
+
+// RetrievalPreset is a named set of chunking and retrieval settings for a kind of content,
+// e.g. small precise chunks for error code articles and larger ones for process guides
+type RetrievalPreset struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name               string     `json:"name" gorm:"not null;uniqueIndex"`
+	Description        string     `json:"description"`
+	ChunkMaxTokens     int        `json:"chunk_max_tokens"`     // 0 uses the configured default
+	ChunkOverlapTokens int        `json:"chunk_overlap_tokens"` // Tokens shared by consecutive chunks
+	TopK               int        `json:"top_k"`                // Most entries of the category returned by one search; 0 means no cap
+	ScoreThreshold     float64    `json:"score_threshold"`      // Vector hits scoring below this are dropped
+	CreatedBy          *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// CategoryRetrievalPreset assigns a retrieval preset to the knowledge entries of a category
+type CategoryRetrievalPreset struct {
+	Category  string    `json:"category" gorm:"primaryKey"`
+	PresetID  uuid.UUID `json:"preset_id" gorm:"type:uuid;not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebSource is a web page or sitemap crawled into knowledge entries, re-crawled on a schedule to keep them fresh
+type WebSource struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	URL           string          `json:"url" gorm:"not null;uniqueIndex"`
+	IsSitemap     bool            `json:"is_sitemap" gorm:"default:false"` // Crawl the pages the sitemap lists instead of the URL itself
+	Category      string          `json:"category" gorm:"not null"`
+	RecrawlHours  int             `json:"recrawl_hours"`                    // 0 crawls once
+	Pages         string          `json:"pages,omitempty" gorm:"type:text"` // Crawled pages with their content hash and entries, JSON array
+	Status        WebSourceStatus `json:"status" gorm:"not null;default:'pending'"`
+	ErrorMessage  string          `json:"error_message"`
+	LastCrawledAt *time.Time      `json:"last_crawled_at"`
+	CreatedBy     uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type WebSourceStatus string
+
+const (
+	WebSourcePending WebSourceStatus = "pending" // Waiting for its first crawl
+	WebSourceCrawled WebSourceStatus = "crawled" // Entries match the last crawl; pages that failed are listed with their error
+	WebSourceFailed  WebSourceStatus = "failed"  // The URL or sitemap could not be fetched
+)
+
+// ConfluencePage is a Confluence page synced into knowledge entries. The page version tells the sync
+// whether the entries are still current.
+type ConfluencePage struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PageID            string    `json:"page_id" gorm:"not null;uniqueIndex"`
+	SpaceKey          string    `json:"space_key" gorm:"not null;index"`
+	Title             string    `json:"title"`
+	URL               string    `json:"url"`
+	Version           int       `json:"version"`                                        // Page version the entries were created from
+	KnowledgeEntryIDs string    `json:"knowledge_entry_ids,omitempty" gorm:"type:text"` // JSON array
+	SyncError         string    `json:"sync_error,omitempty"`                           // Why the last sync of the page failed; its entries are kept
+	SyncedAt          time.Time `json:"synced_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// NotionPage is a Notion page synced into knowledge entries. Its last edit time tells the sync
+// whether the entries are still current.
+type NotionPage struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PageID            string    `json:"page_id" gorm:"not null;uniqueIndex"`
+	DatabaseID        string    `json:"database_id,omitempty" gorm:"index"` // Empty for pages found by search
+	Title             string    `json:"title"`
+	URL               string    `json:"url"`
+	LastEditedAt      time.Time `json:"last_edited_at"`                                 // Edit time of the page the entries were created from
+	KnowledgeEntryIDs string    `json:"knowledge_entry_ids,omitempty" gorm:"type:text"` // JSON array
+	SyncError         string    `json:"sync_error,omitempty"`                           // Why the last sync of the page failed; its entries are kept
+	SyncedAt          time.Time `json:"synced_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// DriveFile is a Google Drive file synced into the ingestion pipeline. The Drive file ID keeps a file from
+// being ingested twice; its revision tells the sync whether the ingested document is still current.
+type DriveFile struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DriveFileID string     `json:"drive_file_id" gorm:"not null;uniqueIndex"`
+	FolderID    string     `json:"folder_id" gorm:"not null;index"`
+	Name        string     `json:"name"`
+	MimeType    string     `json:"mime_type"`
+	Revision    string     `json:"revision"`                                     // MD5 checksum, or modification time for Google Docs
+	DocumentID  *uuid.UUID `json:"document_id,omitempty" gorm:"type:uuid;index"` // Uploaded document of the current revision
+	SyncError   string     `json:"sync_error,omitempty"`                         // Why the last sync of the file failed; its document is kept
+	SyncedAt    time.Time  `json:"synced_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ScheduledJob runs a registered task on a cron schedule. Due runs are queued on the job queue,
+// so each run happens once however many replicas are up.
+type ScheduledJob struct {
+	ID             uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name           string            `json:"name" gorm:"not null;uniqueIndex"`
+	Task           string            `json:"task" gorm:"not null;index"`
+	CronExpression string            `json:"cron_expression" gorm:"not null"` // Five fields or a descriptor such as @daily, in UTC unless prefixed with CRON_TZ=
+	IsActive       bool              `json:"is_active" gorm:"default:true"`
+	NextRunAt      *time.Time        `json:"next_run_at" gorm:"index"` // Nil while inactive
+	LastRunAt      *time.Time        `json:"last_run_at"`
+	LastStatus     ScheduleRunStatus `json:"last_status,omitempty"`
+	LastError      string            `json:"last_error,omitempty" gorm:"type:text"`
+	LastResult     string            `json:"last_result,omitempty" gorm:"type:text"` // What the task reported as JSON, e.g. a sync report
+	LastDurationMs int64             `json:"last_duration_ms"`
+	LastJobID      *uuid.UUID        `json:"last_job_id,omitempty" gorm:"type:uuid"`
+	CreatedBy      uuid.UUID         `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunQueued    ScheduleRunStatus = "queued"
+	ScheduleRunRunning   ScheduleRunStatus = "running"
+	ScheduleRunSucceeded ScheduleRunStatus = "succeeded"
+	ScheduleRunFailed    ScheduleRunStatus = "failed"
+)
+
+// CategoryReviewPolicy sets how long the published entries of a category may go without an update before
+// they are flagged for review. Categories without a policy use the configured default.
+type CategoryReviewPolicy struct {
+	Category   string    `json:"category" gorm:"primaryKey"`
+	MaxAgeDays int       `json:"max_age_days" gorm:"not null"` // 0 never flags the category's entries
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// KnowledgeEntryRedirect points the ID of an entry merged into another one at the entry that replaced it
+type KnowledgeEntryRedirect struct {
+	FromID    uuid.UUID `json:"from_id" gorm:"type:uuid;primaryKey"`
+	ToID      uuid.UUID `json:"to_id" gorm:"type:uuid;not null;index"`
+	CreatedBy uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatEscalation hands a chat session over from the assistant to a support agent. While it is open or
+// claimed the assistant stops answering the session; once resolved it answers again.
+type ChatEscalation struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SessionID  uuid.UUID        `json:"session_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	Reason     string           `json:"reason" gorm:"type:text"`
+	Status     EscalationStatus `json:"status" gorm:"not null;default:'open';index"`
+	AgentID    *uuid.UUID       `json:"agent_id" gorm:"type:uuid;index"` // Support agent who claimed the escalation
+	ClaimedAt  *time.Time       `json:"claimed_at"`
+	ResolvedAt *time.Time       `json:"resolved_at"`
+	CreatedAt  time.Time        `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+
+	// Relations
+	Session *ChatSession `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+	User    *User        `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Agent   *User        `json:"agent,omitempty" gorm:"foreignKey:AgentID"`
+}
+
+type EscalationStatus string
+
+const (
+	EscalationOpen     EscalationStatus = "open"     // Waiting for an agent
+	EscalationClaimed  EscalationStatus = "claimed"  // An agent is answering the user
+	EscalationResolved EscalationStatus = "resolved" // Handed back to the assistant
+)
+
+// Setting is a runtime setting changed by an admin. It takes precedence over the environment default of
+// the same setting until it is reset.
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey;size:100"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	UpdatedBy uuid.UUID `json:"updated_by" gorm:"type:uuid;not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ManagedAssistant is an OpenAI assistant configured from this system instead of the OpenAI console. Its
+// instructions are rendered from a prompt template and it searches the listed vector stores.
+type ManagedAssistant struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OpenAIAssistantID string    `json:"openai_assistant_id" gorm:"not null;uniqueIndex"`
+	Name              string    `json:"name" gorm:"not null"`
+	Description       string    `json:"description"`
+	Model             string    `json:"model" gorm:"not null"`
+	PromptName        string    `json:"prompt_name" gorm:"not null"`
+	PromptVersion     int       `json:"prompt_version"` // Version rendered into the instructions; 0 is the built-in prompt
+	Instructions      string    `json:"instructions" gorm:"type:text"`
+	VectorStoreIDs    string    `json:"vector_store_ids" gorm:"type:text"` // JSON array
+	CreatedBy         uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	UpdatedBy         uuid.UUID `json:"updated_by" gorm:"type:uuid;not null"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Vector is an embedding stored as a PostgreSQL real[] array, which pgvector casts to its vector type
+type Vector []float32
+
+// Value formats the vector as an array literal, or NULL when it is empty
+func (v Vector) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, value := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// Scan reads an array literal, or a pgvector literal such as [0.1,0.2]
+func (v *Vector) Scan(src interface{}) error {
+	var literal string
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		literal = src
+	case []byte:
+		literal = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into a vector", src)
+	}
+
+	literal = strings.Trim(literal, "{}[]")
+	if literal == "" {
+		*v = Vector{}
+		return nil
+	}
+	fields := strings.Split(literal, ",")
+	vector := make(Vector, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector element %q: %w", field, err)
+		}
+		vector = append(vector, float32(value))
+	}
+	*v = vector
+	return nil
+}
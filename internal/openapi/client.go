@@ -0,0 +1,724 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go names
+var initialisms = map[string]bool{
+	"ai": true, "api": true, "csv": true, "gc": true, "html": true, "http": true, "id": true, "ids": true,
+	"ip": true, "json": true, "pdf": true, "sql": true, "sso": true, "ttl": true, "ui": true, "url": true,
+	"uri": true, "uuid": true,
+}
+
+// GenerateClient generates the operations and types of doc as Go source for package pkg. The transport they
+// build on (Client, request, File, Error) is hand-written in the package; see pkg/client.
+//
+// Every operation with a 2xx response becomes a method of Client. Path parameters and required parameters are
+// arguments, optional parameters are fields of a Params struct, and the body is the last argument. The method
+// returns the data of the response envelope, the raw body for non-JSON responses, or a Result struct when the
+// data differs by status code.
+func GenerateClient(doc *Document, pkg string) ([]byte, error) {
+	g := &clientGenerator{
+		doc:      doc,
+		names:    map[string]string{},
+		types:    map[string]string{},
+		inline:   map[string]*Schema{},
+		declared: map[string]bool{},
+	}
+	for name := range doc.Components.Schemas {
+		goName := exportedName(name[strings.LastIndex(name, ".")+1:])
+		if other, ok := g.names[goName]; ok {
+			return nil, fmt.Errorf("schemas %s and %s would both be named %s", other, name, goName)
+		}
+		g.names[goName] = name
+		g.types[name] = goName
+	}
+
+	var methods bytes.Buffer
+	for _, route := range sortedRoutes(doc) {
+		if err := g.operation(&methods, route.path, route.method, route.operation); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(route.method), route.path, err)
+		}
+	}
+
+	var types bytes.Buffer
+	// Declaring a type can use further types, so keep going until every used type is declared
+	for {
+		pending := g.pendingTypes()
+		if len(pending) == 0 {
+			break
+		}
+		for _, name := range pending {
+			if err := g.declareType(&types, name); err != nil {
+				return nil, fmt.Errorf("type %s: %w", name, err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cmd/openapi from the API description. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	out.WriteString("import (\n\t\"context\"\n")
+	for _, imported := range []string{"net/url", "strconv", "strings"} {
+		if bytes.Contains(methods.Bytes(), []byte(imported[strings.LastIndex(imported, "/")+1:]+".")) {
+			fmt.Fprintf(&out, "\t%q\n", imported)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(methods.Bytes())
+	out.Write(types.Bytes())
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %w", err)
+	}
+	return source, nil
+}
+
+type clientGenerator struct {
+	doc      *Document
+	names    map[string]string  // What each Go type name was given to, to catch collisions
+	types    map[string]string  // Go type names of the component schemas
+	used     []string           // Component schemas the client uses, in order of first use
+	inline   map[string]*Schema // Inline object schemas the client uses, by Go type name
+	declared map[string]bool    // Go types already declared
+}
+
+type route struct {
+	path, method string
+	operation    *Operation
+}
+
+func sortedRoutes(doc *Document) []route {
+	var routes []route
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			routes = append(routes, route{path: path, method: method, operation: operation})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].operation.OperationID < routes[j].operation.OperationID
+	})
+	return routes
+}
+
+// argument is a parameter of a generated method, or a field of its Params struct
+type argument struct {
+	param  *Parameter // Nil for form fields
+	name   string     // Wire name
+	in     string     // path, query, header or formData
+	goName string     // Argument or field name
+	goType string
+	schema *Schema
+}
+
+func (g *clientGenerator) operation(w *bytes.Buffer, path, method string, op *Operation) error {
+	name := exportedName(op.OperationID)
+	result, err := g.result(name, op)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil // Browser redirects like the SSO login have no response for a client
+	}
+
+	base := ""
+	if servers := op.Servers; len(servers) > 0 {
+		base = servers[0].URL
+	} else if len(g.doc.Servers) > 0 {
+		base = g.doc.Servers[0].URL
+	}
+	if base == "/" {
+		base = ""
+	}
+
+	// Path parameters first, in path order, then the other required parameters; the optional ones are Params
+	var positional, optional []*argument
+	taken := map[string]bool{"ctx": true, "params": true, "body": true, "req": true, "c": true}
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, "{") {
+			continue
+		}
+		paramName := strings.Trim(segment, "{}")
+		var param *Parameter
+		for _, candidate := range op.Parameters {
+			if candidate.In == "path" && candidate.Name == paramName {
+				param = candidate
+			}
+		}
+		if param == nil {
+			return fmt.Errorf("path parameter %s is not declared", paramName)
+		}
+		positional = append(positional, g.argument(param.Name, param.In, param.Schema, param, taken))
+	}
+	for _, param := range op.Parameters {
+		switch {
+		case param.In == "path":
+		case param.Required:
+			positional = append(positional, g.argument(param.Name, param.In, param.Schema, param, taken))
+		default:
+			optional = append(optional, g.argument(param.Name, param.In, param.Schema, param, nil))
+		}
+	}
+
+	var bodyType string
+	if op.RequestBody != nil {
+		if media := op.RequestBody.Content["application/json"]; media != nil {
+			bodyType = g.goType(media.Schema, name+"Request", true)
+		} else {
+			form := op.RequestBody.Content["multipart/form-data"]
+			if form == nil {
+				form = op.RequestBody.Content["application/x-www-form-urlencoded"]
+			}
+			if form == nil {
+				return fmt.Errorf("unsupported request body")
+			}
+			required := map[string]bool{}
+			for _, field := range form.Schema.Required {
+				required[field] = true
+			}
+			for _, field := range sortedKeys(form.Schema.Properties) {
+				schema := form.Schema.Properties[field]
+				if required[field] {
+					positional = append(positional, g.argument(field, "formData", schema, nil, taken))
+				} else {
+					optional = append(optional, g.argument(field, "formData", schema, nil, nil))
+				}
+			}
+		}
+	}
+
+	fullPath := base + path
+	fmt.Fprintf(w, "// %s calls %s %s", name, strings.ToUpper(method), fullPath)
+	if op.Summary != "" {
+		fmt.Fprintf(w, ": %s", lowerFirst(strings.TrimSuffix(op.Summary, ".")))
+	}
+	w.WriteString(".\n")
+	if op.Description != "" {
+		w.WriteString("//\n")
+		writeComment(w, "", op.Description)
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, arg := range positional {
+		args = append(args, arg.goName+" "+arg.goType)
+	}
+	if len(optional) > 0 {
+		args = append(args, "params *"+name+"Params")
+	}
+	if bodyType != "" {
+		args = append(args, "body "+bodyType)
+	}
+	fmt.Fprintf(w, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), result.signature)
+	fmt.Fprintf(w, "\treq := &request{method: %q, path: %s}\n", strings.ToUpper(method), pathExpression(fullPath, positional))
+	for _, arg := range positional {
+		if arg.in != "path" {
+			writeSetter(w, "\t", arg, arg.goName, true)
+		}
+	}
+	if len(optional) > 0 {
+		w.WriteString("\tif params != nil {\n")
+		for _, arg := range optional {
+			writeSetter(w, "\t\t", arg, "params."+arg.goName, false)
+		}
+		w.WriteString("\t}\n")
+	}
+	if bodyType != "" {
+		w.WriteString("\treq.body = body\n")
+	}
+	w.WriteString(result.body)
+	w.WriteString("}\n\n")
+
+	if len(optional) > 0 {
+		fmt.Fprintf(w, "// %sParams are the optional parameters of %s\n", name, name)
+		fmt.Fprintf(w, "type %sParams struct {\n", name)
+		for _, arg := range optional {
+			description := ""
+			if arg.param != nil {
+				description = arg.param.Description
+			}
+			if description == "" {
+				description = arg.schema.Description
+			}
+			if arg.schema.Default != nil {
+				description = strings.TrimSuffix(description, ".") + fmt.Sprintf(". Defaults to %v.", arg.schema.Default)
+				description = strings.TrimPrefix(description, ". ")
+			}
+			writeComment(w, "\t", description)
+			fmt.Fprintf(w, "\t%s %s\n", arg.goName, arg.goType)
+		}
+		w.WriteString("}\n\n")
+	}
+	return nil
+}
+
+func (g *clientGenerator) argument(name, in string, schema *Schema, param *Parameter, taken map[string]bool) *argument {
+	arg := &argument{param: param, name: name, in: in, schema: schema}
+	if taken == nil {
+		arg.goName = exportedName(name)
+	} else {
+		arg.goName = unexportedName(name)
+		if isGoKeyword(arg.goName) || taken[arg.goName] {
+			arg.goName += "Param"
+		}
+		taken[arg.goName] = true
+	}
+
+	switch {
+	case schema.Type == "string" && schema.Format == "binary":
+		arg.goType = "*File"
+	case schema.Type == "boolean" && taken == nil:
+		arg.goType = "*bool" // Optional booleans may default to true, so false must be sendable
+	default:
+		arg.goType = g.goType(schema, "", false)
+	}
+	return arg
+}
+
+// writeSetter writes the code that adds an argument to the request; optional arguments are left out when unset
+func writeSetter(w *bytes.Buffer, indent string, arg *argument, value string, required bool) {
+	setter := map[string]string{"query": "setQuery", "header": "setHeader", "formData": "addFormField"}[arg.in]
+	if arg.goType == "*File" {
+		if required {
+			fmt.Fprintf(w, "%sreq.addFormFile(%q, %s)\n", indent, arg.name, value)
+		} else {
+			fmt.Fprintf(w, "%sif %s != nil {\n%s\treq.addFormFile(%q, %s)\n%s}\n", indent, value, indent, arg.name, value, indent)
+		}
+		return
+	}
+
+	var set, formatted string
+	switch arg.goType {
+	case "*bool":
+		set, formatted = value+" != nil", "strconv.FormatBool(*"+value+")"
+	case "bool":
+		set, formatted = value, "strconv.FormatBool("+value+")"
+	case "int":
+		set, formatted = value+" != 0", "strconv.Itoa("+value+")"
+	case "float64":
+		set, formatted = value+" != 0", "strconv.FormatFloat("+value+", 'f', -1, 64)"
+	case "[]string":
+		set, formatted = "len("+value+") > 0", `strings.Join(`+value+`, ",")`
+	default:
+		set, formatted = value+` != ""`, value
+		if arg.goType != "string" {
+			formatted = "string(" + value + ")"
+		}
+	}
+	if required {
+		fmt.Fprintf(w, "%sreq.%s(%q, %s)\n", indent, setter, arg.name, formatted)
+		return
+	}
+	fmt.Fprintf(w, "%sif %s {\n%s\treq.%s(%q, %s)\n%s}\n", indent, set, indent, setter, arg.name, formatted, indent)
+}
+
+// pathExpression builds the Go expression of a request path, escaping the path parameters
+func pathExpression(path string, args []*argument) string {
+	byName := map[string]*argument{}
+	for _, arg := range args {
+		if arg.in == "path" {
+			byName[arg.name] = arg
+		}
+	}
+	var parts []string
+	literal := ""
+	for _, segment := range strings.SplitAfter(path, "/") {
+		trimmed := strings.TrimSuffix(segment, "/")
+		if arg, ok := byName[strings.Trim(trimmed, "{}")]; ok && strings.HasPrefix(trimmed, "{") {
+			if literal != "" {
+				parts = append(parts, strconv.Quote(literal))
+			}
+			value := arg.goName
+			if arg.goType == "int" {
+				value = "strconv.Itoa(" + value + ")"
+			}
+			parts = append(parts, "url.PathEscape("+value+")")
+			literal = strings.TrimPrefix(segment, trimmed)
+			continue
+		}
+		literal += segment
+	}
+	if literal != "" {
+		parts = append(parts, strconv.Quote(literal))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// methodResult is what a generated method returns and the code that returns it
+type methodResult struct {
+	signature string
+	body      string
+}
+
+// result works out what the method of an operation returns from its 2xx responses. It returns nil for
+// operations without one.
+func (g *clientGenerator) result(name string, op *Operation) (*methodResult, error) {
+	var codes []string
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	// Each status code carries the data of the envelope, or a raw body
+	type outcome struct {
+		code     string
+		data     *Schema
+		withData bool
+		withMeta bool
+	}
+	var outcomes []outcome
+	for _, code := range codes {
+		response := op.Responses[code]
+		if len(response.Content) == 0 {
+			outcomes = append(outcomes, outcome{code: code})
+			continue
+		}
+		for contentType := range response.Content {
+			if !isJSON(contentType) {
+				return &methodResult{
+					signature: "([]byte, error)",
+					body:      "\treturn c.raw(ctx, req)\n",
+				}, nil
+			}
+		}
+		data, withData, withMeta, err := g.envelopeData(response.Content["application/json"].Schema)
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, outcome{code: code, data: data, withData: withData, withMeta: withMeta})
+	}
+
+	distinct := map[string]bool{}
+	for _, outcome := range outcomes {
+		if outcome.withData {
+			key, _ := json.Marshal(outcome.data)
+			distinct[string(key)] = true
+		}
+	}
+	switch len(distinct) {
+	case 0:
+		return &methodResult{signature: "error", body: "\treturn c.call(ctx, req, nil, nil)\n"}, nil
+	case 1:
+		var data outcome
+		for _, outcome := range outcomes {
+			if outcome.withData {
+				data = outcome
+				break
+			}
+		}
+		dataType := g.goType(data.data, name+"Result", true)
+		if data.withMeta {
+			metaType := g.goType(g.envelopeMeta(), "", true)
+			return &methodResult{
+				signature: fmt.Sprintf("(%s, %s, error)", dataType, metaType),
+				body: fmt.Sprintf("\tvar data %s\n\tvar meta %s\n\tif err := c.call(ctx, req, &data, &meta); err != nil {\n\t\treturn %s, nil, err\n\t}\n\treturn data, meta, nil\n",
+					dataType, metaType, zeroValue(dataType)),
+			}, nil
+		}
+		return &methodResult{
+			signature: fmt.Sprintf("(%s, error)", dataType),
+			body: fmt.Sprintf("\tvar data %s\n\tif err := c.call(ctx, req, &data, nil); err != nil {\n\t\treturn %s, err\n\t}\n\treturn data, nil\n",
+				dataType, zeroValue(dataType)),
+		}, nil
+	}
+
+	// The data differs by status code, e.g. a dry-run report or a queued job
+	resultName := name + "Result"
+	fields := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	var body strings.Builder
+	body.WriteString("\tstatus, env, err := c.send(ctx, req)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&body, "\tresult := &%s{}\n\tswitch status {\n", resultName)
+	for _, outcome := range outcomes {
+		if !outcome.withData {
+			continue
+		}
+		code, _ := strconv.Atoi(outcome.code)
+		field := strings.ReplaceAll(http.StatusText(code), " ", "")
+		fields.Properties[field] = outcome.data
+		fmt.Fprintf(&body, "\tcase %d:\n\t\terr = env.decode(&result.%s)\n", code, exportedName(field))
+	}
+	body.WriteString("\t}\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn result, nil\n")
+	g.addInline(resultName, fields)
+	return &methodResult{signature: fmt.Sprintf("(*%s, error)", resultName), body: body.String()}, nil
+}
+
+// envelopeData unwraps the data of a response envelope, described by swag as
+// allOf: [{$ref: envelope}, {properties: {data: ..., meta: ...}}]
+func (g *clientGenerator) envelopeData(schema *Schema) (data *Schema, withData, withMeta bool, err error) {
+	if g.isEnvelope(schema.RefName()) {
+		return nil, false, false, nil
+	}
+	if len(schema.AllOf) == 2 && g.isEnvelope(schema.AllOf[0].RefName()) {
+		properties := schema.AllOf[1].Properties
+		data, withData = properties["data"]
+		_, withMeta = properties["meta"]
+		return data, withData, withMeta, nil
+	}
+	return nil, false, false, fmt.Errorf("response is not a response envelope")
+}
+
+func (g *clientGenerator) isEnvelope(name string) bool {
+	schema := g.doc.Components.Schemas[name]
+	if schema == nil {
+		return false
+	}
+	_, success := schema.Properties["success"]
+	_, data := schema.Properties["data"]
+	return success && data
+}
+
+// envelopeMeta returns the schema of the pagination of the response envelope
+func (g *clientGenerator) envelopeMeta() *Schema {
+	for name := range g.doc.Components.Schemas {
+		if g.isEnvelope(name) {
+			if meta := g.doc.Components.Schemas[name].Properties["meta"]; meta != nil {
+				return meta
+			}
+		}
+	}
+	return &Schema{}
+}
+
+// goType returns the Go type of a schema. Inline objects are declared as types named context; structs are
+// pointers unless they are elements of slices or maps.
+func (g *clientGenerator) goType(schema *Schema, context string, pointer bool) string {
+	if schema == nil {
+		return "interface{}"
+	}
+	if len(schema.AllOf) == 1 {
+		return g.goType(schema.AllOf[0], context, pointer)
+	}
+	if name := schema.RefName(); name != "" {
+		goName, ok := g.types[name]
+		if !ok {
+			return "interface{}"
+		}
+		g.use(name)
+		if pointer && isStruct(g.doc.Components.Schemas[name]) {
+			return "*" + goName
+		}
+		return goName
+	}
+
+	switch schema.Type {
+	case "string":
+		if schema.Format == "binary" {
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if schema.Format == "int64" {
+			return "int64"
+		}
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(schema.Items, context, false)
+	case "object":
+		if isStruct(schema) && context != "" {
+			g.addInline(context, schema)
+			if pointer {
+				return "*" + context
+			}
+			return context
+		}
+		if schema.AdditionalProperties != nil && (schema.AdditionalProperties.Type != "" || schema.AdditionalProperties.Ref != "") {
+			return "map[string]" + g.goType(schema.AdditionalProperties, context, false)
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+func isStruct(schema *Schema) bool {
+	return schema != nil && schema.Type == "object" && len(schema.Properties) > 0
+}
+
+func (g *clientGenerator) use(name string) {
+	for _, used := range g.used {
+		if used == name {
+			return
+		}
+	}
+	g.used = append(g.used, name)
+}
+
+func (g *clientGenerator) addInline(goName string, schema *Schema) {
+	g.inline[goName] = schema
+}
+
+// pendingTypes lists the Go types used but not declared yet, sorted
+func (g *clientGenerator) pendingTypes() []string {
+	var pending []string
+	for _, name := range g.used {
+		if !g.declared[g.types[name]] {
+			pending = append(pending, g.types[name])
+		}
+	}
+	for name := range g.inline {
+		if !g.declared[name] {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+func (g *clientGenerator) declareType(w *bytes.Buffer, goName string) error {
+	g.declared[goName] = true
+	schema, inline := g.inline[goName]
+	if inline {
+		if other, ok := g.names[goName]; ok {
+			return fmt.Errorf("inline type collides with schema %s", other)
+		}
+	} else {
+		schema = g.doc.Components.Schemas[g.names[goName]]
+	}
+
+	writeComment(w, "", schema.Description)
+	if !isStruct(schema) {
+		underlying := g.goType(&Schema{Type: schema.Type, Format: schema.Format, Items: schema.Items, AdditionalProperties: schema.AdditionalProperties}, "", false)
+		fmt.Fprintf(w, "type %s %s\n\n", goName, underlying)
+		return g.declareEnum(w, goName, schema)
+	}
+
+	fmt.Fprintf(w, "type %s struct {\n", goName)
+	fieldNames := map[string]string{}
+	for _, property := range sortedKeys(schema.Properties) {
+		field := schema.Properties[property]
+		fieldName := exportedName(property)
+		if other, ok := fieldNames[fieldName]; ok {
+			return fmt.Errorf("properties %s and %s would both be named %s", other, property, fieldName)
+		}
+		fieldNames[fieldName] = property
+
+		fieldType := g.goType(field, goName+fieldName, true)
+		if field.Nullable && !strings.HasPrefix(fieldType, "*") && !strings.HasPrefix(fieldType, "[]") && !strings.HasPrefix(fieldType, "map[") && fieldType != "interface{}" {
+			fieldType = "*" + fieldType
+		}
+		writeComment(w, "\t", field.Description)
+		fmt.Fprintf(w, "\t%s %s `json:\"%s,omitempty\"`\n", fieldName, fieldType, property)
+	}
+	w.WriteString("}\n\n")
+	return nil
+}
+
+// declareEnum declares the values of a string enum as constants named like the server's
+func (g *clientGenerator) declareEnum(w *bytes.Buffer, goName string, schema *Schema) error {
+	if len(schema.Enum) == 0 || schema.Type != "string" {
+		return nil
+	}
+	w.WriteString("const (\n")
+	for i, value := range schema.Enum {
+		constName := goName + exportedName(fmt.Sprint(value))
+		if i < len(schema.EnumVarNames) {
+			constName = schema.EnumVarNames[i]
+		}
+		if other, ok := g.names[constName]; ok {
+			return fmt.Errorf("value %v collides with schema %s", value, other)
+		}
+		g.names[constName] = goName
+		if comment := schema.EnumComments[constName]; comment != "" {
+			writeComment(w, "\t", comment)
+		}
+		fmt.Fprintf(w, "\t%s %s = %q\n", constName, goName, fmt.Sprint(value))
+	}
+	w.WriteString(")\n\n")
+	return nil
+}
+
+func writeComment(w *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "%s// %s\n", indent, strings.TrimSpace(line))
+	}
+}
+
+// exportedName turns a wire name like knowledge_entry_id into a Go name like KnowledgeEntryID
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if initialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	goName := b.String()
+	if goName == "" || unicode.IsDigit([]rune(goName)[0]) {
+		goName = "X" + goName
+	}
+	return goName
+}
+
+// unexportedName turns a wire name into a Go name for an argument, like knowledgeEntryID or id
+func unexportedName(name string) string {
+	runes := []rune(exportedName(name))
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		upper-- // Keep the first letter of the next word, as in URLPath
+	}
+	for i := 0; i < upper || i == 0; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+func zeroValue(goType string) string {
+	switch {
+	case strings.HasPrefix(goType, "*"), strings.HasPrefix(goType, "[]"), strings.HasPrefix(goType, "map["), goType == "interface{}":
+		return "nil"
+	case goType == "string":
+		return `""`
+	case goType == "bool":
+		return "false"
+	case goType == "int", goType == "int64", goType == "float64":
+		return "0"
+	}
+	return goType + "{}"
+}
+
+func isGoKeyword(name string) bool {
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+		"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct",
+		"switch", "type", "var":
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
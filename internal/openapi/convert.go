@@ -0,0 +1,272 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// fromSwagger converts the Swagger 2 description swag builds into an OpenAPI 3 document
+func fromSwagger(sw *spec.Swagger) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+	}
+	if sw.Info != nil {
+		doc.Info = Info{Title: sw.Info.Title, Description: sw.Info.Description, Version: sw.Info.Version}
+	}
+	if sw.BasePath != "" {
+		doc.Servers = []Server{{URL: sw.BasePath}}
+	}
+	for _, tag := range sw.Tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag.Name, Description: tag.Description})
+	}
+	for name, definition := range sw.Definitions {
+		definition := definition
+		doc.Components.Schemas[name] = convertSchema(&definition)
+	}
+
+	if sw.Paths == nil {
+		return doc, nil
+	}
+	for path, item := range sw.Paths.Paths {
+		operations := map[string]*spec.Operation{
+			http.MethodGet:    item.Get,
+			http.MethodPut:    item.Put,
+			http.MethodPost:   item.Post,
+			http.MethodDelete: item.Delete,
+			http.MethodPatch:  item.Patch,
+			http.MethodHead:   item.Head,
+		}
+		for method, operation := range operations {
+			if operation == nil {
+				continue
+			}
+			converted, err := convertOperation(operation, sw)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = map[string]*Operation{}
+			}
+			doc.Paths[path][strings.ToLower(method)] = converted
+		}
+	}
+	return doc, nil
+}
+
+func convertOperation(operation *spec.Operation, sw *spec.Swagger) (*Operation, error) {
+	converted := &Operation{
+		OperationID: operation.ID,
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Tags:        operation.Tags,
+		Deprecated:  operation.Deprecated,
+		Responses:   map[string]*Response{},
+	}
+	if servers, ok := operation.Extensions["x-servers"]; ok {
+		// Operations served outside of the base path, like the probes, carry their own servers
+		raw, err := json.Marshal(servers)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &converted.Servers); err != nil {
+			return nil, fmt.Errorf("invalid @x-servers: %w", err)
+		}
+	}
+
+	consumes := firstNonEmpty(operation.Consumes, sw.Consumes, []string{"application/json"})
+	var form *Schema
+	for i := range operation.Parameters {
+		param := &operation.Parameters[i]
+		switch param.In {
+		case "body":
+			body := &RequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     map[string]*MediaType{},
+			}
+			schema := convertSchema(param.Schema)
+			for _, contentType := range consumes {
+				body.Content[contentType] = &MediaType{Schema: schema}
+			}
+			converted.RequestBody = body
+		case "formData":
+			if form == nil {
+				form = &Schema{Type: "object", Properties: map[string]*Schema{}}
+			}
+			form.Properties[param.Name] = simpleSchema(&param.SimpleSchema, &param.CommonValidations, param.Description)
+			if param.Required {
+				form.Required = append(form.Required, param.Name)
+			}
+		default:
+			converted.Parameters = append(converted.Parameters, &Parameter{
+				Name:        param.Name,
+				In:          param.In,
+				Description: param.Description,
+				Required:    param.Required || param.In == "path",
+				Schema:      simpleSchema(&param.SimpleSchema, &param.CommonValidations, ""),
+			})
+		}
+	}
+	if form != nil {
+		if converted.RequestBody != nil {
+			return nil, fmt.Errorf("both body and formData parameters")
+		}
+		contentType := "application/x-www-form-urlencoded"
+		for _, property := range form.Properties {
+			if property.Format == "binary" {
+				contentType = "multipart/form-data"
+			}
+		}
+		converted.RequestBody = &RequestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]*MediaType{contentType: {Schema: form}},
+		}
+	}
+
+	if operation.Responses == nil {
+		return nil, fmt.Errorf("no responses")
+	}
+	produces := firstNonEmpty(operation.Produces, sw.Produces, []string{"application/json"})
+	for code, response := range operation.Responses.StatusCodeResponses {
+		converted.Responses[strconv.Itoa(code)] = convertResponse(code, &response, produces)
+	}
+	if operation.Responses.Default != nil {
+		converted.Responses["default"] = convertResponse(0, operation.Responses.Default, produces)
+	}
+	return converted, nil
+}
+
+// convertResponse gives the schema of a response to each content type it is produced in. Error responses and
+// JSON content types carry the JSON schema; other content types, like the images of the attachment download,
+// are binary.
+func convertResponse(code int, response *spec.Response, produces []string) *Response {
+	converted := &Response{Description: response.Description}
+	if converted.Description == "" {
+		converted.Description = http.StatusText(code)
+	}
+	if response.Schema == nil {
+		return converted
+	}
+
+	schema := convertSchema(response.Schema)
+	converted.Content = map[string]*MediaType{}
+	if code >= 400 {
+		converted.Content["application/json"] = &MediaType{Schema: schema}
+		return converted
+	}
+	for _, contentType := range produces {
+		if isJSON(contentType) && schema.Format != "binary" {
+			converted.Content[contentType] = &MediaType{Schema: schema}
+		} else {
+			converted.Content[contentType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		}
+	}
+	return converted
+}
+
+func convertSchema(schema *spec.Schema) *Schema {
+	if schema == nil {
+		return nil
+	}
+	converted := &Schema{
+		Ref:         convertRef(schema.Ref.String()),
+		Format:      schema.Format,
+		Description: schema.Description,
+		Nullable:    schema.Nullable,
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Default:     schema.Default,
+		Example:     schema.Example,
+		Minimum:     schema.Minimum,
+		Maximum:     schema.Maximum,
+	}
+	if len(schema.Type) > 0 {
+		converted.Type = schema.Type[0]
+	}
+	if converted.Type == "file" {
+		converted.Type, converted.Format = "string", "binary"
+	}
+	if nullable, ok := schema.Extensions.GetBool("x-nullable"); ok {
+		converted.Nullable = nullable
+	}
+	if names, ok := schema.Extensions.GetStringSlice("x-enum-varnames"); ok {
+		converted.EnumVarNames = names
+	}
+	if comments, ok := schema.Extensions["x-enum-comments"].(map[string]interface{}); ok {
+		converted.EnumComments = map[string]string{}
+		for name, comment := range comments {
+			converted.EnumComments[name] = fmt.Sprint(comment)
+		}
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			property := property
+			converted.Properties[name] = convertSchema(&property)
+		}
+	}
+	if schema.Items != nil {
+		converted.Items = convertSchema(schema.Items.Schema)
+	}
+	if additional := schema.AdditionalProperties; additional != nil {
+		if additional.Schema != nil {
+			converted.AdditionalProperties = convertSchema(additional.Schema)
+		} else if additional.Allows {
+			converted.AdditionalProperties = &Schema{}
+		}
+	}
+	for i := range schema.AllOf {
+		converted.AllOf = append(converted.AllOf, convertSchema(&schema.AllOf[i]))
+	}
+	return converted
+}
+
+// simpleSchema converts the type of a non-body parameter
+func simpleSchema(simple *spec.SimpleSchema, validations *spec.CommonValidations, description string) *Schema {
+	schema := &Schema{
+		Type:        simple.Type,
+		Format:      simple.Format,
+		Description: description,
+		Default:     simple.Default,
+		Example:     simple.Example,
+		Enum:        validations.Enum,
+		Minimum:     validations.Minimum,
+		Maximum:     validations.Maximum,
+	}
+	if schema.Type == "file" {
+		schema.Type, schema.Format = "string", "binary"
+	}
+	if simple.Items != nil {
+		schema.Items = simpleSchema(&simple.Items.SimpleSchema, &simple.Items.CommonValidations, "")
+	}
+	return schema
+}
+
+func convertRef(ref string) string {
+	if name, ok := strings.CutPrefix(ref, "#/definitions/"); ok {
+		return schemaRefPrefix + name
+	}
+	return ref
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+func firstNonEmpty(lists ...[]string) []string {
+	for _, list := range lists {
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
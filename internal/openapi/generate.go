@@ -0,0 +1,226 @@
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/swaggo/swag"
+)
+
+// routerAnnotation matches the @Router annotation of a handler
+var routerAnnotation = regexp.MustCompile(`^@Router\s+(\S+)\s+\[(\w+)\]`)
+
+// Generate builds the API description from the swag annotations of the Go code in root, the module root.
+// searchDirs are where the handlers live, relative to root; mainFile, in the first of them, holds the general
+// API info (@title, @BasePath). Operations without an @ID are named after their handler function, and fields
+// the handlers decode into pointers are marked nullable.
+func Generate(root string, searchDirs []string, mainFile string) (*Document, error) {
+	code, err := scanCode(root)
+	if err != nil {
+		return nil, err
+	}
+
+	swagParser := swag.New(swag.ParseUsingGoList(true), swag.SetDebugger(log.New(io.Discard, "", 0)))
+	swagParser.ParseDependency = swag.ParseModels
+	dirs := make([]string, len(searchDirs))
+	for i, dir := range searchDirs {
+		dirs[i] = filepath.Join(root, dir)
+	}
+	if err := swagParser.ParseAPIMultiSearchDir(dirs, mainFile, 100); err != nil {
+		return nil, fmt.Errorf("failed to parse the API annotations: %w", err)
+	}
+
+	doc, err := fromSwagger(swagParser.GetSwagger())
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]string{}
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			route := strings.ToUpper(method) + " " + path
+			if operation.OperationID == "" {
+				handler, ok := code.handlers[route]
+				if !ok {
+					return nil, fmt.Errorf("%s: no handler found for the annotation", route)
+				}
+				operation.OperationID = lowerFirst(handler)
+			}
+			if other, ok := seen[operation.OperationID]; ok {
+				return nil, fmt.Errorf("%s and %s are both named %s; name one of them with @ID", other, route, operation.OperationID)
+			}
+			seen[operation.OperationID] = route
+		}
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		for property := range code.pointerFields(name) {
+			if field := schema.Properties[property]; field != nil && field.Ref == "" && len(field.AllOf) == 0 {
+				field.Nullable = true
+			}
+		}
+	}
+	return doc, nil
+}
+
+// codeIndex is what Generate needs to know about the Go code beyond the annotations
+type codeIndex struct {
+	handlers map[string]string // Handler function by "METHOD /path" of its @Router annotations
+	structs  map[string]*structFields
+}
+
+// structFields are the JSON fields of a struct type, named pkg.Type like swag names its schemas
+type structFields struct {
+	pointers map[string]bool // Fields by JSON name; true when the field is a pointer
+	embedded []string        // Embedded struct types
+}
+
+func scanCode(root string) (*codeIndex, error) {
+	index := &codeIndex{handlers: map[string]string{}, structs: map[string]*structFields{}}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "vendor" || entry.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		index.addFile(file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan the code: %w", err)
+	}
+	return index, nil
+}
+
+func (index *codeIndex) addFile(file *ast.File) {
+	pkg := file.Name.Name
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Doc == nil {
+				continue
+			}
+			for _, comment := range decl.Doc.List {
+				text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+				if match := routerAnnotation.FindStringSubmatch(text); match != nil {
+					index.handlers[strings.ToUpper(match[2])+" "+match[1]] = decl.Name.Name
+				}
+			}
+		case *ast.GenDecl:
+			for _, s := range decl.Specs {
+				typeSpec, ok := s.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					index.structs[pkg+"."+typeSpec.Name.Name] = newStructFields(pkg, structType)
+				}
+			}
+		}
+	}
+}
+
+func newStructFields(pkg string, structType *ast.StructType) *structFields {
+	fields := &structFields{pointers: map[string]bool{}}
+	for _, field := range structType.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		}
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		_, pointer := field.Type.(*ast.StarExpr)
+
+		if len(field.Names) == 0 {
+			if jsonName == "" {
+				fields.embedded = append(fields.embedded, qualifiedTypeName(pkg, field.Type))
+				continue
+			}
+			fields.pointers[jsonName] = pointer
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if jsonName != "" {
+				fields.pointers[jsonName] = pointer
+			} else {
+				fields.pointers[lowerFirst(name.Name)] = pointer
+			}
+		}
+	}
+	return fields
+}
+
+// pointerFields returns the JSON names of the pointer fields of a struct type, including embedded ones
+func (index *codeIndex) pointerFields(name string) map[string]bool {
+	pointers := map[string]bool{}
+	var collect func(name string, depth int)
+	collect = func(name string, depth int) {
+		fields, ok := index.structs[name]
+		if !ok || depth > 10 {
+			return
+		}
+		for _, embedded := range fields.embedded {
+			collect(embedded, depth+1)
+		}
+		for field, pointer := range fields.pointers {
+			pointers[field] = pointer
+		}
+	}
+	collect(name, 0)
+	for field, pointer := range pointers {
+		if !pointer {
+			delete(pointers, field)
+		}
+	}
+	return pointers
+}
+
+func qualifiedTypeName(pkg string, expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return qualifiedTypeName(pkg, expr.X)
+	case *ast.Ident:
+		return pkg + "." + expr.Name
+	case *ast.SelectorExpr:
+		if ident, ok := expr.X.(*ast.Ident); ok {
+			return ident.Name + "." + expr.Sel.Name
+		}
+	}
+	return ""
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	routeParam = regexp.MustCompile(`:(\w+)`)  // Parameters of Fiber paths
+	pathParam  = regexp.MustCompile(`\{\w+\}`) // Parameters of OpenAPI paths
+)
+
+// Route is a route the server serves, with a Fiber path like /api/v1/knowledge/:id
+type Route struct {
+	Method string
+	Path   string
+}
+
+// CheckRoutes reports the routes the document does not describe and the operations it describes that are not
+// served, so that the description cannot drift from the router. HEAD routes and the exempt paths are skipped.
+func CheckRoutes(doc *Document, routes []Route, exempt ...string) error {
+	described := map[string]bool{}
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			servers := operation.Servers
+			if len(servers) == 0 {
+				servers = doc.Servers
+			}
+			base := ""
+			if len(servers) > 0 {
+				base = strings.TrimSuffix(servers[0].URL, "/")
+			}
+			described[strings.ToUpper(method)+" "+normalizePath(base+path)] = true
+		}
+	}
+
+	skip := map[string]bool{}
+	for _, path := range exempt {
+		skip[path] = true
+	}
+	served := map[string]bool{}
+	var problems []string
+	for _, route := range routes {
+		if route.Method == "HEAD" || skip[route.Path] {
+			continue
+		}
+		key := route.Method + " " + normalizePath(routeParam.ReplaceAllString(route.Path, "{$1}"))
+		if served[key] {
+			continue
+		}
+		served[key] = true
+		if !described[key] {
+			problems = append(problems, "not described: "+key)
+		}
+	}
+	for key := range described {
+		if !served[key] {
+			problems = append(problems, "not served: "+key)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("the API description does not match the routes:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// normalizePath drops the trailing slash of group roots like /api/v1/templates/ and the names of parameters,
+// which may differ between the router and the annotations
+func normalizePath(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return pathParam.ReplaceAllString(path, "{}")
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Connectors reported by the system status
+const (
+	ConnectorConfluence  = "confluence"
+	ConnectorNotion      = "notion"
+	ConnectorGoogleDrive = "google_drive"
+	ConnectorWebSources  = "web_sources"
+)
+
+// SystemStatus is the ops dashboard view of the whole system. A section that could not be loaded carries
+// its error instead of failing the report.
+type SystemStatus struct {
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Ready        bool              `json:"ready"` // Database, Qdrant and at least one AI provider reachable
+	Dependencies DependencyStatus  `json:"dependencies"`
+	VectorDB     VectorDBStatus    `json:"vector_db"`
+	AIProviders  []ProviderHealth  `json:"ai_providers"`
+	Jobs         JobsSummary       `json:"jobs"`
+	Documents    DocumentBacklog   `json:"documents"`
+	Connectors   []ConnectorStatus `json:"connectors"`
+}
+
+// VectorDBStatus describes the knowledge collection in Qdrant, or the pgvector table
+type VectorDBStatus struct {
+	*QdrantCollectionInfo
+	Error string `json:"error,omitempty"`
+}
+
+// JobsSummary totals the job queues
+type JobsSummary struct {
+	Pending             int64    `json:"pending"`
+	Running             int64    `json:"running"`
+	Retrying            int64    `json:"retrying"`
+	Dead                int64    `json:"dead"`
+	Due                 int64    `json:"due"`
+	OldestDueAgeSeconds float64  `json:"oldest_due_age_seconds"`
+	PausedQueues        []string `json:"paused_queues"`
+	AliveWorkers        int      `json:"alive_workers"`
+	Error               string   `json:"error,omitempty"`
+}
+
+// DocumentBacklog counts the uploaded documents that are still being processed or need attention
+type DocumentBacklog struct {
+	Processing      int64                           `json:"processing"` // Uploaded and not yet indexed or imported
+	Failed          int64                           `json:"failed"`
+	Quarantined     int64                           `json:"quarantined"`
+	OldestPendingAt *time.Time                      `json:"oldest_pending_at,omitempty"`
+	ByStatus        map[models.DocumentStatus]int64 `json:"by_status"`
+	Error           string                          `json:"error,omitempty"`
+}
+
+// ConnectorStatus is the last sync of a content connector
+type ConnectorStatus struct {
+	Name         string     `json:"name"`
+	Enabled      bool       `json:"enabled"`
+	Synced       int64      `json:"synced"` // Pages, files or web sources synced
+	Failed       int64      `json:"failed"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// processingDocumentStatuses are the statuses of documents the pipeline has not finished with
+var processingDocumentStatuses = []models.DocumentStatus{
+	models.DocumentUploaded,
+	models.DocumentSentToOpenAI,
+	models.DocumentAddedToVector,
+}
+
+// AdminStatusService aggregates the health of the dependencies, queues, document pipeline and connectors
+// into a single report for the ops dashboard
+type AdminStatusService struct {
+	db            *gorm.DB
+	dependencies  *DependencyMonitor
+	vectorService VectorStore
+	unifiedAI     *UnifiedAIService
+	jobDashboard  *JobDashboardService
+	confluence    *ConfluenceService
+	notion        *NotionSyncService
+	drive         *GoogleDriveService
+}
+
+// NewAdminStatusService creates the service
+func NewAdminStatusService(db *gorm.DB, dependencies *DependencyMonitor, vectorService VectorStore, unifiedAI *UnifiedAIService,
+	jobDashboard *JobDashboardService, confluence *ConfluenceService, notion *NotionSyncService, drive *GoogleDriveService) *AdminStatusService {
+	return &AdminStatusService{
+		db:            db,
+		dependencies:  dependencies,
+		vectorService: vectorService,
+		unifiedAI:     unifiedAI,
+		jobDashboard:  jobDashboard,
+		confluence:    confluence,
+		notion:        notion,
+		drive:         drive,
+	}
+}
+
+// Status builds the report. Dependency reachability is the last background check; the rest is read now.
+func (s *AdminStatusService) Status(ctx context.Context) *SystemStatus {
+	dependencies := s.dependencies.Status()
+	return &SystemStatus{
+		GeneratedAt:  time.Now(),
+		Ready:        dependencies.Ready,
+		Dependencies: dependencies,
+		VectorDB:     s.vectorDB(ctx),
+		AIProviders:  s.unifiedAI.GetProviderHealth(),
+		Jobs:         s.jobs(ctx),
+		Documents:    s.documents(ctx),
+		Connectors:   s.connectors(ctx),
+	}
+}
+
+func (s *AdminStatusService) vectorDB(ctx context.Context) VectorDBStatus {
+	checkCtx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	info, err := s.vectorService.CollectionInfo(checkCtx)
+	if err != nil {
+		log.Printf("[WARNING] Failed to read the Qdrant collection for the system status: %v", err)
+		return VectorDBStatus{Error: err.Error()}
+	}
+	return VectorDBStatus{QdrantCollectionInfo: info}
+}
+
+func (s *AdminStatusService) jobs(ctx context.Context) JobsSummary {
+	summary := JobsSummary{PausedQueues: []string{}}
+	dashboard, err := s.jobDashboard.Dashboard(ctx, 24*time.Hour)
+	if err != nil {
+		log.Printf("[WARNING] Failed to load the job queues for the system status: %v", err)
+		summary.Error = err.Error()
+		return summary
+	}
+
+	for _, queue := range dashboard.Queues {
+		summary.Pending += queue.Pending
+		summary.Running += queue.Running
+		summary.Retrying += queue.Retrying
+		summary.Dead += queue.Dead
+		summary.Due += queue.Due
+		if queue.OldestDueAgeSeconds > summary.OldestDueAgeSeconds {
+			summary.OldestDueAgeSeconds = queue.OldestDueAgeSeconds
+		}
+		if queue.Paused {
+			summary.PausedQueues = append(summary.PausedQueues, queue.Queue)
+		}
+	}
+	summary.AliveWorkers = dashboard.AliveWorkers
+	return summary
+}
+
+func (s *AdminStatusService) documents(ctx context.Context) DocumentBacklog {
+	backlog := DocumentBacklog{ByStatus: map[models.DocumentStatus]int64{}}
+	db := s.db.WithContext(ctx)
+
+	var rows []struct {
+		Status models.DocumentStatus
+		Count  int64
+	}
+	if err := db.Model(&models.UploadedDocument{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		log.Printf("[WARNING] Failed to count documents for the system status: %v", err)
+		backlog.Error = err.Error()
+		return backlog
+	}
+	for _, row := range rows {
+		backlog.ByStatus[row.Status] = row.Count
+	}
+	for _, status := range processingDocumentStatuses {
+		backlog.Processing += backlog.ByStatus[status]
+	}
+	backlog.Failed = backlog.ByStatus[models.DocumentProcessingFailed]
+	backlog.Quarantined = backlog.ByStatus[models.DocumentQuarantined]
+
+	if backlog.Processing > 0 {
+		var oldest models.UploadedDocument
+		if err := db.Where("status IN ?", processingDocumentStatuses).Order("created_at").First(&oldest).Error; err == nil {
+			backlog.OldestPendingAt = &oldest.CreatedAt
+		}
+	}
+	return backlog
+}
+
+func (s *AdminStatusService) connectors(ctx context.Context) []ConnectorStatus {
+	connectors := make([]ConnectorStatus, 0, 4)
+
+	confluence := ConnectorStatus{Name: ConnectorConfluence}
+	if status, err := s.confluence.Status(ctx); err != nil {
+		confluence.Error = err.Error()
+	} else {
+		confluence.Enabled = status.Enabled
+		confluence.Synced = status.SyncedPages
+		confluence.Failed = status.FailedPages
+		confluence.LastSyncedAt = status.LastSyncedAt
+	}
+	connectors = append(connectors, confluence)
+
+	notion := ConnectorStatus{Name: ConnectorNotion}
+	if status, err := s.notion.Status(ctx); err != nil {
+		notion.Error = err.Error()
+	} else {
+		notion.Enabled = status.Enabled
+		notion.Synced = status.SyncedPages
+		notion.Failed = status.FailedPages
+		notion.LastSyncedAt = status.LastSyncedAt
+	}
+	connectors = append(connectors, notion)
+
+	drive := ConnectorStatus{Name: ConnectorGoogleDrive}
+	if status, err := s.drive.Status(ctx); err != nil {
+		drive.Error = err.Error()
+	} else {
+		drive.Enabled = status.Enabled
+		drive.Synced = status.SyncedFiles
+		drive.Failed = status.FailedFiles
+		drive.LastSyncedAt = status.LastSyncedAt
+	}
+	connectors = append(connectors, drive)
+
+	web := ConnectorStatus{Name: ConnectorWebSources}
+	var row struct {
+		Total    int64
+		Crawled  int64
+		Failed   int64
+		LastSync *time.Time
+	}
+	err := s.db.WithContext(ctx).Model(&models.WebSource{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE status = ?) AS crawled, COUNT(*) FILTER (WHERE status = ?) AS failed, MAX(last_crawled_at) AS last_sync",
+			models.WebSourceCrawled, models.WebSourceFailed).
+		Scan(&row).Error
+	if err != nil {
+		web.Error = err.Error()
+	} else {
+		web.Enabled = row.Total > 0
+		web.Synced = row.Crawled
+		web.Failed = row.Failed
+		web.LastSyncedAt = row.LastSync
+	}
+	connectors = append(connectors, web)
+
+	for _, connector := range connectors {
+		if connector.Error != "" {
+			log.Printf("[WARNING] Failed to load the %s sync status for the system status: %s", connector.Name, connector.Error)
+		}
+	}
+	return connectors
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	announcementCacheTTL = 30 * time.Second
+
+	// maxAnnouncementLength and maxActiveAnnouncements bound what announcements add to every system prompt
+	maxAnnouncementLength  = 1000
+	maxActiveAnnouncements = 5
+)
+
+var ErrAnnouncementManagerOnly = fmt.Errorf("%w: only support managers can manage announcements", ErrForbidden)
+
+// AnnouncementSpec is the editable part of an announcement
+type AnnouncementSpec struct {
+	Title    string     `json:"title" example:"Payment gateway maintenance"`
+	Content  string     `json:"content" example:"The payment gateway is under maintenance today from 14:00 to 16:00 UTC; card payments fail until then."`
+	Priority int        `json:"priority" example:"10"`
+	StartsAt *time.Time `json:"starts_at,omitempty"` // Empty starts it now
+	EndsAt   *time.Time `json:"ends_at,omitempty"`   // Empty keeps it active until it is ended or deleted
+}
+
+// AnnouncementService manages the org-wide announcements and adds the active ones to the system prompt of chat
+// answers. A nil service adds nothing.
+type AnnouncementService struct {
+	db *gorm.DB
+
+	mu        sync.RWMutex
+	current   []models.Announcement // Announcements not ended at the last load, by priority
+	expiresAt time.Time
+}
+
+// NewAnnouncementService creates the announcement service
+func NewAnnouncementService(db *gorm.DB) *AnnouncementService {
+	return &AnnouncementService{db: db}
+}
+
+// ListAnnouncements lists the announcements, most recent start first; activeOnly keeps those active now
+func (s *AnnouncementService) ListAnnouncements(ctx context.Context, activeOnly bool) ([]models.Announcement, error) {
+	query := s.db.WithContext(ctx).Order("starts_at DESC")
+	if activeOnly {
+		now := time.Now()
+		query = query.Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now)
+	}
+	var announcements []models.Announcement
+	if err := query.Find(&announcements).Error; err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+// CreateAnnouncement stores a new announcement
+func (s *AnnouncementService) CreateAnnouncement(ctx context.Context, managerID uuid.UUID, spec AnnouncementSpec) (*models.Announcement, error) {
+	if err := s.requireManager(ctx, managerID); err != nil {
+		return nil, err
+	}
+	announcement := &models.Announcement{CreatedBy: managerID}
+	if err := applyAnnouncementSpec(announcement, spec, managerID); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Create(announcement).Error; err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	log.Printf("[INFO] Support manager %s created announcement %q (%s)", managerID, announcement.Title, announcement.ID)
+	return announcement, nil
+}
+
+// UpdateAnnouncement replaces an announcement; setting ends_at to now ends it early
+func (s *AnnouncementService) UpdateAnnouncement(ctx context.Context, managerID, id uuid.UUID, spec AnnouncementSpec) (*models.Announcement, error) {
+	if err := s.requireManager(ctx, managerID); err != nil {
+		return nil, err
+	}
+	var announcement models.Announcement
+	if err := s.db.WithContext(ctx).First(&announcement, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "announcement "+id.String())
+	}
+	if spec.StartsAt == nil {
+		spec.StartsAt = &announcement.StartsAt
+	}
+	if err := applyAnnouncementSpec(&announcement, spec, managerID); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Save(&announcement).Error; err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	log.Printf("[INFO] Support manager %s updated announcement %q (%s)", managerID, announcement.Title, id)
+	return &announcement, nil
+}
+
+// DeleteAnnouncement deletes an announcement
+func (s *AnnouncementService) DeleteAnnouncement(ctx context.Context, managerID, id uuid.UUID) error {
+	if err := s.requireManager(ctx, managerID); err != nil {
+		return err
+	}
+	result := s.db.WithContext(ctx).Delete(&models.Announcement{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "announcement "+id.String())
+	}
+	s.invalidate()
+
+	log.Printf("[INFO] Support manager %s deleted announcement %s", managerID, id)
+	return nil
+}
+
+// Active returns the announcements active now, highest priority first, at most maxActiveAnnouncements
+func (s *AnnouncementService) Active() []models.Announcement {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	if time.Now().After(s.expiresAt) {
+		s.mu.RUnlock()
+		s.reload()
+		s.mu.RLock()
+	}
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	var active []models.Announcement
+	for _, announcement := range s.current {
+		if announcement.StartsAt.After(now) || (announcement.EndsAt != nil && !announcement.EndsAt.After(now)) {
+			continue
+		}
+		active = append(active, announcement)
+		if len(active) == maxActiveAnnouncements {
+			break
+		}
+	}
+	return active
+}
+
+// SystemPrompt adds the active announcements to a system prompt, so that answers mention them when they matter
+// to the question
+func (s *AnnouncementService) SystemPrompt(prompt string) string {
+	active := s.Active()
+	if len(active) == 0 {
+		return prompt
+	}
+
+	var instructions strings.Builder
+	instructions.WriteString("Current announcements (mention them when they affect the user's question):\n")
+	for _, announcement := range active {
+		fmt.Fprintf(&instructions, "- %s: %s\n", announcement.Title, announcement.Content)
+	}
+	if prompt == "" {
+		return strings.TrimSpace(instructions.String())
+	}
+	return prompt + "\n\n" + strings.TrimSpace(instructions.String())
+}
+
+// reload refreshes the cached announcements. On failure the previous ones are kept until the next attempt.
+func (s *AnnouncementService) reload() {
+	var announcements []models.Announcement
+	err := s.db.Where("ends_at IS NULL OR ends_at > ?", time.Now()).Find(&announcements).Error
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresAt = time.Now().Add(announcementCacheTTL)
+	if err != nil {
+		log.Printf("[WARNING] Failed to load announcements: %v", err)
+		return
+	}
+	sort.SliceStable(announcements, func(i, j int) bool {
+		if announcements[i].Priority != announcements[j].Priority {
+			return announcements[i].Priority > announcements[j].Priority
+		}
+		return announcements[i].StartsAt.After(announcements[j].StartsAt)
+	})
+	s.current = announcements
+}
+
+func (s *AnnouncementService) invalidate() {
+	s.mu.Lock()
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// requireManager allows support agents and admins, who run the support desk, to manage announcements
+func (s *AnnouncementService) requireManager(ctx context.Context, managerID uuid.UUID) error {
+	var manager models.User
+	if err := s.db.WithContext(ctx).Select("id", "role").First(&manager, "id = ?", managerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAnnouncementManagerOnly
+		}
+		return err
+	}
+	if manager.Role != models.SupportRole && manager.Role != models.AdminRole {
+		return ErrAnnouncementManagerOnly
+	}
+	return nil
+}
+
+func applyAnnouncementSpec(announcement *models.Announcement, spec AnnouncementSpec, managerID uuid.UUID) error {
+	spec.Title = strings.TrimSpace(spec.Title)
+	spec.Content = strings.TrimSpace(spec.Content)
+	if spec.Title == "" {
+		return validationError("title is required")
+	}
+	if spec.Content == "" {
+		return validationError("content is required")
+	}
+	if len(spec.Content) > maxAnnouncementLength {
+		return validationError("content must be at most %d characters", maxAnnouncementLength)
+	}
+	startsAt := time.Now()
+	if spec.StartsAt != nil {
+		startsAt = *spec.StartsAt
+	}
+	if spec.EndsAt != nil && !spec.EndsAt.After(startsAt) {
+		return validationError("ends_at must be after starts_at")
+	}
+
+	announcement.Title = spec.Title
+	announcement.Content = spec.Content
+	announcement.Priority = spec.Priority
+	announcement.StartsAt = startsAt
+	announcement.EndsAt = spec.EndsAt
+	announcement.UpdatedBy = managerID
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+)
+
+// answerEvalContextEntries is how many retrieved entries the chat puts in the context of an answer
+const answerEvalContextEntries = 3
+
+// faithfulnessVerdict is the reply expected from the LLM judge
+type faithfulnessVerdict struct {
+	Faithfulness      *float64 `json:"faithfulness"`
+	UnsupportedClaims []string `json:"unsupported_claims"`
+}
+
+// evaluateAnswer answers the question of a result the way the chat does, from the best retrieved entries,
+// and records the judge's faithfulness score. Failures are noted on the result instead of failing the run.
+func (s *RetrievalEvalService) evaluateAnswer(ctx context.Context, result *models.RetrievalEvalResult, entries []models.KnowledgeEntry) {
+	req := chatPathRequest(result.Question, entries)
+	resp, err := s.unifiedAI.ChatCompletion(ctx, req)
+	if err != nil {
+		log.Printf("[WARNING] Failed to answer evaluation pair %s: %v", result.PairID, err)
+		result.JudgeNotes = "answer failed: " + err.Error()
+		return
+	}
+	result.Answer = resp.Message
+
+	verdict, err := s.judgeFaithfulness(ctx, result.Question, req.Context, resp.Message)
+	if err != nil {
+		log.Printf("[WARNING] Failed to judge the answer of evaluation pair %s: %v", result.PairID, err)
+		result.JudgeNotes = "judge failed: " + err.Error()
+		return
+	}
+	result.Faithfulness = verdict.Faithfulness
+	result.JudgeNotes = strings.Join(verdict.UnsupportedClaims, "\n")
+}
+
+// chatPathRequest is the request the chat sends to answer a question from the best retrieved entries
+func chatPathRequest(question string, entries []models.KnowledgeEntry) UnifiedChatRequest {
+	if len(entries) > answerEvalContextEntries {
+		entries = entries[:answerEvalContextEntries]
+	}
+	context := citationContext(entries)
+	return UnifiedChatRequest{
+		Messages:         []UnifiedChatMessage{{Role: "user", Content: question}},
+		Context:          context,
+		UseKnowledgeBase: len(context) > 0,
+	}
+}
+
+// judgeFaithfulness asks the AI provider to score from 0 to 1 how well an answer is supported by the passages
+// it was given
+func (s *RetrievalEvalService) judgeFaithfulness(ctx context.Context, question string, context []string, answer string) (*faithfulnessVerdict, error) {
+	var passages strings.Builder
+	for i, passage := range context {
+		fmt.Fprintf(&passages, "[%d] %s\n\n", i+1, passage)
+	}
+	if passages.Len() == 0 {
+		passages.WriteString("(no passages were retrieved)\n\n")
+	}
+
+	prompt := fmt.Sprintf("You grade the answers of a support assistant that must answer only from the passages it is given.\n\n"+
+		"Passages:\n%s"+
+		"Question: %s\n\nAnswer: %s\n\n"+
+		"Score how faithful the answer is to the passages: 1 when every claim in it is supported by the passages, 0 when "+
+		"it is made up. Saying that the passages do not answer the question is faithful. Reply with JSON only, in the form "+
+		`{"faithfulness": 0.8, "unsupported_claims": ["claim not found in the passages"]}`, passages.String(), question, answer)
+
+	resp, err := s.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages: []UnifiedChatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw := strings.TrimSpace(resp.Message)
+	if start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}"); start >= 0 && end > start {
+		raw = raw[start : end+1]
+	}
+	var verdict faithfulnessVerdict
+	if err := json.Unmarshal([]byte(raw), &verdict); err != nil || verdict.Faithfulness == nil {
+		return nil, fmt.Errorf("unparseable verdict %q", utils.TruncateString(resp.Message, 200))
+	}
+	score := *verdict.Faithfulness
+	if score < 0 {
+		score = 0
+	}
+	if score > 1 {
+		score = 1
+	}
+	verdict.Faithfulness = &score
+	return &verdict, nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrAssistantAdminOnly = fmt.Errorf("%w: only admins can manage assistants", ErrForbidden)
+
+// AssistantSpec is the editable configuration of a managed assistant. Empty fields of an update keep
+// their current value.
+type AssistantSpec struct {
+	Name           string   `json:"name" example:"Support assistant"`
+	Description    string   `json:"description,omitempty"`
+	Model          string   `json:"model,omitempty" example:"gpt-4o"`                  // Empty uses the default model
+	PromptName     string   `json:"prompt_name,omitempty" example:"support_assistant"` // Prompt template rendered into the instructions
+	VectorStoreIDs []string `json:"vector_store_ids,omitempty"`                        // Empty uses the knowledge vector store
+}
+
+// assistantRequest creates or modifies an assistant with the Assistants API v2
+type assistantRequest struct {
+	Model         string                 `json:"model"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Instructions  string                 `json:"instructions"`
+	Tools         []responsesTool        `json:"tools"`
+	ToolResources assistantToolResources `json:"tool_resources"`
+	Metadata      map[string]string      `json:"metadata,omitempty"`
+}
+
+type assistantToolResources struct {
+	FileSearch struct {
+		VectorStoreIDs []string `json:"vector_store_ids"`
+	} `json:"file_search"`
+}
+
+type assistantObject struct {
+	ID string `json:"id"`
+}
+
+// AssistantAdminService creates and updates OpenAI assistants from the configuration kept here, so that
+// they are not hand-managed in the OpenAI console. Instructions are rendered from the active version of
+// a prompt template, and updating an assistant renders them again.
+type AssistantAdminService struct {
+	db                    *gorm.DB
+	prompts               *PromptService
+	baseURL               string
+	apiKey                string
+	httpClient            *http.Client
+	defaultModel          string
+	defaultVectorStoreIDs []string
+}
+
+// NewAssistantAdminService manages assistants on OpenAI. model and vectorStoreIDs are the defaults of new assistants.
+func NewAssistantAdminService(db *gorm.DB, prompts *PromptService, apiKey, model string, vectorStoreIDs []string) *AssistantAdminService {
+	return &AssistantAdminService{
+		db:                    db,
+		prompts:               prompts,
+		baseURL:               openAIAPIBaseURL,
+		apiKey:                apiKey,
+		httpClient:            &http.Client{Timeout: 60 * time.Second, Transport: &headerTransport{base: http.DefaultTransport}},
+		defaultModel:          model,
+		defaultVectorStoreIDs: vectorStoreIDs,
+	}
+}
+
+// NewAzureAssistantAdminService manages assistants on Azure OpenAI, where the model is a deployment name
+func NewAzureAssistantAdminService(db *gorm.DB, prompts *PromptService, endpoint, apiKey, apiVersion, deployment string, vectorStoreIDs []string) *AssistantAdminService {
+	s := NewAssistantAdminService(db, prompts, apiKey, deployment, vectorStoreIDs)
+	s.baseURL = strings.TrimRight(endpoint, "/") + "/openai"
+	s.httpClient.Transport = &headerTransport{
+		base: &azureTransport{base: http.DefaultTransport, apiKey: apiKey, apiVersion: apiVersion},
+	}
+	return s
+}
+
+// ListAssistants returns the managed assistants, most recently updated first
+func (s *AssistantAdminService) ListAssistants(ctx context.Context) ([]models.ManagedAssistant, error) {
+	var assistants []models.ManagedAssistant
+	if err := s.db.WithContext(ctx).Order("updated_at DESC").Find(&assistants).Error; err != nil {
+		return nil, err
+	}
+	return assistants, nil
+}
+
+// GetAssistant returns a managed assistant
+func (s *AssistantAdminService) GetAssistant(ctx context.Context, id uuid.UUID) (*models.ManagedAssistant, error) {
+	var assistant models.ManagedAssistant
+	if err := s.db.WithContext(ctx).First(&assistant, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: assistant %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// CreateAssistant creates an assistant on OpenAI with file search over the vector stores and records it
+func (s *AssistantAdminService) CreateAssistant(ctx context.Context, spec AssistantSpec, adminID uuid.UUID) (*models.ManagedAssistant, error) {
+	if strings.TrimSpace(spec.Name) == "" {
+		return nil, validationError("name is required")
+	}
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	assistant := &models.ManagedAssistant{
+		ID:         uuid.New(),
+		Model:      s.defaultModel,
+		PromptName: PromptSupportAssistant,
+		CreatedBy:  adminID,
+	}
+	vectorStoreIDs := s.defaultVectorStoreIDs
+	if err := s.apply(ctx, assistant, spec, &vectorStoreIDs, adminID); err != nil {
+		return nil, err
+	}
+
+	var created assistantObject
+	if err := s.call(ctx, http.MethodPost, "/assistants", s.request(assistant, vectorStoreIDs), &created); err != nil {
+		return nil, fmt.Errorf("failed to create assistant: %w", err)
+	}
+	assistant.OpenAIAssistantID = created.ID
+	if err := s.db.WithContext(ctx).Create(assistant).Error; err != nil {
+		log.Printf("[ERROR] Created assistant %s but failed to record it: %v", created.ID, err)
+		return nil, err
+	}
+
+	log.Printf("[INFO] Admin %s created assistant %s (%s) with prompt %s version %d",
+		adminID, assistant.Name, created.ID, assistant.PromptName, assistant.PromptVersion)
+	return assistant, nil
+}
+
+// UpdateAssistant changes a managed assistant and renders its instructions from the active prompt again
+func (s *AssistantAdminService) UpdateAssistant(ctx context.Context, id uuid.UUID, spec AssistantSpec, adminID uuid.UUID) (*models.ManagedAssistant, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	assistant, err := s.GetAssistant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var vectorStoreIDs []string
+	if assistant.VectorStoreIDs != "" {
+		if err := json.Unmarshal([]byte(assistant.VectorStoreIDs), &vectorStoreIDs); err != nil {
+			log.Printf("[WARNING] Invalid vector stores on assistant %s, using the default: %v", assistant.ID, err)
+			vectorStoreIDs = s.defaultVectorStoreIDs
+		}
+	}
+	if err := s.apply(ctx, assistant, spec, &vectorStoreIDs, adminID); err != nil {
+		return nil, err
+	}
+
+	path := "/assistants/" + assistant.OpenAIAssistantID
+	if err := s.call(ctx, http.MethodPost, path, s.request(assistant, vectorStoreIDs), &assistantObject{}); err != nil {
+		return nil, fmt.Errorf("failed to update assistant: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Save(assistant).Error; err != nil {
+		log.Printf("[ERROR] Updated assistant %s but failed to record it: %v", assistant.OpenAIAssistantID, err)
+		return nil, err
+	}
+
+	log.Printf("[INFO] Admin %s updated assistant %s (%s) with prompt %s version %d",
+		adminID, assistant.Name, assistant.OpenAIAssistantID, assistant.PromptName, assistant.PromptVersion)
+	return assistant, nil
+}
+
+// apply sets the non-empty fields of spec on an assistant and renders its instructions
+func (s *AssistantAdminService) apply(ctx context.Context, assistant *models.ManagedAssistant, spec AssistantSpec, vectorStoreIDs *[]string, adminID uuid.UUID) error {
+	if name := strings.TrimSpace(spec.Name); name != "" {
+		assistant.Name = name
+	}
+	if description := strings.TrimSpace(spec.Description); description != "" {
+		assistant.Description = description
+	}
+	if model := strings.TrimSpace(spec.Model); model != "" {
+		assistant.Model = model
+	}
+	if assistant.Model == "" {
+		return validationError("model is required")
+	}
+	if promptName := strings.TrimSpace(spec.PromptName); promptName != "" {
+		assistant.PromptName = promptName
+	}
+	if len(spec.VectorStoreIDs) > 0 {
+		*vectorStoreIDs = spec.VectorStoreIDs
+	}
+	encoded, _ := json.Marshal(*vectorStoreIDs)
+	assistant.VectorStoreIDs = string(encoded)
+
+	instructions, version, err := s.instructions(ctx, assistant.PromptName)
+	if err != nil {
+		return err
+	}
+	assistant.Instructions = instructions
+	assistant.PromptVersion = version
+	assistant.UpdatedBy = adminID
+	return nil
+}
+
+// instructions renders the active version of a prompt template. The built-in support prompt is used for
+// the support prompt when it has no active version; other prompts must exist.
+func (s *AssistantAdminService) instructions(ctx context.Context, promptName string) (string, int, error) {
+	prompt, err := s.prompts.active(ctx, promptName)
+	if err != nil {
+		return "", 0, err
+	}
+	if prompt == nil {
+		if promptName == PromptSupportAssistant {
+			return defaultSupportPrompt, 0, nil
+		}
+		return "", 0, validationError("prompt template %s has no active version", promptName)
+	}
+	rendered := resolvePrompt(withPromptOverride(ctx, prompt), s.prompts, promptName, OpenAIProvider, prompt.Content)
+	return rendered, prompt.Version, nil
+}
+
+func (s *AssistantAdminService) request(assistant *models.ManagedAssistant, vectorStoreIDs []string) assistantRequest {
+	request := assistantRequest{
+		Model:        assistant.Model,
+		Name:         assistant.Name,
+		Description:  assistant.Description,
+		Instructions: assistant.Instructions,
+		Tools:        []responsesTool{{Type: "file_search"}},
+		Metadata: map[string]string{
+			"managed_assistant_id": assistant.ID.String(),
+			"prompt":               fmt.Sprintf("%s@%d", assistant.PromptName, assistant.PromptVersion),
+		},
+	}
+	request.ToolResources.FileSearch.VectorStoreIDs = vectorStoreIDs
+	if request.ToolResources.FileSearch.VectorStoreIDs == nil {
+		request.ToolResources.FileSearch.VectorStoreIDs = []string{}
+	}
+	return request
+}
+
+// call sends a request to the Assistants API and decodes the JSON response into out
+func (s *AssistantAdminService) call(ctx context.Context, method, path string, body, out interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message := strings.TrimSpace(string(data))
+		var apiError struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiError) == nil && apiError.Error.Message != "" {
+			message = apiError.Error.Message
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrNotFound, message)
+		}
+		if resp.StatusCode == http.StatusBadRequest {
+			return validationError("%s", message)
+		}
+		return &responsesAPIError{StatusCode: resp.StatusCode, Message: message}
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (s *AssistantAdminService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAssistantAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrAssistantAdminOnly
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Assistant engines selectable with ASSISTANT_ENGINE
+const (
+	AssistantEngineAssistants = "assistants" // Beta Assistants API: threads, runs and assistant objects
+	AssistantEngineResponses  = "responses"  // Responses API with file_search over conversations
+)
+
+// AssistantEngine serves the /assistant endpoints. Clients talk about threads and runs; an engine
+// maps them onto its own API objects, so the engine can change without changing client contracts.
+type AssistantEngine interface {
+	// Name identifies the engine in responses and logs
+	Name() string
+	// ChatWithAssistant adds a message to a thread and answers it, waiting up to the request timeout
+	ChatWithAssistant(ctx context.Context, req ChatAssistantRequest) (*ChatAssistantResponse, error)
+	// GetThreadMessages returns a page of the messages of a thread, newest first unless the query asks otherwise
+	GetThreadMessages(ctx context.Context, threadID string, query ThreadMessagesQuery) (*ThreadMessagesPage, error)
+	// CreateThread starts an empty thread
+	CreateThread(ctx context.Context) (*openai.Thread, error)
+	// WaitForRunCompletion waits for a run to finish and returns its final status
+	WaitForRunCompletion(ctx context.Context, threadID, runID string, timeout time.Duration) (string, error)
+	// WaitForRun long-polls a run; a run still going when timeout elapses is returned with Done false
+	WaitForRun(ctx context.Context, threadID, runID string, timeout time.Duration) (*RunWaitResult, error)
+}
+
+// Bounds of a page of thread messages
+const (
+	defaultThreadMessagesLimit = 50
+	maxThreadMessagesLimit     = 100
+)
+
+// ThreadMessagesQuery pages and filters the messages of a thread. After and Before are message IDs in the
+// requested order; at most one of them may be set.
+type ThreadMessagesQuery struct {
+	Limit  int    // Messages fetched per page, 1 to 100; 0 fetches 50
+	Order  string // asc or desc; empty is desc
+	After  string
+	Before string
+	Role   string // Only messages of this role
+	RunID  string // Only messages created by this run
+}
+
+// ThreadMessagesPage is a page of thread messages with the cursors of the neighbouring pages. Role and
+// run filters apply to the fetched page, so a page may hold fewer messages than the limit while more follow.
+type ThreadMessagesPage struct {
+	Messages   []AssistantMessage `json:"messages"`
+	HasMore    bool               `json:"has_more"`
+	NextCursor string             `json:"next_cursor,omitempty"` // Pass as after to get the next page
+	PrevCursor string             `json:"prev_cursor,omitempty"` // Pass as before to get the previous page
+}
+
+// normalize applies the defaults of a query and validates it
+func (q ThreadMessagesQuery) normalize() (ThreadMessagesQuery, error) {
+	if q.Limit == 0 {
+		q.Limit = defaultThreadMessagesLimit
+	}
+	if q.Limit < 0 || q.Limit > maxThreadMessagesLimit {
+		return q, validationError("limit must be between 1 and %d", maxThreadMessagesLimit)
+	}
+	if q.Order == "" {
+		q.Order = "desc"
+	}
+	if q.Order != "asc" && q.Order != "desc" {
+		return q, validationError("order must be asc or desc")
+	}
+	if q.After != "" && q.Before != "" {
+		return q, validationError("after and before cannot be combined")
+	}
+	return q, nil
+}
+
+// threadMessagesPage filters the messages of a fetched page and sets the cursors from the IDs of its
+// first and last items, which may not be messages
+func threadMessagesPage(query ThreadMessagesQuery, messages []AssistantMessage, firstID, lastID string, hasMore bool) *ThreadMessagesPage {
+	page := &ThreadMessagesPage{Messages: []AssistantMessage{}, HasMore: hasMore}
+	for _, message := range messages {
+		if (query.Role == "" || message.Role == query.Role) && (query.RunID == "" || message.RunID == query.RunID) {
+			page.Messages = append(page.Messages, message)
+		}
+	}
+	if query.Before != "" {
+		// Paging backwards: more messages lie before this page when it is full, and after it in any case
+		page.NextCursor = lastID
+		if hasMore {
+			page.PrevCursor = firstID
+		}
+	} else {
+		if hasMore {
+			page.NextCursor = lastID
+		}
+		if query.After != "" {
+			page.PrevCursor = firstID
+		}
+	}
+	return page
+}
+
+var (
+	_ AssistantEngine = (*OpenAIAssistantService)(nil)
+	_ AssistantEngine = (*OpenAIResponsesService)(nil)
+)
+
+// trackAssistantThread marks a thread as used now, so the garbage collector knows when it went idle
+func trackAssistantThread(db *gorm.DB, logger *log.Logger, threadID string) {
+	if db == nil || threadID == "" {
+		return
+	}
+	thread := models.AssistantThread{ThreadID: threadID, LastUsedAt: time.Now()}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "thread_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_used_at"}),
+	}).Create(&thread).Error
+	if err != nil {
+		logger.Printf("Warning: failed to record use of thread %s: %v", threadID, err)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrAuthTokenInvalid is returned for session tokens that are malformed, forged or expired
+var ErrAuthTokenInvalid = errors.New("invalid or expired session token")
+
+const authTokenIssuer = "tic-knowledge-system"
+
+// AuthClaims identifies the user a session token was issued to
+type AuthClaims struct {
+	Subject   uuid.UUID       `json:"sub"`
+	Email     string          `json:"email"`
+	Role      models.UserRole `json:"role"`
+	Issuer    string          `json:"iss"`
+	IssuedAt  int64           `json:"iat"`
+	ExpiresAt int64           `json:"exp"`
+}
+
+// AuthTokenService issues and verifies the HS256 JWTs identifying signed-in users
+type AuthTokenService struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewAuthTokenService creates the token service. Without a secret a random one is generated, so tokens
+// do not survive a restart and are not accepted by other replicas.
+func NewAuthTokenService(secret string, ttl time.Duration) *AuthTokenService {
+	if ttl <= 0 {
+		ttl = 12 * time.Hour
+	}
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate session token secret: %v", err))
+		}
+		log.Printf("[WARNING] JWT_SECRET is not set; session tokens are signed with a random secret and end with this process")
+	}
+	return &AuthTokenService{secret: key, ttl: ttl}
+}
+
+// TTL is how long issued tokens are valid
+func (s *AuthTokenService) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue returns a signed token for the user and its expiry
+func (s *AuthTokenService) Issue(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	claims := AuthClaims{
+		Subject:   user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		Issuer:    authTokenIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+	token, err := s.sign(claims)
+	return token, expiresAt, err
+}
+
+// Verify returns the claims of a valid token, or ErrAuthTokenInvalid
+func (s *AuthTokenService) Verify(token string) (*AuthClaims, error) {
+	var claims AuthClaims
+	if err := s.verify(token, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != authTokenIssuer || claims.Subject == uuid.Nil || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrAuthTokenInvalid
+	}
+	return &claims, nil
+}
+
+// sign encodes claims as an HS256 JWT
+func (s *AuthTokenService) sign(claims interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(s.mac(signingInput)), nil
+}
+
+// verify checks the signature of an HS256 JWT and decodes its claims
+func (s *AuthTokenService) verify(token string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrAuthTokenInvalid
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return ErrAuthTokenInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.mac(parts[0]+"."+parts[1])) {
+		return ErrAuthTokenInvalid
+	}
+	if err := decodeJWTSegment(parts[1], claims); err != nil {
+		return ErrAuthTokenInvalid
+	}
+	return nil
+}
+
+func (s *AuthTokenService) mac(signingInput string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// decodeJWTSegment decodes a base64url JSON segment of a JWT
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
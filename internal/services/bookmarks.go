@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// bookmarkBoost raises the relevance score of a bookmarked entry when the matches of a search are ranked,
+// so that it comes before unsaved entries that match about as well, not before clearly better matches
+const bookmarkBoost = 1.15
+
+// Bookmark kinds accepted by the listing filter
+const (
+	BookmarkMessages = "messages"
+	BookmarkEntries  = "entries"
+)
+
+// BookmarkRequest saves a chat answer or a knowledge entry; exactly one of them is set
+type BookmarkRequest struct {
+	MessageID        *uuid.UUID `json:"message_id,omitempty"`
+	KnowledgeEntryID *uuid.UUID `json:"knowledge_entry_id,omitempty"`
+	Note             string     `json:"note,omitempty" example:"VPN fix that worked"`
+}
+
+// BookmarkService lets users save useful answers and entries
+type BookmarkService struct {
+	db               *gorm.DB
+	knowledgeService *KnowledgeService
+}
+
+// NewBookmarkService creates the bookmark service
+func NewBookmarkService(db *gorm.DB, knowledgeService *KnowledgeService) *BookmarkService {
+	return &BookmarkService{db: db, knowledgeService: knowledgeService}
+}
+
+// ListBookmarks returns the bookmarks of a user with the saved message or entry, most recent first.
+// kind restricts them to messages or entries.
+func (s *BookmarkService) ListBookmarks(ctx context.Context, userID uuid.UUID, kind string) ([]models.Bookmark, error) {
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	switch kind {
+	case "":
+	case BookmarkMessages:
+		query = query.Where("message_id IS NOT NULL")
+	case BookmarkEntries:
+		query = query.Where("knowledge_entry_id IS NOT NULL")
+	default:
+		return nil, validationError("type must be %s or %s", BookmarkMessages, BookmarkEntries)
+	}
+
+	var bookmarks []models.Bookmark
+	err := query.Preload("Message").Preload("KnowledgeEntry").Order("created_at DESC").Find(&bookmarks).Error
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// CreateBookmark saves a message of one of the user's sessions or an entry the user can see. Saving the same
+// message or entry again updates the note of the existing bookmark.
+func (s *BookmarkService) CreateBookmark(ctx context.Context, userID uuid.UUID, req BookmarkRequest) (*models.Bookmark, error) {
+	if (req.MessageID == nil) == (req.KnowledgeEntryID == nil) {
+		return nil, validationError("exactly one of message_id and knowledge_entry_id is required")
+	}
+
+	existing := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if req.MessageID != nil {
+		var owned int64
+		err := s.db.WithContext(ctx).Model(&models.ChatMessage{}).
+			Joins("JOIN chat_sessions ON chat_sessions.id = chat_messages.session_id AND chat_sessions.deleted_at IS NULL").
+			Where("chat_messages.id = ? AND chat_sessions.user_id = ?", *req.MessageID, userID).Count(&owned).Error
+		if err != nil {
+			return nil, err
+		}
+		if owned == 0 {
+			return nil, notFound(gorm.ErrRecordNotFound, "chat message "+req.MessageID.String())
+		}
+		existing = existing.Where("message_id = ?", *req.MessageID)
+	} else {
+		var visible int64
+		scope := s.knowledgeService.ScopeForUser(userID)
+		err := scope.Apply(s.db.WithContext(ctx).Model(&models.KnowledgeEntry{})).
+			Where("id = ? AND is_published = true", *req.KnowledgeEntryID).Count(&visible).Error
+		if err != nil {
+			return nil, err
+		}
+		if visible == 0 {
+			return nil, notFound(gorm.ErrRecordNotFound, "knowledge entry "+req.KnowledgeEntryID.String())
+		}
+		existing = existing.Where("knowledge_entry_id = ?", *req.KnowledgeEntryID)
+	}
+
+	var bookmark models.Bookmark
+	err := existing.First(&bookmark).Error
+	switch {
+	case err == nil:
+		bookmark.Note = strings.TrimSpace(req.Note)
+		if err := s.db.WithContext(ctx).Model(&bookmark).Update("note", bookmark.Note).Error; err != nil {
+			return nil, err
+		}
+		return &bookmark, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	bookmark = models.Bookmark{
+		UserID:           userID,
+		MessageID:        req.MessageID,
+		KnowledgeEntryID: req.KnowledgeEntryID,
+		Note:             strings.TrimSpace(req.Note),
+	}
+	if err := s.db.WithContext(ctx).Create(&bookmark).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] User %s bookmarked %s", userID, bookmarkTarget(&bookmark))
+	return &bookmark, nil
+}
+
+// DeleteBookmark removes a bookmark of the user
+func (s *BookmarkService) DeleteBookmark(ctx context.Context, userID, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "bookmark "+id.String())
+	}
+	return nil
+}
+
+func bookmarkTarget(bookmark *models.Bookmark) string {
+	if bookmark.MessageID != nil {
+		return "message " + bookmark.MessageID.String()
+	}
+	return "knowledge entry " + bookmark.KnowledgeEntryID.String()
+}
+
+// bookmarkedEntries returns the entries a user bookmarked, nil when there are none
+func (s *KnowledgeService) bookmarkedEntries(userID uuid.UUID) map[uuid.UUID]bool {
+	var ids []uuid.UUID
+	err := readDB(s.reads, s.db).Model(&models.Bookmark{}).
+		Where("user_id = ? AND knowledge_entry_id IS NOT NULL", userID).Pluck("knowledge_entry_id", &ids).Error
+	if err != nil {
+		log.Printf("[WARNING] Failed to load the bookmarks of user %s, ranking without them: %v", userID, err)
+		return nil
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	bookmarked := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked
+}
+
+// rankBookmarked moves the bookmarked entries of the scope up among the matches of a search. Vector matches are
+// reordered by their boosted score; keyword matches, which have no score, put bookmarked entries first. The
+// citations keep their scores and are renumbered in the new order.
+func (scope RetrievalScope) rankBookmarked(entries []models.KnowledgeEntry, citations []Citation) {
+	if len(scope.Bookmarked) == 0 || len(entries) < 2 {
+		return
+	}
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	boosted := func(i int) float64 {
+		score := citations[i].Score
+		if score == 0 {
+			score = 1
+		}
+		if scope.Bookmarked[entries[i].ID] {
+			return score * bookmarkBoost
+		}
+		return score
+	}
+	sort.SliceStable(order, func(a, b int) bool { return boosted(order[a]) > boosted(order[b]) })
+
+	rankedEntries := make([]models.KnowledgeEntry, len(entries))
+	rankedCitations := make([]Citation, len(citations))
+	for rank, i := range order {
+		rankedEntries[rank] = entries[i]
+		rankedCitations[rank] = citations[i]
+		rankedCitations[rank].Index = rank + 1
+	}
+	copy(entries, rankedEntries)
+	copy(citations, rankedCitations)
+}
@@ -14,6 +14,14 @@ type ChatService struct {
 	db            *gorm.DB
 	openAIService *OpenAIService
 	knowledgeService *KnowledgeService
+	dedup         *ChatDeduplicator
+	usage         *UsageService
+	translator    *TranslationService
+	confidence    ConfidenceOptions
+	handoff       *HandoffService
+	queryRewriter *QueryRewriter
+	analytics     *EntryAnalyticsService
+	announcements *AnnouncementService
 }
 
 func NewChatService(db *gorm.DB, openAIService *OpenAIService, knowledgeService *KnowledgeService) *ChatService {
@@ -24,21 +32,102 @@ func NewChatService(db *gorm.DB, openAIService *OpenAIService, knowledgeService
 	}
 }
 
+// SetDeduplicator collapses identical messages submitted to a session within the deduplicator's window
+func (s *ChatService) SetDeduplicator(dedup *ChatDeduplicator) {
+	s.dedup = dedup
+}
+
+// SetUsageService records the token usage and cost of every answer
+func (s *ChatService) SetUsageService(usage *UsageService) {
+	s.usage = usage
+}
+
+// SetTranslator answers in the language of the user, translating knowledge written in other languages
+func (s *ChatService) SetTranslator(translator *TranslationService) {
+	s.translator = translator
+}
+
+// SetConfidence replaces answers the knowledge base supports too weakly with one saying the bot does not know
+func (s *ChatService) SetConfidence(opts ConfidenceOptions) {
+	s.confidence = opts
+}
+
+// SetHandoff stops the assistant from answering sessions escalated to a support agent
+func (s *ChatService) SetHandoff(handoff *HandoffService) {
+	s.handoff = handoff
+}
+
+// SetQueryRewriter rewrites messages into standalone retrieval queries before the knowledge base is searched
+func (s *ChatService) SetQueryRewriter(rewriter *QueryRewriter) {
+	s.queryRewriter = rewriter
+}
+
+// SetAnalytics records the searches of chat messages, so that questions the knowledge base has no answer for
+// are reported
+func (s *ChatService) SetAnalytics(analytics *EntryAnalyticsService) {
+	s.analytics = analytics
+}
+
+// SetAnnouncements adds the active org-wide announcements to the system prompt of every answer
+func (s *ChatService) SetAnnouncements(announcements *AnnouncementService) {
+	s.announcements = announcements
+}
+
 type ChatRequest struct {
 	Message   string    `json:"message" validate:"required"`
 	SessionID *uuid.UUID `json:"session_id,omitempty"`
 	UserID    uuid.UUID `json:"user_id" validate:"required"`
+	Language  string    `json:"language,omitempty"` // Language of the answer; empty answers in the language of the message
+	PersonaID *uuid.UUID `json:"persona_id,omitempty"` // Persona preset the session answers as from now on (see GET /chat/personas)
+	Labels    []string  `json:"labels,omitempty"`     // Answer only from entries tagged with one of these, e.g. context file labels
 }
 
 type ChatResponse struct {
-	Message   string    `json:"message"`
-	SessionID uuid.UUID `json:"session_id"`
-	Sources   []string  `json:"sources,omitempty"`
+	Message   string     `json:"message"`
+	SessionID uuid.UUID  `json:"session_id"`
+	Sources   []string   `json:"sources,omitempty"`
+	Citations []Citation `json:"citations,omitempty"`
+	SeeAlso   []RelatedEntry `json:"see_also,omitempty"` // Entries related to the best match, suggested when confidence is low
+	Deduplicated bool    `json:"deduplicated,omitempty"` // Response of an identical request sent moments earlier
+	Language  string     `json:"language,omitempty"` // ISO 639-1 code of the answer's language, when known
+	Confidence float64   `json:"confidence"` // How well the knowledge base supports the answer, from 0 to 1
+	SuggestEscalation bool `json:"suggest_escalation,omitempty"` // The bot does not know the answer; the user should contact support
+	Escalation *models.ChatEscalation `json:"escalation,omitempty"` // Set while a support agent answers the session instead of the assistant
 }
 
+// ProcessChat answers a chat message, sharing the response of an identical message
+// sent to the same session within the deduplication window
 func (s *ChatService) ProcessChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	keyReq := req
+	keyReq.Message = ""
+	result, duplicate, err := s.dedup.Do(ctx, chatDedupKey(keyReq, req.Message), func() (interface{}, error) {
+		response, err := s.processChat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return response, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := result.(*ChatResponse)
+	if duplicate {
+		log.Printf("[INFO] Duplicate chat message for user %s, returning the earlier response", req.UserID)
+		deduplicated := *response
+		deduplicated.Deduplicated = true
+		return &deduplicated, nil
+	}
+	return response, nil
+}
+
+func (s *ChatService) processChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	log.Printf("[INFO] ProcessChat started for user_id: %s, message: %.50s...", req.UserID, req.Message)
-	
+	language, err := chatLanguage(req.Language, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get or create session
 	session, err := s.getOrCreateSession(req.UserID, req.SessionID)
 	if err != nil {
@@ -46,13 +135,27 @@ func (s *ChatService) ProcessChat(ctx context.Context, req ChatRequest) (*ChatRe
 		return nil, err
 	}
 	log.Printf("[INFO] Using session_id: %s for user_id: %s", session.ID, req.UserID)
+	if req.PersonaID != nil {
+		if err := selectPersona(ctx, s.db, session, req.PersonaID); err != nil {
+			return nil, err
+		}
+	}
+
+	escalation, err := s.handoff.ActiveEscalation(ctx, session.ID)
+	if err != nil {
+		log.Printf("[WARNING] Failed to check whether session %s is escalated: %v", session.ID, err)
+	}
 
-	// Save user message
+	// Save user message; it stays pending until its answer is saved (see saveAnswer)
 	userMessage := &models.ChatMessage{
 		SessionID: session.ID,
 		Role:      models.UserMessage,
 		Content:   req.Message,
 		Metadata:  "{}",
+		Status:    models.MessagePending,
+	}
+	if escalation != nil {
+		userMessage.Status = "" // Answered by the support agent
 	}
 	if err := s.db.Create(userMessage).Error; err != nil {
 		log.Printf("[ERROR] Failed to save user message to database: %v", err)
@@ -60,22 +163,39 @@ func (s *ChatService) ProcessChat(ctx context.Context, req ChatRequest) (*ChatRe
 	}
 	log.Printf("[INFO] User message saved with ID: %s", userMessage.ID)
 
+	if escalation != nil {
+		log.Printf("[INFO] Session %s is escalated to support, leaving the message to the agent", session.ID)
+		return &ChatResponse{
+			Message:    s.translator.LocalizeAnswer(ctx, handoffNotice, language),
+			SessionID:  session.ID,
+			Language:   language,
+			Escalation: escalation,
+		}, nil
+	}
+
 	// Search for relevant knowledge
 	log.Printf("[INFO] Searching knowledge base for query: %.50s...", req.Message)
-	knowledgeEntries, err := s.knowledgeService.SearchKnowledgeEntries(ctx, req.Message, 5)
+	scope := s.knowledgeService.ScopeForUser(req.UserID)
+	scope.Filters.Tags = req.Labels
+	queries := s.queryRewriter.Queries(ctx, session.ID, userMessage.ID, req.Message)
+	knowledgeEntries, citations, err := s.knowledgeService.SearchKnowledgeMultiQuery(ctx, queries, 5, scope)
 	if err != nil {
 		log.Printf("[WARNING] Knowledge search failed, continuing without context: %v", err)
 		// Log error but continue without knowledge context
 		knowledgeEntries = []models.KnowledgeEntry{}
+		citations = nil
+	} else {
+		s.analytics.RecordSearch(req.Message, models.ViewFromChat, &req.UserID, len(knowledgeEntries))
 	}
 	log.Printf("[INFO] Found %d knowledge entries for context", len(knowledgeEntries))
 
-	// Build context from knowledge entries
-	var context []string
+	// Build numbered context from knowledge entries; context[i] is cited as [i+1]
+	context := s.translator.LocalizeContext(ctx, knowledgeEntries, citationContext(knowledgeEntries), language)
 	var sources []string
+	var sourceIDs []string
 	for _, entry := range knowledgeEntries {
-		context = append(context, entry.Title+"\n"+entry.Content)
 		sources = append(sources, entry.Title)
+		sourceIDs = append(sourceIDs, entry.ID.String())
 		log.Printf("[DEBUG] Added knowledge entry to context: %s", entry.Title)
 	}
 	
@@ -99,8 +219,12 @@ func (s *ChatService) ProcessChat(ctx context.Context, req ChatRequest) (*ChatRe
 	for i := len(recentMessages) - 1; i >= 0; i-- {
 		msg := recentMessages[i]
 		if msg.ID != userMessage.ID { // Don't include the message we just created
+			role := msg.Role
+			if role == models.SupportMessage {
+				role = models.AssistantMessage // Agents answered in place of the assistant
+			}
 			openAIMessages = append(openAIMessages, OpenAIChatMessage{
-				Role:    string(msg.Role),
+				Role:    string(role),
 				Content: msg.Content,
 			})
 			log.Printf("[DEBUG] Added historical message to OpenAI context: role=%s, content=%.30s...", msg.Role, msg.Content)
@@ -120,33 +244,65 @@ func (s *ChatService) ProcessChat(ctx context.Context, req ChatRequest) (*ChatRe
 		Context:         context,
 		SessionID:       session.ID.String(),
 		UseKnowledgeBase: len(context) > 0,
+		SystemPrompt:    s.announcements.SystemPrompt(sessionPersonaPrompt(ctx, s.db, session)),
 	}
 	
 	log.Printf("[INFO] Calling OpenAI API with %d messages, knowledge_base=%t", len(openAIMessages), len(context) > 0)
 	response, err := s.openAIService.ChatCompletion(ctx, openAIReq)
 	if err != nil {
 		log.Printf("[ERROR] OpenAI API call failed: %v", err)
-		return nil, err
+		return nil, providerUnavailable(err)
 	}
 	log.Printf("[INFO] OpenAI API call successful, response length: %d characters", len(response.Message))
+	response.Message = s.translator.LocalizeAnswer(ctx, response.Message, language)
+	confidence := AnswerConfidence(citations, response.Message)
+	withheld := s.confidence.withholds(confidence)
+	var withheldAnswer string
+	if withheld {
+		log.Printf("[INFO] Answer confidence %.2f is below %.2f, answering that the bot does not know", confidence, s.confidence.Threshold)
+		withheldAnswer = response.Message
+		response.Message = localizedUnknownAnswer(ctx, s.translator, language)
+	}
 
-	// Save assistant message
+	// Save assistant message with sources and token usage
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"provider":  string(OpenAIProvider),
+		"model":     response.Model,
+		"sources":   sourceIDs,
+		"citations": citations,
+		"usage":     response.Usage,
+		"cost_usd":  EstimateCost(response.Model, response.Usage),
+		"retrieved": len(knowledgeEntries),
+		"language":  language,
+		"confidence":      confidence,
+		"withheld_answer": withheldAnswer,
+		"queries":         queries,
+	})
 	assistantMessage := &models.ChatMessage{
 		SessionID: session.ID,
 		Role:      models.AssistantMessage,
 		Content:   response.Message,
-		Metadata:  "{}", // Could store sources here as JSON
+		Metadata:  string(metadataJSON),
 	}
-	if err := s.db.Create(assistantMessage).Error; err != nil {
-		log.Printf("[ERROR] Failed to save assistant message to database: %v", err)
-		return nil, err
+	messageRef := &assistantMessage.ID
+	if err := saveAnswer(s.db, userMessage, assistantMessage); err != nil {
+		// The user still gets the answer; the question stays pending and is answered in the session later
+		log.Printf("[ERROR] Failed to save assistant message to database, the question will be replayed: %v", err)
+		messageRef = nil
+	} else {
+		log.Printf("[INFO] Assistant message saved with ID: %s", assistantMessage.ID)
 	}
-	log.Printf("[INFO] Assistant message saved with ID: %s", assistantMessage.ID)
+	s.usage.RecordChat(req.UserID, session.ID, messageRef, req.Message, OpenAIProvider, response.Model, response.Usage)
 
 	chatResponse := &ChatResponse{
 		Message:   response.Message,
 		SessionID: session.ID,
 		Sources:   sources,
+		Citations: citations,
+		SeeAlso:   s.knowledgeService.SeeAlso(ctx, knowledgeEntries, citations, scope),
+		Language:  language,
+		Confidence: confidence,
+		SuggestEscalation: withheld,
 	}
 	
 	log.Printf("[INFO] ProcessChat completed successfully for session: %s, sources: %d", session.ID, len(sources))
@@ -175,22 +331,33 @@ func (s *ChatService) GetChatSession(sessionID uuid.UUID, userID uuid.UUID) (*mo
 	}).Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error
 	if err != nil {
 		log.Printf("[ERROR] Failed to retrieve chat session %s for user %s: %v", sessionID, userID, err)
-		return nil, err
+		return nil, notFound(err, "chat session "+sessionID.String())
 	}
 	log.Printf("[INFO] Retrieved chat session %s with %d messages", sessionID, len(session.Messages))
 	return &session, nil
 }
 
-func (s *ChatService) DeleteChatSession(sessionID uuid.UUID, userID uuid.UUID) error {
+// DeleteChatSession soft-deletes a session of the user with its messages. The user can restore it within
+// ChatSessionUndoWindow; it then stays in the trash until purged.
+func (s *ChatService) DeleteChatSession(ctx context.Context, sessionID uuid.UUID, userID uuid.UUID) (*DeletedChatSession, error) {
 	log.Printf("[INFO] Deleting chat session %s for user %s", sessionID, userID)
-	err := s.db.Where("id = ? AND user_id = ?", sessionID, userID).
-		Update("is_active", false).Error
+	deleted, err := deleteChatSession(ctx, s.db, sessionID, userID)
 	if err != nil {
 		log.Printf("[ERROR] Failed to delete chat session %s for user %s: %v", sessionID, userID, err)
-		return err
+		return nil, err
 	}
-	log.Printf("[INFO] Successfully deleted chat session %s for user %s", sessionID, userID)
-	return nil
+	log.Printf("[INFO] Successfully deleted chat session %s for user %s with %d messages", sessionID, userID, deleted.Messages)
+	return deleted, nil
+}
+
+// RestoreChatSession undoes the deletion of a session of the user within ChatSessionUndoWindow
+func (s *ChatService) RestoreChatSession(ctx context.Context, sessionID uuid.UUID, userID uuid.UUID) (*models.ChatSession, error) {
+	session, err := restoreDeletedChatSession(ctx, s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Restored deleted chat session %s for user %s", sessionID, userID)
+	return session, nil
 }
 
 func (s *ChatService) getOrCreateSession(userID uuid.UUID, sessionID *uuid.UUID) (*models.ChatSession, error) {
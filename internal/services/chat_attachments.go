@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+// chatImageExtensions are the image types accepted as chat attachments, by sniffed content type
+var chatImageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// ChatImage is an image sent to a vision-capable model with a chat message
+type ChatImage struct {
+	ContentType string
+	Data        []byte
+}
+
+// ChatAttachmentService stores the images users attach to chat messages
+type ChatAttachmentService struct {
+	db       *gorm.DB
+	storage  FileStorage
+	maxBytes int64
+}
+
+// NewChatAttachmentService creates the service. Images larger than maxBytes are rejected.
+func NewChatAttachmentService(db *gorm.DB, storage FileStorage, maxBytes int64) *ChatAttachmentService {
+	return &ChatAttachmentService{db: db, storage: storage, maxBytes: maxBytes}
+}
+
+// Upload stores an image of the user, to be attached to a chat message by its ID. The type is taken from the
+// content, not from the file name.
+func (s *ChatAttachmentService) Upload(ctx context.Context, userID uuid.UUID, filename string, data []byte) (*models.ChatAttachment, error) {
+	if len(data) == 0 {
+		return nil, validationError("image is empty")
+	}
+	if s.maxBytes > 0 && int64(len(data)) > s.maxBytes {
+		return nil, validationError("image is larger than %d MB", s.maxBytes>>20)
+	}
+	contentType := http.DetectContentType(data)
+	extension, ok := chatImageExtensions[contentType]
+	if !ok {
+		return nil, validationError("only PNG, JPEG, WebP and GIF images can be attached, got %s", contentType)
+	}
+
+	attachment := &models.ChatAttachment{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	attachment.StorageKey = "chat-attachments/" + userID.String() + "/" + attachment.ID.String() + extension
+	if err := s.storage.Put(ctx, attachment.StorageKey, data, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		if deleteErr := s.storage.Delete(ctx, attachment.StorageKey); deleteErr != nil {
+			log.Printf("[WARNING] Failed to delete orphaned chat attachment %s: %v", attachment.StorageKey, deleteErr)
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// Image returns an attachment of the user with its content
+func (s *ChatAttachmentService) Image(ctx context.Context, id, userID uuid.UUID) (*models.ChatAttachment, *ChatImage, error) {
+	var attachment models.ChatAttachment
+	if err := s.db.WithContext(ctx).First(&attachment, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, nil, notFound(err, "attachment "+id.String())
+	}
+	data, err := s.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &attachment, &ChatImage{ContentType: attachment.ContentType, Data: data}, nil
+}
+
+// openAIChatMessage converts a chat message for OpenAI-compatible APIs, sending its images as data URLs
+// next to the text
+func openAIChatMessage(role, content string, images []ChatImage) openai.ChatCompletionMessage {
+	if len(images) == 0 {
+		return openai.ChatCompletionMessage{Role: role, Content: content}
+	}
+	parts := []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: content}}
+	for _, image := range images {
+		parts = append(parts, openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{
+				URL:    "data:" + image.ContentType + ";base64," + base64.StdEncoding.EncodeToString(image.Data),
+				Detail: openai.ImageURLDetailAuto,
+			},
+		})
+	}
+	return openai.ChatCompletionMessage{Role: role, MultiContent: parts}
+}
+
+// geminiImageFormat is the format genai.ImageData expects for a content type, e.g. "png" for image/png
+func geminiImageFormat(contentType string) string {
+	return strings.TrimPrefix(contentType, "image/")
+}
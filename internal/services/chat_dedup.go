@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChatDeduplicator collapses identical chat requests submitted within a short window,
+// e.g. a double-clicked send button. A duplicate of an in-flight request waits for it
+// and a duplicate of a just-completed request gets the same response, so the provider
+// is called only once.
+type ChatDeduplicator struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*chatDedupEntry
+}
+
+type chatDedupEntry struct {
+	done        chan struct{}
+	response    interface{}
+	err         error
+	completedAt time.Time
+}
+
+// NewChatDeduplicator creates a deduplicator that remembers completed responses for window
+func NewChatDeduplicator(window time.Duration) *ChatDeduplicator {
+	return &ChatDeduplicator{
+		window:  window,
+		entries: make(map[string]*chatDedupEntry),
+	}
+}
+
+// Do runs process unless an identical request is in flight or completed within the window,
+// in which case that request's response is returned and duplicate is true.
+// Failed requests are forgotten right away so that they can be retried.
+func (d *ChatDeduplicator) Do(ctx context.Context, key string, process func() (interface{}, error)) (response interface{}, duplicate bool, err error) {
+	if d == nil {
+		response, err = process()
+		return response, false, err
+	}
+
+	d.mu.Lock()
+	d.evictExpired()
+	if entry, ok := d.entries[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		return entry.response, true, entry.err
+	}
+	entry := &chatDedupEntry{done: make(chan struct{})}
+	d.entries[key] = entry
+	d.mu.Unlock()
+
+	response, err = process()
+
+	d.mu.Lock()
+	entry.response, entry.err, entry.completedAt = response, err, time.Now()
+	if err != nil {
+		delete(d.entries, key)
+	}
+	d.mu.Unlock()
+	close(entry.done)
+
+	return response, false, err
+}
+
+// evictExpired drops completed entries older than the window. Callers hold mu.
+func (d *ChatDeduplicator) evictExpired() {
+	for key, entry := range d.entries {
+		if !entry.completedAt.IsZero() && time.Since(entry.completedAt) > d.window {
+			delete(d.entries, key)
+		}
+	}
+}
+
+// chatDedupKey identifies a chat request by its session (or user, for a new session),
+// the trimmed message, and every option that changes the answer
+func chatDedupKey(req interface{}, message string) string {
+	options, _ := json.Marshal(req)
+	hash := sha256.New()
+	hash.Write(options)
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.TrimSpace(message)))
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// chatKey is the deduplication key ChatService.ProcessChat uses for req
+func chatKey(req ChatRequest) string {
+	keyReq := req
+	keyReq.Message = ""
+	return chatDedupKey(keyReq, req.Message)
+}
+
+func TestChatDedupKey(t *testing.T) {
+	user, session, otherSession := uuid.New(), uuid.New(), uuid.New()
+	base := ChatRequest{Message: "How do I print labels?", SessionID: &session, UserID: user}
+	with := func(change func(req *ChatRequest)) ChatRequest {
+		req := base
+		change(&req)
+		return req
+	}
+
+	tests := []struct {
+		name string
+		req  ChatRequest
+		same bool
+	}{
+		{"identical request", with(func(*ChatRequest) {}), true},
+		{"surrounding whitespace", with(func(req *ChatRequest) { req.Message = "  How do I print labels?\n" }), true},
+		{"other message", with(func(req *ChatRequest) { req.Message = "How do I print receipts?" }), false},
+		{"other case", with(func(req *ChatRequest) { req.Message = "how do i print labels?" }), false},
+		{"other session", with(func(req *ChatRequest) { req.SessionID = &otherSession }), false},
+		{"new session", with(func(req *ChatRequest) { req.SessionID = nil }), false},
+		{"other user", with(func(req *ChatRequest) { req.UserID = uuid.New() }), false},
+		{"other language", with(func(req *ChatRequest) { req.Language = "de" }), false},
+		{"other labels", with(func(req *ChatRequest) { req.Labels = []string{"zebra"} }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := chatKey(tt.req) == chatKey(base); same != tt.same {
+				t.Errorf("same key %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestChatDeduplicatorWindow(t *testing.T) {
+	tests := []struct {
+		name          string
+		window        time.Duration
+		gap           time.Duration // Between the end of the first request and the second
+		failFirst     bool
+		otherKey      bool
+		wantCalls     int32
+		wantDuplicate bool
+	}{
+		{name: "duplicate within the window", window: time.Minute, wantCalls: 1, wantDuplicate: true},
+		{name: "repeat after the window", window: 20 * time.Millisecond, gap: 50 * time.Millisecond, wantCalls: 2},
+		{name: "retry of a failed request", window: time.Minute, failFirst: true, wantCalls: 2},
+		{name: "other request within the window", window: time.Minute, otherKey: true, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dedup := NewChatDeduplicator(tt.window)
+			var calls atomic.Int32
+			process := func() (interface{}, error) {
+				if calls.Add(1) == 1 && tt.failFirst {
+					return nil, errors.New("provider unavailable")
+				}
+				return calls.Load(), nil
+			}
+
+			key := "session-1\x00How do I print labels?"
+			dedup.Do(context.Background(), key, process)
+			time.Sleep(tt.gap)
+			if tt.otherKey {
+				key = "session-2\x00How do I print labels?"
+			}
+			response, duplicate, err := dedup.Do(context.Background(), key, process)
+			if err != nil {
+				t.Fatalf("second request: %v", err)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d calls, want %d", got, tt.wantCalls)
+			}
+			if duplicate != tt.wantDuplicate {
+				t.Errorf("duplicate %v, want %v", duplicate, tt.wantDuplicate)
+			}
+			if want := tt.wantCalls; response != want {
+				t.Errorf("response of call %v, want call %d", response, want)
+			}
+		})
+	}
+}
+
+func TestChatDeduplicatorWaitsForTheRequestInFlight(t *testing.T) {
+	dedup := NewChatDeduplicator(time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	go dedup.Do(context.Background(), "key", func() (interface{}, error) {
+		calls.Add(1)
+		close(started)
+		<-release
+		return "answer", nil
+	})
+	<-started
+
+	// A duplicate whose client gives up stops waiting
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := dedup.Do(ctx, "key", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err %v, want the wait to end with the context", err)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	response, duplicate, err := dedup.Do(context.Background(), "key", func() (interface{}, error) {
+		calls.Add(1)
+		return "second answer", nil
+	})
+	if err != nil || !duplicate || response != "answer" {
+		t.Errorf("got %v, duplicate %v, err %v; want the answer of the request in flight", response, duplicate, err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d calls, want 1", got)
+	}
+}
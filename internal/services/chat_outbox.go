@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// QueueReasonUnanswered marks questions replayed because the chat that saved them failed before saving an answer
+const QueueReasonUnanswered = "unanswered"
+
+const (
+	// unansweredReplayGrace leaves questions alone while their chat may still be answering them
+	unansweredReplayGrace = 5 * time.Minute
+	// unansweredReplayMaxAge stops replaying questions the user has most likely given up on
+	unansweredReplayMaxAge = 24 * time.Hour
+	unansweredReplayBatch  = 100
+)
+
+// UnansweredReplay reports a replay of unanswered questions
+type UnansweredReplay struct {
+	Queued int `json:"queued"`
+	Failed int `json:"failed"`
+}
+
+// saveAnswer saves an assistant message and marks the question it answers answered in one transaction,
+// so that a question is either answered in its session or left pending to be replayed
+func saveAnswer(db *gorm.DB, question, answer *models.ChatMessage) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(answer).Error; err != nil {
+			return err
+		}
+		return markAnswered(tx, question.ID)
+	})
+}
+
+// markAnswered marks a pending user question answered
+func markAnswered(tx *gorm.DB, messageID uuid.UUID) error {
+	return tx.Model(&models.ChatMessage{}).
+		Where("id = ? AND status = ?", messageID, models.MessagePending).
+		Update("status", models.MessageAnswered).Error
+}
+
+// ReplayUnanswered queues the user questions whose chat failed before saving an answer, e.g. because the
+// server stopped or the database failed while the answer was generated. They are answered in their session
+// in the background and the user is notified, like questions queued while every provider was down.
+func (s *DeferredAnswerService) ReplayUnanswered(ctx context.Context) (*UnansweredReplay, error) {
+	now := time.Now()
+	var pending []struct {
+		ID        uuid.UUID
+		SessionID uuid.UUID
+		UserID    uuid.UUID
+		Content   string
+	}
+	err := s.db.WithContext(ctx).Table("chat_messages").
+		Select("chat_messages.id, chat_messages.session_id, chat_sessions.user_id, chat_messages.content").
+		Joins("JOIN chat_sessions ON chat_sessions.id = chat_messages.session_id AND chat_sessions.deleted_at IS NULL").
+		Where("chat_messages.status = ? AND chat_messages.deleted_at IS NULL", models.MessagePending).
+		Where("chat_messages.created_at BETWEEN ? AND ?", now.Add(-unansweredReplayMaxAge), now.Add(-unansweredReplayGrace)).
+		Order("chat_messages.created_at").
+		Limit(unansweredReplayBatch).
+		Scan(&pending).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unanswered questions: %w", err)
+	}
+
+	replay := &UnansweredReplay{}
+	for _, message := range pending {
+		messageID := message.ID
+		if _, err := s.QueueQuestion(ctx, message.SessionID, message.UserID, &messageID, message.Content, QueueReasonUnanswered); err != nil {
+			log.Printf("[WARNING] Failed to replay unanswered message %s: %v", message.ID, err)
+			replay.Failed++
+			continue
+		}
+		replay.Queued++
+	}
+	if replay.Queued > 0 || replay.Failed > 0 {
+		log.Printf("[INFO] Replayed unanswered questions: %d queued, %d failed", replay.Queued, replay.Failed)
+	}
+	return replay, nil
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// regeneratedAnswer is an answer generated again for a question already in its session
+type regeneratedAnswer struct {
+	question   string
+	response   *UnifiedChatResponse
+	metadata   string
+	sources    []string
+	citations  []Citation
+	entries    []models.KnowledgeEntry
+	scope      RetrievalScope
+	confidence float64
+	withheld   bool
+	language   string
+}
+
+// EditMessage replaces the last question of a session and answers it again. The previous question and
+// answer are kept as revisions of their messages.
+func (s *EnhancedChatService) EditMessage(ctx context.Context, userID, messageID uuid.UUID, content string, provider AIProvider) (*EnhancedChatResponse, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, validationError("content is required")
+	}
+	question, session, err := s.ownedMessage(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if question.Role != models.UserMessage {
+		return nil, validationError("only questions can be edited; regenerate an answer instead")
+	}
+	answer, err := s.answerOf(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireLastExchange(ctx, session, question, answer); err != nil {
+		return nil, err
+	}
+
+	content, err = s.guardrails.ProcessInput(ctx, userID, &session.ID, content)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := s.regenerate(ctx, session, question, content, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := reviseMessage(tx, question, content, question.Metadata); err != nil {
+			return err
+		}
+		if err := tx.Model(question).Update("status", models.MessageAnswered).Error; err != nil {
+			return err
+		}
+		if answer == nil {
+			answer = &models.ChatMessage{
+				SessionID: session.ID,
+				Role:      models.AssistantMessage,
+				Content:   generated.response.Message,
+				Metadata:  generated.metadata,
+			}
+			return tx.Create(answer).Error
+		}
+		return reviseMessage(tx, answer, generated.response.Message, generated.metadata)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to save edited message %s: %v", messageID, err)
+		return nil, err
+	}
+
+	log.Printf("[INFO] User %s edited message %s (version %d) and got a new answer from %s", userID, messageID, question.Version, generated.response.Provider)
+	return s.regeneratedResponse(ctx, session, answer, generated), nil
+}
+
+// RegenerateAnswer answers the last question of a session again, optionally with another provider. The previous
+// answer is kept as a revision of the message.
+func (s *EnhancedChatService) RegenerateAnswer(ctx context.Context, userID, messageID uuid.UUID, provider AIProvider) (*EnhancedChatResponse, error) {
+	answer, session, err := s.ownedMessage(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if answer.Role != models.AssistantMessage {
+		return nil, validationError("only answers of the assistant can be regenerated")
+	}
+	var question models.ChatMessage
+	err = s.db.WithContext(ctx).Where("session_id = ? AND role = ? AND created_at < ?", session.ID, models.UserMessage, answer.CreatedAt).
+		Order("created_at DESC").First(&question).Error
+	if err != nil {
+		return nil, notFound(err, "question answered by message "+messageID.String())
+	}
+	if err := s.requireLastExchange(ctx, session, &question, answer); err != nil {
+		return nil, err
+	}
+
+	generated, err := s.regenerate(ctx, session, &question, question.Content, provider)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return reviseMessage(tx, answer, generated.response.Message, generated.metadata)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to save regenerated answer %s: %v", messageID, err)
+		return nil, err
+	}
+
+	log.Printf("[INFO] User %s regenerated answer %s (version %d) with %s", userID, messageID, answer.Version, generated.response.Provider)
+	return s.regeneratedResponse(ctx, session, answer, generated), nil
+}
+
+// MessageRevisions lists the superseded versions of a message of the user, oldest first
+func (s *EnhancedChatService) MessageRevisions(ctx context.Context, userID, messageID uuid.UUID) ([]models.ChatMessageRevision, error) {
+	if _, _, err := s.ownedMessage(ctx, userID, messageID); err != nil {
+		return nil, err
+	}
+	var revisions []models.ChatMessageRevision
+	if err := s.db.WithContext(ctx).Where("message_id = ?", messageID).Order("version").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// ownedMessage loads a message of an active session of the user
+func (s *EnhancedChatService) ownedMessage(ctx context.Context, userID, messageID uuid.UUID) (*models.ChatMessage, *models.ChatSession, error) {
+	var message models.ChatMessage
+	if err := s.db.WithContext(ctx).First(&message, "id = ?", messageID).Error; err != nil {
+		return nil, nil, notFound(err, "chat message "+messageID.String())
+	}
+	var session models.ChatSession
+	err := s.db.WithContext(ctx).Where("id = ? AND user_id = ? AND is_active = ?", message.SessionID, userID, true).First(&session).Error
+	if err != nil {
+		// Messages of other users' sessions are reported as missing
+		return nil, nil, notFound(err, "chat message "+messageID.String())
+	}
+	return &message, &session, nil
+}
+
+// answerOf returns the assistant answer that follows a question, or nil when it was not answered
+func (s *EnhancedChatService) answerOf(ctx context.Context, question *models.ChatMessage) (*models.ChatMessage, error) {
+	var answer models.ChatMessage
+	err := s.db.WithContext(ctx).Where("session_id = ? AND created_at > ?", question.SessionID, question.CreatedAt).
+		Order("created_at").First(&answer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if answer.Role != models.AssistantMessage {
+		return nil, validationError("only the last question of a session can be edited")
+	}
+	return &answer, nil
+}
+
+// requireLastExchange checks that a question and its answer end the session and that the assistant still
+// answers it, so that changing them does not rewrite the context of later messages
+func (s *EnhancedChatService) requireLastExchange(ctx context.Context, session *models.ChatSession, question, answer *models.ChatMessage) error {
+	last := question
+	if answer != nil {
+		last = answer
+	}
+	var later int64
+	err := s.db.WithContext(ctx).Model(&models.ChatMessage{}).
+		Where("session_id = ? AND created_at > ? AND id <> ?", session.ID, last.CreatedAt, last.ID).Count(&later).Error
+	if err != nil {
+		return err
+	}
+	if later > 0 {
+		return validationError("only the last question and answer of a session can be changed")
+	}
+
+	escalation, err := s.handoff.ActiveEscalation(ctx, session.ID)
+	if err != nil {
+		log.Printf("[WARNING] Failed to check whether session %s is escalated: %v", session.ID, err)
+	}
+	if escalation != nil {
+		return validationError("session %s is handed over to a support agent", session.ID)
+	}
+	return nil
+}
+
+// regenerate answers a question from the knowledge base and the history before it, the way the chat does
+func (s *EnhancedChatService) regenerate(ctx context.Context, session *models.ChatSession, question *models.ChatMessage, content string, provider AIProvider) (*regeneratedAnswer, error) {
+	if provider != "" && !slices.Contains(s.unifiedAIService.GetAvailableProviders(), provider) {
+		return nil, validationError("provider %q is not available", provider)
+	}
+	if err := s.quotas.Check(session.UserID); err != nil {
+		return nil, err
+	}
+	language, err := chatLanguage("", content)
+	if err != nil {
+		language = ""
+	}
+
+	generated := &regeneratedAnswer{question: content, scope: s.knowledgeService.ScopeForUser(session.UserID), language: language}
+	queries := s.queryRewriter.Queries(ctx, session.ID, question.ID, content)
+	generated.entries, generated.citations, err = s.knowledgeService.SearchKnowledgeMultiQuery(ctx, queries, 3, generated.scope)
+	if err != nil {
+		log.Printf("[WARNING] Knowledge search failed, continuing without context: %v", err)
+	}
+	for _, entry := range generated.entries {
+		generated.sources = append(generated.sources, entry.ID.String())
+	}
+	context := s.translator.LocalizeContext(ctx, generated.entries, citationContext(generated.entries), language)
+
+	var history []models.ChatMessage
+	err = s.db.WithContext(ctx).Where("session_id = ? AND created_at < ?", session.ID, question.CreatedAt).
+		Order("created_at DESC").Limit(10).Find(&history).Error
+	if err != nil {
+		log.Printf("[WARNING] Failed to get recent messages: %v", err)
+	}
+	slices.Reverse(history)
+	var messages []UnifiedChatMessage
+	for _, msg := range history {
+		role := string(msg.Role)
+		if role == "assistant" || role == "support" {
+			role = "model" // Gemini uses "model" instead of "assistant"
+		}
+		messages = append(messages, UnifiedChatMessage{Role: role, Content: msg.Content})
+	}
+	messages = append(messages, UnifiedChatMessage{Role: "user", Content: content})
+
+	generated.response, err = s.unifiedAIService.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages:          messages,
+		Context:           context,
+		SessionID:         session.ID.String(),
+		UseKnowledgeBase:  len(context) > 0,
+		SystemPrompt:      s.announcements.SystemPrompt(sessionPersonaPrompt(ctx, s.db, session)),
+		PreferredProvider: provider,
+	})
+	if err != nil {
+		log.Printf("[ERROR] AI API call failed while regenerating an answer in session %s: %v", session.ID, err)
+		return nil, err
+	}
+
+	response := generated.response
+	response.Message = s.translator.LocalizeAnswer(ctx, response.Message, language)
+	var moderated bool
+	response.Message, moderated = s.guardrails.ProcessOutput(ctx, session.UserID, &session.ID, response.Message)
+	generated.confidence = AnswerConfidence(generated.citations, response.Message)
+	generated.withheld = s.confidence.withholds(generated.confidence)
+	var withheldAnswer string
+	if generated.withheld {
+		withheldAnswer = response.Message
+		response.Message = localizedUnknownAnswer(ctx, s.translator, language)
+	}
+	generated.metadata = buildMessageMetadata(response.Provider, response.Model, generated.sources, map[string]interface{}{
+		"usage":           response.Usage,
+		"cost_usd":        EstimateCost(response.Model, response.Usage),
+		"retrieved":       len(generated.entries),
+		"citations":       generated.citations,
+		"moderated":       moderated,
+		"language":        language,
+		"confidence":      generated.confidence,
+		"withheld_answer": withheldAnswer,
+		"queries":         queries,
+		"regenerated":     true,
+	})
+	return generated, nil
+}
+
+// regeneratedResponse records the usage of a saved regenerated answer and builds the chat response
+func (s *EnhancedChatService) regeneratedResponse(ctx context.Context, session *models.ChatSession, answer *models.ChatMessage, generated *regeneratedAnswer) *EnhancedChatResponse {
+	response := generated.response
+	s.usage.RecordChat(session.UserID, session.ID, &answer.ID, generated.question, response.Provider, response.Model, response.Usage)
+	return &EnhancedChatResponse{
+		Response:  response.Message,
+		SessionID: session.ID,
+		MessageID: answer.ID,
+		Version:   answer.Version,
+		Sources:   generated.sources,
+		Provider:  response.Provider,
+		Model:     response.Model,
+		CreatedAt: time.Now().Format("2006-01-02T15:04:05Z"),
+		Citations: generated.citations,
+		Language:  generated.language,
+		SeeAlso:   s.knowledgeService.SeeAlso(ctx, generated.entries, generated.citations, generated.scope),
+
+		Confidence:        generated.confidence,
+		SuggestEscalation: generated.withheld,
+	}
+}
+
+// reviseMessage keeps the current version of a message as a revision and replaces its content
+func reviseMessage(tx *gorm.DB, message *models.ChatMessage, content, metadata string) error {
+	revision := &models.ChatMessageRevision{
+		MessageID: message.ID,
+		Version:   message.Version,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+	}
+	if err := tx.Create(revision).Error; err != nil {
+		return err
+	}
+	message.Content = content
+	message.Metadata = metadata
+	message.Version++
+	return tx.Model(message).Updates(map[string]interface{}{
+		"content":  message.Content,
+		"metadata": message.Metadata,
+		"version":  message.Version,
+	}).Error
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeChatRetention is the job type of a chat retention run
+const JobTypeChatRetention = "chat_retention"
+
+// RetentionTriggerManual is the trigger of runs of a single policy queued on request
+const RetentionTriggerManual = "manual"
+
+const retentionBatchSize = 100
+
+// ErrRetentionAdminOnly is returned when a non-admin manages chat retention policies
+var ErrRetentionAdminOnly = fmt.Errorf("%w: only admins can manage chat retention policies", ErrForbidden)
+
+// RetentionReport describes what a retention run found and removed
+type RetentionReport struct {
+	PolicyID        uuid.UUID              `json:"policy_id"`
+	PolicyName      string                 `json:"policy_name"`
+	Action          models.RetentionAction `json:"action"`
+	DryRun          bool                   `json:"dry_run"`
+	Cutoff          time.Time              `json:"cutoff"`
+	Sessions        int                    `json:"sessions"`
+	Messages        int64                  `json:"messages"`
+	QueuedQuestions int64                  `json:"queued_questions"`
+	TrackedChatLogs int64                  `json:"tracked_chat_logs"`
+	ThreadsDeleted  int                    `json:"threads_deleted"`
+	ThreadsFailed   int                    `json:"threads_failed"`
+	Exported        int                    `json:"exported"`
+	ExportDir       string                 `json:"export_dir,omitempty"`
+	StartedAt       time.Time              `json:"started_at"`
+	CompletedAt     time.Time              `json:"completed_at"`
+}
+
+type chatRetentionPayload struct {
+	Trigger  string     `json:"trigger"`
+	PolicyID *uuid.UUID `json:"policy_id,omitempty"`
+}
+
+// ChatRetentionService applies the chat retention policies: sessions and messages older than a policy
+// allows are archived or deleted, optionally after exporting their transcripts
+type ChatRetentionService struct {
+	db          *gorm.DB
+	chatService *ChatService
+	gcService   *OpenAIGCService
+	jobQueue    *JobQueue
+	exportDir   string
+	storage     FileStorage
+}
+
+// NewChatRetentionService creates the retention service. Transcripts are exported under exportDir.
+// Every active policy is applied by the chat_retention task of the scheduler.
+func NewChatRetentionService(db *gorm.DB, chatService *ChatService, gcService *OpenAIGCService, jobQueue *JobQueue, exportDir string) *ChatRetentionService {
+	return &ChatRetentionService{
+		db:          db,
+		chatService: chatService,
+		gcService:   gcService,
+		jobQueue:    jobQueue,
+		exportDir:   exportDir,
+	}
+}
+
+// SetStorage lets delete policies remove the files of the attachments of the sessions they delete
+func (s *ChatRetentionService) SetStorage(storage FileStorage) {
+	s.storage = storage
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *ChatRetentionService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeChatRetention, s.handleRetentionJob)
+}
+
+// ListPolicies lists the retention policies
+func (s *ChatRetentionService) ListPolicies() ([]models.ChatRetentionPolicy, error) {
+	var policies []models.ChatRetentionPolicy
+	if err := s.db.Order("created_at ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// CreatePolicy validates and saves a retention policy. adminID must belong to an admin.
+func (s *ChatRetentionService) CreatePolicy(policy *models.ChatRetentionPolicy, adminID uuid.UUID) error {
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+	if err := validateRetentionPolicy(policy); err != nil {
+		return err
+	}
+	policy.CreatedBy = adminID
+
+	if err := s.db.Create(policy).Error; err != nil {
+		return err
+	}
+	log.Printf("[INFO] Admin %s created chat retention policy %s: %s after %d months (team=%q)",
+		adminID, policy.ID, policy.Action, policy.MaxAgeMonths, policy.Team)
+	return nil
+}
+
+// DeletePolicy removes a retention policy. adminID must belong to an admin.
+func (s *ChatRetentionService) DeletePolicy(id, adminID uuid.UUID) error {
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+	result := s.db.Delete(&models.ChatRetentionPolicy{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "chat retention policy "+id.String())
+	}
+	log.Printf("[INFO] Admin %s deleted chat retention policy %s", adminID, id)
+	return nil
+}
+
+// PreviewPolicy reports what applying a policy now would remove, without changing anything
+func (s *ChatRetentionService) PreviewPolicy(ctx context.Context, id, adminID uuid.UUID) (*RetentionReport, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	policy, err := s.getPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Apply(ctx, policy, true)
+}
+
+// ScheduleRun queues a job that applies a policy. adminID must belong to an admin.
+func (s *ChatRetentionService) ScheduleRun(ctx context.Context, id, adminID uuid.UUID) (*models.Job, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	if _, err := s.getPolicy(id); err != nil {
+		return nil, err
+	}
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeChatRetention, chatRetentionPayload{Trigger: RetentionTriggerManual, PolicyID: &id}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// ApplyActivePolicies applies every active policy, oldest first. A failing policy does not stop the others.
+func (s *ChatRetentionService) ApplyActivePolicies(ctx context.Context) ([]*RetentionReport, error) {
+	var policies []models.ChatRetentionPolicy
+	if err := s.db.Where("is_active = ?", true).Order("created_at ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	reports := make([]*RetentionReport, 0, len(policies))
+	var failed []string
+	for i := range policies {
+		report, err := s.Apply(ctx, &policies[i], false)
+		if err != nil {
+			log.Printf("[ERROR] Chat retention policy %s failed: %v", policies[i].ID, err)
+			failed = append(failed, policies[i].Name)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	if len(failed) > 0 {
+		return reports, fmt.Errorf("chat retention failed for policies: %s", strings.Join(failed, ", "))
+	}
+	return reports, nil
+}
+
+// handleRetentionJob applies the policy of a run queued on request
+func (s *ChatRetentionService) handleRetentionJob(ctx context.Context, job *models.Job) error {
+	var payload chatRetentionPayload
+	if err := DecodeJobPayload(job, &payload); err != nil {
+		return err
+	}
+	if payload.PolicyID == nil {
+		// Queued by the retention interval of earlier versions
+		_, err := s.ApplyActivePolicies(ctx)
+		return err
+	}
+
+	policy, err := s.getPolicy(*payload.PolicyID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Apply(ctx, policy, false)
+	return err
+}
+
+// Apply archives or deletes the sessions covered by a policy that have had no message since the cutoff.
+// Tracked chat logs and assistant threads are not linked to users, so only deleting policies that apply
+// to every user remove them. With dryRun set nothing is changed and the report counts what would be removed.
+func (s *ChatRetentionService) Apply(ctx context.Context, policy *models.ChatRetentionPolicy, dryRun bool) (*RetentionReport, error) {
+	report := &RetentionReport{
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		Action:     policy.Action,
+		DryRun:     dryRun,
+		Cutoff:     time.Now().AddDate(0, -policy.MaxAgeMonths, 0),
+		StartedAt:  time.Now(),
+	}
+	if policy.ExportBeforeDelete && !dryRun {
+		report.ExportDir = filepath.Join(s.exportDir, policy.ID.String(), report.StartedAt.Format("20060102-150405"))
+		if err := os.MkdirAll(report.ExportDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create export directory: %w", err)
+		}
+	}
+
+	db := s.db.WithContext(ctx)
+	if policy.Action == models.RetentionDelete {
+		db = db.Unscoped().Session(&gorm.Session{}) // Sessions archived earlier are deleted too
+	}
+
+	// Sessions are removed as they are processed, so the first page is always the next batch
+	var processed []uuid.UUID
+	for {
+		var sessionIDs []uuid.UUID
+		query := s.expiredSessions(db, policy, report.Cutoff)
+		if len(processed) > 0 {
+			query = query.Where("chat_sessions.id NOT IN ?", processed)
+		}
+		if err := query.Order("chat_sessions.created_at ASC").Limit(retentionBatchSize).Pluck("chat_sessions.id", &sessionIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(sessionIDs) == 0 {
+			break
+		}
+
+		if err := s.applyBatch(db, policy, report, sessionIDs); err != nil {
+			return nil, err
+		}
+		report.Sessions += len(sessionIDs)
+		if dryRun {
+			processed = append(processed, sessionIDs...)
+		}
+	}
+
+	if policy.Action == models.RetentionDelete && policy.Team == "" {
+		if err := s.deleteProviderData(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+
+	report.CompletedAt = time.Now()
+	if !dryRun {
+		if err := s.db.Model(policy).UpdateColumn("last_run_at", report.CompletedAt).Error; err != nil {
+			log.Printf("[WARNING] Failed to record run of chat retention policy %s: %v", policy.ID, err)
+		}
+	}
+	log.Printf("[INFO] Chat retention policy %s (%s, dry_run=%t): %d sessions, %d messages, %d tracked chat logs, %d threads",
+		policy.Name, policy.Action, dryRun, report.Sessions, report.Messages, report.TrackedChatLogs, report.ThreadsDeleted)
+	return report, nil
+}
+
+// expiredSessions selects the sessions of a policy without any message since the cutoff
+func (s *ChatRetentionService) expiredSessions(db *gorm.DB, policy *models.ChatRetentionPolicy, cutoff time.Time) *gorm.DB {
+	query := db.Model(&models.ChatSession{}).
+		Where("chat_sessions.created_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM chat_messages WHERE chat_messages.session_id = chat_sessions.id AND chat_messages.created_at >= ?)", cutoff)
+	if policy.Team != "" {
+		query = query.Where("chat_sessions.user_id IN (SELECT id FROM users WHERE ? = ANY(string_to_array(users.teams, ',')))", policy.Team)
+	}
+	return query
+}
+
+// applyBatch exports and removes a batch of sessions, or only counts them in a dry run. Deleted sessions are
+// purged with every record referencing them, as the trash purges them.
+func (s *ChatRetentionService) applyBatch(db *gorm.DB, policy *models.ChatRetentionPolicy, report *RetentionReport, sessionIDs []uuid.UUID) error {
+	var messages, queued int64
+	if err := db.Model(&models.ChatMessage{}).Where("session_id IN ?", sessionIDs).Count(&messages).Error; err != nil {
+		return err
+	}
+	if policy.Action == models.RetentionDelete {
+		if err := db.Model(&models.QueuedQuestion{}).Where("session_id IN ?", sessionIDs).Count(&queued).Error; err != nil {
+			return err
+		}
+	}
+	report.Messages += messages
+	report.QueuedQuestions += queued
+	if report.DryRun {
+		return nil
+	}
+
+	if report.ExportDir != "" {
+		for _, sessionID := range sessionIDs {
+			if err := s.exportTranscript(db, report.ExportDir, sessionID); err != nil {
+				return fmt.Errorf("failed to export session %s, nothing was removed: %w", sessionID, err)
+			}
+			report.Exported++
+		}
+	}
+
+	if policy.Action == models.RetentionArchive {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.ChatMessage{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", sessionIDs).Delete(&models.ChatSession{}).Error
+		})
+	}
+
+	var attachmentKeys []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, sessionID := range sessionIDs {
+			keys, err := purgeChatSessionTx(tx, sessionID)
+			if err != nil {
+				return err
+			}
+			attachmentKeys = append(attachmentKeys, keys...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	deleteAttachmentFiles(db.Statement.Context, s.storage, attachmentKeys)
+	return nil
+}
+
+// exportTranscript writes the JSON transcript of a session to dir
+func (s *ChatRetentionService) exportTranscript(db *gorm.DB, dir string, sessionID uuid.UUID) error {
+	transcript, err := s.chatService.exportSession(db, sessionID)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, sessionID.String()+".json"), encoded, 0644)
+}
+
+// deleteProviderData deletes the tracked chat logs and assistant threads older than the cutoff
+func (s *ChatRetentionService) deleteProviderData(ctx context.Context, report *RetentionReport) error {
+	logs := s.db.WithContext(ctx).Model(&models.TrackedChatLog{}).Where("created_at < ?", report.Cutoff)
+	if report.DryRun {
+		if err := logs.Count(&report.TrackedChatLogs).Error; err != nil {
+			return err
+		}
+	} else {
+		result := logs.Delete(&models.TrackedChatLog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		report.TrackedChatLogs = result.RowsAffected
+	}
+
+	if s.gcService == nil {
+		return nil
+	}
+	threads, err := s.gcService.DeleteIdleThreads(ctx, report.Cutoff, report.DryRun)
+	if err != nil {
+		log.Printf("[WARNING] Not deleting assistant threads for chat retention: %v", err)
+		return nil
+	}
+	report.ThreadsDeleted = threads.Deleted
+	report.ThreadsFailed = threads.Failed
+	if report.DryRun {
+		report.ThreadsDeleted = len(threads.Orphans)
+	}
+	return nil
+}
+
+func (s *ChatRetentionService) getPolicy(id uuid.UUID) (*models.ChatRetentionPolicy, error) {
+	var policy models.ChatRetentionPolicy
+	if err := s.db.First(&policy, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "chat retention policy "+id.String())
+	}
+	return &policy, nil
+}
+
+// validateRetentionPolicy trims and checks the editable settings of a policy
+func validateRetentionPolicy(policy *models.ChatRetentionPolicy) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	policy.Team = strings.TrimSpace(policy.Team)
+	if policy.Name == "" {
+		return validationError("name is required")
+	}
+	if policy.MaxAgeMonths < 1 {
+		return validationError("max_age_months must be at least 1")
+	}
+	if policy.Action != models.RetentionArchive && policy.Action != models.RetentionDelete {
+		return validationError("action must be %s or %s", models.RetentionArchive, models.RetentionDelete)
+	}
+	return nil
+}
+
+func (s *ChatRetentionService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRetentionAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrRetentionAdminOnly
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+)
+
+func TestRetentionDeletePurgesEscalatedSessions(t *testing.T) {
+	tx := testDB(t)
+	ctx := context.Background()
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := createChatFixture(t, tx, storage)
+	if err := tx.Model(f.user).Update("teams", "retention-test").Error; err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(0, -3, 0)
+	tx.Model(&models.ChatSession{}).Where("id = ?", f.session.ID).UpdateColumn("created_at", old)
+	tx.Model(&models.ChatMessage{}).Where("session_id = ?", f.session.ID).UpdateColumn("created_at", old)
+
+	policy := &models.ChatRetentionPolicy{Name: "Delete after a month", Team: "retention-test", MaxAgeMonths: 1,
+		Action: models.RetentionDelete, IsActive: true, CreatedBy: f.user.ID}
+	mustCreate(t, tx, policy)
+
+	retention := NewChatRetentionService(tx, nil, nil, nil, t.TempDir())
+	retention.SetStorage(storage)
+	report, err := retention.Apply(ctx, policy, false)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if report.Sessions != 1 || report.Messages != 2 {
+		t.Errorf("deleted %d sessions and %d messages, want 1 and 2", report.Sessions, report.Messages)
+	}
+
+	var left int64
+	tx.Unscoped().Model(&models.ChatSession{}).Where("id = ?", f.session.ID).Count(&left)
+	if left != 0 {
+		t.Error("session left after the delete policy ran")
+	}
+	tx.Model(&models.ChatEscalation{}).Where("session_id = ?", f.session.ID).Count(&left)
+	if left != 0 {
+		t.Error("escalation left after the delete policy ran")
+	}
+	tx.Model(&models.ChatMessageRevision{}).Where("message_id = ?", f.answer.ID).Count(&left)
+	if left != 0 {
+		t.Error("message revision left after the delete policy ran")
+	}
+}
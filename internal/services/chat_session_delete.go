@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChatSessionUndoWindow is how long the owner of a deleted chat session can restore it. Admins can restore
+// it from the trash until it is purged.
+const ChatSessionUndoWindow = 10 * time.Minute
+
+// DeletedChatSession reports a deleted chat session
+type DeletedChatSession struct {
+	ID        uuid.UUID `json:"id"`
+	Messages  int64     `json:"messages"` // Messages deleted with the session
+	DeletedAt time.Time `json:"deleted_at"`
+	UndoUntil time.Time `json:"undo_until"` // Until when POST /chat/sessions/{id}/restore brings it back
+}
+
+// deleteChatSession soft-deletes a session of a user together with its messages. The session is also marked
+// inactive, which tells a deletion by its user, purged with the trash, from archiving by a retention policy.
+func deleteChatSession(ctx context.Context, db *gorm.DB, sessionID, userID uuid.UUID) (*DeletedChatSession, error) {
+	deleted := &DeletedChatSession{ID: sessionID, DeletedAt: time.Now()}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ChatSession{}).Where("id = ? AND user_id = ?", sessionID, userID).
+			Updates(map[string]interface{}{"is_active": false, "deleted_at": deleted.DeletedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return notFound(gorm.ErrRecordNotFound, "chat session "+sessionID.String())
+		}
+		result = tx.Model(&models.ChatMessage{}).Where("session_id = ?", sessionID).Update("deleted_at", deleted.DeletedAt)
+		deleted.Messages = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	deleted.UndoUntil = deleted.DeletedAt.Add(ChatSessionUndoWindow)
+	return deleted, nil
+}
+
+// restoreDeletedChatSession brings back a session its user deleted less than ChatSessionUndoWindow ago
+func restoreDeletedChatSession(ctx context.Context, db *gorm.DB, sessionID, userID uuid.UUID) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := db.WithContext(ctx).Unscoped().
+		Where("id = ? AND user_id = ? AND is_active = ? AND deleted_at IS NOT NULL", sessionID, userID, false).
+		First(&session).Error
+	if err != nil {
+		return nil, notFound(err, "deleted chat session "+sessionID.String())
+	}
+	if time.Since(session.DeletedAt.Time) > ChatSessionUndoWindow {
+		return nil, validationError("chat session %s was deleted more than %s ago; an admin can restore it from the trash",
+			sessionID, ChatSessionUndoWindow)
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return restoreChatSessionTx(tx, sessionID, session.DeletedAt.Time)
+	})
+	if err != nil {
+		return nil, err
+	}
+	session.IsActive = true
+	session.DeletedAt = gorm.DeletedAt{}
+	return &session, nil
+}
+
+// restoreChatSessionTx restores a deleted session with the messages deleted along with it. Messages deleted
+// on their own before the session stay deleted.
+func restoreChatSessionTx(tx *gorm.DB, sessionID uuid.UUID, deletedAt time.Time) error {
+	err := tx.Unscoped().Model(&models.ChatSession{}).Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"is_active": true, "deleted_at": nil}).Error
+	if err != nil {
+		return err
+	}
+	// Users and retention policies delete the messages of a session right before or with the session
+	return tx.Unscoped().Model(&models.ChatMessage{}).
+		Where("session_id = ? AND deleted_at BETWEEN ? AND ?", sessionID, deletedAt.Add(-time.Minute), deletedAt.Add(time.Minute)).
+		Update("deleted_at", nil).Error
+}
+
+// purgeChatSessionTx permanently deletes a session with its messages and every record referencing them:
+// revisions, feedback, bookmarks, query logs, queued questions, moderation events, escalations and the
+// attachments no message of another session uses. It returns the storage keys of the deleted attachments,
+// whose files the caller deletes once the transaction is committed. Usage records hold no text and are kept
+// for quotas and cost reports.
+func purgeChatSessionTx(tx *gorm.DB, sessionID uuid.UUID) ([]string, error) {
+	messageIDs := tx.Unscoped().Model(&models.ChatMessage{}).Select("id").Where("session_id = ?", sessionID)
+	var attachments []models.ChatAttachment
+	err := tx.Where("id IN (?)", tx.Unscoped().Model(&models.ChatMessage{}).Select("attachment_id").Where("session_id = ?", sessionID)).
+		Where("NOT EXISTS (SELECT 1 FROM chat_messages WHERE chat_messages.attachment_id = chat_attachments.id AND chat_messages.session_id <> ?)", sessionID).
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byMessage := []interface{}{&models.ChatMessageRevision{}, &models.Feedback{}, &models.Bookmark{}, &models.QueryLog{}}
+	for _, model := range byMessage {
+		if err := tx.Unscoped().Where("message_id IN (?)", messageIDs).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	bySession := []interface{}{&models.QueuedQuestion{}, &models.ModerationEvent{}, &models.ChatEscalation{}, &models.ChatMessage{}}
+	for _, model := range bySession {
+		if err := tx.Unscoped().Where("session_id = ?", sessionID).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(attachments))
+	if len(attachments) > 0 {
+		ids := make([]uuid.UUID, len(attachments))
+		for i, attachment := range attachments {
+			ids[i] = attachment.ID
+			keys = append(keys, attachment.StorageKey)
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&models.ChatAttachment{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return keys, tx.Unscoped().Where("id = ?", sessionID).Delete(&models.ChatSession{}).Error
+}
+
+// deleteAttachmentFiles deletes the stored files of purged chat attachments. Failures only leave files behind
+// and are logged.
+func deleteAttachmentFiles(ctx context.Context, storage FileStorage, keys []string) {
+	if storage == nil {
+		if len(keys) > 0 {
+			log.Printf("[WARNING] No file storage configured, %d purged chat attachment files were kept", len(keys))
+		}
+		return
+	}
+	for _, key := range keys {
+		if err := storage.Delete(ctx, key); err != nil {
+			log.Printf("[WARNING] Failed to delete purged chat attachment %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// chatFixture is a chat session with a question and an answer, and a record of every kind referencing them
+type chatFixture struct {
+	user       *models.User
+	session    *models.ChatSession
+	question   *models.ChatMessage
+	answer     *models.ChatMessage
+	attachment *models.ChatAttachment
+}
+
+func createChatFixture(t *testing.T, tx *gorm.DB, storage FileStorage) *chatFixture {
+	t.Helper()
+	ctx := context.Background()
+	f := &chatFixture{user: createTestUser(t, tx, models.RegularUser, "")}
+	f.session = &models.ChatSession{UserID: f.user.ID, Title: "Printing labels", IsActive: true}
+	f.attachment = &models.ChatAttachment{UserID: f.user.ID, Filename: "error.png", ContentType: "image/png", StorageKey: "chat-attachments/" + uuid.NewString() + ".png"}
+	f.question = &models.ChatMessage{Role: models.UserMessage, Content: "How do I print a shipping label?"}
+	f.answer = &models.ChatMessage{Role: models.AssistantMessage, Content: "Open the order and press Print label."}
+	mustCreate(t, tx, f.session, f.attachment)
+	if err := storage.Put(ctx, f.attachment.StorageKey, []byte("png"), "image/png"); err != nil {
+		t.Fatalf("failed to store attachment: %v", err)
+	}
+	f.question.SessionID, f.answer.SessionID = f.session.ID, f.session.ID
+	f.question.AttachmentID = &f.attachment.ID
+	mustCreate(t, tx, f.question, f.answer)
+	mustCreate(t, tx,
+		&models.ChatMessageRevision{MessageID: f.answer.ID, Version: 1, Content: "An earlier answer"},
+		&models.Feedback{MessageID: f.answer.ID, UserID: f.user.ID, Rating: 1, Type: models.NotHelpfulFeedback},
+		&models.Bookmark{UserID: f.user.ID, MessageID: &f.answer.ID},
+		&models.QueryLog{Query: f.question.Content, Source: models.ViewFromChat, Reason: models.QueryNegativeFeedback, MessageID: &f.answer.ID},
+		&models.QueuedQuestion{SessionID: f.session.ID, UserID: f.user.ID, MessageID: &f.question.ID, Question: f.question.Content, Reason: "providers_unavailable"},
+		&models.ModerationEvent{UserID: f.user.ID, SessionID: &f.session.ID, Stage: models.ModerationInput, Guardrail: "pii_redaction", Category: "email", Action: models.ModerationRedacted},
+		&models.ChatEscalation{SessionID: f.session.ID, UserID: f.user.ID, Reason: "Wrong answer"},
+	)
+	return f
+}
+
+func mustCreate(t *testing.T, tx *gorm.DB, records ...interface{}) {
+	t.Helper()
+	for _, record := range records {
+		if err := tx.Create(record).Error; err != nil {
+			t.Fatalf("failed to create %T: %v", record, err)
+		}
+	}
+}
+
+// backdateDeletion moves the deletion of a session and its messages back by age
+func backdateDeletion(t *testing.T, tx *gorm.DB, sessionID uuid.UUID, age time.Duration) {
+	t.Helper()
+	deletedAt := time.Now().Add(-age)
+	if err := tx.Unscoped().Model(&models.ChatSession{}).Where("id = ?", sessionID).Update("deleted_at", deletedAt).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Unscoped().Model(&models.ChatMessage{}).Where("session_id = ?", sessionID).Update("deleted_at", deletedAt).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteChatSessionAndRestoreWithinUndoWindow(t *testing.T) {
+	tx := testDB(t)
+	ctx := context.Background()
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := createChatFixture(t, tx, storage)
+
+	deleted, err := deleteChatSession(ctx, tx, f.session.ID, f.user.ID)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if deleted.Messages != 2 {
+		t.Errorf("deleted %d messages, want 2", deleted.Messages)
+	}
+	if want := deleted.DeletedAt.Add(ChatSessionUndoWindow); !deleted.UndoUntil.Equal(want) {
+		t.Errorf("undo until %s, want %s", deleted.UndoUntil, want)
+	}
+	var visible int64
+	tx.Model(&models.ChatMessage{}).Where("session_id = ?", f.session.ID).Count(&visible)
+	if visible != 0 {
+		t.Errorf("%d messages still visible after the delete", visible)
+	}
+
+	if _, err := deleteChatSession(ctx, tx, f.session.ID, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete by another user: got %v, want ErrNotFound", err)
+	}
+
+	restored, err := restoreDeletedChatSession(ctx, tx, f.session.ID, f.user.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !restored.IsActive {
+		t.Error("restored session is not active")
+	}
+	tx.Model(&models.ChatMessage{}).Where("session_id = ?", f.session.ID).Count(&visible)
+	if visible != 2 {
+		t.Errorf("%d messages visible after the restore, want 2", visible)
+	}
+}
+
+func TestRestoreChatSessionAfterUndoWindow(t *testing.T) {
+	tx := testDB(t)
+	ctx := context.Background()
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := createChatFixture(t, tx, storage)
+
+	if _, err := deleteChatSession(ctx, tx, f.session.ID, f.user.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	backdateDeletion(t, tx, f.session.ID, ChatSessionUndoWindow+time.Minute)
+
+	if _, err := restoreDeletedChatSession(ctx, tx, f.session.ID, f.user.ID); !errors.Is(err, ErrValidation) {
+		t.Errorf("restore after the undo window: got %v, want ErrValidation", err)
+	}
+}
+
+func TestPurgeChatSessionAfterUndoWindow(t *testing.T) {
+	tx := testDB(t)
+	ctx := context.Background()
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := createChatFixture(t, tx, storage)
+	if _, err := deleteChatSession(ctx, tx, f.session.ID, f.user.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	backdateDeletion(t, tx, f.session.ID, 2*time.Hour)
+
+	trash := NewTrashService(tx, nil, nil, nil, time.Hour)
+	trash.SetStorage(storage)
+	report, err := trash.Purge(ctx)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if report.Purged[TrashChatSession] < 1 {
+		t.Errorf("purged %d chat sessions, want the deleted one", report.Purged[TrashChatSession])
+	}
+
+	remaining := map[string]*gorm.DB{
+		"chat_sessions":          tx.Unscoped().Model(&models.ChatSession{}).Where("id = ?", f.session.ID),
+		"chat_messages":          tx.Unscoped().Model(&models.ChatMessage{}).Where("session_id = ?", f.session.ID),
+		"chat_message_revisions": tx.Model(&models.ChatMessageRevision{}).Where("message_id = ?", f.answer.ID),
+		"feedbacks":              tx.Unscoped().Model(&models.Feedback{}).Where("message_id = ?", f.answer.ID),
+		"bookmarks":              tx.Model(&models.Bookmark{}).Where("message_id = ?", f.answer.ID),
+		"query_logs":             tx.Model(&models.QueryLog{}).Where("message_id = ?", f.answer.ID),
+		"queued_questions":       tx.Model(&models.QueuedQuestion{}).Where("session_id = ?", f.session.ID),
+		"moderation_events":      tx.Model(&models.ModerationEvent{}).Where("session_id = ?", f.session.ID),
+		"chat_escalations":       tx.Model(&models.ChatEscalation{}).Where("session_id = ?", f.session.ID),
+		"chat_attachments":       tx.Model(&models.ChatAttachment{}).Where("id = ?", f.attachment.ID),
+	}
+	for table, query := range remaining {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("%d %s left after the purge", count, table)
+		}
+	}
+	if _, err := storage.Get(ctx, f.attachment.StorageKey); err == nil {
+		t.Error("attachment file left after the purge")
+	}
+}
+
+func TestPurgeChatSessionTxDeletesDependentRecords(t *testing.T) {
+	tx, recorder := dryRunDB(t)
+	if _, err := purgeChatSessionTx(tx, uuid.New()); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	byMessage := []string{"chat_message_revisions", "feedbacks", "bookmarks", "query_logs"}
+	for _, table := range byMessage {
+		if !recorder.contains(`DELETE FROM "`+table+`"`, `message_id IN (SELECT "id" FROM "chat_messages"`) {
+			t.Errorf("no delete of %s by message:\n%v", table, recorder.statements)
+		}
+	}
+	bySession := []string{"queued_questions", "moderation_events", "chat_escalations", "chat_messages"}
+	for _, table := range bySession {
+		if !recorder.contains(`DELETE FROM "`+table+`"`, "session_id = ") {
+			t.Errorf("no delete of %s by session:\n%v", table, recorder.statements)
+		}
+	}
+	if !recorder.contains(`SELECT * FROM "chat_attachments"`) {
+		t.Errorf("attachments of the session are not looked up:\n%v", recorder.statements)
+	}
+	if !recorder.contains(`DELETE FROM "chat_sessions"`) {
+		t.Errorf("session is not deleted:\n%v", recorder.statements)
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChunkOptions configures how text is split into chunks for embedding
+type ChunkOptions struct {
+	MaxTokens     int
+	OverlapTokens int
+	Model         string // Embedding model whose tokenizer counts the tokens; empty counts in cl100k_base
+}
+
+// DefaultChunkOptions returns the chunking parameters used when none are configured
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		MaxTokens:     400,
+		OverlapTokens: 50,
+	}
+}
+
+// TextChunk is a piece of a larger text. Offsets are character (rune) offsets
+// into the original text so that clients can highlight the matching passage.
+type TextChunk struct {
+	Index       int
+	Text        string
+	StartOffset int
+	EndOffset   int
+	TokenCount  int
+}
+
+// textSpan is a byte range of the source text with its token count
+type textSpan struct {
+	start  int
+	end    int
+	tokens int
+}
+
+// ChunkText splits text into token-bounded chunks that respect sentence boundaries.
+// Consecutive chunks share up to OverlapTokens tokens of trailing sentences.
+func ChunkText(text string, opts ChunkOptions) []TextChunk {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultChunkOptions().MaxTokens
+	}
+	if opts.OverlapTokens < 0 || opts.OverlapTokens >= opts.MaxTokens {
+		opts.OverlapTokens = 0
+	}
+
+	count := func(s string) int { return CountTokens(opts.Model, s) }
+
+	var spans []textSpan
+	for _, sentence := range splitSentences(text, count) {
+		if sentence.tokens > opts.MaxTokens {
+			spans = append(spans, splitWords(text, sentence, opts.MaxTokens, opts.Model)...)
+		} else {
+			spans = append(spans, sentence)
+		}
+	}
+
+	var chunks []TextChunk
+	for i := 0; i < len(spans); {
+		j := i
+		tokens := 0
+		for j < len(spans) && (j == i || tokens+spans[j].tokens <= opts.MaxTokens) {
+			tokens += spans[j].tokens
+			j++
+		}
+
+		// Tokens can merge differently across span boundaries, so the chunk is counted as a whole
+		chunk, ok := buildChunk(text, spans[i].start, spans[j-1].end, count)
+		for ok && chunk.TokenCount > opts.MaxTokens && j-1 > i {
+			j--
+			chunk, ok = buildChunk(text, spans[i].start, spans[j-1].end, count)
+		}
+		if ok {
+			chunk.Index = len(chunks)
+			chunks = append(chunks, chunk)
+		}
+		if j >= len(spans) {
+			break
+		}
+
+		// Step back over trailing sentences to create the overlap, always making progress
+		next := j
+		overlap := 0
+		for next-1 > i && overlap+spans[next-1].tokens <= opts.OverlapTokens {
+			next--
+			overlap += spans[next].tokens
+		}
+		i = next
+	}
+
+	return chunks
+}
+
+// buildChunk trims the byte range and converts it into a chunk with rune offsets
+func buildChunk(text string, start, end int, count func(string) int) (TextChunk, bool) {
+	raw := text[start:end]
+	trimmedLeft := strings.TrimLeftFunc(raw, unicode.IsSpace)
+	start += len(raw) - len(trimmedLeft)
+	trimmed := strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	if trimmed == "" {
+		return TextChunk{}, false
+	}
+	end = start + len(trimmed)
+
+	startOffset := utf8.RuneCountInString(text[:start])
+	return TextChunk{
+		Text:        trimmed,
+		StartOffset: startOffset,
+		EndOffset:   startOffset + utf8.RuneCountInString(trimmed),
+		TokenCount:  count(trimmed),
+	}, true
+}
+
+// splitSentences splits text at sentence terminators and line breaks, keeping
+// trailing whitespace with the preceding sentence so spans cover the whole text
+func splitSentences(text string, count func(string) int) []textSpan {
+	var spans []textSpan
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		if !isSentenceTerminator(r) {
+			continue
+		}
+		if r != '\n' && i < len(text) {
+			next, _ := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsSpace(next) {
+				continue // e.g. decimals, abbreviations inside words, URLs
+			}
+		}
+
+		for i < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			i += nextSize
+		}
+		spans = append(spans, textSpan{start: start, end: i, tokens: count(text[start:i])})
+		start = i
+	}
+
+	if start < len(text) {
+		spans = append(spans, textSpan{start: start, end: len(text), tokens: count(text[start:])})
+	}
+	return spans
+}
+
+// splitWords breaks an oversized sentence into word-aligned spans of at most maxTokens. Words longer than
+// maxTokens, such as CJK text without spaces or long identifiers in code, are cut between tokens.
+func splitWords(text string, sentence textSpan, maxTokens int, model string) []textSpan {
+	var spans []textSpan
+	start := sentence.start
+	tokens := 0
+	i := sentence.start
+	for i < sentence.end {
+		// Find the next word including its trailing whitespace
+		wordEnd := i
+		for wordEnd < sentence.end {
+			r, size := utf8.DecodeRuneInString(text[wordEnd:])
+			if unicode.IsSpace(r) && wordEnd > i {
+				break
+			}
+			wordEnd += size
+		}
+		for wordEnd < sentence.end {
+			r, size := utf8.DecodeRuneInString(text[wordEnd:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			wordEnd += size
+		}
+
+		wordTokens := CountTokens(model, text[i:wordEnd])
+		if wordTokens > maxTokens {
+			if tokens > 0 {
+				spans = append(spans, textSpan{start: start, end: i, tokens: tokens})
+			}
+			spans = append(spans, splitTokens(text, i, wordEnd, maxTokens, model)...)
+			start, tokens, i = wordEnd, 0, wordEnd
+			continue
+		}
+		if tokens > 0 && tokens+wordTokens > maxTokens {
+			spans = append(spans, textSpan{start: start, end: i, tokens: tokens})
+			start = i
+			tokens = 0
+		}
+		tokens += wordTokens
+		i = wordEnd
+	}
+
+	if start < sentence.end {
+		spans = append(spans, textSpan{start: start, end: sentence.end, tokens: tokens})
+	}
+	return spans
+}
+
+// splitTokens cuts text[start:end] into spans of at most maxTokens tokens. Cuts inside a multi-byte
+// character move back to its start.
+func splitTokens(text string, start, end, maxTokens int, model string) []textSpan {
+	ends := tokenEnds(model, text[start:end])
+	for k := range ends {
+		ends[k] += start
+	}
+
+	var spans []textSpan
+	next := 0 // First token ending after from
+	for from := start; from < end; {
+		for next < len(ends) && ends[next] <= from {
+			next++
+		}
+		last := next + maxTokens - 1
+		if last >= len(ends) {
+			last = len(ends) - 1
+		}
+
+		var cut, tokens int
+		for ; ; last-- {
+			cut = end
+			if last >= next {
+				cut = ends[last]
+			}
+			for cut > from && cut < end && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+			if cut == from {
+				// A single character longer than maxTokens still has to go somewhere
+				_, size := utf8.DecodeRuneInString(text[from:])
+				cut = from + size
+			}
+			// The piece is tokenized on its own, which can take more tokens than the cut ones
+			tokens = CountTokens(model, text[from:cut])
+			if tokens <= maxTokens || last <= next {
+				break
+			}
+		}
+		spans = append(spans, textSpan{start: from, end: cut, tokens: tokens})
+		from = cut
+	}
+	return spans
+}
+
+func isSentenceTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', ';', '\n', '。', '！', '？':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkText(t *testing.T) {
+	runbook := "The label printer is offline. Restart it from the front panel. Then print a test label. Call support if it still fails."
+
+	tests := []struct {
+		name string
+		text string
+		opts ChunkOptions
+		want []string // Chunk texts; nil only checks the chunks stay within the limit
+	}{
+		{
+			name: "whole text within the limit",
+			text: runbook,
+			opts: ChunkOptions{MaxTokens: 400},
+			want: []string{runbook},
+		},
+		{
+			name: "split at sentence boundaries",
+			text: runbook,
+			opts: ChunkOptions{MaxTokens: 16},
+			want: []string{
+				"The label printer is offline. Restart it from the front panel.",
+				"Then print a test label. Call support if it still fails.",
+			},
+		},
+		{
+			name: "overlap repeats trailing sentences",
+			text: runbook,
+			opts: ChunkOptions{MaxTokens: 16, OverlapTokens: 8},
+			want: []string{
+				"The label printer is offline. Restart it from the front panel.",
+				"Restart it from the front panel. Then print a test label.",
+				"Then print a test label. Call support if it still fails.",
+			},
+		},
+		{
+			name: "overlap larger than the sentences it could repeat",
+			text: runbook,
+			opts: ChunkOptions{MaxTokens: 16, OverlapTokens: 4},
+			want: []string{
+				"The label printer is offline. Restart it from the front panel.",
+				"Then print a test label. Call support if it still fails.",
+			},
+		},
+		{
+			name: "line breaks end sentences",
+			text: "Open the tray\nLoad the labels\nClose the tray",
+			opts: ChunkOptions{MaxTokens: 4},
+			want: []string{"Open the tray", "Load the labels", "Close the tray"},
+		},
+		{
+			name: "oversized paragraph without terminators",
+			text: strings.Repeat("restart the label printer from the front panel ", 60),
+			opts: ChunkOptions{MaxTokens: 50, OverlapTokens: 10},
+		},
+		{
+			name: "oversized CJK paragraph without spaces",
+			text: strings.Repeat("标签打印机离线时请从前面板重新启动", 40),
+			opts: ChunkOptions{MaxTokens: 20},
+		},
+		{
+			name: "oversized token without spaces",
+			text: strings.Repeat("aGVsbG8gd29ybGQ=", 80),
+			opts: ChunkOptions{MaxTokens: 16, Model: "text-embedding-3-small"},
+		},
+		{
+			name: "empty text",
+			text: " \n ",
+			opts: ChunkOptions{MaxTokens: 16},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := ChunkText(tt.text, tt.opts)
+			runes := []rune(tt.text)
+			covered := 0
+			for i, chunk := range chunks {
+				if chunk.Index != i {
+					t.Errorf("chunk %d has index %d", i, chunk.Index)
+				}
+				if got := string(runes[chunk.StartOffset:chunk.EndOffset]); got != chunk.Text {
+					t.Errorf("chunk %d offsets select %q, want %q", i, got, chunk.Text)
+				}
+				if !utf8.ValidString(chunk.Text) {
+					t.Errorf("chunk %d cuts a character in half: %q", i, chunk.Text)
+				}
+				if tokens := CountTokens(tt.opts.Model, chunk.Text); chunk.TokenCount != tokens {
+					t.Errorf("chunk %d counts %d tokens, the tokenizer %d", i, chunk.TokenCount, tokens)
+				}
+				if chunk.TokenCount > tt.opts.MaxTokens {
+					t.Errorf("chunk %d has %d tokens, more than %d", i, chunk.TokenCount, tt.opts.MaxTokens)
+				}
+				if gap := string(runes[min(covered, chunk.StartOffset):chunk.StartOffset]); strings.TrimSpace(gap) != "" {
+					t.Errorf("chunk %d leaves %q out", i, gap)
+				}
+				covered = max(covered, chunk.EndOffset)
+			}
+			if rest := string(runes[min(covered, len(runes)):]); strings.TrimSpace(rest) != "" {
+				t.Errorf("the chunks leave %q out", rest)
+			}
+
+			if tt.want == nil {
+				if len(chunks) < 2 {
+					t.Errorf("%d chunks for oversized text, want it split", len(chunks))
+				}
+				return
+			}
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("%d chunks, want %d: %+v", len(chunks), len(tt.want), chunks)
+			}
+			for i, chunk := range chunks {
+				if chunk.Text != tt.want[i] {
+					t.Errorf("chunk %d is %q, want %q", i, chunk.Text, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCountTokensUsesTheModelTokenizer(t *testing.T) {
+	tests := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{"text-embedding-3-small", "hello world", 2},
+		{"text-embedding-3-small", "", 0},
+		{"gpt-4o", "hello world", 2},
+		// Models tiktoken does not know are counted in cl100k_base
+		{"nomic-embed-text", "hello world", 2},
+		{"", "标签打印机", CountTokens("text-embedding-3-small", "标签打印机")},
+	}
+	for _, tt := range tests {
+		if got := CountTokens(tt.model, tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q, %q) = %d, want %d", tt.model, tt.text, got, tt.want)
+		}
+	}
+	if tokens, estimate := CountTokens("", "标签打印机"), len("标签打印机")/4; tokens <= estimate {
+		t.Errorf("CJK text counts %d tokens, no more than a bytes/4 estimate of %d", tokens, estimate)
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// maxFallbackCitationRunes bounds the excerpt used when a citation comes from text search
+const maxFallbackCitationRunes = 300
+
+// Citation links a [n] marker in an answer to the passage of a knowledge entry it came from.
+// Offsets are character offsets into the entry's embedding text (see EmbeddingText).
+type Citation struct {
+	Index            int       `json:"index"`
+	KnowledgeEntryID uuid.UUID `json:"knowledge_entry_id"`
+	Title            string    `json:"title"`
+	ChunkText        string    `json:"chunk_text"`
+	Score            float64   `json:"score"`
+	StartOffset      int       `json:"start_offset"`
+	EndOffset        int       `json:"end_offset"`
+	MatchedQuestion  string    `json:"matched_question,omitempty"` // Related question of the entry that matched the query
+}
+
+// EmbeddingText returns the text of an entry that is chunked and embedded
+func EmbeddingText(entry *models.KnowledgeEntry) string {
+	fullText := entry.Title + "\n\n" + entry.Content
+	if entry.Summary != "" {
+		fullText = entry.Summary + "\n\n" + fullText
+	}
+	return fullText
+}
+
+// SearchKnowledgeWithCitations searches the knowledge base and returns the matching entries
+// ordered by relevance together with one citation per entry, numbered from 1 in the same order
+// Vector hits are filtered by the retrieval preset of each entry's template or category. Entries the user
+// bookmarked rank higher.
+func (s *KnowledgeService) SearchKnowledgeWithCitations(ctx context.Context, query string, limit int, scope RetrievalScope) ([]models.KnowledgeEntry, []Citation, error) {
+	if s.vectorService != nil && s.embedder != nil {
+		vectorResults, err := s.searchVectors(ctx, query, limit, scope)
+		if err == nil && len(vectorResults) > 0 {
+			entries, citations, err := s.citeVectorResults(vectorResults, scope)
+			if err == nil && len(entries) > 0 {
+				scope.rankBookmarked(entries, citations)
+				return entries, citations, nil
+			}
+		}
+	}
+
+	entries, err := s.textSearch(query, limit, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	citations := make([]Citation, len(entries))
+	for i := range entries {
+		citations[i] = fallbackCitation(i+1, &entries[i], query)
+	}
+	scope.rankBookmarked(entries, citations)
+	return entries, citations, nil
+}
+
+// citeVectorResults loads the entries of vector hits, keeping the best scoring chunk of each entry
+func (s *KnowledgeService) citeVectorResults(results []VectorSearchResult, scope RetrievalScope) ([]models.KnowledgeEntry, []Citation, error) {
+	// Results are sorted by score, so the first chunk seen for an entry is its best one
+	var entryIDs []uuid.UUID
+	bestChunk := make(map[uuid.UUID]VectorSearchResult)
+	for _, result := range results {
+		if _, seen := bestChunk[result.KnowledgeEntryID]; seen {
+			continue
+		}
+		bestChunk[result.KnowledgeEntryID] = result
+		entryIDs = append(entryIDs, result.KnowledgeEntryID)
+	}
+
+	var found []models.KnowledgeEntry
+	err := scope.Apply(readDB(s.reads, s.db).Preload("Template").Preload("Creator")).
+		Where("id IN ? AND is_published = true", entryIDs).
+		Find(&found).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[uuid.UUID]models.KnowledgeEntry, len(found))
+	for _, entry := range found {
+		byID[entry.ID] = entry
+	}
+
+	var entries []models.KnowledgeEntry
+	var citations []Citation
+	perCategory := make(map[string]int)
+	for _, id := range entryIDs {
+		entry, ok := byID[id]
+		if !ok {
+			continue
+		}
+		chunk := bestChunk[id]
+		if s.presets != nil {
+			// The entry's preset may drop weak matches and cap how many entries of its category are returned
+			if preset := s.presets.PresetFor(&entry); preset != nil {
+				if chunk.Score < preset.ScoreThreshold {
+					continue
+				}
+				if preset.TopK > 0 && perCategory[entry.Category] >= preset.TopK {
+					continue
+				}
+			}
+			perCategory[entry.Category]++
+		}
+		entries = append(entries, entry)
+		if chunk.Question != "" {
+			// A related question has no passage of its own, cite the start of the entry
+			citation := fallbackCitation(len(citations)+1, &entry, "")
+			citation.Score = chunk.Score
+			citation.MatchedQuestion = chunk.Question
+			citations = append(citations, citation)
+			continue
+		}
+		citations = append(citations, Citation{
+			Index:            len(citations) + 1,
+			KnowledgeEntryID: entry.ID,
+			Title:            entry.Title,
+			ChunkText:        chunk.ChunkText,
+			Score:            chunk.Score,
+			StartOffset:      chunk.StartOffset,
+			EndOffset:        chunk.EndOffset,
+		})
+	}
+	return entries, citations, nil
+}
+
+// fallbackCitation cites the passage of an entry around the first occurrence of the query
+func fallbackCitation(index int, entry *models.KnowledgeEntry, query string) Citation {
+	text := EmbeddingText(entry)
+	runes := []rune(text)
+
+	start := 0
+	if pos := strings.Index(strings.ToLower(text), strings.ToLower(query)); pos >= 0 && query != "" {
+		start = utf8.RuneCountInString(text[:pos])
+	}
+	end := start + maxFallbackCitationRunes
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	return Citation{
+		Index:            index,
+		KnowledgeEntryID: entry.ID,
+		Title:            entry.Title,
+		ChunkText:        string(runes[start:end]),
+		StartOffset:      start,
+		EndOffset:        end,
+	}
+}
+
+// citationContext builds the numbered AI context for the entries, matching the citation order
+func citationContext(entries []models.KnowledgeEntry) []string {
+	context := make([]string, len(entries))
+	for i, entry := range entries {
+		context[i] = entry.Title + "\n" + entry.Content
+	}
+	return context
+}
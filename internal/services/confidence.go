@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	keywordRetrievalScore = 0.5 // Keyword search fallbacks have no similarity score, so their matches count as middling
+	retrievalWeight       = 0.7 // Share of the confidence coming from retrieval, the rest comes from the answer citing it
+	hedgedConfidenceCap   = 0.2 // Answers admitting they do not know score at most this
+)
+
+// unknownAnswer replaces answers whose confidence is below the threshold
+const unknownAnswer = "I don't know the answer to this question: the knowledge base has nothing that answers it reliably. " +
+	"Please contact the support team, or submit the question to be researched and answered later."
+
+// hedgePhrases, folded, are how answers in English or Vietnamese admit the provider does not know
+var hedgePhrases = []string{
+	"i don t know", "i do not know", "not sure", "no information",
+	"don t have information", "do not have information", "couldn t find", "could not find", "cannot find",
+	"khong biet", "khong chac", "khong co thong tin", "khong tim thay",
+}
+
+var citationMarkerPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// ConfidenceOptions controls answer confidence scoring
+type ConfidenceOptions struct {
+	Threshold float64 // Answers scoring below this are replaced by one saying the bot does not know; 0 keeps every answer
+}
+
+// AnswerConfidence scores from 0 to 1 how well the knowledge base supports an answer. Providers do not
+// report token probabilities here, so it combines the similarity of the best retrieved passage with
+// whether the answer cites the passages it was given, and caps answers that hedge.
+func AnswerConfidence(citations []Citation, answer string) float64 {
+	retrieval := 0.0
+	for _, citation := range citations {
+		score := citation.Score
+		if score == 0 {
+			score = keywordRetrievalScore
+		}
+		if score > retrieval {
+			retrieval = score
+		}
+	}
+	if retrieval > 1 {
+		retrieval = 1
+	}
+
+	grounded := 0.0
+	for _, match := range citationMarkerPattern.FindAllStringSubmatch(answer, -1) {
+		if index, err := strconv.Atoi(match[1]); err == nil && index >= 1 && index <= len(citations) {
+			grounded = 1
+			break
+		}
+	}
+
+	confidence := retrievalWeight*retrieval + (1-retrievalWeight)*grounded
+	if hedges(answer) && confidence > hedgedConfidenceCap {
+		confidence = hedgedConfidenceCap
+	}
+	return confidence
+}
+
+// hedges reports whether an answer admits it does not know
+func hedges(answer string) bool {
+	folded := " " + foldSearchText(answer) + " "
+	for _, phrase := range hedgePhrases {
+		if strings.Contains(folded, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// withholds reports whether an answer of the given confidence must be replaced by unknownAnswer
+func (o ConfidenceOptions) withholds(confidence float64) bool {
+	return confidence < o.Threshold
+}
+
+// localizedUnknownAnswer returns unknownAnswer in the language of the user
+func localizedUnknownAnswer(ctx context.Context, translator *TranslationService, language string) string {
+	return translator.LocalizeAnswer(ctx, unknownAnswer, language)
+}
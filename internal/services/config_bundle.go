@@ -0,0 +1,742 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigBundleVersion is the format version written by ExportConfig. Import accepts bundles up to this version.
+const ConfigBundleVersion = 1
+
+var ErrConfigAdminOnly = fmt.Errorf("%w: only admins can import configuration", ErrForbidden)
+
+// errConfigDryRun rolls back the import transaction of a dry run
+var errConfigDryRun = errors.New("config import dry run")
+
+// Kinds of configuration in a bundle
+const (
+	ConfigKindRetrievalPreset = "retrieval_preset"
+	ConfigKindCategory        = "category"
+	ConfigKindTemplate        = "template"
+	ConfigKindPrompt          = "prompt"
+	ConfigKindRetentionPolicy = "retention_policy"
+	ConfigKindOrgQuota        = "org_quota"
+	ConfigKindHelpScreen      = "help_screen"
+)
+
+// ConfigBundle is the configuration of an environment, without its content. Records are identified by name rather
+// than ID, so a bundle exported from staging can be imported into production. Knowledge entries, documents, users
+// and per-user settings are not part of a bundle.
+type ConfigBundle struct {
+	Version           int                     `json:"version" example:"1"`
+	ExportedAt        time.Time               `json:"exported_at"`
+	RetrievalPresets  []RetrievalPresetSpec   `json:"retrieval_presets"`
+	Categories        []CategoryConfig        `json:"categories"`
+	Templates         []TemplateConfig        `json:"templates"`
+	Prompts           []PromptConfig          `json:"prompts"`
+	RetentionPolicies []RetentionPolicyConfig `json:"retention_policies"`
+	OrgQuota          *OrgQuotaConfig         `json:"org_quota,omitempty"` // Absent when the configured default applies
+	HelpScreens       []HelpScreenConfig      `json:"help_screens"`
+}
+
+// CategoryConfig is the retrieval preset of a category
+type CategoryConfig struct {
+	Category        string `json:"category" example:"Error Codes"`
+	RetrievalPreset string `json:"retrieval_preset" example:"error-codes"`
+}
+
+// TemplateConfig is a knowledge entry template with its fields. Templates are matched by name and category.
+type TemplateConfig struct {
+	Name            string                `json:"name"`
+	Description     string                `json:"description,omitempty"`
+	Category        string                `json:"category"`
+	IsActive        bool                  `json:"is_active"`
+	RetrievalPreset string                `json:"retrieval_preset,omitempty"` // Overrides the category's preset
+	Fields          []TemplateFieldConfig `json:"fields"`
+}
+
+// TemplateFieldConfig is a field of a template
+type TemplateFieldConfig struct {
+	Name        string           `json:"name"`
+	Type        models.FieldType `json:"type"`
+	Label       string           `json:"label"`
+	Description string           `json:"description,omitempty"`
+	Required    bool             `json:"required"`
+	Options     string           `json:"options,omitempty"`
+	Placeholder string           `json:"placeholder,omitempty"`
+	Validation  string           `json:"validation,omitempty"`
+	Order       int              `json:"order"`
+}
+
+// PromptConfig is the active version of a prompt template. Importing a changed prompt adds it as a new active version.
+type PromptConfig struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Content     string            `json:"content"`
+	Variables   map[string]string `json:"variables,omitempty"`
+}
+
+// RetentionPolicyConfig is a chat retention policy. Policies are matched by name and team.
+type RetentionPolicyConfig struct {
+	Name               string                 `json:"name"`
+	Team               string                 `json:"team,omitempty"`
+	MaxAgeMonths       int                    `json:"max_age_months"`
+	Action             models.RetentionAction `json:"action"`
+	ExportBeforeDelete bool                   `json:"export_before_delete"`
+	IsActive           bool                   `json:"is_active"`
+}
+
+// OrgQuotaConfig is the organization-wide monthly usage quota override
+type OrgQuotaConfig struct {
+	MonthlyTokenLimit   int64   `json:"monthly_token_limit"`
+	MonthlyCostLimitUSD float64 `json:"monthly_cost_limit_usd"`
+	Note                string  `json:"note,omitempty"`
+}
+
+// HelpScreenConfig is the help text of a screen. Curated entries and generated tips stay behind: entry IDs
+// differ between environments and tips are regenerated on the next request.
+type HelpScreenConfig struct {
+	ScreenID    string `json:"screen_id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// ConfigImportChange is a record created or updated by an import
+type ConfigImportChange struct {
+	Kind   string `json:"kind" example:"template"`
+	Name   string `json:"name" example:"Error Codes/Error code article"`
+	Action string `json:"action" example:"updated"` // created or updated
+}
+
+// ConfigImportReport lists what an import changed, or would change for a dry run
+type ConfigImportReport struct {
+	DryRun    bool                 `json:"dry_run"`
+	Changes   []ConfigImportChange `json:"changes"`
+	Unchanged int                  `json:"unchanged"`
+}
+
+func (r *ConfigImportReport) record(kind, name string, created, changed bool) {
+	switch {
+	case created:
+		r.Changes = append(r.Changes, ConfigImportChange{Kind: kind, Name: name, Action: "created"})
+	case changed:
+		r.Changes = append(r.Changes, ConfigImportChange{Kind: kind, Name: name, Action: "updated"})
+	default:
+		r.Unchanged++
+	}
+}
+
+// ConfigBundleService exports the configuration of an environment as a versioned bundle and imports it into another,
+// to promote configuration from staging to production. Import creates and updates records; nothing missing from the
+// bundle is deleted.
+type ConfigBundleService struct {
+	db      *gorm.DB
+	presets *RetrievalPresetService
+	prompts *PromptService
+}
+
+// NewConfigBundleService creates the configuration export and import service
+func NewConfigBundleService(db *gorm.DB, presets *RetrievalPresetService, prompts *PromptService) *ConfigBundleService {
+	return &ConfigBundleService{db: db, presets: presets, prompts: prompts}
+}
+
+// ExportConfig returns the current configuration as a bundle
+func (s *ConfigBundleService) ExportConfig() (*ConfigBundle, error) {
+	bundle := &ConfigBundle{
+		Version:           ConfigBundleVersion,
+		ExportedAt:        time.Now().UTC(),
+		RetrievalPresets:  []RetrievalPresetSpec{},
+		Categories:        []CategoryConfig{},
+		Templates:         []TemplateConfig{},
+		Prompts:           []PromptConfig{},
+		RetentionPolicies: []RetentionPolicyConfig{},
+		HelpScreens:       []HelpScreenConfig{},
+	}
+
+	var presets []models.RetrievalPreset
+	if err := s.db.Order("name ASC").Find(&presets).Error; err != nil {
+		return nil, err
+	}
+	presetNames := make(map[uuid.UUID]string, len(presets))
+	for _, preset := range presets {
+		presetNames[preset.ID] = preset.Name
+		bundle.RetrievalPresets = append(bundle.RetrievalPresets, presetSpec(preset))
+	}
+
+	var categories []models.CategoryRetrievalPreset
+	if err := s.db.Order("category ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		bundle.Categories = append(bundle.Categories, CategoryConfig{
+			Category:        category.Category,
+			RetrievalPreset: presetNames[category.PresetID],
+		})
+	}
+
+	var templates []models.Template
+	if err := s.db.Preload("Fields", orderTemplateFields).Order("category ASC, name ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		bundle.Templates = append(bundle.Templates, templateConfig(template, presetNames))
+	}
+
+	var prompts []models.PromptTemplate
+	if err := s.db.Where("is_active = ?", true).Order("name ASC").Find(&prompts).Error; err != nil {
+		return nil, err
+	}
+	for _, prompt := range prompts {
+		bundle.Prompts = append(bundle.Prompts, promptConfig(prompt))
+	}
+
+	var policies []models.ChatRetentionPolicy
+	if err := s.db.Order("name ASC, team ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	for _, policy := range policies {
+		bundle.RetentionPolicies = append(bundle.RetentionPolicies, retentionPolicyConfig(policy))
+	}
+
+	var quota models.UsageQuota
+	if err := s.db.First(&quota, "user_id IS NULL").Error; err == nil {
+		bundle.OrgQuota = orgQuotaConfig(quota)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var screens []models.HelpScreen
+	if err := s.db.Order("screen_id ASC").Find(&screens).Error; err != nil {
+		return nil, err
+	}
+	for _, screen := range screens {
+		bundle.HelpScreens = append(bundle.HelpScreens, HelpScreenConfig{
+			ScreenID:    screen.ScreenID,
+			Title:       screen.Title,
+			Description: screen.Description,
+		})
+	}
+
+	return bundle, nil
+}
+
+// ImportConfig creates and updates the configuration of a bundle in one transaction. A dry run reports the
+// changes and rolls them back. adminID must belong to an admin.
+func (s *ConfigBundleService) ImportConfig(ctx context.Context, bundle *ConfigBundle, adminID uuid.UUID, dryRun bool) (*ConfigImportReport, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	if bundle.Version < 1 || bundle.Version > ConfigBundleVersion {
+		return nil, validationError("unsupported bundle version %d, expected 1 to %d", bundle.Version, ConfigBundleVersion)
+	}
+
+	report := &ConfigImportReport{DryRun: dryRun, Changes: []ConfigImportChange{}}
+	imp := &configImport{adminID: adminID, report: report}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		imp.tx = tx
+		if err := imp.run(bundle); err != nil {
+			return err
+		}
+		if dryRun {
+			return errConfigDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errConfigDryRun) {
+		return nil, err
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	if s.prompts != nil {
+		for _, prompt := range bundle.Prompts {
+			s.prompts.invalidate(prompt.Name)
+		}
+	}
+	if s.presets != nil {
+		s.presets.invalidate()
+		for _, id := range imp.rechunkedPresets {
+			s.presets.reembedPresetEntries(ctx, id)
+		}
+		s.presets.reembed(ctx, imp.reassignedCategories, imp.reassignedTemplates)
+	}
+
+	log.Printf("[INFO] Admin %s imported configuration bundle version %d: %d changes, %d unchanged",
+		adminID, bundle.Version, len(report.Changes), report.Unchanged)
+	return report, nil
+}
+
+func (s *ConfigBundleService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrConfigAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrConfigAdminOnly
+	}
+	return nil
+}
+
+// configImport applies a bundle within a transaction and remembers which entries need new embeddings afterwards
+type configImport struct {
+	tx      *gorm.DB
+	adminID uuid.UUID
+	report  *ConfigImportReport
+
+	presetIDs            map[string]uuid.UUID
+	rechunkedPresets     []uuid.UUID
+	reassignedCategories []string
+	reassignedTemplates  []uuid.UUID
+}
+
+func (imp *configImport) run(bundle *ConfigBundle) error {
+	// Presets first: categories and templates refer to them by name
+	steps := []func(*ConfigBundle) error{
+		imp.importPresets,
+		imp.importCategories,
+		imp.importTemplates,
+		imp.importPrompts,
+		imp.importRetentionPolicies,
+		imp.importOrgQuota,
+		imp.importHelpScreens,
+	}
+	for _, step := range steps {
+		if err := step(bundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *configImport) importPresets(bundle *ConfigBundle) error {
+	for _, spec := range bundle.RetrievalPresets {
+		if err := validatePresetSpec(&spec); err != nil {
+			return fmt.Errorf("retrieval preset %q: %w", spec.Name, err)
+		}
+		var preset models.RetrievalPreset
+		err := imp.tx.First(&preset, "name = ?", spec.Name).Error
+		created := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !created {
+			return err
+		}
+		if created {
+			preset.CreatedBy = &imp.adminID
+		}
+
+		changed := !created && !reflect.DeepEqual(presetSpec(preset), spec)
+		rechunk := changed && (preset.ChunkMaxTokens != spec.ChunkMaxTokens || preset.ChunkOverlapTokens != spec.ChunkOverlapTokens)
+		if created || changed {
+			applyPresetSpec(&preset, spec)
+			if err := imp.tx.Save(&preset).Error; err != nil {
+				return err
+			}
+		}
+		if rechunk {
+			imp.rechunkedPresets = append(imp.rechunkedPresets, preset.ID)
+		}
+		imp.report.record(ConfigKindRetrievalPreset, spec.Name, created, changed)
+	}
+
+	var presets []models.RetrievalPreset
+	if err := imp.tx.Select("id", "name").Find(&presets).Error; err != nil {
+		return err
+	}
+	imp.presetIDs = make(map[string]uuid.UUID, len(presets))
+	for _, preset := range presets {
+		imp.presetIDs[preset.Name] = preset.ID
+	}
+	return nil
+}
+
+// presetID resolves a preset name of the bundle, nil for none
+func (imp *configImport) presetID(name string) (*uuid.UUID, error) {
+	if name == "" {
+		return nil, nil
+	}
+	id, ok := imp.presetIDs[name]
+	if !ok {
+		return nil, validationError("retrieval preset %q is neither in the bundle nor in this environment", name)
+	}
+	return &id, nil
+}
+
+func (imp *configImport) importCategories(bundle *ConfigBundle) error {
+	for _, category := range bundle.Categories {
+		name := strings.TrimSpace(category.Category)
+		if name == "" {
+			return validationError("category is required")
+		}
+		presetID, err := imp.presetID(category.RetrievalPreset)
+		if err != nil {
+			return err
+		}
+		if presetID == nil {
+			return validationError("category %q: retrieval_preset is required", name)
+		}
+
+		var existing models.CategoryRetrievalPreset
+		err = imp.tx.First(&existing, "category = ?", name).Error
+		created := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !created {
+			return err
+		}
+		changed := !created && existing.PresetID != *presetID
+		if created || changed {
+			assignment := models.CategoryRetrievalPreset{Category: name, PresetID: *presetID}
+			err := imp.tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "category"}},
+				DoUpdates: clause.AssignmentColumns([]string{"preset_id", "updated_at"}),
+			}).Create(&assignment).Error
+			if err != nil {
+				return err
+			}
+			imp.reassignedCategories = append(imp.reassignedCategories, name)
+		}
+		imp.report.record(ConfigKindCategory, name, created, changed)
+	}
+	return nil
+}
+
+func (imp *configImport) importTemplates(bundle *ConfigBundle) error {
+	for _, config := range bundle.Templates {
+		config.Name = strings.TrimSpace(config.Name)
+		config.Category = strings.TrimSpace(config.Category)
+		if config.Name == "" || config.Category == "" {
+			return validationError("templates need a name and a category")
+		}
+		label := config.Category + "/" + config.Name
+		if config.Fields == nil {
+			config.Fields = []TemplateFieldConfig{}
+		}
+		sort.SliceStable(config.Fields, func(i, j int) bool {
+			a, b := config.Fields[i], config.Fields[j]
+			return a.Order < b.Order || (a.Order == b.Order && a.Name < b.Name)
+		})
+		for _, field := range config.Fields {
+			if field.Name == "" || field.Label == "" || field.Type == "" {
+				return validationError("template %q: fields need a name, a label and a type", label)
+			}
+		}
+		presetID, err := imp.presetID(config.RetrievalPreset)
+		if err != nil {
+			return err
+		}
+
+		var template models.Template
+		err = imp.tx.Preload("Fields", orderTemplateFields).First(&template, "name = ? AND category = ?", config.Name, config.Category).Error
+		created := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !created {
+			return err
+		}
+
+		var current TemplateConfig
+		if !created {
+			current = templateConfig(template, imp.presetNames())
+		}
+		changed := !created && !reflect.DeepEqual(current, config)
+		if !created && !changed {
+			imp.report.Unchanged++
+			continue
+		}
+
+		if created {
+			template.CreatedBy = imp.adminID
+		}
+		template.Name = config.Name
+		template.Description = config.Description
+		template.Category = config.Category
+		template.IsActive = config.IsActive
+		template.RetrievalPresetID = presetID
+		template.Fields = nil
+		if err := saveAll(imp.tx.Omit("Fields", "Creator"), &template, &template.ID, created); err != nil {
+			return err
+		}
+		if !created {
+			if err := imp.tx.Where("template_id = ?", template.ID).Delete(&models.TemplateField{}).Error; err != nil {
+				return err
+			}
+		}
+		for _, field := range config.Fields {
+			row := models.TemplateField{
+				TemplateID:  template.ID,
+				Name:        field.Name,
+				Type:        field.Type,
+				Label:       field.Label,
+				Description: field.Description,
+				Required:    field.Required,
+				Options:     field.Options,
+				Placeholder: field.Placeholder,
+				Validation:  field.Validation,
+				Order:       field.Order,
+			}
+			if err := imp.tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+
+		if created || current.RetrievalPreset != config.RetrievalPreset {
+			imp.reassignedTemplates = append(imp.reassignedTemplates, template.ID)
+		}
+		imp.report.record(ConfigKindTemplate, label, created, changed)
+	}
+	return nil
+}
+
+func (imp *configImport) presetNames() map[uuid.UUID]string {
+	names := make(map[uuid.UUID]string, len(imp.presetIDs))
+	for name, id := range imp.presetIDs {
+		names[id] = name
+	}
+	return names
+}
+
+func (imp *configImport) importPrompts(bundle *ConfigBundle) error {
+	for _, config := range bundle.Prompts {
+		if config.Name == "" {
+			return validationError("prompt name is required")
+		}
+		spec := PromptTemplateSpec{Description: config.Description, Content: config.Content, Variables: config.Variables, IsActive: true}
+		variables, err := validatePromptSpec(spec)
+		if err != nil {
+			return fmt.Errorf("prompt %q: %w", config.Name, err)
+		}
+
+		var active models.PromptTemplate
+		err = imp.tx.First(&active, "name = ? AND is_active = ?", config.Name, true).Error
+		missing := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !missing {
+			return err
+		}
+		if !missing {
+			current := promptConfig(active)
+			if current.Variables == nil {
+				current.Variables = map[string]string{}
+			}
+			if config.Variables == nil {
+				config.Variables = map[string]string{}
+			}
+			if reflect.DeepEqual(current, config) {
+				imp.report.Unchanged++
+				continue
+			}
+		}
+
+		var versions int64
+		if err := imp.tx.Model(&models.PromptTemplate{}).Where("name = ?", config.Name).Count(&versions).Error; err != nil {
+			return err
+		}
+		prompt := &models.PromptTemplate{
+			Name:        config.Name,
+			Description: config.Description,
+			Content:     config.Content,
+			Variables:   variables,
+			IsActive:    true,
+			CreatedBy:   &imp.adminID,
+		}
+		if err := createPromptVersion(imp.tx, prompt); err != nil {
+			return err
+		}
+		imp.report.record(ConfigKindPrompt, config.Name, versions == 0, true)
+	}
+	return nil
+}
+
+func (imp *configImport) importRetentionPolicies(bundle *ConfigBundle) error {
+	for _, config := range bundle.RetentionPolicies {
+		incoming := models.ChatRetentionPolicy{
+			Name:               config.Name,
+			Team:               config.Team,
+			MaxAgeMonths:       config.MaxAgeMonths,
+			Action:             config.Action,
+			ExportBeforeDelete: config.ExportBeforeDelete,
+			IsActive:           config.IsActive,
+		}
+		if err := validateRetentionPolicy(&incoming); err != nil {
+			return fmt.Errorf("retention policy %q: %w", config.Name, err)
+		}
+
+		var policy models.ChatRetentionPolicy
+		err := imp.tx.First(&policy, "name = ? AND team = ?", incoming.Name, incoming.Team).Error
+		created := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !created {
+			return err
+		}
+		changed := !created && retentionPolicyConfig(policy) != retentionPolicyConfig(incoming)
+		if created {
+			policy.CreatedBy = imp.adminID
+		}
+		if created || changed {
+			policy.Name = incoming.Name
+			policy.Team = incoming.Team
+			policy.MaxAgeMonths = incoming.MaxAgeMonths
+			policy.Action = incoming.Action
+			policy.ExportBeforeDelete = incoming.ExportBeforeDelete
+			policy.IsActive = incoming.IsActive
+			if err := saveAll(imp.tx, &policy, &policy.ID, created); err != nil {
+				return err
+			}
+		}
+		name := incoming.Name
+		if incoming.Team != "" {
+			name += " (" + incoming.Team + ")"
+		}
+		imp.report.record(ConfigKindRetentionPolicy, name, created, changed)
+	}
+	return nil
+}
+
+func (imp *configImport) importOrgQuota(bundle *ConfigBundle) error {
+	config := bundle.OrgQuota
+	if config == nil {
+		return nil
+	}
+	if config.MonthlyTokenLimit < 0 || config.MonthlyCostLimitUSD < 0 {
+		return ErrQuotaInvalidSpec
+	}
+
+	var quota models.UsageQuota
+	err := imp.tx.First(&quota, "user_id IS NULL").Error
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return err
+	}
+	changed := !created && *orgQuotaConfig(quota) != *config
+	if created || changed {
+		quota.MonthlyTokenLimit = config.MonthlyTokenLimit
+		quota.MonthlyCostLimitUSD = config.MonthlyCostLimitUSD
+		quota.Note = config.Note
+		quota.UpdatedBy = imp.adminID
+		if err := imp.tx.Save(&quota).Error; err != nil {
+			return err
+		}
+	}
+	imp.report.record(ConfigKindOrgQuota, "organization", created, changed)
+	return nil
+}
+
+func (imp *configImport) importHelpScreens(bundle *ConfigBundle) error {
+	for _, config := range bundle.HelpScreens {
+		if !helpScreenIDPattern.MatchString(config.ScreenID) {
+			return validationError("help screen %q: screen ID must be lowercase dotted segments such as orders.pending", config.ScreenID)
+		}
+
+		var screen models.HelpScreen
+		err := imp.tx.First(&screen, "screen_id = ?", config.ScreenID).Error
+		created := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !created {
+			return err
+		}
+		changed := !created && (screen.Title != config.Title || screen.Description != config.Description)
+		if created || changed {
+			// The tips were written for the old text
+			screen.ScreenID = config.ScreenID
+			screen.Title = config.Title
+			screen.Description = config.Description
+			screen.Tips = "[]"
+			screen.TipsGeneratedAt = nil
+			screen.UpdatedBy = &imp.adminID
+			if err := imp.tx.Save(&screen).Error; err != nil {
+				return err
+			}
+		}
+		imp.report.record(ConfigKindHelpScreen, config.ScreenID, created, changed)
+	}
+	return nil
+}
+
+// saveAll writes every column of a record, so that false values are not replaced by column defaults on create
+func saveAll(tx *gorm.DB, value interface{}, id *uuid.UUID, create bool) error {
+	if !create {
+		return tx.Save(value).Error
+	}
+	*id = uuid.New()
+	return tx.Select("*").Create(value).Error
+}
+
+func presetSpec(preset models.RetrievalPreset) RetrievalPresetSpec {
+	return RetrievalPresetSpec{
+		Name:               preset.Name,
+		Description:        preset.Description,
+		ChunkMaxTokens:     preset.ChunkMaxTokens,
+		ChunkOverlapTokens: preset.ChunkOverlapTokens,
+		TopK:               preset.TopK,
+		ScoreThreshold:     preset.ScoreThreshold,
+	}
+}
+
+func orderTemplateFields(db *gorm.DB) *gorm.DB {
+	return db.Order(`"order" ASC, name ASC`)
+}
+
+func templateConfig(template models.Template, presetNames map[uuid.UUID]string) TemplateConfig {
+	config := TemplateConfig{
+		Name:        template.Name,
+		Description: template.Description,
+		Category:    template.Category,
+		IsActive:    template.IsActive,
+		Fields:      []TemplateFieldConfig{},
+	}
+	if template.RetrievalPresetID != nil {
+		config.RetrievalPreset = presetNames[*template.RetrievalPresetID]
+	}
+	for _, field := range template.Fields {
+		config.Fields = append(config.Fields, TemplateFieldConfig{
+			Name:        field.Name,
+			Type:        field.Type,
+			Label:       field.Label,
+			Description: field.Description,
+			Required:    field.Required,
+			Options:     field.Options,
+			Placeholder: field.Placeholder,
+			Validation:  field.Validation,
+			Order:       field.Order,
+		})
+	}
+	return config
+}
+
+func promptConfig(prompt models.PromptTemplate) PromptConfig {
+	config := PromptConfig{Name: prompt.Name, Description: prompt.Description, Content: prompt.Content}
+	if prompt.Variables != "" {
+		if err := json.Unmarshal([]byte(prompt.Variables), &config.Variables); err != nil {
+			log.Printf("[WARNING] Ignoring invalid variables of prompt template %s version %d: %v", prompt.Name, prompt.Version, err)
+		}
+	}
+	return config
+}
+
+func retentionPolicyConfig(policy models.ChatRetentionPolicy) RetentionPolicyConfig {
+	return RetentionPolicyConfig{
+		Name:               policy.Name,
+		Team:               policy.Team,
+		MaxAgeMonths:       policy.MaxAgeMonths,
+		Action:             policy.Action,
+		ExportBeforeDelete: policy.ExportBeforeDelete,
+		IsActive:           policy.IsActive,
+	}
+}
+
+func orgQuotaConfig(quota models.UsageQuota) *OrgQuotaConfig {
+	return &OrgQuotaConfig{
+		MonthlyTokenLimit:   quota.MonthlyTokenLimit,
+		MonthlyCostLimitUSD: quota.MonthlyCostLimitUSD,
+		Note:                quota.Note,
+	}
+}
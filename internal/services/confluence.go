@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeConfluenceSync is the job type of a Confluence space sync
+const JobTypeConfluenceSync = "confluence_sync"
+
+// ConfluenceSyncTriggerManual is the trigger of syncs queued on request
+const ConfluenceSyncTriggerManual = "manual"
+
+const (
+	confluencePageLimit       = 50
+	confluenceSyncEmail       = "confluence-sync@integrations.local"
+	defaultConfluenceCategory = "Confluence"
+)
+
+// ConfluenceConfig connects the Confluence integration to a space. With Email set the API token is
+// sent with basic auth (Confluence Cloud), otherwise as a bearer personal access token (Server and Data Center).
+type ConfluenceConfig struct {
+	BaseURL  string // e.g. https://example.atlassian.net/wiki
+	Email    string
+	APIToken string
+	SpaceKey string
+	Category string // Category of the synced entries
+}
+
+// ConfluenceService syncs the pages of a Confluence space into knowledge entries. Pages are compared by
+// version, so only new and edited pages are fetched into new entries; pages removed from the space lose theirs.
+type ConfluenceService struct {
+	db         *gorm.DB
+	knowledge  *KnowledgeService
+	jobQueue   *JobQueue
+	config     ConfluenceConfig
+	httpClient *http.Client
+}
+
+// ConfluenceSyncReport describes what a sync changed
+type ConfluenceSyncReport struct {
+	SpaceKey    string    `json:"space_key"`
+	Pages       int       `json:"pages"`
+	Created     int       `json:"created"`
+	Updated     int       `json:"updated"`
+	Unchanged   int       `json:"unchanged"`
+	Deleted     int       `json:"deleted"`
+	Failed      int       `json:"failed"`
+	Errors      []string  `json:"errors,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// ConfluenceStatus describes the integration and the pages synced so far
+type ConfluenceStatus struct {
+	Enabled      bool       `json:"enabled"`
+	BaseURL      string     `json:"base_url,omitempty"`
+	SpaceKey     string     `json:"space_key,omitempty"`
+	Category     string     `json:"category,omitempty"`
+	SyncedPages  int64      `json:"synced_pages"`
+	FailedPages  int64      `json:"failed_pages"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+}
+
+type confluenceSyncPayload struct {
+	Trigger string `json:"trigger"`
+}
+
+// confluencePage is a page of the content API with its storage format body
+type confluencePage struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+type confluencePageList struct {
+	Results []confluencePage `json:"results"`
+	Links   struct {
+		Base string `json:"base"`
+		Next string `json:"next"`
+	} `json:"_links"`
+}
+
+// NewConfluenceService creates the Confluence integration. It is disabled unless the base URL, API token
+// and space key are configured.
+func NewConfluenceService(db *gorm.DB, knowledge *KnowledgeService, jobQueue *JobQueue, config ConfluenceConfig) *ConfluenceService {
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Category == "" {
+		config.Category = defaultConfluenceCategory
+	}
+	return &ConfluenceService{
+		db:         db,
+		knowledge:  knowledge,
+		jobQueue:   jobQueue,
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Enabled reports whether a space is configured
+func (s *ConfluenceService) Enabled() bool {
+	return s.config.BaseURL != "" && s.config.APIToken != "" && s.config.SpaceKey != ""
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *ConfluenceService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeConfluenceSync, s.handleSyncJob)
+}
+
+// ScheduleSync queues a manual sync
+func (s *ConfluenceService) ScheduleSync(ctx context.Context) (*models.Job, error) {
+	if !s.Enabled() {
+		return nil, validationError("the Confluence integration is not configured")
+	}
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeConfluenceSync, confluenceSyncPayload{Trigger: ConfluenceSyncTriggerManual}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// handleSyncJob runs a sync as a background job
+func (s *ConfluenceService) handleSyncJob(ctx context.Context, job *models.Job) error {
+	if !s.Enabled() {
+		log.Printf("[INFO] Skipped Confluence sync: the integration is no longer configured")
+		return nil
+	}
+
+	// Pages that fail are recorded with their error and retried by the next sync, not by retrying the job
+	_, err := s.Sync(ctx)
+	return err
+}
+
+// Sync brings the knowledge entries in line with the pages of the space. New pages get entries, pages
+// with a newer version get new entries replacing their old ones, and pages gone from the space lose
+// theirs. A page that fails keeps the entries of the sync before.
+func (s *ConfluenceService) Sync(ctx context.Context) (*ConfluenceSyncReport, error) {
+	if !s.Enabled() {
+		return nil, validationError("the Confluence integration is not configured")
+	}
+	report := &ConfluenceSyncReport{SpaceKey: s.config.SpaceKey, StartedAt: time.Now()}
+
+	pages, baseURL, err := s.listPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Pages = len(pages)
+
+	var synced []models.ConfluencePage
+	if err := s.db.Where("space_key = ?", s.config.SpaceKey).Find(&synced).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]*models.ConfluencePage, len(synced))
+	for i := range synced {
+		known[synced[i].PageID] = &synced[i]
+	}
+
+	createdBy, err := integrationUser(s.db, confluenceSyncEmail, "Confluence sync")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		record := known[page.ID]
+		delete(known, page.ID)
+		if record != nil && record.Version == page.Version.Number && record.SyncError == "" {
+			report.Unchanged++
+			continue
+		}
+		if err := s.syncPage(ctx, page, baseURL, record, createdBy); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("page %s (%s): %v", page.ID, page.Title, err))
+			log.Printf("[WARNING] Failed to sync Confluence page %s (%s): %v", page.ID, page.Title, err)
+		} else if record == nil {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	// Pages deleted, archived or moved out of the space
+	for _, record := range known {
+		if err := s.removePage(record); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("page %s (%s): %v", record.PageID, record.Title, err))
+			continue
+		}
+		report.Deleted++
+	}
+
+	report.CompletedAt = time.Now()
+	log.Printf("[INFO] Synced Confluence space %s: %d pages, %d created, %d updated, %d deleted, %d failed",
+		report.SpaceKey, report.Pages, report.Created, report.Updated, report.Deleted, report.Failed)
+	return report, nil
+}
+
+// syncPage replaces the entries of a page with entries created from its current version
+func (s *ConfluenceService) syncPage(ctx context.Context, page confluencePage, baseURL string, record *models.ConfluencePage, createdBy uuid.UUID) error {
+	if record == nil {
+		record = &models.ConfluencePage{PageID: page.ID, SpaceKey: s.config.SpaceKey}
+	}
+	record.Title = page.Title
+	record.URL = baseURL + page.Links.WebUI
+	record.SyncedAt = time.Now()
+
+	fail := func(err error) error {
+		record.SyncError = err.Error()
+		if saveErr := s.db.Save(record).Error; saveErr != nil {
+			log.Printf("[ERROR] Failed to save Confluence page %s: %v", page.ID, saveErr)
+		}
+		return err
+	}
+
+	text := htmlParagraphs(page.Body.Storage.Value)
+	if text == "" {
+		return fail(errors.New("page has no content"))
+	}
+	if findings := ScanForPromptInjection(text); len(findings) > 0 {
+		rules := make([]string, len(findings))
+		for i, finding := range findings {
+			rules[i] = finding.Rule
+		}
+		return fail(fmt.Errorf("page contains instruction-like content (%s)", strings.Join(rules, ", ")))
+	}
+
+	fieldData, _ := json.Marshal(map[string]interface{}{
+		"source_url":         record.URL,
+		"confluence_page_id": page.ID,
+		"confluence_space":   s.config.SpaceKey,
+		"confluence_version": page.Version.Number,
+	})
+	entryIDs, err := createSectionEntries(ctx, s.knowledge, splitIntoSections(text, defaultSectionOptions()), sectionEntry{
+		Title:     page.Title,
+		Category:  s.config.Category,
+		Tag:       "confluence",
+		FieldData: string(fieldData),
+		CreatedBy: createdBy,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	previous, err := pageEntryIDs(record)
+	if err != nil {
+		log.Printf("[WARNING] %v", err)
+	}
+	encoded, _ := json.Marshal(entryIDs)
+	record.Version = page.Version.Number
+	record.KnowledgeEntryIDs = string(encoded)
+	record.SyncError = ""
+	if err := s.db.Save(record).Error; err != nil {
+		deleteSectionEntries(s.knowledge, entryIDs)
+		return fmt.Errorf("failed to save page: %w", err)
+	}
+	deleteSectionEntries(s.knowledge, previous)
+	return nil
+}
+
+// removePage deletes a page that left the space along with its entries
+func (s *ConfluenceService) removePage(record *models.ConfluencePage) error {
+	entryIDs, err := pageEntryIDs(record)
+	if err != nil {
+		return err
+	}
+	deleteSectionEntries(s.knowledge, entryIDs)
+	return s.db.Delete(record).Error
+}
+
+func pageEntryIDs(record *models.ConfluencePage) ([]uuid.UUID, error) {
+	entryIDs, err := decodeEntryIDs(record.KnowledgeEntryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid knowledge entry IDs of Confluence page %s: %w", record.PageID, err)
+	}
+	return entryIDs, nil
+}
+
+// Status describes the integration and the pages synced so far
+func (s *ConfluenceService) Status(ctx context.Context) (*ConfluenceStatus, error) {
+	status := &ConfluenceStatus{
+		Enabled:  s.Enabled(),
+		BaseURL:  s.config.BaseURL,
+		SpaceKey: s.config.SpaceKey,
+		Category: s.config.Category,
+	}
+	if !status.Enabled {
+		return status, nil
+	}
+
+	var row struct {
+		Synced   int64
+		Failed   int64
+		LastSync *time.Time
+	}
+	err := s.db.WithContext(ctx).Model(&models.ConfluencePage{}).
+		Select("COUNT(*) AS synced, COUNT(*) FILTER (WHERE sync_error <> '') AS failed, MAX(synced_at) AS last_sync").
+		Where("space_key = ?", s.config.SpaceKey).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	status.SyncedPages = row.Synced
+	status.FailedPages = row.Failed
+	status.LastSyncedAt = row.LastSync
+	return status, nil
+}
+
+// listPages returns the current pages of the space with their bodies, and the base URL of page links
+func (s *ConfluenceService) listPages(ctx context.Context) ([]confluencePage, string, error) {
+	query := url.Values{}
+	query.Set("spaceKey", s.config.SpaceKey)
+	query.Set("type", "page")
+	query.Set("status", "current")
+	query.Set("expand", "version,body.storage")
+	query.Set("limit", fmt.Sprint(confluencePageLimit))
+	next := "/rest/api/content?" + query.Encode()
+
+	var pages []confluencePage
+	baseURL := s.config.BaseURL
+	for next != "" {
+		var list confluencePageList
+		if err := s.get(ctx, baseURL+next, &list); err != nil {
+			return nil, "", err
+		}
+		pages = append(pages, list.Results...)
+		if list.Links.Base != "" {
+			baseURL = strings.TrimRight(list.Links.Base, "/")
+		}
+		next = list.Links.Next
+	}
+	return pages, baseURL, nil
+}
+
+func (s *ConfluenceService) get(ctx context.Context, rawURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.config.Email != "" {
+		req.SetBasicAuth(s.config.Email, s.config.APIToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIToken)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Confluence request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Confluence returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid Confluence response: %w", err)
+	}
+	return nil
+}
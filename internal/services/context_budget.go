@@ -0,0 +1,130 @@
+package services
+
+import (
+	"log"
+	"strings"
+)
+
+const (
+	defaultContextWindow  = 8192 // Models missing from modelContextWindows are assumed to have this window
+	defaultCompletionSize = 1024 // Tokens kept for the completion when the provider has no max_tokens
+	contextWindowHeadroom = 0.9  // Share of the window budgeted, since token counts are estimates
+	messageOverheadTokens = 4    // Role and separators each chat message costs on top of its content
+	imageTokens           = 765  // An attached image of about 1024x1024 pixels at OpenAI's high detail
+	contextWrapperTokens  = 80   // Instructions wrapped around the passages by the system message builders
+	minPassageTokens      = 48   // Passages are never summarized to less than this; the last ones are dropped instead
+	passageSummarySuffix  = " …"
+)
+
+// modelContextWindows are the context windows in tokens of common models, by model name prefix. More specific
+// prefixes come first.
+var modelContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"gemini-1.5", 1048576},
+	{"gemini-2", 1048576},
+	{"gemini-pro", 32760},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3", 8192},
+	{"mistral", 32768},
+	{"qwen2.5", 32768},
+}
+
+// contextWindow returns the context window of a model, or override when it is positive
+func contextWindow(model string, override int) int {
+	if override > 0 {
+		return override
+	}
+	model = strings.ToLower(model)
+	for _, known := range modelContextWindows {
+		if strings.HasPrefix(model, known.prefix) {
+			return known.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// messageTokens estimates the tokens a chat message takes in the context window
+func messageTokens(content string) int {
+	return EstimateTokens(content) + messageOverheadTokens
+}
+
+// contextBudget is how many tokens of knowledge base passages fit in the context window of model next to the
+// system prompt, conversationTokens of conversation and a completion of up to maxTokens
+func contextBudget(model string, windowOverride, maxTokens int, prompt string, conversationTokens int) int {
+	if maxTokens <= 0 {
+		maxTokens = defaultCompletionSize
+	}
+	window := int(float64(contextWindow(model, windowOverride)) * contextWindowHeadroom)
+	return window - maxTokens - messageTokens(prompt) - contextWrapperTokens - conversationTokens
+}
+
+// fitContext fits knowledge base passages, best first, into budget tokens. Passages are kept whole while they
+// fit; the rest are summarized to their leading sentences so that they keep their citation marker, and once
+// not even a summary fits the remaining passages are dropped. The first passage is always kept.
+func fitContext(model string, context []string, budget int) []string {
+	total := 0
+	for _, passage := range context {
+		total += EstimateTokens(passage)
+	}
+	if total <= budget {
+		return context
+	}
+
+	fitted := make([]string, 0, len(context))
+	remaining := budget
+	summarized := 0
+	for i, passage := range context {
+		if i > 0 && remaining < minPassageTokens {
+			break
+		}
+		// Leave the passages after this one room for at least a summary
+		allowance := remaining - (len(context)-i-1)*minPassageTokens
+		if allowance < minPassageTokens {
+			allowance = minPassageTokens
+		}
+		tokens := EstimateTokens(passage)
+		if tokens > allowance {
+			passage = summarizePassage(passage, allowance)
+			tokens = EstimateTokens(passage)
+			summarized++
+		}
+		fitted = append(fitted, passage)
+		remaining -= tokens
+	}
+
+	log.Printf("[INFO] Fitted knowledge base context for %s into %d tokens: %d of %d passages summarized, %d dropped",
+		model, budget, summarized, len(context), len(context)-len(fitted))
+	return fitted
+}
+
+// summarizePassage shortens a passage to its leading sentences, its title first, within maxTokens
+func summarizePassage(passage string, maxTokens int) string {
+	maxTokens -= EstimateTokens(passageSummarySuffix)
+	end, tokens := 0, 0
+	for _, sentence := range splitSentences(passage, EstimateTokens) {
+		if tokens+sentence.tokens > maxTokens {
+			if end == 0 {
+				// Not even the first sentence fits: cut it between words
+				if words := splitWords(passage, sentence, maxTokens, ""); len(words) > 0 {
+					end = words[0].end
+				}
+			}
+			break
+		}
+		end = sentence.end
+		tokens += sentence.tokens
+	}
+	return strings.TrimSpace(passage[:end]) + passageSummarySuffix
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// contextFilesEmail is the user context files are imported as when the upload is not signed in
+const contextFilesEmail = "context-files@system.local"
+
+// IngestContextFile imports an active context file into the knowledge base. Its labels are added to the tags
+// of its entries, so retrieval can be narrowed to them, and its status follows the import from Processing
+// to Active, Failed or Quarantined. Without a signed-in uploader the file is imported as a system user.
+func (s *IngestionService) IngestContextFile(ctx context.Context, file *models.ContextFile, content []byte, mimeType string, uploadedBy *uuid.UUID) error {
+	if s.knowledge == nil {
+		return validationError("knowledge base imports are not enabled")
+	}
+	var uploader uuid.UUID
+	if uploadedBy != nil {
+		uploader = *uploadedBy
+	} else {
+		var err error
+		if uploader, err = integrationUser(s.db, contextFilesEmail, "Context files"); err != nil {
+			return err
+		}
+	}
+
+	document, err := s.store(ctx, IngestRequest{
+		FileName:         file.FileName,
+		OriginalFileName: file.FileName,
+		MimeType:         mimeType,
+		Content:          content,
+		UploadedBy:       uploader,
+		Target:           models.IngestKnowledgeBase,
+	})
+	if err != nil {
+		return err
+	}
+	file.DocumentID = &document.ID
+	file.Status = contextFileStatus(document.Status)
+	err = s.db.WithContext(ctx).Model(&models.ContextFile{}).Where("id = ?", file.ID).
+		Updates(map[string]interface{}{"document_id": document.ID, "status": file.Status}).Error
+	if err != nil {
+		return err
+	}
+	if document.Status == models.DocumentQuarantined {
+		return nil
+	}
+	return s.scheduleIngest(ctx, document.ID)
+}
+
+// contextFileStatus returns the status of a context file whose document is in status
+func contextFileStatus(status models.DocumentStatus) string {
+	switch status {
+	case models.DocumentImported, models.DocumentIndexed, models.DocumentSuperseded:
+		return models.ContextFileActive
+	case models.DocumentProcessingFailed, models.DocumentRejected:
+		return models.ContextFileFailed
+	case models.DocumentQuarantined:
+		return models.ContextFileQuarantined
+	default:
+		return models.ContextFileProcessing
+	}
+}
+
+// syncContextFile carries the status of a document over to the context file it imports, if any
+func (s *IngestionService) syncContextFile(documentID uuid.UUID, status models.DocumentStatus) {
+	err := s.db.Model(&models.ContextFile{}).Where("document_id = ?", documentID).
+		Update("status", contextFileStatus(status)).Error
+	if err != nil {
+		log.Printf("[WARNING] Failed to update the context file of document %s: %v", documentID, err)
+	}
+}
+
+// tagContextLabels adds the labels of the context file a document imports to the tags of its entries
+func (s *IngestionService) tagContextLabels(ctx context.Context, document *models.UploadedDocument, entries []ProposedEntry) {
+	var file models.ContextFile
+	if err := s.db.WithContext(ctx).Where("document_id = ?", document.ID).Limit(1).Find(&file).Error; err != nil {
+		log.Printf("[WARNING] Failed to look up the context file of document %s: %v", document.ID, err)
+		return
+	}
+	labels := splitCommaList(file.Labels)
+	if file.ID == 0 || len(labels) == 0 {
+		return
+	}
+	for i := range entries {
+		// Tags are stored comma-separated
+		entries[i].Tags += "," + strings.ToLower(strings.Join(labels, ","))
+	}
+}
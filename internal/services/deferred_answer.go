@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// QuestionQueue is the job queue for deferred answers
+const QuestionQueue = "questions"
+
+// JobTypeAnswerQueuedQuestion answers a queued question and notifies the user
+const JobTypeAnswerQueuedQuestion = "answer_queued_question"
+
+// QueueReasonResearch marks questions explicitly submitted for a later, long-running answer
+const QueueReasonResearch = "research"
+
+const (
+	deferredAnswerDelay       = time.Minute
+	deferredAnswerMaxAttempts = 10
+)
+
+// queuedQuestionPayload is the job payload for answering a queued question
+type queuedQuestionPayload struct {
+	QueuedQuestionID uuid.UUID `json:"queued_question_id"`
+}
+
+// DeferredAnswerService answers queued questions in the background, records the answer
+// in the original chat session and notifies the user
+type DeferredAnswerService struct {
+	db               *gorm.DB
+	unifiedAIService *UnifiedAIService
+	knowledgeService *KnowledgeService
+	jobQueue         *JobQueue
+	notifier         Notifier
+	usage            *UsageService
+	announcements    *AnnouncementService
+}
+
+// NewDeferredAnswerService creates the deferred answer service
+func NewDeferredAnswerService(db *gorm.DB, unifiedAIService *UnifiedAIService, knowledgeService *KnowledgeService, jobQueue *JobQueue, notifier Notifier) *DeferredAnswerService {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &DeferredAnswerService{
+		db:               db,
+		unifiedAIService: unifiedAIService,
+		knowledgeService: knowledgeService,
+		jobQueue:         jobQueue,
+		notifier:         notifier,
+	}
+}
+
+// SetUsageService records the token usage and cost of every answer
+func (s *DeferredAnswerService) SetUsageService(usage *UsageService) {
+	s.usage = usage
+}
+
+// SetAnnouncements adds the active org-wide announcements to the system prompt of every answer
+func (s *DeferredAnswerService) SetAnnouncements(announcements *AnnouncementService) {
+	s.announcements = announcements
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *DeferredAnswerService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeAnswerQueuedQuestion, func(ctx context.Context, job *models.Job) error {
+		var payload queuedQuestionPayload
+		if err := DecodeJobPayload(job, &payload); err != nil {
+			return err
+		}
+		err := s.AnswerQueuedQuestion(ctx, payload.QueuedQuestionID)
+		if err != nil && job.Attempts >= job.MaxAttempts {
+			s.db.Model(&models.QueuedQuestion{}).Where("id = ?", payload.QueuedQuestionID).Updates(map[string]interface{}{
+				"status":     models.QueuedQuestionFailed,
+				"last_error": err.Error(),
+			})
+		}
+		return err
+	})
+}
+
+// QueueQuestion records a question for a later answer and schedules the answering job
+func (s *DeferredAnswerService) QueueQuestion(ctx context.Context, sessionID, userID uuid.UUID, messageID *uuid.UUID, question, reason string) (*models.QueuedQuestion, error) {
+	queued := &models.QueuedQuestion{
+		SessionID: sessionID,
+		UserID:    userID,
+		MessageID: messageID,
+		Question:  question,
+		Reason:    reason,
+		Status:    models.QueuedQuestionPending,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(queued).Error; err != nil {
+			return err
+		}
+		// A queued question counts as answered, so it is not replayed as well
+		if messageID != nil {
+			if err := markAnswered(tx, *messageID); err != nil {
+				return err
+			}
+		}
+		_, err := s.jobQueue.EnqueueTx(tx, QuestionQueue, JobTypeAnswerQueuedQuestion, queuedQuestionPayload{QueuedQuestionID: queued.ID}, &EnqueueOptions{
+			MaxAttempts: deferredAnswerMaxAttempts,
+			RunAt:       time.Now().Add(deferredAnswerDelay),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue question: %w", err)
+	}
+
+	log.Printf("[INFO] Queued question %s for session %s (reason=%s)", queued.ID, sessionID, reason)
+	return queued, nil
+}
+
+// AnswerQueuedQuestion generates the answer for a pending queued question
+func (s *DeferredAnswerService) AnswerQueuedQuestion(ctx context.Context, id uuid.UUID) error {
+	var queued models.QueuedQuestion
+	if err := s.db.WithContext(ctx).First(&queued, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to load queued question %s: %w", id, err)
+	}
+	if queued.Status != models.QueuedQuestionPending {
+		log.Printf("[INFO] Queued question %s is already %s, skipping", id, queued.Status)
+		return nil
+	}
+
+	scope := s.knowledgeService.ScopeForUser(queued.UserID)
+	entries, citations, err := s.knowledgeService.SearchKnowledgeWithCitations(ctx, queued.Question, 5, scope)
+	if err != nil {
+		log.Printf("[WARNING] Knowledge search failed for queued question %s, answering without context: %v", id, err)
+		entries, citations = nil, nil
+	}
+	context := citationContext(entries)
+
+	// Queued questions are answered as the persona of their session, like the questions answered right away
+	var persona string
+	var session models.ChatSession
+	if err := s.db.WithContext(ctx).Select("id", "persona_id").First(&session, "id = ?", queued.SessionID).Error; err == nil {
+		persona = sessionPersonaPrompt(ctx, s.db, &session)
+	}
+
+	aiResponse, err := s.unifiedAIService.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages:         []UnifiedChatMessage{{Role: "user", Content: queued.Question}},
+		Context:          context,
+		SessionID:        queued.SessionID.String(),
+		UseKnowledgeBase: len(context) > 0,
+		SystemPrompt:     s.announcements.SystemPrompt(persona),
+	})
+	if err != nil {
+		s.db.Model(&queued).Update("last_error", err.Error())
+		return fmt.Errorf("failed to answer queued question %s: %w", id, err)
+	}
+
+	var sources []string
+	for _, entry := range entries {
+		sources = append(sources, entry.ID.String())
+	}
+
+	// Record the answer in the original session and mark the question answered together
+	now := time.Now()
+	assistantMessage := &models.ChatMessage{
+		SessionID: queued.SessionID,
+		Role:      models.AssistantMessage,
+		Content:   aiResponse.Message,
+		Metadata: buildMessageMetadata(aiResponse.Provider, aiResponse.Model, sources, map[string]interface{}{
+			"usage":              aiResponse.Usage,
+			"cost_usd":           EstimateCost(aiResponse.Model, aiResponse.Usage),
+			"retrieved":          len(entries),
+			"citations":          citations,
+			"deferred":           true,
+			"queued_question_id": queued.ID,
+		}),
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(assistantMessage).Error; err != nil {
+			return err
+		}
+		return tx.Model(&queued).Updates(map[string]interface{}{
+			"status":      models.QueuedQuestionAnswered,
+			"answered_at": &now,
+			"last_error":  "",
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record answer for queued question %s: %w", id, err)
+	}
+	log.Printf("[INFO] Answered queued question %s in session %s", id, queued.SessionID)
+	s.usage.RecordChat(queued.UserID, queued.SessionID, &assistantMessage.ID, queued.Question, aiResponse.Provider, aiResponse.Model, aiResponse.Usage)
+
+	// The answer is already recorded, so notification failures are logged instead of retried
+	s.notifyUser(ctx, &queued, aiResponse.Message, citations)
+	return nil
+}
+
+// notifyUser sends the deferred answer with its sources to the user who asked
+func (s *DeferredAnswerService) notifyUser(ctx context.Context, queued *models.QueuedQuestion, answer string, citations []Citation) {
+	var user models.User
+	if err := s.db.Select("id", "email", "name").First(&user, "id = ?", queued.UserID).Error; err != nil {
+		log.Printf("[WARNING] Failed to load user %s to notify about queued question %s: %v", queued.UserID, queued.ID, err)
+		return
+	}
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Hi %s,\n\n", user.Name))
+	body.WriteString("Here is the answer to the question you asked earlier:\n\n")
+	body.WriteString("> " + strings.ReplaceAll(queued.Question, "\n", "\n> ") + "\n\n")
+	body.WriteString(answer + "\n")
+	if len(citations) > 0 {
+		body.WriteString("\nSources:\n")
+		for _, citation := range citations {
+			body.WriteString(fmt.Sprintf("[%d] %s\n", citation.Index, citation.Title))
+		}
+	}
+	body.WriteString(fmt.Sprintf("\nThe answer has also been added to your chat session %s.\n", queued.SessionID))
+
+	if err := s.notifier.Notify(ctx, user.Email, "Your question has been answered", body.String()); err != nil {
+		log.Printf("[WARNING] Failed to notify user %s about queued question %s: %v", user.ID, queued.ID, err)
+	}
+}
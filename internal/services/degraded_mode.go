@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// QueueReasonProvidersUnavailable marks questions queued because every AI provider failed
+const QueueReasonProvidersUnavailable = "providers_unavailable"
+
+const (
+	degradedSearchLimit   = 5
+	degradedSummaryLength = 200
+)
+
+// respondDegraded answers with keyword search results when every AI provider failed,
+// and queues the question so that it can be answered once the providers recover
+func (s *EnhancedChatService) respondDegraded(ctx context.Context, session *models.ChatSession, req EnhancedChatRequest, userMessage *models.ChatMessage, providerErr error) (*EnhancedChatResponse, error) {
+	log.Printf("[WARNING] All AI providers failed, answering session %s in degraded mode: %v", session.ID, providerErr)
+
+	scope := s.knowledgeService.ScopeForUser(req.UserID)
+	scope.Filters = req.Filters
+	entries, err := s.knowledgeService.textSearch(req.Message, degradedSearchLimit, scope)
+	if err != nil {
+		log.Printf("[WARNING] Keyword search failed in degraded mode: %v", err)
+		entries = nil
+	}
+
+	var sources []string
+	citations := make([]Citation, len(entries))
+	for i := range entries {
+		sources = append(sources, entries[i].ID.String())
+		citations[i] = fallbackCitation(i+1, &entries[i], req.Message)
+	}
+
+	var queued *models.QueuedQuestion
+	if s.deferredAnswers != nil {
+		queued, err = s.deferredAnswers.QueueQuestion(ctx, session.ID, req.UserID, &userMessage.ID, req.Message, QueueReasonProvidersUnavailable)
+		if err != nil {
+			log.Printf("[ERROR] Failed to queue question for later answer: %v", err)
+			queued = nil
+		}
+	}
+
+	content := degradedAnswer(entries, queued != nil)
+	metadata := map[string]interface{}{
+		"degraded":  true,
+		"retrieved": len(entries),
+		"citations": citations,
+	}
+	if queued != nil {
+		metadata["queued_question_id"] = queued.ID
+	}
+
+	assistantMessage := &models.ChatMessage{
+		SessionID: session.ID,
+		Role:      models.AssistantMessage,
+		Content:   content,
+		Metadata:  buildMessageMetadata("", "", sources, metadata),
+	}
+	if err := s.db.Create(assistantMessage).Error; err != nil {
+		log.Printf("[ERROR] Failed to save degraded assistant message to database: %v", err)
+		return nil, err
+	}
+
+	response := &EnhancedChatResponse{
+		Response:  content,
+		SessionID: session.ID,
+		Sources:   sources,
+		CreatedAt: assistantMessage.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		Citations: citations,
+		Degraded:  true,
+	}
+	if queued != nil {
+		response.QueuedQuestionID = &queued.ID
+	}
+	return response, nil
+}
+
+// degradedAnswer renders the apology template listing the best keyword matches
+func degradedAnswer(entries []models.KnowledgeEntry, queued bool) string {
+	var answer strings.Builder
+	answer.WriteString("Sorry, our AI assistant is temporarily unavailable and cannot answer your question right now.")
+
+	if len(entries) > 0 {
+		answer.WriteString("\n\nIn the meantime, these knowledge base articles may help:\n")
+		for i, entry := range entries {
+			summary := entry.Summary
+			if summary == "" {
+				summary = entry.Content
+			}
+			if runes := []rune(summary); len(runes) > degradedSummaryLength {
+				summary = string(runes[:degradedSummaryLength]) + "..."
+			}
+			answer.WriteString(fmt.Sprintf("\n[%d] %s\n%s\n", i+1, entry.Title, strings.TrimSpace(summary)))
+		}
+	} else {
+		answer.WriteString("\n\nWe could not find any knowledge base articles matching your question.\n")
+	}
+
+	if queued {
+		answer.WriteString("\nYour question has been saved and we will send you an answer as soon as the assistant is available again.")
+	}
+	return answer.String()
+}
+
+// GetQueuedQuestion returns a queued question by ID
+func (s *EnhancedChatService) GetQueuedQuestion(id uuid.UUID) (*models.QueuedQuestion, error) {
+	var queued models.QueuedQuestion
+	if err := s.db.First(&queued, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &queued, nil
+}
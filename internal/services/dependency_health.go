@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dependencies the server needs before it accepts traffic
+const (
+	DependencyDatabase   = "database"
+	DependencyVectorDB   = "qdrant"
+	DependencyAIProvider = "ai_provider"
+)
+
+const (
+	defaultDependencyCheckInterval = 15 * time.Second
+	dependencyCheckTimeout         = 5 * time.Second
+)
+
+// DependencyCheck is the result of checking one dependency
+type DependencyCheck struct {
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMs int64  `json:"latency_ms"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DependencyStatus reports whether the server is ready to receive traffic
+type DependencyStatus struct {
+	Ready        bool              `json:"ready"`
+	ShuttingDown bool              `json:"shutting_down,omitempty"`
+	CheckedAt    *time.Time        `json:"checked_at,omitempty"`
+	Checks       []DependencyCheck `json:"checks"`
+}
+
+// DependencyMonitor reports the server ready once the database, Qdrant, and at least one AI provider
+// are reachable, and not ready again while any of them is down or the server is shutting down
+type DependencyMonitor struct {
+	db            *gorm.DB
+	vectorService VectorStore
+	unifiedAI     *UnifiedAIService
+
+	mu           sync.RWMutex
+	status       DependencyStatus
+	shuttingDown atomic.Bool
+}
+
+// NewDependencyMonitor creates a monitor that is not ready until its first successful check
+func NewDependencyMonitor(db *gorm.DB, vectorService VectorStore, unifiedAI *UnifiedAIService) *DependencyMonitor {
+	return &DependencyMonitor{
+		db:            db,
+		vectorService: vectorService,
+		unifiedAI:     unifiedAI,
+		status:        DependencyStatus{Checks: []DependencyCheck{}},
+	}
+}
+
+// Start checks the dependencies in the background right away and then at every interval until ctx is cancelled
+func (m *DependencyMonitor) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultDependencyCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check checks every dependency once and records the result
+func (m *DependencyMonitor) Check(ctx context.Context) DependencyStatus {
+	checks := []DependencyCheck{
+		m.check(ctx, DependencyDatabase, m.pingDatabase),
+		m.check(ctx, DependencyVectorDB, func(ctx context.Context) (string, error) {
+			return "", m.vectorService.Ping(ctx)
+		}),
+		m.check(ctx, DependencyAIProvider, m.pingAIProviders),
+	}
+
+	ready := true
+	for _, check := range checks {
+		ready = ready && check.Healthy
+	}
+	now := time.Now()
+
+	m.mu.Lock()
+	if ready && !m.status.Ready {
+		log.Printf("[INFO] Dependencies reachable, server is ready")
+	} else if !ready && m.status.Ready {
+		log.Printf("[WARNING] A dependency is unreachable, server is not ready")
+	}
+	m.status = DependencyStatus{Ready: ready, CheckedAt: &now, Checks: checks}
+	m.mu.Unlock()
+
+	return m.Status()
+}
+
+// Status returns the result of the last check
+func (m *DependencyMonitor) Status() DependencyStatus {
+	m.mu.RLock()
+	status := m.status
+	m.mu.RUnlock()
+
+	if m.shuttingDown.Load() {
+		status.Ready = false
+		status.ShuttingDown = true
+	}
+	return status
+}
+
+// SetShuttingDown reports the server not ready from now on, so load balancers stop sending it new requests
+func (m *DependencyMonitor) SetShuttingDown() {
+	m.shuttingDown.Store(true)
+}
+
+// check runs a single dependency check with a timeout
+func (m *DependencyMonitor) check(ctx context.Context, name string, ping func(ctx context.Context) (string, error)) DependencyCheck {
+	checkCtx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := ping(checkCtx)
+	check := DependencyCheck{
+		Name:      name,
+		Healthy:   err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Detail:    detail,
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+func (m *DependencyMonitor) pingDatabase(ctx context.Context) (string, error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return "", err
+	}
+	return "", sqlDB.PingContext(ctx)
+}
+
+// pingAIProviders passes when at least one available provider answers
+func (m *DependencyMonitor) pingAIProviders(ctx context.Context) (string, error) {
+	reachable := m.unifiedAI.ProbeProviders(ctx)
+	if len(reachable) == 0 {
+		return "", fmt.Errorf("none of the AI providers %v is reachable", m.unifiedAI.GetAvailableProviders())
+	}
+
+	names := make([]string, len(reachable))
+	for i, provider := range reachable {
+		names[i] = string(provider)
+	}
+	return "reachable: " + strings.Join(names, ", "), nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Document webhook events
+const (
+	DocumentEventIndexed  = "document.indexed"
+	DocumentEventImported = "document.imported"
+	DocumentEventFailed   = "document.failed"
+)
+
+// Headers of outbound webhook requests. The signature is the hex HMAC-SHA256 of "<timestamp>\n<body>".
+const (
+	WebhookTimestampHeader = "X-TIC-Timestamp"
+	WebhookSignatureHeader = "X-TIC-Signature"
+	WebhookEventHeader     = "X-TIC-Event"
+)
+
+// DocumentEvent is the body posted to the document webhook
+type DocumentEvent struct {
+	Event        string                `json:"event"`
+	DocumentID   uuid.UUID             `json:"document_id"`
+	FileName     string                `json:"file_name"`
+	Status       models.DocumentStatus `json:"status"`
+	ErrorMessage string                `json:"error_message,omitempty"`
+	UploadedBy   uuid.UUID             `json:"uploaded_by"`
+	OccurredAt   time.Time             `json:"occurred_at"`
+}
+
+func newDocumentEvent(document *models.UploadedDocument) DocumentEvent {
+	event := DocumentEventFailed
+	switch document.Status {
+	case models.DocumentIndexed:
+		event = DocumentEventIndexed
+	case models.DocumentImported:
+		event = DocumentEventImported
+	}
+	return DocumentEvent{
+		Event:        event,
+		DocumentID:   document.ID,
+		FileName:     document.OriginalFileName,
+		Status:       document.Status,
+		ErrorMessage: document.ErrorMessage,
+		UploadedBy:   document.UploadedBy,
+		OccurredAt:   time.Now().UTC(),
+	}
+}
+
+// DocumentWebhook posts document processing events to a URL, signed when a secret is configured
+type DocumentWebhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewDocumentWebhook creates a webhook posting to url
+func NewDocumentWebhook(url, secret string) *DocumentWebhook {
+	return &DocumentWebhook{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts an event. Any response other than 2xx is an error.
+func (w *DocumentWebhook) Send(ctx context.Context, event DocumentEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.Event)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write([]byte(timestamp + "\n"))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %d - %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
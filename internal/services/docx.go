@@ -0,0 +1,459 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// docxMaxPartSize bounds the parts of a DOCX file that are read, against zip bombs
+const docxMaxPartSize = 256 << 20
+
+// docxBlock is a top-level block of a Word document: a paragraph, heading, list item or table
+type docxBlock struct {
+	text    string
+	heading int  // Heading level from 1, 0 for other blocks
+	list    bool // List item, nested level deep
+	level   int
+	numID   string
+	rows    [][]string // Table rows; the first row is rendered as the header
+}
+
+// docxParagraph collects a paragraph while its XML is read
+type docxParagraph struct {
+	text    strings.Builder
+	style   string
+	outline int // Outline level from 1 set on the paragraph itself, 0 when unset
+	numID   string
+	level   int
+}
+
+// docxTable collects a table while its XML is read
+type docxTable struct {
+	rows [][]string
+	cell *strings.Builder // Cell being read, nil between cells
+	span int              // Columns the cell being read spans
+}
+
+// docxStyles are the paragraph styles of a document that matter for extraction
+type docxStyles struct {
+	headings map[string]int    // Heading level by style ID
+	lists    map[string]string // Numbering ID by style ID, for list styles
+}
+
+// XML of word/styles.xml
+type docxStyleSheet struct {
+	Styles []struct {
+		Type    string   `xml:"type,attr"`
+		ID      string   `xml:"styleId,attr"`
+		Name    docxVal  `xml:"name"`
+		BasedOn docxVal  `xml:"basedOn"`
+		Outline *docxVal `xml:"pPr>outlineLvl"`
+		NumID   *docxVal `xml:"pPr>numPr>numId"`
+	} `xml:"style"`
+}
+
+// XML of word/numbering.xml
+type docxNumbering struct {
+	AbstractNums []struct {
+		ID     string `xml:"abstractNumId,attr"`
+		Levels []struct {
+			Level  string  `xml:"ilvl,attr"`
+			Format docxVal `xml:"numFmt"`
+		} `xml:"lvl"`
+	} `xml:"abstractNum"`
+	Nums []struct {
+		ID       string  `xml:"numId,attr"`
+		Abstract docxVal `xml:"abstractNumId"`
+	} `xml:"num"`
+}
+
+type docxVal struct {
+	Val string `xml:"val,attr"`
+}
+
+// extractDOCX renders a Word document as Markdown: headings become #-headings, which split knowledge base
+// imports into sections, tables become Markdown tables and list items keep their bullets or numbers.
+func extractDOCX(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read DOCX file: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		file, ok := files[name]
+		if !ok {
+			return nil, nil
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DOCX file %s: %w", name, err)
+		}
+		return r, nil
+	}
+	readXML := func(name string, v interface{}) error {
+		r, err := open(name)
+		if err != nil || r == nil {
+			return err
+		}
+		defer r.Close()
+		if err := xml.NewDecoder(io.LimitReader(r, docxMaxPartSize)).Decode(v); err != nil {
+			return fmt.Errorf("failed to read DOCX file %s: %w", name, err)
+		}
+		return nil
+	}
+
+	var styleSheet docxStyleSheet
+	if err := readXML("word/styles.xml", &styleSheet); err != nil {
+		return "", err
+	}
+	var numbering docxNumbering
+	if err := readXML("word/numbering.xml", &numbering); err != nil {
+		return "", err
+	}
+
+	document, err := open("word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	if document == nil {
+		return "", errors.New("failed to read DOCX file: missing word/document.xml")
+	}
+	defer document.Close()
+	blocks, err := readDOCXBlocks(io.LimitReader(document, docxMaxPartSize), newDOCXStyles(styleSheet))
+	if err != nil {
+		return "", fmt.Errorf("failed to read DOCX file: %w", err)
+	}
+	return renderDOCXBlocks(blocks, orderedLists(numbering)), nil
+}
+
+// newDOCXStyles finds the heading and list styles of a document. Headings are recognized by the built-in
+// style names, which stay English in localized Word versions, or by an outline level; styles inherit both
+// from the style they are based on.
+func newDOCXStyles(sheet docxStyleSheet) docxStyles {
+	type style struct {
+		basedOn string
+		heading int
+		numID   string
+	}
+	byID := map[string]style{}
+	for _, s := range sheet.Styles {
+		if s.Type != "" && s.Type != "paragraph" {
+			continue
+		}
+		st := style{basedOn: s.BasedOn.Val}
+		name := strings.ToLower(s.Name.Val)
+		switch {
+		case name == "title":
+			st.heading = 1
+		case strings.HasPrefix(name, "heading "):
+			if level, err := strconv.Atoi(strings.TrimPrefix(name, "heading ")); err == nil {
+				st.heading = level
+			}
+		case s.Outline != nil:
+			if level, err := strconv.Atoi(s.Outline.Val); err == nil && level < 9 {
+				st.heading = level + 1
+			}
+		}
+		if s.NumID != nil {
+			st.numID = s.NumID.Val
+		}
+		byID[s.ID] = st
+	}
+
+	styles := docxStyles{headings: map[string]int{}, lists: map[string]string{}}
+	for id, st := range byID {
+		// Follow basedOn for what the style does not set itself, guarding against cycles
+		for parent, depth := st.basedOn, 0; parent != "" && depth < 10 && (st.heading == 0 || st.numID == ""); depth++ {
+			base := byID[parent]
+			if st.heading == 0 {
+				st.heading = base.heading
+			}
+			if st.numID == "" {
+				st.numID = base.numID
+			}
+			parent = base.basedOn
+		}
+		if st.heading > 0 {
+			styles.headings[id] = st.heading
+		}
+		if st.numID != "" && st.numID != "0" {
+			styles.lists[id] = st.numID
+		}
+	}
+	return styles
+}
+
+// orderedLists returns the numbering IDs and levels that are numbered rather than bulleted, keyed
+// "numID/level"
+func orderedLists(numbering docxNumbering) map[string]bool {
+	formats := map[string]map[string]string{}
+	for _, abstract := range numbering.AbstractNums {
+		levels := map[string]string{}
+		for _, level := range abstract.Levels {
+			levels[level.Level] = level.Format.Val
+		}
+		formats[abstract.ID] = levels
+	}
+	ordered := map[string]bool{}
+	for _, num := range numbering.Nums {
+		for level, format := range formats[num.Abstract.Val] {
+			if format != "" && format != "bullet" && format != "none" {
+				ordered[num.ID+"/"+level] = true
+			}
+		}
+	}
+	return ordered
+}
+
+// readDOCXBlocks reads the blocks of word/document.xml in document order. Deleted revisions and field codes
+// are skipped; tables nested in a cell are flattened into the cell's text, and the paragraphs of a text box
+// come before the paragraph holding the box.
+func readDOCXBlocks(r io.Reader, styles docxStyles) ([]docxBlock, error) {
+	decoder := xml.NewDecoder(r)
+	var blocks []docxBlock
+	var tables []*docxTable
+	var paragraphs []*docxParagraph
+	runs := 0 // Depth of w:r elements; tabs and breaks count only inside runs
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var paragraph *docxParagraph
+		if len(paragraphs) > 0 {
+			paragraph = paragraphs[len(paragraphs)-1]
+		}
+		var table *docxTable
+		if len(tables) > 0 {
+			table = tables[len(tables)-1]
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraphs = append(paragraphs, &docxParagraph{})
+			case "r":
+				runs++
+			case "t":
+				if paragraph != nil && runs > 0 {
+					var text string
+					if err := decoder.DecodeElement(&text, &t); err != nil {
+						return nil, err
+					}
+					paragraph.text.WriteString(text)
+				}
+			case "tab":
+				if paragraph != nil && runs > 0 {
+					paragraph.text.WriteString("\t")
+				}
+			case "br", "cr":
+				if paragraph != nil && runs > 0 {
+					paragraph.text.WriteString("\n")
+				}
+			case "pStyle":
+				if paragraph != nil {
+					paragraph.style = docxAttr(t, "val")
+				}
+			case "outlineLvl":
+				if level, err := strconv.Atoi(docxAttr(t, "val")); paragraph != nil && err == nil && level < 9 {
+					paragraph.outline = level + 1
+				}
+			case "numId":
+				if paragraph != nil {
+					paragraph.numID = docxAttr(t, "val")
+				}
+			case "ilvl":
+				if level, err := strconv.Atoi(docxAttr(t, "val")); paragraph != nil && err == nil {
+					paragraph.level = level
+				}
+			case "tbl":
+				tables = append(tables, &docxTable{})
+			case "tr":
+				if table != nil {
+					table.rows = append(table.rows, nil)
+				}
+			case "tc":
+				if table != nil {
+					table.cell = &strings.Builder{}
+					table.span = 1
+				}
+			case "gridSpan":
+				if span, err := strconv.Atoi(docxAttr(t, "val")); table != nil && err == nil && span > 1 && span <= 64 {
+					table.span = span
+				}
+			case "del", "delText", "instrText", "Fallback":
+				// Deleted text, field codes and the legacy copy of drawings that newer Word versions also store
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "r":
+				runs--
+			case "p":
+				if paragraph == nil {
+					continue
+				}
+				paragraphs = paragraphs[:len(paragraphs)-1]
+				text := strings.TrimSpace(paragraph.text.String())
+				switch {
+				case text == "":
+				case table != nil && table.cell != nil:
+					if table.cell.Len() > 0 {
+						table.cell.WriteString(" ")
+					}
+					table.cell.WriteString(text)
+				default:
+					blocks = append(blocks, paragraph.block(text, styles))
+				}
+			case "tc":
+				if table == nil || table.cell == nil || len(table.rows) == 0 {
+					continue
+				}
+				// A merged cell spans several columns; pad the row so that later cells stay under their headers
+				row := len(table.rows) - 1
+				table.rows[row] = append(table.rows[row], strings.Join(strings.Fields(table.cell.String()), " "))
+				for i := 1; i < table.span; i++ {
+					table.rows[row] = append(table.rows[row], "")
+				}
+				table.cell = nil
+			case "tbl":
+				if table == nil {
+					continue
+				}
+				tables = tables[:len(tables)-1]
+				rows := trimDOCXRows(table.rows)
+				if len(rows) == 0 {
+					continue
+				}
+				if len(tables) > 0 {
+					if cell := tables[len(tables)-1].cell; cell != nil {
+						for _, row := range rows {
+							cell.WriteString(" " + strings.Join(row, " / "))
+						}
+					}
+					continue
+				}
+				blocks = append(blocks, docxBlock{rows: rows})
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// block classifies a finished paragraph as a heading, list item or plain paragraph
+func (p *docxParagraph) block(text string, styles docxStyles) docxBlock {
+	heading := p.outline
+	if heading == 0 {
+		heading = styles.headings[p.style]
+	}
+	if heading > 0 {
+		return docxBlock{text: strings.Join(strings.Fields(text), " "), heading: heading}
+	}
+
+	numID := p.numID
+	if numID == "" {
+		numID = styles.lists[p.style]
+	}
+	if numID != "" && numID != "0" {
+		return docxBlock{text: text, list: true, level: p.level, numID: numID}
+	}
+	return docxBlock{text: text}
+}
+
+// renderDOCXBlocks renders blocks as Markdown. Consecutive list items stay on consecutive lines; other
+// blocks are separated by blank lines.
+func renderDOCXBlocks(blocks []docxBlock, ordered map[string]bool) string {
+	var out strings.Builder
+	counters := map[string][]int{} // Item numbers of each numbered list, by level
+	previousList := false
+	for _, block := range blocks {
+		if out.Len() > 0 {
+			if block.list && previousList {
+				out.WriteString("\n")
+			} else {
+				out.WriteString("\n\n")
+			}
+		}
+		previousList = block.list
+
+		switch {
+		case block.heading > 0:
+			level := block.heading
+			if level > 6 {
+				level = 6
+			}
+			out.WriteString(strings.Repeat("#", level) + " " + block.text)
+		case block.list:
+			level := block.level
+			if level > 8 {
+				level = 8
+			}
+			marker := "-"
+			if ordered[block.numID+"/"+strconv.Itoa(block.level)] {
+				numbers := counters[block.numID]
+				for len(numbers) <= level {
+					numbers = append(numbers, 0)
+				}
+				numbers[level]++
+				for i := level + 1; i < len(numbers); i++ {
+					numbers[i] = 0 // A new item restarts the numbering of its sublists
+				}
+				counters[block.numID] = numbers
+				marker = strconv.Itoa(numbers[level]) + "."
+			}
+			indent := strings.Repeat("  ", level)
+			text := strings.ReplaceAll(block.text, "\n", "\n"+indent+"  ")
+			out.WriteString(indent + marker + " " + text)
+		case block.rows != nil:
+			out.WriteString(markdownTable(block.rows[0], block.rows[1:]))
+		default:
+			text := block.text
+			if strings.HasPrefix(text, "#") {
+				text = `\` + text // Not a heading
+			}
+			out.WriteString(text)
+		}
+	}
+	return out.String()
+}
+
+// trimDOCXRows drops empty rows of a table and pads the rest to the same width
+func trimDOCXRows(rows [][]string) [][]string {
+	var kept [][]string
+	for _, row := range rows {
+		if !blankRow(row) {
+			kept = append(kept, row)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return trimColumns(kept)
+}
+
+// docxAttr returns the value of an attribute of an element, whatever its namespace
+func docxAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// embeddingDimensions are the dimensions of the embedding models known to the system. Models not listed,
+// such as Azure deployments, are taken to have VECTOR_DIMENSION dimensions.
+var embeddingDimensions = map[string]int{
+	"text-embedding-ada-002": 1536,
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	GeminiEmbeddingModel:     768,
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"all-minilm":             384,
+}
+
+// EmbeddingDimension returns the dimension of a known embedding model
+func EmbeddingDimension(model string) (int, bool) {
+	dimension, ok := embeddingDimensions[model]
+	return dimension, ok
+}
+
+// EmbeddingService creates the embeddings of the knowledge base with one provider and model. It refuses
+// vectors of any other dimension than the collection's, so that one collection never mixes dimensions.
+type EmbeddingService struct {
+	ai        *UnifiedAIService
+	provider  AIProvider
+	model     string
+	dimension int
+	err       error // Set by Verify when the vector store cannot take the embeddings
+}
+
+// NewEmbeddingService creates an embedder for model of provider, whose vectors have dimension dimensions
+func NewEmbeddingService(ai *UnifiedAIService, provider AIProvider, model string, dimension int) *EmbeddingService {
+	return &EmbeddingService{ai: ai, provider: provider, model: model, dimension: dimension}
+}
+
+// EmbeddingModel returns the model embeddings are created with
+func (s *EmbeddingService) EmbeddingModel() string {
+	return s.model
+}
+
+// Dimension returns the dimension of the embeddings
+func (s *EmbeddingService) Dimension() int {
+	return s.dimension
+}
+
+// CreateEmbedding embeds text, failing when Verify found the vector store incompatible or the model
+// returns a vector of another dimension
+func (s *EmbeddingService) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	embedding, err := s.ai.CreateEmbedding(ctx, text, s.provider)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedding) != s.dimension {
+		return nil, fmt.Errorf("embedding model %s returned %d dimensions but VECTOR_DIMENSION is %d", s.model, len(embedding), s.dimension)
+	}
+	return embedding, nil
+}
+
+// Verify checks that the embeddings fit the vector store: the model and the collection must have
+// VECTOR_DIMENSION dimensions. On a mismatch embedding is refused until Verify passes again, which a reindex
+// runs first. Embeddings recorded with another dimension or model only warn, since a reindex replaces them.
+func (s *EmbeddingService) Verify(ctx context.Context, db *gorm.DB, store VectorStore) error {
+	s.err = s.verify(ctx, db, store)
+	return s.err
+}
+
+func (s *EmbeddingService) verify(ctx context.Context, db *gorm.DB, store VectorStore) error {
+	if dimension, ok := EmbeddingDimension(s.model); ok && dimension != s.dimension {
+		return fmt.Errorf("embedding model %s has %d dimensions but VECTOR_DIMENSION is %d", s.model, dimension, s.dimension)
+	}
+
+	if store != nil {
+		info, err := store.CollectionInfo(ctx)
+		if err != nil {
+			log.Printf("[WARNING] Failed to read the dimension of the vector collection: %v", err)
+		} else if info.Dimension > 0 && info.Dimension != s.dimension {
+			return fmt.Errorf("the vector collection %s has %d dimensions but VECTOR_DIMENSION is %d, recreate it and run the vector_reindex task",
+				info.Name, info.Dimension, s.dimension)
+		}
+	}
+
+	var dimensions []int
+	err := db.WithContext(ctx).Model(&models.VectorEmbedding{}).
+		Where("dimension > 0 AND dimension <> ?", s.dimension).Distinct().Pluck("dimension", &dimensions).Error
+	if err != nil {
+		return err
+	}
+	if len(dimensions) > 0 {
+		log.Printf("[WARNING] Knowledge entries are embedded with %v dimensions but VECTOR_DIMENSION is %d, run ticctl reindex",
+			dimensions, s.dimension)
+	}
+
+	var others int64
+	err = db.WithContext(ctx).Model(&models.VectorEmbedding{}).
+		Where("embedding_model <> '' AND embedding_model <> ?", s.model).Count(&others).Error
+	if err != nil {
+		return err
+	}
+	if others > 0 {
+		log.Printf("[WARNING] %d embeddings were created with another model than %s, run ticctl reindex", others, s.model)
+	}
+	return nil
+}
+
+// embeddingModelOf returns the model embedder creates embeddings with, empty when it does not say
+func embeddingModelOf(embedder Embedder) string {
+	if named, ok := embedder.(interface{ EmbeddingModel() string }); ok {
+		return named.EmbeddingModel()
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+// sizedVectorStore is a vector store whose collection has a given dimension
+type sizedVectorStore struct {
+	staticVectorStore
+	dimension int
+}
+
+func (v *sizedVectorStore) CollectionInfo(context.Context) (*QdrantCollectionInfo, error) {
+	return &QdrantCollectionInfo{Name: "test", Status: "green", Dimension: v.dimension}, nil
+}
+
+func TestEmbeddingVerifyBlocksUntilTheCollectionMatches(t *testing.T) {
+	ctx := context.Background()
+	db, _ := dryRunDB(t)
+	store := &sizedVectorStore{dimension: 768}
+	embedder := NewEmbeddingService(nil, OpenAIProvider, "text-embedding-3-small", 1536)
+
+	if err := embedder.Verify(ctx, db, store); err == nil {
+		t.Fatal("verify passed with a collection of another dimension")
+	}
+	if _, err := embedder.CreateEmbedding(ctx, "label printer"); err == nil {
+		t.Fatal("embedding allowed while the collection has another dimension")
+	}
+
+	store.dimension = 1536
+	if err := embedder.Verify(ctx, db, store); err != nil {
+		t.Fatalf("verify after recreating the collection: %v", err)
+	}
+	if embedder.err != nil {
+		t.Errorf("embedding still refused after the collection was recreated: %v", embedder.err)
+	}
+}
+
+func TestEmbeddingVerifyRejectsModelOfAnotherDimension(t *testing.T) {
+	db, _ := dryRunDB(t)
+	embedder := NewEmbeddingService(nil, OllamaProvider, "nomic-embed-text", 1536)
+	if err := embedder.Verify(context.Background(), db, nil); err == nil {
+		t.Error("verify passed for a 768-dimension model with VECTOR_DIMENSION 1536")
+	}
+}
@@ -3,33 +3,153 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"tic-knowledge-system/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type EnhancedChatService struct {
-	db               *gorm.DB
-	unifiedAIService *UnifiedAIService
-	knowledgeService *KnowledgeService
+	db                *gorm.DB
+	unifiedAIService  *UnifiedAIService
+	knowledgeService  *KnowledgeService
+	answerCache       *SemanticCache
+	embeddingProvider AIProvider
+	deferredAnswers   *DeferredAnswerService
+	dedup             *ChatDeduplicator
+	usage             *UsageService
+	quotas            *QuotaService
+	guardrails        *GuardrailService
+	translator        *TranslationService
+	confidence        ConfidenceOptions
+	handoff           *HandoffService
+	queryRewriter     *QueryRewriter
+	attachments       *ChatAttachmentService
+	analytics         *EntryAnalyticsService
+	announcements     *AnnouncementService
 }
 
-func NewEnhancedChatService(db *gorm.DB, unifiedAIService *UnifiedAIService, knowledgeService *KnowledgeService) *EnhancedChatService {
+// NewEnhancedChatService creates the enhanced chat service. answerCache may be nil to disable semantic caching,
+// and deferredAnswers may be nil to disable queuing questions that cannot be answered immediately.
+func NewEnhancedChatService(db *gorm.DB, unifiedAIService *UnifiedAIService, knowledgeService *KnowledgeService, answerCache *SemanticCache, embeddingProvider AIProvider, deferredAnswers *DeferredAnswerService) *EnhancedChatService {
 	return &EnhancedChatService{
-		db:               db,
-		unifiedAIService: unifiedAIService,
-		knowledgeService: knowledgeService,
+		db:                db,
+		unifiedAIService:  unifiedAIService,
+		knowledgeService:  knowledgeService,
+		answerCache:       answerCache,
+		embeddingProvider: embeddingProvider,
+		deferredAnswers:   deferredAnswers,
 	}
 }
 
+// SetDeduplicator collapses identical messages submitted to a session within the deduplicator's window
+func (s *EnhancedChatService) SetDeduplicator(dedup *ChatDeduplicator) {
+	s.dedup = dedup
+}
+
+// SetUsageService records the token usage and cost of every answer
+func (s *EnhancedChatService) SetUsageService(usage *UsageService) {
+	s.usage = usage
+}
+
+// SetQuotaService enforces monthly usage quotas before providers are called
+func (s *EnhancedChatService) SetQuotaService(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// SetGuardrails redacts user messages before they reach a provider and moderates the responses
+func (s *EnhancedChatService) SetGuardrails(guardrails *GuardrailService) {
+	s.guardrails = guardrails
+}
+
+// SetTranslator answers in the language of the user, translating knowledge written in other languages
+func (s *EnhancedChatService) SetTranslator(translator *TranslationService) {
+	s.translator = translator
+}
+
+// SetConfidence replaces answers the knowledge base supports too weakly with one saying the bot does not know
+func (s *EnhancedChatService) SetConfidence(opts ConfidenceOptions) {
+	s.confidence = opts
+}
+
+// SetHandoff stops the assistant from answering sessions escalated to a support agent
+func (s *EnhancedChatService) SetHandoff(handoff *HandoffService) {
+	s.handoff = handoff
+}
+
+// SetQueryRewriter rewrites messages into standalone retrieval queries before the knowledge base is searched
+func (s *EnhancedChatService) SetQueryRewriter(rewriter *QueryRewriter) {
+	s.queryRewriter = rewriter
+}
+
+// SetAttachments lets users attach images to their messages, which are sent to the provider with the message
+func (s *EnhancedChatService) SetAttachments(attachments *ChatAttachmentService) {
+	s.attachments = attachments
+}
+
+// SetAnalytics records the searches of chat messages, so that questions the knowledge base has no answer for
+// are reported
+func (s *EnhancedChatService) SetAnalytics(analytics *EntryAnalyticsService) {
+	s.analytics = analytics
+}
+
+// SetAnnouncements adds the active org-wide announcements to the system prompt of every answer
+func (s *EnhancedChatService) SetAnnouncements(announcements *AnnouncementService) {
+	s.announcements = announcements
+}
+
+// QueueQuestion queues a question to be answered in the background and delivered by email
+func (s *EnhancedChatService) QueueQuestion(ctx context.Context, req EnhancedChatRequest) (*models.QueuedQuestion, error) {
+	if s.deferredAnswers == nil {
+		return nil, fmt.Errorf("deferred answers are not enabled")
+	}
+
+	session, err := s.getOrCreateSession(req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Message, err = s.guardrails.ProcessInput(ctx, req.UserID, &session.ID, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	userMessage := &models.ChatMessage{
+		SessionID: session.ID,
+		Role:      models.UserMessage,
+		Content:   req.Message,
+		Metadata:  `{"queued": true}`,
+	}
+	if err := s.db.Create(userMessage).Error; err != nil {
+		return nil, err
+	}
+
+	return s.deferredAnswers.QueueQuestion(ctx, session.ID, req.UserID, &userMessage.ID, req.Message, QueueReasonResearch)
+}
+
 type EnhancedChatRequest struct {
 	Message           string     `json:"message" validate:"required"`
 	SessionID         *uuid.UUID `json:"session_id,omitempty"`
-	UserID            uuid.UUID  `json:"user_id" validate:"required"`
+	UserID            uuid.UUID  `json:"user_id,omitempty"` // Set to the signed-in user; a different user_id is rejected
 	PreferredProvider AIProvider `json:"preferred_provider,omitempty"`
 	SystemPrompt      string     `json:"system_prompt,omitempty"`
+	Language          string     `json:"language,omitempty"`      // Language of the answer; empty answers in the language of the message
+	AttachmentID      *uuid.UUID `json:"attachment_id,omitempty"` // Image uploaded through /ai/attachments, e.g. a screenshot of an error
+	PersonaID         *uuid.UUID `json:"persona_id,omitempty"`    // Persona preset the session answers as from now on (see GET /chat/personas)
+
+	// Optional generation overrides, limited per user role (see DefaultGenerationLimits)
+	Generation GenerationParams `json:"generation,omitempty"`
+
+	// Optional retrieval filters, e.g. {"categories": ["Troubleshooting"]} to answer from troubleshooting articles only
+	Filters RetrievalFilters `json:"filters,omitempty"`
+
+	// Ephemeral answers without touching the user's chat history: no session or message is saved, the
+	// semantic cache and search analytics are skipped, and a provider failure is returned instead of a
+	// degraded answer. Provider comparisons use it; SessionID is ignored.
+	Ephemeral bool `json:"-"`
 }
 
 type EnhancedChatResponse struct {
@@ -39,55 +159,204 @@ type EnhancedChatResponse struct {
 	Provider      AIProvider `json:"provider"`
 	Model         string     `json:"model"`
 	CreatedAt     string     `json:"created_at"`
+	Cached        bool       `json:"cached,omitempty"`
+	Citations     []Citation `json:"citations,omitempty"`
+	Language      string     `json:"language,omitempty"` // ISO 639-1 code of the answer's language, when known
+
+	// SeeAlso suggests entries related to the best match when the knowledge base supports the answer weakly
+	SeeAlso []RelatedEntry `json:"see_also,omitempty"`
+
+	// Confidence scores from 0 to 1 how well the knowledge base supports the answer (see AnswerConfidence).
+	// Below the configured threshold the answer says the bot does not know and SuggestEscalation is set.
+	Confidence        float64 `json:"confidence"`
+	SuggestEscalation bool    `json:"suggest_escalation,omitempty"`
+
+	// Escalation is set while the session is handed over to a support agent, who answers in the session
+	// instead of the assistant
+	Escalation *models.ChatEscalation `json:"escalation,omitempty"`
+
+	// Degraded is set when every AI provider failed and the answer was built from keyword search.
+	// The question is then queued to be answered later.
+	Degraded         bool       `json:"degraded,omitempty"`
+	QueuedQuestionID *uuid.UUID `json:"queued_question_id,omitempty"`
+
+	// Deduplicated is set when this is the response of an identical request sent moments earlier
+	Deduplicated bool `json:"deduplicated,omitempty"`
+
+	// MessageID is the saved answer, which PATCH /chat/messages/{id} and POST /chat/messages/{id}/regenerate
+	// change; Version counts its regenerations
+	MessageID uuid.UUID `json:"message_id,omitempty"`
+	Version   int       `json:"version,omitempty"`
 }
 
+// ProcessChat answers a chat message. An identical message sent to the same session within
+// the deduplication window shares the response of the first one instead of being processed again.
 func (s *EnhancedChatService) ProcessChat(ctx context.Context, req EnhancedChatRequest) (*EnhancedChatResponse, error) {
+	if req.Ephemeral {
+		// Nothing is saved, so there is no session whose history a duplicate could corrupt
+		return s.processChat(ctx, req)
+	}
+	keyReq := req
+	keyReq.Message = ""
+	result, duplicate, err := s.dedup.Do(ctx, chatDedupKey(keyReq, req.Message), func() (interface{}, error) {
+		response, err := s.processChat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return response, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := result.(*EnhancedChatResponse)
+	if duplicate {
+		log.Printf("[INFO] Duplicate chat message for user %s, returning the earlier response", req.UserID)
+		deduplicated := *response
+		deduplicated.Deduplicated = true
+		return &deduplicated, nil
+	}
+	return response, nil
+}
+
+func (s *EnhancedChatService) processChat(ctx context.Context, req EnhancedChatRequest) (*EnhancedChatResponse, error) {
 	log.Printf("[INFO] ProcessChat started for user_id: %s, message: %.50s...", req.UserID, req.Message)
 
+	scope := s.knowledgeService.ScopeForUser(req.UserID)
+	scope.Filters = req.Filters
+	if err := ValidateGenerationParams(scope.Role, req.Generation); err != nil {
+		log.Printf("[WARNING] Rejected generation overrides for user %s (role %q): %v", req.UserID, scope.Role, err)
+		return nil, err
+	}
+	if err := s.quotas.Check(req.UserID); err != nil {
+		log.Printf("[WARNING] Rejected chat for user %s: %v", req.UserID, err)
+		return nil, err
+	}
+	language, err := chatLanguage(req.Language, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get or create session
-	session, err := s.getOrCreateSession(req.UserID, req.SessionID)
+	session, err := s.sessionFor(req)
 	if err != nil {
 		log.Printf("[ERROR] Failed to get or create session for user %s: %v", req.UserID, err)
 		return nil, err
 	}
-	log.Printf("[INFO] Using session_id: %s for user_id: %s", session.ID, req.UserID)
+	log.Printf("[INFO] Using session_id: %s for user_id: %s (ephemeral: %t)", session.ID, req.UserID, req.Ephemeral)
+	// Moderation events of an ephemeral chat are kept, but not attached to a session that does not exist
+	sessionRef := &session.ID
+	if req.Ephemeral {
+		sessionRef = nil
+	} else if req.PersonaID != nil {
+		if err := selectPersona(ctx, s.db, session, req.PersonaID); err != nil {
+			return nil, err
+		}
+	}
+	// The persona of the session applies unless the request brings its own system prompt
+	if req.SystemPrompt == "" {
+		req.SystemPrompt = sessionPersonaPrompt(ctx, s.db, session)
+	}
 
-	// Save user message to database
+	// Redact the message before it is stored, searched, embedded, or sent to a provider
+	req.Message, err = s.guardrails.ProcessInput(ctx, req.UserID, sessionRef, req.Message)
+	if err != nil {
+		log.Printf("[WARNING] Rejected chat message for user %s: %v", req.UserID, err)
+		return nil, err
+	}
+
+	// The image is sent with the message; the knowledge base is still searched with the text
+	var images []ChatImage
+	if req.AttachmentID != nil {
+		if s.attachments == nil {
+			return nil, validationError("image attachments are not enabled")
+		}
+		_, image, err := s.attachments.Image(ctx, *req.AttachmentID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *image)
+	}
+
+	escalation, err := s.handoff.ActiveEscalation(ctx, session.ID)
+	if err != nil {
+		log.Printf("[WARNING] Failed to check whether session %s is escalated: %v", session.ID, err)
+	}
+
+	// Save user message to database. It stays pending until its answer is saved, so that it is replayed
+	// if the chat fails before then; a support agent answers the messages of an escalated session.
 	userMessage := &models.ChatMessage{
-		SessionID: session.ID,
-		Role:      "user",
-		Content:   req.Message,
-		Metadata:  "{}",
+		SessionID:    session.ID,
+		Role:         "user",
+		Content:      req.Message,
+		Metadata:     "{}",
+		AttachmentID: req.AttachmentID,
+		Status:       models.MessagePending,
+	}
+	if escalation != nil {
+		userMessage.Status = ""
 	}
 
-	if err := s.db.Create(userMessage).Error; err != nil {
+	if err := s.saveMessage(req, userMessage, nil); err != nil {
 		log.Printf("[ERROR] Failed to save user message to database: %v", err)
 		return nil, err
 	}
 	log.Printf("[INFO] User message saved with ID: %s", userMessage.ID)
 
+	if escalation != nil {
+		log.Printf("[INFO] Session %s is escalated to support, leaving the message to the agent", session.ID)
+		return &EnhancedChatResponse{
+			Response:   s.translator.LocalizeAnswer(ctx, handoffNotice, language),
+			SessionID:  session.ID,
+			CreatedAt:  userMessage.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Language:   language,
+			Escalation: escalation,
+		}, nil
+	}
+
 	// Search knowledge base for relevant information
 	log.Printf("[INFO] Searching knowledge base for query: %.50s...", req.Message)
-	knowledgeEntries, err := s.knowledgeService.SearchKnowledgeEntries(context.Background(), req.Message, 3)
+	queries := s.queryRewriter.Queries(ctx, session.ID, userMessage.ID, req.Message)
+	knowledgeEntries, citations, err := s.knowledgeService.SearchKnowledgeMultiQuery(context.Background(), queries, 3, scope)
 	if err != nil {
 		log.Printf("[WARNING] Knowledge search failed, continuing without context: %v", err)
+	} else if !req.Ephemeral {
+		s.analytics.RecordSearch(req.Message, models.ViewFromChat, &req.UserID, len(knowledgeEntries))
 	}
 
 	log.Printf("[INFO] Found %d knowledge entries for context", len(knowledgeEntries))
 
-	// Build context from knowledge entries
-	var context []string
-	if len(knowledgeEntries) > 0 {
-		for _, entry := range knowledgeEntries {
-			contextEntry := entry.Title + ": " + entry.Content
-			context = append(context, contextEntry)
-			log.Printf("[DEBUG] Added knowledge entry to context: %s", entry.Title)
-		}
+	// Build numbered context from knowledge entries; context[i] is cited as [i+1]
+	context := citationContext(knowledgeEntries)
+	if len(context) > 0 {
 		log.Printf("[INFO] Built context from %d knowledge entries", len(context))
 	} else {
 		log.Printf("[INFO] No knowledge context available, using general AI knowledge")
 	}
 
+	// Prepare sources
+	var sources []string
+	var entryIDs []uuid.UUID
+	for _, entry := range knowledgeEntries {
+		sources = append(sources, entry.ID.String())
+		entryIDs = append(entryIDs, entry.ID)
+	}
+
+	// Answer from the semantic cache when a near-identical question was recently answered
+	contextKey := knowledgeContextKey(entryIDs)
+	if language != "" {
+		// Answers are given in the language of the question, so they are only shared within it
+		contextKey += "@" + language
+	}
+	questionEmbedding := s.embedQuestionForCache(ctx, req)
+	if questionEmbedding != nil {
+		if cached, similarity, ok := s.answerCache.Lookup(questionEmbedding, contextKey); ok {
+			log.Printf("[INFO] Semantic cache hit (similarity %.4f) for question: %.50s...", similarity, cached.Question)
+			return s.respondFromCache(session, userMessage, cached, similarity, sources, language)
+		}
+	}
+	context = s.translator.LocalizeContext(ctx, knowledgeEntries, context, language)
+
 	// Get conversation history
 	log.Printf("[INFO] Retrieving conversation history for session: %s", session.ID)
 	recentMessages, err := s.getRecentMessages(session.ID, 10)
@@ -104,7 +373,7 @@ func (s *EnhancedChatService) ProcessChat(ctx context.Context, req EnhancedChatR
 	for _, msg := range recentMessages {
 		if msg.ID != userMessage.ID {
 			role := string(msg.Role)
-			if role == "assistant" {
+			if role == "assistant" || role == "support" {
 				role = "model" // Gemini uses "model" instead of "assistant"
 			}
 			messages = append(messages, UnifiedChatMessage{
@@ -119,6 +388,7 @@ func (s *EnhancedChatService) ProcessChat(ctx context.Context, req EnhancedChatR
 	messages = append(messages, UnifiedChatMessage{
 		Role:    "user",
 		Content: req.Message,
+		Images:  images,
 	})
 
 	log.Printf("[INFO] Prepared %d messages for AI API call", len(messages))
@@ -129,8 +399,9 @@ func (s *EnhancedChatService) ProcessChat(ctx context.Context, req EnhancedChatR
 		Context:          context,
 		SessionID:        session.ID.String(),
 		UseKnowledgeBase: len(context) > 0,
-		SystemPrompt:     req.SystemPrompt,
+		SystemPrompt:     s.announcements.SystemPrompt(req.SystemPrompt),
 		PreferredProvider: req.PreferredProvider,
+		Generation:       req.Generation,
 	}
 
 	log.Printf("[INFO] Calling AI service with %d messages, knowledge_base=%t", len(messages), len(context) > 0)
@@ -142,43 +413,118 @@ func (s *EnhancedChatService) ProcessChat(ctx context.Context, req EnhancedChatR
 	aiResponse, err := s.unifiedAIService.ChatCompletion(ctx, aiRequest)
 	if err != nil {
 		log.Printf("[ERROR] AI API call failed: %v", err)
-		return nil, err
+		if req.Ephemeral {
+			return nil, err
+		}
+		return s.respondDegraded(ctx, session, req, userMessage, err)
 	}
 	log.Printf("[INFO] AI API call successful, provider: %s, response length: %d characters", aiResponse.Provider, len(aiResponse.Message))
 
+	aiResponse.Message = s.translator.LocalizeAnswer(ctx, aiResponse.Message, language)
+	var moderated bool
+	aiResponse.Message, moderated = s.guardrails.ProcessOutput(ctx, req.UserID, sessionRef, aiResponse.Message)
+	confidence := AnswerConfidence(citations, aiResponse.Message)
+	withheld := s.confidence.withholds(confidence)
+	var withheldAnswer string
+	if withheld {
+		log.Printf("[INFO] Answer confidence %.2f is below %.2f, answering that the bot does not know", confidence, s.confidence.Threshold)
+		withheldAnswer = aiResponse.Message
+		aiResponse.Message = localizedUnknownAnswer(ctx, s.translator, language)
+	}
+
 	// Save assistant response to database
 	assistantMessage := &models.ChatMessage{
 		SessionID: session.ID,
 		Role:      "assistant",
 		Content:   aiResponse.Message,
-		Metadata:  s.buildMetadata(aiResponse.Provider, aiResponse.Model, aiResponse.Sources),
+		Metadata: buildMessageMetadata(aiResponse.Provider, aiResponse.Model, sources, map[string]interface{}{
+			"usage":     aiResponse.Usage,
+			"cost_usd":  EstimateCost(aiResponse.Model, aiResponse.Usage),
+			"retrieved": len(knowledgeEntries),
+			"citations": citations,
+			"moderated": moderated,
+			"language":  language,
+
+			"confidence":      confidence,
+			"withheld_answer": withheldAnswer,
+			"queries":         queries,
+		}),
 	}
 
-	if err := s.db.Create(assistantMessage).Error; err != nil {
-		log.Printf("[ERROR] Failed to save assistant message to database: %v", err)
-		return nil, err
+	messageRef := &assistantMessage.ID
+	if err := s.saveMessage(req, assistantMessage, userMessage); err != nil {
+		// The user still gets the answer; the question stays pending and is answered in the session later
+		log.Printf("[ERROR] Failed to save assistant message to database, the question will be replayed: %v", err)
+		assistantMessage.CreatedAt = time.Now()
+		messageRef = nil
+	} else {
+		log.Printf("[INFO] Assistant message saved with ID: %s", assistantMessage.ID)
 	}
-	log.Printf("[INFO] Assistant message saved with ID: %s", assistantMessage.ID)
-
-	// Prepare sources
-	var sources []string
-	for _, entry := range knowledgeEntries {
-		sources = append(sources, entry.ID.String())
+	// Ephemeral answers still cost tokens, so their usage is recorded without the unsaved message
+	if req.Ephemeral {
+		messageRef = nil
+	}
+	s.usage.RecordChat(req.UserID, session.ID, messageRef, req.Message, aiResponse.Provider, aiResponse.Model, aiResponse.Usage)
+
+	if questionEmbedding != nil && !moderated && !withheld {
+		s.answerCache.Store(questionEmbedding, contextKey, CachedAnswer{
+			Question:  req.Message,
+			Response:  aiResponse.Message,
+			Provider:  aiResponse.Provider,
+			Model:     aiResponse.Model,
+			Citations: citations,
+		})
 	}
 
 	response := &EnhancedChatResponse{
 		Response:  aiResponse.Message,
 		SessionID: session.ID,
+		MessageID: assistantMessage.ID,
 		Sources:   sources,
 		Provider:  aiResponse.Provider,
 		Model:     aiResponse.Model,
 		CreatedAt: assistantMessage.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		Citations: citations,
+		Language:  language,
+		SeeAlso:   s.knowledgeService.SeeAlso(ctx, knowledgeEntries, citations, scope),
+
+		Confidence:        confidence,
+		SuggestEscalation: withheld,
 	}
 
 	log.Printf("[INFO] ProcessChat completed successfully for session: %s, provider: %s, sources: %d", session.ID, aiResponse.Provider, len(sources))
 	return response, nil
 }
 
+// sessionFor returns the session a request is answered in. An ephemeral request gets a session that
+// is never saved.
+func (s *EnhancedChatService) sessionFor(req EnhancedChatRequest) (*models.ChatSession, error) {
+	if req.Ephemeral {
+		return &models.ChatSession{
+			ID:        uuid.New(),
+			UserID:    req.UserID,
+			Title:     "Ephemeral chat",
+			IsActive:  true,
+			CreatedAt: time.Now(),
+		}, nil
+	}
+	return s.getOrCreateSession(req.UserID, req.SessionID)
+}
+
+// saveMessage saves a message of the session, or only gives it an ID and timestamp for an ephemeral request.
+// An answer is saved together with marking the question it answers answered.
+func (s *EnhancedChatService) saveMessage(req EnhancedChatRequest, message, question *models.ChatMessage) error {
+	if req.Ephemeral {
+		message.ID = uuid.New()
+		message.CreatedAt = time.Now()
+		return nil
+	}
+	if question != nil {
+		return saveAnswer(s.db, question, message)
+	}
+	return s.db.Create(message).Error
+}
+
 func (s *EnhancedChatService) getOrCreateSession(userID uuid.UUID, sessionID *uuid.UUID) (*models.ChatSession, error) {
 	var session models.ChatSession
 
@@ -214,12 +560,72 @@ func (s *EnhancedChatService) getRecentMessages(sessionID uuid.UUID, limit int)
 	return messages, err
 }
 
-func (s *EnhancedChatService) buildMetadata(provider AIProvider, model string, sources []string) string {
+// embedQuestionForCache returns the question embedding used for semantic caching,
+// or nil when caching does not apply to this request
+func (s *EnhancedChatService) embedQuestionForCache(ctx context.Context, req EnhancedChatRequest) []float32 {
+	// Explicit provider or prompt overrides must always reach the provider
+	// Answers about an attached image depend on the image, not only on the question
+	if s.answerCache == nil || req.Ephemeral || req.PreferredProvider != "" || req.SystemPrompt != "" || !req.Generation.IsZero() || req.AttachmentID != nil {
+		return nil
+	}
+	// Cached answers predate the active announcements, which answers must take into account
+	if len(s.announcements.Active()) > 0 {
+		return nil
+	}
+
+	embedding, err := s.unifiedAIService.CreateEmbedding(ctx, req.Message, s.embeddingProvider)
+	if err != nil {
+		log.Printf("[WARNING] Failed to embed question for semantic cache: %v", err)
+		return nil
+	}
+	return embedding
+}
+
+// respondFromCache saves a cached answer to a question into the session and builds the response
+func (s *EnhancedChatService) respondFromCache(session *models.ChatSession, question *models.ChatMessage, cached *CachedAnswer, similarity float64, sources []string, language string) (*EnhancedChatResponse, error) {
+	assistantMessage := &models.ChatMessage{
+		SessionID: session.ID,
+		Role:      "assistant",
+		Content:   cached.Response,
+		Metadata: buildMessageMetadata(cached.Provider, cached.Model, sources, map[string]interface{}{
+			"cached":           true,
+			"cache_similarity": similarity,
+			"cached_question":  cached.Question,
+			"retrieved":        len(sources),
+			"citations":        cached.Citations,
+		}),
+	}
+
+	if err := saveAnswer(s.db, question, assistantMessage); err != nil {
+		log.Printf("[ERROR] Failed to save cached assistant message to database: %v", err)
+		return nil, err
+	}
+
+	return &EnhancedChatResponse{
+		Response:  cached.Response,
+		SessionID: session.ID,
+		Sources:   sources,
+		Provider:  cached.Provider,
+		Model:     cached.Model,
+		CreatedAt: assistantMessage.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		Cached:    true,
+		Citations: cached.Citations,
+		Language:  language,
+
+		Confidence: AnswerConfidence(cached.Citations, cached.Response),
+	}, nil
+}
+
+// buildMessageMetadata serializes the metadata stored on assistant messages
+func buildMessageMetadata(provider AIProvider, model string, sources []string, extra map[string]interface{}) string {
 	metadata := map[string]interface{}{
 		"provider": string(provider),
 		"model":    model,
 		"sources":  sources,
 	}
+	for key, value := range extra {
+		metadata[key] = value
+	}
 
 	metadataJSON, _ := json.Marshal(metadata)
 	return string(metadataJSON)
@@ -250,31 +656,25 @@ func (s *EnhancedChatService) GetChatSession(userID, sessionID uuid.UUID) (*mode
 
 	if err != nil {
 		log.Printf("[ERROR] Failed to get chat session: %v", err)
-		return nil, err
+		return nil, notFound(err, "chat session "+sessionID.String())
 	}
 
 	log.Printf("[INFO] Retrieved chat session: %s", sessionID)
 	return &session, nil
 }
 
-func (s *EnhancedChatService) DeleteChatSession(userID, sessionID uuid.UUID) error {
+// DeleteChatSession soft-deletes a session of the user with its messages (see ChatService.DeleteChatSession)
+func (s *EnhancedChatService) DeleteChatSession(ctx context.Context, userID, sessionID uuid.UUID) (*DeletedChatSession, error) {
 	log.Printf("[INFO] Deleting chat session %s for user: %s", sessionID, userID)
 
-	result := s.db.Where("id = ? AND user_id = ?", sessionID, userID).
-		Update("is_active", false)
-
-	if result.Error != nil {
-		log.Printf("[ERROR] Failed to delete chat session: %v", result.Error)
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		log.Printf("[WARNING] No chat session found to delete: %s", sessionID)
-		return gorm.ErrRecordNotFound
+	deleted, err := deleteChatSession(ctx, s.db, sessionID, userID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to delete chat session: %v", err)
+		return nil, err
 	}
 
 	log.Printf("[INFO] Successfully deleted chat session: %s", sessionID)
-	return nil
+	return deleted, nil
 }
 
 // GetAvailableProviders returns the list of available AI providers
@@ -282,6 +682,11 @@ func (s *EnhancedChatService) GetAvailableProviders() []AIProvider {
 	return s.unifiedAIService.GetAvailableProviders()
 }
 
+// GetProviderHealth returns the circuit breaker state and latency of each provider
+func (s *EnhancedChatService) GetProviderHealth() []ProviderHealth {
+	return s.unifiedAIService.GetProviderHealth()
+}
+
 // SetPrimaryProvider changes the primary AI provider
 func (s *EnhancedChatService) SetPrimaryProvider(provider AIProvider) error {
 	return s.unifiedAIService.SetPrimaryProvider(provider)
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxLoggedQueryRunes bounds the search queries kept for the zero-result report
+const maxLoggedQueryRunes = 500
+
+// EntryAnalyticsService records how knowledge entries are found and opened, and reports the most used
+// entries, the entries chat keeps retrieving that nobody opens, and the questions the knowledge base
+// does not answer
+type EntryAnalyticsService struct {
+	db                *gorm.DB
+	reads             ReadReplicaRouter
+	unifiedAI         *UnifiedAIService
+	embeddingProvider AIProvider
+}
+
+// NewEntryAnalyticsService creates the service. reads may be nil to query the primary.
+func NewEntryAnalyticsService(db *gorm.DB, reads ReadReplicaRouter) *EntryAnalyticsService {
+	return &EntryAnalyticsService{db: db, reads: reads}
+}
+
+// EntryViewStats are the views of an entry within the reported period
+type EntryViewStats struct {
+	KnowledgeEntryID uuid.UUID `json:"knowledge_entry_id"`
+	Title            string    `json:"title"`
+	Category         string    `json:"category"`
+	Views            int64     `json:"views"`
+	UniqueUsers      int64     `json:"unique_users"`
+	FromSearch       int64     `json:"from_search"`
+	FromChat         int64     `json:"from_chat"`
+	Direct           int64     `json:"direct"`
+	LastViewedAt     time.Time `json:"last_viewed_at"`
+}
+
+// UnclickedEntry is an entry used as a source of chat answers that no chat user opened
+type UnclickedEntry struct {
+	KnowledgeEntryID uuid.UUID `json:"knowledge_entry_id"`
+	Title            string    `json:"title"`
+	Category         string    `json:"category"`
+	Retrievals       int64     `json:"retrievals"` // Chat answers the entry was a source of
+	LastRetrievedAt  time.Time `json:"last_retrieved_at"`
+}
+
+// ZeroResultQuery is a search that found no entries, grouped case-insensitively
+type ZeroResultQuery struct {
+	Query          string    `json:"query"`
+	Searches       int64     `json:"searches"`
+	UniqueUsers    int64     `json:"unique_users"`
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
+
+// RecordView records that a user opened an entry. userID is nil for anonymous views.
+func (s *EntryAnalyticsService) RecordView(entryID uuid.UUID, userID *uuid.UUID, source models.ViewSource) {
+	if s == nil {
+		return
+	}
+	event := &models.ViewEvent{KnowledgeEntryID: entryID, UserID: userID, Source: source}
+	if err := s.db.Create(event).Error; err != nil {
+		log.Printf("[WARNING] Failed to record view of knowledge entry %s: %v", entryID, err)
+	}
+}
+
+// RecordSearch records a knowledge base search and the number of entries it found. Searches that found
+// nothing are also logged as unanswered questions.
+func (s *EntryAnalyticsService) RecordSearch(query string, source models.ViewSource, userID *uuid.UUID, results int) {
+	if s == nil {
+		return
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	record := &models.SearchQueryLog{
+		Query:       utils.TruncateString(query, maxLoggedQueryRunes),
+		Source:      source,
+		UserID:      userID,
+		ResultCount: results,
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		log.Printf("[WARNING] Failed to record search %.50q: %v", query, err)
+	}
+	if results == 0 {
+		s.logQuery(query, source, models.QueryNoResults, userID, nil)
+	}
+}
+
+// TopEntries returns the most viewed entries since a time, optionally only views from one source
+func (s *EntryAnalyticsService) TopEntries(ctx context.Context, since time.Time, source models.ViewSource, limit int) ([]EntryViewStats, error) {
+	query := readDB(s.reads, s.db).WithContext(ctx).Table("view_events AS ve").
+		Select(`ve.knowledge_entry_id, ke.title, ke.category,
+			COUNT(*) AS views,
+			COUNT(DISTINCT ve.user_id) AS unique_users,
+			COUNT(*) FILTER (WHERE ve.source = ?) AS from_search,
+			COUNT(*) FILTER (WHERE ve.source = ?) AS from_chat,
+			COUNT(*) FILTER (WHERE ve.source = ?) AS direct,
+			MAX(ve.created_at) AS last_viewed_at`, models.ViewFromSearch, models.ViewFromChat, models.ViewDirect).
+		Joins("JOIN knowledge_entries AS ke ON ke.id = ve.knowledge_entry_id AND ke.deleted_at IS NULL").
+		Where("ve.created_at >= ?", since).
+		Group("ve.knowledge_entry_id, ke.title, ke.category").
+		Order("views DESC, last_viewed_at DESC").
+		Limit(limit)
+	if source != "" {
+		query = query.Where("ve.source = ?", source)
+	}
+
+	var stats []EntryViewStats
+	if err := query.Scan(&stats).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate knowledge entry views: %v", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// UnclickedChatEntries returns the entries that were sources of chat answers since a time but were not
+// opened from chat since then, most retrieved first. They are candidates for better titles, or for
+// retrieval tuning when they are retrieved for questions they do not answer.
+func (s *EntryAnalyticsService) UnclickedChatEntries(ctx context.Context, since time.Time, limit int) ([]UnclickedEntry, error) {
+	var entries []UnclickedEntry
+	err := readDB(s.reads, s.db).WithContext(ctx).Table("chat_messages AS cm").
+		Select(`ke.id AS knowledge_entry_id, ke.title, ke.category,
+			COUNT(*) AS retrievals,
+			MAX(cm.created_at) AS last_retrieved_at`).
+		Joins(`CROSS JOIN LATERAL jsonb_array_elements_text(
+			CASE WHEN jsonb_typeof(cm.metadata->'sources') = 'array' THEN cm.metadata->'sources' ELSE '[]'::jsonb END
+		) AS src(entry_id)`).
+		Joins("JOIN knowledge_entries AS ke ON ke.id::text = src.entry_id AND ke.deleted_at IS NULL").
+		Where("cm.role = ? AND cm.created_at >= ? AND cm.deleted_at IS NULL", models.AssistantMessage, since).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM view_events AS ve
+			WHERE ve.knowledge_entry_id = ke.id AND ve.source = ? AND ve.created_at >= ?
+		)`, models.ViewFromChat, since).
+		Group("ke.id, ke.title, ke.category").
+		Order("retrievals DESC, last_retrieved_at DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	if err != nil {
+		log.Printf("[ERROR] Failed to find knowledge entries retrieved in chat but never opened: %v", err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ZeroResultQueries returns the searches since a time that found no entries, most frequent first,
+// optionally only from one source
+func (s *EntryAnalyticsService) ZeroResultQueries(ctx context.Context, since time.Time, source models.ViewSource, limit int) ([]ZeroResultQuery, error) {
+	query := readDB(s.reads, s.db).WithContext(ctx).Table("search_query_logs").
+		Select(`LOWER(TRIM(query)) AS query,
+			COUNT(*) AS searches,
+			COUNT(DISTINCT user_id) AS unique_users,
+			MAX(created_at) AS last_searched_at`).
+		Where("result_count = 0 AND created_at >= ?", since).
+		Group("LOWER(TRIM(query))").
+		Order("searches DESC, last_searched_at DESC").
+		Limit(limit)
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var queries []ZeroResultQuery
+	if err := query.Scan(&queries).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate zero-result searches: %v", err)
+		return nil, err
+	}
+	return queries, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Error kinds. Services wrap one of these so that the API error handler can tell
+// user errors apart from failures and answer with the right HTTP status.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrValidation          = errors.New("invalid request")
+	ErrForbidden           = errors.New("forbidden")
+	ErrProviderUnavailable = errors.New("AI provider unavailable")
+	ErrInvalidResponse     = errors.New("AI provider returned an unusable response")
+	ErrQuotaExceeded       = errors.New("monthly usage quota exceeded")
+)
+
+// notFound turns gorm's record-not-found error into ErrNotFound naming what was missing.
+// Other errors are returned unchanged.
+func notFound(err error, what string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, what)
+	}
+	return err
+}
+
+// validationError returns an ErrValidation describing what is wrong with the request
+func validationError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrValidation, fmt.Sprintf(format, args...))
+}
+
+// providerUnavailable wraps a provider failure in ErrProviderUnavailable
+func providerUnavailable(err error) error {
+	return fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+}
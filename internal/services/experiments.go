@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypePromptExperiment answers the golden questions for a queued experiment run
+const JobTypePromptExperiment = "prompt_experiment"
+
+// defaultExperimentThreshold is the similarity below which an answer regresses when none is configured
+const defaultExperimentThreshold = 0.85
+
+// experimentPayload is the job payload for an experiment run
+type experimentPayload struct {
+	RunID uuid.UUID `json:"run_id"`
+}
+
+// ExperimentSpec selects the prompt version and provider an experiment run tries out
+type ExperimentSpec struct {
+	PromptID      *uuid.UUID `json:"prompt_id,omitempty"`       // Prompt version answering instead of the active one
+	Provider      AIProvider `json:"provider,omitempty"`        // Defaults to the primary provider
+	Model         string     `json:"model,omitempty"`           // Defaults to the provider's model
+	BaselineRunID *uuid.UUID `json:"baseline_run_id,omitempty"` // Defaults to the golden run of the provider
+	Threshold     float64    `json:"threshold,omitempty"`       // Defaults to the configured threshold
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+}
+
+// ExperimentService snapshots the answers to a fixed set of golden questions per prompt version and provider,
+// and compares the answers of new runs with the golden snapshot by semantic similarity, so that prompt
+// changes can be checked before they are activated
+type ExperimentService struct {
+	db                *gorm.DB
+	knowledgeService  *KnowledgeService
+	unifiedAI         *UnifiedAIService
+	promptService     *PromptService
+	jobQueue          *JobQueue
+	embeddingProvider AIProvider
+	threshold         float64
+}
+
+// NewExperimentService creates the service. Answers less similar than threshold to their golden answer regress.
+func NewExperimentService(db *gorm.DB, knowledgeService *KnowledgeService, unifiedAI *UnifiedAIService, promptService *PromptService,
+	jobQueue *JobQueue, embeddingProvider AIProvider, threshold float64) *ExperimentService {
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultExperimentThreshold
+	}
+	return &ExperimentService{
+		db:                db,
+		knowledgeService:  knowledgeService,
+		unifiedAI:         unifiedAI,
+		promptService:     promptService,
+		jobQueue:          jobQueue,
+		embeddingProvider: embeddingProvider,
+		threshold:         threshold,
+	}
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *ExperimentService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypePromptExperiment, func(ctx context.Context, job *models.Job) error {
+		var payload experimentPayload
+		if err := DecodeJobPayload(job, &payload); err != nil {
+			return err
+		}
+		_, err := s.RunExperiment(ctx, payload.RunID)
+		return err
+	})
+}
+
+// CreateQuestion adds a question to the golden set
+func (s *ExperimentService) CreateQuestion(question *models.GoldenQuestion) error {
+	question.Question = strings.TrimSpace(question.Question)
+	if question.Question == "" {
+		return validationError("question is required")
+	}
+	return s.db.Create(question).Error
+}
+
+// ListQuestions returns the golden questions, oldest first
+func (s *ExperimentService) ListQuestions() ([]models.GoldenQuestion, error) {
+	var questions []models.GoldenQuestion
+	err := s.db.Order("created_at ASC").Find(&questions).Error
+	return questions, err
+}
+
+// DeleteQuestion removes a question from the golden set. Runs keep the answers they gave to it.
+func (s *ExperimentService) DeleteQuestion(id uuid.UUID) error {
+	result := s.db.Delete(&models.GoldenQuestion{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "golden question "+id.String())
+	}
+	return nil
+}
+
+// StartRun records an experiment run and queues the job answering the golden questions
+func (s *ExperimentService) StartRun(ctx context.Context, spec ExperimentSpec) (*models.ExperimentRun, *models.Job, error) {
+	if s.jobQueue == nil {
+		return nil, nil, errors.New("job queue not configured")
+	}
+	if spec.Threshold < 0 || spec.Threshold > 1 {
+		return nil, nil, validationError("threshold must be between 0 and 1")
+	}
+
+	run := &models.ExperimentRun{
+		Provider:  string(spec.Provider),
+		Model:     spec.Model,
+		Threshold: spec.Threshold,
+		Status:    models.ExperimentQueued,
+		CreatedBy: spec.CreatedBy,
+	}
+	if run.Provider == "" {
+		run.Provider = string(s.unifiedAI.primaryProvider)
+	}
+	if run.Threshold == 0 {
+		run.Threshold = s.threshold
+	}
+	if spec.PromptID != nil {
+		prompt, err := s.promptService.GetPrompt(*spec.PromptID)
+		if err != nil {
+			return nil, nil, err
+		}
+		run.PromptID = &prompt.ID
+		run.PromptName = prompt.Name
+		run.PromptVersion = prompt.Version
+	}
+
+	if spec.BaselineRunID != nil {
+		baseline, err := s.GetRun(*spec.BaselineRunID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if baseline.Status != models.ExperimentCompleted {
+			return nil, nil, validationError("baseline run %s is %s", baseline.ID, baseline.Status)
+		}
+		run.BaselineRunID = &baseline.ID
+	} else {
+		golden, err := s.goldenRun(ctx, run.Provider)
+		if err != nil {
+			return nil, nil, err
+		}
+		if golden != nil {
+			run.BaselineRunID = &golden.ID
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, nil, err
+	}
+	job, err := s.jobQueue.Enqueue(ctx, EvaluationQueue, JobTypePromptExperiment, experimentPayload{RunID: run.ID}, &EnqueueOptions{MaxAttempts: 1})
+	if err != nil {
+		s.failRun(run, err)
+		return nil, nil, err
+	}
+	return run, job, nil
+}
+
+// ListRuns returns recent experiment runs, newest first
+func (s *ExperimentService) ListRuns(limit int) ([]models.ExperimentRun, error) {
+	var runs []models.ExperimentRun
+	err := s.db.Order("created_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// GetRun returns an experiment run with its answers, regressions first
+func (s *ExperimentService) GetRun(id uuid.UUID) (*models.ExperimentRun, error) {
+	var run models.ExperimentRun
+	err := s.db.Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("regressed DESC, similarity ASC NULLS LAST")
+	}).First(&run, "id = ?", id).Error
+	if err != nil {
+		return nil, notFound(err, "experiment run "+id.String())
+	}
+	return &run, nil
+}
+
+// PromoteRun makes a completed run the golden snapshot of its provider, replacing the previous one
+func (s *ExperimentService) PromoteRun(ctx context.Context, id uuid.UUID) (*models.ExperimentRun, error) {
+	run, err := s.GetRun(id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != models.ExperimentCompleted {
+		return nil, validationError("only completed runs can become golden, run %s is %s", run.ID, run.Status)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ExperimentRun{}).Where("provider = ? AND is_golden = ?", run.Provider, true).
+			Update("is_golden", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(run).Update("is_golden", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	run.IsGolden = true
+	log.Printf("[INFO] Experiment run %s is the golden run of provider %s", run.ID, run.Provider)
+	return run, nil
+}
+
+// RunExperiment answers every golden question the way the chat does, with the run's prompt version and
+// provider, and compares each answer with the baseline answer to the same question. The run passes when no
+// answer is less similar to its baseline answer than the threshold.
+func (s *ExperimentService) RunExperiment(ctx context.Context, id uuid.UUID) (*models.ExperimentRun, error) {
+	var run models.ExperimentRun
+	if err := s.db.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "experiment run "+id.String())
+	}
+	now := time.Now()
+	run.Status = models.ExperimentRunning
+	run.StartedAt = &now
+	if err := s.db.Save(&run).Error; err != nil {
+		return nil, err
+	}
+
+	if run.PromptID != nil {
+		prompt, err := s.promptService.GetPrompt(*run.PromptID)
+		if err != nil {
+			s.failRun(&run, err)
+			return nil, err
+		}
+		ctx = withPromptOverride(ctx, prompt)
+	}
+
+	questions, err := s.ListQuestions()
+	if err != nil {
+		s.failRun(&run, err)
+		return nil, err
+	}
+	baseline := map[uuid.UUID]string{}
+	if run.BaselineRunID != nil {
+		var answers []models.ExperimentAnswer
+		if err := s.db.Where("run_id = ? AND error = ''", *run.BaselineRunID).Find(&answers).Error; err != nil {
+			s.failRun(&run, err)
+			return nil, err
+		}
+		for _, answer := range answers {
+			baseline[answer.QuestionID] = answer.Answer
+		}
+	}
+
+	log.Printf("[INFO] Running experiment %s over %d golden questions with provider %s", run.ID, len(questions), run.Provider)
+
+	// Answers must be reproducible for the comparison to mean anything
+	temperature := float32(0)
+	scope := RetrievalScope{Role: models.AdminRole}
+	var similaritySum float64
+	run.MinSimilarity = 1
+
+	for _, question := range questions {
+		answer := models.ExperimentAnswer{
+			RunID:          run.ID,
+			QuestionID:     question.ID,
+			Question:       question.Question,
+			BaselineAnswer: baseline[question.ID],
+		}
+
+		entries, _, err := s.knowledgeService.SearchKnowledgeWithCitations(ctx, question.Question, retrievalEvalTopK, scope)
+		if err != nil {
+			s.failRun(&run, err)
+			return nil, fmt.Errorf("search failed for golden question %s: %w", question.ID, err)
+		}
+		req := chatPathRequest(question.Question, entries)
+		req.PreferredProvider = AIProvider(run.Provider)
+		req.Generation = GenerationParams{Model: run.Model, Temperature: &temperature}
+		resp, err := s.unifiedAI.ChatCompletion(ctx, req)
+		if err != nil {
+			log.Printf("[WARNING] Experiment %s failed to answer golden question %s: %v", run.ID, question.ID, err)
+			answer.Error = "answer failed: " + err.Error()
+		} else {
+			answer.Answer = resp.Message
+			answer.Provider = string(resp.Provider)
+		}
+
+		if answer.BaselineAnswer != "" {
+			if answer.Error == "" {
+				similarity, err := s.similarity(ctx, answer.Answer, answer.BaselineAnswer)
+				if err != nil {
+					log.Printf("[WARNING] Experiment %s failed to compare the answer to golden question %s: %v", run.ID, question.ID, err)
+					answer.Error = "comparison failed: " + err.Error()
+				} else {
+					answer.Similarity = &similarity
+					run.ComparedCount++
+					similaritySum += similarity
+					if similarity < run.MinSimilarity {
+						run.MinSimilarity = similarity
+					}
+				}
+			}
+			// An answer that could not be compared cannot be shown not to regress
+			answer.Regressed = answer.Similarity == nil || *answer.Similarity < run.Threshold
+			if answer.Regressed {
+				run.RegressionCount++
+			}
+		}
+
+		if err := s.db.Create(&answer).Error; err != nil {
+			s.failRun(&run, err)
+			return nil, err
+		}
+	}
+
+	run.QuestionCount = len(questions)
+	if run.ComparedCount > 0 {
+		run.MeanSimilarity = similaritySum / float64(run.ComparedCount)
+	} else {
+		run.MinSimilarity = 0
+	}
+	run.Passed = run.RegressionCount == 0
+	completed := time.Now()
+	run.Status = models.ExperimentCompleted
+	run.CompletedAt = &completed
+	if err := s.db.Save(&run).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Experiment %s completed: %d of %d answers compared, mean similarity %.3f, %d regressions",
+		run.ID, run.ComparedCount, run.QuestionCount, run.MeanSimilarity, run.RegressionCount)
+	return &run, nil
+}
+
+// similarity is the cosine similarity of the embeddings of two answers
+func (s *ExperimentService) similarity(ctx context.Context, answer, baseline string) (float64, error) {
+	if answer == baseline {
+		return 1, nil
+	}
+	a, err := s.unifiedAI.CreateEmbedding(ctx, answer, s.embeddingProvider)
+	if err != nil {
+		return 0, err
+	}
+	b, err := s.unifiedAI.CreateEmbedding(ctx, baseline, s.embeddingProvider)
+	if err != nil {
+		return 0, err
+	}
+	return cosineSimilarity(a, b), nil
+}
+
+// goldenRun returns the golden run of a provider, or nil when none was promoted
+func (s *ExperimentService) goldenRun(ctx context.Context, provider string) (*models.ExperimentRun, error) {
+	var run models.ExperimentRun
+	err := s.db.WithContext(ctx).Where("provider = ? AND is_golden = ?", provider, true).
+		Order("completed_at DESC").
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// failRun marks an experiment run as failed
+func (s *ExperimentService) failRun(run *models.ExperimentRun, runErr error) {
+	now := time.Now()
+	s.db.Model(run).Updates(map[string]interface{}{
+		"status":       models.ExperimentFailed,
+		"error":        runErr.Error(),
+		"completed_at": &now,
+	})
+	log.Printf("[ERROR] Experiment %s failed: %v", run.ID, runErr)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"tic-knowledge-system/internal/utils"
+)
+
+// Extractor returns the plain text of a file's content
+type Extractor interface {
+	Extract(data []byte) (string, error)
+}
+
+// ExtractorFunc adapts a function to an Extractor
+type ExtractorFunc func(data []byte) (string, error)
+
+// Extract calls f(data)
+func (f ExtractorFunc) Extract(data []byte) (string, error) {
+	return f(data)
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   = map[string]Extractor{
+		".docx": ExtractorFunc(extractDOCX),
+		".txt":  ExtractorFunc(extractPlainText),
+		".md":   ExtractorFunc(extractPlainText),
+		".html": ExtractorFunc(extractHTML),
+		".htm":  ExtractorFunc(extractHTML),
+	}
+)
+
+// RegisterExtractor sets the extractor for files with the extension ext, e.g. ".pdf", replacing any
+// registered before. Extractors are shared by document ingestion, bootstrap seeding and the prompt
+// injection scanner.
+func RegisterExtractor(ext string, extractor Extractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors[strings.ToLower(ext)] = extractor
+}
+
+// CanExtract reports whether the text of files with the extension ext, e.g. ".docx", can be extracted
+func CanExtract(ext string) bool {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	_, ok := extractors[strings.ToLower(ext)]
+	return ok
+}
+
+// extractText returns the plain text of file content whose type is given by ext
+func extractText(data []byte, ext string) (string, error) {
+	extractorsMu.RLock()
+	extractor, ok := extractors[strings.ToLower(ext)]
+	extractorsMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unsupported file type: %s", ext)
+	}
+	return extractor.Extract(data)
+}
+
+func extractPlainText(data []byte) (string, error) {
+	return string(data), nil
+}
+
+func extractHTML(data []byte) (string, error) {
+	return utils.StripHTML(string(data)), nil
+}
@@ -10,6 +10,9 @@ import (
 	"google.golang.org/api/option"
 )
 
+// GeminiEmbeddingModel is the model Gemini embeddings are created with
+const GeminiEmbeddingModel = "text-embedding-004"
+
 type GeminiService struct {
 	client              *genai.Client
 	model               string
@@ -17,6 +20,8 @@ type GeminiService struct {
 	temperature         float32
 	topP                float32
 	topK                int32
+	prompts             PromptSource
+	contextWindow       int // Overrides the context window of the model when positive
 }
 
 func NewGeminiService(apiKey, model string, maxTokens int, temperature float32) (*GeminiService, error) {
@@ -46,24 +51,37 @@ func NewGeminiService(apiKey, model string, maxTokens int, temperature float32)
 	}, nil
 }
 
+// SetPromptSource makes the system instruction come from the active prompt template when one exists
+func (s *GeminiService) SetPromptSource(prompts PromptSource) {
+	s.prompts = prompts
+}
+
+// SetContextWindow overrides the context window, in tokens, the knowledge base context is fitted into
+func (s *GeminiService) SetContextWindow(tokens int) {
+	s.contextWindow = tokens
+}
+
 type GeminiChatRequest struct {
 	Messages        []GeminiChatMessage `json:"messages"`
 	Context         []string           `json:"context,omitempty"`
 	SessionID       string             `json:"session_id,omitempty"`
 	UseKnowledgeBase bool              `json:"use_knowledge_base"`
 	SystemPrompt    string             `json:"system_prompt,omitempty"`
+	Generation      GenerationParams   `json:"generation,omitempty"`
 }
 
 type GeminiChatMessage struct {
-	Role    string `json:"role"`    // "user" or "model"
-	Content string `json:"content"`
+	Role    string      `json:"role"` // "user" or "model"
+	Content string      `json:"content"`
+	Images  []ChatImage `json:"-"`
 }
 
 type GeminiChatResponse struct {
-	Message   string   `json:"message"`
-	Sources   []string `json:"sources,omitempty"`
-	SessionID string   `json:"session_id"`
-	Model     string   `json:"model"`
+	Message   string     `json:"message"`
+	Sources   []string   `json:"sources,omitempty"`
+	SessionID string     `json:"session_id"`
+	Model     string     `json:"model"`
+	Usage     TokenUsage `json:"usage"`
 }
 
 func (s *GeminiService) ChatCompletion(ctx context.Context, req GeminiChatRequest) (*GeminiChatResponse, error) {
@@ -71,16 +89,36 @@ func (s *GeminiService) ChatCompletion(ctx context.Context, req GeminiChatReques
 	log.Printf("[DEBUG] Request contains %d messages, knowledge_base=%t", len(req.Messages), req.UseKnowledgeBase)
 
 	// Get the generative model
-	model := s.client.GenerativeModel(s.model)
+	modelName := s.model
+	if req.Generation.Model != "" {
+		modelName = req.Generation.Model
+	}
+	model := s.client.GenerativeModel(modelName)
 	
-	// Configure generation parameters
-	model.SetMaxOutputTokens(s.maxTokens)
-	model.SetTemperature(s.temperature)
-	model.SetTopP(s.topP)
+	// Configure generation parameters, applying per-request overrides
+	maxTokens, temperature, topP := s.maxTokens, s.temperature, s.topP
+	if req.Generation.MaxTokens != nil {
+		maxTokens = int32(*req.Generation.MaxTokens)
+	}
+	if req.Generation.Temperature != nil {
+		temperature = *req.Generation.Temperature
+	}
+	if req.Generation.TopP != nil {
+		topP = *req.Generation.TopP
+	}
+	model.SetMaxOutputTokens(maxTokens)
+	model.SetTemperature(temperature)
+	model.SetTopP(topP)
 	model.SetTopK(s.topK)
 
-	// Build system instruction with context
-	systemInstruction := s.buildSystemInstruction(req.Context, req.SystemPrompt)
+	// Build system instruction with as much of the context as fits the model's context window
+	basePrompt := resolvePrompt(ctx, s.prompts, PromptKnowledgeAssistant, GeminiProvider, defaultKnowledgePrompt)
+	conversationTokens := 0
+	for _, msg := range req.Messages {
+		conversationTokens += messageTokens(msg.Content) + len(msg.Images)*imageTokens
+	}
+	budget := contextBudget(modelName, s.contextWindow, int(maxTokens), basePrompt+req.SystemPrompt, conversationTokens)
+	systemInstruction := s.buildSystemInstruction(basePrompt, fitContext(modelName, req.Context, budget), req.SystemPrompt)
 	if systemInstruction != "" {
 		model.SystemInstruction = &genai.Content{
 			Parts: []genai.Part{genai.Text(systemInstruction)},
@@ -96,7 +134,7 @@ func (s *GeminiService) ChatCompletion(ctx context.Context, req GeminiChatReques
 		for _, msg := range req.Messages[:len(req.Messages)-1] {
 			role := s.convertRole(msg.Role)
 			chat.History = append(chat.History, &genai.Content{
-				Parts: []genai.Part{genai.Text(msg.Content)},
+				Parts: geminiParts(msg),
 				Role:  role,
 			})
 		}
@@ -107,7 +145,7 @@ func (s *GeminiService) ChatCompletion(ctx context.Context, req GeminiChatReques
 	currentMessage := req.Messages[len(req.Messages)-1]
 	log.Printf("[DEBUG] Sending message to Gemini: %.100s...", currentMessage.Content)
 
-	resp, err := chat.SendMessage(ctx, genai.Text(currentMessage.Content))
+	resp, err := chat.SendMessage(ctx, geminiParts(currentMessage)...)
 	if err != nil {
 		log.Printf("[ERROR] Gemini API call failed: %v", err)
 		return nil, fmt.Errorf("Gemini API error: %w", err)
@@ -127,11 +165,21 @@ func (s *GeminiService) ChatCompletion(ctx context.Context, req GeminiChatReques
 	response := responseText.String()
 	log.Printf("[INFO] Gemini API call successful, response length: %d characters", len(response))
 
+	var usage TokenUsage
+	if resp.UsageMetadata != nil {
+		usage = TokenUsage{
+			PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+			CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+			TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
+		}
+	}
+
 	return &GeminiChatResponse{
 		Message:   response,
 		Sources:   req.Context, // Return the context sources used
 		SessionID: req.SessionID,
-		Model:     s.model,
+		Model:     modelName,
+		Usage:     usage,
 	}, nil
 }
 
@@ -139,7 +187,7 @@ func (s *GeminiService) CreateEmbedding(ctx context.Context, text string) ([]flo
 	log.Printf("[INFO] Creating embedding for text with length: %d characters", len(text))
 	
 	// Use Gemini's embedding model
-	model := s.client.EmbeddingModel("text-embedding-004")
+	model := s.client.EmbeddingModel(GeminiEmbeddingModel)
 	
 	resp, err := model.EmbedContent(ctx, genai.Text(text))
 	if err != nil {
@@ -247,11 +295,8 @@ Keywords:`, content[:min(len(content), 1000)]) // Limit content
 	return keywords, nil
 }
 
-func (s *GeminiService) buildSystemInstruction(context []string, customPrompt string) string {
-	var instruction strings.Builder
-	
-	// Base system instruction
-	baseInstruction := `You are an AI assistant for a knowledge management system. You help employees find information and answer questions about operational procedures, troubleshooting, and company processes.
+// defaultKnowledgePrompt is the built-in system instruction used until a knowledge_assistant prompt template is active
+const defaultKnowledgePrompt = `You are an AI assistant for a knowledge management system. You help employees find information and answer questions about operational procedures, troubleshooting, and company processes.
 
 Instructions:
 - Provide accurate, helpful, and concise responses
@@ -260,6 +305,8 @@ Instructions:
 - Format your responses clearly with bullet points or numbered lists when appropriate
 - Focus on practical, actionable advice`
 
+func (s *GeminiService) buildSystemInstruction(baseInstruction string, context []string, customPrompt string) string {
+	var instruction strings.Builder
 	instruction.WriteString(baseInstruction)
 
 	// Add custom system prompt if provided
@@ -272,14 +319,25 @@ Instructions:
 	if len(context) > 0 {
 		instruction.WriteString("\n\nRelevant Knowledge Base Information:\n")
 		for i, ctx := range context {
-			instruction.WriteString(fmt.Sprintf("%d. %s\n", i+1, ctx))
+			instruction.WriteString(fmt.Sprintf("[%d] %s\n", i+1, ctx))
 		}
-		instruction.WriteString("\nUse this information to help answer the user's question when relevant.")
+		instruction.WriteString("\nUse this information to help answer the user's question when relevant. ")
+		instruction.WriteString("When a statement uses information from a source, cite it inline with the source's marker, e.g. [1] or [2]. ")
+		instruction.WriteString("Only cite the numbered sources above and never invent markers.")
 	}
 
 	return instruction.String()
 }
 
+// geminiParts converts a message into its text followed by its images
+func geminiParts(msg GeminiChatMessage) []genai.Part {
+	parts := []genai.Part{genai.Text(msg.Content)}
+	for _, image := range msg.Images {
+		parts = append(parts, genai.ImageData(geminiImageFormat(image.ContentType), image.Data))
+	}
+	return parts
+}
+
 func (s *GeminiService) convertRole(role string) string {
 	switch strings.ToLower(role) {
 	case "user":
@@ -303,3 +361,11 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// Ping checks that the API is reachable and the configured model exists without spending tokens
+func (s *GeminiService) Ping(ctx context.Context) error {
+	if _, err := s.client.GenerativeModel(s.model).Info(ctx); err != nil {
+		return fmt.Errorf("Gemini API error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"tic-knowledge-system/internal/models"
+)
+
+// Generation parameter override errors
+var (
+	ErrGenerationOverrideForbidden = fmt.Errorf("%w: generation parameter override not allowed for this role", ErrForbidden)
+	ErrGenerationParamOutOfRange   = fmt.Errorf("%w: generation parameter out of range", ErrValidation)
+)
+
+// GenerationParams are optional per-request overrides of the provider defaults.
+// Nil or empty fields keep the configured default.
+type GenerationParams struct {
+	Model       string   `json:"model,omitempty" example:"gpt-4o-mini"`
+	Temperature *float32 `json:"temperature,omitempty" example:"0.2"`
+	MaxTokens   *int     `json:"max_tokens,omitempty" example:"1024"`
+	TopP        *float32 `json:"top_p,omitempty" example:"0.9"`
+}
+
+// IsZero reports whether no parameter is overridden
+func (p GenerationParams) IsZero() bool {
+	return p.Model == "" && p.Temperature == nil && p.MaxTokens == nil && p.TopP == nil
+}
+
+// GenerationLimits bound the overrides a role may send
+type GenerationLimits struct {
+	AllowModel     bool    // May pick a different model
+	AllowSampling  bool    // May set temperature and top_p
+	MaxTemperature float32 // Upper bound for temperature when sampling is allowed
+	MaxTokens      int     // Upper bound for max_tokens, 0 disallows the override
+}
+
+// DefaultGenerationLimits are the per-role override limits. Roles not listed may not override anything.
+var DefaultGenerationLimits = map[models.UserRole]GenerationLimits{
+	models.AdminRole:   {AllowModel: true, AllowSampling: true, MaxTemperature: 2, MaxTokens: 8192},
+	models.EditorRole:  {AllowModel: true, AllowSampling: true, MaxTemperature: 1.5, MaxTokens: 4096},
+	models.SupportRole: {AllowSampling: true, MaxTemperature: 1, MaxTokens: 2048},
+}
+
+// ValidateGenerationParams checks the overrides against the limits of a role
+func ValidateGenerationParams(role models.UserRole, params GenerationParams) error {
+	if params.IsZero() {
+		return nil
+	}
+
+	limits := DefaultGenerationLimits[role]
+	if params.Model != "" && !limits.AllowModel {
+		return fmt.Errorf("%w: model", ErrGenerationOverrideForbidden)
+	}
+	if params.Temperature != nil {
+		if !limits.AllowSampling {
+			return fmt.Errorf("%w: temperature", ErrGenerationOverrideForbidden)
+		}
+		if *params.Temperature < 0 || *params.Temperature > limits.MaxTemperature {
+			return fmt.Errorf("%w: temperature must be between 0 and %.1f", ErrGenerationParamOutOfRange, limits.MaxTemperature)
+		}
+	}
+	if params.TopP != nil {
+		if !limits.AllowSampling {
+			return fmt.Errorf("%w: top_p", ErrGenerationOverrideForbidden)
+		}
+		if *params.TopP <= 0 || *params.TopP > 1 {
+			return fmt.Errorf("%w: top_p must be greater than 0 and at most 1", ErrGenerationParamOutOfRange)
+		}
+	}
+	if params.MaxTokens != nil {
+		if limits.MaxTokens == 0 {
+			return fmt.Errorf("%w: max_tokens", ErrGenerationOverrideForbidden)
+		}
+		if *params.MaxTokens <= 0 || *params.MaxTokens > limits.MaxTokens {
+			return fmt.Errorf("%w: max_tokens must be between 1 and %d", ErrGenerationParamOutOfRange, limits.MaxTokens)
+		}
+	}
+	return nil
+}
+
+// withDefaults fills the parameters a request does not override from defaults
+func (p GenerationParams) withDefaults(defaults GenerationParams) GenerationParams {
+	if p.Model == "" {
+		p.Model = defaults.Model
+	}
+	if p.Temperature == nil {
+		p.Temperature = defaults.Temperature
+	}
+	if p.MaxTokens == nil {
+		p.MaxTokens = defaults.MaxTokens
+	}
+	if p.TopP == nil {
+		p.TopP = defaults.TopP
+	}
+	return p
+}
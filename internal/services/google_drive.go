@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"gorm.io/gorm"
+)
+
+// JobTypeDriveSync is the job type of a Google Drive folder sync
+const JobTypeDriveSync = "drive_sync"
+
+// DriveSyncTriggerManual is the trigger of syncs queued on request
+const DriveSyncTriggerManual = "manual"
+
+const (
+	driveSyncEmail = "drive-sync@integrations.local"
+
+	driveMimePDF       = "application/pdf"
+	driveMimeDOCX      = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	driveMimeGoogleDoc = "application/vnd.google-apps.document" // Exported as DOCX
+)
+
+// GoogleDriveConfig connects the Google Drive integration to a folder, read with a service account the folder is shared with
+type GoogleDriveConfig struct {
+	CredentialsFile string // Service account JSON key
+	FolderID        string
+	Target          models.IngestionTarget // Empty uses the pipeline's default target
+	Category        string                 // Category of the entries of knowledge base imports
+	MaxBytes        int64                  // Larger files are skipped; zero for no limit
+}
+
+// GoogleDriveService watches a Google Drive folder and pushes its PDF and DOCX files, and Google Docs exported
+// as DOCX, through the ingestion pipeline. New and changed files are ingested as new documents replacing the
+// previous revision's; files removed from the folder have their documents deleted.
+type GoogleDriveService struct {
+	db        *gorm.DB
+	ingestion *IngestionService
+	jobQueue  *JobQueue
+	config    GoogleDriveConfig
+}
+
+// DriveSyncReport describes what a sync changed
+type DriveSyncReport struct {
+	FolderID    string    `json:"folder_id"`
+	Files       int       `json:"files"`
+	Created     int       `json:"created"`
+	Updated     int       `json:"updated"`
+	Unchanged   int       `json:"unchanged"`
+	Deleted     int       `json:"deleted"`
+	Skipped     int       `json:"skipped"` // Files over the size limit
+	Failed      int       `json:"failed"`
+	Errors      []string  `json:"errors,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// DriveStatus describes the integration and the files synced so far
+type DriveStatus struct {
+	Enabled      bool       `json:"enabled"`
+	FolderID     string     `json:"folder_id,omitempty"`
+	SyncedFiles  int64      `json:"synced_files"`
+	FailedFiles  int64      `json:"failed_files"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+}
+
+type driveSyncPayload struct {
+	Trigger string `json:"trigger"`
+}
+
+// NewGoogleDriveService creates the Google Drive integration. It is disabled unless the credentials file and folder are configured.
+func NewGoogleDriveService(db *gorm.DB, ingestion *IngestionService, jobQueue *JobQueue, config GoogleDriveConfig) *GoogleDriveService {
+	return &GoogleDriveService{
+		db:        db,
+		ingestion: ingestion,
+		jobQueue:  jobQueue,
+		config:    config,
+	}
+}
+
+// Enabled reports whether a folder is configured
+func (s *GoogleDriveService) Enabled() bool {
+	return s.config.CredentialsFile != "" && s.config.FolderID != ""
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *GoogleDriveService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeDriveSync, s.handleSyncJob)
+}
+
+// ScheduleSync queues a manual sync
+func (s *GoogleDriveService) ScheduleSync(ctx context.Context) (*models.Job, error) {
+	if !s.Enabled() {
+		return nil, validationError("the Google Drive integration is not configured")
+	}
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeDriveSync, driveSyncPayload{Trigger: DriveSyncTriggerManual}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// handleSyncJob runs a sync as a background job
+func (s *GoogleDriveService) handleSyncJob(ctx context.Context, job *models.Job) error {
+	if !s.Enabled() {
+		log.Printf("[INFO] Skipped Google Drive sync: the integration is no longer configured")
+		return nil
+	}
+
+	// Files that fail are recorded with their error and retried by the next sync, not by retrying the job
+	_, err := s.Sync(ctx)
+	return err
+}
+
+// Sync brings the ingested documents in line with the files of the folder. A file that fails keeps the
+// document of the sync before.
+func (s *GoogleDriveService) Sync(ctx context.Context) (*DriveSyncReport, error) {
+	if !s.Enabled() {
+		return nil, validationError("the Google Drive integration is not configured")
+	}
+	report := &DriveSyncReport{FolderID: s.config.FolderID, StartedAt: time.Now()}
+
+	client, err := drive.NewService(ctx, option.WithCredentialsFile(s.config.CredentialsFile), option.WithScopes(drive.DriveReadonlyScope))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Google Drive client: %w", err)
+	}
+	files, err := s.listFiles(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	report.Files = len(files)
+
+	var synced []models.DriveFile
+	if err := s.db.Where("folder_id = ?", s.config.FolderID).Find(&synced).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]*models.DriveFile, len(synced))
+	for i := range synced {
+		known[synced[i].DriveFileID] = &synced[i]
+	}
+
+	uploadedBy, err := integrationUser(s.db, driveSyncEmail, "Google Drive sync")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		record := known[file.Id]
+		delete(known, file.Id)
+		if s.config.MaxBytes > 0 && file.Size > s.config.MaxBytes {
+			report.Skipped++
+			continue
+		}
+		if record != nil && record.Revision == driveRevision(file) && record.SyncError == "" {
+			report.Unchanged++
+			continue
+		}
+		if err := s.syncFile(ctx, client, file, record, uploadedBy); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("file %s (%s): %v", file.Id, file.Name, err))
+			log.Printf("[WARNING] Failed to sync Google Drive file %s (%s): %v", file.Id, file.Name, err)
+		} else if record == nil {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	// Files deleted, trashed or moved out of the folder
+	for _, record := range known {
+		if err := s.removeFile(ctx, record); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("file %s (%s): %v", record.DriveFileID, record.Name, err))
+			continue
+		}
+		report.Deleted++
+	}
+
+	report.CompletedAt = time.Now()
+	log.Printf("[INFO] Synced Google Drive folder %s: %d files, %d created, %d updated, %d deleted, %d failed",
+		report.FolderID, report.Files, report.Created, report.Updated, report.Deleted, report.Failed)
+	return report, nil
+}
+
+// syncFile ingests the current revision of a file, then deletes the document of the previous one
+func (s *GoogleDriveService) syncFile(ctx context.Context, client *drive.Service, file *drive.File, record *models.DriveFile, uploadedBy uuid.UUID) error {
+	if record == nil {
+		record = &models.DriveFile{DriveFileID: file.Id, FolderID: s.config.FolderID}
+	}
+	record.Name = file.Name
+	record.MimeType = file.MimeType
+	record.SyncedAt = time.Now()
+
+	fail := func(err error) error {
+		record.SyncError = err.Error()
+		if saveErr := s.db.Save(record).Error; saveErr != nil {
+			log.Printf("[ERROR] Failed to save Google Drive file %s: %v", file.Id, saveErr)
+		}
+		return err
+	}
+
+	content, fileName, mimeType, err := s.download(ctx, client, file)
+	if err != nil {
+		return fail(err)
+	}
+	document, err := s.ingestion.Ingest(ctx, IngestRequest{
+		FileName:         fileName,
+		OriginalFileName: fileName,
+		MimeType:         mimeType,
+		Content:          content,
+		UploadedBy:       uploadedBy,
+		Target:           s.config.Target,
+		Category:         s.config.Category,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	previous := record.DocumentID
+	record.DocumentID = &document.ID
+	record.Revision = driveRevision(file)
+	record.SyncError = ""
+	if err := s.db.Save(record).Error; err != nil {
+		if deleteErr := s.ingestion.DeleteDocument(ctx, document.ID); deleteErr != nil {
+			log.Printf("[WARNING] Failed to delete document %s: %v", document.ID, deleteErr)
+		}
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if previous != nil {
+		if err := s.ingestion.DeleteDocument(ctx, *previous); err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("[WARNING] Failed to delete document %s of the previous revision of %s: %v", *previous, file.Name, err)
+		}
+	}
+	return nil
+}
+
+// removeFile deletes a file that left the folder along with its document
+func (s *GoogleDriveService) removeFile(ctx context.Context, record *models.DriveFile) error {
+	if record.DocumentID != nil {
+		if err := s.ingestion.DeleteDocument(ctx, *record.DocumentID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return s.db.Delete(record).Error
+}
+
+// listFiles returns the PDF, DOCX and Google Docs files directly in the folder
+func (s *GoogleDriveService) listFiles(ctx context.Context, client *drive.Service) ([]*drive.File, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false and (mimeType = '%s' or mimeType = '%s' or mimeType = '%s')",
+		strings.ReplaceAll(s.config.FolderID, "'", `\'`), driveMimePDF, driveMimeDOCX, driveMimeGoogleDoc)
+
+	var files []*drive.File
+	err := client.Files.List().
+		Q(query).
+		Fields("nextPageToken, files(id, name, mimeType, md5Checksum, modifiedTime, size)").
+		PageSize(100).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(page *drive.FileList) error {
+			files = append(files, page.Files...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Google Drive folder %s: %w", s.config.FolderID, err)
+	}
+	return files, nil
+}
+
+// download returns the content of a file, exporting Google Docs as DOCX
+func (s *GoogleDriveService) download(ctx context.Context, client *drive.Service, file *drive.File) ([]byte, string, string, error) {
+	fileName, mimeType := file.Name, file.MimeType
+	var body io.ReadCloser
+	if file.MimeType == driveMimeGoogleDoc {
+		resp, err := client.Files.Export(file.Id, driveMimeDOCX).Context(ctx).Download()
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to export: %w", err)
+		}
+		body = resp.Body
+		mimeType = driveMimeDOCX
+		if filepath.Ext(fileName) != ".docx" {
+			fileName += ".docx"
+		}
+	} else {
+		resp, err := client.Files.Get(file.Id).SupportsAllDrives(true).Context(ctx).Download()
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to download: %w", err)
+		}
+		body = resp.Body
+	}
+	defer body.Close()
+
+	reader := io.Reader(body)
+	if s.config.MaxBytes > 0 {
+		reader = io.LimitReader(body, s.config.MaxBytes+1)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to download: %w", err)
+	}
+	if s.config.MaxBytes > 0 && int64(len(content)) > s.config.MaxBytes {
+		return nil, "", "", fmt.Errorf("file is larger than %d bytes", s.config.MaxBytes)
+	}
+	return content, fileName, mimeType, nil
+}
+
+// driveRevision identifies the content of a file. Google Docs have no checksum, so their modification time is used.
+func driveRevision(file *drive.File) string {
+	if file.Md5Checksum != "" {
+		return file.Md5Checksum
+	}
+	return file.ModifiedTime
+}
+
+// Status describes the integration and the files synced so far
+func (s *GoogleDriveService) Status(ctx context.Context) (*DriveStatus, error) {
+	status := &DriveStatus{
+		Enabled:  s.Enabled(),
+		FolderID: s.config.FolderID,
+	}
+	if !status.Enabled {
+		return status, nil
+	}
+
+	var row struct {
+		Synced   int64
+		Failed   int64
+		LastSync *time.Time
+	}
+	err := s.db.WithContext(ctx).Model(&models.DriveFile{}).
+		Select("COUNT(*) AS synced, COUNT(*) FILTER (WHERE sync_error <> '') AS failed, MAX(synced_at) AS last_sync").
+		Where("folder_id = ?", s.config.FolderID).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	status.SyncedFiles = row.Synced
+	status.FailedFiles = row.Failed
+	status.LastSyncedAt = row.LastSync
+	return status, nil
+}
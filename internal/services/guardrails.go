@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrContentBlocked is returned when a guardrail blocks a user message
+var ErrContentBlocked = fmt.Errorf("%w: message blocked by content guardrails", ErrValidation)
+
+// guardrailRefusal replaces an AI response blocked by a guardrail
+const guardrailRefusal = "I'm sorry, but I can't provide that response. Please rephrase your question or contact support."
+
+// GuardrailFinding is a category of content a guardrail found in a text
+type GuardrailFinding struct {
+	Category string
+	Count    int
+}
+
+// GuardrailResult is the outcome of a guardrail. Text is the possibly rewritten text;
+// Blocked means the text must not be used at all.
+type GuardrailResult struct {
+	Text     string
+	Findings []GuardrailFinding
+	Blocked  bool
+}
+
+// Guardrail inspects, and may rewrite or block, text exchanged with the AI providers
+type Guardrail interface {
+	Name() string
+	Apply(ctx context.Context, text string) (GuardrailResult, error)
+}
+
+// ModerationEventFilter filters the moderation events
+type ModerationEventFilter struct {
+	UserID *uuid.UUID
+	Stage  models.ModerationStage
+	Limit  int
+	Offset int
+}
+
+// GuardrailService runs user messages and AI responses through pipelines of guardrails
+// and records every violation as a moderation event
+type GuardrailService struct {
+	db     *gorm.DB
+	input  []Guardrail
+	output []Guardrail
+}
+
+// NewGuardrailService creates an empty guardrail pipeline
+func NewGuardrailService(db *gorm.DB) *GuardrailService {
+	return &GuardrailService{db: db}
+}
+
+// AddInput adds guardrails applied to user messages before they reach a provider
+func (s *GuardrailService) AddInput(guardrails ...Guardrail) {
+	s.input = append(s.input, guardrails...)
+}
+
+// AddOutput adds guardrails applied to AI responses before they reach the user
+func (s *GuardrailService) AddOutput(guardrails ...Guardrail) {
+	s.output = append(s.output, guardrails...)
+}
+
+// ProcessInput returns the user message with sensitive content redacted, or ErrContentBlocked
+func (s *GuardrailService) ProcessInput(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID, text string) (string, error) {
+	if s == nil {
+		return text, nil
+	}
+	text, blocked := s.run(ctx, s.input, models.ModerationInput, userID, sessionID, text)
+	if blocked {
+		return "", ErrContentBlocked
+	}
+	return text, nil
+}
+
+// ProcessOutput returns the AI response to show the user. A blocked response is replaced by a refusal
+// and reported as blocked so it is not cached.
+func (s *GuardrailService) ProcessOutput(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID, text string) (string, bool) {
+	if s == nil {
+		return text, false
+	}
+	text, blocked := s.run(ctx, s.output, models.ModerationOutput, userID, sessionID, text)
+	if blocked {
+		return guardrailRefusal, true
+	}
+	return text, false
+}
+
+// ListEvents lists moderation events, newest first
+func (s *GuardrailService) ListEvents(filter ModerationEventFilter) ([]models.ModerationEvent, int64, error) {
+	var events []models.ModerationEvent
+	var total int64
+
+	query := s.db.Model(&models.ModerationEvent{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Stage != "" {
+		query = query.Where("stage = ?", filter.Stage)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// run applies the guardrails in order, stopping at the first one that blocks the text.
+// A guardrail that fails is skipped so an unavailable moderation API does not take chat down.
+func (s *GuardrailService) run(ctx context.Context, guardrails []Guardrail, stage models.ModerationStage, userID uuid.UUID, sessionID *uuid.UUID, text string) (string, bool) {
+	for _, guardrail := range guardrails {
+		result, err := guardrail.Apply(ctx, text)
+		if err != nil {
+			log.Printf("[WARNING] Guardrail %s failed on %s, skipping it: %v", guardrail.Name(), stage, err)
+			continue
+		}
+
+		action := models.ModerationRedacted
+		if result.Blocked {
+			action = models.ModerationBlocked
+		}
+		s.recordEvents(userID, sessionID, stage, guardrail.Name(), action, result.Findings)
+
+		if result.Blocked {
+			return "", true
+		}
+		text = result.Text
+	}
+	return text, false
+}
+
+func (s *GuardrailService) recordEvents(userID uuid.UUID, sessionID *uuid.UUID, stage models.ModerationStage, guardrail string, action models.ModerationAction, findings []GuardrailFinding) {
+	for _, finding := range findings {
+		log.Printf("[INFO] [GUARDRAIL] %s %s %d %s occurrence(s) in %s of user %s",
+			guardrail, action, finding.Count, finding.Category, stage, userID)
+		event := &models.ModerationEvent{
+			UserID:    userID,
+			SessionID: sessionID,
+			Stage:     stage,
+			Guardrail: guardrail,
+			Category:  finding.Category,
+			Action:    action,
+			Count:     finding.Count,
+		}
+		if err := s.db.Create(event).Error; err != nil {
+			log.Printf("[ERROR] Failed to record moderation event for user %s: %v", userID, err)
+		}
+	}
+}
+
+// PIIRedactor replaces email addresses, phone numbers, and card numbers with placeholders
+type PIIRedactor struct{}
+
+var (
+	piiEmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	piiCardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	piiPhonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)\s?|\d{2,4}[\s.-])\d{3,4}[\s.-]?\d{3,4}\b|\+\d{8,14}\b`)
+)
+
+func (PIIRedactor) Name() string { return "pii_redaction" }
+
+func (PIIRedactor) Apply(ctx context.Context, text string) (GuardrailResult, error) {
+	result := GuardrailResult{Text: text}
+	redact := func(category, placeholder string, pattern *regexp.Regexp, valid func(string) bool) {
+		count := 0
+		result.Text = pattern.ReplaceAllStringFunc(result.Text, func(match string) string {
+			if valid != nil && !valid(match) {
+				return match
+			}
+			count++
+			return placeholder
+		})
+		if count > 0 {
+			result.Findings = append(result.Findings, GuardrailFinding{Category: category, Count: count})
+		}
+	}
+
+	// Cards go before phone numbers, which would otherwise match parts of them
+	redact("email", "[REDACTED_EMAIL]", piiEmailPattern, nil)
+	redact("card_number", "[REDACTED_CARD]", piiCardPattern, luhnValid)
+	redact("phone_number", "[REDACTED_PHONE]", piiPhonePattern, func(match string) bool {
+		digits := countDigits(match)
+		return digits >= 7 && digits <= 15
+	})
+	return result, nil
+}
+
+// OpenAIModerator blocks text flagged by the OpenAI moderation endpoint
+type OpenAIModerator struct {
+	openAI *OpenAIService
+}
+
+// NewOpenAIModerator creates a guardrail backed by the OpenAI moderation endpoint
+func NewOpenAIModerator(openAI *OpenAIService) *OpenAIModerator {
+	return &OpenAIModerator{openAI: openAI}
+}
+
+func (m *OpenAIModerator) Name() string { return "openai_moderation" }
+
+func (m *OpenAIModerator) Apply(ctx context.Context, text string) (GuardrailResult, error) {
+	categories, err := m.openAI.Moderate(ctx, text)
+	if err != nil {
+		return GuardrailResult{}, err
+	}
+	result := GuardrailResult{Text: text, Blocked: len(categories) > 0}
+	for _, category := range categories {
+		result.Findings = append(result.Findings, GuardrailFinding{Category: category, Count: 1})
+	}
+	return result, nil
+}
+
+// RegexModerator blocks text matching a configured pattern
+type RegexModerator struct {
+	pattern *regexp.Regexp
+}
+
+// NewRegexModerator creates a guardrail that blocks text matching pattern
+func NewRegexModerator(pattern string) (*RegexModerator, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blocked pattern: %w", err)
+	}
+	return &RegexModerator{pattern: re}, nil
+}
+
+func (m *RegexModerator) Name() string { return "regex_moderation" }
+
+func (m *RegexModerator) Apply(ctx context.Context, text string) (GuardrailResult, error) {
+	matches := m.pattern.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return GuardrailResult{Text: text}, nil
+	}
+	return GuardrailResult{
+		Text:     text,
+		Findings: []GuardrailFinding{{Category: "blocked_pattern", Count: len(matches)}},
+		Blocked:  true,
+	}, nil
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum used by card numbers
+func luhnValid(s string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func countDigits(s string) int {
+	count := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			count++
+		}
+	}
+	return count
+}
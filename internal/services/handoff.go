@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrHandoffAgentOnly is returned when someone other than a support agent or admin handles an escalation
+var ErrHandoffAgentOnly = fmt.Errorf("%w: only support agents can handle escalations", ErrForbidden)
+
+// handoffNotice answers the messages a user sends while their session is escalated
+const handoffNotice = "Your message was passed on to our support team, who will answer you here."
+
+// EscalationFilter filters the escalations listed for support agents
+type EscalationFilter struct {
+	Status  models.EscalationStatus
+	AgentID *uuid.UUID // Only escalations claimed by this agent
+	Limit   int
+	Offset  int
+}
+
+// HandoffService hands escalated chat sessions over to support agents, who answer the user in the same
+// session until they hand it back to the assistant
+type HandoffService struct {
+	db *gorm.DB
+}
+
+// NewHandoffService creates the conversation handoff service
+func NewHandoffService(db *gorm.DB) *HandoffService {
+	return &HandoffService{db: db}
+}
+
+// Escalate asks for a support agent to take over a session of the user. A session that is already
+// escalated keeps its escalation.
+func (s *HandoffService) Escalate(ctx context.Context, sessionID, userID uuid.UUID, reason string) (*models.ChatEscalation, error) {
+	var session models.ChatSession
+	err := s.db.WithContext(ctx).Where("id = ? AND user_id = ? AND is_active = true", sessionID, userID).First(&session).Error
+	if err != nil {
+		return nil, notFound(err, "chat session "+sessionID.String())
+	}
+
+	active, err := s.ActiveEscalation(ctx, sessionID)
+	if err != nil || active != nil {
+		return active, err
+	}
+
+	escalation := &models.ChatEscalation{
+		SessionID: sessionID,
+		UserID:    userID,
+		Reason:    strings.TrimSpace(reason),
+		Status:    models.EscalationOpen,
+	}
+	if err := s.db.WithContext(ctx).Create(escalation).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Chat session %s escalated to support by user %s", sessionID, userID)
+	return escalation, nil
+}
+
+// ActiveEscalation returns the open or claimed escalation of a session, or nil when the assistant answers it
+func (s *HandoffService) ActiveEscalation(ctx context.Context, sessionID uuid.UUID) (*models.ChatEscalation, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var escalation models.ChatEscalation
+	err := s.db.WithContext(ctx).
+		Where("session_id = ? AND status IN ?", sessionID, []models.EscalationStatus{models.EscalationOpen, models.EscalationClaimed}).
+		Order("created_at DESC").
+		First(&escalation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &escalation, nil
+}
+
+// List lists escalations for a support agent, oldest first so that the longest waiting users are served first
+func (s *HandoffService) List(ctx context.Context, agentID uuid.UUID, filter EscalationFilter) ([]models.ChatEscalation, int64, error) {
+	if _, err := s.requireAgent(ctx, agentID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.ChatEscalation{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.AgentID != nil {
+		query = query.Where("agent_id = ?", *filter.AgentID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var escalations []models.ChatEscalation
+	err := query.Preload("User").Preload("Agent").
+		Order("created_at ASC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&escalations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return escalations, total, nil
+}
+
+// Get returns an escalation with the transcript of its session
+func (s *HandoffService) Get(ctx context.Context, id, agentID uuid.UUID) (*models.ChatEscalation, error) {
+	if _, err := s.requireAgent(ctx, agentID); err != nil {
+		return nil, err
+	}
+	var escalation models.ChatEscalation
+	err := s.db.WithContext(ctx).Preload("User").Preload("Agent").
+		Preload("Session.Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&escalation, "id = ?", id).Error
+	if err != nil {
+		return nil, notFound(err, "escalation "+id.String())
+	}
+	return &escalation, nil
+}
+
+// Claim assigns an open escalation to the agent, who then answers the user in their session
+func (s *HandoffService) Claim(ctx context.Context, id, agentID uuid.UUID) (*models.ChatEscalation, error) {
+	if _, err := s.requireAgent(ctx, agentID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&models.ChatEscalation{}).
+		Where("id = ? AND status = ?", id, models.EscalationOpen).
+		Updates(map[string]interface{}{
+			"status":     models.EscalationClaimed,
+			"agent_id":   agentID,
+			"claimed_at": now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var escalation models.ChatEscalation
+	if err := s.db.WithContext(ctx).First(&escalation, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "escalation "+id.String())
+	}
+	if result.RowsAffected == 0 && !claimedBy(&escalation, agentID) {
+		// Two agents claiming at once: the conditional update lets only one of them win
+		return nil, validationError("escalation is already %s", escalation.Status)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("[INFO] Escalation %s of session %s claimed by agent %s", id, escalation.SessionID, agentID)
+	}
+	return &escalation, nil
+}
+
+// PostMessage posts a message of the agent who claimed an escalation into the user's session
+func (s *HandoffService) PostMessage(ctx context.Context, id, agentID uuid.UUID, content string) (*models.ChatMessage, error) {
+	agent, err := s.requireAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, validationError("content is required")
+	}
+	escalation, err := s.claimed(ctx, id, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The agent's name lets clients show who joined the conversation
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"escalation_id": escalation.ID,
+		"agent_id":      agent.ID,
+		"agent_name":    agent.Name,
+	})
+	message := &models.ChatMessage{
+		SessionID: escalation.SessionID,
+		Role:      models.SupportMessage,
+		Content:   content,
+		Metadata:  string(metadata),
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ChatSession{}).Where("id = ?", escalation.SessionID).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// Resolve closes an escalation claimed by the agent and hands the session back to the assistant
+func (s *HandoffService) Resolve(ctx context.Context, id, agentID uuid.UUID) (*models.ChatEscalation, error) {
+	if _, err := s.requireAgent(ctx, agentID); err != nil {
+		return nil, err
+	}
+	escalation, err := s.claimed(ctx, id, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	escalation.Status = models.EscalationResolved
+	escalation.ResolvedAt = &now
+	if err := s.db.WithContext(ctx).Save(escalation).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Escalation %s resolved by agent %s, session %s is answered by the assistant again", id, agentID, escalation.SessionID)
+	return escalation, nil
+}
+
+// claimed returns an escalation the agent claimed and has not resolved yet
+func (s *HandoffService) claimed(ctx context.Context, id, agentID uuid.UUID) (*models.ChatEscalation, error) {
+	var escalation models.ChatEscalation
+	if err := s.db.WithContext(ctx).First(&escalation, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "escalation "+id.String())
+	}
+	if !claimedBy(&escalation, agentID) {
+		if escalation.Status == models.EscalationClaimed {
+			return nil, validationError("escalation is claimed by another agent")
+		}
+		return nil, validationError("escalation is %s, it must be claimed first", escalation.Status)
+	}
+	return &escalation, nil
+}
+
+func claimedBy(escalation *models.ChatEscalation, agentID uuid.UUID) bool {
+	return escalation.Status == models.EscalationClaimed && escalation.AgentID != nil && *escalation.AgentID == agentID
+}
+
+func (s *HandoffService) requireAgent(ctx context.Context, agentID uuid.UUID) (*models.User, error) {
+	var agent models.User
+	if err := s.db.WithContext(ctx).Select("id", "name", "role").First(&agent, "id = ?", agentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHandoffAgentOnly
+		}
+		return nil, err
+	}
+	if agent.Role != models.SupportRole && agent.Role != models.AdminRole {
+		return nil, ErrHandoffAgentOnly
+	}
+	return &agent, nil
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	helpMaxTips         = 5
+	helpTipContextChars = 1500
+	defaultHelpTipsTTL  = 24 * time.Hour
+)
+
+var helpScreenIDPattern = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)*$`)
+
+// HelpScreenSpec is the curated help of a screen
+type HelpScreenSpec struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	EntryIDs    []uuid.UUID `json:"entry_ids"` // Knowledge entries in display order
+}
+
+// HelpContext is the help shown on a screen
+type HelpContext struct {
+	ScreenID        string                  `json:"screen_id"`         // Requested screen
+	MatchedScreenID string                  `json:"matched_screen_id"` // Screen whose help was found, the requested one or a parent
+	Title           string                  `json:"title"`
+	Entries         []models.KnowledgeEntry `json:"entries"`
+	Tips            []string                `json:"tips"`
+	TipsGeneratedAt *time.Time              `json:"tips_generated_at,omitempty"`
+}
+
+// HelpService maps application screens to curated knowledge entries and AI-generated quick tips
+type HelpService struct {
+	db               *gorm.DB
+	knowledgeService *KnowledgeService
+	unifiedAI        *UnifiedAIService
+	tipsTTL          time.Duration
+}
+
+// NewHelpService creates the contextual help service. Tips are regenerated after tipsTTL.
+func NewHelpService(db *gorm.DB, knowledgeService *KnowledgeService, unifiedAI *UnifiedAIService, tipsTTL time.Duration) *HelpService {
+	if tipsTTL <= 0 {
+		tipsTTL = defaultHelpTipsTTL
+	}
+	return &HelpService{db: db, knowledgeService: knowledgeService, unifiedAI: unifiedAI, tipsTTL: tipsTTL}
+}
+
+// ListScreens lists the screens that have help, with their curated entries
+func (s *HelpService) ListScreens() ([]models.HelpScreen, error) {
+	var screens []models.HelpScreen
+	err := s.db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Order("screen_id ASC").Find(&screens).Error
+	return screens, err
+}
+
+// SetScreen replaces the help of a screen. Its tips are regenerated on the next request.
+func (s *HelpService) SetScreen(screenID string, spec HelpScreenSpec, updatedBy *uuid.UUID) (*models.HelpScreen, error) {
+	if !helpScreenIDPattern.MatchString(screenID) {
+		return nil, validationError("screen ID must be lowercase dotted segments such as orders.pending")
+	}
+
+	if len(spec.EntryIDs) > 0 {
+		var found int64
+		if err := s.db.Model(&models.KnowledgeEntry{}).Where("id IN ?", spec.EntryIDs).Count(&found).Error; err != nil {
+			return nil, err
+		}
+		if int(found) != len(uniqueUUIDs(spec.EntryIDs)) {
+			return nil, validationError("entry_ids contains unknown knowledge entries")
+		}
+	}
+
+	screen := &models.HelpScreen{
+		ScreenID:    screenID,
+		Title:       spec.Title,
+		Description: spec.Description,
+		Tips:        "[]",
+		UpdatedBy:   updatedBy,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.HelpScreen
+		if err := tx.Select("screen_id", "created_at").First(&existing, "screen_id = ?", screenID).Error; err == nil {
+			screen.CreatedAt = existing.CreatedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := tx.Save(screen).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("screen_id = ?", screenID).Delete(&models.HelpScreenEntry{}).Error; err != nil {
+			return err
+		}
+		for i, entryID := range uniqueUUIDs(spec.EntryIDs) {
+			entry := models.HelpScreenEntry{ScreenID: screenID, KnowledgeEntryID: entryID, Position: i}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			screen.Entries = append(screen.Entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Updated help for screen %s with %d entries", screenID, len(screen.Entries))
+	return screen, nil
+}
+
+// DeleteScreen removes the help of a screen
+func (s *HelpService) DeleteScreen(screenID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("screen_id = ?", screenID).Delete(&models.HelpScreenEntry{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.HelpScreen{}, "screen_id = ?", screenID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return notFound(gorm.ErrRecordNotFound, "help screen "+screenID)
+		}
+		return nil
+	})
+}
+
+// GetContext returns the help of a screen, falling back to its closest parent with help
+// (orders.pending, then orders). Unpublished entries and entries the user may not see are left out;
+// without a user only entries that carry no ACL are returned.
+func (s *HelpService) GetContext(ctx context.Context, screenID string, userID *uuid.UUID) (*HelpContext, error) {
+	screen, err := s.findScreen(screenID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scope RetrievalScope
+	if userID != nil {
+		scope = s.knowledgeService.ScopeForUser(*userID)
+	}
+
+	entries, err := s.screenEntries(screen.ScreenID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.refreshTips(ctx, screen)
+	var tips []string
+	if screen.Tips != "" {
+		if err := json.Unmarshal([]byte(screen.Tips), &tips); err != nil {
+			log.Printf("[WARNING] Invalid tips stored for help screen %s: %v", screen.ScreenID, err)
+		}
+	}
+	if tips == nil {
+		tips = []string{}
+	}
+
+	return &HelpContext{
+		ScreenID:        screenID,
+		MatchedScreenID: screen.ScreenID,
+		Title:           screen.Title,
+		Entries:         entries,
+		Tips:            tips,
+		TipsGeneratedAt: screen.TipsGeneratedAt,
+	}, nil
+}
+
+// screenEntries returns the published entries curated for a screen that the scope may see, in display order
+func (s *HelpService) screenEntries(screenID string, scope RetrievalScope) ([]models.KnowledgeEntry, error) {
+	var entries []models.KnowledgeEntry
+	err := scope.Apply(s.db.Model(&models.KnowledgeEntry{})).
+		Select("knowledge_entries.*").
+		Joins("JOIN help_screen_entries ON help_screen_entries.knowledge_entry_id = knowledge_entries.id").
+		Where("help_screen_entries.screen_id = ? AND knowledge_entries.is_published = ?", screenID, true).
+		Order("help_screen_entries.position ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// findScreen returns the help of the screen or of its closest parent
+func (s *HelpService) findScreen(screenID string) (*models.HelpScreen, error) {
+	for candidate := screenID; candidate != ""; {
+		var screen models.HelpScreen
+		err := s.db.First(&screen, "screen_id = ?", candidate).Error
+		if err == nil {
+			return &screen, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		dot := strings.LastIndex(candidate, ".")
+		if dot < 0 {
+			break
+		}
+		candidate = candidate[:dot]
+	}
+	return nil, notFound(gorm.ErrRecordNotFound, "help for screen "+screenID)
+}
+
+// refreshTips regenerates stale tips. Tips are only written from entries without access
+// restrictions since every user of the screen sees them. On failure the previous tips are kept.
+func (s *HelpService) refreshTips(ctx context.Context, screen *models.HelpScreen) {
+	if s.unifiedAI == nil {
+		return
+	}
+	if screen.TipsGeneratedAt != nil && time.Since(*screen.TipsGeneratedAt) < s.tipsTTL {
+		return
+	}
+
+	// The zero scope only sees entries without access restrictions
+	entries, err := s.screenEntries(screen.ScreenID, RetrievalScope{})
+	if err != nil {
+		log.Printf("[WARNING] Failed to load entries for help tips of screen %s: %v", screen.ScreenID, err)
+		return
+	}
+
+	resp, err := s.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages: []UnifiedChatMessage{{Role: "user", Content: helpTipsPrompt(screen, entries)}},
+	})
+	if err != nil {
+		log.Printf("[WARNING] Failed to generate help tips for screen %s, keeping previous tips: %v", screen.ScreenID, err)
+		return
+	}
+
+	tips, _ := json.Marshal(parseListAnswer(resp.Message, helpMaxTips))
+	now := time.Now()
+	err = s.db.Model(&models.HelpScreen{}).Where("screen_id = ?", screen.ScreenID).Updates(map[string]interface{}{
+		"tips":              string(tips),
+		"tips_generated_at": &now,
+	}).Error
+	if err != nil {
+		log.Printf("[WARNING] Failed to store help tips for screen %s: %v", screen.ScreenID, err)
+	}
+	screen.Tips = string(tips)
+	screen.TipsGeneratedAt = &now
+}
+
+func helpTipsPrompt(screen *models.HelpScreen, entries []models.KnowledgeEntry) string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Write quick tips for the %q screen (%s) of our operations web application.\n", screen.Title, screen.ScreenID)
+	if screen.Description != "" {
+		fmt.Fprintf(&prompt, "What the screen is for: %s\n", screen.Description)
+	}
+	if len(entries) > 0 {
+		prompt.WriteString("\nRelevant knowledge base articles:\n")
+		for i, entry := range entries {
+			content := entry.Content
+			if len(content) > helpTipContextChars {
+				content = content[:helpTipContextChars] + "..."
+			}
+			fmt.Fprintf(&prompt, "[%d] %s\n%s\n\n", i+1, entry.Title, content)
+		}
+	}
+	fmt.Fprintf(&prompt, "\nReply with at most %d short, practical tips, one per line, without numbering or any other text. "+
+		"Only use facts from the articles and the screen description.", helpMaxTips)
+	return prompt.String()
+}
+
+// parseListAnswer splits a model answer written one item per line into at most max items,
+// dropping list markers and numbering
+func parseListAnswer(answer string, max int) []string {
+	items := []string{}
+	for _, line := range strings.Split(answer, "\n") {
+		item := strings.TrimSpace(line)
+		item = strings.TrimLeft(item, "-*• ")
+		if i := strings.Index(item, ". "); i > 0 && i <= 3 && strings.Trim(item[:i], "0123456789") == "" {
+			item = item[i+2:]
+		}
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		items = append(items, item)
+		if len(items) == max {
+			break
+		}
+	}
+	return items
+}
+
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
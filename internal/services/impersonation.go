@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Impersonation errors
+var (
+	ErrImpersonationInvalid   = errors.New("invalid or expired impersonation token")
+	ErrImpersonationAdminOnly = fmt.Errorf("%w: only admins can impersonate users", ErrForbidden)
+	ErrImpersonationTarget    = fmt.Errorf("%w: admins cannot be impersonated", ErrForbidden)
+)
+
+const impersonationTokenPrefix = "imp_"
+
+// ImpersonationGrant is a started impersonation session and its token. The token is only returned here.
+type ImpersonationGrant struct {
+	Session *models.ImpersonationSession `json:"session"`
+	Token   string                       `json:"token"`
+}
+
+// ImpersonationAuditFilter filters the impersonation audit trail
+type ImpersonationAuditFilter struct {
+	SessionID    *uuid.UUID
+	AdminID      *uuid.UUID
+	TargetUserID *uuid.UUID
+	Limit        int
+	Offset       int
+}
+
+// ImpersonationService lets admins act as another user, read-only, to reproduce what the user sees.
+// Every session and every request made with it is recorded in the audit trail.
+type ImpersonationService struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+// NewImpersonationService creates the impersonation service. Tokens expire after ttl.
+func NewImpersonationService(db *gorm.DB, ttl time.Duration) *ImpersonationService {
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	return &ImpersonationService{db: db, ttl: ttl}
+}
+
+// Start issues an impersonation token for targetUserID. adminID must belong to an admin.
+func (s *ImpersonationService) Start(ctx context.Context, adminID, targetUserID uuid.UUID, reason string) (*ImpersonationGrant, error) {
+	if reason == "" {
+		return nil, validationError("reason is required")
+	}
+	if adminID == targetUserID {
+		return nil, validationError("admins cannot impersonate themselves")
+	}
+
+	if err := s.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	var target models.User
+	if err := s.db.WithContext(ctx).Select("id", "role").First(&target, "id = ?", targetUserID).Error; err != nil {
+		return nil, notFound(err, "user "+targetUserID.String())
+	}
+	if target.Role == models.AdminRole {
+		return nil, ErrImpersonationTarget
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate impersonation token: %w", err)
+	}
+	token := impersonationTokenPrefix + hex.EncodeToString(secret)
+
+	session := &models.ImpersonationSession{
+		AdminID:      adminID,
+		TargetUserID: targetUserID,
+		TokenHash:    hashImpersonationToken(token),
+		Reason:       reason,
+		ExpiresAt:    time.Now().Add(s.ttl),
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Create(impersonationAuditEntry(session, models.ImpersonationStarted, "", "", 0)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] [IMPERSONATION] Admin %s started impersonating user %s until %s (session %s): %s",
+		adminID, targetUserID, session.ExpiresAt.Format(time.RFC3339), session.ID, reason)
+	return &ImpersonationGrant{Session: session, Token: token}, nil
+}
+
+// Resolve returns the active session of a token, or ErrImpersonationInvalid
+func (s *ImpersonationService) Resolve(ctx context.Context, token string) (*models.ImpersonationSession, error) {
+	var session models.ImpersonationSession
+	err := s.db.WithContext(ctx).
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hashImpersonationToken(token), time.Now()).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrImpersonationInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Stop revokes an impersonation session. adminID must belong to an admin.
+func (s *ImpersonationService) Stop(ctx context.Context, sessionID, adminID uuid.UUID) error {
+	if err := s.requireAdmin(ctx, adminID); err != nil {
+		return err
+	}
+
+	var session models.ImpersonationSession
+	if err := s.db.WithContext(ctx).First(&session, "id = ?", sessionID).Error; err != nil {
+		return notFound(err, "impersonation session "+sessionID.String())
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&session).Update("revoked_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Create(impersonationAuditEntry(&session, models.ImpersonationStopped, "", "", 0)).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] [IMPERSONATION] Admin %s stopped impersonation session %s (admin %s as user %s)",
+		adminID, session.ID, session.AdminID, session.TargetUserID)
+	return nil
+}
+
+// RecordRequest adds a request made with an impersonation token to the audit trail
+func (s *ImpersonationService) RecordRequest(session *models.ImpersonationSession, event models.ImpersonationEvent, method, path string, status int) {
+	log.Printf("[INFO] [IMPERSONATION] Admin %s as user %s: %s %s -> %d (%s)",
+		session.AdminID, session.TargetUserID, method, path, status, event)
+	if err := s.db.Create(impersonationAuditEntry(session, event, method, path, status)).Error; err != nil {
+		log.Printf("[ERROR] Failed to record impersonation audit entry for session %s: %v", session.ID, err)
+	}
+}
+
+// ListAudit lists the impersonation audit trail, newest first
+func (s *ImpersonationService) ListAudit(filter ImpersonationAuditFilter) ([]models.ImpersonationAuditLog, int64, error) {
+	var entries []models.ImpersonationAuditLog
+	var total int64
+
+	query := s.db.Model(&models.ImpersonationAuditLog{})
+	if filter.SessionID != nil {
+		query = query.Where("session_id = ?", *filter.SessionID)
+	}
+	if filter.AdminID != nil {
+		query = query.Where("admin_id = ?", *filter.AdminID)
+	}
+	if filter.TargetUserID != nil {
+		query = query.Where("target_user_id = ?", *filter.TargetUserID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (s *ImpersonationService) requireAdmin(ctx context.Context, adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.WithContext(ctx).Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrImpersonationAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrImpersonationAdminOnly
+	}
+	return nil
+}
+
+func impersonationAuditEntry(session *models.ImpersonationSession, event models.ImpersonationEvent, method, path string, status int) *models.ImpersonationAuditLog {
+	return &models.ImpersonationAuditLog{
+		SessionID:    session.ID,
+		AdminID:      session.AdminID,
+		TargetUserID: session.TargetUserID,
+		Event:        event,
+		Method:       method,
+		Path:         path,
+		Status:       status,
+	}
+}
+
+func hashImpersonationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,758 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+)
+
+// defaultDownloadURLExpiry is how long presigned document download URLs stay valid
+const defaultDownloadURLExpiry = 15 * time.Minute
+
+// defaultImportCategory is the category of knowledge base imports that do not name one
+const defaultImportCategory = "Documents"
+
+// IngestionService is the document ingestion pipeline. Every document is stored, scanned for prompt
+// injection and recorded as an UploadedDocument, then driven by background jobs to its target:
+//
+//	uploaded -> quarantined -> uploaded (approved) | rejected
+//	uploaded -> sent_to_openai -> added_to_vector -> indexed | processing_failed  (vector store)
+//	uploaded -> imported | processing_failed                                      (knowledge base)
+//	imported -> superseded                     (a newer version of the file took over its entries)
+//
+// Web pages skip the file stages: a WebSource is crawled straight into knowledge entries and re-crawled
+// on its schedule.
+type IngestionService struct {
+	db                *gorm.DB
+	openaiAPIKey      string
+	vectorStoreID     string
+	storage           FileStorage
+	jobQueue          *JobQueue
+	downloadURLExpiry time.Duration
+	notifier          Notifier
+	webhook           *DocumentWebhook
+	knowledge         *KnowledgeService
+	aiService         *UnifiedAIService
+	enricher          *MetadataEnricher
+	settings          *SettingsService
+	rowsPerChunk      int
+	crawlClient       *http.Client
+	crawlMaxPages     int
+}
+
+type DocumentUploadRequest struct {
+	FileName string                 `json:"file_name" validate:"required"`
+	Target   models.IngestionTarget `json:"target"`   // Defaults to the knowledge base for spreadsheets and the vector store otherwise
+	Category string                 `json:"category"` // Category of the entries of a knowledge base import
+}
+
+type DocumentUploadResponse struct {
+	ID               uuid.UUID `json:"id"`
+	FileName         string    `json:"file_name"`
+	Status           string    `json:"status"`
+	OpenAIFileID     string    `json:"openai_file_id,omitempty"`
+	VectorFileID     string    `json:"vector_file_id,omitempty"`
+	Message          string    `json:"message"`
+}
+
+type OpenAIFileUploadResponse struct {
+	ID       string `json:"id"`
+	Object   string `json:"object"`
+	Bytes    int    `json:"bytes"`
+	Filename string `json:"filename"`
+	Purpose  string `json:"purpose"`
+}
+
+type VectorStoreFileResponse struct {
+	ID            string `json:"id"`
+	Object        string `json:"object"`
+	VectorStoreID string `json:"vector_store_id"`
+	Status        string `json:"status"`
+}
+
+// ingestPayload is the job payload for moving a document to the next state of the pipeline
+type ingestPayload struct {
+	DocumentID uuid.UUID `json:"document_id"`
+}
+
+// IngestRequest is a file entering the ingestion pipeline
+type IngestRequest struct {
+	FileName         string // Sanitized and used as the stored name
+	OriginalFileName string
+	MimeType         string
+	Content          []byte
+	UploadedBy       uuid.UUID
+	Target           models.IngestionTarget
+	Category         string // Category of the entries of a knowledge base import
+}
+
+// NewIngestionService creates the document ingestion pipeline. Ingested files are kept in storage.
+func NewIngestionService(db *gorm.DB, openaiAPIKey, vectorStoreID string, storage FileStorage, jobQueue *JobQueue) *IngestionService {
+	return &IngestionService{
+		db:                db,
+		openaiAPIKey:      openaiAPIKey,
+		vectorStoreID:     vectorStoreID,
+		storage:           storage,
+		jobQueue:          jobQueue,
+		downloadURLExpiry: defaultDownloadURLExpiry,
+		rowsPerChunk:      defaultRowsPerChunk,
+		crawlClient:       &http.Client{Timeout: 30 * time.Second},
+		crawlMaxPages:     defaultCrawlMaxPages,
+	}
+}
+
+// SetKnowledgeBase enables knowledge base imports. The AI service, when set, titles imported documents.
+func (s *IngestionService) SetKnowledgeBase(knowledge *KnowledgeService, aiService *UnifiedAIService) {
+	s.knowledge = knowledge
+	s.aiService = aiService
+}
+
+// SetSettings makes imports split documents with the section options of the import_sections setting
+func (s *IngestionService) SetSettings(settings *SettingsService) {
+	s.settings = settings
+}
+
+// SetRowsPerChunk sets how many table rows of an imported spreadsheet go into one knowledge entry
+func (s *IngestionService) SetRowsPerChunk(rows int) {
+	if rows > 0 {
+		s.rowsPerChunk = rows
+	}
+}
+
+// SetDownloadURLExpiry sets how long presigned document download URLs stay valid
+func (s *IngestionService) SetDownloadURLExpiry(expiry time.Duration) {
+	if expiry > 0 {
+		s.downloadURLExpiry = expiry
+	}
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *IngestionService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeDocumentIngest, s.handleIngestJob)
+	queue.Register(JobTypeOpenAIUpload, s.handleIngestJob)
+	queue.Register(JobTypeDocumentProcess, s.handleLegacyDocumentProcessJob)
+	queue.Register(JobTypeVectorStatusPoll, s.handleVectorStatusPollJob)
+	queue.Register(JobTypeWebCrawl, s.handleWebCrawlJob)
+}
+
+// UploadDocument ingests an uploaded file
+func (s *IngestionService) UploadDocument(ctx context.Context, req DocumentUploadRequest, fileContent []byte, originalFileName string, mimeType string, uploadedBy uuid.UUID) (*DocumentUploadResponse, error) {
+	document, err := s.Ingest(ctx, IngestRequest{
+		FileName:         req.FileName,
+		OriginalFileName: originalFileName,
+		MimeType:         mimeType,
+		Content:          fileContent,
+		UploadedBy:       uploadedBy,
+		Target:           req.Target,
+		Category:         req.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Status == models.DocumentQuarantined {
+		return &DocumentUploadResponse{
+			ID:       document.ID,
+			FileName: document.FileName,
+			Status:   string(document.Status),
+			Message:  "Document quarantined: it contains instruction-like content and needs admin approval before it is used",
+		}, nil
+	}
+	return &DocumentUploadResponse{
+		ID:       document.ID,
+		FileName: document.FileName,
+		Status:   string(document.Status),
+		Message:  "Document uploaded successfully",
+	}, nil
+}
+
+// Ingest stores a file, records it and schedules its processing. Files that look like they carry
+// instructions for the assistant are quarantined for admin review instead.
+func (s *IngestionService) Ingest(ctx context.Context, req IngestRequest) (*models.UploadedDocument, error) {
+	document, err := s.store(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if document.Status == models.DocumentQuarantined {
+		return document, nil
+	}
+	if err := s.scheduleIngest(ctx, document.ID); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// store saves the file under the document ID, so uploads with the same name do not collide, and
+// creates its record
+func (s *IngestionService) store(ctx context.Context, req IngestRequest) (*models.UploadedDocument, error) {
+	fileName := utils.SanitizeFileName(req.FileName)
+	if fileName == "" {
+		return nil, validationError("invalid file name")
+	}
+	if req.OriginalFileName == "" {
+		req.OriginalFileName = fileName
+	}
+	ext := filepath.Ext(req.OriginalFileName)
+	if ext == "" {
+		ext = filepath.Ext(fileName)
+	}
+
+	switch req.Target {
+	case "":
+		// The vector store does not index spreadsheets, they are imported row by row instead
+		req.Target = models.IngestVectorStore
+		if _, ok := lookupTableExtractor(ext); ok && s.knowledge != nil {
+			req.Target = models.IngestKnowledgeBase
+		}
+	case models.IngestVectorStore, models.IngestKnowledgeBase:
+	default:
+		return nil, validationError("unknown ingestion target %q", req.Target)
+	}
+	if req.Target == models.IngestKnowledgeBase {
+		if s.knowledge == nil {
+			return nil, validationError("knowledge base imports are not enabled")
+		}
+		if req.Category == "" {
+			req.Category = defaultImportCategory
+		}
+	}
+
+	documentID := uuid.New()
+	storageKey := path.Join("documents", documentID.String(), fileName)
+	if err := s.storage.Put(ctx, storageKey, req.Content, req.MimeType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	findings := scanContentForPromptInjection(req.Content, "file"+ext)
+
+	document := &models.UploadedDocument{
+		ID:               documentID,
+		FileName:         fileName,
+		OriginalFileName: req.OriginalFileName,
+		FilePath:         s.storage.Location(storageKey),
+		StorageKey:       storageKey,
+		FileSize:         int64(len(req.Content)),
+		MimeType:         req.MimeType,
+		Checksum:         ContentChecksum(req.Content),
+		Target:           req.Target,
+		Category:         req.Category,
+		Status:           models.DocumentUploaded,
+		UploadedBy:       req.UploadedBy,
+	}
+	if req.Target == models.IngestVectorStore {
+		document.VectorStoreID = s.vectorStoreID
+	}
+
+	var quarantine *models.DocumentQuarantine
+	if len(findings) > 0 {
+		document.Status = models.DocumentQuarantined
+		quarantine = newDocumentQuarantine(models.QuarantineUpload, req.OriginalFileName, findings)
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(document).Error; err != nil {
+			return err
+		}
+		if quarantine == nil {
+			return nil
+		}
+		quarantine.UploadedDocumentID = &document.ID
+		return tx.Create(quarantine).Error
+	})
+	if err != nil {
+		// Clean up file if database insert fails
+		if err := s.storage.Delete(ctx, storageKey); err != nil {
+			log.Printf("[WARNING] Failed to remove stored file %s: %v", storageKey, err)
+		}
+		return nil, fmt.Errorf("failed to create document record: %w", err)
+	}
+
+	if quarantine != nil {
+		log.Printf("[WARNING] Quarantined document %s (%s): %d suspicious instruction pattern(s), quarantine %s",
+			document.ID, req.OriginalFileName, len(findings), quarantine.ID)
+	}
+	return document, nil
+}
+
+// ContentChecksum returns the SHA-256 of a file's content as recorded on its UploadedDocument
+func ContentChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// IngestedDocument returns the document a file with the checksum was already ingested as into target, or nil.
+// Documents that failed, were rejected or were superseded by a newer version do not count, so their files
+// can be ingested again.
+func (s *IngestionService) IngestedDocument(ctx context.Context, checksum string, target models.IngestionTarget) (*models.UploadedDocument, error) {
+	var document models.UploadedDocument
+	err := s.db.WithContext(ctx).
+		Where("checksum = ? AND target = ? AND status NOT IN ?", checksum, target,
+			[]models.DocumentStatus{models.DocumentProcessingFailed, models.DocumentRejected, models.DocumentSuperseded}).
+		Order("created_at").First(&document).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// scheduleIngest queues the next step of a document's processing
+func (s *IngestionService) scheduleIngest(ctx context.Context, documentID uuid.UUID) error {
+	if _, err := s.jobQueue.Enqueue(ctx, DocumentQueue, JobTypeDocumentIngest, ingestPayload{DocumentID: documentID}, nil); err != nil {
+		s.updateDocumentStatus(documentID, models.DocumentProcessingFailed, "", "", err.Error())
+		return fmt.Errorf("failed to schedule document processing: %w", err)
+	}
+	return nil
+}
+
+// handleIngestJob processes a document as a background job
+func (s *IngestionService) handleIngestJob(ctx context.Context, job *models.Job) error {
+	var payload ingestPayload
+	if err := DecodeJobPayload(job, &payload); err != nil {
+		return err
+	}
+
+	var document models.UploadedDocument
+	if err := s.db.Preload("Uploader").First(&document, "id = ?", payload.DocumentID).Error; err != nil {
+		return fmt.Errorf("document not found: %w", err)
+	}
+
+	return s.advance(ctx, &document)
+}
+
+// advance moves a document on from its current state towards its target
+func (s *IngestionService) advance(ctx context.Context, document *models.UploadedDocument) error {
+	switch document.Status {
+	case models.DocumentUploaded, models.DocumentSentToOpenAI, models.DocumentProcessingFailed:
+	default:
+		return nil // Quarantined, already processed or waiting for the vector store
+	}
+
+	if document.Target == models.IngestKnowledgeBase {
+		_, err := s.importToKnowledgeBase(ctx, document)
+		return err
+	}
+	return s.processOpenAIUpload(ctx, document)
+}
+
+// processOpenAIUpload uploads the document to OpenAI and attaches it to the vector store.
+// Steps that already succeeded on a previous attempt are skipped.
+func (s *IngestionService) processOpenAIUpload(ctx context.Context, document *models.UploadedDocument) error {
+	// Step 1: Upload to OpenAI Files API
+	openaiFileID := document.OpenAIFileID
+	if openaiFileID == "" {
+		var err error
+		openaiFileID, err = s.uploadToOpenAI(ctx, document)
+		if err != nil {
+			s.updateDocumentStatus(document.ID, models.DocumentProcessingFailed, "", "", err.Error())
+			return err
+		}
+
+		// Update document with OpenAI file ID
+		s.updateDocumentStatus(document.ID, models.DocumentSentToOpenAI, openaiFileID, "", "")
+	}
+
+	// Step 2: Add to Vector Store
+	vectorFileID, err := s.addToVectorStore(openaiFileID)
+	if err != nil {
+		s.updateDocumentStatus(document.ID, models.DocumentProcessingFailed, openaiFileID, "", err.Error())
+		return err
+	}
+
+	s.updateDocumentStatus(document.ID, models.DocumentAddedToVector, openaiFileID, vectorFileID, "")
+
+	// Step 3: OpenAI processes the file asynchronously, poll until it is indexed or fails
+	err = s.scheduleVectorStatusPoll(ctx, vectorStatusPayload{
+		DocumentID:   document.ID,
+		VectorFileID: vectorFileID,
+		Deadline:     time.Now().Add(vectorStatusTimeout),
+	})
+	if err != nil {
+		log.Printf("[WARNING] Failed to schedule vector store status polling for document %s: %v", document.ID, err)
+	}
+	return nil
+}
+
+func (s *IngestionService) uploadToOpenAI(ctx context.Context, document *models.UploadedDocument) (string, error) {
+	content, err := s.readDocumentFile(ctx, document)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Create multipart form
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+
+	// Add purpose field
+	if err := writer.WriteField("purpose", "assistants"); err != nil {
+		return "", fmt.Errorf("failed to write purpose field: %w", err)
+	}
+
+	// Add file field
+	part, err := writer.CreateFormFile("file", document.FileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+
+	if _, err := part.Write(content); err != nil {
+		return "", fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequest("POST", "https://api.openai.com/v1/files", &b)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.openaiAPIKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	// Send request
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("OpenAI API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	// Parse response
+	var uploadResp OpenAIFileUploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return uploadResp.ID, nil
+}
+
+func (s *IngestionService) addToVectorStore(fileID string) (string, error) {
+	requestBody := map[string]string{
+		"file_id": fileID,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("https://api.openai.com/v1/vector_stores/%s/files", s.vectorStoreID)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.openaiAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("OpenAI-Beta", "assistants=v2")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Vector Store API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var vectorResp VectorStoreFileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vectorResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return vectorResp.ID, nil
+}
+
+// ReleaseQuarantined sends an approved quarantined document on to its target
+func (s *IngestionService) ReleaseQuarantined(ctx context.Context, documentID uuid.UUID) error {
+	result := s.db.Model(&models.UploadedDocument{}).
+		Where("id = ? AND status = ?", documentID, models.DocumentQuarantined).
+		Updates(map[string]interface{}{"status": models.DocumentUploaded, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "quarantined document "+documentID.String())
+	}
+	s.syncContextFile(documentID, models.DocumentUploaded)
+
+	return s.scheduleIngest(ctx, documentID)
+}
+
+// RejectQuarantined marks a quarantined document as rejected and removes its stored file
+func (s *IngestionService) RejectQuarantined(ctx context.Context, documentID uuid.UUID) error {
+	var document models.UploadedDocument
+	if err := s.db.First(&document, "id = ? AND status = ?", documentID, models.DocumentQuarantined).Error; err != nil {
+		return notFound(err, "quarantined document "+documentID.String())
+	}
+	s.updateDocumentStatus(documentID, models.DocumentRejected, "", "", "")
+	if err := s.removeDocumentFile(ctx, &document); err != nil {
+		log.Printf("[WARNING] Failed to remove rejected document file %s: %v", document.FilePath, err)
+	}
+	return nil
+}
+
+// DeleteDocument removes a document from the OpenAI vector store and file storage, deletes the
+// knowledge entries it was imported into, then deletes its record. Remote resources and entries
+// that are already gone count as deleted.
+func (s *IngestionService) DeleteDocument(ctx context.Context, documentID uuid.UUID) error {
+	var document models.UploadedDocument
+	if err := s.db.First(&document, "id = ?", documentID).Error; err != nil {
+		return notFound(err, "document "+documentID.String())
+	}
+
+	if err := s.deleteRemoteFile(ctx, &document); err != nil {
+		return err
+	}
+	if err := s.deleteImportedEntries(&document); err != nil {
+		return err
+	}
+	if err := s.removeDocumentFile(ctx, &document); err != nil {
+		return fmt.Errorf("failed to remove stored file: %w", err)
+	}
+	if err := s.db.Delete(&document).Error; err != nil {
+		return err
+	}
+	log.Printf("[INFO] Deleted uploaded document %s (%s)", document.ID, document.FileName)
+	return nil
+}
+
+// ResyncDocument uploads a document to OpenAI again and re-attaches it to the vector store,
+// replacing any remote file left by an earlier attempt
+func (s *IngestionService) ResyncDocument(ctx context.Context, documentID uuid.UUID) (*models.UploadedDocument, error) {
+	var document models.UploadedDocument
+	if err := s.db.First(&document, "id = ?", documentID).Error; err != nil {
+		return nil, notFound(err, "document "+documentID.String())
+	}
+	if document.Status == models.DocumentQuarantined || document.Status == models.DocumentRejected {
+		return nil, validationError("document is %s, it must be approved in the quarantine first", document.Status)
+	}
+	if document.Target != models.IngestVectorStore {
+		return nil, validationError("only vector store documents can be resynced")
+	}
+
+	if err := s.deleteRemoteFile(ctx, &document); err != nil {
+		return nil, err
+	}
+	err := s.db.Model(&document).Updates(map[string]interface{}{
+		"status":         models.DocumentUploaded,
+		"openai_file_id": "",
+		"vector_file_id": "",
+		"error_message":  "",
+		"updated_at":     time.Now(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	document.Status = models.DocumentUploaded
+	document.OpenAIFileID = ""
+	document.VectorFileID = ""
+	document.ErrorMessage = ""
+
+	if err := s.scheduleIngest(ctx, document.ID); err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Resyncing uploaded document %s (%s) with the vector store", document.ID, document.FileName)
+	return &document, nil
+}
+
+// deleteRemoteFile detaches a document from the vector store and deletes its OpenAI file
+func (s *IngestionService) deleteRemoteFile(ctx context.Context, document *models.UploadedDocument) error {
+	if document.OpenAIFileID == "" {
+		return nil
+	}
+	if document.VectorFileID != "" && document.VectorStoreID != "" {
+		vectorFile := fmt.Sprintf("/vector_stores/%s/files/%s", document.VectorStoreID, document.VectorFileID)
+		if err := s.deleteFromOpenAI(ctx, vectorFile); err != nil {
+			return fmt.Errorf("failed to remove file from vector store: %w", err)
+		}
+	}
+	if err := s.deleteFromOpenAI(ctx, "/files/"+document.OpenAIFileID); err != nil {
+		return fmt.Errorf("failed to delete OpenAI file: %w", err)
+	}
+	return nil
+}
+
+// deleteFromOpenAI deletes an OpenAI resource. A resource that does not exist is not an error.
+func (s *IngestionService) deleteFromOpenAI(ctx context.Context, resource string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, openAIAPIBaseURL+resource, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.openaiAPIKey)
+	req.Header.Set("OpenAI-Beta", "assistants=v2")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("OpenAI API error: %d - %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// DownloadURL returns a presigned URL downloading the file of a document and when it expires.
+// It returns ErrPresignUnsupported when the storage backend cannot serve files directly.
+func (s *IngestionService) DownloadURL(ctx context.Context, document *models.UploadedDocument) (string, time.Time, error) {
+	if document.StorageKey == "" {
+		return "", time.Time{}, ErrPresignUnsupported // Stored on local disk before storage backends existed
+	}
+	expiresAt := time.Now().Add(s.downloadURLExpiry)
+	downloadURL, err := s.storage.PresignGet(ctx, document.StorageKey, s.downloadURLExpiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return downloadURL, expiresAt, nil
+}
+
+// readDocumentFile reads the content of a document from storage. Documents uploaded before storage
+// backends existed have no storage key and are read from their local path.
+func (s *IngestionService) readDocumentFile(ctx context.Context, document *models.UploadedDocument) ([]byte, error) {
+	if document.StorageKey == "" {
+		return os.ReadFile(document.FilePath)
+	}
+	return s.storage.Get(ctx, document.StorageKey)
+}
+
+func (s *IngestionService) removeDocumentFile(ctx context.Context, document *models.UploadedDocument) error {
+	if document.StorageKey == "" {
+		if err := os.Remove(document.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return s.storage.Delete(ctx, document.StorageKey)
+}
+
+func (s *IngestionService) updateDocumentStatus(documentID uuid.UUID, status models.DocumentStatus, openaiFileID, vectorFileID, errorMessage string) {
+	updates := map[string]interface{}{
+		"status":      status,
+		"updated_at":  time.Now(),
+	}
+
+	if openaiFileID != "" {
+		updates["openai_file_id"] = openaiFileID
+	}
+	if vectorFileID != "" {
+		updates["vector_file_id"] = vectorFileID
+	}
+	if errorMessage != "" {
+		updates["error_message"] = errorMessage
+	}
+
+	s.db.Model(&models.UploadedDocument{}).Where("id = ?", documentID).Updates(updates)
+	s.syncContextFile(documentID, status)
+}
+
+func (s *IngestionService) GetDocumentStatus(ctx context.Context, documentID uuid.UUID) (*models.UploadedDocument, error) {
+	var document models.UploadedDocument
+	if err := s.db.Preload("Uploader").First(&document, documentID).Error; err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	return &document, nil
+}
+
+// DocumentQuery filters, sorts and pages the uploaded documents
+type DocumentQuery struct {
+	UploadedBy *uuid.UUID
+	Status     models.DocumentStatus
+	Target     models.IngestionTarget
+	Category   string
+	FileName   string     // Substring of the original or stored file name, case-insensitive
+	Since      *time.Time // Uploaded at or after
+	Until      *time.Time // Uploaded before
+	Sort       string     // One of DocumentSortFields; created_at by default
+	Desc       bool
+	Limit      int
+	Offset     int
+}
+
+// DocumentSortFields are the fields documents can be sorted by
+var DocumentSortFields = []string{"created_at", "updated_at", "file_name", "file_size", "status"}
+
+// ListDocuments returns a page of the documents matching a query with the total number of them
+func (s *IngestionService) ListDocuments(ctx context.Context, q DocumentQuery) ([]models.UploadedDocument, int64, error) {
+	sort := q.Sort
+	if sort == "" {
+		sort = "created_at"
+	}
+	if !utils.SliceContains(DocumentSortFields, sort) {
+		return nil, 0, validationError("cannot sort documents by %q, expected one of %s", sort, strings.Join(DocumentSortFields, ", "))
+	}
+	if sort == "file_name" {
+		sort = "lower(original_file_name)"
+	}
+	order := sort + " ASC"
+	if q.Desc {
+		order = sort + " DESC"
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.UploadedDocument{})
+	if q.UploadedBy != nil {
+		query = query.Where("uploaded_by = ?", *q.UploadedBy)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Target != "" {
+		query = query.Where("target = ?", q.Target)
+	}
+	if q.Category != "" {
+		query = query.Where("category = ?", q.Category)
+	}
+	if name := strings.TrimSpace(q.FileName); name != "" {
+		pattern := utils.LikePattern(name)
+		query = query.Where("(original_file_name ILIKE ? OR file_name ILIKE ?)", pattern, pattern)
+	}
+	if q.Since != nil {
+		query = query.Where("created_at >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		query = query.Where("created_at < ?", *q.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	var documents []models.UploadedDocument
+	if err := query.Preload("Uploader").Limit(q.Limit).Offset(q.Offset).Order(order + ", id").Find(&documents).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list documents: %w", err)
+	}
+	return documents, total, nil
+}
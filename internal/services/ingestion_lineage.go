@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// versionSimilarityThreshold is the cosine similarity above which a section of a new version of a document
+// is taken to be an edited section of the earlier version, when neither its content nor its title match
+const versionSimilarityThreshold = 0.9
+
+// sectionHash identifies the content of a section regardless of its whitespace
+func sectionHash(content string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(content), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// previousVersion returns the latest imported document with the file name and category of a knowledge base
+// import, which the import is a new version of, with its entries. It returns nil for the first version.
+func (s *IngestionService) previousVersion(ctx context.Context, document *models.UploadedDocument) (*models.UploadedDocument, []*models.KnowledgeEntry, error) {
+	var previous models.UploadedDocument
+	err := s.db.WithContext(ctx).
+		Where("id <> ? AND target = ? AND status = ? AND lower(original_file_name) = lower(?) AND category = ? AND created_at <= ?",
+			document.ID, models.IngestKnowledgeBase, models.DocumentImported, document.OriginalFileName, document.Category, document.CreatedAt).
+		Order("created_at DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Entries imported before lineage was recorded are only listed on the document
+	ids, err := decodeEntryIDs(previous.KnowledgeEntryIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var entries []models.KnowledgeEntry
+	query := s.db.WithContext(ctx).Where("source_document_id = ?", previous.ID)
+	if len(ids) > 0 {
+		query = query.Or("id IN ?", ids)
+	}
+	if err := query.Order("created_at").Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+	pointers := make([]*models.KnowledgeEntry, len(entries))
+	for i := range entries {
+		pointers[i] = &entries[i]
+	}
+	return &previous, pointers, nil
+}
+
+// matchVersions pairs the sections of a new version of a document with the entries of the earlier version:
+// first by identical content, then by title, then by the similarity of their embeddings. It returns the
+// entry each section takes over, or nil for an added section, and the entries no section took over.
+func (s *IngestionService) matchVersions(ctx context.Context, sections []ProposedEntry, previous []*models.KnowledgeEntry) ([]*models.KnowledgeEntry, []*models.KnowledgeEntry) {
+	matches := make([]*models.KnowledgeEntry, len(sections))
+	taken := make(map[uuid.UUID]bool, len(previous))
+	pair := func(key func(title, content string) string) {
+		byKey := map[string][]*models.KnowledgeEntry{}
+		for _, entry := range previous {
+			if !taken[entry.ID] {
+				k := key(entry.Title, entry.Content)
+				byKey[k] = append(byKey[k], entry)
+			}
+		}
+		for i, section := range sections {
+			if matches[i] != nil {
+				continue
+			}
+			k := key(section.Title, section.Content)
+			if candidates := byKey[k]; len(candidates) > 0 {
+				matches[i] = candidates[0]
+				byKey[k] = candidates[1:]
+				taken[candidates[0].ID] = true
+			}
+		}
+	}
+	pair(func(_, content string) string { return sectionHash(content) })
+	pair(func(title, _ string) string { return strings.ToLower(strings.TrimSpace(title)) })
+	s.pairSimilar(ctx, sections, previous, matches, taken)
+
+	var remaining []*models.KnowledgeEntry
+	for _, entry := range previous {
+		if !taken[entry.ID] {
+			remaining = append(remaining, entry)
+		}
+	}
+	return matches, remaining
+}
+
+// pairSimilar pairs the sections and entries left unmatched by content and title whose embeddings are most
+// alike, above versionSimilarityThreshold. Only those are embedded; without an embedder nothing is paired.
+func (s *IngestionService) pairSimilar(ctx context.Context, sections []ProposedEntry, previous []*models.KnowledgeEntry, matches []*models.KnowledgeEntry, taken map[uuid.UUID]bool) {
+	embedder := s.knowledge.embedder
+	if embedder == nil {
+		return
+	}
+	var open []int
+	for i := range sections {
+		if matches[i] == nil {
+			open = append(open, i)
+		}
+	}
+	var left []*models.KnowledgeEntry
+	for _, entry := range previous {
+		if !taken[entry.ID] {
+			left = append(left, entry)
+		}
+	}
+	if len(open) == 0 || len(left) == 0 {
+		return
+	}
+
+	leftEmbeddings := make([][]float32, len(left))
+	for j, entry := range left {
+		embedding, err := embedder.CreateEmbedding(ctx, entry.Content)
+		if err != nil {
+			log.Printf("[WARNING] Failed to embed entry %s to compare versions: %v", entry.ID, err)
+			continue
+		}
+		leftEmbeddings[j] = embedding
+	}
+	for _, i := range open {
+		embedding, err := embedder.CreateEmbedding(ctx, sections[i].Content)
+		if err != nil {
+			log.Printf("[WARNING] Failed to embed section %q to compare versions: %v", sections[i].Title, err)
+			continue
+		}
+		best, bestScore := -1, versionSimilarityThreshold
+		for j, entry := range left {
+			if taken[entry.ID] || leftEmbeddings[j] == nil {
+				continue
+			}
+			if score := cosineSimilarity(embedding, leftEmbeddings[j]); score >= bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best >= 0 {
+			matches[i] = left[best]
+			taken[left[best].ID] = true
+		}
+	}
+}
+
+// updateImportedEntry makes an entry of the earlier version of a document the entry of a section of its new
+// version. Only a changed title or content is written, and embedded again; the category, tags and
+// publication of the entry, which editors may have changed, are kept. It reports whether the entry changed.
+func (s *IngestionService) updateImportedEntry(ctx context.Context, entry *models.KnowledgeEntry, document *models.UploadedDocument, proposed ProposedEntry, sectionOptions string) (bool, error) {
+	entry.SourceDocumentID = &document.ID
+	entry.SectionOptions = sectionOptions
+	if entry.Title == proposed.Title && sectionHash(entry.Content) == sectionHash(proposed.Content) {
+		return false, s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("id = ?", entry.ID).UpdateColumns(map[string]interface{}{
+			"source_document_id": document.ID,
+			"section_options":    sectionOptions,
+		}).Error
+	}
+	entry.Title = proposed.Title
+	entry.Content = proposed.Content
+	if proposed.Summary != "" {
+		entry.Summary = proposed.Summary
+	}
+	entry.UpdatedBy = &document.UploadedBy
+	return true, s.knowledge.UpdateKnowledgeEntry(ctx, entry)
+}
+
+// supersede marks the earlier version of a document replaced by its new version, which took over its entries
+func (s *IngestionService) supersede(previous, document *models.UploadedDocument) {
+	err := s.db.Model(&models.UploadedDocument{}).Where("id = ?", previous.ID).Updates(map[string]interface{}{
+		"status":              models.DocumentSuperseded,
+		"knowledge_entry_ids": "",
+		"updated_at":          time.Now(),
+	}).Error
+	if err != nil {
+		log.Printf("[ERROR] Failed to mark document %s superseded by %s: %v", previous.ID, document.ID, err)
+	}
+}
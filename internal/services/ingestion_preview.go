@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ProposedEntry is a knowledge entry an import would create from a section of a document
+type ProposedEntry struct {
+	Title             string `json:"title"`
+	Content           string `json:"content"`
+	Category          string `json:"category"`
+	Tags              string `json:"tags"` // Comma-separated
+	Summary           string `json:"summary,omitempty"`
+	SuggestedCategory string `json:"suggested_category,omitempty"` // The AI's category, when it differs from Category
+	WordCount         int    `json:"word_count"`
+}
+
+// DocumentPreview is the parse of a file on the server's disk as an import would save it. Editors may fix
+// the entries, e.g. merge a bad split, before confirming it.
+type DocumentPreview struct {
+	FilePath          string                 `json:"file_path"`
+	Checksum          string                 `json:"checksum"` // Of the file when it was previewed
+	Title             string                 `json:"title"`
+	Category          string                 `json:"category"`
+	Entries           []ProposedEntry        `json:"entries"`
+	InjectionFindings []InjectionFinding     `json:"injection_findings,omitempty"` // The import is quarantined if any
+	Metadata          map[string]interface{} `json:"metadata"`
+	SectionOptions    *SectionOptions        `json:"section_options,omitempty"` // Recorded on the entries when confirmed
+}
+
+// PreviewFile parses a file on the server's disk into the entries ImportFile would create under category,
+// without saving anything
+func (s *IngestionService) PreviewFile(ctx context.Context, filePath, category string) (*DocumentPreview, error) {
+	if s.knowledge == nil {
+		return nil, validationError("knowledge base imports are not enabled")
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if category == "" {
+		category = defaultImportCategory
+	}
+	result, err := s.parse(ctx, filepath.Base(filePath), data, category)
+	if err != nil {
+		return nil, err
+	}
+	entries := proposeEntries(result, category)
+	s.enrichEntries(ctx, entries)
+	return &DocumentPreview{
+		FilePath:          filePath,
+		Checksum:          ContentChecksum(data),
+		Title:             result.Title,
+		Category:          category,
+		Entries:           entries,
+		InjectionFindings: scanContentForPromptInjection(data, "file"+filepath.Ext(filePath)),
+		Metadata:          result.Metadata,
+		SectionOptions:    result.SectionOptions,
+	}, nil
+}
+
+// ConfirmPreview imports the file of a preview into the knowledge base as the preview's entries, edited
+// or not. The file must not have changed since it was previewed. A document quarantined by the prompt
+// injection scanner is returned without a result, like with ImportFile.
+func (s *IngestionService) ConfirmPreview(ctx context.Context, preview *DocumentPreview, uploadedBy uuid.UUID) (*models.UploadedDocument, *DocumentParseResult, error) {
+	if s.knowledge == nil {
+		return nil, nil, validationError("knowledge base imports are not enabled")
+	}
+	if preview == nil || preview.FilePath == "" {
+		return nil, nil, validationError("preview with file_path is required")
+	}
+	if len(preview.Entries) == 0 {
+		return nil, nil, validationError("preview has no entries")
+	}
+	category := strings.TrimSpace(preview.Category)
+	if category == "" {
+		category = defaultImportCategory
+	}
+	entries := make([]ProposedEntry, len(preview.Entries))
+	for i, entry := range preview.Entries {
+		entry.Title, entry.Content = strings.TrimSpace(entry.Title), strings.TrimSpace(entry.Content)
+		if entry.Title == "" || entry.Content == "" {
+			return nil, nil, validationError("entry %d needs a title and content", i+1)
+		}
+		if entry.Category = strings.TrimSpace(entry.Category); entry.Category == "" {
+			entry.Category = category
+		}
+		entry.WordCount = len(strings.Fields(entry.Content))
+		entries[i] = entry
+	}
+
+	data, err := os.ReadFile(preview.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if ContentChecksum(data) != preview.Checksum {
+		return nil, nil, validationError("%s changed since it was previewed, preview it again", filepath.Base(preview.FilePath))
+	}
+	if err := s.ensureUploader(uploadedBy); err != nil {
+		return nil, nil, err
+	}
+
+	document, err := s.store(ctx, fileImportRequest(preview.FilePath, data, category, uploadedBy))
+	if err != nil {
+		return nil, nil, err
+	}
+	if document.Status == models.DocumentQuarantined {
+		return document, nil, nil
+	}
+	entryIDs, err := s.saveImportedEntries(ctx, document, entries, preview.SectionOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &DocumentParseResult{
+		FilePath:       preview.FilePath,
+		Title:          preview.Title,
+		TotalChunks:    len(entries),
+		ProcessedAt:    time.Now(),
+		Metadata:       preview.Metadata,
+		SectionOptions: preview.SectionOptions,
+	}
+	for i, entry := range entries {
+		result.Sections = append(result.Sections, DocumentSection{Title: entry.Title, Content: entry.Content, Order: i + 1, WordCount: entry.WordCount})
+		result.KnowledgeIDs = append(result.KnowledgeIDs, entryIDs[i].String())
+	}
+	s.announceProcessed(ctx, document)
+	return document, result, nil
+}
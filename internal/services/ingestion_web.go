@@ -0,0 +1,535 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeWebCrawl crawls a web source into knowledge entries
+const JobTypeWebCrawl = "web_crawl"
+
+// Web crawl triggers
+const (
+	WebCrawlTriggerManual    = "manual"
+	WebCrawlTriggerScheduled = "scheduled"
+)
+
+const (
+	defaultCrawlMaxPages = 100
+	webCrawlMaxBytes     = 5 << 20
+	webCrawlUserAgent    = "tic-knowledge-crawler/1.0"
+	// Sitemap indexes may list further sitemaps; deeper nesting is ignored
+	sitemapMaxDepth = 2
+)
+
+var (
+	htmlMainContent = regexp.MustCompile(`(?is)<(main|article)\b[^>]*>(.*)</(main|article)\s*>`)
+	// Page chrome repeated across a site. Go regexps have no backreferences, so each element gets its own pattern.
+	htmlBoilerplate = []*regexp.Regexp{
+		regexp.MustCompile(`(?is)<nav\b[^>]*>.*?</nav\s*>`),
+		regexp.MustCompile(`(?is)<header\b[^>]*>.*?</header\s*>`),
+		regexp.MustCompile(`(?is)<footer\b[^>]*>.*?</footer\s*>`),
+		regexp.MustCompile(`(?is)<aside\b[^>]*>.*?</aside\s*>`),
+		regexp.MustCompile(`(?is)<form\b[^>]*>.*?</form\s*>`),
+		regexp.MustCompile(`(?is)<iframe\b[^>]*>.*?</iframe\s*>`),
+	}
+)
+
+// WebIngestRequest is a web page or sitemap to crawl into the knowledge base
+type WebIngestRequest struct {
+	URL          string
+	Sitemap      bool // URL is a sitemap; its pages on the same host are crawled
+	Category     string
+	RecrawlHours int // Re-crawl this often to keep the entries fresh; 0 crawls once
+	CreatedBy    uuid.UUID
+}
+
+// webCrawlPayload is the job payload for crawling a web source
+type webCrawlPayload struct {
+	SourceID uuid.UUID `json:"source_id"`
+	Trigger  string    `json:"trigger"`
+}
+
+// webPage is the state of a crawled page, kept in WebSource.Pages to skip unchanged pages on a re-crawl
+type webPage struct {
+	URL       string      `json:"url"`
+	Title     string      `json:"title"`
+	Hash      string      `json:"hash,omitempty"` // SHA-256 of the extracted text
+	EntryIDs  []uuid.UUID `json:"entry_ids"`
+	Error     string      `json:"error,omitempty"` // Why the last crawl failed; the entries of the crawl before are kept
+	CrawledAt time.Time   `json:"crawled_at"`
+}
+
+// sitemapDocument is a sitemap or sitemap index
+type sitemapDocument struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// SetCrawlMaxPages sets how many pages of a sitemap one crawl visits
+func (s *IngestionService) SetCrawlMaxPages(pages int) {
+	if pages > 0 {
+		s.crawlMaxPages = pages
+	}
+}
+
+// IngestURL registers a web page or sitemap and queues a crawl that turns its pages into published
+// knowledge entries. Submitting a URL again updates its settings and crawls it again.
+func (s *IngestionService) IngestURL(ctx context.Context, req WebIngestRequest) (*models.WebSource, error) {
+	if s.knowledge == nil {
+		return nil, errors.New("knowledge base imports are not enabled")
+	}
+	rawURL := strings.TrimSpace(req.URL)
+	if err := validateCrawlURL(rawURL); err != nil {
+		return nil, validationError("%v", err)
+	}
+	if req.RecrawlHours < 0 {
+		return nil, validationError("recrawl_hours must not be negative")
+	}
+	category := strings.TrimSpace(req.Category)
+	if category == "" {
+		category = defaultImportCategory
+	}
+	if err := s.ensureUploader(req.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	var source models.WebSource
+	err := s.db.WithContext(ctx).Where("url = ?", rawURL).First(&source).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		source = models.WebSource{
+			URL:          rawURL,
+			IsSitemap:    req.Sitemap,
+			Category:     category,
+			RecrawlHours: req.RecrawlHours,
+			Pages:        "[]",
+			Status:       models.WebSourcePending,
+			CreatedBy:    req.CreatedBy,
+		}
+		if err := s.db.WithContext(ctx).Create(&source).Error; err != nil {
+			return nil, fmt.Errorf("failed to save web source: %w", err)
+		}
+	case err != nil:
+		return nil, err
+	default:
+		source.IsSitemap = req.Sitemap
+		source.Category = category
+		source.RecrawlHours = req.RecrawlHours
+		err := s.db.WithContext(ctx).Model(&source).Updates(map[string]interface{}{
+			"is_sitemap":    source.IsSitemap,
+			"category":      source.Category,
+			"recrawl_hours": source.RecrawlHours,
+		}).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to update web source: %w", err)
+		}
+	}
+
+	if err := s.scheduleCrawl(ctx, &source); err != nil {
+		return nil, fmt.Errorf("failed to schedule crawl: %w", err)
+	}
+	return &source, nil
+}
+
+// GetWebSource returns a web source with the state of its crawled pages
+func (s *IngestionService) GetWebSource(ctx context.Context, id uuid.UUID) (*models.WebSource, error) {
+	var source models.WebSource
+	if err := s.db.WithContext(ctx).First(&source, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "web source")
+	}
+	return &source, nil
+}
+
+// scheduleCrawl queues a manual crawl unless one is already waiting or running
+func (s *IngestionService) scheduleCrawl(ctx context.Context, source *models.WebSource) error {
+	var waiting int64
+	err := s.db.Model(&models.Job{}).
+		Where("type = ? AND payload->>'source_id' = ?", JobTypeWebCrawl, source.ID.String()).
+		Where("(status = ? OR (status IN ? AND payload->>'trigger' = ?))", models.JobRunning,
+			[]models.JobStatus{models.JobPending, models.JobFailed}, WebCrawlTriggerManual).
+		Count(&waiting).Error
+	if err != nil || waiting > 0 {
+		return err
+	}
+	_, err = s.jobQueue.Enqueue(ctx, DocumentQueue, JobTypeWebCrawl, webCrawlPayload{SourceID: source.ID, Trigger: WebCrawlTriggerManual}, &EnqueueOptions{
+		MaxAttempts: 3,
+	})
+	return err
+}
+
+// scheduleRecrawl queues the next scheduled crawl of a source unless one other than exclude is already waiting
+func (s *IngestionService) scheduleRecrawl(ctx context.Context, source *models.WebSource, exclude uuid.UUID) error {
+	if source.RecrawlHours <= 0 {
+		return nil
+	}
+	var waiting int64
+	err := s.db.Model(&models.Job{}).
+		Where("type = ? AND status IN ?", JobTypeWebCrawl, []models.JobStatus{models.JobPending, models.JobFailed}).
+		Where("payload->>'source_id' = ? AND payload->>'trigger' = ?", source.ID.String(), WebCrawlTriggerScheduled).
+		Where("id <> ?", exclude).
+		Count(&waiting).Error
+	if err != nil || waiting > 0 {
+		return err
+	}
+
+	next := time.Now().Add(time.Duration(source.RecrawlHours) * time.Hour)
+	_, err = s.jobQueue.Enqueue(ctx, DocumentQueue, JobTypeWebCrawl, webCrawlPayload{SourceID: source.ID, Trigger: WebCrawlTriggerScheduled}, &EnqueueOptions{
+		MaxAttempts: 3,
+		RunAt:       next,
+	})
+	if err == nil {
+		log.Printf("[INFO] Scheduled re-crawl of %s at %s", source.URL, next.Format(time.RFC3339))
+	}
+	return err
+}
+
+// handleWebCrawlJob crawls a web source and schedules its next re-crawl
+func (s *IngestionService) handleWebCrawlJob(ctx context.Context, job *models.Job) error {
+	var payload webCrawlPayload
+	if err := DecodeJobPayload(job, &payload); err != nil {
+		return err
+	}
+
+	var source models.WebSource
+	if err := s.db.First(&source, "id = ?", payload.SourceID).Error; err != nil {
+		log.Printf("[INFO] Skipped crawl of web source %s: %v", payload.SourceID, err)
+		return nil
+	}
+	// Re-crawls were switched off after this one was scheduled
+	if payload.Trigger == WebCrawlTriggerScheduled && source.RecrawlHours <= 0 {
+		return nil
+	}
+
+	var running int64
+	err := s.db.Model(&models.Job{}).
+		Where("type = ? AND status = ? AND id <> ?", JobTypeWebCrawl, models.JobRunning, job.ID).
+		Where("payload->>'source_id' = ?", source.ID.String()).
+		Count(&running).Error
+	if err != nil {
+		return err
+	}
+	if running > 0 {
+		return fmt.Errorf("web source %s is already being crawled", source.ID)
+	}
+
+	defer func() {
+		if err := s.scheduleRecrawl(ctx, &source, job.ID); err != nil {
+			log.Printf("[WARNING] Failed to schedule re-crawl of %s: %v", source.URL, err)
+		}
+	}()
+	return s.crawl(ctx, &source)
+}
+
+// crawl fetches the pages of a source and brings its knowledge entries in line with them. Unchanged
+// pages keep their entries, changed pages get new ones, and entries of pages gone from the sitemap are
+// deleted. A page that fails to fetch keeps the entries of the crawl before.
+func (s *IngestionService) crawl(ctx context.Context, source *models.WebSource) error {
+	if s.knowledge == nil {
+		return errors.New("knowledge base imports are not enabled")
+	}
+
+	urls := []string{source.URL}
+	if source.IsSitemap {
+		var err error
+		if urls, err = s.sitemapURLs(ctx, source.URL, 0); err != nil {
+			s.updateWebSource(source, models.WebSourceFailed, nil, err.Error())
+			return err
+		}
+		if len(urls) > s.crawlMaxPages {
+			log.Printf("[WARNING] Sitemap %s lists %d pages, crawling the first %d", source.URL, len(urls), s.crawlMaxPages)
+			urls = urls[:s.crawlMaxPages]
+		}
+	}
+
+	var previous []webPage
+	if source.Pages != "" {
+		if err := json.Unmarshal([]byte(source.Pages), &previous); err != nil {
+			log.Printf("[WARNING] Ignoring invalid page state of web source %s: %v", source.ID, err)
+		}
+	}
+	known := make(map[string]webPage, len(previous))
+	for _, page := range previous {
+		known[page.URL] = page
+	}
+
+	pages := make([]webPage, 0, len(urls))
+	failed, changed := 0, 0
+	for _, pageURL := range urls {
+		page, err := s.crawlPage(ctx, source, pageURL, known[pageURL])
+		if err != nil {
+			failed++
+			log.Printf("[WARNING] Failed to crawl %s: %v", pageURL, err)
+		} else if page.Hash != known[pageURL].Hash {
+			changed++
+		}
+		delete(known, pageURL)
+		pages = append(pages, page)
+	}
+	// Pages no longer listed by the sitemap
+	for _, page := range known {
+		s.deleteWebEntries(page.EntryIDs)
+	}
+
+	if failed == len(urls) {
+		message := fmt.Sprintf("failed to crawl %s: %s", source.URL, pages[0].Error)
+		if len(urls) > 1 {
+			message = fmt.Sprintf("failed to crawl all %d pages of %s", len(urls), source.URL)
+		}
+		s.updateWebSource(source, models.WebSourceFailed, pages, message)
+		return errors.New(message)
+	}
+	message := ""
+	if failed > 0 {
+		message = fmt.Sprintf("%d of %d pages failed to crawl", failed, len(urls))
+	}
+	s.updateWebSource(source, models.WebSourceCrawled, pages, message)
+	log.Printf("[INFO] Crawled %s: %d pages, %d changed, %d failed", source.URL, len(urls), changed, failed)
+	return nil
+}
+
+// crawlPage fetches a page and replaces its entries when its text changed since the last crawl
+func (s *IngestionService) crawlPage(ctx context.Context, source *models.WebSource, pageURL string, last webPage) (webPage, error) {
+	page := last
+	page.URL = pageURL
+	page.CrawledAt = time.Now()
+	fail := func(err error) (webPage, error) {
+		page.Error = err.Error()
+		return page, err
+	}
+
+	title, text, err := s.fetchWebPage(ctx, pageURL)
+	if err != nil {
+		return fail(err)
+	}
+	if text == "" {
+		return fail(errors.New("no content found on page"))
+	}
+	if findings := ScanForPromptInjection(text); len(findings) > 0 {
+		rules := make([]string, len(findings))
+		for i, finding := range findings {
+			rules[i] = finding.Rule
+		}
+		return fail(fmt.Errorf("page contains instruction-like content (%s)", strings.Join(rules, ", ")))
+	}
+
+	sum := sha256.Sum256([]byte(title + "\n" + text))
+	hash := hex.EncodeToString(sum[:])
+	page.Error = ""
+	if hash == last.Hash && len(last.EntryIDs) > 0 {
+		return page, nil
+	}
+
+	if title == "" {
+		title = pageURL
+	}
+	entryIDs, err := s.createWebEntries(ctx, source, pageURL, title, text)
+	if err != nil {
+		return fail(err)
+	}
+	s.deleteWebEntries(last.EntryIDs)
+	page.Title = title
+	page.Hash = hash
+	page.EntryIDs = entryIDs
+	return page, nil
+}
+
+// createWebEntries splits the text of a page into sections and creates a published knowledge entry for
+// each, with the page URL in its field data
+func (s *IngestionService) createWebEntries(ctx context.Context, source *models.WebSource, pageURL, title, text string) ([]uuid.UUID, error) {
+	fieldData, _ := json.Marshal(map[string]interface{}{
+		"source_url":    pageURL,
+		"web_source_id": source.ID,
+		"crawled_at":    time.Now().Format(time.RFC3339),
+	})
+	return createSectionEntries(ctx, s.knowledge, splitIntoSections(text, defaultSectionOptions()), sectionEntry{
+		Title:     title,
+		Category:  source.Category,
+		Tag:       "web",
+		FieldData: string(fieldData),
+		CreatedBy: source.CreatedBy,
+	})
+}
+
+// deleteWebEntries deletes the entries of a crawled page
+func (s *IngestionService) deleteWebEntries(entryIDs []uuid.UUID) {
+	deleteSectionEntries(s.knowledge, entryIDs)
+}
+
+func (s *IngestionService) updateWebSource(source *models.WebSource, status models.WebSourceStatus, pages []webPage, errorMessage string) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":          status,
+		"error_message":   errorMessage,
+		"last_crawled_at": now,
+		"updated_at":      now,
+	}
+	if pages != nil {
+		encoded, _ := json.Marshal(pages)
+		updates["pages"] = string(encoded)
+		source.Pages = string(encoded)
+	}
+	if err := s.db.Model(&models.WebSource{}).Where("id = ?", source.ID).Updates(updates).Error; err != nil {
+		log.Printf("[ERROR] Failed to update web source %s: %v", source.ID, err)
+	}
+	source.Status = status
+	source.ErrorMessage = errorMessage
+	source.LastCrawledAt = &now
+}
+
+// sitemapURLs returns the page URLs a sitemap lists, following sitemap indexes. Pages on other hosts
+// than the sitemap are skipped.
+func (s *IngestionService) sitemapURLs(ctx context.Context, sitemapURL string, depth int) ([]string, error) {
+	body, _, err := s.fetchURL(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	var sitemap sitemapDocument
+	if err := xml.Unmarshal(body, &sitemap); err != nil {
+		return nil, fmt.Errorf("invalid sitemap %s: %w", sitemapURL, err)
+	}
+	base, _ := url.Parse(sitemapURL)
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, entry := range sitemap.URLs {
+		loc := strings.TrimSpace(entry.Loc)
+		parsed, err := url.Parse(loc)
+		if err != nil || validateCrawlURL(loc) != nil || !strings.EqualFold(parsed.Host, base.Host) {
+			log.Printf("[INFO] Skipping sitemap entry %q of %s", loc, sitemapURL)
+			continue
+		}
+		if !seen[loc] {
+			seen[loc] = true
+			urls = append(urls, loc)
+		}
+	}
+	if depth+1 < sitemapMaxDepth {
+		for _, entry := range sitemap.Sitemaps {
+			loc := strings.TrimSpace(entry.Loc)
+			nested, err := s.sitemapURLs(ctx, loc, depth+1)
+			if err != nil {
+				log.Printf("[WARNING] Skipping sitemap %s: %v", loc, err)
+				continue
+			}
+			for _, pageURL := range nested {
+				if !seen[pageURL] {
+					seen[pageURL] = true
+					urls = append(urls, pageURL)
+				}
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("sitemap %s lists no pages", sitemapURL)
+	}
+	return urls, nil
+}
+
+// fetchWebPage downloads a page and returns its title and main text without the page chrome
+func (s *IngestionService) fetchWebPage(ctx context.Context, pageURL string) (string, string, error) {
+	body, contentType, err := s.fetchURL(ctx, pageURL)
+	if err != nil {
+		return "", "", err
+	}
+	switch {
+	case strings.Contains(contentType, "html"):
+		title, text := webPageText(string(body))
+		return title, text, nil
+	case strings.HasPrefix(contentType, "text/"):
+		return "", strings.TrimSpace(string(body)), nil
+	default:
+		return "", "", fmt.Errorf("unsupported content type %q", contentType)
+	}
+}
+
+func (s *IngestionService) fetchURL(ctx context.Context, rawURL string) ([]byte, string, error) {
+	return fetchURLBody(ctx, s.crawlClient, rawURL, webCrawlUserAgent, webCrawlMaxBytes)
+}
+
+// webPageText extracts the title and text of an HTML page. The main or article element is preferred
+// when the page has one, and navigation, headers, footers, sidebars and forms are dropped.
+func webPageText(page string) (string, string) {
+	title := ""
+	if match := htmlTitlePattern.FindStringSubmatch(page); match != nil {
+		title = strings.Join(strings.Fields(html.UnescapeString(match[1])), " ")
+	}
+
+	content := page
+	if match := htmlMainContent.FindStringSubmatch(page); match != nil {
+		content = match[2]
+	}
+	for _, pattern := range htmlBoilerplate {
+		content = pattern.ReplaceAllString(content, " ")
+	}
+	return title, htmlParagraphs(content)
+}
+
+// htmlParagraphs converts HTML into plain text with every block element as a paragraph, so long
+// pages split into sections
+func htmlParagraphs(content string) string {
+	var paragraphs []string
+	for _, line := range strings.Split(utils.StripHTML(content), "\n") {
+		if line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// validateCrawlURL accepts absolute http and https URLs
+func validateCrawlURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid URL: %q", rawURL)
+	}
+	return nil
+}
+
+// fetchURLBody downloads up to maxBytes of a URL and returns the body with its content type
+func fetchURLBody(ctx context.Context, client *http.Client, rawURL, userAgent string, maxBytes int64) ([]byte, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid URL: %w", err)
+	}
+	httpReq.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch URL: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read URL body: %w", err)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
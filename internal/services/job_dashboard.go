@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrJobQueueAdminOnly is returned when a non-admin pauses or resumes a job queue
+var ErrJobQueueAdminOnly = fmt.Errorf("%w: only admins can pause or resume job queues", ErrForbidden)
+
+// QueueHealth summarizes the state of a single job queue
+type QueueHealth struct {
+	Queue       string     `json:"queue"`
+	Paused      bool       `json:"paused"`
+	PausedBy    *uuid.UUID `json:"paused_by,omitempty"`
+	PausedAt    *time.Time `json:"paused_at,omitempty"`
+	PauseReason string     `json:"pause_reason,omitempty"`
+
+	Pending  int64 `json:"pending"`
+	Running  int64 `json:"running"`
+	Retrying int64 `json:"retrying"`
+	Dead     int64 `json:"dead"`
+
+	// Due jobs are waiting jobs whose run_at has passed; the age is how long the oldest has been ready
+	Due                 int64   `json:"due"`
+	OldestDueAgeSeconds float64 `json:"oldest_due_age_seconds"`
+	CompletedInWindow   int64   `json:"completed_in_window"`
+	FailedInWindow      int64   `json:"failed_in_window"`
+	FailureRateInWindow float64 `json:"failure_rate_in_window"`
+}
+
+// WorkerHealth is a worker heartbeat with its liveness
+type WorkerHealth struct {
+	models.JobWorkerHeartbeat
+	Alive bool `json:"alive"`
+}
+
+// JobDashboard is the admin view of the job queues and the processes working them
+type JobDashboard struct {
+	GeneratedAt  time.Time      `json:"generated_at"`
+	WindowHours  int            `json:"window_hours"`
+	Queues       []QueueHealth  `json:"queues"`
+	Workers      []WorkerHealth `json:"workers"`
+	AliveWorkers int            `json:"alive_workers"`
+}
+
+// JobDashboardService reports job queue health and lets admins pause queues during incidents
+type JobDashboardService struct {
+	db *gorm.DB
+}
+
+// NewJobDashboardService creates a new job dashboard service
+func NewJobDashboardService(db *gorm.DB) *JobDashboardService {
+	return &JobDashboardService{db: db}
+}
+
+type queueStatusCount struct {
+	Queue  string
+	Status models.JobStatus
+	Count  int64
+}
+
+// Dashboard returns per-queue depth, oldest due job age and failure rates over the window, plus worker heartbeats
+func (s *JobDashboardService) Dashboard(ctx context.Context, window time.Duration) (*JobDashboard, error) {
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+
+	queues := make(map[string]*QueueHealth)
+	queue := func(name string) *QueueHealth {
+		if q, ok := queues[name]; ok {
+			return q
+		}
+		q := &QueueHealth{Queue: name}
+		queues[name] = q
+		return q
+	}
+
+	// Current depth by status
+	var depth []queueStatusCount
+	err := db.Model(&models.Job{}).
+		Select("queue, status, COUNT(*) AS count").
+		Where("status IN ?", []models.JobStatus{models.JobPending, models.JobRunning, models.JobFailed, models.JobDead}).
+		Group("queue, status").
+		Scan(&depth).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	for _, row := range depth {
+		q := queue(row.Queue)
+		switch row.Status {
+		case models.JobPending:
+			q.Pending = row.Count
+		case models.JobRunning:
+			q.Running = row.Count
+		case models.JobFailed:
+			q.Retrying = row.Count
+		case models.JobDead:
+			q.Dead = row.Count
+		}
+	}
+
+	// Jobs ready to run and how long the oldest has been waiting
+	var due []struct {
+		Queue       string
+		Count       int64
+		OldestRunAt time.Time
+	}
+	err = db.Model(&models.Job{}).
+		Select("queue, COUNT(*) AS count, MIN(run_at) AS oldest_run_at").
+		Where("status IN ? AND run_at <= ?", []models.JobStatus{models.JobPending, models.JobFailed}, now).
+		Group("queue").
+		Scan(&due).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count due jobs: %w", err)
+	}
+	for _, row := range due {
+		q := queue(row.Queue)
+		q.Due = row.Count
+		q.OldestDueAgeSeconds = now.Sub(row.OldestRunAt).Seconds()
+	}
+
+	// Outcomes of the jobs that finished or failed an attempt during the window
+	var outcomes []queueStatusCount
+	err = db.Model(&models.Job{}).
+		Select("queue, status, COUNT(*) AS count").
+		Where("status IN ? AND updated_at >= ?", []models.JobStatus{models.JobCompleted, models.JobFailed, models.JobDead}, now.Add(-window)).
+		Group("queue, status").
+		Scan(&outcomes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count job outcomes: %w", err)
+	}
+	for _, row := range outcomes {
+		q := queue(row.Queue)
+		if row.Status == models.JobCompleted {
+			q.CompletedInWindow += row.Count
+		} else {
+			q.FailedInWindow += row.Count
+		}
+	}
+
+	var pauses []models.JobQueuePause
+	if err := db.Find(&pauses).Error; err != nil {
+		return nil, fmt.Errorf("failed to load paused queues: %w", err)
+	}
+	for _, pause := range pauses {
+		q := queue(pause.Queue)
+		pausedBy, pausedAt := pause.PausedBy, pause.CreatedAt
+		q.Paused = true
+		q.PausedBy = &pausedBy
+		q.PausedAt = &pausedAt
+		q.PauseReason = pause.Reason
+	}
+
+	dashboard := &JobDashboard{
+		GeneratedAt: now,
+		WindowHours: int(window / time.Hour),
+		Queues:      make([]QueueHealth, 0, len(queues)),
+		Workers:     []WorkerHealth{},
+	}
+	for _, q := range queues {
+		if total := q.CompletedInWindow + q.FailedInWindow; total > 0 {
+			q.FailureRateInWindow = float64(q.FailedInWindow) / float64(total)
+		}
+		dashboard.Queues = append(dashboard.Queues, *q)
+	}
+	sort.Slice(dashboard.Queues, func(i, j int) bool { return dashboard.Queues[i].Queue < dashboard.Queues[j].Queue })
+
+	var beats []models.JobWorkerHeartbeat
+	if err := db.Order("worker_id ASC").Find(&beats).Error; err != nil {
+		return nil, fmt.Errorf("failed to load worker heartbeats: %w", err)
+	}
+	for _, beat := range beats {
+		alive := now.Sub(beat.LastSeenAt) <= workerHeartbeatStale
+		if alive {
+			dashboard.AliveWorkers++
+		}
+		dashboard.Workers = append(dashboard.Workers, WorkerHealth{JobWorkerHeartbeat: beat, Alive: alive})
+	}
+
+	return dashboard, nil
+}
+
+// PauseQueue stops workers from claiming new jobs from a queue. Jobs already running finish normally.
+func (s *JobDashboardService) PauseQueue(ctx context.Context, queue string, adminID uuid.UUID, reason string) (*models.JobQueuePause, error) {
+	queue = strings.TrimSpace(queue)
+	if queue == "" {
+		return nil, validationError("queue is required")
+	}
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	pause := &models.JobQueuePause{
+		Queue:     queue,
+		PausedBy:  adminID,
+		Reason:    strings.TrimSpace(reason),
+		CreatedAt: time.Now(),
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "queue"}},
+		DoUpdates: clause.AssignmentColumns([]string{"paused_by", "reason"}),
+	}).Create(pause).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to pause queue %s: %w", queue, err)
+	}
+
+	log.Printf("[WARNING] Job queue %s paused by %s: %s", queue, adminID, pause.Reason)
+	return pause, nil
+}
+
+// ResumeQueue lets workers claim jobs from a paused queue again
+func (s *JobDashboardService) ResumeQueue(ctx context.Context, queue string, adminID uuid.UUID) error {
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Delete(&models.JobQueuePause{}, "queue = ?", queue)
+	if result.Error != nil {
+		return fmt.Errorf("failed to resume queue %s: %w", queue, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: queue %s is not paused", ErrNotFound, queue)
+	}
+
+	log.Printf("[INFO] Job queue %s resumed by %s", queue, adminID)
+	return nil
+}
+
+func (s *JobDashboardService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrJobQueueAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrJobQueueAdminOnly
+	}
+	return nil
+}
@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Job queues
+const (
+	DocumentQueue  = "documents"
+	EmbeddingQueue = "embeddings"
+)
+
+// Job types
+const (
+	JobTypeDocumentIngest = "document_ingest"
+	JobTypeKnowledgeEmbed = "knowledge_embed"
+
+	// Queued before the ingestion pipeline; still handled so pending jobs finish
+	JobTypeOpenAIUpload    = "openai_upload"
+	JobTypeDocumentProcess = "document_process"
+)
+
+const (
+	defaultJobMaxAttempts     = 5
+	defaultJobStaleLockAfter  = 15 * time.Minute
+	jobStaleLockCheckInterval = time.Minute
+
+	// Workers report a heartbeat every interval; a worker is considered down after missing a few
+	workerHeartbeatInterval = 30 * time.Second
+	workerHeartbeatStale    = 3 * workerHeartbeatInterval
+	workerHeartbeatRetain   = 24 * time.Hour
+)
+
+// JobHandler processes a single job. Returning an error schedules a retry.
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// JobQueue is a database-backed job queue with retries and dead-lettering
+type JobQueue struct {
+	db           *gorm.DB
+	handlers     map[string]JobHandler
+	workerID     string
+	pollInterval time.Duration
+	mu           sync.RWMutex
+	wg           sync.WaitGroup
+
+	// Reported in the worker heartbeat
+	workers   int
+	startedAt time.Time
+	active    int64
+	processed int64
+	failed    int64
+}
+
+// EnqueueOptions customizes how a job is scheduled
+type EnqueueOptions struct {
+	MaxAttempts int
+	RunAt       time.Time
+}
+
+// JobFilter filters the job listing
+type JobFilter struct {
+	Queue  string
+	Type   string
+	Status models.JobStatus
+	Limit  int
+	Offset int
+}
+
+// NewJobQueue creates a new job queue
+func NewJobQueue(db *gorm.DB, pollInterval time.Duration) *JobQueue {
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+	hostname, _ := os.Hostname()
+	return &JobQueue{
+		db:           db,
+		handlers:     make(map[string]JobHandler),
+		workerID:     fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		pollInterval: pollInterval,
+	}
+}
+
+// Register registers the handler for a job type
+func (q *JobQueue) Register(jobType string, handler JobHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+	log.Printf("[INFO] Registered job handler for type: %s", jobType)
+}
+
+// Enqueue persists a new job
+func (q *JobQueue) Enqueue(ctx context.Context, queue, jobType string, payload interface{}, opts *EnqueueOptions) (*models.Job, error) {
+	return q.EnqueueTx(q.db.WithContext(ctx), queue, jobType, payload, opts)
+}
+
+// EnqueueTx persists a new job using the given transaction
+func (q *JobQueue) EnqueueTx(tx *gorm.DB, queue, jobType string, payload interface{}, opts *EnqueueOptions) (*models.Job, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	job := &models.Job{
+		Queue:       queue,
+		Type:        jobType,
+		Payload:     string(payloadJSON),
+		Status:      models.JobPending,
+		MaxAttempts: defaultJobMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	if opts != nil {
+		if opts.MaxAttempts > 0 {
+			job.MaxAttempts = opts.MaxAttempts
+		}
+		if !opts.RunAt.IsZero() {
+			job.RunAt = opts.RunAt
+		}
+	}
+
+	if err := tx.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	log.Printf("[INFO] Enqueued job %s (queue=%s, type=%s)", job.ID, queue, jobType)
+	return job, nil
+}
+
+// Start launches worker goroutines that poll for jobs until ctx is cancelled
+func (q *JobQueue) Start(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	q.releaseStaleLocks()
+
+	q.workers = workers
+	q.startedAt = time.Now()
+	q.wg.Add(1)
+	go q.runHeartbeat(ctx)
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.runWorker(ctx, i)
+	}
+	log.Printf("[INFO] Job queue started with %d workers (worker_id=%s)", workers, q.workerID)
+}
+
+// Wait blocks until all workers have exited
+func (q *JobQueue) Wait() {
+	q.wg.Wait()
+}
+
+// Drain waits for the workers to finish their in-flight jobs after the context passed to Start is cancelled.
+// If ctx expires first, the jobs still running are released so another worker retries them.
+func (q *JobQueue) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("[INFO] Job queue drained (worker_id=%s)", q.workerID)
+		return nil
+	case <-ctx.Done():
+		active := atomic.LoadInt64(&q.active)
+		q.releaseOwnLocks()
+		return fmt.Errorf("job queue did not drain, %d jobs still running: %w", active, ctx.Err())
+	}
+}
+
+func (q *JobQueue) runWorker(ctx context.Context, index int) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	// The first worker also returns jobs of workers that crashed since Start to the queue
+	var staleLocks <-chan time.Time
+	if index == 0 {
+		staleTicker := time.NewTicker(jobStaleLockCheckInterval)
+		defer staleTicker.Stop()
+		staleLocks = staleTicker.C
+	}
+
+	// Cancelling ctx stops claiming new jobs but lets the job in flight finish
+	jobCtx := context.WithoutCancel(ctx)
+	for {
+		// Drain all available jobs before sleeping
+		for ctx.Err() == nil {
+			processed, err := q.processNext(jobCtx)
+			if err != nil {
+				log.Printf("[ERROR] Job worker %d failed to process job: %v", index, err)
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[INFO] Job worker %d stopped", index)
+			return
+		case <-staleLocks:
+			q.releaseStaleLocks()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runHeartbeat records this process's liveness and counters until ctx is cancelled
+func (q *JobQueue) runHeartbeat(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(workerHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		q.heartbeat()
+		select {
+		case <-ctx.Done():
+			// A clean shutdown removes the heartbeat so the worker is not reported as down
+			q.db.Delete(&models.JobWorkerHeartbeat{}, "worker_id = ?", q.workerID)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *JobQueue) heartbeat() {
+	hostname, _ := os.Hostname()
+	beat := models.JobWorkerHeartbeat{
+		WorkerID:      q.workerID,
+		Hostname:      hostname,
+		PID:           os.Getpid(),
+		Workers:       q.workers,
+		ActiveJobs:    atomic.LoadInt64(&q.active),
+		JobsProcessed: atomic.LoadInt64(&q.processed),
+		JobsFailed:    atomic.LoadInt64(&q.failed),
+		StartedAt:     q.startedAt,
+		LastSeenAt:    time.Now(),
+	}
+	err := q.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "worker_id"}},
+		UpdateAll: true,
+	}).Create(&beat).Error
+	if err != nil {
+		log.Printf("[WARNING] Failed to record job worker heartbeat: %v", err)
+		return
+	}
+
+	// Forget processes that stopped without cleaning up a long time ago
+	q.db.Where("last_seen_at < ?", time.Now().Add(-workerHeartbeatRetain)).Delete(&models.JobWorkerHeartbeat{})
+}
+
+// processNext claims and runs a single due job. It reports whether a job was processed.
+func (q *JobQueue) processNext(ctx context.Context) (bool, error) {
+	job, err := q.claim()
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	atomic.AddInt64(&q.active, 1)
+	defer atomic.AddInt64(&q.active, -1)
+
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	var runErr error
+	if !ok {
+		runErr = fmt.Errorf("no handler registered for job type %s", job.Type)
+	} else {
+		runErr = q.runHandler(ctx, handler, job)
+	}
+
+	atomic.AddInt64(&q.processed, 1)
+	if runErr != nil {
+		atomic.AddInt64(&q.failed, 1)
+		q.markFailed(job, runErr)
+		return true, nil
+	}
+
+	now := time.Now()
+	completed := q.finish(job, map[string]interface{}{
+		"status":       models.JobCompleted,
+		"completed_at": &now,
+		"locked_at":    nil,
+		"locked_by":    "",
+		"last_error":   "",
+	})
+	if completed {
+		log.Printf("[INFO] Job %s (type=%s) completed on attempt %d", job.ID, job.Type, job.Attempts)
+	}
+	return true, nil
+}
+
+// finish records the outcome of a job this worker ran. It reports false when the job's lock was lost, e.g.
+// released as stale while the handler was still running, as the state of the job then belongs to the worker
+// that claimed it again. The attempt count tells apart claims of workers sharing this process's worker ID.
+func (q *JobQueue) finish(job *models.Job, updates map[string]interface{}) bool {
+	result := q.db.Model(&models.Job{}).
+		Where("id = ? AND locked_by = ? AND status = ? AND attempts = ?", job.ID, q.workerID, models.JobRunning, job.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("[ERROR] Failed to record the outcome of job %s: %v", job.ID, result.Error)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("[WARNING] Job %s (type=%s) lost its lock while running attempt %d, leaving it to the worker holding it", job.ID, job.Type, job.Attempts)
+		return false
+	}
+	return true
+}
+
+// runHandler runs the handler, converting panics into errors
+func (q *JobQueue) runHandler(ctx context.Context, handler JobHandler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// claim locks the next due job using SKIP LOCKED so multiple workers and replicas can share the queue.
+// Jobs in paused queues are left alone.
+func (q *JobQueue) claim() (*models.Job, error) {
+	var job models.Job
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND run_at <= ?", []models.JobStatus{models.JobPending, models.JobFailed}, time.Now()).
+			Where("queue NOT IN (?)", q.db.Model(&models.JobQueuePause{}).Select("queue")).
+			Order("run_at ASC").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		job.LockedBy = q.workerID
+		return tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": job.LockedAt,
+			"locked_by": job.LockedBy,
+		}).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// markFailed schedules a retry with exponential backoff or dead-letters the job
+func (q *JobQueue) markFailed(job *models.Job, runErr error) {
+	updates := map[string]interface{}{
+		"last_error": runErr.Error(),
+		"locked_at":  nil,
+		"locked_by":  "",
+	}
+
+	dead := job.Attempts >= job.MaxAttempts
+	backoff := time.Duration(1<<uint(job.Attempts)) * 10 * time.Second
+	if dead {
+		updates["status"] = models.JobDead
+	} else {
+		updates["status"] = models.JobFailed
+		updates["run_at"] = time.Now().Add(backoff)
+	}
+	if !q.finish(job, updates) {
+		return
+	}
+
+	if dead {
+		log.Printf("[ERROR] Job %s (type=%s) moved to dead-letter after %d attempts: %v", job.ID, job.Type, job.Attempts, runErr)
+	} else {
+		log.Printf("[WARNING] Job %s (type=%s) failed attempt %d/%d, retrying in %v: %v", job.ID, job.Type, job.Attempts, job.MaxAttempts, backoff, runErr)
+	}
+}
+
+// releaseStaleLocks returns jobs left running by a crashed worker to the queue
+func (q *JobQueue) releaseStaleLocks() {
+	result := q.db.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobRunning, time.Now().Add(-defaultJobStaleLockAfter)).
+		Updates(map[string]interface{}{
+			"status":    models.JobFailed,
+			"locked_at": nil,
+			"locked_by": "",
+			"run_at":    time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("[WARNING] Failed to release stale job locks: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[INFO] Released %d stale job locks", result.RowsAffected)
+	}
+}
+
+// releaseOwnLocks makes the jobs this process is running due again, for when it stops before they finish
+func (q *JobQueue) releaseOwnLocks() {
+	result := q.db.Model(&models.Job{}).
+		Where("status = ? AND locked_by = ?", models.JobRunning, q.workerID).
+		Updates(map[string]interface{}{
+			"status":    models.JobFailed,
+			"locked_at": nil,
+			"locked_by": "",
+			"run_at":    time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("[WARNING] Failed to release job locks: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[WARNING] Released %d jobs interrupted by shutdown", result.RowsAffected)
+	}
+}
+
+// GetJob returns a job by ID
+func (q *JobQueue) GetJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	if err := q.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "job")
+	}
+	return &job, nil
+}
+
+// ListJobs lists jobs matching the filter, newest first
+func (q *JobQueue) ListJobs(ctx context.Context, filter JobFilter) ([]models.Job, int64, error) {
+	var jobs []models.Job
+	var total int64
+
+	query := q.db.WithContext(ctx).Model(&models.Job{})
+	if filter.Queue != "" {
+		query = query.Where("queue = ?", filter.Queue)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// RetryJob requeues a failed or dead-lettered job immediately with a fresh attempt budget
+func (q *JobQueue) RetryJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	result := q.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status IN ?", id, []models.JobStatus{models.JobFailed, models.JobDead}).
+		Updates(map[string]interface{}{
+			"status":   models.JobPending,
+			"attempts": 0,
+			"run_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		job, err := q.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, validationError("job %s is %s, only failed and dead jobs can be retried", id, job.Status)
+	}
+	return q.GetJob(ctx, id)
+}
+
+// DecodeJobPayload unmarshals a job payload into v
+func DecodeJobPayload(job *models.Job, v interface{}) error {
+	if err := json.Unmarshal([]byte(job.Payload), v); err != nil {
+		return fmt.Errorf("invalid payload for job %s: %w", job.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestJobOutcomeRequiresTheLock(t *testing.T) {
+	db, recorder := dryRunDB(t)
+	queue := NewJobQueue(db, time.Second)
+	job := &models.Job{ID: uuid.New(), Type: "test", Attempts: 2, MaxAttempts: 5}
+
+	// The dry run updates no rows, like a job whose lock was released and claimed again
+	if queue.finish(job, map[string]interface{}{"status": models.JobCompleted}) {
+		t.Error("the outcome of a job whose lock was lost was recorded")
+	}
+	queue.markFailed(job, errors.New("timeout"))
+
+	for _, statement := range recorder.statements {
+		for _, part := range []string{"locked_by = '" + queue.workerID + "'", "status = 'running'", "attempts = 2"} {
+			if !strings.Contains(statement, part) {
+				t.Errorf("outcome update lacks %q:\n%s", part, statement)
+			}
+		}
+	}
+	if len(recorder.statements) != 2 {
+		t.Errorf("%d updates, want 2", len(recorder.statements))
+	}
+}
+
+func TestSlowJobLosingItsLockKeepsTheNewClaim(t *testing.T) {
+	tx := testDB(t)
+	ctx := context.Background()
+	slow := NewJobQueue(tx, time.Second)
+	other := NewJobQueue(tx, time.Second)
+	other.workerID = "other-worker"
+
+	job, err := slow.Enqueue(ctx, EmbeddingQueue, "slow_test", nil, nil)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	var reclaimed *models.Job
+	slow.Register("slow_test", func(ctx context.Context, running *models.Job) error {
+		// The handler runs past the stale lock timeout, so another worker claims the job meanwhile
+		tx.Model(&models.Job{}).Where("id = ?", running.ID).Update("locked_at", time.Now().Add(-2*defaultJobStaleLockAfter))
+		other.releaseStaleLocks()
+		reclaimed, err = other.claim()
+		if err != nil || reclaimed == nil {
+			t.Fatalf("reclaim: %v", err)
+		}
+		return nil
+	})
+
+	if processed, err := slow.processNext(ctx); err != nil || !processed {
+		t.Fatalf("process: %v, %v", processed, err)
+	}
+	var stored models.Job
+	if err := tx.First(&stored, "id = ?", job.ID).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	if stored.Status != models.JobRunning || stored.LockedBy != other.workerID || stored.Attempts != 2 {
+		t.Fatalf("job %s locked by %q on attempt %d after the first worker finished, want running by %q on attempt 2",
+			stored.Status, stored.LockedBy, stored.Attempts, other.workerID)
+	}
+
+	// The worker holding the job records its outcome
+	other.markFailed(reclaimed, errors.New("timeout"))
+	if err := tx.First(&stored, "id = ?", job.ID).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	if stored.Status != models.JobFailed || stored.LastError != "timeout" {
+		t.Errorf("job %s with error %q, want failed with the second worker's error", stored.Status, stored.LastError)
+	}
+}
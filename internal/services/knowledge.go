@@ -2,24 +2,86 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log"
 	"tic-knowledge-system/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Embedder creates embedding vectors for knowledge chunks and search queries
+type Embedder interface {
+	CreateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
 type KnowledgeService struct {
 	db            *gorm.DB
-	openAIService *OpenAIService
-	vectorService *VectorService
+	embedder      Embedder
+	vectorService VectorStore
+	jobQueue      *JobQueue
+	chunkOptions  ChunkOptions
+	reads         ReadReplicaRouter
+	questions     *RelatedQuestionGenerator
+	presets       *RetrievalPresetService
+	seeAlso       SeeAlsoOptions
+	settings      *SettingsService
 }
 
-func NewKnowledgeService(db *gorm.DB, openAIService *OpenAIService, vectorService *VectorService) *KnowledgeService {
-	return &KnowledgeService{
+// knowledgeEmbedPayload is the job payload for (re)generating an entry's embeddings
+type knowledgeEmbedPayload struct {
+	KnowledgeEntryID uuid.UUID `json:"knowledge_entry_id"`
+}
+
+// NewKnowledgeService creates the knowledge service. When jobQueue is nil embeddings are generated inline.
+func NewKnowledgeService(db *gorm.DB, openAIService *OpenAIService, vectorService VectorStore, jobQueue *JobQueue) *KnowledgeService {
+	s := &KnowledgeService{
 		db:            db,
-		openAIService: openAIService,
 		vectorService: vectorService,
+		jobQueue:      jobQueue,
+		chunkOptions:  DefaultChunkOptions(),
+	}
+	if openAIService != nil {
+		s.embedder = openAIService
+	}
+	return s
+}
+
+// SetEmbedder replaces the embedding model, e.g. with an EmbeddingService for the configured provider.
+// The vector collection dimension must match the new model.
+func (s *KnowledgeService) SetEmbedder(embedder Embedder) {
+	s.embedder = embedder
+}
+
+// SetChunkOptions overrides the chunking parameters used when generating embeddings
+func (s *KnowledgeService) SetChunkOptions(opts ChunkOptions) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultChunkOptions().MaxTokens
 	}
+	s.chunkOptions = opts
+}
+
+// SetSettings applies the chunking parameters set by admins over the ones of SetChunkOptions
+func (s *KnowledgeService) SetSettings(settings *SettingsService) {
+	s.settings = settings
+}
+
+// SetReadRouter routes knowledge listing and search queries to read replicas
+func (s *KnowledgeService) SetReadRouter(router ReadReplicaRouter) {
+	s.reads = router
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *KnowledgeService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeKnowledgeEmbed, func(ctx context.Context, job *models.Job) error {
+		var payload knowledgeEmbedPayload
+		if err := DecodeJobPayload(job, &payload); err != nil {
+			return err
+		}
+		return s.GenerateEmbeddings(ctx, payload.KnowledgeEntryID)
+	})
 }
 
 // Template Management
@@ -46,53 +108,69 @@ func (s *KnowledgeService) GetTemplateByID(id uuid.UUID) (*models.Template, erro
 	var template models.Template
 	err := s.db.Preload("Fields").Preload("Creator").First(&template, "id = ?", id).Error
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "template "+id.String())
 	}
 	return &template, nil
 }
 
+// UpdateTemplate saves the template. Changing its retrieval preset re-embeds the template's entries.
 func (s *KnowledgeService) UpdateTemplate(template *models.Template) error {
-	return s.db.Save(template).Error
+	var previous models.Template
+	if err := s.db.Select("id", "retrieval_preset_id").First(&previous, "id = ?", template.ID).Error; err != nil {
+		return notFound(err, "template "+template.ID.String())
+	}
+	if err := s.db.Save(template).Error; err != nil {
+		return err
+	}
+
+	if s.presets != nil && !sameUUID(previous.RetrievalPresetID, template.RetrievalPresetID) {
+		s.presets.invalidate()
+		s.presets.reembed(context.Background(), nil, []uuid.UUID{template.ID})
+	}
+	return nil
 }
 
 func (s *KnowledgeService) DeleteTemplate(id uuid.UUID) error {
-	return s.db.Delete(&models.Template{}, "id = ?", id).Error
+	result := s.db.Delete(&models.Template{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "template "+id.String())
+	}
+	return nil
 }
 
 // Knowledge Entry Management
-func (s *KnowledgeService) CreateKnowledgeEntry(ctx context.Context, entry *models.KnowledgeEntry) error {
-	// Start transaction
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
 
-	// Create the knowledge entry
-	if err := tx.Create(entry).Error; err != nil {
-		tx.Rollback()
+// CreateKnowledgeEntry saves the entry. Embeddings of published entries are generated by a job
+// enqueued in the same transaction, so the write never waits on the embedding and vector APIs.
+// An entry with a publish_at time still to come is saved unpublished.
+func (s *KnowledgeService) CreateKnowledgeEntry(ctx context.Context, entry *models.KnowledgeEntry) error {
+	if err := validatePublicationSchedule(entry); err != nil {
 		return err
 	}
-
-	// Create embeddings if the entry is published
-	if entry.IsPublished {
-		if err := s.createEmbeddings(ctx, tx, entry); err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-
-	return tx.Commit().Error
+	applyPublicationSchedule(entry, time.Now())
+	applyReadingStats(entry)
+	applyLanguage(entry)
+	applySearchText(entry)
+	return s.writeWithEmbeddings(ctx, entry, entry.IsPublished, func(tx *gorm.DB) error {
+		return tx.Create(entry).Error
+	})
 }
 
-func (s *KnowledgeService) GetKnowledgeEntries(category string, isPublished *bool, limit, offset int) ([]models.KnowledgeEntry, error) {
+// GetKnowledgeEntries lists the entries visible in scope. Only editors and admins see unpublished entries.
+func (s *KnowledgeService) GetKnowledgeEntries(category string, isPublished *bool, reading ReadingFilter, scope RetrievalScope, limit, offset int) ([]models.KnowledgeEntry, error) {
 	var entries []models.KnowledgeEntry
-	query := s.db.Preload("Template").Preload("Creator")
+	query := scope.Apply(reading.Apply(readDB(s.reads, s.db).Preload("Template").Preload("Creator")))
 
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
+	if !scope.SeesUnpublished() {
+		published := true
+		isPublished = &published
+	}
 	if isPublished != nil {
 		query = query.Where("is_published = ?", *isPublished)
 	}
@@ -101,11 +179,27 @@ func (s *KnowledgeService) GetKnowledgeEntries(category string, isPublished *boo
 	return entries, err
 }
 
-func (s *KnowledgeService) GetKnowledgeEntryByID(id uuid.UUID) (*models.KnowledgeEntry, error) {
+// GetKnowledgeEntryByID returns an entry visible in scope, following the redirect of a merged entry.
+// Entries outside the scope are reported as not found.
+func (s *KnowledgeService) GetKnowledgeEntryByID(id uuid.UUID, scope RetrievalScope) (*models.KnowledgeEntry, error) {
+	find := func(id uuid.UUID, entry *models.KnowledgeEntry) error {
+		query := scope.Apply(s.db.Preload("Template").Preload("Creator").Preload("SourceDocument"))
+		if !scope.SeesUnpublished() {
+			query = query.Where("is_published = true")
+		}
+		return query.First(entry, "id = ?", id).Error
+	}
+
 	var entry models.KnowledgeEntry
-	err := s.db.Preload("Template").Preload("Creator").First(&entry, "id = ?", id).Error
+	err := find(id, &entry)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The entry may have been merged into another one
+		if target, ok := s.ResolveRedirect(id); ok {
+			err = find(target, &entry)
+		}
+	}
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "knowledge entry "+id.String())
 	}
 
 	// Increment view count
@@ -114,38 +208,166 @@ func (s *KnowledgeService) GetKnowledgeEntryByID(id uuid.UUID) (*models.Knowledg
 	return &entry, nil
 }
 
+// UpdateKnowledgeEntry saves the entry and regenerates its embeddings so content and
+// publication changes reach the vector database
 func (s *KnowledgeService) UpdateKnowledgeEntry(ctx context.Context, entry *models.KnowledgeEntry) error {
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	if err := validatePublicationSchedule(entry); err != nil {
+		return err
+	}
+	applyPublicationSchedule(entry, time.Now())
+	applyReadingStats(entry)
+	applyLanguage(entry)
+	applySearchText(entry)
+	// An update is a review: the entry is current again
+	entry.StaleAt = nil
+	entry.NeedsReview = false
+	entry.ReviewRemindedAt = nil
+	// An accepted category suggestion is settled
+	if entry.SuggestedCategory == entry.Category {
+		entry.SuggestedCategory = ""
+	}
+	return s.writeWithEmbeddings(ctx, entry, true, func(tx *gorm.DB) error {
+		return tx.Save(entry).Error
+	})
+}
+
+// writeWithEmbeddings runs write in a transaction that also records an embedding job for the entry
+// (transactional outbox). Without a job queue the embeddings are generated inline after the commit.
+func (s *KnowledgeService) writeWithEmbeddings(ctx context.Context, entry *models.KnowledgeEntry, embed bool, write func(tx *gorm.DB) error) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
 		}
-	}()
+		if !embed || s.jobQueue == nil {
+			return nil
+		}
+		return s.enqueueEmbeddingsTx(tx, entry.ID)
+	})
+	if err != nil || !embed || s.jobQueue != nil {
+		return err
+	}
+	return s.GenerateEmbeddings(ctx, entry.ID)
+}
 
-	// Update the entry
-	if err := tx.Save(entry).Error; err != nil {
-		tx.Rollback()
+// enqueueEmbeddingsTx records an embedding job for the entry unless one that has not started yet is
+// already waiting. Such a job reads the entry when it runs, so it also covers this change. The waiting
+// job is locked until the transaction ends so a worker cannot start it before this write is visible.
+func (s *KnowledgeService) enqueueEmbeddingsTx(tx *gorm.DB, entryID uuid.UUID) error {
+	var waiting models.Job
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("type = ? AND status IN ? AND payload->>'knowledge_entry_id' = ?",
+			JobTypeKnowledgeEmbed, []models.JobStatus{models.JobPending, models.JobFailed}, entryID.String()).
+		Take(&waiting).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 
-	// Update embeddings if content changed and entry is published
-	if entry.IsPublished {
-		// Delete existing embeddings
-		if err := tx.Where("knowledge_entry_id = ?", entry.ID).Delete(&models.VectorEmbedding{}).Error; err != nil {
-			tx.Rollback()
+	_, err = s.jobQueue.EnqueueTx(tx, EmbeddingQueue, JobTypeKnowledgeEmbed, knowledgeEmbedPayload{KnowledgeEntryID: entryID}, nil)
+	return err
+}
+
+// GenerateEmbeddings replaces the stored embeddings of an entry with freshly computed ones.
+// Unpublished and deleted entries only have their embeddings removed. The embedding and vector
+// APIs are called outside of any database transaction, and vectors are always cleared first,
+// so a failed attempt can be retried without leaving duplicates behind.
+func (s *KnowledgeService) GenerateEmbeddings(ctx context.Context, entryID uuid.UUID) error {
+	var entry models.KnowledgeEntry
+	err := s.db.First(&entry, "id = ?", entryID).Error
+	deleted := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !deleted {
+		return err
+	}
+
+	if s.vectorService != nil {
+		if err := s.vectorService.DeleteByKnowledgeEntry(ctx, entryID); err != nil {
 			return err
 		}
+	}
 
-		// Create new embeddings
-		if err := s.createEmbeddings(ctx, tx, entry); err != nil {
-			tx.Rollback()
+	var embeddings []models.VectorEmbedding
+	if !deleted && entry.IsPublished {
+		s.refreshRelatedQuestions(ctx, &entry)
+		if s.embedder == nil || s.vectorService == nil {
+			log.Printf("[WARNING] Embedding services not configured, skipping embeddings for entry %s", entry.ID)
+		} else if embeddings, err = s.createEmbeddings(ctx, &entry); err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit().Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Delete existing embeddings
+		if err := tx.Where("knowledge_entry_id = ?", entryID).Delete(&models.VectorEmbedding{}).Error; err != nil {
+			return err
+		}
+		if len(embeddings) == 0 {
+			return nil
+		}
+		return tx.Create(&embeddings).Error
+	})
 }
 
+// ReembedEntries regenerates the embeddings of the published entries in the categories or of the templates,
+// e.g. after their chunking settings changed. It returns the number of entries queued.
+func (s *KnowledgeService) ReembedEntries(ctx context.Context, categories []string, templateIDs []uuid.UUID) (int, error) {
+	query := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("is_published = true")
+	switch {
+	case len(categories) > 0 && len(templateIDs) > 0:
+		query = query.Where("category IN ? OR template_id IN ?", categories, templateIDs)
+	case len(categories) > 0:
+		query = query.Where("category IN ?", categories)
+	case len(templateIDs) > 0:
+		query = query.Where("template_id IN ?", templateIDs)
+	default:
+		return 0, nil
+	}
+
+	var ids []uuid.UUID
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return s.reembed(ctx, ids)
+}
+
+// ReembedAllEntries regenerates the embeddings of every published entry, e.g. to rebuild the vector index
+// after changing the embedding model. It returns the number of entries queued.
+func (s *KnowledgeService) ReembedAllEntries(ctx context.Context) (int, error) {
+	var ids []uuid.UUID
+	err := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("is_published = true").Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	return s.reembed(ctx, ids)
+}
+
+// reembed queues new embeddings for the entries, or generates them inline without a job queue
+func (s *KnowledgeService) reembed(ctx context.Context, ids []uuid.UUID) (int, error) {
+	if s.jobQueue == nil {
+		for _, id := range ids {
+			if err := s.GenerateEmbeddings(ctx, id); err != nil {
+				return 0, err
+			}
+		}
+		return len(ids), nil
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			if err := s.enqueueEmbeddingsTx(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// DeleteKnowledgeEntry deletes the entry. Its vectors are removed by an embedding job recorded in the same transaction.
 func (s *KnowledgeService) DeleteKnowledgeEntry(id uuid.UUID) error {
 	tx := s.db.Begin()
 	defer func() {
@@ -161,83 +383,119 @@ func (s *KnowledgeService) DeleteKnowledgeEntry(id uuid.UUID) error {
 	}
 
 	// Delete the entry
-	if err := tx.Delete(&models.KnowledgeEntry{}, "id = ?", id).Error; err != nil {
+	result := tx.Delete(&models.KnowledgeEntry{}, "id = ?", id)
+	if result.Error != nil {
 		tx.Rollback()
-		return err
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return notFound(gorm.ErrRecordNotFound, "knowledge entry "+id.String())
 	}
 
-	return tx.Commit().Error
-}
-
-func (s *KnowledgeService) SearchKnowledgeEntries(ctx context.Context, query string, limit int) ([]models.KnowledgeEntry, error) {
-	// First, try vector search if we have a vector service
-	if s.vectorService != nil {
-		vectorResults, err := s.vectorService.Search(ctx, query, limit)
-		if err == nil && len(vectorResults) > 0 {
-			// Get the actual entries based on vector search results
-			var entryIDs []uuid.UUID
-			for _, result := range vectorResults {
-				entryIDs = append(entryIDs, result.KnowledgeEntryID)
-			}
-
-			var entries []models.KnowledgeEntry
-			err := s.db.Preload("Template").Preload("Creator").
-				Where("id IN ? AND is_published = true", entryIDs).
-				Find(&entries).Error
-			if err == nil {
-				return entries, nil
-			}
+	if s.jobQueue != nil {
+		if err := s.enqueueEmbeddingsTx(tx, id); err != nil {
+			tx.Rollback()
+			return err
 		}
 	}
 
-	// Fallback to text search
-	var entries []models.KnowledgeEntry
-	searchTerm := "%" + query + "%"
-	err := s.db.Preload("Template").Preload("Creator").
-		Where("is_published = true AND (title ILIKE ? OR content ILIKE ? OR summary ILIKE ?)", 
-			searchTerm, searchTerm, searchTerm).
-		Limit(limit).
-		Order("priority DESC, view_count DESC").
-		Find(&entries).Error
-
-	return entries, err
+	return tx.Commit().Error
 }
 
-func (s *KnowledgeService) createEmbeddings(ctx context.Context, tx *gorm.DB, entry *models.KnowledgeEntry) error {
-	// Combine title and content for embedding
-	fullText := entry.Title + "\n\n" + entry.Content
-	if entry.Summary != "" {
-		fullText = entry.Summary + "\n\n" + fullText
+// searchVectors embeds the query and searches the vector database within the given scope
+func (s *KnowledgeService) searchVectors(ctx context.Context, query string, limit int, scope RetrievalScope) ([]VectorSearchResult, error) {
+	embedding, err := s.embedder.CreateEmbedding(ctx, query)
+	if err != nil {
+		return nil, err
 	}
+	return s.vectorService.SearchByVectorWithFilter(ctx, embedding, limit, scope.QdrantFilter())
+}
 
-	// Chunk the text
-	chunks := s.openAIService.ChunkText(fullText, 1000)
+// createEmbeddings embeds the chunks of an entry and stores them in the vector database,
+// returning the embedding records to save
+func (s *KnowledgeService) createEmbeddings(ctx context.Context, entry *models.KnowledgeEntry) ([]models.VectorEmbedding, error) {
+	// Chunk the summary, title and content
+	chunkOptions := s.settings.ChunkOptions(ctx, s.chunkOptions)
+	if s.presets != nil {
+		chunkOptions = s.presets.ChunkOptions(entry, chunkOptions)
+	}
+	model := embeddingModelOf(s.embedder)
+	chunkOptions.Model = model
+	chunks := ChunkText(EmbeddingText(entry), chunkOptions)
+	embeddings := make([]models.VectorEmbedding, 0, len(chunks))
 
-	for i, chunk := range chunks {
+	for _, chunk := range chunks {
 		// Create embedding for this chunk
-		embedding, err := s.openAIService.CreateEmbedding(ctx, chunk)
+		embedding, err := s.embedder.CreateEmbedding(ctx, chunk.Text)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// Store in vector database and get vector ID
-		vectorID, err := s.vectorService.Store(ctx, embedding, chunk, entry.ID)
+		// Store in vector database and keep the embedding record
+		payload := entryPayload(entry)
+		payload["chunk_index"] = chunk.Index
+		payload["start_offset"] = chunk.StartOffset
+		payload["end_offset"] = chunk.EndOffset
+		record := models.VectorEmbedding{
+			KnowledgeEntryID: entry.ID,
+			ChunkIndex:       chunk.Index,
+			ChunkText:        chunk.Text,
+			StartOffset:      chunk.StartOffset,
+			EndOffset:        chunk.EndOffset,
+			TokenCount:       chunk.TokenCount,
+			EmbeddingModel:   model,
+			Dimension:        len(embedding),
+		}
+		if err := s.storeVector(ctx, &record, embedding, payload); err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, record)
+	}
+
+	// Related questions get points of their own so colloquial queries can match them
+	for i, question := range relatedQuestions(entry) {
+		embedding, err := s.embedder.CreateEmbedding(ctx, question)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// Store embedding record
-		vectorEmbedding := &models.VectorEmbedding{
+		index := len(chunks) + i
+		payload := entryPayload(entry)
+		payload["chunk_index"] = index
+		payload["kind"] = vectorKindQuestion
+		record := models.VectorEmbedding{
 			KnowledgeEntryID: entry.ID,
-			VectorID:         vectorID,
-			ChunkIndex:       i,
-			ChunkText:        chunk,
+			ChunkIndex:       index,
+			ChunkText:        question,
+			TokenCount:       CountTokens(model, question),
+			EmbeddingModel:   model,
+			Dimension:        len(embedding),
 		}
-
-		if err := tx.Create(vectorEmbedding).Error; err != nil {
-			return err
+		if err := s.storeVector(ctx, &record, embedding, payload); err != nil {
+			return nil, err
 		}
+		embeddings = append(embeddings, record)
 	}
 
+	return embeddings, nil
+}
+
+// recordVectorStore is a vector store that keeps the vectors on the embedding records themselves
+type recordVectorStore interface {
+	setRecordVector(record *models.VectorEmbedding, vector []float32, payload map[string]interface{}) error
+}
+
+// storeVector stores the vector of an embedding record. Stores that keep the vectors on the records get
+// them saved with the record; the others store a point and the record refers to it.
+func (s *KnowledgeService) storeVector(ctx context.Context, record *models.VectorEmbedding, vector []float32, payload map[string]interface{}) error {
+	if store, ok := s.vectorService.(recordVectorStore); ok {
+		return store.setRecordVector(record, vector, payload)
+	}
+	vectorID, err := s.vectorService.StoreWithPayload(ctx, vector, record.ChunkText, record.KnowledgeEntryID, payload)
+	if err != nil {
+		return err
+	}
+	record.VectorID = vectorID
 	return nil
 }
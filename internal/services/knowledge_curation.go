@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxMergeEntries          = 20
+	maxSplitSections         = 20
+	splitParagraphPreview    = 300 // Characters of each paragraph shown to the AI when proposing a split
+	maxSplitPromptParagraphs = 200
+)
+
+// splitBoundaryLine matches "<paragraph number>: <section title>" lines of a split proposal
+var splitBoundaryLine = regexp.MustCompile(`^\[?(\d+)\]?\s*[:.)|-]\s*(.+)$`)
+
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+
+// MergeEntriesRequest combines knowledge entries into one
+type MergeEntriesRequest struct {
+	EntryIDs []uuid.UUID `json:"entry_ids"`
+	TargetID *uuid.UUID  `json:"target_id,omitempty"` // Entry that is kept; defaults to the first one
+	Title    string      `json:"title,omitempty"`     // Title of the merged entry; defaults to the target's
+	UserID   uuid.UUID   `json:"user_id"`
+}
+
+// MergeResult is the entry that absorbed the others and the IDs that now redirect to it
+type MergeResult struct {
+	Entry     *models.KnowledgeEntry `json:"entry"`
+	MergedIDs []uuid.UUID            `json:"merged_ids"`
+}
+
+// SplitSection is one entry a knowledge entry is split into
+type SplitSection struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// KnowledgeCurationService merges overlapping knowledge entries and splits long ones. Entries and their
+// embedding jobs are written in one transaction.
+type KnowledgeCurationService struct {
+	db               *gorm.DB
+	knowledgeService *KnowledgeService
+	unifiedAI        *UnifiedAIService
+}
+
+// NewKnowledgeCurationService creates the curation service. unifiedAI proposes split boundaries.
+func NewKnowledgeCurationService(db *gorm.DB, knowledgeService *KnowledgeService, unifiedAI *UnifiedAIService) *KnowledgeCurationService {
+	return &KnowledgeCurationService{
+		db:               db,
+		knowledgeService: knowledgeService,
+		unifiedAI:        unifiedAI,
+	}
+}
+
+// MergeEntries appends the content of the other entries to the target under their titles, unifies their tags,
+// and deletes them. Their IDs redirect to the target afterwards. Entries with different ACLs cannot be merged,
+// as the merged entry would be visible to readers who could not see part of its content.
+func (s *KnowledgeCurationService) MergeEntries(ctx context.Context, req MergeEntriesRequest) (*MergeResult, error) {
+	ids := uniqueUUIDs(req.EntryIDs)
+	if len(ids) < 2 {
+		return nil, validationError("at least two entries are required to merge")
+	}
+	if len(ids) > maxMergeEntries {
+		return nil, validationError("at most %d entries can be merged at once", maxMergeEntries)
+	}
+	targetID := ids[0]
+	if req.TargetID != nil {
+		targetID = *req.TargetID
+	}
+
+	var target models.KnowledgeEntry
+	var merged []uuid.UUID
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entries []models.KnowledgeEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Find(&entries).Error; err != nil {
+			return err
+		}
+		byID := make(map[uuid.UUID]models.KnowledgeEntry, len(entries))
+		for _, entry := range entries {
+			byID[entry.ID] = entry
+		}
+		for _, id := range ids {
+			if _, ok := byID[id]; !ok {
+				return notFound(gorm.ErrRecordNotFound, "knowledge entry "+id.String())
+			}
+		}
+		var ok bool
+		if target, ok = byID[targetID]; !ok {
+			return validationError("target_id must be one of entry_ids")
+		}
+
+		var content strings.Builder
+		content.WriteString(target.Content)
+		tags := entryTags(target.Tags)
+		for _, id := range ids {
+			if id == targetID {
+				continue
+			}
+			source := byID[id]
+			if !sameACL(&target, &source) {
+				return validationError("entry %s has different allowed roles or teams than the target", id)
+			}
+			content.WriteString("\n\n## " + source.Title + "\n\n" + source.Content)
+			tags = append(tags, entryTags(source.Tags)...)
+			target.IsPublished = target.IsPublished || source.IsPublished
+			target.ViewCount += source.ViewCount
+			if source.Priority > target.Priority {
+				target.Priority = source.Priority
+			}
+			merged = append(merged, id)
+		}
+
+		if title := strings.TrimSpace(req.Title); title != "" {
+			target.Title = title
+		}
+		target.Content = content.String()
+		target.Tags = encodeTags(tags)
+		target.UpdatedBy = &req.UserID
+		target.StaleAt = nil
+		target.NeedsReview = false
+		target.ReviewRemindedAt = nil
+		applyReadingStats(&target)
+		applySearchText(&target)
+		if err := tx.Save(&target).Error; err != nil {
+			return err
+		}
+		if err := s.knowledgeService.enqueueEmbeddingsTx(tx, target.ID); err != nil {
+			return err
+		}
+
+		for _, id := range merged {
+			if err := s.deleteMergedTx(tx, id, target.ID, req.UserID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.embedInline(ctx, append([]uuid.UUID{target.ID}, merged...))
+	log.Printf("[INFO] User %s merged %d knowledge entries into %s", req.UserID, len(merged), target.ID)
+	return &MergeResult{Entry: &target, MergedIDs: merged}, nil
+}
+
+// deleteMergedTx deletes an entry merged into target and redirects its ID, and any ID redirecting to it, to target
+func (s *KnowledgeCurationService) deleteMergedTx(tx *gorm.DB, id, targetID, userID uuid.UUID) error {
+	if err := tx.Where("knowledge_entry_id = ?", id).Delete(&models.VectorEmbedding{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&models.KnowledgeEntry{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := s.knowledgeService.enqueueEmbeddingsTx(tx, id); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.KnowledgeEntryRedirect{}).Where("to_id = ?", id).Update("to_id", targetID).Error; err != nil {
+		return err
+	}
+	redirect := models.KnowledgeEntryRedirect{FromID: id, ToID: targetID, CreatedBy: userID}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"to_id", "created_by"}),
+	}).Create(&redirect).Error
+}
+
+// ProposeSplit asks the AI where the sections of an entry begin and returns the sections it proposes.
+// Sections consist of whole paragraphs of the entry, so no content is rewritten or lost.
+func (s *KnowledgeCurationService) ProposeSplit(ctx context.Context, id uuid.UUID) ([]SplitSection, error) {
+	var entry models.KnowledgeEntry
+	if err := s.db.WithContext(ctx).First(&entry, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "knowledge entry "+id.String())
+	}
+	paragraphs := splitParagraphs(entry.Content)
+	if len(paragraphs) < 2 {
+		return nil, validationError("the entry has a single paragraph and cannot be split")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Here is an article from our internal knowledge base, titled %q, as numbered paragraphs.\n\n", entry.Title))
+	for i, paragraph := range paragraphs {
+		if i == maxSplitPromptParagraphs {
+			break
+		}
+		if len(paragraph) > splitParagraphPreview {
+			paragraph = paragraph[:splitParagraphPreview] + "..."
+		}
+		prompt.WriteString(fmt.Sprintf("[%d] %s\n\n", i+1, paragraph))
+	}
+	prompt.WriteString(fmt.Sprintf("The article covers too much for one entry. Split it into 2 to %d self-contained articles "+
+		"on distinct subjects. Reply with one line per article in the form \"<number of its first paragraph>: <title>\", "+
+		"starting with paragraph 1, without any other text.", maxSplitSections))
+
+	resp, err := s.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages: []UnifiedChatMessage{{Role: "user", Content: prompt.String()}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sections := splitAtBoundaries(paragraphs, parseSplitBoundaries(resp.Message, len(paragraphs)))
+	if len(sections) < 2 {
+		return nil, validationError("no split was proposed for the entry")
+	}
+	return sections, nil
+}
+
+// SplitEntry replaces an entry with the sections. The entry keeps its ID and becomes the first section;
+// the other sections become new entries with the same category, tags, ACL, publication state and creator.
+func (s *KnowledgeCurationService) SplitEntry(ctx context.Context, id uuid.UUID, sections []SplitSection, userID uuid.UUID) ([]models.KnowledgeEntry, error) {
+	if len(sections) < 2 {
+		return nil, validationError("at least two sections are required to split an entry")
+	}
+	if len(sections) > maxSplitSections {
+		return nil, validationError("an entry can be split into at most %d sections", maxSplitSections)
+	}
+	for i := range sections {
+		sections[i].Title = strings.TrimSpace(sections[i].Title)
+		sections[i].Content = strings.TrimSpace(sections[i].Content)
+		if sections[i].Title == "" || sections[i].Content == "" {
+			return nil, validationError("section %d needs a title and content", i+1)
+		}
+	}
+
+	var entries []models.KnowledgeEntry
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var original models.KnowledgeEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, "id = ?", id).Error; err != nil {
+			return notFound(err, "knowledge entry "+id.String())
+		}
+
+		entries = make([]models.KnowledgeEntry, len(sections))
+		for i, section := range sections {
+			entry := original
+			if i > 0 {
+				entry = models.KnowledgeEntry{
+					Category:     original.Category,
+					Tags:         original.Tags,
+					Language:     original.Language,
+					FieldData:    "{}",
+					IsPublished:  original.IsPublished,
+					AllowedRoles: original.AllowedRoles,
+					AllowedTeams: original.AllowedTeams,
+					Priority:     original.Priority,
+					CreatedBy:    original.CreatedBy,
+				}
+			}
+			entry.Title = section.Title
+			entry.Content = section.Content
+			entry.Summary = ""
+			entry.UpdatedBy = &userID
+			entry.StaleAt = nil
+			entry.NeedsReview = false
+			entry.ReviewRemindedAt = nil
+			applyReadingStats(&entry)
+			applySearchText(&entry)
+
+			if i == 0 {
+				if err := tx.Save(&entry).Error; err != nil {
+					return err
+				}
+			} else {
+				// Select keeps a false is_published instead of the column default
+				entry.ID = uuid.New()
+				if err := tx.Select("*").Omit("Template", "Creator", "Updater").Create(&entry).Error; err != nil {
+					return err
+				}
+			}
+			if err := s.knowledgeService.enqueueEmbeddingsTx(tx, entry.ID); err != nil {
+				return err
+			}
+			entries[i] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	s.embedInline(ctx, ids)
+	log.Printf("[INFO] User %s split knowledge entry %s into %d entries", userID, id, len(entries))
+	return entries, nil
+}
+
+// embedInline generates embeddings after the commit when there is no job queue to run the jobs enqueued with the change
+func (s *KnowledgeCurationService) embedInline(ctx context.Context, ids []uuid.UUID) {
+	if s.knowledgeService.jobQueue != nil {
+		return
+	}
+	for _, id := range ids {
+		if err := s.knowledgeService.GenerateEmbeddings(ctx, id); err != nil {
+			log.Printf("[WARNING] Failed to update embeddings of knowledge entry %s: %v", id, err)
+		}
+	}
+}
+
+// ResolveRedirect returns the entry an ID merged into another entry now points at
+func (s *KnowledgeService) ResolveRedirect(id uuid.UUID) (uuid.UUID, bool) {
+	var redirect models.KnowledgeEntryRedirect
+	if err := s.db.First(&redirect, "from_id = ?", id).Error; err != nil {
+		return uuid.Nil, false
+	}
+	return redirect.ToID, true
+}
+
+func sameACL(a, b *models.KnowledgeEntry) bool {
+	return strings.Join(sortedCommaList(a.AllowedRoles), ",") == strings.Join(sortedCommaList(b.AllowedRoles), ",") &&
+		strings.Join(sortedCommaList(a.AllowedTeams), ",") == strings.Join(sortedCommaList(b.AllowedTeams), ",")
+}
+
+func sortedCommaList(value string) []string {
+	items := splitCommaList(value)
+	sort.Strings(items)
+	return items
+}
+
+// entryTags reads the tags of an entry, stored as a JSON array or, by older imports, as a comma-separated list
+func entryTags(raw string) []string {
+	var tags []string
+	if err := json.Unmarshal([]byte(raw), &tags); err == nil {
+		return tags
+	}
+	return splitCommaList(raw)
+}
+
+// encodeTags stores tags as a JSON array, dropping duplicates regardless of case
+func encodeTags(tags []string) string {
+	seen := make(map[string]bool, len(tags))
+	unique := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, tag)
+	}
+	encoded, _ := json.Marshal(unique)
+	return string(encoded)
+}
+
+// splitParagraphs splits content on blank lines
+func splitParagraphs(content string) []string {
+	var paragraphs []string
+	for _, paragraph := range blankLines.Split(strings.ReplaceAll(content, "\r\n", "\n"), -1) {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
+
+type splitBoundary struct {
+	start int // Index of the first paragraph
+	title string
+}
+
+// parseSplitBoundaries reads the "<paragraph>: <title>" lines of a split proposal, ordered by paragraph.
+// The first section always starts at the first paragraph.
+func parseSplitBoundaries(answer string, paragraphs int) []splitBoundary {
+	seen := make(map[int]bool)
+	var boundaries []splitBoundary
+	for _, line := range strings.Split(answer, "\n") {
+		match := splitBoundaryLine.FindStringSubmatch(strings.TrimSpace(strings.TrimLeft(line, "-*• ")))
+		if match == nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 || number > paragraphs || seen[number] {
+			continue
+		}
+		seen[number] = true
+		boundaries = append(boundaries, splitBoundary{start: number - 1, title: strings.Trim(strings.TrimSpace(match[2]), `"`)})
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].start < boundaries[j].start })
+	if len(boundaries) > maxSplitSections {
+		boundaries = boundaries[:maxSplitSections]
+	}
+	if len(boundaries) > 0 {
+		boundaries[0].start = 0
+	}
+	return boundaries
+}
+
+func splitAtBoundaries(paragraphs []string, boundaries []splitBoundary) []SplitSection {
+	sections := make([]SplitSection, 0, len(boundaries))
+	for i, boundary := range boundaries {
+		end := len(paragraphs)
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1].start
+		}
+		sections = append(sections, SplitSection{
+			Title:   boundary.title,
+			Content: strings.Join(paragraphs[boundary.start:end], "\n\n"),
+		})
+	}
+	return sections
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// searchFacetLimit bounds the values returned per facet
+const searchFacetLimit = 20
+
+// entryTagsSQL expands the tags of an entry, stored as a JSON array or a comma-separated list, into rows
+const entryTagsSQL = "jsonb_array_elements_text(CASE WHEN knowledge_entries.tags LIKE '[%' THEN knowledge_entries.tags::jsonb " +
+	"ELSE to_jsonb(string_to_array(coalesce(knowledge_entries.tags, ''), ',')) END)"
+
+// searchHeadlineOptions marks matched terms in snippets
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// FullTextQuery is a full-text search of the published knowledge entries
+type FullTextQuery struct {
+	Query      string
+	Category   string
+	Tag        string
+	TemplateID *uuid.UUID
+	Reading    ReadingFilter
+	Scope      RetrievalScope
+	Highlight  bool // Return a snippet of the content with the matched terms marked
+	Limit      int
+	Offset     int
+}
+
+// FullTextHit is an entry matching a full-text search
+type FullTextHit struct {
+	Entry   models.KnowledgeEntry `json:"entry"`
+	Rank    float64               `json:"rank"`
+	Snippet string                `json:"snippet,omitempty"` // Matched terms are wrapped in <mark></mark>
+}
+
+// FacetCount is the number of hits sharing a facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// SearchFacets counts the hits of a search per category, tag and template
+type SearchFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Tags       []FacetCount `json:"tags"`
+	Templates  []FacetCount `json:"templates"`
+}
+
+// FullTextResult is a page of hits with the total hit count and the facets of every hit
+type FullTextResult struct {
+	Hits   []FullTextHit `json:"hits"`
+	Facets SearchFacets  `json:"facets"`
+	Total  int64         `json:"-"`
+}
+
+// FullTextSearch searches the published entries the scope may see with PostgreSQL full-text search, ranking
+// title matches above summary, content and related question matches. The query accepts web search syntax:
+// quoted phrases, OR, and -excluded terms.
+func (s *KnowledgeService) FullTextSearch(ctx context.Context, q FullTextQuery) (*FullTextResult, error) {
+	if strings.TrimSpace(q.Query) == "" {
+		return nil, validationError("query is required")
+	}
+	tsQuery := gorm.Expr("websearch_to_tsquery('english', ?)", q.Query)
+	base := q.Scope.Apply(q.Reading.Apply(readDB(s.reads, s.db).WithContext(ctx).Model(&models.KnowledgeEntry{}))).
+		Where("is_published = true").
+		Where("("+models.KnowledgeSearchDocument+") @@ ?", tsQuery)
+	if q.Category != "" {
+		base = base.Where("category = ?", q.Category)
+	}
+	if q.TemplateID != nil {
+		base = base.Where("template_id = ?", *q.TemplateID)
+	}
+	if q.Tag != "" {
+		base = base.Where("EXISTS (SELECT 1 FROM "+entryTagsSQL+" AS tag(value) WHERE lower(trim(tag.value)) = lower(?))", strings.TrimSpace(q.Tag))
+	}
+
+	result := &FullTextResult{Hits: []FullTextHit{}}
+	if err := base.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+	if result.Total == 0 {
+		result.Facets = SearchFacets{Categories: []FacetCount{}, Tags: []FacetCount{}, Templates: []FacetCount{}}
+		return result, nil
+	}
+
+	var rows []struct {
+		ID      uuid.UUID
+		Rank    float64
+		Snippet string
+	}
+	columns := "knowledge_entries.id, ts_rank(" + models.KnowledgeSearchDocument + ", ?) AS rank"
+	args := []interface{}{tsQuery}
+	if q.Highlight {
+		columns += ", ts_headline('english', knowledge_entries.content, ?, ?) AS snippet"
+		args = append(args, tsQuery, searchHeadlineOptions)
+	}
+	err := base.Session(&gorm.Session{}).
+		Select(columns, args...).
+		Order("rank DESC, priority DESC, knowledge_entries.id").
+		Limit(q.Limit).Offset(q.Offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var entries []models.KnowledgeEntry
+	if err := readDB(s.reads, s.db).Preload("Template").Preload("Creator").Where("id IN ?", ids).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.KnowledgeEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+	for _, row := range rows {
+		if entry, ok := byID[row.ID]; ok {
+			result.Hits = append(result.Hits, FullTextHit{Entry: entry, Rank: row.Rank, Snippet: row.Snippet})
+		}
+	}
+
+	if result.Facets, err = s.searchFacets(base); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// searchFacets counts the hits of a search query per category, tag and template, most frequent first
+func (s *KnowledgeService) searchFacets(base *gorm.DB) (SearchFacets, error) {
+	facets := SearchFacets{Categories: []FacetCount{}, Tags: []FacetCount{}, Templates: []FacetCount{}}
+
+	err := base.Session(&gorm.Session{}).
+		Select("category AS value, count(*) AS count").
+		Group("category").Order("count DESC, value").Limit(searchFacetLimit).
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return facets, err
+	}
+
+	err = base.Session(&gorm.Session{}).
+		Joins("CROSS JOIN LATERAL " + entryTagsSQL + " AS tag(value)").
+		Where("trim(tag.value) <> ''").
+		Select("lower(trim(tag.value)) AS value, count(DISTINCT knowledge_entries.id) AS count").
+		Group("lower(trim(tag.value))").Order("count DESC, value").Limit(searchFacetLimit).
+		Scan(&facets.Tags).Error
+	if err != nil {
+		return facets, err
+	}
+
+	err = base.Session(&gorm.Session{}).
+		Joins("JOIN templates ON templates.id = knowledge_entries.template_id").
+		Select("knowledge_entries.template_id::text AS value, templates.name AS label, count(*) AS count").
+		Group("knowledge_entries.template_id, templates.name").Order("count DESC, label").Limit(searchFacetLimit).
+		Scan(&facets.Templates).Error
+	return facets, err
+}
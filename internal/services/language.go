@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+
+	"tic-knowledge-system/internal/models"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"gorm.io/gorm"
+)
+
+const (
+	languageSampleRunes     = 2000 // Detection reads the start of the text only
+	languageMinStopwords    = 2    // Latin-script text needs this many stopword hits to be attributed
+	translationCacheEntries = 500
+)
+
+// languageScripts maps writing systems used by a single language to that language. Han is handled
+// separately because Japanese text mixes it with kana.
+var languageScripts = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// languageStopwords are frequent function words of the Latin-script languages told apart by DetectLanguage
+var languageStopwords = map[string]map[string]bool{
+	"en": wordSet("the and is are to of in that it for you with on this be how what do can i not"),
+	"es": wordSet("el la los las de que y en es por un una para con no se del cómo qué está son"),
+	"fr": wordSet("le la les de des et est un une pour que qui dans pas du je vous comment sur avec"),
+	"de": wordSet("der die das und ist nicht ein eine zu den mit von ich sie es wie auf für dem im"),
+	"it": wordSet("il lo la di che è un una per non con del della sono come gli le mi ho nel"),
+	"pt": wordSet("o os as de que do da em um uma para com não é no na como você são dos"),
+	"nl": wordSet("de het een en van is dat niet ik op te zijn voor met je hoe wat er die naar"),
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// DetectLanguage guesses the ISO 639-1 code of the language of a text from its script and, for Latin
+// script, its most frequent function words. It returns "" when the text is too short or ambiguous.
+func DetectLanguage(text string) string {
+	runes := []rune(text)
+	if len(runes) > languageSampleRunes {
+		runes = runes[:languageSampleRunes]
+	}
+
+	var letters, latin, han, kana int
+	scripts := map[string]int{}
+	for _, r := range runes {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for _, script := range languageScripts {
+				if unicode.Is(script.table, r) {
+					scripts[script.code]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Text in another script often quotes Latin product names, so a third of the letters is enough
+	switch {
+	case kana > 0 && (han+kana)*3 >= letters:
+		return "ja"
+	case han*3 >= letters:
+		return "zh"
+	}
+	for _, script := range languageScripts {
+		if scripts[script.code]*3 >= letters {
+			return script.code
+		}
+	}
+	if latin*2 < letters {
+		return ""
+	}
+
+	hits := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(string(runes)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		for code, stopwords := range languageStopwords {
+			if stopwords[word] {
+				hits[code]++
+			}
+		}
+	}
+	best, bestHits, ties := "", 0, false
+	for code, count := range hits {
+		switch {
+		case count > bestHits:
+			best, bestHits, ties = code, count, false
+		case count == bestHits:
+			ties = true
+		}
+	}
+	if bestHits < languageMinStopwords || ties {
+		return ""
+	}
+	return best
+}
+
+// NormalizeLanguage turns a language tag such as "fr-CA" or "French" into its ISO 639-1 code
+func NormalizeLanguage(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", nil
+	}
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		for _, candidate := range display.Supported.Tags() {
+			if strings.EqualFold(display.English.Languages().Name(candidate), tag) {
+				parsed, err = candidate, nil
+				break
+			}
+		}
+	}
+	if err != nil {
+		return "", validationError("unknown language %q", tag)
+	}
+	base, _ := parsed.Base()
+	if base.String() == "und" {
+		return "", validationError("unknown language %q", tag)
+	}
+	return base.String(), nil
+}
+
+// LanguageName returns the English name of a language code, e.g. "German" for "de"
+func LanguageName(code string) string {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return code
+	}
+	if name := display.English.Languages().Name(tag); name != "" {
+		return name
+	}
+	return code
+}
+
+// applyLanguage detects the language of an entry that does not name one, and normalizes the one it names
+func applyLanguage(entry *models.KnowledgeEntry) {
+	if entry.Language != "" {
+		if code, err := NormalizeLanguage(entry.Language); err == nil {
+			entry.Language = code
+			return
+		}
+	}
+	entry.Language = DetectLanguage(entry.Title + ".\n" + entry.Content)
+}
+
+// BackfillLanguages detects the language of entries saved before languages were recorded
+func (s *KnowledgeService) BackfillLanguages(ctx context.Context) (int, error) {
+	updated := 0
+	var entries []models.KnowledgeEntry
+	err := s.db.WithContext(ctx).Select("id", "title", "content").
+		Where("language IS NULL").
+		FindInBatches(&entries, readingStatsBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range entries {
+				applyLanguage(&entries[i])
+				err := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("id = ?", entries[i].ID).
+					UpdateColumn("language", entries[i].Language).Error
+				if err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if updated > 0 {
+		log.Printf("[INFO] Detected the language of %d knowledge entries", updated)
+	}
+	return updated, err
+}
+
+// TranslationService translates retrieved knowledge and answers into the language of the user through the
+// AI provider. Translations are cached in memory since the same entries are retrieved again and again.
+type TranslationService struct {
+	unifiedAI *UnifiedAIService
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewTranslationService creates a translation service
+func NewTranslationService(unifiedAI *UnifiedAIService) *TranslationService {
+	return &TranslationService{unifiedAI: unifiedAI, cache: map[string]string{}}
+}
+
+// Translate translates text into the language of the given code
+func (t *TranslationService) Translate(ctx context.Context, text, target string) (string, error) {
+	sum := sha256.Sum256([]byte(target + "\x00" + text))
+	key := hex.EncodeToString(sum[:])
+	t.mu.Lock()
+	cached, ok := t.cache[key]
+	t.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	prompt := fmt.Sprintf("Translate the text below into %s. Keep Markdown formatting, code, numbers, product names "+
+		"and citation markers such as [1] unchanged. Reply with the translation only.\n\n%s", LanguageName(target), text)
+	resp, err := t.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages: []UnifiedChatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+	translation := strings.TrimSpace(resp.Message)
+
+	t.mu.Lock()
+	if len(t.cache) >= translationCacheEntries {
+		t.cache = map[string]string{}
+	}
+	t.cache[key] = translation
+	t.mu.Unlock()
+	return translation, nil
+}
+
+// LocalizeContext translates the context of the entries written in another language than target;
+// context[i] belongs to entries[i]. Entries whose translation fails keep their original text.
+func (t *TranslationService) LocalizeContext(ctx context.Context, entries []models.KnowledgeEntry, context []string, target string) []string {
+	if t == nil || target == "" {
+		return context
+	}
+	localized := make([]string, len(context))
+	copy(localized, context)
+	for i, entry := range entries {
+		if i >= len(localized) || entry.Language == "" || entry.Language == target {
+			continue
+		}
+		translation, err := t.Translate(ctx, localized[i], target)
+		if err != nil {
+			log.Printf("[WARNING] Failed to translate knowledge entry %s from %s to %s: %v", entry.ID, entry.Language, target, err)
+			continue
+		}
+		localized[i] = translation
+	}
+	return localized
+}
+
+// LocalizeAnswer translates an answer that came back in another language than target. Answers in the
+// right language, or whose language cannot be told, are returned unchanged, as is the answer when
+// translation fails.
+func (t *TranslationService) LocalizeAnswer(ctx context.Context, answer, target string) string {
+	if t == nil || target == "" {
+		return answer
+	}
+	detected := DetectLanguage(answer)
+	if detected == "" || detected == target {
+		return answer
+	}
+	translation, err := t.Translate(ctx, answer, target)
+	if err != nil {
+		log.Printf("[WARNING] Failed to translate answer from %s to %s: %v", detected, target, err)
+		return answer
+	}
+	return translation
+}
+
+// chatLanguage returns the language an answer must be given in: the requested one, else the language
+// of the message
+func chatLanguage(requested, message string) (string, error) {
+	if requested != "" {
+		return NormalizeLanguage(requested)
+	}
+	return DetectLanguage(message), nil
+}
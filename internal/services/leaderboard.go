@@ -0,0 +1,215 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Leaderboard sort keys
+const (
+	LeaderboardSortEntries     = "entries"
+	LeaderboardSortViews       = "views"
+	LeaderboardSortHelpfulRate = "helpful_rate"
+)
+
+// Badge thresholds
+const (
+	prolificAuthorEntries   = 10
+	topContributorEntries   = 50
+	popularAuthorViews      = 1000
+	helpfulGuideRate        = 0.8
+	helpfulGuideMinFeedback = 10
+)
+
+// LeaderboardService computes knowledge contribution statistics per author
+type LeaderboardService struct {
+	db    *gorm.DB
+	reads ReadReplicaRouter
+}
+
+// NewLeaderboardService creates a new leaderboard service. reads may be nil to query the primary.
+func NewLeaderboardService(db *gorm.DB, reads ReadReplicaRouter) *LeaderboardService {
+	return &LeaderboardService{db: db, reads: reads}
+}
+
+// ContributorStats summarizes the contributions of a single author
+type ContributorStats struct {
+	UserID           uuid.UUID `json:"user_id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	EntriesPublished int64     `json:"entries_published"`
+	EntriesTotal     int64     `json:"entries_total"`
+	ViewsGenerated   int64     `json:"views_generated"`
+	FeedbackCount    int64     `json:"feedback_count"`
+	HelpfulCount     int64     `json:"helpful_count"`
+	HelpfulRate      float64   `json:"helpful_rate"`
+	Badges           []string  `json:"badges,omitempty"`
+	Rank             int       `json:"rank"`
+}
+
+// LeaderboardQuery configures a leaderboard request
+type LeaderboardQuery struct {
+	SortBy        string
+	Since         *time.Time
+	Limit         int
+	IncludeBadges bool
+}
+
+type feedbackStatsRow struct {
+	UserID        uuid.UUID
+	FeedbackCount int64
+	HelpfulCount  int64
+}
+
+// GetLeaderboard returns authors ranked by the requested metric
+func (s *LeaderboardService) GetLeaderboard(query LeaderboardQuery) ([]ContributorStats, error) {
+	stats, err := s.collectStats(nil, query.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	sortContributors(stats, query.SortBy)
+	for i := range stats {
+		stats[i].Rank = i + 1
+		if query.IncludeBadges {
+			stats[i].Badges = badgesFor(stats[i])
+		}
+	}
+
+	if query.Limit > 0 && len(stats) > query.Limit {
+		stats = stats[:query.Limit]
+	}
+
+	log.Printf("[INFO] Built contribution leaderboard with %d authors (sort=%s)", len(stats), query.SortBy)
+	return stats, nil
+}
+
+// GetContributorStats returns the statistics and badges of a single author
+func (s *LeaderboardService) GetContributorStats(userID uuid.UUID) (*ContributorStats, error) {
+	stats, err := s.collectStats(&userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	contributor := stats[0]
+	contributor.Badges = badgesFor(contributor)
+	return &contributor, nil
+}
+
+// collectStats aggregates entry and feedback statistics per author
+func (s *LeaderboardService) collectStats(userID *uuid.UUID, since *time.Time) ([]ContributorStats, error) {
+	var stats []ContributorStats
+	conn := readDB(s.reads, s.db)
+
+	entryQuery := conn.Table("users AS u").
+		Select(`u.id AS user_id, u.name, u.email,
+			COUNT(ke.id) FILTER (WHERE ke.is_published) AS entries_published,
+			COUNT(ke.id) AS entries_total,
+			COALESCE(SUM(ke.view_count), 0) AS views_generated`).
+		Joins("JOIN knowledge_entries AS ke ON ke.created_by = u.id AND ke.deleted_at IS NULL").
+		Where("u.deleted_at IS NULL").
+		Group("u.id, u.name, u.email")
+	if userID != nil {
+		entryQuery = entryQuery.Where("u.id = ?", *userID)
+	}
+	if since != nil {
+		entryQuery = entryQuery.Where("ke.created_at >= ?", *since)
+	}
+	if err := entryQuery.Scan(&stats).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate contributor entry stats: %v", err)
+		return nil, err
+	}
+
+	// Feedback is attributed to the authors of the entries cited as sources of the rated answer
+	var feedbackRows []feedbackStatsRow
+	feedbackQuery := conn.Table("feedbacks AS f").
+		Select(`ke.created_by AS user_id,
+			COUNT(*) AS feedback_count,
+			COUNT(*) FILTER (WHERE f.type = 'helpful') AS helpful_count`).
+		Joins("JOIN chat_messages AS cm ON cm.id = f.message_id").
+		Joins(`CROSS JOIN LATERAL jsonb_array_elements_text(
+			CASE WHEN jsonb_typeof(cm.metadata->'sources') = 'array' THEN cm.metadata->'sources' ELSE '[]'::jsonb END
+		) AS src(entry_id)`).
+		Joins("JOIN knowledge_entries AS ke ON ke.id::text = src.entry_id").
+		Where("f.deleted_at IS NULL").
+		Group("ke.created_by")
+	if userID != nil {
+		feedbackQuery = feedbackQuery.Where("ke.created_by = ?", *userID)
+	}
+	if since != nil {
+		feedbackQuery = feedbackQuery.Where("f.created_at >= ?", *since)
+	}
+	if err := feedbackQuery.Scan(&feedbackRows).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate contributor feedback stats: %v", err)
+		return nil, err
+	}
+
+	feedbackByUser := make(map[uuid.UUID]feedbackStatsRow, len(feedbackRows))
+	for _, row := range feedbackRows {
+		feedbackByUser[row.UserID] = row
+	}
+
+	for i := range stats {
+		if row, ok := feedbackByUser[stats[i].UserID]; ok {
+			stats[i].FeedbackCount = row.FeedbackCount
+			stats[i].HelpfulCount = row.HelpfulCount
+			if row.FeedbackCount > 0 {
+				stats[i].HelpfulRate = float64(row.HelpfulCount) / float64(row.FeedbackCount)
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// sortContributors orders contributors by the requested metric, highest first
+func sortContributors(stats []ContributorStats, sortBy string) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		switch sortBy {
+		case LeaderboardSortViews:
+			if a.ViewsGenerated != b.ViewsGenerated {
+				return a.ViewsGenerated > b.ViewsGenerated
+			}
+		case LeaderboardSortHelpfulRate:
+			if a.HelpfulRate != b.HelpfulRate {
+				return a.HelpfulRate > b.HelpfulRate
+			}
+			if a.FeedbackCount != b.FeedbackCount {
+				return a.FeedbackCount > b.FeedbackCount
+			}
+		}
+		if a.EntriesPublished != b.EntriesPublished {
+			return a.EntriesPublished > b.EntriesPublished
+		}
+		return a.ViewsGenerated > b.ViewsGenerated
+	})
+}
+
+// badgesFor awards badges based on contribution milestones
+func badgesFor(stats ContributorStats) []string {
+	var badges []string
+	if stats.EntriesPublished >= 1 {
+		badges = append(badges, "first_entry")
+	}
+	if stats.EntriesPublished >= prolificAuthorEntries {
+		badges = append(badges, "prolific_author")
+	}
+	if stats.EntriesPublished >= topContributorEntries {
+		badges = append(badges, "top_contributor")
+	}
+	if stats.ViewsGenerated >= popularAuthorViews {
+		badges = append(badges, "popular_author")
+	}
+	if stats.FeedbackCount >= helpfulGuideMinFeedback && stats.HelpfulRate >= helpfulGuideRate {
+		badges = append(badges, "helpful_guide")
+	}
+	return badges
+}
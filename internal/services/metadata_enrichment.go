@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	enrichmentMaxTags      = 6
+	enrichmentContentChars = 4000
+)
+
+// EntryMetadata is the metadata the AI writes for a section of an imported document
+type EntryMetadata struct {
+	Tags     []string `json:"tags"`
+	Category string   `json:"category"` // Suggested category, preferably an existing one
+	Summary  string   `json:"summary"`
+}
+
+// MetadataEnricher asks the AI for the tags, a category suggestion and a summary of each section of an
+// imported document, in place of the generic section tags
+type MetadataEnricher struct {
+	db        *gorm.DB
+	unifiedAI *UnifiedAIService
+}
+
+// NewMetadataEnricher creates a metadata enricher
+func NewMetadataEnricher(db *gorm.DB, unifiedAI *UnifiedAIService) *MetadataEnricher {
+	return &MetadataEnricher{db: db, unifiedAI: unifiedAI}
+}
+
+// Enrich returns the metadata of a section titled title, suggesting one of categories when it fits
+func (e *MetadataEnricher) Enrich(ctx context.Context, title, content string, categories []string) (*EntryMetadata, error) {
+	prompt := fmt.Sprintf(`Here is a section of a document imported into our internal knowledge base.
+Reply with JSON only: {"tags": ["<tag>", ...], "category": "<short category name>", "summary": "<summary>"}
+Give 3 to %d short lowercase tags naming the topics of the section, a category for it, preferring one of these
+existing categories when it fits: %s
+and a summary of one or two sentences.
+
+Title: %s
+
+%s`, enrichmentMaxTags, strings.Join(categories, ", "), title, utils.TruncateString(content, enrichmentContentChars))
+
+	resp, err := e.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages: []UnifiedChatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw := strings.TrimSpace(resp.Message)
+	if start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}"); start >= 0 && end > start {
+		raw = raw[start : end+1]
+	}
+	var metadata EntryMetadata
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+		return nil, fmt.Errorf("unparseable metadata %q", utils.TruncateString(resp.Message, 200))
+	}
+
+	var tags []string
+	for _, tag := range metadata.Tags {
+		// Tags are stored comma-separated
+		tag = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")))
+		if tag != "" && !utils.SliceContains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > enrichmentMaxTags {
+		tags = tags[:enrichmentMaxTags]
+	}
+	metadata.Tags = tags
+	metadata.Category = strings.TrimSpace(metadata.Category)
+	metadata.Summary = strings.TrimSpace(metadata.Summary)
+	return &metadata, nil
+}
+
+// categories returns the categories in use, for the AI to suggest from
+func (e *MetadataEnricher) categories(ctx context.Context) []string {
+	var categories []string
+	if err := e.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Distinct("category").Limit(50).Pluck("category", &categories).Error; err != nil {
+		log.Printf("[WARNING] Failed to list categories for metadata enrichment: %v", err)
+	}
+	return categories
+}
+
+// SetMetadataEnricher makes document imports tag, summarize and suggest a category for each section with
+// the AI. Without it sections get generic tags only.
+func (s *IngestionService) SetMetadataEnricher(enricher *MetadataEnricher) {
+	s.enricher = enricher
+}
+
+// enrichEntries replaces the generic tags of proposed entries with the AI's, and adds its summary and
+// category suggestion. Sections the AI fails on keep their generic tags; the import goes ahead regardless.
+func (s *IngestionService) enrichEntries(ctx context.Context, entries []ProposedEntry) {
+	if s.enricher == nil || len(entries) == 0 {
+		return
+	}
+	categories := s.enricher.categories(ctx)
+	enriched := 0
+	for i := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		entry := &entries[i]
+		metadata, err := s.enricher.Enrich(ctx, entry.Title, entry.Content, categories)
+		if err != nil {
+			log.Printf("[WARNING] Failed to enrich the metadata of section %q: %v", entry.Title, err)
+			continue
+		}
+		if len(metadata.Tags) > 0 {
+			entry.Tags = strings.Join(append([]string{"document"}, metadata.Tags...), ",")
+		}
+		entry.Summary = metadata.Summary
+		if metadata.Category != entry.Category {
+			entry.SuggestedCategory = metadata.Category
+		}
+		enriched++
+	}
+	log.Printf("[INFO] Enriched the metadata of %d of %d sections", enriched, len(entries))
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Notifier delivers a message to a user out of band, e.g. by email
+type Notifier interface {
+	Notify(ctx context.Context, to, subject, body string) error
+}
+
+// SMTPNotifier sends notifications as plain-text emails
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier creates an SMTP notifier. Authentication is skipped when username is empty.
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{
+		addr: host + ":" + port,
+		auth: auth,
+		from: from,
+	}
+}
+
+// Notify sends an email to the recipient
+func (n *SMTPNotifier) Notify(ctx context.Context, to, subject, body string) error {
+	if to == "" {
+		return fmt.Errorf("no recipient address")
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + n.from + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to, err)
+	}
+	log.Printf("[INFO] Sent email notification to %s: %s", to, subject)
+	return nil
+}
+
+// LogNotifier logs notifications instead of delivering them; used when no mail server is configured
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(ctx context.Context, to, subject, body string) error {
+	log.Printf("[INFO] Notification for %s (email delivery not configured): %s", to, subject)
+	return nil
+}
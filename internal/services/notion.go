@@ -0,0 +1,626 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeNotionSync is the job type of a Notion sync
+const JobTypeNotionSync = "notion_sync"
+
+// NotionSyncTriggerManual is the trigger of syncs queued on request
+const NotionSyncTriggerManual = "manual"
+
+const (
+	notionAPIURL          = "https://api.notion.com/v1"
+	notionAPIVersion      = "2022-06-28"
+	notionPageSize        = 100
+	notionMaxBlockDepth   = 3 // Nested blocks below this depth are left out
+	notionMaxRetries      = 3 // Rate limited requests are retried after the Retry-After delay
+	notionSyncEmail       = "notion-sync@integrations.local"
+	defaultNotionCategory = "Notion"
+)
+
+// NotionConfig connects the Notion integration. Without database IDs every page shared with the integration is synced.
+type NotionConfig struct {
+	APIToken    string // Internal integration secret
+	DatabaseIDs []string
+	Category    string // Category of the synced entries
+}
+
+// NotionSyncService syncs Notion pages into knowledge entries, one entry per heading section. Pages are compared by
+// their last edit time, so only new and edited pages are fetched again; pages removed or no longer shared lose their entries.
+type NotionSyncService struct {
+	db         *gorm.DB
+	knowledge  *KnowledgeService
+	jobQueue   *JobQueue
+	config     NotionConfig
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NotionSyncReport describes what a sync changed
+type NotionSyncReport struct {
+	Pages       int       `json:"pages"`
+	Created     int       `json:"created"`
+	Updated     int       `json:"updated"`
+	Unchanged   int       `json:"unchanged"`
+	Deleted     int       `json:"deleted"`
+	Failed      int       `json:"failed"`
+	Errors      []string  `json:"errors,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// NotionStatus describes the integration and the pages synced so far
+type NotionStatus struct {
+	Enabled      bool       `json:"enabled"`
+	DatabaseIDs  []string   `json:"database_ids,omitempty"` // Empty syncs every page shared with the integration
+	Category     string     `json:"category,omitempty"`
+	SyncedPages  int64      `json:"synced_pages"`
+	FailedPages  int64      `json:"failed_pages"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+}
+
+type notionSyncPayload struct {
+	Trigger string `json:"trigger"`
+}
+
+// notionPage is a page object of the Notion API. Only its title property is read.
+type notionPage struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	LastEditedTime time.Time `json:"last_edited_time"`
+	Archived       bool      `json:"archived"`
+	InTrash        bool      `json:"in_trash"`
+	Properties     map[string]struct {
+		Type  string           `json:"type"`
+		Title []notionRichText `json:"title"`
+	} `json:"properties"`
+
+	databaseID string
+}
+
+func (p notionPage) title() string {
+	for _, property := range p.Properties {
+		if property.Type == "title" {
+			if title := strings.TrimSpace(plainText(property.Title)); title != "" {
+				return title
+			}
+		}
+	}
+	return "Untitled"
+}
+
+type notionRichText struct {
+	PlainText string `json:"plain_text"`
+}
+
+func plainText(parts []notionRichText) string {
+	var text strings.Builder
+	for _, part := range parts {
+		text.WriteString(part.PlainText)
+	}
+	return text.String()
+}
+
+// notionBlock is a block object. The content sits under a key named after the block type.
+type notionBlock struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+	content     notionBlockContent
+	children    []notionBlock
+}
+
+type notionBlockContent struct {
+	RichText []notionRichText   `json:"rich_text"`
+	Checked  bool               `json:"checked"`
+	Cells    [][]notionRichText `json:"cells"`
+}
+
+func (b *notionBlock) UnmarshalJSON(data []byte) error {
+	var header struct {
+		ID          string `json:"id"`
+		Type        string `json:"type"`
+		HasChildren bool   `json:"has_children"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	b.ID, b.Type, b.HasChildren = header.ID, header.Type, header.HasChildren
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if raw, ok := fields[header.Type]; ok && raw[0] == '{' {
+		return json.Unmarshal(raw, &b.content)
+	}
+	return nil
+}
+
+type notionList[T any] struct {
+	Results    []T    `json:"results"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// NewNotionSyncService creates the Notion integration. It is disabled unless an API token is configured.
+func NewNotionSyncService(db *gorm.DB, knowledge *KnowledgeService, jobQueue *JobQueue, config NotionConfig) *NotionSyncService {
+	var databaseIDs []string
+	for _, id := range config.DatabaseIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			databaseIDs = append(databaseIDs, id)
+		}
+	}
+	config.DatabaseIDs = databaseIDs
+	if config.Category == "" {
+		config.Category = defaultNotionCategory
+	}
+	return &NotionSyncService{
+		db:         db,
+		knowledge:  knowledge,
+		jobQueue:   jobQueue,
+		config:     config,
+		baseURL:    notionAPIURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Enabled reports whether an API token is configured
+func (s *NotionSyncService) Enabled() bool {
+	return s.config.APIToken != ""
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *NotionSyncService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeNotionSync, s.handleSyncJob)
+}
+
+// ScheduleSync queues a manual sync
+func (s *NotionSyncService) ScheduleSync(ctx context.Context) (*models.Job, error) {
+	if !s.Enabled() {
+		return nil, validationError("the Notion integration is not configured")
+	}
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeNotionSync, notionSyncPayload{Trigger: NotionSyncTriggerManual}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// handleSyncJob runs a sync as a background job
+func (s *NotionSyncService) handleSyncJob(ctx context.Context, job *models.Job) error {
+	if !s.Enabled() {
+		log.Printf("[INFO] Skipped Notion sync: the integration is no longer configured")
+		return nil
+	}
+
+	// Pages that fail are recorded with their error and retried by the next sync, not by retrying the job
+	_, err := s.Sync(ctx)
+	return err
+}
+
+// Sync brings the knowledge entries in line with the Notion pages. New pages get entries, pages edited
+// since their last sync get new entries replacing their old ones, and pages archived, deleted or no longer
+// shared lose theirs. A page that fails keeps the entries of the sync before.
+func (s *NotionSyncService) Sync(ctx context.Context) (*NotionSyncReport, error) {
+	if !s.Enabled() {
+		return nil, validationError("the Notion integration is not configured")
+	}
+	report := &NotionSyncReport{StartedAt: time.Now()}
+
+	pages, err := s.listPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Pages = len(pages)
+
+	var synced []models.NotionPage
+	if err := s.db.Find(&synced).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]*models.NotionPage, len(synced))
+	for i := range synced {
+		known[synced[i].PageID] = &synced[i]
+	}
+
+	createdBy, err := integrationUser(s.db, notionSyncEmail, "Notion sync")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		record := known[page.ID]
+		delete(known, page.ID)
+		if record != nil && record.LastEditedAt.Equal(page.LastEditedTime) && record.SyncError == "" {
+			report.Unchanged++
+			continue
+		}
+		if err := s.syncPage(ctx, page, record, createdBy); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("page %s (%s): %v", page.ID, page.title(), err))
+			log.Printf("[WARNING] Failed to sync Notion page %s (%s): %v", page.ID, page.title(), err)
+		} else if record == nil {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	// Pages archived, deleted, no longer shared or moved out of the configured databases
+	for _, record := range known {
+		if err := s.removePage(record); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("page %s (%s): %v", record.PageID, record.Title, err))
+			continue
+		}
+		report.Deleted++
+	}
+
+	report.CompletedAt = time.Now()
+	log.Printf("[INFO] Synced Notion: %d pages, %d created, %d updated, %d deleted, %d failed",
+		report.Pages, report.Created, report.Updated, report.Deleted, report.Failed)
+	return report, nil
+}
+
+// syncPage replaces the entries of a page with entries created from its current content
+func (s *NotionSyncService) syncPage(ctx context.Context, page notionPage, record *models.NotionPage, createdBy uuid.UUID) error {
+	if record == nil {
+		record = &models.NotionPage{PageID: page.ID}
+	}
+	record.DatabaseID = page.databaseID
+	record.Title = page.title()
+	record.URL = page.URL
+	record.SyncedAt = time.Now()
+
+	fail := func(err error) error {
+		record.SyncError = err.Error()
+		if saveErr := s.db.Save(record).Error; saveErr != nil {
+			log.Printf("[ERROR] Failed to save Notion page %s: %v", page.ID, saveErr)
+		}
+		return err
+	}
+
+	blocks, err := s.pageBlocks(ctx, page.ID, 1)
+	if err != nil {
+		return fail(err)
+	}
+	sections := notionSections(blocks)
+	if len(sections) == 0 {
+		return fail(errors.New("page has no content"))
+	}
+	for _, section := range sections {
+		if findings := ScanForPromptInjection(section.Content); len(findings) > 0 {
+			rules := make([]string, len(findings))
+			for i, finding := range findings {
+				rules[i] = finding.Rule
+			}
+			return fail(fmt.Errorf("page contains instruction-like content (%s)", strings.Join(rules, ", ")))
+		}
+	}
+
+	fieldData, _ := json.Marshal(map[string]interface{}{
+		"source_url":         page.URL,
+		"notion_page_id":     page.ID,
+		"notion_database_id": page.databaseID,
+		"notion_edited_at":   page.LastEditedTime,
+	})
+	entryIDs, err := createSectionEntries(ctx, s.knowledge, sections, sectionEntry{
+		Title:     record.Title,
+		Category:  s.config.Category,
+		Tag:       "notion",
+		FieldData: string(fieldData),
+		CreatedBy: createdBy,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	previous, err := notionEntryIDs(record)
+	if err != nil {
+		log.Printf("[WARNING] %v", err)
+	}
+	encoded, _ := json.Marshal(entryIDs)
+	record.LastEditedAt = page.LastEditedTime
+	record.KnowledgeEntryIDs = string(encoded)
+	record.SyncError = ""
+	if err := s.db.Save(record).Error; err != nil {
+		deleteSectionEntries(s.knowledge, entryIDs)
+		return fmt.Errorf("failed to save page: %w", err)
+	}
+	deleteSectionEntries(s.knowledge, previous)
+	return nil
+}
+
+// removePage deletes a page that is gone from Notion along with its entries
+func (s *NotionSyncService) removePage(record *models.NotionPage) error {
+	entryIDs, err := notionEntryIDs(record)
+	if err != nil {
+		return err
+	}
+	deleteSectionEntries(s.knowledge, entryIDs)
+	return s.db.Delete(record).Error
+}
+
+func notionEntryIDs(record *models.NotionPage) ([]uuid.UUID, error) {
+	entryIDs, err := decodeEntryIDs(record.KnowledgeEntryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid knowledge entry IDs of Notion page %s: %w", record.PageID, err)
+	}
+	return entryIDs, nil
+}
+
+// Status describes the integration and the pages synced so far
+func (s *NotionSyncService) Status(ctx context.Context) (*NotionStatus, error) {
+	status := &NotionStatus{
+		Enabled:     s.Enabled(),
+		DatabaseIDs: s.config.DatabaseIDs,
+		Category:    s.config.Category,
+	}
+	if !status.Enabled {
+		return status, nil
+	}
+
+	var row struct {
+		Synced   int64
+		Failed   int64
+		LastSync *time.Time
+	}
+	err := s.db.WithContext(ctx).Model(&models.NotionPage{}).
+		Select("COUNT(*) AS synced, COUNT(*) FILTER (WHERE sync_error <> '') AS failed, MAX(synced_at) AS last_sync").
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	status.SyncedPages = row.Synced
+	status.FailedPages = row.Failed
+	status.LastSyncedAt = row.LastSync
+	return status, nil
+}
+
+// listPages returns the live pages of the configured databases, or every page shared with the integration
+func (s *NotionSyncService) listPages(ctx context.Context) ([]notionPage, error) {
+	var pages []notionPage
+	collect := func(path, databaseID string, filter map[string]interface{}) error {
+		cursor := ""
+		for {
+			body := map[string]interface{}{"page_size": notionPageSize}
+			for key, value := range filter {
+				body[key] = value
+			}
+			if cursor != "" {
+				body["start_cursor"] = cursor
+			}
+			var list notionList[notionPage]
+			if err := s.call(ctx, http.MethodPost, path, body, &list); err != nil {
+				return err
+			}
+			for _, page := range list.Results {
+				if page.Archived || page.InTrash {
+					continue
+				}
+				page.databaseID = databaseID
+				pages = append(pages, page)
+			}
+			if !list.HasMore || list.NextCursor == "" {
+				return nil
+			}
+			cursor = list.NextCursor
+		}
+	}
+
+	if len(s.config.DatabaseIDs) == 0 {
+		filter := map[string]interface{}{"filter": map[string]string{"property": "object", "value": "page"}}
+		if err := collect("/search", "", filter); err != nil {
+			return nil, err
+		}
+		return pages, nil
+	}
+	for _, databaseID := range s.config.DatabaseIDs {
+		if err := collect("/databases/"+databaseID+"/query", databaseID, nil); err != nil {
+			return nil, fmt.Errorf("database %s: %w", databaseID, err)
+		}
+	}
+	return pages, nil
+}
+
+// pageBlocks returns the blocks of a page or block with their children. Child pages and databases
+// are synced as pages of their own and not descended into.
+func (s *NotionSyncService) pageBlocks(ctx context.Context, blockID string, depth int) ([]notionBlock, error) {
+	var blocks []notionBlock
+	cursor := ""
+	for {
+		path := "/blocks/" + blockID + "/children?page_size=" + strconv.Itoa(notionPageSize)
+		if cursor != "" {
+			path += "&start_cursor=" + cursor
+		}
+		var list notionList[notionBlock]
+		if err := s.call(ctx, http.MethodGet, path, nil, &list); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, list.Results...)
+		if !list.HasMore || list.NextCursor == "" {
+			break
+		}
+		cursor = list.NextCursor
+	}
+
+	if depth < notionMaxBlockDepth {
+		for i := range blocks {
+			block := &blocks[i]
+			if !block.HasChildren || block.Type == "child_page" || block.Type == "child_database" {
+				continue
+			}
+			children, err := s.pageBlocks(ctx, block.ID, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			block.children = children
+		}
+	}
+	return blocks, nil
+}
+
+// notionSections turns the blocks of a page into sections, one per heading. Content before the first
+// heading forms a section of its own; sections longer than an import section are split further.
+func notionSections(blocks []notionBlock) []DocumentSection {
+	var sections []DocumentSection
+	title := "Overview"
+	var body []notionBlock
+	flush := func() {
+		sections = append(sections, titledSections(title, notionBlockText(body, 0), len(sections), defaultSectionOptions())...)
+		body = nil
+	}
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "heading_1", "heading_2", "heading_3":
+			flush()
+			title = strings.TrimSpace(plainText(block.content.RichText))
+			if title == "" {
+				title = fmt.Sprintf("Section %d", len(sections)+1)
+			}
+			// Toggle headings keep their content as children
+			body = append(body, block.children...)
+		default:
+			body = append(body, block)
+		}
+	}
+	flush()
+	return sections
+}
+
+// notionBlockText renders blocks as plain text. Top-level blocks are separated by blank lines, except that
+// consecutive list items and table rows stay on consecutive lines; nested blocks are indented below their parent.
+func notionBlockText(blocks []notionBlock, indent int) string {
+	var out strings.Builder
+	prefix := strings.Repeat("  ", indent)
+	number := 0
+	previousListed := false
+	for _, block := range blocks {
+		text := strings.TrimSpace(plainText(block.content.RichText))
+		empty := text == ""
+		listed := true
+		if block.Type != "numbered_list_item" {
+			number = 0
+		}
+		switch block.Type {
+		case "bulleted_list_item", "toggle":
+			text = "- " + text
+		case "numbered_list_item":
+			number++
+			text = fmt.Sprintf("%d. %s", number, text)
+		case "to_do":
+			if block.content.Checked {
+				text = "[x] " + text
+			} else {
+				text = "[ ] " + text
+			}
+		case "table_row":
+			cells := make([]string, len(block.content.Cells))
+			for i, cell := range block.content.Cells {
+				cells[i] = strings.TrimSpace(plainText(cell))
+			}
+			text = strings.Join(cells, " | ")
+			empty = strings.Trim(text, " |") == ""
+		case "quote":
+			text = "> " + text
+			listed = false
+		case "heading_1", "heading_2", "heading_3", "paragraph", "callout", "code", "table", "column_list", "column", "synced_block":
+			listed = false
+		default:
+			// Images, embeds, child pages and other blocks without text
+			continue
+		}
+
+		childIndent := indent + 1
+		switch block.Type {
+		case "table", "column_list", "column", "synced_block":
+			// Containers without text of their own
+			childIndent = indent
+		}
+		children := notionBlockText(block.children, childIndent)
+		if empty && children == "" {
+			continue
+		}
+		if out.Len() > 0 {
+			if indent == 0 && !(listed && previousListed) {
+				out.WriteString("\n\n")
+			} else {
+				out.WriteString("\n")
+			}
+		}
+		previousListed = listed
+		if !empty {
+			out.WriteString(prefix + text)
+			if children != "" {
+				out.WriteString("\n")
+			}
+		}
+		out.WriteString(children)
+	}
+	return out.String()
+}
+
+// call sends a request to the Notion API, waiting out rate limits
+func (s *NotionSyncService) call(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+s.config.APIToken)
+		req.Header.Set("Notion-Version", notionAPIVersion)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("Notion request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < notionMaxRetries {
+			resp.Body.Close()
+			delay := time.Second
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				delay = time.Duration(seconds) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("Notion returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("invalid Notion response: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OllamaService talks to a self-hosted model server through its OpenAI-compatible API.
+// It works with Ollama as well as other OpenAI-compatible servers such as vLLM or LM Studio,
+// so prompts and documents never leave the deployment.
+type OllamaService struct {
+	client         *openai.Client
+	httpClient     *http.Client
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+	maxTokens      int
+	temperature    float32
+	prompts        PromptSource
+	contextWindow  int // Overrides the context window of the model when positive
+}
+
+// NewOllamaService creates a client for a local model server. baseURL is the server root
+// (e.g. http://localhost:11434); the /v1 suffix is added when missing. apiKey is optional.
+func NewOllamaService(baseURL, apiKey, model, embeddingModel string, maxTokens int, temperature float32) *OllamaService {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	if apiKey == "" {
+		apiKey = "ollama" // Ollama ignores the key but the client always sends one
+	}
+
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+
+	return &OllamaService{
+		client:         openai.NewClientWithConfig(config),
+		httpClient:     &http.Client{Timeout: 60 * time.Second},
+		baseURL:        baseURL,
+		apiKey:         apiKey,
+		model:          model,
+		embeddingModel: embeddingModel,
+		maxTokens:      maxTokens,
+		temperature:    temperature,
+	}
+}
+
+// SetPromptSource makes the system prompt come from the active prompt template when one exists
+func (s *OllamaService) SetPromptSource(prompts PromptSource) {
+	s.prompts = prompts
+}
+
+// SetContextWindow overrides the context window, in tokens, the knowledge base context is fitted into. Ollama
+// serves models with a smaller context than they support unless num_ctx is raised, so set it to num_ctx.
+func (s *OllamaService) SetContextWindow(tokens int) {
+	s.contextWindow = tokens
+}
+
+// ChatCompletion sends a chat request to the local model
+func (s *OllamaService) ChatCompletion(ctx context.Context, req UnifiedChatRequest) (*UnifiedChatResponse, error) {
+	// The system message is built once the model is known
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: req.SystemPrompt,
+		},
+	}
+	conversationTokens := 0
+	for _, msg := range req.Messages {
+		messages = append(messages, openAIChatMessage(msg.Role, msg.Content, msg.Images))
+		conversationTokens += messageTokens(msg.Content) + len(msg.Images)*imageTokens
+	}
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:       s.model,
+		Messages:    messages,
+		MaxTokens:   s.maxTokens,
+		Temperature: s.temperature,
+	}
+	applyGenerationParams(&chatReq, req.Generation)
+	if req.SystemPrompt == "" {
+		basePrompt := resolvePrompt(ctx, s.prompts, PromptSupportAssistant, OllamaProvider, defaultSupportPrompt)
+		budget := contextBudget(chatReq.Model, s.contextWindow, chatReq.MaxTokens, basePrompt, conversationTokens)
+		messages[0].Content = buildSystemMessage(basePrompt, fitContext(chatReq.Model, req.Context, budget))
+	}
+
+	resp, err := s.client.CreateChatCompletion(ctx, chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("Ollama API error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from Ollama")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = chatReq.Model
+	}
+
+	return &UnifiedChatResponse{
+		Message:   resp.Choices[0].Message.Content,
+		Sources:   req.Context,
+		SessionID: req.SessionID,
+		Model:     model,
+		Usage: TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// CreateEmbedding embeds text with the local embedding model. The request is sent directly
+// because the OpenAI client only accepts the hosted OpenAI embedding model names.
+func (s *OllamaService) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": s.embeddingModel,
+		"input": []string{text},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Ollama embedding error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("Ollama embedding error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var embeddingResp struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Ollama embedding response: %w", err)
+	}
+
+	if len(embeddingResp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding data returned")
+	}
+
+	return embeddingResp.Data[0].Embedding, nil
+}
+
+// Model returns the chat model served by the local server
+func (s *OllamaService) Model() string {
+	return s.model
+}
+
+// EmbeddingModel returns the local model embeddings are created with
+func (s *OllamaService) EmbeddingModel() string {
+	return s.embeddingModel
+}
+
+// Ping checks that the local model server is reachable
+func (s *OllamaService) Ping(ctx context.Context) error {
+	if _, err := s.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("Ollama API error: %w", err)
+	}
+	return nil
+}
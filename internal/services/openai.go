@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sashabaranov/go-openai"
@@ -14,6 +16,9 @@ type OpenAIService struct {
 	embeddingModel      string
 	maxTokens           int
 	temperature         float32
+	prompts             PromptSource
+	contextWindow       int // Overrides the context window of the model when positive
+	azure               bool // Embeddings go to the embedding deployment whatever model is requested
 }
 
 func NewOpenAIService(apiKey, model, embeddingModel string, maxTokens int, temperature float32) *OpenAIService {
@@ -27,41 +32,79 @@ func NewOpenAIService(apiKey, model, embeddingModel string, maxTokens int, tempe
 	}
 }
 
+// NewAzureOpenAIService creates an OpenAIService backed by Azure OpenAI deployments.
+// Chat requests go to deployment and embedding requests to embeddingDeployment.
+func NewAzureOpenAIService(endpoint, apiKey, apiVersion, deployment, embeddingDeployment string, maxTokens int, temperature float32) *OpenAIService {
+	config := openai.DefaultAzureConfig(apiKey, endpoint)
+	config.APIVersion = apiVersion
+	config.AzureModelMapperFunc = func(model string) string {
+		if strings.Contains(model, "embedding") && embeddingDeployment != "" {
+			return embeddingDeployment
+		}
+		return deployment
+	}
+
+	return &OpenAIService{
+		client:              openai.NewClientWithConfig(config),
+		model:               deployment,
+		embeddingModel:      embeddingDeployment,
+		maxTokens:           maxTokens,
+		temperature:         temperature,
+		azure:               true,
+	}
+}
+
+// SetPromptSource makes the system prompt come from the active prompt template when one exists
+func (s *OpenAIService) SetPromptSource(prompts PromptSource) {
+	s.prompts = prompts
+}
+
+// SetContextWindow overrides the context window, in tokens, the knowledge base context is fitted into.
+// By default it is looked up from the model name, which Azure deployment names do not carry.
+func (s *OpenAIService) SetContextWindow(tokens int) {
+	s.contextWindow = tokens
+}
+
 type OpenAIChatRequest struct {
 	Messages        []OpenAIChatMessage `json:"messages"`
 	Context         []string      `json:"context,omitempty"`
 	SessionID       string        `json:"session_id,omitempty"`
 	UseKnowledgeBase bool         `json:"use_knowledge_base"`
+	Generation      GenerationParams `json:"generation,omitempty"`
+	SystemPrompt    string        `json:"system_prompt,omitempty"` // Added to the support prompt, e.g. the persona of the session
 }
 
 type OpenAIChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content string      `json:"content"`
+	Images  []ChatImage `json:"-"`
 }
 
 type OpenAIChatResponse struct {
-	Message   string   `json:"message"`
-	Sources   []string `json:"sources,omitempty"`
-	SessionID string   `json:"session_id"`
+	Message   string     `json:"message"`
+	Sources   []string   `json:"sources,omitempty"`
+	SessionID string     `json:"session_id"`
+	Model     string     `json:"model"`
+	Usage     TokenUsage `json:"usage"`
 }
 
 func (s *OpenAIService) ChatCompletion(ctx context.Context, req OpenAIChatRequest) (*OpenAIChatResponse, error) {
-	// Build system message with context
-	systemMessage := s.buildSystemMessage(req.Context)
-	
-	// Convert messages to OpenAI format
+	basePrompt := resolvePrompt(ctx, s.prompts, PromptSupportAssistant, OpenAIProvider, defaultSupportPrompt)
+	if req.SystemPrompt != "" {
+		basePrompt += "\n\nAdditional Instructions:\n" + req.SystemPrompt
+	}
+
+	// Convert messages to OpenAI format; the system message is built once the model is known
 	messages := []openai.ChatCompletionMessage{
 		{
-			Role:    openai.ChatMessageRoleSystem,
-			Content: systemMessage,
+			Role: openai.ChatMessageRoleSystem,
 		},
 	}
 	
+	conversationTokens := 0
 	for _, msg := range req.Messages {
-		messages = append(messages, openai.ChatCompletionMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+		messages = append(messages, openAIChatMessage(msg.Role, msg.Content, msg.Images))
+		conversationTokens += messageTokens(msg.Content) + len(msg.Images)*imageTokens
 	}
 
 	// Create chat completion request
@@ -71,6 +114,11 @@ func (s *OpenAIService) ChatCompletion(ctx context.Context, req OpenAIChatReques
 		MaxTokens:   s.maxTokens,
 		Temperature: s.temperature,
 	}
+	applyGenerationParams(&chatReq, req.Generation)
+
+	// Build system message with as much of the context as fits the model's context window
+	budget := contextBudget(chatReq.Model, s.contextWindow, chatReq.MaxTokens, basePrompt, conversationTokens)
+	messages[0].Content = buildSystemMessage(basePrompt, fitContext(chatReq.Model, req.Context, budget))
 
 	resp, err := s.client.CreateChatCompletion(ctx, chatReq)
 	if err != nil {
@@ -85,13 +133,60 @@ func (s *OpenAIService) ChatCompletion(ctx context.Context, req OpenAIChatReques
 		Message:   resp.Choices[0].Message.Content,
 		Sources:   req.Context, // Return the context sources used
 		SessionID: req.SessionID,
+		Model:     resp.Model,
+		Usage: TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
 	}, nil
 }
 
+// applyGenerationParams overrides the defaults of an OpenAI-compatible request
+func applyGenerationParams(chatReq *openai.ChatCompletionRequest, params GenerationParams) {
+	if params.Model != "" {
+		chatReq.Model = params.Model
+	}
+	if params.Temperature != nil {
+		chatReq.Temperature = *params.Temperature
+	}
+	if params.MaxTokens != nil {
+		chatReq.MaxTokens = *params.MaxTokens
+	}
+	if params.TopP != nil {
+		chatReq.TopP = *params.TopP
+	}
+}
+
+// EmbeddingModel returns the model embeddings are created with, the embedding deployment on Azure
+func (s *OpenAIService) EmbeddingModel() string {
+	if s.embeddingModel == "" {
+		return openai.AdaEmbeddingV2.String()
+	}
+	return s.embeddingModel
+}
+
+// embeddingRequestModel returns the model embedding requests name. On Azure the name only routes the request
+// to the embedding deployment, which decides the model.
+func (s *OpenAIService) embeddingRequestModel() (openai.EmbeddingModel, error) {
+	if s.azure || s.embeddingModel == "" {
+		return openai.AdaEmbeddingV2, nil
+	}
+	var model openai.EmbeddingModel
+	if err := model.UnmarshalText([]byte(s.embeddingModel)); err != nil {
+		return openai.Unknown, fmt.Errorf("embedding model %q is not supported by the OpenAI client", s.embeddingModel)
+	}
+	return model, nil
+}
+
 func (s *OpenAIService) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	model, err := s.embeddingRequestModel()
+	if err != nil {
+		return nil, err
+	}
 	req := openai.EmbeddingRequest{
 		Input: []string{text},
-		Model: openai.AdaEmbeddingV2,
+		Model: model,
 	}
 
 	resp, err := s.client.CreateEmbeddings(ctx, req)
@@ -111,9 +206,13 @@ func (s *OpenAIService) CreateEmbeddings(ctx context.Context, texts []string) ([
 		return nil, fmt.Errorf("no texts provided")
 	}
 
+	model, err := s.embeddingRequestModel()
+	if err != nil {
+		return nil, err
+	}
 	req := openai.EmbeddingRequest{
 		Input: texts,
-		Model: openai.AdaEmbeddingV2,
+		Model: model,
 	}
 
 	resp, err := s.client.CreateEmbeddings(ctx, req)
@@ -129,8 +228,8 @@ func (s *OpenAIService) CreateEmbeddings(ctx context.Context, texts []string) ([
 	return embeddings, nil
 }
 
-func (s *OpenAIService) buildSystemMessage(context []string) string {
-	baseMessage := `You are a helpful AI assistant for operational support. Your primary role is to help employees with questions about:
+// defaultSupportPrompt is the built-in support assistant prompt used until a support_assistant prompt template is active
+const defaultSupportPrompt = `You are a helpful AI assistant for operational support. Your primary role is to help employees with questions about:
 - How to operate the application/webapp
 - Understanding error messages and their solutions
 - Role and permission requirements
@@ -146,48 +245,54 @@ Guidelines:
 
 `
 
+// buildSystemMessage appends the knowledge base context to the support assistant prompt shared by OpenAI-compatible providers
+func buildSystemMessage(baseMessage string, context []string) string {
 	if len(context) > 0 {
 		baseMessage += "Based on the following knowledge base information:\n\n"
 		for i, ctx := range context {
-			baseMessage += fmt.Sprintf("Knowledge %d:\n%s\n\n", i+1, ctx)
+			baseMessage += fmt.Sprintf("[%d]\n%s\n\n", i+1, ctx)
 		}
-		baseMessage += "Please answer the user's question using this information as context."
+		baseMessage += "Please answer the user's question using this information as context. " +
+			"When a statement uses information from a source, cite it inline with the source's marker, e.g. [1] or [2]. " +
+			"Only cite the numbered sources above and never invent markers."
 	}
 
 	return baseMessage
 }
 
-// ChunkText splits text into smaller chunks for better embedding
-func (s *OpenAIService) ChunkText(text string, maxChunkSize int) []string {
-	if maxChunkSize <= 0 {
-		maxChunkSize = 1000 // Default chunk size
-	}
-
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return []string{}
+// Moderate runs text through the OpenAI moderation endpoint and returns the flagged categories
+func (s *OpenAIService) Moderate(ctx context.Context, text string) ([]string, error) {
+	resp, err := s.client.Moderations(ctx, openai.ModerationRequest{Input: text})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI moderation error: %w", err)
 	}
 
-	var chunks []string
-	var currentChunk strings.Builder
-	
-	for _, word := range words {
-		// Check if adding this word would exceed the limit
-		if currentChunk.Len() > 0 && currentChunk.Len()+len(word)+1 > maxChunkSize {
-			chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
-			currentChunk.Reset()
+	var flagged []string
+	for _, result := range resp.Results {
+		if !result.Flagged {
+			continue
 		}
-		
-		if currentChunk.Len() > 0 {
-			currentChunk.WriteString(" ")
+		// Categories are booleans keyed by their API name, e.g. "hate/threatening"
+		raw, _ := json.Marshal(result.Categories)
+		var categories map[string]bool
+		_ = json.Unmarshal(raw, &categories)
+		for category, hit := range categories {
+			if hit {
+				flagged = append(flagged, category)
+			}
+		}
+		if len(flagged) == 0 {
+			flagged = append(flagged, "flagged")
 		}
-		currentChunk.WriteString(word)
 	}
-	
-	// Add the last chunk if it's not empty
-	if currentChunk.Len() > 0 {
-		chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
+	sort.Strings(flagged)
+	return flagged, nil
+}
+
+// Ping checks that the API is reachable and the key is accepted without spending tokens
+func (s *OpenAIService) Ping(ctx context.Context) error {
+	if _, err := s.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("OpenAI API error: %w", err)
 	}
-	
-	return chunks
+	return nil
 }
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
 )
 
 // OpenAIAssistantService handles OpenAI Assistant API interactions
@@ -15,6 +17,13 @@ type OpenAIAssistantService struct {
 	client   *openai.Client
 	logger   *log.Logger
 	threadID string
+	db       *gorm.DB // Optional, records used threads for garbage collection
+	runs     runStatusCache
+
+	// Runs are streamed over plain HTTP, which the client library does not support
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
 }
 
 // NewOpenAIAssistantService creates a new OpenAI Assistant service
@@ -30,12 +39,81 @@ func NewOpenAIAssistantService(apiKey, threadID string, logger *log.Logger) *Ope
 	
 	client := openai.NewClientWithConfig(config)
 	return &OpenAIAssistantService{
-		client:   client,
-		logger:   logger,
-		threadID: threadID,
+		client:     client,
+		logger:     logger,
+		threadID:   threadID,
+		httpClient: config.HTTPClient,
+		baseURL:    config.BaseURL,
+		apiKey:     apiKey,
 	}
 }
 
+// NewAzureOpenAIAssistantService creates an assistant service backed by Azure OpenAI.
+// Azure serves assistants, threads and runs under {endpoint}/openai without a deployment
+// segment, authenticates with the api-key header, and requires an api-version query parameter.
+func NewAzureOpenAIAssistantService(endpoint, apiKey, apiVersion, threadID string, logger *log.Logger) *OpenAIAssistantService {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = strings.TrimRight(endpoint, "/") + "/openai"
+	config.HTTPClient = &http.Client{
+		Transport: &headerTransport{
+			base: &azureTransport{
+				base:       http.DefaultTransport,
+				apiKey:     apiKey,
+				apiVersion: apiVersion,
+			},
+		},
+	}
+
+	return &OpenAIAssistantService{
+		client:     openai.NewClientWithConfig(config),
+		logger:     logger,
+		threadID:   threadID,
+		httpClient: config.HTTPClient,
+		baseURL:    config.BaseURL,
+		apiKey:     apiKey,
+	}
+}
+
+// SetThreadTracking records created and used threads in the database so that idle threads
+// can be garbage collected (OpenAI has no API to list threads)
+func (s *OpenAIAssistantService) SetThreadTracking(db *gorm.DB) {
+	s.db = db
+}
+
+// DefaultThreadID returns the thread used when a request does not name one
+func (s *OpenAIAssistantService) DefaultThreadID() string {
+	return s.threadID
+}
+
+// trackThread marks a thread as used now
+func (s *OpenAIAssistantService) trackThread(threadID string) {
+	trackAssistantThread(s.db, s.logger, threadID)
+}
+
+// Name identifies the engine
+func (s *OpenAIAssistantService) Name() string {
+	return AssistantEngineAssistants
+}
+
+// azureTransport adapts OpenAI-style requests to Azure OpenAI authentication and versioning
+type azureTransport struct {
+	base       http.RoundTripper
+	apiKey     string
+	apiVersion string
+}
+
+func (t *azureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Del("Authorization")
+	req.Header.Set("api-key", t.apiKey)
+
+	query := req.URL.Query()
+	query.Set("api-version", t.apiVersion)
+	req.URL.RawQuery = query.Encode()
+
+	return t.base.RoundTrip(req)
+}
+
 // headerTransport is a custom transport that adds the required OpenAI-Beta header
 type headerTransport struct {
 	base http.RoundTripper
@@ -87,7 +165,8 @@ type MessageTextData struct {
 	Annotations []any  `json:"annotations,omitempty"`
 }
 
-// ChatWithAssistant implements the 4-step workflow you specified
+// ChatWithAssistant adds the message to the thread and streams a run of the assistant on it, so the
+// answer is returned as soon as the run finishes instead of at the next poll
 func (s *OpenAIAssistantService) ChatWithAssistant(ctx context.Context, req ChatAssistantRequest) (*ChatAssistantResponse, error) {
 	s.logger.Printf("Starting OpenAI Assistant chat workflow")
 	
@@ -104,61 +183,41 @@ func (s *OpenAIAssistantService) ChatWithAssistant(ctx context.Context, req Chat
 		return nil, fmt.Errorf("failed to add message to thread: %w", err)
 	}
 	s.logger.Printf("Message added successfully: %s", message.ID)
+	s.trackThread(threadID)
 	
-	// Step 2: Create and start a run
-	s.logger.Printf("Step 2: Creating run for thread %s with assistant %s", threadID, req.AssistantID)
-	run, err := s.createRun(ctx, threadID, req.AssistantID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create run: %w", err)
-	}
-	s.logger.Printf("Run created successfully: %s", run.ID)
-	
-	// Step 3: Wait for run completion instead of fixed delay
+	// Step 2: Run the assistant, streaming the run so the answer is returned as soon as it is complete
 	timeoutSeconds := req.TimeoutSeconds
 	if timeoutSeconds <= 0 {
 		timeoutSeconds = 30 // Default to 30 seconds timeout
 	}
-	s.logger.Printf("Step 3: Waiting for run %s to complete (timeout: %d seconds)...", run.ID, timeoutSeconds)
-	
-	startTime := time.Now()
-	finalStatus, err := s.WaitForRunCompletion(ctx, threadID, run.ID, time.Duration(timeoutSeconds)*time.Second)
-	waitDuration := time.Since(startTime)
-	
+	s.logger.Printf("Step 2: Streaming run for thread %s with assistant %s (timeout: %d seconds)", threadID, req.AssistantID, timeoutSeconds)
+	result, err := s.streamRun(ctx, threadID, req.AssistantID, time.Duration(timeoutSeconds)*time.Second)
 	if err != nil {
-		s.logger.Printf("Warning: Run completion wait failed after %v: %v", waitDuration, err)
-		// Continue to get messages even if wait failed
-	} else {
-		s.logger.Printf("Run %s completed with status: %s after %v", run.ID, finalStatus, waitDuration)
+		return nil, fmt.Errorf("failed to run assistant: %w", err)
 	}
-	
-	// Step 4: Get messages with run_id
-	s.logger.Printf("Step 4: Retrieving messages ONLY for run %s", run.ID)
-	messages, err := s.getMessagesWithRunID(ctx, threadID, run.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get messages: %w", err)
+	if !result.Done {
+		s.logger.Printf("Warning: Run %s still %s after %d ms, poll /assistant/runs/%s/wait for the answer", result.RunID, result.Status, result.WaitedMs, result.RunID)
+	} else {
+		s.logger.Printf("Run %s finished with status %s after %d ms with %d messages", result.RunID, result.Status, result.WaitedMs, len(result.Messages))
 	}
-	s.logger.Printf("Retrieved %d messages specifically for run %s", len(messages), run.ID)
-	
-	// Check run status
-	// runStatus, err := s.getRunStatus(ctx, threadID, run.ID)
-	// if err != nil {
-	// 	s.logger.Printf("Warning: Could not get run status: %v", err)
-	// 	runStatus = "unknown"
-	// }
-	
+
 	response := &ChatAssistantResponse{
 		ThreadID:    threadID,
-		RunID:       run.ID,
-		Messages:    messages,
-		Status:      finalStatus,
+		RunID:       result.RunID,
+		Messages:    result.Messages,
+		Status:      result.Status,
 		ProcessedAt: time.Now(),
 		Metadata: map[string]interface{}{
 			"assistant_id":       req.AssistantID,
 			"original_message":   req.Message,
 			"timeout_seconds":    timeoutSeconds,
-			"workflow_completed": true,
+			"workflow_completed": result.Done,
+			"waited_ms":          result.WaitedMs,
 		},
 	}
+	if result.LastError != nil {
+		response.Metadata["last_error"] = result.LastError
+	}
 	
 	s.logger.Printf("OpenAI Assistant workflow completed successfully")
 	return response, nil
@@ -179,20 +238,6 @@ func (s *OpenAIAssistantService) addMessageToThread(ctx context.Context, threadI
 	return &message, nil
 }
 
-// createRun creates and starts a run on the thread
-func (s *OpenAIAssistantService) createRun(ctx context.Context, threadID, assistantID string) (*openai.Run, error) {
-	runRequest := openai.RunRequest{
-		AssistantID: assistantID,
-	}
-	
-	run, err := s.client.CreateRun(ctx, threadID, runRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create run: %w", err)
-	}
-	
-	return &run, nil
-}
-
 // getMessagesWithRunID retrieves messages for a specific run
 func (s *OpenAIAssistantService) getMessagesWithRunID(ctx context.Context, threadID, runID string) ([]AssistantMessage, error) {
 	s.logger.Printf("Getting messages for specific run_id: %s in thread: %s", runID, threadID)
@@ -284,27 +329,41 @@ func (s *OpenAIAssistantService) convertToAssistantMessage(msg openai.Message) A
 	}
 }
 
-// GetThreadMessages gets all messages from a thread (utility method)
-func (s *OpenAIAssistantService) GetThreadMessages(ctx context.Context, threadID string) ([]AssistantMessage, error) {
+// GetThreadMessages returns a page of the messages of a thread. The API does not filter by run or role,
+// so those filters apply to the fetched page.
+func (s *OpenAIAssistantService) GetThreadMessages(ctx context.Context, threadID string, query ThreadMessagesQuery) (*ThreadMessagesPage, error) {
 	if threadID == "" {
 		threadID = s.threadID
 	}
-	
-	limit := 50
-	order := "desc"
-	
-	messagesList, err := s.client.ListMessage(ctx, threadID, &limit, &order, nil, nil)
+	query, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var after, before *string
+	if query.After != "" {
+		after = &query.After
+	}
+	if query.Before != "" {
+		before = &query.Before
+	}
+	messagesList, err := s.client.ListMessage(ctx, threadID, &query.Limit, &query.Order, after, before)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list messages: %w", err)
 	}
-	
-	var assistantMessages []AssistantMessage
+
+	assistantMessages := make([]AssistantMessage, 0, len(messagesList.Messages))
 	for _, msg := range messagesList.Messages {
-		assistantMsg := s.convertToAssistantMessage(msg)
-		assistantMessages = append(assistantMessages, assistantMsg)
+		assistantMessages = append(assistantMessages, s.convertToAssistantMessage(msg))
 	}
-	
-	return assistantMessages, nil
+	var firstID, lastID string
+	if messagesList.FirstID != nil {
+		firstID = *messagesList.FirstID
+	}
+	if messagesList.LastID != nil {
+		lastID = *messagesList.LastID
+	}
+	return threadMessagesPage(query, assistantMessages, firstID, lastID, messagesList.HasMore), nil
 }
 
 // CreateThread creates a new thread (utility method)
@@ -315,36 +374,23 @@ func (s *OpenAIAssistantService) CreateThread(ctx context.Context) (*openai.Thre
 	if err != nil {
 		return nil, fmt.Errorf("failed to create thread: %w", err)
 	}
+	s.trackThread(thread.ID)
 	
 	return &thread, nil
 }
 
-// WaitForRunCompletion waits for a run to complete (utility method)
+// WaitForRunCompletion waits for a run to finish and returns its final status
 func (s *OpenAIAssistantService) WaitForRunCompletion(ctx context.Context, threadID, runID string, timeout time.Duration) (string, error) {
-	deadline := time.Now().Add(timeout)
-	
-	for time.Now().Before(deadline) {
-		startTime := time.Now()
-		status, err := s.getRunStatus(ctx, threadID, runID)
-		waitDuration := time.Since(startTime)
-		s.logger.Printf("getRunStatus after %v", waitDuration)
-		if err != nil {
-			return "", err
-		}
-		
-		// Check if run is completed
-		switch status {
-		case "completed":
-			return status, nil
-		case "failed", "cancelled", "expired":
-			return status, fmt.Errorf("run finished with status: %s", status)
-		case "requires_action":
-			return status, fmt.Errorf("run requires action, please handle manually")
-		}
-		
-		// Wait before checking again
-		time.Sleep(11 * time.Second)
+	result, err := s.WaitForRun(ctx, threadID, runID, timeout)
+	switch {
+	case err != nil:
+		return "", err
+	case !result.Done:
+		return "", fmt.Errorf("timeout waiting for run completion")
+	case result.Status == string(openai.RunStatusRequiresAction):
+		return result.Status, fmt.Errorf("run requires action, please handle manually")
+	case result.Status != string(openai.RunStatusCompleted):
+		return result.Status, fmt.Errorf("run finished with status: %s", result.Status)
 	}
-	
-	return "", fmt.Errorf("timeout waiting for run completion")
+	return result.Status, nil
 }
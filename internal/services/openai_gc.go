@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MaintenanceQueue is the job queue for housekeeping jobs
+const MaintenanceQueue = "maintenance"
+
+// JobTypeOpenAIGC is the job type of an OpenAI resource garbage collection run
+const JobTypeOpenAIGC = "openai_gc"
+
+// OpenAIGCTriggerManual is the trigger of collections queued on request
+const OpenAIGCTriggerManual = "manual"
+
+// Kinds of remote OpenAI resources
+const (
+	OpenAIResourceFile            = "file"
+	OpenAIResourceVectorStoreFile = "vector_store_file"
+	OpenAIResourceThread          = "thread"
+)
+
+const openAIAPIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIGCService deletes OpenAI files, vector-store files, and assistant threads
+// that no longer belong to any record in the database
+type OpenAIGCService struct {
+	db               *gorm.DB
+	apiKey           string
+	vectorStoreID    string
+	maxAge           time.Duration
+	protectedThreads map[string]bool
+	jobQueue         *JobQueue
+	httpClient       *http.Client
+}
+
+// NewOpenAIGCService creates the garbage collector. Resources younger than maxAge are never deleted,
+// which leaves in-flight uploads alone. Scheduled collections run as the openai_gc task of the scheduler.
+// protectedThreads are never deleted, e.g. the default assistant thread.
+func NewOpenAIGCService(db *gorm.DB, apiKey, vectorStoreID string, maxAge time.Duration, protectedThreads []string, jobQueue *JobQueue) *OpenAIGCService {
+	protected := make(map[string]bool, len(protectedThreads))
+	for _, threadID := range protectedThreads {
+		protected[threadID] = true
+	}
+	return &OpenAIGCService{
+		db:               db,
+		apiKey:           apiKey,
+		vectorStoreID:    vectorStoreID,
+		maxAge:           maxAge,
+		protectedThreads: protected,
+		jobQueue:         jobQueue,
+		httpClient:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// OpenAIOrphan is a remote resource without a matching database record
+type OpenAIOrphan struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Bytes     int64     `json:"bytes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Deleted   bool      `json:"deleted"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// OpenAIGCReport describes what a garbage collection run found and deleted
+type OpenAIGCReport struct {
+	DryRun                  bool           `json:"dry_run"`
+	Cutoff                  time.Time      `json:"cutoff"`
+	ScannedFiles            int            `json:"scanned_files"`
+	ScannedVectorStoreFiles int            `json:"scanned_vector_store_files"`
+	TrackedThreads          int            `json:"tracked_threads"`
+	Orphans                 []OpenAIOrphan `json:"orphans"`
+	OrphanedBytes           int64          `json:"orphaned_bytes"`
+	Deleted                 int            `json:"deleted"`
+	Failed                  int            `json:"failed"`
+	StartedAt               time.Time      `json:"started_at"`
+	CompletedAt             time.Time      `json:"completed_at"`
+}
+
+type openAIGCPayload struct {
+	Trigger string `json:"trigger"`
+}
+
+type openAIRemoteFile struct {
+	ID        string `json:"id"`
+	Filename  string `json:"filename"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type openAIListResponse struct {
+	Data    []openAIRemoteFile `json:"data"`
+	HasMore bool               `json:"has_more"`
+	LastID  string             `json:"last_id"`
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *OpenAIGCService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeOpenAIGC, s.handleGCJob)
+}
+
+// ScheduleCollection queues a manual collection that deletes orphans
+func (s *OpenAIGCService) ScheduleCollection(ctx context.Context) (*models.Job, error) {
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeOpenAIGC, openAIGCPayload{Trigger: OpenAIGCTriggerManual}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// CollectOrphans deletes the orphaned resources, failing when some could not be deleted
+func (s *OpenAIGCService) CollectOrphans(ctx context.Context) (*OpenAIGCReport, error) {
+	report, err := s.Collect(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if report.Failed > 0 {
+		return report, fmt.Errorf("failed to delete %d of %d orphaned OpenAI resources", report.Failed, len(report.Orphans))
+	}
+	return report, nil
+}
+
+// handleGCJob runs a collection as a background job
+func (s *OpenAIGCService) handleGCJob(ctx context.Context, job *models.Job) error {
+	_, err := s.CollectOrphans(ctx)
+	return err
+}
+
+// Collect finds OpenAI resources older than the configured age that no database record refers to.
+// With dryRun set nothing is deleted and the report lists what would be.
+func (s *OpenAIGCService) Collect(ctx context.Context, dryRun bool) (*OpenAIGCReport, error) {
+	if s.apiKey == "" {
+		return nil, errors.New("OpenAI API key not configured")
+	}
+
+	report := &OpenAIGCReport{
+		DryRun:    dryRun,
+		Cutoff:    time.Now().Add(-s.maxAge),
+		Orphans:   []OpenAIOrphan{},
+		StartedAt: time.Now(),
+	}
+
+	// Known file IDs; documents that were deleted locally no longer hold on to their remote files
+	var documents []models.UploadedDocument
+	if err := s.db.Select("openai_file_id", "vector_file_id").
+		Where("openai_file_id <> '' OR vector_file_id <> ''").
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	knownFiles := make(map[string]bool, len(documents))
+	knownVectorFiles := make(map[string]bool, len(documents))
+	for _, document := range documents {
+		if document.OpenAIFileID != "" {
+			knownFiles[document.OpenAIFileID] = true
+			knownVectorFiles[document.OpenAIFileID] = true
+		}
+		if document.VectorFileID != "" {
+			knownVectorFiles[document.VectorFileID] = true
+		}
+	}
+
+	// Vector store files go first so their underlying files are no longer attached when deleted
+	if s.vectorStoreID != "" {
+		vectorFiles, err := s.listRemote(ctx, fmt.Sprintf("/vector_stores/%s/files", s.vectorStoreID), url.Values{"limit": {"100"}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list vector store files: %w", err)
+		}
+		report.ScannedVectorStoreFiles = len(vectorFiles)
+		for _, file := range vectorFiles {
+			if knownVectorFiles[file.ID] || time.Unix(file.CreatedAt, 0).After(report.Cutoff) {
+				continue
+			}
+			s.collectOrphan(ctx, report, OpenAIOrphan{
+				Kind:      OpenAIResourceVectorStoreFile,
+				ID:        file.ID,
+				CreatedAt: time.Unix(file.CreatedAt, 0),
+			}, fmt.Sprintf("/vector_stores/%s/files/%s", s.vectorStoreID, file.ID))
+		}
+	}
+
+	files, err := s.listRemote(ctx, "/files", url.Values{"purpose": {"assistants"}, "limit": {"10000"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	report.ScannedFiles = len(files)
+	for _, file := range files {
+		if knownFiles[file.ID] || time.Unix(file.CreatedAt, 0).After(report.Cutoff) {
+			continue
+		}
+		s.collectOrphan(ctx, report, OpenAIOrphan{
+			Kind:      OpenAIResourceFile,
+			ID:        file.ID,
+			Name:      file.Filename,
+			Bytes:     file.Bytes,
+			CreatedAt: time.Unix(file.CreatedAt, 0),
+		}, "/files/"+file.ID)
+	}
+
+	if err := s.collectIdleThreads(ctx, report); err != nil {
+		return nil, err
+	}
+
+	report.CompletedAt = time.Now()
+	log.Printf("[INFO] OpenAI garbage collection (dry_run=%t): %d orphans, %d deleted, %d failed",
+		dryRun, len(report.Orphans), report.Deleted, report.Failed)
+	return report, nil
+}
+
+// DeleteIdleThreads deletes the tracked assistant threads last used before cutoff. The chat retention
+// policies use it to remove conversations kept by OpenAI. With dryRun set nothing is deleted.
+func (s *OpenAIGCService) DeleteIdleThreads(ctx context.Context, cutoff time.Time, dryRun bool) (*OpenAIGCReport, error) {
+	if s.apiKey == "" {
+		return nil, errors.New("OpenAI API key not configured")
+	}
+
+	report := &OpenAIGCReport{
+		DryRun:    dryRun,
+		Cutoff:    cutoff,
+		Orphans:   []OpenAIOrphan{},
+		StartedAt: time.Now(),
+	}
+	if err := s.collectIdleThreads(ctx, report); err != nil {
+		return nil, err
+	}
+	report.CompletedAt = time.Now()
+	return report, nil
+}
+
+// collectIdleThreads collects the tracked threads idle since the report cutoff. Threads cannot be listed,
+// so only tracked threads are known.
+func (s *OpenAIGCService) collectIdleThreads(ctx context.Context, report *OpenAIGCReport) error {
+	var threads []models.AssistantThread
+	if err := s.db.Find(&threads).Error; err != nil {
+		return err
+	}
+	report.TrackedThreads = len(threads)
+	for _, thread := range threads {
+		if s.protectedThreads[thread.ThreadID] || thread.LastUsedAt.After(report.Cutoff) {
+			continue
+		}
+		// The responses assistant engine uses conversations as threads
+		path := "/threads/" + thread.ThreadID
+		if strings.HasPrefix(thread.ThreadID, "conv_") {
+			path = "/conversations/" + thread.ThreadID
+		}
+		orphan := s.collectOrphan(ctx, report, OpenAIOrphan{
+			Kind:      OpenAIResourceThread,
+			ID:        thread.ThreadID,
+			CreatedAt: thread.CreatedAt,
+		}, path)
+		if orphan.Deleted {
+			if err := s.db.Delete(&models.AssistantThread{}, "id = ?", thread.ID).Error; err != nil {
+				log.Printf("[WARNING] Failed to remove tracked thread %s: %v", thread.ThreadID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// collectOrphan adds an orphan to the report and deletes it unless the run is a dry run
+func (s *OpenAIGCService) collectOrphan(ctx context.Context, report *OpenAIGCReport, orphan OpenAIOrphan, path string) OpenAIOrphan {
+	report.OrphanedBytes += orphan.Bytes
+	if !report.DryRun {
+		if err := s.deleteRemote(ctx, path); err != nil {
+			log.Printf("[WARNING] Failed to delete orphaned OpenAI %s %s: %v", orphan.Kind, orphan.ID, err)
+			orphan.Error = err.Error()
+			report.Failed++
+		} else {
+			orphan.Deleted = true
+			report.Deleted++
+		}
+	}
+	report.Orphans = append(report.Orphans, orphan)
+	return orphan
+}
+
+// listRemote pages through an OpenAI list endpoint
+func (s *OpenAIGCService) listRemote(ctx context.Context, path string, query url.Values) ([]openAIRemoteFile, error) {
+	var all []openAIRemoteFile
+	for {
+		resp, err := s.do(ctx, http.MethodGet, path+"?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+
+		var page openAIListResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		all = append(all, page.Data...)
+		if !page.HasMore || len(page.Data) == 0 {
+			return all, nil
+		}
+		after := page.LastID
+		if after == "" {
+			after = page.Data[len(page.Data)-1].ID
+		}
+		query.Set("after", after)
+	}
+}
+
+// deleteRemote deletes a remote resource. Resources that are already gone count as deleted.
+func (s *OpenAIGCService) deleteRemote(ctx context.Context, path string) error {
+	resp, err := s.do(ctx, http.MethodDelete, path)
+	if err != nil {
+		var notFound *openAINotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type openAINotFoundError struct {
+	path string
+}
+
+func (e *openAINotFoundError) Error() string {
+	return fmt.Sprintf("OpenAI resource %s not found", e.path)
+}
+
+// do sends an authenticated request and returns the response of a successful call
+func (s *OpenAIGCService) do(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, openAIAPIBaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("OpenAI-Beta", "assistants=v2")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, &openAINotFoundError{path: path}
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("OpenAI API error: %d - %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+// Response statuses of the Responses API that are still changing
+const (
+	responseQueued     = "queued"
+	responseInProgress = "in_progress"
+	responseCompleted  = "completed"
+)
+
+// OpenAIResponsesService is an assistant engine on the Responses API. Threads are conversations and
+// runs are responses created in background mode, so clients of the Assistants engine keep working.
+// The model, instructions and vector stores are configured here instead of on an assistant object;
+// the assistant ID of a request is only echoed in the response metadata.
+type OpenAIResponsesService struct {
+	apiKey         string
+	baseURL        string
+	model          string
+	instructions   string
+	vectorStoreIDs []string
+	httpClient     *http.Client
+	logger         *log.Logger
+	db             *gorm.DB // Optional, records used conversations for garbage collection
+}
+
+type responsesRequest struct {
+	Model        string            `json:"model"`
+	Input        string            `json:"input"`
+	Instructions string            `json:"instructions,omitempty"`
+	Conversation string            `json:"conversation,omitempty"`
+	Tools        []responsesTool   `json:"tools,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Background   bool              `json:"background"`
+	Store        bool              `json:"store"`
+}
+
+type responsesTool struct {
+	Type           string   `json:"type"`
+	VectorStoreIDs []string `json:"vector_store_ids,omitempty"`
+}
+
+// responseObject is the part of a response the engine reads
+type responseObject struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"created_at"`
+	Error     *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Output []responseItem `json:"output"`
+}
+
+// responseItem is an output item of a response or an item of a conversation
+type responseItem struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content []struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Annotations []any  `json:"annotations"`
+	} `json:"content"`
+}
+
+type conversationObject struct {
+	ID        string         `json:"id"`
+	CreatedAt int64          `json:"created_at"`
+	Metadata  map[string]any `json:"metadata"`
+}
+
+type conversationItemList struct {
+	Data    []responseItem `json:"data"`
+	FirstID string         `json:"first_id"`
+	LastID  string         `json:"last_id"`
+	HasMore bool           `json:"has_more"`
+}
+
+// responsesAPIError is an unsuccessful Responses or Conversations API call
+type responsesAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *responsesAPIError) Error() string {
+	return fmt.Sprintf("OpenAI API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// NewOpenAIResponsesService creates an assistant engine on the Responses API. Answers are grounded
+// with file_search over vectorStoreIDs; empty instructions use the default support prompt.
+func NewOpenAIResponsesService(apiKey, model, instructions string, vectorStoreIDs []string, logger *log.Logger) *OpenAIResponsesService {
+	if instructions == "" {
+		instructions = defaultSupportPrompt
+	}
+	return &OpenAIResponsesService{
+		apiKey:         apiKey,
+		baseURL:        openAIAPIBaseURL,
+		model:          model,
+		instructions:   instructions,
+		vectorStoreIDs: vectorStoreIDs,
+		httpClient:     &http.Client{Timeout: 60 * time.Second},
+		logger:         logger,
+	}
+}
+
+// SetThreadTracking records created and used conversations so that idle ones can be garbage collected
+func (s *OpenAIResponsesService) SetThreadTracking(db *gorm.DB) {
+	s.db = db
+}
+
+// Name identifies the engine
+func (s *OpenAIResponsesService) Name() string {
+	return AssistantEngineResponses
+}
+
+// ChatWithAssistant adds the message to a conversation and starts a background response, then waits
+// for it like the Assistants engine waits for a run. Without a thread ID a new conversation is started.
+func (s *OpenAIResponsesService) ChatWithAssistant(ctx context.Context, req ChatAssistantRequest) (*ChatAssistantResponse, error) {
+	threadID := req.ThreadID
+	if strings.HasPrefix(threadID, "thread_") {
+		return nil, validationError("thread %s belongs to the Assistants API; start a conversation with POST /assistant/threads", threadID)
+	}
+	if threadID == "" {
+		thread, err := s.CreateThread(ctx)
+		if err != nil {
+			return nil, err
+		}
+		threadID = thread.ID
+	}
+	s.trackThread(threadID)
+
+	request := responsesRequest{
+		Model:        s.model,
+		Input:        req.Message,
+		Instructions: s.instructions,
+		Conversation: threadID,
+		Background:   true,
+		Store:        true,
+	}
+	if len(s.vectorStoreIDs) > 0 {
+		request.Tools = []responsesTool{{Type: "file_search", VectorStoreIDs: s.vectorStoreIDs}}
+	}
+	if req.AssistantID != "" {
+		request.Metadata = map[string]string{"assistant_id": req.AssistantID}
+	}
+	var response responseObject
+	if err := s.call(ctx, http.MethodPost, "/responses", request, &response); err != nil {
+		return nil, fmt.Errorf("failed to create response: %w", err)
+	}
+	s.logger.Printf("Response %s created in conversation %s", response.ID, threadID)
+
+	timeoutSeconds := req.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+	result, err := s.WaitForRun(ctx, threadID, response.ID, time.Duration(timeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChatAssistantResponse{
+		ThreadID:    threadID,
+		RunID:       response.ID,
+		Messages:    result.Messages,
+		Status:      result.Status,
+		ProcessedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"assistant_id":       req.AssistantID,
+			"original_message":   req.Message,
+			"timeout_seconds":    timeoutSeconds,
+			"workflow_completed": result.Done,
+			"engine":             s.Name(),
+		},
+	}, nil
+}
+
+// GetThreadMessages returns a page of the messages of a conversation. The Conversations API only pages
+// forwards, so a page before an item is fetched after it in the opposite order and reversed.
+func (s *OpenAIResponsesService) GetThreadMessages(ctx context.Context, threadID string, query ThreadMessagesQuery) (*ThreadMessagesPage, error) {
+	if threadID == "" {
+		return nil, validationError("thread ID is required")
+	}
+	query, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(query.Limit))
+	params.Set("order", query.Order)
+	if query.After != "" {
+		params.Set("after", query.After)
+	}
+	if query.Before != "" {
+		params.Set("after", query.Before)
+		if query.Order == "desc" {
+			params.Set("order", "asc")
+		} else {
+			params.Set("order", "desc")
+		}
+	}
+	var items conversationItemList
+	if err := s.call(ctx, http.MethodGet, "/conversations/"+threadID+"/items?"+params.Encode(), nil, &items); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if query.Before != "" {
+		slices.Reverse(items.Data)
+		items.FirstID, items.LastID = items.LastID, items.FirstID
+	}
+	return threadMessagesPage(query, responseMessages(items.Data, "", 0), items.FirstID, items.LastID, items.HasMore), nil
+}
+
+// CreateThread starts an empty conversation
+func (s *OpenAIResponsesService) CreateThread(ctx context.Context) (*openai.Thread, error) {
+	var conversation conversationObject
+	if err := s.call(ctx, http.MethodPost, "/conversations", map[string]interface{}{}, &conversation); err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	s.trackThread(conversation.ID)
+	return &openai.Thread{
+		ID:        conversation.ID,
+		Object:    "thread",
+		CreatedAt: conversation.CreatedAt,
+		Metadata:  conversation.Metadata,
+	}, nil
+}
+
+// WaitForRunCompletion waits for a response to finish and returns its final status
+func (s *OpenAIResponsesService) WaitForRunCompletion(ctx context.Context, threadID, runID string, timeout time.Duration) (string, error) {
+	result, err := s.WaitForRun(ctx, threadID, runID, timeout)
+	switch {
+	case err != nil:
+		return "", err
+	case !result.Done:
+		return "", fmt.Errorf("timeout waiting for run completion")
+	case result.Status != responseCompleted:
+		return result.Status, fmt.Errorf("run finished with status: %s", result.Status)
+	}
+	return result.Status, nil
+}
+
+// WaitForRun long-polls a response until it finishes or timeout elapses, backing off between lookups
+func (s *OpenAIResponsesService) WaitForRun(ctx context.Context, threadID, runID string, timeout time.Duration) (*RunWaitResult, error) {
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := runWaitFirstInterval
+	status := ""
+	for {
+		var response responseObject
+		err := s.call(waitCtx, http.MethodGet, "/responses/"+runID, nil, &response)
+
+		var apiErr *responsesAPIError
+		switch {
+		case err == nil:
+			status = response.Status
+		case errors.Is(err, ErrNotFound):
+			return nil, fmt.Errorf("%w: run %s on thread %s", ErrNotFound, runID, threadID)
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+			s.logger.Printf("Rate limited while waiting for response %s, backing off", runID)
+			interval = runWaitRateLimited
+		case waitCtx.Err() != nil:
+			return &RunWaitResult{RunID: runID, ThreadID: threadID, Status: status, WaitedMs: time.Since(start).Milliseconds()}, nil
+		default:
+			return nil, fmt.Errorf("failed to retrieve response: %w", err)
+		}
+
+		if err == nil && response.Status != responseQueued && response.Status != responseInProgress {
+			result := &RunWaitResult{
+				RunID:    runID,
+				ThreadID: threadID,
+				Status:   response.Status,
+				Done:     true,
+				WaitedMs: time.Since(start).Milliseconds(),
+			}
+			switch {
+			case response.Error != nil:
+				result.LastError = &openai.RunLastError{Code: openai.RunError(response.Error.Code), Message: response.Error.Message}
+			case response.IncompleteDetails != nil:
+				result.LastError = &openai.RunLastError{Code: openai.RunError(response.IncompleteDetails.Reason), Message: "response incomplete"}
+			}
+			if response.Status == responseCompleted {
+				result.Messages = responseMessages(response.Output, runID, response.CreatedAt)
+			}
+			return result, nil
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-waitCtx.Done():
+			return &RunWaitResult{RunID: runID, ThreadID: threadID, Status: status, WaitedMs: time.Since(start).Milliseconds()}, nil
+		}
+		interval = interval * 3 / 2
+		if interval > runWaitMaxInterval {
+			interval = runWaitMaxInterval
+		}
+	}
+}
+
+// trackThread marks a conversation as used now
+func (s *OpenAIResponsesService) trackThread(threadID string) {
+	trackAssistantThread(s.db, s.logger, threadID)
+}
+
+// responseMessages converts the message items of a response or conversation into assistant messages.
+// Input and output text both become text content, as in Assistants API messages.
+func responseMessages(items []responseItem, runID string, createdAt int64) []AssistantMessage {
+	var messages []AssistantMessage
+	for _, item := range items {
+		if item.Type != "message" {
+			continue // Tool calls such as file_search
+		}
+		message := AssistantMessage{
+			ID:        item.ID,
+			Role:      item.Role,
+			CreatedAt: createdAt,
+			RunID:     runID,
+		}
+		for _, content := range item.Content {
+			switch content.Type {
+			case "input_text", "output_text":
+				message.Content = append(message.Content, MessageContent{
+					Type: "text",
+					Text: MessageTextData{Value: content.Text, Annotations: content.Annotations},
+				})
+			default:
+				message.Content = append(message.Content, MessageContent{Type: content.Type})
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// call sends a request to the OpenAI API and decodes the JSON response into out
+func (s *OpenAIResponsesService) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message := strings.TrimSpace(string(data))
+		var apiError struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiError) == nil && apiError.Error.Message != "" {
+			message = apiError.Error.Message
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrNotFound, message)
+		}
+		return &responsesAPIError{StatusCode: resp.StatusCode, Message: message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxPersonaPromptLength bounds the system prompt of a persona, which is sent with every message of its sessions
+const maxPersonaPromptLength = 4000
+
+var ErrPersonaAdminOnly = fmt.Errorf("%w: only admins can manage persona presets", ErrForbidden)
+
+// PersonaSpec is the editable part of a persona preset
+type PersonaSpec struct {
+	Name         string `json:"name" example:"Warehouse supervisor"`
+	Description  string `json:"description,omitempty" example:"Practical answers for the warehouse floor"`
+	SystemPrompt string `json:"system_prompt" example:"Answer as an experienced warehouse supervisor talking to a new team member."`
+	IsActive     *bool  `json:"is_active,omitempty"` // Inactive presets can no longer be picked and stop applying to their sessions
+}
+
+// PersonaService manages the persona presets users pick for their chat sessions
+type PersonaService struct {
+	db *gorm.DB
+}
+
+// NewPersonaService creates the persona service
+func NewPersonaService(db *gorm.DB) *PersonaService {
+	return &PersonaService{db: db}
+}
+
+// ListPersonas lists the persona presets by name; inactive ones only when includeInactive is set
+func (s *PersonaService) ListPersonas(ctx context.Context, includeInactive bool) ([]models.PersonaPreset, error) {
+	query := s.db.WithContext(ctx).Order("name ASC")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+	var personas []models.PersonaPreset
+	if err := query.Find(&personas).Error; err != nil {
+		return nil, err
+	}
+	return personas, nil
+}
+
+// CreatePersona stores a new persona preset
+func (s *PersonaService) CreatePersona(ctx context.Context, adminID uuid.UUID, spec PersonaSpec) (*models.PersonaPreset, error) {
+	if err := s.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+	if err := validatePersonaSpec(&spec); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(ctx, spec.Name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	persona := &models.PersonaPreset{IsActive: true, CreatedBy: adminID}
+	applyPersonaSpec(persona, spec, adminID)
+	if err := s.db.WithContext(ctx).Create(persona).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Admin %s created persona preset %s", adminID, persona.Name)
+	return persona, nil
+}
+
+// UpdatePersona replaces a persona preset. Sessions using it answer with the new prompt from their next message.
+func (s *PersonaService) UpdatePersona(ctx context.Context, adminID, id uuid.UUID, spec PersonaSpec) (*models.PersonaPreset, error) {
+	if err := s.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+	if err := validatePersonaSpec(&spec); err != nil {
+		return nil, err
+	}
+	var persona models.PersonaPreset
+	if err := s.db.WithContext(ctx).First(&persona, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "persona preset "+id.String())
+	}
+	if err := s.checkNameFree(ctx, spec.Name, id); err != nil {
+		return nil, err
+	}
+
+	applyPersonaSpec(&persona, spec, adminID)
+	if err := s.db.WithContext(ctx).Save(&persona).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Admin %s updated persona preset %s (active: %t)", adminID, persona.Name, persona.IsActive)
+	return &persona, nil
+}
+
+// SetSessionPersona makes a session of the user answer as a persona, or without one when personaID is nil
+func (s *PersonaService) SetSessionPersona(ctx context.Context, userID, sessionID uuid.UUID, personaID *uuid.UUID) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := s.db.WithContext(ctx).Where("id = ? AND user_id = ? AND is_active = ?", sessionID, userID, true).First(&session).Error
+	if err != nil {
+		return nil, notFound(err, "chat session "+sessionID.String())
+	}
+	if err := selectPersona(ctx, s.db, &session, personaID); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// selectPersona sets the persona of a session, checking that the preset can be picked
+func selectPersona(ctx context.Context, db *gorm.DB, session *models.ChatSession, personaID *uuid.UUID) error {
+	if sameUUID(session.PersonaID, personaID) {
+		return nil
+	}
+	if personaID != nil {
+		var persona models.PersonaPreset
+		if err := db.WithContext(ctx).Where("id = ? AND is_active = ?", *personaID, true).First(&persona).Error; err != nil {
+			return notFound(err, "persona preset "+personaID.String())
+		}
+		session.Persona = &persona
+	} else {
+		session.Persona = nil
+	}
+	if err := db.WithContext(ctx).Model(&models.ChatSession{}).Where("id = ?", session.ID).Update("persona_id", personaID).Error; err != nil {
+		return err
+	}
+	session.PersonaID = personaID
+	log.Printf("[INFO] Chat session %s now uses persona %v", session.ID, personaID)
+	return nil
+}
+
+// sessionPersonaPrompt returns the system prompt of the persona a session answers as, or "" when it has none
+// or its preset was deactivated. A failure to load it is logged and the session is answered without it.
+func sessionPersonaPrompt(ctx context.Context, db *gorm.DB, session *models.ChatSession) string {
+	if session.PersonaID == nil {
+		return ""
+	}
+	var persona models.PersonaPreset
+	err := db.WithContext(ctx).Select("system_prompt").Where("id = ? AND is_active = ?", *session.PersonaID, true).First(&persona).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[WARNING] Failed to load the persona of session %s, answering without it: %v", session.ID, err)
+		}
+		return ""
+	}
+	return persona.SystemPrompt
+}
+
+// checkNameFree rejects a name used by a persona other than except
+func (s *PersonaService) checkNameFree(ctx context.Context, name string, except uuid.UUID) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.PersonaPreset{}).Where("name = ? AND id <> ?", name, except).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return validationError("a persona preset named %q already exists", name)
+	}
+	return nil
+}
+
+func (s *PersonaService) requireAdmin(ctx context.Context, adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.WithContext(ctx).Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPersonaAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrPersonaAdminOnly
+	}
+	return nil
+}
+
+func validatePersonaSpec(spec *PersonaSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	spec.SystemPrompt = strings.TrimSpace(spec.SystemPrompt)
+	if spec.Name == "" {
+		return validationError("name is required")
+	}
+	if spec.SystemPrompt == "" {
+		return validationError("system_prompt is required")
+	}
+	if len(spec.SystemPrompt) > maxPersonaPromptLength {
+		return validationError("system_prompt must be at most %d characters", maxPersonaPromptLength)
+	}
+	return nil
+}
+
+func applyPersonaSpec(persona *models.PersonaPreset, spec PersonaSpec, adminID uuid.UUID) {
+	persona.Name = spec.Name
+	persona.Description = strings.TrimSpace(spec.Description)
+	persona.SystemPrompt = spec.SystemPrompt
+	if spec.IsActive != nil {
+		persona.IsActive = *spec.IsActive
+	}
+	persona.UpdatedBy = adminID
+}
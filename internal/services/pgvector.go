@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pgvector index types
+const (
+	PgvectorIndexHNSW    = "hnsw"
+	PgvectorIndexIVFFlat = "ivfflat"
+)
+
+// ivfflatLists is the number of lists of an ivfflat index, about rows/1000 for up to a million points
+const ivfflatLists = 100
+
+// PgVectorService stores vectors in PostgreSQL with the pgvector extension, for deployments that cannot run
+// Qdrant. The vectors are kept on the VectorEmbedding records with the same payload as in Qdrant, in a jsonb
+// column that Qdrant style filters are translated to SQL over, and scores are cosine similarities like Qdrant's.
+type PgVectorService struct {
+	db        *gorm.DB
+	dimension int
+	indexType string
+}
+
+// NewPgVectorService creates a pgvector store of vectors of dimension, indexed with HNSW
+func NewPgVectorService(db *gorm.DB, dimension int) *PgVectorService {
+	return &PgVectorService{db: db, dimension: dimension, indexType: PgvectorIndexHNSW}
+}
+
+// SetIndexType sets the index of the vectors, hnsw or ivfflat. ivfflat builds faster and smaller but
+// recalls less, and should be built once the table has most of its rows.
+func (s *PgVectorService) SetIndexType(indexType string) error {
+	switch indexType {
+	case PgvectorIndexHNSW, PgvectorIndexIVFFlat:
+		s.indexType = indexType
+		return nil
+	default:
+		return fmt.Errorf("unknown pgvector index %q, expected hnsw or ivfflat", indexType)
+	}
+}
+
+// EnsureExtension creates the pgvector extension. The columns of the vectors are migrated with VectorEmbedding.
+func (s *PgVectorService) EnsureExtension(ctx context.Context) error {
+	if s.dimension <= 0 {
+		return fmt.Errorf("invalid vector dimension %d", s.dimension)
+	}
+	return s.db.WithContext(ctx).Exec("CREATE EXTENSION IF NOT EXISTS vector").Error
+}
+
+// EnsurePayloadIndexes creates the similarity index of the vectors and the index of the payload filters.
+// The embedding column is a real[], so the index is over its cast to a vector of the configured dimension.
+func (s *PgVectorService) EnsurePayloadIndexes(ctx context.Context) error {
+	vectorIndex := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_vector_embeddings_embedding_%s ON vector_embeddings USING %s ((%s) vector_cosine_ops)",
+		s.indexType, s.indexType, s.vectorColumn())
+	if s.indexType == PgvectorIndexIVFFlat {
+		vectorIndex += fmt.Sprintf(" WITH (lists = %d)", ivfflatLists)
+	}
+	statements := []string{
+		vectorIndex,
+		"CREATE INDEX IF NOT EXISTS idx_vector_embeddings_payload ON vector_embeddings USING gin (payload jsonb_path_ops)",
+	}
+	for _, statement := range statements {
+		if err := s.db.WithContext(ctx).Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vectorColumn is the indexed expression of the vectors
+func (s *PgVectorService) vectorColumn() string {
+	return fmt.Sprintf("embedding::vector(%d)", s.dimension)
+}
+
+// setRecordVector puts a vector and its payload on an embedding record, which the knowledge service creates
+// with the other records of the entry in one transaction
+func (s *PgVectorService) setRecordVector(record *models.VectorEmbedding, vector []float32, extra map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"text":               record.ChunkText,
+		"knowledge_entry_id": record.KnowledgeEntryID.String(),
+	}
+	for key, value := range extra {
+		payload[key] = value
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	record.VectorID = uuid.NewString()
+	record.Embedding = vector
+	record.Payload = string(encoded)
+	return nil
+}
+
+// StoreWithPayload stores a vector together with additional payload fields, like VectorService, as an
+// embedding record of its own
+func (s *PgVectorService) StoreWithPayload(ctx context.Context, vector []float32, text string, knowledgeEntryID uuid.UUID, extra map[string]interface{}) (string, error) {
+	record := &models.VectorEmbedding{KnowledgeEntryID: knowledgeEntryID, ChunkText: text, Dimension: len(vector)}
+	if err := s.setRecordVector(record, vector, extra); err != nil {
+		return "", err
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return "", fmt.Errorf("failed to store vector: %w", err)
+	}
+	return record.VectorID, nil
+}
+
+// SearchByVectorWithFilter returns the points most similar to vector that match a Qdrant style filter
+func (s *PgVectorService) SearchByVectorWithFilter(ctx context.Context, vector []float32, limit int, filter map[string]interface{}) ([]VectorSearchResult, error) {
+	where, args, err := pgvectorFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	literal := vectorLiteral(vector)
+	distance := s.vectorColumn() + " <=> ?::vector"
+
+	var rows []struct {
+		Payload string
+		Score   float64
+	}
+	err = s.db.WithContext(ctx).Model(&models.VectorEmbedding{}).
+		Select("payload, 1 - ("+distance+") AS score", literal).
+		Where("embedding IS NOT NULL").
+		Where(where, args...).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: distance, Vars: []interface{}{literal}}}).
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+
+	var results []VectorSearchResult
+	for _, row := range rows {
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(row.Payload), &payload); err != nil {
+			continue
+		}
+		if found, ok := vectorSearchResult(row.Score, payload); ok {
+			results = append(results, found)
+		}
+	}
+	return results, nil
+}
+
+// DeleteByKnowledgeEntry clears the vectors of a knowledge entry. Its records are replaced or deleted by
+// the knowledge service.
+func (s *PgVectorService) DeleteByKnowledgeEntry(ctx context.Context, knowledgeEntryID uuid.UUID) error {
+	err := s.db.WithContext(ctx).Unscoped().Model(&models.VectorEmbedding{}).
+		Where("knowledge_entry_id = ? AND embedding IS NOT NULL", knowledgeEntryID).
+		Updates(map[string]interface{}{"embedding": nil, "payload": "{}"}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	return nil
+}
+
+// Vectors returns the stored vectors of points, skipping IDs that no longer exist
+func (s *PgVectorService) Vectors(ctx context.Context, pointIDs []string) ([][]float32, error) {
+	if len(pointIDs) == 0 {
+		return nil, nil
+	}
+	var stored []models.Vector
+	err := s.db.WithContext(ctx).Model(&models.VectorEmbedding{}).
+		Where("vector_id IN ? AND embedding IS NOT NULL", pointIDs).
+		Pluck("embedding", &stored).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve vectors: %w", err)
+	}
+	vectors := make([][]float32, 0, len(stored))
+	for _, vector := range stored {
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// Ping checks that the pgvector extension is usable
+func (s *PgVectorService) Ping(ctx context.Context) error {
+	return s.db.WithContext(ctx).Exec("SELECT '[1]'::vector").Error
+}
+
+// CollectionInfo returns the point count and vector dimension of the embeddings
+func (s *PgVectorService) CollectionInfo(ctx context.Context) (*QdrantCollectionInfo, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.VectorEmbedding{}).Where("embedding IS NOT NULL").Count(&count).Error; err != nil {
+		return nil, err
+	}
+	return &QdrantCollectionInfo{Name: "vector_embeddings", Status: "green", PointsCount: count, Dimension: s.dimension}, nil
+}
+
+// vectorLiteral formats a vector as pgvector's text input, e.g. [0.1,0.2]
+func vectorLiteral(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, value := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// qdrantCondition is a Qdrant filter, or a condition of one
+type qdrantCondition struct {
+	Key   string `json:"key"`
+	Match *struct {
+		Value interface{}   `json:"value"`
+		Any   []interface{} `json:"any"`
+	} `json:"match"`
+	Range   map[string]float64 `json:"range"`
+	IsEmpty *struct {
+		Key string `json:"key"`
+	} `json:"is_empty"`
+	Must    []qdrantCondition `json:"must"`
+	Should  []qdrantCondition `json:"should"`
+	MustNot []qdrantCondition `json:"must_not"`
+}
+
+// pgvectorFilter translates a Qdrant filter into a predicate on the payload column. It supports the
+// conditions the knowledge service builds: match value and any, range, is_empty, and nested must, should
+// and must_not clauses.
+func pgvectorFilter(filter map[string]interface{}) (string, []interface{}, error) {
+	if len(filter) == 0 {
+		return "TRUE", nil, nil
+	}
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	var condition qdrantCondition
+	if err := json.Unmarshal(encoded, &condition); err != nil {
+		return "", nil, fmt.Errorf("unsupported vector filter: %w", err)
+	}
+	return condition.sql()
+}
+
+// sql returns the predicate of a condition; all of its parts must hold
+func (c qdrantCondition) sql() (string, []interface{}, error) {
+	var parts []string
+	var args []interface{}
+	add := func(part string, partArgs ...interface{}) {
+		parts = append(parts, part)
+		args = append(args, partArgs...)
+	}
+
+	if c.Match != nil {
+		if c.Key == "" {
+			return "", nil, errors.New("unsupported vector filter: match without key")
+		}
+		values := c.Match.Any
+		if c.Match.Value != nil {
+			values = append(values, c.Match.Value)
+		}
+		if len(values) == 0 {
+			return "FALSE", nil, nil
+		}
+		// The field equals the value or is an array holding it, like a Qdrant match on either. Containment of
+		// the whole payload is what the payload index serves.
+		var matches []string
+		var matchArgs []interface{}
+		for _, value := range values {
+			scalar, err := json.Marshal(map[string]interface{}{c.Key: value})
+			if err != nil {
+				return "", nil, err
+			}
+			array, _ := json.Marshal(map[string]interface{}{c.Key: []interface{}{value}})
+			matches = append(matches, "payload @> ?::jsonb OR payload @> ?::jsonb")
+			matchArgs = append(matchArgs, string(scalar), string(array))
+		}
+		add("("+strings.Join(matches, " OR ")+")", matchArgs...)
+	}
+	if len(c.Range) > 0 {
+		if c.Key == "" {
+			return "", nil, errors.New("unsupported vector filter: range without key")
+		}
+		operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+		for name, bound := range c.Range {
+			operator, ok := operators[name]
+			if !ok {
+				return "", nil, fmt.Errorf("unsupported vector filter: range %q", name)
+			}
+			add("CASE WHEN jsonb_typeof(payload->?::text) = 'number' THEN (payload->>?::text)::numeric "+operator+" ? ELSE false END", c.Key, c.Key, bound)
+		}
+	}
+	if c.IsEmpty != nil {
+		add("(payload->?::text IS NULL OR payload->?::text IN ('null'::jsonb, '[]'::jsonb))", c.IsEmpty.Key, c.IsEmpty.Key)
+	}
+	if c.Match == nil && len(c.Range) == 0 && c.IsEmpty == nil && c.Key != "" {
+		return "", nil, fmt.Errorf("unsupported vector filter on %q", c.Key)
+	}
+
+	for _, sub := range c.Must {
+		part, partArgs, err := sub.sql()
+		if err != nil {
+			return "", nil, err
+		}
+		add(part, partArgs...)
+	}
+	if len(c.Should) > 0 {
+		part, partArgs, err := anyOf(c.Should)
+		if err != nil {
+			return "", nil, err
+		}
+		add(part, partArgs...)
+	}
+	if len(c.MustNot) > 0 {
+		part, partArgs, err := anyOf(c.MustNot)
+		if err != nil {
+			return "", nil, err
+		}
+		add("NOT "+part, partArgs...)
+	}
+
+	if len(parts) == 0 {
+		return "TRUE", nil, nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", args, nil
+}
+
+// anyOf returns the predicate that holds when any of the conditions does
+func anyOf(conditions []qdrantCondition) (string, []interface{}, error) {
+	var parts []string
+	var args []interface{}
+	for _, condition := range conditions {
+		part, partArgs, err := condition.sql()
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, part)
+		args = append(args, partArgs...)
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args, nil
+}
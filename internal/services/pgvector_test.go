@@ -0,0 +1,135 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"tic-knowledge-system/internal/models"
+)
+
+func TestPgvectorFilter(t *testing.T) {
+	const (
+		isEmpty = "(payload->?::text IS NULL OR payload->?::text IN ('null'::jsonb, '[]'::jsonb))"
+		matches = "(payload @> ?::jsonb OR payload @> ?::jsonb)"
+	)
+	// emptyOrMatch is a scope condition: the field is missing or empty, or it matches
+	emptyOrMatch := "(((" + isEmpty + ") OR (" + matches + ")))"
+	publishedArgs := []interface{}{"is_published", "is_published", `{"is_published":true}`, `{"is_published":[true]}`}
+	concat := func(parts ...[]interface{}) []interface{} {
+		var all []interface{}
+		for _, part := range parts {
+			all = append(all, part...)
+		}
+		return all
+	}
+
+	tests := []struct {
+		name     string
+		filter   map[string]interface{}
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:    "no filter",
+			wantSQL: "TRUE",
+		},
+		{
+			name:     "match value",
+			filter:   map[string]interface{}{"key": "category", "match": map[string]interface{}{"value": "printers"}},
+			wantSQL:  "(" + matches + ")",
+			wantArgs: []interface{}{`{"category":"printers"}`, `{"category":["printers"]}`},
+		},
+		{
+			name:    "match any",
+			filter:  map[string]interface{}{"key": "tags", "match": map[string]interface{}{"any": []string{"zebra", "labels"}}},
+			wantSQL: "((payload @> ?::jsonb OR payload @> ?::jsonb OR payload @> ?::jsonb OR payload @> ?::jsonb))",
+			wantArgs: []interface{}{
+				`{"tags":"zebra"}`, `{"tags":["zebra"]}`, `{"tags":"labels"}`, `{"tags":["labels"]}`,
+			},
+		},
+		{
+			name:    "match any of nothing",
+			filter:  map[string]interface{}{"key": "tags", "match": map[string]interface{}{"any": []string{}}},
+			wantSQL: "FALSE",
+		},
+		{
+			name:     "range",
+			filter:   map[string]interface{}{"key": "priority", "range": map[string]interface{}{"gte": 3}},
+			wantSQL:  "(CASE WHEN jsonb_typeof(payload->?::text) = 'number' THEN (payload->>?::text)::numeric >= ? ELSE false END)",
+			wantArgs: []interface{}{"priority", "priority", float64(3)},
+		},
+		{
+			name: "must not",
+			filter: map[string]interface{}{"must_not": []map[string]interface{}{
+				{"key": "category", "match": map[string]interface{}{"value": "archive"}},
+			}},
+			wantSQL:  "(NOT ((" + matches + ")))",
+			wantArgs: []interface{}{`{"category":"archive"}`, `{"category":["archive"]}`},
+		},
+		{
+			name:     "admin scope",
+			filter:   RetrievalScope{Role: models.AdminRole}.QdrantFilter(),
+			wantSQL:  "(" + emptyOrMatch + ")",
+			wantArgs: publishedArgs,
+		},
+		{
+			name:    "restricted scope",
+			filter:  RetrievalScope{Role: models.SupportRole, Teams: []string{"billing"}}.QdrantFilter(),
+			wantSQL: "(" + emptyOrMatch + " AND " + emptyOrMatch + " AND " + emptyOrMatch + ")",
+			wantArgs: concat(publishedArgs,
+				[]interface{}{"allowed_roles", "allowed_roles", `{"allowed_roles":"support"}`, `{"allowed_roles":["support"]}`},
+				[]interface{}{"allowed_teams", "allowed_teams", `{"allowed_teams":"billing"}`, `{"allowed_teams":["billing"]}`}),
+		},
+		{
+			name:     "restricted scope without teams",
+			filter:   RetrievalScope{Role: models.SupportRole}.QdrantFilter(),
+			wantSQL:  "(" + emptyOrMatch + " AND " + emptyOrMatch + " AND (((" + isEmpty + "))))",
+			wantArgs: concat(publishedArgs, []interface{}{"allowed_roles", "allowed_roles", `{"allowed_roles":"support"}`, `{"allowed_roles":["support"]}`}, []interface{}{"allowed_teams", "allowed_teams"}),
+		},
+		{
+			name: "scope with retrieval filters",
+			filter: RetrievalScope{Role: models.AdminRole, Filters: RetrievalFilters{
+				Categories: []string{"printers"}, MinPriority: 3,
+			}}.QdrantFilter(),
+			wantSQL: "(" + emptyOrMatch + " AND (" + matches + ")" +
+				" AND (CASE WHEN jsonb_typeof(payload->?::text) = 'number' THEN (payload->>?::text)::numeric >= ? ELSE false END))",
+			wantArgs: concat(publishedArgs, []interface{}{`{"category":"printers"}`, `{"category":["printers"]}`, "priority", "priority", float64(3)}),
+		},
+		{
+			name:    "unsupported range",
+			filter:  map[string]interface{}{"key": "priority", "range": map[string]interface{}{"ne": 3}},
+			wantErr: true,
+		},
+		{
+			name:    "condition without a match",
+			filter:  map[string]interface{}{"key": "priority"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := pgvectorFilter(tt.filter)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("no error, got %s", sql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pgvectorFilter: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql\n%s\nwant\n%s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args %#v, want %#v", args, tt.wantArgs)
+			}
+			if placeholders := strings.Count(sql, "?"); placeholders != len(args) {
+				t.Errorf("%d placeholders for %d args", placeholders, len(args))
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import "strings"
+
+// modelPrice is the list price of a model in USD per 1K tokens
+type modelPrice struct {
+	prompt     float64
+	completion float64
+}
+
+// modelPrices maps model name prefixes to their list prices. Longer prefixes must
+// come before shorter ones so that e.g. gpt-4o-mini is not priced as gpt-4o.
+var modelPrices = []struct {
+	prefix string
+	price  modelPrice
+}{
+	{"gpt-4o-mini", modelPrice{prompt: 0.00015, completion: 0.0006}},
+	{"gpt-4o", modelPrice{prompt: 0.0025, completion: 0.01}},
+	{"gpt-4-turbo", modelPrice{prompt: 0.01, completion: 0.03}},
+	{"gpt-4", modelPrice{prompt: 0.03, completion: 0.06}},
+	{"gpt-3.5-turbo", modelPrice{prompt: 0.0005, completion: 0.0015}},
+	{"gemini-1.5-flash", modelPrice{prompt: 0.000075, completion: 0.0003}},
+	{"gemini-1.5-pro", modelPrice{prompt: 0.00125, completion: 0.005}},
+	{"gemini-2.0-flash", modelPrice{prompt: 0.0001, completion: 0.0004}},
+}
+
+// EstimateCost returns the estimated cost in USD of a completion. Unknown models cost 0.
+func EstimateCost(model string, usage TokenUsage) float64 {
+	model = strings.ToLower(model)
+	for _, entry := range modelPrices {
+		if strings.HasPrefix(model, entry.prefix) {
+			return float64(usage.PromptTokens)/1000*entry.price.prompt +
+				float64(usage.CompletionTokens)/1000*entry.price.completion
+		}
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"tic-knowledge-system/internal/models"
+)
+
+const injectionExcerptChars = 60
+
+// InjectionFinding is a passage of a document that looks like an instruction aimed at the assistant
+type InjectionFinding struct {
+	Rule    string `json:"rule"`
+	Count   int    `json:"count"`
+	Excerpt string `json:"excerpt"` // First match with some surrounding text
+}
+
+type injectionRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// injectionRules match phrasing used to hijack an assistant. Reference documents describe how things
+// work; they have no reason to address the model reading them.
+var injectionRules = []injectionRule{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+|the\s+)?(previous|prior|above|earlier|preceding|your|system)\s+(instructions|prompts?|rules|directions|guidelines)`)},
+	{"role_override", regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(a|an|the|in)\b|\bfrom\s+now\s+on,?\s+you\s+(will|must|are|should)\b|\bact\s+as\s+(a|an)\s+(unrestricted|unfiltered|jailbroken)`)},
+	{"prompt_disclosure", regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|leak)\s+(your|the)\s+(system\s+prompt|initial\s+prompt|hidden\s+(prompt|instructions))`)},
+	{"chat_markup", regexp.MustCompile(`(?im)<\|im_start\|>|<\|im_end\|>|<\|system\|>|\[/?INST\]|<<SYS>>|^\s*(system|assistant)\s*:\s`)},
+	{"jailbreak", regexp.MustCompile(`(?i)\b(jailbreak|DAN\s+mode|developer\s+mode\s+enabled|do\s+anything\s+now)\b`)},
+	{"concealment", regexp.MustCompile(`(?i)\b(do\s+not|don't|never)\s+(tell|inform|mention\s+(this\s+)?to|reveal\s+(this\s+)?to)\s+the\s+user\b|\bwithout\s+telling\s+the\s+user\b`)},
+	{"exfiltration", regexp.MustCompile(`(?i)\b(send|post|forward|upload|email)\s+(the\s+|all\s+)?(conversation|chat\s+history|user'?s?\s+data|credentials|passwords|api\s+keys?)\s+to\b`)},
+}
+
+// ScanForPromptInjection returns the passages of text that look like instructions aimed at the assistant
+func ScanForPromptInjection(text string) []InjectionFinding {
+	var findings []InjectionFinding
+	for _, rule := range injectionRules {
+		matches := rule.pattern.FindAllStringIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		findings = append(findings, InjectionFinding{
+			Rule:    rule.name,
+			Count:   len(matches),
+			Excerpt: injectionExcerpt(text, matches[0][0], matches[0][1]),
+		})
+	}
+	return findings
+}
+
+func injectionExcerpt(text string, start, end int) string {
+	from := start - injectionExcerptChars
+	if from < 0 {
+		from = 0
+	}
+	to := end + injectionExcerptChars
+	if to > len(text) {
+		to = len(text)
+	}
+	// Keep the excerpt on rune boundaries
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	return strings.Join(strings.Fields(text[from:to]), " ")
+}
+
+// scanContentForPromptInjection scans the text of an uploaded file. Files whose text cannot be extracted,
+// such as PDFs, are not scanned.
+func scanContentForPromptInjection(content []byte, name string) []InjectionFinding {
+	text, err := extractText(content, filepath.Ext(name))
+	if err != nil {
+		log.Printf("[WARNING] Not scanning %s for prompt injection: %v", name, err)
+		return nil
+	}
+	return ScanForPromptInjection(text)
+}
+
+func newDocumentQuarantine(source models.QuarantineSource, fileName string, findings []InjectionFinding) *models.DocumentQuarantine {
+	encoded, _ := json.Marshal(findings)
+	return &models.DocumentQuarantine{
+		Source:            source,
+		FileName:          fileName,
+		KnowledgeEntryIDs: "[]",
+		Findings:          string(encoded),
+		Status:            models.QuarantinePending,
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Prompt template names rendered by the chat providers
+const (
+	PromptSupportAssistant   = "support_assistant"   // OpenAI and Ollama system prompt
+	PromptKnowledgeAssistant = "knowledge_assistant" // Gemini system instruction
+)
+
+// Variables every prompt can use without declaring them
+const (
+	PromptVarDate     = "date"     // Current date, YYYY-MM-DD
+	PromptVarProvider = "provider" // AI provider rendering the prompt
+)
+
+const promptCacheTTL = 30 * time.Second
+
+var promptVariablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+
+// PromptSource renders the active version of a named prompt template
+type PromptSource interface {
+	RenderActive(ctx context.Context, name string, vars map[string]string) (string, bool)
+}
+
+// PromptTemplateSpec is the editable part of a prompt template
+type PromptTemplateSpec struct {
+	Description string            `json:"description,omitempty"`
+	Content     string            `json:"content"`
+	Variables   map[string]string `json:"variables,omitempty"` // Variable names and their default values
+	IsActive    bool              `json:"is_active"`
+}
+
+// PromptService stores versioned system prompts and renders the active ones for the chat providers
+type PromptService struct {
+	db *gorm.DB
+
+	mu    sync.Mutex
+	cache map[string]cachedPrompt
+}
+
+type cachedPrompt struct {
+	prompt    *models.PromptTemplate // nil when the name has no active version
+	expiresAt time.Time
+}
+
+// NewPromptService creates the prompt template service
+func NewPromptService(db *gorm.DB) *PromptService {
+	return &PromptService{db: db, cache: make(map[string]cachedPrompt)}
+}
+
+// ListPrompts lists prompt templates, newest version first
+func (s *PromptService) ListPrompts(name string, activeOnly bool) ([]models.PromptTemplate, error) {
+	var prompts []models.PromptTemplate
+	query := s.db.Order("name ASC, version DESC")
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Find(&prompts).Error
+	return prompts, err
+}
+
+// GetPrompt returns a prompt template version by ID
+func (s *PromptService) GetPrompt(id uuid.UUID) (*models.PromptTemplate, error) {
+	var prompt models.PromptTemplate
+	if err := s.db.First(&prompt, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "prompt template "+id.String())
+	}
+	return &prompt, nil
+}
+
+// CreatePrompt stores the spec as the next version of the named prompt
+func (s *PromptService) CreatePrompt(name string, spec PromptTemplateSpec, createdBy *uuid.UUID) (*models.PromptTemplate, error) {
+	if name == "" {
+		return nil, validationError("name is required")
+	}
+	variables, err := validatePromptSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := &models.PromptTemplate{
+		Name:        name,
+		Description: spec.Description,
+		Content:     spec.Content,
+		Variables:   variables,
+		IsActive:    spec.IsActive,
+		CreatedBy:   createdBy,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return createPromptVersion(tx, prompt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(name)
+	log.Printf("[INFO] Created prompt template %s version %d (active=%t)", name, prompt.Version, prompt.IsActive)
+	return prompt, nil
+}
+
+// UpdatePrompt replaces the content of a prompt template version. Activating it deactivates the other versions.
+func (s *PromptService) UpdatePrompt(id uuid.UUID, spec PromptTemplateSpec) (*models.PromptTemplate, error) {
+	variables, err := validatePromptSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := s.GetPrompt(id)
+	if err != nil {
+		return nil, err
+	}
+	prompt.Description = spec.Description
+	prompt.Content = spec.Content
+	prompt.Variables = variables
+	prompt.IsActive = spec.IsActive
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(prompt).Error; err != nil {
+			return err
+		}
+		if prompt.IsActive {
+			return deactivateOtherVersions(tx, prompt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(prompt.Name)
+	log.Printf("[INFO] Updated prompt template %s version %d (active=%t)", prompt.Name, prompt.Version, prompt.IsActive)
+	return prompt, nil
+}
+
+// DeletePrompt deletes a prompt template version. Providers fall back to their built-in prompt
+// when a name has no active version left.
+func (s *PromptService) DeletePrompt(id uuid.UUID) error {
+	prompt, err := s.GetPrompt(id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(prompt).Error; err != nil {
+		return err
+	}
+	s.invalidate(prompt.Name)
+	return nil
+}
+
+// RenderActive renders the active version of a prompt. Placeholders are filled from vars,
+// then from the defaults declared by the template. It reports false when no version is active.
+func (s *PromptService) RenderActive(ctx context.Context, name string, vars map[string]string) (string, bool) {
+	prompt, ok := ctx.Value(promptOverrideKey{}).(*models.PromptTemplate)
+	if !ok || prompt.Name != name {
+		var err error
+		prompt, err = s.active(ctx, name)
+		if err != nil {
+			log.Printf("[WARNING] Failed to load prompt template %s, using built-in prompt: %v", name, err)
+			return "", false
+		}
+	}
+	if prompt == nil {
+		return "", false
+	}
+
+	defaults := map[string]string{}
+	if prompt.Variables != "" {
+		if err := json.Unmarshal([]byte(prompt.Variables), &defaults); err != nil {
+			log.Printf("[WARNING] Invalid variables on prompt template %s version %d: %v", name, prompt.Version, err)
+		}
+	}
+	return promptVariablePattern.ReplaceAllStringFunc(prompt.Content, func(placeholder string) string {
+		variable := promptVariablePattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := vars[variable]; ok {
+			return value
+		}
+		if value, ok := defaults[variable]; ok {
+			return value
+		}
+		return placeholder
+	}), true
+}
+
+type promptOverrideKey struct{}
+
+// withPromptOverride makes RenderActive render prompt in place of the active version of its name, so that a
+// version can be tried out before it is activated
+func withPromptOverride(ctx context.Context, prompt *models.PromptTemplate) context.Context {
+	return context.WithValue(ctx, promptOverrideKey{}, prompt)
+}
+
+// active returns the active version of a prompt, cached briefly since it is read on every chat
+func (s *PromptService) active(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.prompt, nil
+	}
+
+	var prompt models.PromptTemplate
+	err := s.db.WithContext(ctx).Where("name = ? AND is_active = ?", name, true).Order("version DESC").Take(&prompt).Error
+	var active *models.PromptTemplate
+	if err == nil {
+		active = &prompt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cachedPrompt{prompt: active, expiresAt: time.Now().Add(promptCacheTTL)}
+	s.mu.Unlock()
+	return active, nil
+}
+
+func (s *PromptService) invalidate(name string) {
+	s.mu.Lock()
+	delete(s.cache, name)
+	s.mu.Unlock()
+}
+
+// createPromptVersion stores a prompt as the next version of its name
+func createPromptVersion(tx *gorm.DB, prompt *models.PromptTemplate) error {
+	var latest int
+	if err := tx.Model(&models.PromptTemplate{}).Where("name = ?", prompt.Name).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+	prompt.Version = latest + 1
+	if err := tx.Create(prompt).Error; err != nil {
+		return err
+	}
+	if prompt.IsActive {
+		return deactivateOtherVersions(tx, prompt)
+	}
+	return nil
+}
+
+func deactivateOtherVersions(tx *gorm.DB, prompt *models.PromptTemplate) error {
+	return tx.Model(&models.PromptTemplate{}).
+		Where("name = ? AND id <> ? AND is_active = ?", prompt.Name, prompt.ID, true).
+		Update("is_active", false).Error
+}
+
+// validatePromptSpec checks that every placeholder is declared or built in and returns the variables as JSON
+func validatePromptSpec(spec PromptTemplateSpec) (string, error) {
+	if spec.Content == "" {
+		return "", validationError("content is required")
+	}
+	for _, match := range promptVariablePattern.FindAllStringSubmatch(spec.Content, -1) {
+		variable := match[1]
+		if variable == PromptVarDate || variable == PromptVarProvider {
+			continue
+		}
+		if _, ok := spec.Variables[variable]; !ok {
+			return "", validationError("variable %q is used in content but not declared", variable)
+		}
+	}
+
+	variables := spec.Variables
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// resolvePrompt renders the active version of a prompt, falling back to the built-in prompt
+func resolvePrompt(ctx context.Context, source PromptSource, name string, provider AIProvider, fallback string) string {
+	if source == nil {
+		return fallback
+	}
+	vars := map[string]string{
+		PromptVarDate:     time.Now().Format("2006-01-02"),
+		PromptVarProvider: string(provider),
+	}
+	if prompt, ok := source.RenderActive(ctx, name, vars); ok {
+		return prompt
+	}
+	return fallback
+}
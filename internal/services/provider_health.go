@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Requests flow normally
+	CircuitOpen     = "open"      // Provider is skipped until the cooldown elapses
+	CircuitHalfOpen = "half_open" // One trial request is allowed to test recovery
+)
+
+const (
+	defaultCircuitFailureThreshold = 3
+	defaultCircuitCooldown         = 30 * time.Second
+	providerProbeTimeout           = 10 * time.Second
+)
+
+// ProviderHealth reports the circuit breaker state and recent latency of a provider
+type ProviderHealth struct {
+	Provider            AIProvider `json:"provider"`
+	State               string     `json:"state"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastLatencyMs       int64      `json:"last_latency_ms"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastProbeAt         *time.Time `json:"last_probe_at,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// ProviderHealthMonitor tracks provider failures and trips a circuit breaker per provider
+// after a number of consecutive failures from requests or health probes
+type ProviderHealthMonitor struct {
+	mu               sync.Mutex
+	providers        map[AIProvider]*ProviderHealth
+	failureThreshold int
+	cooldown         time.Duration
+}
+
+// NewProviderHealthMonitor creates a monitor that opens a provider's circuit after failureThreshold
+// consecutive failures and allows a trial request after cooldown
+func NewProviderHealthMonitor(failureThreshold int, cooldown time.Duration) *ProviderHealthMonitor {
+	if failureThreshold <= 0 {
+		failureThreshold = defaultCircuitFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCircuitCooldown
+	}
+	return &ProviderHealthMonitor{
+		providers:        make(map[AIProvider]*ProviderHealth),
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+	}
+}
+
+// health returns the tracked state of a provider, creating it on first use. Callers hold mu.
+func (m *ProviderHealthMonitor) health(provider AIProvider) *ProviderHealth {
+	health, ok := m.providers[provider]
+	if !ok {
+		health = &ProviderHealth{Provider: provider, State: CircuitClosed, Healthy: true}
+		m.providers[provider] = health
+	}
+	return health
+}
+
+// Allow reports whether a request may be sent to the provider. An open circuit moves to
+// half-open once the cooldown has elapsed so that a single trial request can go through.
+func (m *ProviderHealthMonitor) Allow(provider AIProvider) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health := m.health(provider)
+	switch health.State {
+	case CircuitOpen:
+		if health.OpenedAt != nil && time.Since(*health.OpenedAt) >= m.cooldown {
+			health.State = CircuitHalfOpen
+			log.Printf("[INFO] Circuit for provider %s is half-open, sending a trial request", provider)
+			return true
+		}
+		return false
+	case CircuitHalfOpen:
+		// A trial request is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the provider's circuit
+func (m *ProviderHealthMonitor) RecordSuccess(provider AIProvider, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health := m.health(provider)
+	now := time.Now()
+	if health.State != CircuitClosed {
+		log.Printf("[INFO] Circuit for provider %s closed after a successful call", provider)
+	}
+	health.State = CircuitClosed
+	health.Healthy = true
+	health.ConsecutiveFailures = 0
+	health.LastLatencyMs = latency.Milliseconds()
+	health.LastError = ""
+	health.LastSuccessAt = &now
+	health.OpenedAt = nil
+}
+
+// RecordFailure counts a failure and opens the circuit once the threshold is reached.
+// A failed trial request in the half-open state reopens the circuit immediately.
+func (m *ProviderHealthMonitor) RecordFailure(provider AIProvider, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health := m.health(provider)
+	now := time.Now()
+	health.ConsecutiveFailures++
+	health.LastLatencyMs = latency.Milliseconds()
+	health.LastFailureAt = &now
+	if err != nil {
+		health.LastError = err.Error()
+	}
+
+	if health.State == CircuitHalfOpen || (health.State == CircuitClosed && health.ConsecutiveFailures >= m.failureThreshold) {
+		health.State = CircuitOpen
+		health.Healthy = false
+		health.OpenedAt = &now
+		log.Printf("[WARNING] Circuit for provider %s opened after %d consecutive failures: %v", provider, health.ConsecutiveFailures, err)
+	} else if health.State == CircuitOpen {
+		// Failures while open (e.g. probes) extend the cooldown
+		health.OpenedAt = &now
+	}
+}
+
+// recordProbe stamps the time of the last health probe
+func (m *ProviderHealthMonitor) recordProbe(provider AIProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.health(provider).LastProbeAt = &now
+}
+
+// Snapshot returns the health of the given providers
+func (m *ProviderHealthMonitor) Snapshot(providers []AIProvider) []ProviderHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]ProviderHealth, 0, len(providers))
+	for _, provider := range providers {
+		snapshot = append(snapshot, *m.health(provider))
+	}
+	return snapshot
+}
+
+// SetHealthMonitor replaces the circuit breaker settings of the service
+func (s *UnifiedAIService) SetHealthMonitor(monitor *ProviderHealthMonitor) {
+	s.health = monitor
+}
+
+// GetProviderHealth returns the circuit breaker state and latency of every available provider
+func (s *UnifiedAIService) GetProviderHealth() []ProviderHealth {
+	return s.health.Snapshot(s.GetAvailableProviders())
+}
+
+// StartHealthChecks probes every available provider at the given interval until ctx is cancelled.
+// Probes feed the same circuit breaker as real requests, so a provider that is down is skipped
+// before users hit it, and one that recovers is closed again without waiting for a request.
+func (s *UnifiedAIService) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	s.ProbeProviders(ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.ProbeProviders(ctx)
+			}
+		}
+	}()
+}
+
+// ProbeProviders pings every available provider once and returns those that answered
+func (s *UnifiedAIService) ProbeProviders(ctx context.Context) []AIProvider {
+	var reachable []AIProvider
+	for _, provider := range s.GetAvailableProviders() {
+		probeCtx, cancel := context.WithTimeout(ctx, providerProbeTimeout)
+		start := time.Now()
+		err := s.pingProvider(probeCtx, provider)
+		cancel()
+
+		s.health.recordProbe(provider)
+		if err != nil {
+			log.Printf("[WARNING] Health probe for provider %s failed: %v", provider, err)
+			s.health.RecordFailure(provider, time.Since(start), err)
+		} else {
+			s.health.RecordSuccess(provider, time.Since(start))
+			reachable = append(reachable, provider)
+		}
+	}
+	return reachable
+}
+
+// pingProvider runs the cheapest available check against a provider
+func (s *UnifiedAIService) pingProvider(ctx context.Context, provider AIProvider) error {
+	switch provider {
+	case OpenAIProvider:
+		return s.openAIService.Ping(ctx)
+	case GeminiProvider:
+		return s.geminiService.Ping(ctx)
+	case OllamaProvider:
+		return s.ollamaService.Ping(ctx)
+	default:
+		return nil
+	}
+}
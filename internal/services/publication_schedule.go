@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// publicationScheduleBatch bounds the entries one run of the publication schedule flips
+const publicationScheduleBatch = 500
+
+// PublicationScheduleRun reports a run of the publication schedule
+type PublicationScheduleRun struct {
+	Published   int `json:"published"`
+	Unpublished int `json:"unpublished"`
+	Failed      int `json:"failed"`
+}
+
+// validatePublicationSchedule rejects an unpublish time that does not come after the publish time
+func validatePublicationSchedule(entry *models.KnowledgeEntry) error {
+	if entry.PublishAt != nil && entry.UnpublishAt != nil && !entry.UnpublishAt.After(*entry.PublishAt) {
+		return validationError("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// applyPublicationSchedule sets the publication state an entry has at now. A publish or unpublish time that
+// has come is cleared once applied, so that the entry can be unpublished or published again by hand; an entry
+// with a publish time still to come stays unpublished until then.
+func applyPublicationSchedule(entry *models.KnowledgeEntry, now time.Time) {
+	if entry.PublishAt != nil {
+		entry.IsPublished = !entry.PublishAt.After(now)
+		if entry.IsPublished {
+			entry.PublishAt = nil
+		}
+	}
+	if entry.UnpublishAt != nil && !entry.UnpublishAt.After(now) {
+		entry.IsPublished = false
+		entry.UnpublishAt = nil
+	}
+}
+
+// ApplyPublicationSchedule publishes and unpublishes the entries whose publish_at or unpublish_at time has
+// come. Their embeddings are generated or removed like on any publication change.
+func (s *KnowledgeService) ApplyPublicationSchedule(ctx context.Context) (*PublicationScheduleRun, error) {
+	now := time.Now()
+	var due []models.KnowledgeEntry
+	err := s.db.WithContext(ctx).
+		Where("publish_at <= ? OR unpublish_at <= ?", now, now).
+		Order("coalesce(publish_at, unpublish_at)").
+		Limit(publicationScheduleBatch).
+		Find(&due).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load entries due for publication changes: %w", err)
+	}
+
+	run := &PublicationScheduleRun{}
+	for i := range due {
+		entry := &due[i]
+		wasPublished := entry.IsPublished
+		applyPublicationSchedule(entry, now)
+
+		err := s.writeWithEmbeddings(ctx, entry, entry.IsPublished != wasPublished, func(tx *gorm.DB) error {
+			return tx.Model(entry).Updates(map[string]interface{}{
+				"is_published": entry.IsPublished,
+				"publish_at":   entry.PublishAt,
+				"unpublish_at": entry.UnpublishAt,
+			}).Error
+		})
+		if err != nil {
+			log.Printf("[WARNING] Failed to apply the publication schedule of entry %s: %v", entry.ID, err)
+			run.Failed++
+			continue
+		}
+		switch {
+		case entry.IsPublished && !wasPublished:
+			run.Published++
+		case !entry.IsPublished && wasPublished:
+			run.Unpublished++
+		}
+	}
+	if run.Published > 0 || run.Unpublished > 0 || run.Failed > 0 {
+		log.Printf("[INFO] Applied the publication schedule: %d published, %d unpublished, %d failed", run.Published, run.Unpublished, run.Failed)
+	}
+	return run, nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrQuarantineAdminOnly is returned when a non-admin reviews a quarantined document
+var ErrQuarantineAdminOnly = fmt.Errorf("%w: only admins can review quarantined documents", ErrForbidden)
+
+// QuarantineFilter filters quarantined documents
+type QuarantineFilter struct {
+	Status models.QuarantineStatus
+	Limit  int
+	Offset int
+}
+
+// QuarantineService lets admins review ingested documents flagged by the prompt injection scanner
+type QuarantineService struct {
+	db               *gorm.DB
+	knowledgeService *KnowledgeService
+	ingestion        *IngestionService
+}
+
+// NewQuarantineService creates the document quarantine review service
+func NewQuarantineService(db *gorm.DB, knowledgeService *KnowledgeService, ingestion *IngestionService) *QuarantineService {
+	return &QuarantineService{
+		db:               db,
+		knowledgeService: knowledgeService,
+		ingestion:        ingestion,
+	}
+}
+
+// List lists quarantined documents, newest first
+func (s *QuarantineService) List(filter QuarantineFilter) ([]models.DocumentQuarantine, int64, error) {
+	var quarantines []models.DocumentQuarantine
+	var total int64
+
+	query := s.db.Model(&models.DocumentQuarantine{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&quarantines).Error; err != nil {
+		return nil, 0, err
+	}
+	return quarantines, total, nil
+}
+
+// Approve releases a quarantined document: an upload is sent on to its target and the entries
+// of a knowledge import are published and embedded
+func (s *QuarantineService) Approve(ctx context.Context, id, adminID uuid.UUID, note string) (*models.DocumentQuarantine, error) {
+	quarantine, err := s.pending(ctx, id, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch quarantine.Source {
+	case models.QuarantineUpload:
+		if quarantine.UploadedDocumentID != nil {
+			if err := s.ingestion.ReleaseQuarantined(ctx, *quarantine.UploadedDocumentID); err != nil {
+				return nil, err
+			}
+		}
+	case models.QuarantineKnowledgeImport:
+		entryIDs, err := quarantinedEntryIDs(quarantine)
+		if err != nil {
+			return nil, err
+		}
+		for _, entryID := range entryIDs {
+			var entry models.KnowledgeEntry
+			if err := s.db.WithContext(ctx).First(&entry, "id = ?", entryID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue // Deleted while in quarantine
+				}
+				return nil, err
+			}
+			entry.IsPublished = true
+			if err := s.knowledgeService.UpdateKnowledgeEntry(ctx, &entry); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.review(ctx, quarantine, adminID, models.QuarantineApproved, note)
+}
+
+// Reject discards a quarantined document: an upload is never sent to the vector store and the
+// entries of a knowledge import are deleted
+func (s *QuarantineService) Reject(ctx context.Context, id, adminID uuid.UUID, note string) (*models.DocumentQuarantine, error) {
+	quarantine, err := s.pending(ctx, id, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch quarantine.Source {
+	case models.QuarantineUpload:
+		if quarantine.UploadedDocumentID != nil {
+			if err := s.ingestion.RejectQuarantined(ctx, *quarantine.UploadedDocumentID); err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
+	case models.QuarantineKnowledgeImport:
+		entryIDs, err := quarantinedEntryIDs(quarantine)
+		if err != nil {
+			return nil, err
+		}
+		for _, entryID := range entryIDs {
+			if err := s.knowledgeService.DeleteKnowledgeEntry(entryID); err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
+	}
+
+	return s.review(ctx, quarantine, adminID, models.QuarantineRejected, note)
+}
+
+// pending checks that adminID belongs to an admin and returns the quarantine awaiting review
+func (s *QuarantineService) pending(ctx context.Context, id, adminID uuid.UUID) (*models.DocumentQuarantine, error) {
+	var admin models.User
+	if err := s.db.WithContext(ctx).Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuarantineAdminOnly
+		}
+		return nil, err
+	}
+	if admin.Role != models.AdminRole {
+		return nil, ErrQuarantineAdminOnly
+	}
+
+	var quarantine models.DocumentQuarantine
+	if err := s.db.WithContext(ctx).First(&quarantine, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "quarantined document "+id.String())
+	}
+	if quarantine.Status != models.QuarantinePending {
+		return nil, validationError("quarantined document was already %s", quarantine.Status)
+	}
+	return &quarantine, nil
+}
+
+func (s *QuarantineService) review(ctx context.Context, quarantine *models.DocumentQuarantine, adminID uuid.UUID, status models.QuarantineStatus, note string) (*models.DocumentQuarantine, error) {
+	now := time.Now()
+	quarantine.Status = status
+	quarantine.ReviewedBy = &adminID
+	quarantine.ReviewedAt = &now
+	quarantine.ReviewNote = note
+	if err := s.db.WithContext(ctx).Save(quarantine).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Admin %s %s quarantined document %s (%s, %s)", adminID, status, quarantine.ID, quarantine.Source, quarantine.FileName)
+	return quarantine, nil
+}
+
+func quarantinedEntryIDs(quarantine *models.DocumentQuarantine) ([]uuid.UUID, error) {
+	var entryIDs []uuid.UUID
+	if quarantine.KnowledgeEntryIDs == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(quarantine.KnowledgeEntryIDs), &entryIDs); err != nil {
+		return nil, fmt.Errorf("invalid knowledge entry IDs on quarantine %s: %w", quarantine.ID, err)
+	}
+	return entryIDs, nil
+}
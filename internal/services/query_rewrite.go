@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	queryRewriteHistory      = 6   // Earlier messages of the session shown to the rewriter
+	queryRewriteMessageRunes = 500 // Earlier messages are truncated to this many characters
+	maxQueryParaphrases      = 5
+	fusionRankConstant       = 60 // Reciprocal rank fusion constant; higher flattens the advantage of top ranks
+)
+
+// QueryRewriteOptions controls how chat messages are turned into retrieval queries
+type QueryRewriteOptions struct {
+	Enabled     bool // Rewrite messages into standalone queries, resolving references to the conversation history
+	Paraphrases int  // Paraphrased queries searched in addition to the rewrite, with their results merged
+}
+
+// queryRewrite is the reply expected from the rewriter
+type queryRewrite struct {
+	Query       string   `json:"query"`
+	Paraphrases []string `json:"paraphrases"`
+}
+
+// QueryRewriter uses the AI provider to turn a chat message into standalone retrieval queries, so that follow-up
+// questions like "and how do I cancel it?" retrieve the entries about what "it" refers to
+type QueryRewriter struct {
+	db        *gorm.DB
+	unifiedAI *UnifiedAIService
+	opts      QueryRewriteOptions
+}
+
+// NewQueryRewriter creates the query rewriter
+func NewQueryRewriter(db *gorm.DB, unifiedAI *UnifiedAIService, opts QueryRewriteOptions) *QueryRewriter {
+	if opts.Paraphrases < 0 {
+		opts.Paraphrases = 0
+	}
+	if opts.Paraphrases > maxQueryParaphrases {
+		opts.Paraphrases = maxQueryParaphrases
+	}
+	return &QueryRewriter{db: db, unifiedAI: unifiedAI, opts: opts}
+}
+
+// Queries returns the retrieval queries for a message of a session, the standalone rewrite first. messageID is
+// the stored message itself, left out of the history. The message is searched as is when rewriting is disabled,
+// when there is nothing to rewrite, or when the provider fails.
+func (r *QueryRewriter) Queries(ctx context.Context, sessionID, messageID uuid.UUID, message string) []string {
+	if r == nil || !r.opts.Enabled {
+		return []string{message}
+	}
+
+	var history []models.ChatMessage
+	if err := r.db.WithContext(ctx).Where("session_id = ? AND id <> ?", sessionID, messageID).
+		Order("created_at DESC").Limit(queryRewriteHistory).Find(&history).Error; err != nil {
+		log.Printf("[WARNING] Failed to load the history of session %s for query rewriting: %v", sessionID, err)
+	}
+	if len(history) == 0 && r.opts.Paraphrases == 0 {
+		// A first message has nothing to resolve
+		return []string{message}
+	}
+
+	rewrite, err := r.rewrite(ctx, message, history)
+	if err != nil {
+		log.Printf("[WARNING] Query rewriting failed, searching the message as is: %v", err)
+		return []string{message}
+	}
+
+	queries := []string{}
+	seen := map[string]bool{}
+	add := func(query string) {
+		query = strings.TrimSpace(query)
+		if key := foldSearchText(query); query != "" && !seen[key] {
+			seen[key] = true
+			queries = append(queries, query)
+		}
+	}
+	add(rewrite.Query)
+	for i, paraphrase := range rewrite.Paraphrases {
+		if i >= r.opts.Paraphrases {
+			break
+		}
+		add(paraphrase)
+	}
+	if len(queries) == 0 {
+		return []string{message}
+	}
+	if r.opts.Paraphrases > 0 {
+		// The user's own wording is one more phrasing, in case the rewrite drifted
+		add(message)
+	}
+	log.Printf("[INFO] Rewrote message %.50s... into %d retrieval queries: %q", message, len(queries), queries)
+	return queries
+}
+
+// rewrite asks the AI provider for the standalone query and its paraphrases
+func (r *QueryRewriter) rewrite(ctx context.Context, message string, history []models.ChatMessage) (*queryRewrite, error) {
+	var prompt strings.Builder
+	prompt.WriteString("You turn the messages of a support chat into search queries for its knowledge base.\n\n")
+	if len(history) > 0 {
+		prompt.WriteString("Conversation so far:\n")
+		for i := len(history) - 1; i >= 0; i-- {
+			speaker := "Assistant"
+			if history[i].Role == models.UserMessage {
+				speaker = "User"
+			}
+			fmt.Fprintf(&prompt, "%s: %s\n", speaker, utils.TruncateString(history[i].Content, queryRewriteMessageRunes))
+		}
+		prompt.WriteString("\n")
+	}
+	fmt.Fprintf(&prompt, "Latest message: %s\n\n", message)
+	prompt.WriteString("Rewrite the latest message into one standalone search query: replace pronouns and references to " +
+		"earlier messages with what they refer to, drop greetings and filler, and keep the language of the message. ")
+	if r.opts.Paraphrases > 0 {
+		fmt.Fprintf(&prompt, "Also give %d paraphrases of the query that use different words for the same question. ", r.opts.Paraphrases)
+	}
+	prompt.WriteString(`Reply with JSON only, in the form {"query": "standalone query", "paraphrases": ["paraphrase"]}`)
+
+	temperature := float32(0)
+	resp, err := r.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages:   []UnifiedChatMessage{{Role: "user", Content: prompt.String()}},
+		Generation: GenerationParams{Temperature: &temperature},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw := strings.TrimSpace(resp.Message)
+	if start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}"); start >= 0 && end > start {
+		raw = raw[start : end+1]
+	}
+	var rewrite queryRewrite
+	if err := json.Unmarshal([]byte(raw), &rewrite); err != nil {
+		return nil, fmt.Errorf("unparseable rewrite %q", utils.TruncateString(resp.Message, 200))
+	}
+	return &rewrite, nil
+}
+
+// SearchKnowledgeMultiQuery searches the knowledge base for each query and merges the results by reciprocal
+// rank fusion, so that entries found by several queries come first. Each entry keeps its best scoring
+// citation, renumbered in the merged order.
+func (s *KnowledgeService) SearchKnowledgeMultiQuery(ctx context.Context, queries []string, limit int, scope RetrievalScope) ([]models.KnowledgeEntry, []Citation, error) {
+	if len(queries) == 1 {
+		return s.SearchKnowledgeWithCitations(ctx, queries[0], limit, scope)
+	}
+
+	type searchResult struct {
+		entries   []models.KnowledgeEntry
+		citations []Citation
+		err       error
+	}
+	results := make([]searchResult, len(queries))
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			entries, citations, err := s.SearchKnowledgeWithCitations(ctx, query, limit, scope)
+			results[i] = searchResult{entries: entries, citations: citations, err: err}
+		}(i, query)
+	}
+	wg.Wait()
+
+	type fused struct {
+		entry    models.KnowledgeEntry
+		citation Citation
+		score    float64
+	}
+	byID := map[uuid.UUID]*fused{}
+	var lastErr error
+	for i, result := range results {
+		if result.err != nil {
+			log.Printf("[WARNING] Knowledge search failed for query %q: %v", queries[i], result.err)
+			lastErr = result.err
+			continue
+		}
+		for rank, entry := range result.entries {
+			citation := result.citations[rank]
+			match, ok := byID[entry.ID]
+			if !ok {
+				match = &fused{entry: entry, citation: citation}
+				byID[entry.ID] = match
+			} else if citation.Score > match.citation.Score {
+				match.citation = citation
+			}
+			match.score += 1 / float64(fusionRankConstant+rank+1)
+		}
+	}
+	if len(byID) == 0 && lastErr != nil {
+		return nil, nil, lastErr
+	}
+
+	merged := make([]*fused, 0, len(byID))
+	for _, match := range byID {
+		merged = append(merged, match)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].score != merged[j].score {
+			return merged[i].score > merged[j].score
+		}
+		return merged[i].citation.Score > merged[j].citation.Score
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	entries := make([]models.KnowledgeEntry, len(merged))
+	citations := make([]Citation, len(merged))
+	for i, match := range merged {
+		entries[i] = match.entry
+		citations[i] = match.citation
+		citations[i].Index = i + 1
+	}
+	return entries, citations, nil
+}
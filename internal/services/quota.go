@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Quota scopes
+const (
+	QuotaScopeUser = "user"
+	QuotaScopeOrg  = "org"
+)
+
+// Quota override errors
+var (
+	ErrQuotaAdminOnly   = fmt.Errorf("%w: only admins can override quotas", ErrForbidden)
+	ErrQuotaInvalidSpec = fmt.Errorf("%w: quota limits must not be negative", ErrValidation)
+)
+
+// QuotaLimits are monthly token and cost limits. A limit of 0 means unlimited.
+type QuotaLimits struct {
+	MonthlyTokens  int64   `json:"monthly_tokens"`
+	MonthlyCostUSD float64 `json:"monthly_cost_usd"`
+}
+
+// QuotaUsage is the usage of one quota scope in the current month
+type QuotaUsage struct {
+	Scope            string   `json:"scope"`
+	Overridden       bool     `json:"overridden"`
+	TokenLimit       int64    `json:"token_limit"`
+	TokensUsed       int64    `json:"tokens_used"`
+	TokensRemaining  *int64   `json:"tokens_remaining,omitempty"` // Omitted when unlimited
+	CostLimitUSD     float64  `json:"cost_limit_usd"`
+	CostUsedUSD      float64  `json:"cost_used_usd"`
+	CostRemainingUSD *float64 `json:"cost_remaining_usd,omitempty"` // Omitted when unlimited
+}
+
+// Exceeded reports whether a limit of the scope has been reached
+func (u QuotaUsage) Exceeded() bool {
+	return (u.TokenLimit > 0 && u.TokensUsed >= u.TokenLimit) ||
+		(u.CostLimitUSD > 0 && u.CostUsedUSD >= u.CostLimitUSD)
+}
+
+// QuotaStatus is the quota usage of a user and of the organization in the current month
+type QuotaStatus struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	PeriodStart time.Time  `json:"period_start"`
+	ResetsAt    time.Time  `json:"resets_at"`
+	User        QuotaUsage `json:"user"`
+	Org         QuotaUsage `json:"org"`
+}
+
+// QuotaExceededError is returned when a chat would exceed a monthly quota
+type QuotaExceededError struct {
+	Status *QuotaStatus
+	Scope  string // The scope whose quota was exceeded
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: %s quota resets at %s", ErrQuotaExceeded, e.Scope, e.Status.ResetsAt.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrQuotaExceeded) match
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaService enforces monthly token and cost quotas per user and for the whole organization
+type QuotaService struct {
+	db          *gorm.DB
+	userDefault QuotaLimits
+	orgDefault  QuotaLimits
+}
+
+// NewQuotaService creates the quota service with the configured default limits
+func NewQuotaService(db *gorm.DB, userDefault, orgDefault QuotaLimits) *QuotaService {
+	return &QuotaService{db: db, userDefault: userDefault, orgDefault: orgDefault}
+}
+
+// monthPeriod returns the start of the current month and of the next one, in UTC
+func monthPeriod(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Check returns a *QuotaExceededError when the user or the organization has used up a monthly quota
+func (s *QuotaService) Check(userID uuid.UUID) error {
+	if s == nil {
+		return nil
+	}
+
+	status, err := s.GetStatus(userID)
+	if err != nil {
+		// Accounting problems must not take chat down
+		log.Printf("[WARNING] Failed to check quota for user %s, allowing request: %v", userID, err)
+		return nil
+	}
+	if status.User.Exceeded() {
+		return &QuotaExceededError{Status: status, Scope: QuotaScopeUser}
+	}
+	if status.Org.Exceeded() {
+		return &QuotaExceededError{Status: status, Scope: QuotaScopeOrg}
+	}
+	return nil
+}
+
+// GetStatus returns the quota usage of a user and of the organization in the current month
+func (s *QuotaService) GetStatus(userID uuid.UUID) (*QuotaStatus, error) {
+	start, end := monthPeriod(time.Now())
+	status := &QuotaStatus{UserID: userID, PeriodStart: start, ResetsAt: end}
+
+	userLimits, userOverridden, err := s.limits(&userID)
+	if err != nil {
+		return nil, err
+	}
+	orgLimits, orgOverridden, err := s.limits(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.User, err = s.usage(QuotaScopeUser, &userID, start, userLimits, userOverridden); err != nil {
+		return nil, err
+	}
+	if status.Org, err = s.usage(QuotaScopeOrg, nil, start, orgLimits, orgOverridden); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// limits returns the override of a user (or of the organization when userID is nil), falling back to the defaults
+func (s *QuotaService) limits(userID *uuid.UUID) (QuotaLimits, bool, error) {
+	override, err := s.findOverride(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if userID == nil {
+			return s.orgDefault, false, nil
+		}
+		return s.userDefault, false, nil
+	}
+	if err != nil {
+		return QuotaLimits{}, false, err
+	}
+	return QuotaLimits{MonthlyTokens: override.MonthlyTokenLimit, MonthlyCostUSD: override.MonthlyCostLimitUSD}, true, nil
+}
+
+// usage sums the usage recorded since start for a user, or for everyone when userID is nil
+func (s *QuotaService) usage(scope string, userID *uuid.UUID, start time.Time, limits QuotaLimits, overridden bool) (QuotaUsage, error) {
+	var totals UsageTotals
+	query := s.db.Table("usage_records AS u").Select(usageTotalsSelect).Where("u.created_at >= ?", start)
+	if userID != nil {
+		query = query.Where("u.user_id = ?", *userID)
+	}
+	if err := query.Scan(&totals).Error; err != nil {
+		return QuotaUsage{}, err
+	}
+
+	usage := QuotaUsage{
+		Scope:        scope,
+		Overridden:   overridden,
+		TokenLimit:   limits.MonthlyTokens,
+		TokensUsed:   totals.TotalTokens,
+		CostLimitUSD: limits.MonthlyCostUSD,
+		CostUsedUSD:  totals.CostUSD,
+	}
+	if limits.MonthlyTokens > 0 {
+		remaining := max(limits.MonthlyTokens-totals.TotalTokens, 0)
+		usage.TokensRemaining = &remaining
+	}
+	if limits.MonthlyCostUSD > 0 {
+		remaining := max(limits.MonthlyCostUSD-totals.CostUSD, 0)
+		usage.CostRemainingUSD = &remaining
+	}
+	return usage, nil
+}
+
+func (s *QuotaService) findOverride(userID *uuid.UUID) (*models.UsageQuota, error) {
+	var override models.UsageQuota
+	query := s.db.Where("user_id IS NULL")
+	if userID != nil {
+		query = s.db.Where("user_id = ?", *userID)
+	}
+	if err := query.First(&override).Error; err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// SetOverride replaces the monthly quota of a user, or of the organization when userID is nil.
+// adminID must belong to an admin.
+func (s *QuotaService) SetOverride(userID *uuid.UUID, limits QuotaLimits, note string, adminID uuid.UUID) (*models.UsageQuota, error) {
+	if limits.MonthlyTokens < 0 || limits.MonthlyCostUSD < 0 {
+		return nil, ErrQuotaInvalidSpec
+	}
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	override, err := s.findOverride(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		override = &models.UsageQuota{UserID: userID}
+	} else if err != nil {
+		return nil, err
+	}
+	override.MonthlyTokenLimit = limits.MonthlyTokens
+	override.MonthlyCostLimitUSD = limits.MonthlyCostUSD
+	override.Note = note
+	override.UpdatedBy = adminID
+
+	if err := s.db.Save(override).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Admin %s set quota override for %s: tokens=%d cost=%.2f", adminID, quotaTarget(userID), limits.MonthlyTokens, limits.MonthlyCostUSD)
+	return override, nil
+}
+
+// DeleteOverride restores the configured default quota of a user, or of the organization when userID is nil
+func (s *QuotaService) DeleteOverride(userID *uuid.UUID, adminID uuid.UUID) error {
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+
+	override, err := s.findOverride(userID)
+	if err != nil {
+		return notFound(err, "quota override for "+quotaTarget(userID))
+	}
+	if err := s.db.Delete(override).Error; err != nil {
+		return err
+	}
+	log.Printf("[INFO] Admin %s removed quota override for %s", adminID, quotaTarget(userID))
+	return nil
+}
+
+func (s *QuotaService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuotaAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrQuotaAdminOnly
+	}
+	return nil
+}
+
+func quotaTarget(userID *uuid.UUID) string {
+	if userID == nil {
+		return "organization"
+	}
+	return "user " + userID.String()
+}
@@ -0,0 +1,19 @@
+package services
+
+import "gorm.io/gorm"
+
+// ReadReplicaRouter selects the connection used for read-only queries that tolerate replication lag
+type ReadReplicaRouter interface {
+	Reader() *gorm.DB
+}
+
+// readDB returns the read connection chosen by router, or primary when read routing is disabled
+func readDB(router ReadReplicaRouter, primary *gorm.DB) *gorm.DB {
+	if router == nil {
+		return primary
+	}
+	if conn := router.Reader(); conn != nil {
+		return conn
+	}
+	return primary
+}
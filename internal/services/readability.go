@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"unicode"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	readingWordsPerMinute = 200
+	readingStatsBatchSize = 200
+)
+
+// ReadingStats describes how long an entry takes to read and how hard it is
+type ReadingStats struct {
+	ReadingTimeSeconds int
+	ReadabilityScore   float64
+	Complexity         models.ComplexityLevel
+}
+
+// ReadingFilter narrows knowledge entries by reading time and complexity. The zero value matches everything.
+type ReadingFilter struct {
+	MaxReadingSeconds int // 0 means no limit
+	Complexity        models.ComplexityLevel
+}
+
+// IsZero reports whether the filter matches every entry
+func (f ReadingFilter) IsZero() bool {
+	return f.MaxReadingSeconds <= 0 && f.Complexity == ""
+}
+
+// Apply adds the filter predicates to a knowledge entry query
+func (f ReadingFilter) Apply(query *gorm.DB) *gorm.DB {
+	if f.MaxReadingSeconds > 0 {
+		query = query.Where("reading_time_seconds > 0 AND reading_time_seconds <= ?", f.MaxReadingSeconds)
+	}
+	if f.Complexity != "" {
+		query = query.Where("complexity = ?", f.Complexity)
+	}
+	return query
+}
+
+// Matches reports whether an entry passes the filter
+func (f ReadingFilter) Matches(entry *models.KnowledgeEntry) bool {
+	if f.MaxReadingSeconds > 0 && (entry.ReadingTimeSeconds <= 0 || entry.ReadingTimeSeconds > f.MaxReadingSeconds) {
+		return false
+	}
+	return f.Complexity == "" || entry.Complexity == f.Complexity
+}
+
+// ValidComplexity reports whether level is a known complexity level
+func ValidComplexity(level models.ComplexityLevel) bool {
+	switch level {
+	case models.ComplexityEasy, models.ComplexityModerate, models.ComplexityAdvanced:
+		return true
+	}
+	return false
+}
+
+// ComputeReadingStats estimates the reading time of text at 200 words per minute and scores
+// its readability with the Flesch reading ease formula
+func ComputeReadingStats(text string) ReadingStats {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	if len(words) == 0 {
+		return ReadingStats{}
+	}
+
+	sentences := 0
+	// Only the sentence boundaries matter here, so the sentences are not tokenized
+	for _, span := range splitSentences(text, func(string) int { return 0 }) {
+		if strings.IndexFunc(text[span.start:span.end], unicode.IsLetter) >= 0 {
+			sentences++
+		}
+	}
+	if sentences == 0 {
+		sentences = 1
+	}
+	syllables := 0
+	for _, word := range words {
+		syllables += countSyllables(word)
+	}
+
+	wordCount := float64(len(words))
+	score := 206.835 - 1.015*(wordCount/float64(sentences)) - 84.6*(float64(syllables)/wordCount)
+	score = math.Round(math.Max(0, math.Min(100, score))*10) / 10
+
+	stats := ReadingStats{
+		ReadingTimeSeconds: int(math.Ceil(wordCount / readingWordsPerMinute * 60)),
+		ReadabilityScore:   score,
+		Complexity:         models.ComplexityAdvanced,
+	}
+	switch {
+	case score >= 60:
+		stats.Complexity = models.ComplexityEasy
+	case score >= 30:
+		stats.Complexity = models.ComplexityModerate
+	}
+	return stats
+}
+
+// applyReadingStats computes the reading stats of an entry from its title and content
+func applyReadingStats(entry *models.KnowledgeEntry) {
+	stats := ComputeReadingStats(entry.Title + ".\n" + entry.Content)
+	entry.ReadingTimeSeconds = stats.ReadingTimeSeconds
+	entry.ReadabilityScore = stats.ReadabilityScore
+	entry.Complexity = stats.Complexity
+}
+
+// BackfillReadingStats computes the reading stats of entries saved before they existed
+func (s *KnowledgeService) BackfillReadingStats(ctx context.Context) (int, error) {
+	updated := 0
+	var entries []models.KnowledgeEntry
+	err := s.db.WithContext(ctx).Select("id", "title", "content").
+		Where("COALESCE(complexity, '') = ''").
+		FindInBatches(&entries, readingStatsBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range entries {
+				applyReadingStats(&entries[i])
+				err := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("id = ?", entries[i].ID).
+					UpdateColumns(map[string]interface{}{
+						"reading_time_seconds": entries[i].ReadingTimeSeconds,
+						"readability_score":    entries[i].ReadabilityScore,
+						"complexity":           entries[i].Complexity,
+					}).Error
+				if err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if updated > 0 {
+		log.Printf("[INFO] Computed reading stats for %d knowledge entries", updated)
+	}
+	return updated, err
+}
+
+// countSyllables approximates the syllables of an English word by counting vowel groups
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count-- // Silent final e
+	}
+	if count == 0 {
+		return 1
+	}
+	return count
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// relatedEntriesCandidates is how many vector points are searched per related entry returned; an entry
+// usually matches with several of its chunks
+const relatedEntriesCandidates = 5
+
+// RelatedEntry is a published knowledge entry similar to another one
+type RelatedEntry struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	Summary  string    `json:"summary,omitempty"`
+	Category string    `json:"category"`
+	Score    float64   `json:"score"` // Cosine similarity of the best matching chunk
+}
+
+// SeeAlsoOptions controls the related entries suggested with chat answers
+type SeeAlsoOptions struct {
+	Limit    int     // Entries suggested per answer; 0 disables suggestions
+	MinScore float64 // Answers whose best citation scores below this are considered low confidence
+}
+
+// SetSeeAlso suggests entries related to the best match with answers the knowledge base supports weakly
+func (s *KnowledgeService) SetSeeAlso(opts SeeAlsoOptions) {
+	s.seeAlso = opts
+}
+
+// RelatedEntries returns up to limit published entries within the scope whose content is most similar to
+// the entry, most similar first. The entry is represented by the mean of its stored content chunk vectors,
+// so nothing is embedded again. Entries without embeddings have no related entries.
+func (s *KnowledgeService) RelatedEntries(ctx context.Context, id uuid.UUID, limit int, scope RetrievalScope) ([]RelatedEntry, error) {
+	var entry models.KnowledgeEntry
+	if err := scope.Apply(readDB(s.reads, s.db)).Select("id").First(&entry, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "knowledge entry "+id.String())
+	}
+	related := []RelatedEntry{}
+	if s.vectorService == nil {
+		return related, nil
+	}
+
+	// Related question points are left out: they describe what users ask, not what the entry says
+	var pointIDs []string
+	err := s.db.WithContext(ctx).Model(&models.VectorEmbedding{}).
+		Where("knowledge_entry_id = ? AND end_offset > 0", id).
+		Order("chunk_index ASC").
+		Pluck("vector_id", &pointIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(pointIDs) == 0 {
+		return related, nil
+	}
+	vectors, err := s.vectorService.Vectors(ctx, pointIDs)
+	if err != nil {
+		return nil, err
+	}
+	centroid := meanVector(vectors)
+	if centroid == nil {
+		return related, nil
+	}
+
+	filter := scope.QdrantFilter()
+	if filter == nil {
+		filter = map[string]interface{}{}
+	}
+	filter["must_not"] = []map[string]interface{}{
+		{"key": "knowledge_entry_id", "match": map[string]string{"value": id.String()}},
+	}
+	results, err := s.vectorService.SearchByVectorWithFilter(ctx, centroid, limit*relatedEntriesCandidates, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results are sorted by score, so the first point seen for an entry is its best one
+	var entryIDs []uuid.UUID
+	best := make(map[uuid.UUID]float64)
+	for _, result := range results {
+		if _, seen := best[result.KnowledgeEntryID]; seen {
+			continue
+		}
+		best[result.KnowledgeEntryID] = result.Score
+		entryIDs = append(entryIDs, result.KnowledgeEntryID)
+	}
+	if len(entryIDs) == 0 {
+		return related, nil
+	}
+
+	var found []models.KnowledgeEntry
+	err = scope.Apply(readDB(s.reads, s.db)).
+		Select("id", "title", "summary", "category").
+		Where("id IN ? AND is_published = true", entryIDs).
+		Find(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.KnowledgeEntry, len(found))
+	for _, entry := range found {
+		byID[entry.ID] = entry
+	}
+	for _, entryID := range entryIDs {
+		entry, ok := byID[entryID]
+		if !ok {
+			continue
+		}
+		related = append(related, RelatedEntry{
+			ID:       entry.ID,
+			Title:    entry.Title,
+			Summary:  entry.Summary,
+			Category: entry.Category,
+			Score:    best[entryID],
+		})
+		if len(related) == limit {
+			break
+		}
+	}
+	return related, nil
+}
+
+// SeeAlso suggests entries related to the best match of a chat question when the answer has low
+// confidence: its best citation scores below the configured minimum, as text search fallbacks always do.
+// Entries already cited are not suggested. It returns nil when suggestions are disabled or not needed.
+func (s *KnowledgeService) SeeAlso(ctx context.Context, entries []models.KnowledgeEntry, citations []Citation, scope RetrievalScope) []RelatedEntry {
+	if s.seeAlso.Limit <= 0 || len(entries) == 0 {
+		return nil
+	}
+	for _, citation := range citations {
+		if citation.Score >= s.seeAlso.MinScore {
+			return nil
+		}
+	}
+
+	related, err := s.RelatedEntries(ctx, entries[0].ID, s.seeAlso.Limit+len(entries), scope)
+	if err != nil {
+		log.Printf("[WARNING] Failed to find entries related to %s for a low-confidence answer: %v", entries[0].ID, err)
+		return nil
+	}
+	cited := make(map[uuid.UUID]bool, len(entries))
+	for _, entry := range entries {
+		cited[entry.ID] = true
+	}
+	var suggestions []RelatedEntry
+	for _, entry := range related {
+		if cited[entry.ID] {
+			continue
+		}
+		suggestions = append(suggestions, entry)
+		if len(suggestions) == s.seeAlso.Limit {
+			break
+		}
+	}
+	return suggestions
+}
+
+// meanVector returns the normalized mean of vectors of the same dimension, or nil when there are none
+func meanVector(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	mean := make([]float32, len(vectors[0]))
+	for _, vector := range vectors {
+		if len(vector) != len(mean) {
+			continue
+		}
+		for i, value := range vector {
+			mean[i] += value
+		}
+	}
+	var norm float64
+	for _, value := range mean {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return nil
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range mean {
+		mean[i] *= scale
+	}
+	return mean
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"tic-knowledge-system/internal/models"
+)
+
+const (
+	relatedQuestionsMax          = 5
+	relatedQuestionsContentChars = 4000
+)
+
+// vectorKindQuestion marks vector points holding a related question rather than a content chunk
+const vectorKindQuestion = "question"
+
+// RelatedQuestionGenerator writes alternative phrasings of the questions a knowledge entry answers.
+// Embedded next to the content, they let retrieval match colloquial queries that share few words with it.
+type RelatedQuestionGenerator struct {
+	unifiedAI *UnifiedAIService
+}
+
+// NewRelatedQuestionGenerator creates a related question generator
+func NewRelatedQuestionGenerator(unifiedAI *UnifiedAIService) *RelatedQuestionGenerator {
+	return &RelatedQuestionGenerator{unifiedAI: unifiedAI}
+}
+
+// Generate returns up to five questions the entry answers, in the words a user would type
+func (g *RelatedQuestionGenerator) Generate(ctx context.Context, entry *models.KnowledgeEntry) ([]string, error) {
+	content := entry.Content
+	if len(content) > relatedQuestionsContentChars {
+		content = content[:relatedQuestionsContentChars] + "..."
+	}
+	prompt := fmt.Sprintf("Here is an article from our internal knowledge base.\n\nTitle: %s\n\n%s\n\n"+
+		"Write 3 to %d different questions this article answers, phrased the way a frontline employee would "+
+		"casually type them into a chat: everyday words, no jargon from the article unless it is unavoidable. "+
+		"Reply with one question per line, without numbering or any other text.", entry.Title, content, relatedQuestionsMax)
+
+	resp, err := g.unifiedAI.ChatCompletion(ctx, UnifiedChatRequest{
+		Messages: []UnifiedChatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseListAnswer(resp.Message, relatedQuestionsMax), nil
+}
+
+// SetQuestionGenerator generates related questions for published entries and embeds them with the content
+func (s *KnowledgeService) SetQuestionGenerator(generator *RelatedQuestionGenerator) {
+	s.questions = generator
+}
+
+// refreshRelatedQuestions regenerates the related questions of an entry whose text changed since they were
+// written. On failure the previous questions are kept and generation is retried with the next embedding.
+func (s *KnowledgeService) refreshRelatedQuestions(ctx context.Context, entry *models.KnowledgeEntry) {
+	if s.questions == nil {
+		return
+	}
+	hash := relatedQuestionsHash(entry)
+	if entry.RelatedQuestionsHash == hash {
+		return
+	}
+
+	questions, err := s.questions.Generate(ctx, entry)
+	if err != nil {
+		log.Printf("[WARNING] Failed to generate related questions for entry %s: %v", entry.ID, err)
+		return
+	}
+
+	encoded, _ := json.Marshal(questions)
+	entry.RelatedQuestions = string(encoded)
+	entry.RelatedQuestionsHash = hash
+	applySearchText(entry)
+	err = s.db.Model(&models.KnowledgeEntry{}).Where("id = ?", entry.ID).UpdateColumns(map[string]interface{}{
+		"related_questions":      entry.RelatedQuestions,
+		"related_questions_hash": hash,
+		"search_text":            entry.SearchText,
+	}).Error
+	if err != nil {
+		log.Printf("[WARNING] Failed to store related questions for entry %s: %v", entry.ID, err)
+		return
+	}
+	log.Printf("[INFO] Generated %d related questions for entry %s", len(questions), entry.ID)
+}
+
+// relatedQuestions returns the stored related questions of an entry
+func relatedQuestions(entry *models.KnowledgeEntry) []string {
+	if entry.RelatedQuestions == "" {
+		return nil
+	}
+	var questions []string
+	if err := json.Unmarshal([]byte(entry.RelatedQuestions), &questions); err != nil {
+		log.Printf("[WARNING] Invalid related questions stored on entry %s: %v", entry.ID, err)
+		return nil
+	}
+	return questions
+}
+
+func relatedQuestionsHash(entry *models.KnowledgeEntry) string {
+	sum := sha256.Sum256([]byte(EmbeddingText(entry)))
+	return hex.EncodeToString(sum[:])
+}
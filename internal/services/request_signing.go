@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Signature verification errors
+var (
+	ErrSignatureMissing = errors.New("missing signature headers")
+	ErrSignatureExpired = errors.New("request timestamp outside the allowed window")
+	ErrSignatureInvalid = errors.New("invalid request signature")
+	ErrRequestReplayed  = errors.New("request nonce has already been used")
+)
+
+// SignedRequest holds the parts of an inbound request covered by its signature
+type SignedRequest struct {
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+}
+
+// RequestSigner verifies HMAC-SHA256 signed requests from widgets and webhooks.
+// Each request carries a unix timestamp and a nonce; requests outside the
+// tolerance window are rejected and nonces are recorded so they cannot be replayed.
+type RequestSigner struct {
+	db        *gorm.DB
+	channel   string
+	secret    []byte
+	tolerance time.Duration
+
+	mu         sync.Mutex
+	lastPurged time.Time
+}
+
+// NewRequestSigner creates a signer for a channel (e.g. "widget" or "webhook")
+func NewRequestSigner(db *gorm.DB, channel, secret string, tolerance time.Duration) *RequestSigner {
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	return &RequestSigner{
+		db:        db,
+		channel:   channel,
+		secret:    []byte(secret),
+		tolerance: tolerance,
+	}
+}
+
+// Sign computes the hex-encoded signature of a request
+func (s *RequestSigner) Sign(timestamp, nonce, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join([]string{timestamp, nonce, strings.ToUpper(method), path}, "\n")))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature, timestamp window and nonce of a request
+func (s *RequestSigner) Verify(ctx context.Context, req SignedRequest) error {
+	if req.Timestamp == "" || req.Nonce == "" || req.Signature == "" {
+		return ErrSignatureMissing
+	}
+
+	unixSeconds, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureExpired
+	}
+	signedAt := time.Unix(unixSeconds, 0)
+	if skew := time.Since(signedAt); skew > s.tolerance || skew < -s.tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := s.Sign(req.Timestamp, req.Nonce, req.Method, req.Path, req.Body)
+	provided := strings.TrimPrefix(req.Signature, "sha256=")
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(provided))) {
+		return ErrSignatureInvalid
+	}
+
+	// Only record the nonce once the signature is valid so forged requests cannot burn nonces
+	if err := s.recordNonce(ctx, req.Nonce, signedAt); err != nil {
+		return err
+	}
+
+	s.purgeExpiredNonces()
+	return nil
+}
+
+// recordNonce stores the nonce until the request could no longer pass the timestamp check
+func (s *RequestSigner) recordNonce(ctx context.Context, nonce string, signedAt time.Time) error {
+	if len(nonce) > 128 {
+		return ErrSignatureInvalid
+	}
+
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RequestNonce{
+		Nonce:     nonce,
+		Channel:   s.channel,
+		ExpiresAt: signedAt.Add(s.tolerance),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to record request nonce: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRequestReplayed
+	}
+	return nil
+}
+
+// purgeExpiredNonces deletes nonces that can no longer be replayed, at most once per tolerance window
+func (s *RequestSigner) purgeExpiredNonces() {
+	s.mu.Lock()
+	if time.Since(s.lastPurged) < s.tolerance {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPurged = time.Now()
+	s.mu.Unlock()
+
+	result := s.db.Where("channel = ? AND expires_at < ?", s.channel, time.Now()).Delete(&models.RequestNonce{})
+	if result.Error != nil {
+		log.Printf("[WARNING] Failed to purge expired %s nonces: %v", s.channel, result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[INFO] Purged %d expired %s nonces", result.RowsAffected, s.channel)
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signedRequest returns a request signed by signer at the given time
+func signedRequest(signer *RequestSigner, signedAt time.Time, nonce string) SignedRequest {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	body := []byte(`{"message":"How do I print labels?"}`)
+	return SignedRequest{
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: "sha256=" + signer.Sign(timestamp, nonce, "POST", "/api/v1/widget/chat", body),
+		Method:    "POST",
+		Path:      "/api/v1/widget/chat",
+		Body:      body,
+	}
+}
+
+func TestRequestSignerRejectsBadRequests(t *testing.T) {
+	now := time.Now()
+	// Signing only needs the secret
+	sameSecret := NewRequestSigner(nil, "widget", "secret", 0)
+	otherSecret := NewRequestSigner(nil, "widget", "other", 0)
+
+	tests := []struct {
+		name   string
+		modify func(req *SignedRequest)
+		want   error
+	}{
+		{"missing signature", func(req *SignedRequest) { req.Signature = "" }, ErrSignatureMissing},
+		{"missing nonce", func(req *SignedRequest) { req.Nonce = "" }, ErrSignatureMissing},
+		{"malformed timestamp", func(req *SignedRequest) { req.Timestamp = "yesterday" }, ErrSignatureExpired},
+		{"expired timestamp", func(req *SignedRequest) { *req = signedRequest(sameSecret, now.Add(-6*time.Minute), "nonce-1") }, ErrSignatureExpired},
+		{"timestamp in the future", func(req *SignedRequest) { *req = signedRequest(sameSecret, now.Add(6*time.Minute), "nonce-1") }, ErrSignatureExpired},
+		{"other secret", func(req *SignedRequest) { *req = signedRequest(otherSecret, now, "nonce-1") }, ErrSignatureInvalid},
+		{"tampered body", func(req *SignedRequest) { req.Body = []byte(`{"message":"Delete everything"}`) }, ErrSignatureInvalid},
+		{"tampered path", func(req *SignedRequest) { req.Path = "/api/v1/ai/chat" }, ErrSignatureInvalid},
+		{"nonce swapped after signing", func(req *SignedRequest) { req.Nonce = "nonce-2" }, ErrSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := dryRunDB(t)
+			signer := NewRequestSigner(db, "widget", "secret", 5*time.Minute)
+			req := signedRequest(signer, now, "nonce-1")
+			tt.modify(&req)
+
+			if err := signer.Verify(context.Background(), req); !errors.Is(err, tt.want) {
+				t.Fatalf("err %v, want %v", err, tt.want)
+			}
+			// Rejected requests must not burn the nonce of the genuine one
+			if recorder.contains("request_nonces") {
+				t.Error("the nonce of a rejected request was recorded")
+			}
+		})
+	}
+}
+
+func TestRequestSignerAcceptsUppercaseSignature(t *testing.T) {
+	db, recorder := dryRunDB(t)
+	signer := NewRequestSigner(db, "widget", "secret", 5*time.Minute)
+	req := signedRequest(signer, time.Now().Add(-4*time.Minute), "nonce-1")
+	req.Signature = strings.ToUpper(strings.TrimPrefix(req.Signature, "sha256="))
+
+	// The dry run inserts no rows, so the nonce looks used; the signature and timestamp passed
+	if err := signer.Verify(context.Background(), req); !errors.Is(err, ErrRequestReplayed) {
+		t.Fatalf("err %v, want the nonce check to be reached", err)
+	}
+	if !recorder.contains(`INSERT INTO "request_nonces"`, "nonce-1", "widget") {
+		t.Error("the nonce of a valid request was not recorded")
+	}
+}
+
+func TestRequestSignerRejectsReusedNonce(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	widget := NewRequestSigner(db, "widget", "secret", 5*time.Minute)
+	webhook := NewRequestSigner(db, "webhook", "secret", 5*time.Minute)
+	nonce := "nonce-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	if err := widget.Verify(ctx, signedRequest(widget, time.Now(), nonce)); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	// Replayed as is, and re-signed with a fresh timestamp
+	if err := widget.Verify(ctx, signedRequest(widget, time.Now(), nonce)); !errors.Is(err, ErrRequestReplayed) {
+		t.Errorf("reused nonce: err %v, want %v", err, ErrRequestReplayed)
+	}
+	if err := widget.Verify(ctx, signedRequest(widget, time.Now().Add(time.Minute), nonce)); !errors.Is(err, ErrRequestReplayed) {
+		t.Errorf("reused nonce with a new timestamp: err %v, want %v", err, ErrRequestReplayed)
+	}
+	// Nonces are tracked per channel
+	if err := webhook.Verify(ctx, signedRequest(webhook, time.Now(), nonce)); err != nil {
+		t.Errorf("same nonce on another channel: %v", err)
+	}
+	if err := widget.Verify(ctx, signedRequest(widget, time.Now(), nonce+"-next")); err != nil {
+		t.Errorf("fresh nonce: %v", err)
+	}
+}
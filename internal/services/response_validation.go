@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"tic-knowledge-system/internal/utils"
+)
+
+// Kinds of problems found in provider output
+const (
+	ResponseIssueEmpty      = "empty"             // Nothing but whitespace
+	ResponseIssuePromptLeak = "prompt_leak"       // Repeats part of the system prompt verbatim
+	ResponseIssueRefusal    = "refusal"           // Boilerplate refusal instead of an answer
+	ResponseIssueLanguage   = "language_mismatch" // Written in another script than the question
+)
+
+const (
+	// Refusals are short; longer answers that happen to apologise are kept
+	refusalMaxLength = 400
+	// A system prompt line this long or longer found in a response counts as leaked
+	promptLeakMinLength = 40
+	// Texts with fewer letters are too short to tell their script
+	scriptMinLetters = 20
+	// A response is in the question's script when at least this share of its letters use it
+	scriptMinShare = 0.2
+)
+
+// ResponseIssue describes why provider output was rejected
+type ResponseIssue struct {
+	Kind   string
+	Detail string
+}
+
+// hard reports whether the output must never reach the user. Refusals and language mismatches
+// that persist after a retry are more likely intended, e.g. a translation, and are let through.
+func (i *ResponseIssue) hard() bool {
+	return i.Kind == ResponseIssueEmpty || i.Kind == ResponseIssuePromptLeak
+}
+
+func (i *ResponseIssue) String() string {
+	return i.Kind + ": " + i.Detail
+}
+
+// refusalPhrases mark boilerplate refusals, lowercased. Apologies for not finding an answer are not refusals.
+var refusalPhrases = []string{
+	"i can't assist with",
+	"i cannot assist with",
+	"i can't help with that",
+	"i cannot help with that",
+	"i'm unable to assist",
+	"i am unable to assist",
+	"i'm not able to assist",
+	"i can't comply",
+	"i cannot comply",
+	"as an ai language model",
+}
+
+// promptScaffolding is wording the providers wrap the knowledge base context in
+var promptScaffolding = []string{
+	"Based on the following knowledge base information:",
+	"Relevant Knowledge Base Information:",
+	"Please answer the user's question using this information as context.",
+	"Use this information to help answer the user's question when relevant.",
+	"Only cite the numbered sources above and never invent markers.",
+}
+
+// validateResponse checks provider output for the request it answers and returns the first problem found
+func validateResponse(req UnifiedChatRequest, message string) *ResponseIssue {
+	trimmed := strings.TrimSpace(message)
+	if trimmed == "" {
+		return &ResponseIssue{Kind: ResponseIssueEmpty, Detail: "response is empty"}
+	}
+	if leaked := leakedPromptLine(req, trimmed); leaked != "" {
+		return &ResponseIssue{Kind: ResponseIssuePromptLeak, Detail: fmt.Sprintf("response repeats the system prompt (%q)", utils.TruncateString(leaked, 60))}
+	}
+	if len(trimmed) <= refusalMaxLength {
+		lower := strings.ToLower(strings.ReplaceAll(trimmed, "’", "'"))
+		for _, phrase := range refusalPhrases {
+			if strings.Contains(lower, phrase) {
+				return &ResponseIssue{Kind: ResponseIssueRefusal, Detail: fmt.Sprintf("response is a refusal (%q)", phrase)}
+			}
+		}
+	}
+	if question := lastUserMessage(req); question != "" {
+		script, ok := dominantScript(question)
+		if ok && scriptShare(trimmed, script) < scriptMinShare {
+			if answerScript, ok := dominantScript(trimmed); ok && answerScript != script {
+				return &ResponseIssue{Kind: ResponseIssueLanguage, Detail: fmt.Sprintf("question is in %s script, response in %s", script, answerScript)}
+			}
+		}
+	}
+	return nil
+}
+
+// leakedPromptLine returns a line of the system prompt that the response repeats verbatim
+func leakedPromptLine(req UnifiedChatRequest, message string) string {
+	prompts := append([]string{req.SystemPrompt, defaultSupportPrompt, defaultKnowledgePrompt}, promptScaffolding...)
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			prompts = append(prompts, msg.Content)
+		}
+	}
+
+	normalized := normalizeForLeak(message)
+	for _, prompt := range prompts {
+		for _, line := range strings.Split(prompt, "\n") {
+			line = normalizeForLeak(strings.TrimLeft(strings.TrimSpace(line), "-*0123456789. "))
+			if len(line) >= promptLeakMinLength && strings.Contains(normalized, line) {
+				return line
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeForLeak(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// lastUserMessage returns the content of the request's last user message
+func lastUserMessage(req UnifiedChatRequest) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			return req.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// scripts told apart by the language check. Han, Hiragana and Katakana count as one, since Japanese mixes them.
+var scripts = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{"Latin", []*unicode.RangeTable{unicode.Latin}},
+	{"Cyrillic", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"Greek", []*unicode.RangeTable{unicode.Greek}},
+	{"Arabic", []*unicode.RangeTable{unicode.Arabic}},
+	{"Hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	{"CJK", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}},
+	{"Hangul", []*unicode.RangeTable{unicode.Hangul}},
+	{"Thai", []*unicode.RangeTable{unicode.Thai}},
+	{"Devanagari", []*unicode.RangeTable{unicode.Devanagari}},
+}
+
+// scriptCounts counts the letters of text per script
+func scriptCounts(text string) (map[string]int, int) {
+	counts := make(map[string]int)
+	total := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		for _, script := range scripts {
+			if unicode.In(r, script.tables...) {
+				counts[script.name]++
+				break
+			}
+		}
+	}
+	return counts, total
+}
+
+// dominantScript returns the script most letters of text use. Texts with too few letters have none.
+func dominantScript(text string) (string, bool) {
+	counts, total := scriptCounts(text)
+	if total < scriptMinLetters {
+		return "", false
+	}
+	best, bestCount := "", 0
+	for _, script := range scripts {
+		if counts[script.name] > bestCount {
+			best, bestCount = script.name, counts[script.name]
+		}
+	}
+	return best, best != ""
+}
+
+// scriptShare returns the share of the letters of text that use script
+func scriptShare(text, script string) float64 {
+	counts, total := scriptCounts(text)
+	if total == 0 {
+		return 1
+	}
+	return float64(counts[script]) / float64(total)
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EvaluationQueue is the job queue for retrieval evaluations
+const EvaluationQueue = "evaluations"
+
+// JobTypeRetrievalEval runs a retrieval evaluation over every labeled pair
+const JobTypeRetrievalEval = "retrieval_eval"
+
+// Evaluation triggers
+const (
+	RetrievalEvalTriggerScheduled = "scheduled"
+	RetrievalEvalTriggerManual    = "manual"
+	RetrievalEvalTriggerCLI       = "cli" // cmd/eval
+)
+
+const (
+	retrievalEvalTopK         = 10
+	retrievalEvalScoreBuckets = 10
+)
+
+// retrievalEvalPayload is the job payload for a retrieval evaluation
+type retrievalEvalPayload struct {
+	Trigger string `json:"trigger"`
+	Answers bool   `json:"answers,omitempty"` // Also answer and judge every question
+}
+
+// RetrievalEvalService measures retrieval quality against editor-labeled question/entry pairs
+type RetrievalEvalService struct {
+	db               *gorm.DB
+	knowledgeService *KnowledgeService
+	jobQueue         *JobQueue
+	nightlyHour      int
+	unifiedAI        *UnifiedAIService // Answers and judges questions; nil evaluates retrieval only
+	nightlyAnswers   bool
+}
+
+// NewRetrievalEvalService creates the service. Scheduled evaluations run daily at nightlyHour (local time).
+func NewRetrievalEvalService(db *gorm.DB, knowledgeService *KnowledgeService, jobQueue *JobQueue, nightlyHour int) *RetrievalEvalService {
+	if nightlyHour < 0 || nightlyHour > 23 {
+		nightlyHour = 2
+	}
+	return &RetrievalEvalService{
+		db:               db,
+		knowledgeService: knowledgeService,
+		jobQueue:         jobQueue,
+		nightlyHour:      nightlyHour,
+	}
+}
+
+// SetAnswerEvaluation lets evaluations answer every question through the chat path and have an LLM judge score
+// how faithful the answers are to the retrieved passages. nightly makes scheduled runs evaluate answers too.
+func (s *RetrievalEvalService) SetAnswerEvaluation(unifiedAI *UnifiedAIService, nightly bool) {
+	s.unifiedAI = unifiedAI
+	s.nightlyAnswers = nightly
+}
+
+// ScoreDistribution holds histograms of retrieval scores in buckets of 0.1 from 0 to 1
+type ScoreDistribution struct {
+	TopScore      []int `json:"top_score"`
+	ExpectedScore []int `json:"expected_score"`
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *RetrievalEvalService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeRetrievalEval, func(ctx context.Context, job *models.Job) error {
+		var payload retrievalEvalPayload
+		if err := DecodeJobPayload(job, &payload); err != nil {
+			return err
+		}
+		if payload.Trigger == RetrievalEvalTriggerScheduled {
+			// Chain the next nightly run first so a failing evaluation does not stop the schedule
+			if err := s.scheduleNextNightly(ctx, &job.ID); err != nil {
+				log.Printf("[WARNING] Failed to schedule next nightly retrieval evaluation: %v", err)
+			}
+		}
+		_, err := s.RunEvaluation(ctx, payload.Trigger, payload.Answers)
+		return err
+	})
+}
+
+// EnsureNightlySchedule makes sure a scheduled evaluation is queued. Call it once at startup.
+func (s *RetrievalEvalService) EnsureNightlySchedule(ctx context.Context) error {
+	return s.scheduleNextNightly(ctx, nil)
+}
+
+// scheduleNextNightly enqueues the next nightly evaluation unless one other than exclude is already waiting
+func (s *RetrievalEvalService) scheduleNextNightly(ctx context.Context, exclude *uuid.UUID) error {
+	if s.jobQueue == nil {
+		return errors.New("job queue not configured")
+	}
+
+	query := s.db.Model(&models.Job{}).
+		Where("type = ? AND status IN ?", JobTypeRetrievalEval, []models.JobStatus{models.JobPending, models.JobFailed}).
+		Where("payload->>'trigger' = ?", RetrievalEvalTriggerScheduled)
+	if exclude != nil {
+		query = query.Where("id <> ?", *exclude)
+	}
+	var waiting int64
+	if err := query.Count(&waiting).Error; err != nil {
+		return err
+	}
+	if waiting > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.nightlyHour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	_, err := s.jobQueue.Enqueue(ctx, EvaluationQueue, JobTypeRetrievalEval, retrievalEvalPayload{Trigger: RetrievalEvalTriggerScheduled, Answers: s.nightlyAnswers}, &EnqueueOptions{
+		MaxAttempts: 3,
+		RunAt:       next,
+	})
+	if err == nil {
+		log.Printf("[INFO] Scheduled nightly retrieval evaluation at %s", next.Format(time.RFC3339))
+	}
+	return err
+}
+
+// ScheduleEvaluation queues a manual evaluation run, evaluating answers as well when answers is set
+func (s *RetrievalEvalService) ScheduleEvaluation(ctx context.Context, answers bool) (*models.Job, error) {
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	if answers && s.unifiedAI == nil {
+		return nil, validationError("answer evaluation is not enabled")
+	}
+	return s.jobQueue.Enqueue(ctx, EvaluationQueue, JobTypeRetrievalEval, retrievalEvalPayload{Trigger: RetrievalEvalTriggerManual, Answers: answers}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// CreatePair labels a question with the entry that should be retrieved for it
+func (s *RetrievalEvalService) CreatePair(pair *models.RetrievalEvalPair) error {
+	var count int64
+	if err := s.db.Model(&models.KnowledgeEntry{}).Where("id = ?", pair.ExpectedEntryID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return validationError("knowledge entry %s not found", pair.ExpectedEntryID)
+	}
+	return s.db.Create(pair).Error
+}
+
+// ListPairs returns the labeled pairs, newest first
+func (s *RetrievalEvalService) ListPairs(limit, offset int) ([]models.RetrievalEvalPair, int64, error) {
+	var pairs []models.RetrievalEvalPair
+	var total int64
+	if err := s.db.Model(&models.RetrievalEvalPair{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := s.db.Preload("ExpectedEntry").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&pairs).Error
+	return pairs, total, err
+}
+
+// DeletePair removes a labeled pair
+func (s *RetrievalEvalService) DeletePair(id uuid.UUID) error {
+	result := s.db.Delete(&models.RetrievalEvalPair{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListRuns returns recent evaluation runs, newest first, for trending metrics over time
+func (s *RetrievalEvalService) ListRuns(limit int) ([]models.RetrievalEvalRun, error) {
+	var runs []models.RetrievalEvalRun
+	err := s.db.Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// GetRun returns an evaluation run with its per-pair results
+func (s *RetrievalEvalService) GetRun(id uuid.UUID) (*models.RetrievalEvalRun, error) {
+	var run models.RetrievalEvalRun
+	err := s.db.Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("rank = 0 DESC, rank DESC")
+	}).First(&run, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RunEvaluation searches every labeled question with the current retrieval configuration
+// and records recall@k, MRR, and score distributions. With answers, every question is also answered
+// through the chat path and the answer's faithfulness to the retrieved passages is scored by an LLM judge.
+func (s *RetrievalEvalService) RunEvaluation(ctx context.Context, trigger string, answers bool) (*models.RetrievalEvalRun, error) {
+	if answers && s.unifiedAI == nil {
+		return nil, validationError("answer evaluation is not enabled")
+	}
+	retrievalConfig := s.knowledgeService.retrievalConfig(ctx)
+	retrievalConfig["answers"] = answers
+	config, _ := json.Marshal(retrievalConfig)
+	run := &models.RetrievalEvalRun{
+		Trigger:   trigger,
+		Status:    models.RetrievalEvalRunning,
+		Config:    string(config),
+		StartedAt: time.Now(),
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	var pairs []models.RetrievalEvalPair
+	if err := s.db.Find(&pairs).Error; err != nil {
+		s.failRun(run, err)
+		return nil, err
+	}
+
+	log.Printf("[INFO] Running retrieval evaluation %s over %d labeled pairs", run.ID, len(pairs))
+
+	// Evaluate against the whole knowledge base, independent of any user's ACL
+	scope := RetrievalScope{Role: models.AdminRole}
+	distribution := ScoreDistribution{
+		TopScore:      make([]int, retrievalEvalScoreBuckets),
+		ExpectedScore: make([]int, retrievalEvalScoreBuckets),
+	}
+	hitsAt := map[int]int{1: 0, 3: 0, 5: 0, 10: 0}
+	var reciprocalRankSum, topScoreSum, expectedScoreSum, faithfulnessSum float64
+	retrieved := 0
+
+	for _, pair := range pairs {
+		entries, citations, err := s.knowledgeService.SearchKnowledgeWithCitations(ctx, pair.Question, retrievalEvalTopK, scope)
+		if err != nil {
+			s.failRun(run, err)
+			return nil, fmt.Errorf("search failed for pair %s: %w", pair.ID, err)
+		}
+
+		result := models.RetrievalEvalResult{
+			RunID:           run.ID,
+			PairID:          pair.ID,
+			Question:        pair.Question,
+			ExpectedEntryID: pair.ExpectedEntryID,
+		}
+		if len(citations) > 0 {
+			topEntryID := citations[0].KnowledgeEntryID
+			result.TopEntryID = &topEntryID
+			result.TopScore = citations[0].Score
+			topScoreSum += result.TopScore
+			distribution.TopScore[scoreBucket(result.TopScore)]++
+		}
+		for i, citation := range citations {
+			if citation.KnowledgeEntryID == pair.ExpectedEntryID {
+				result.Rank = i + 1
+				result.ExpectedScore = citation.Score
+				break
+			}
+		}
+
+		if result.Rank > 0 {
+			retrieved++
+			reciprocalRankSum += 1 / float64(result.Rank)
+			expectedScoreSum += result.ExpectedScore
+			distribution.ExpectedScore[scoreBucket(result.ExpectedScore)]++
+			for k := range hitsAt {
+				if result.Rank <= k {
+					hitsAt[k]++
+				}
+			}
+		}
+
+		if answers {
+			s.evaluateAnswer(ctx, &result, entries)
+			if result.Faithfulness != nil {
+				run.AnswerCount++
+				faithfulnessSum += *result.Faithfulness
+			}
+		}
+
+		if err := s.db.Create(&result).Error; err != nil {
+			s.failRun(run, err)
+			return nil, err
+		}
+	}
+
+	run.PairCount = len(pairs)
+	if len(pairs) > 0 {
+		total := float64(len(pairs))
+		run.RecallAt1 = float64(hitsAt[1]) / total
+		run.RecallAt3 = float64(hitsAt[3]) / total
+		run.RecallAt5 = float64(hitsAt[5]) / total
+		run.RecallAt10 = float64(hitsAt[10]) / total
+		run.MRR = reciprocalRankSum / total
+		run.MeanTopScore = topScoreSum / total
+	}
+	if retrieved > 0 {
+		run.MeanExpectedScore = expectedScoreSum / float64(retrieved)
+	}
+	if run.AnswerCount > 0 {
+		run.MeanFaithfulness = faithfulnessSum / float64(run.AnswerCount)
+	}
+	distributionJSON, _ := json.Marshal(distribution)
+	run.ScoreDistribution = string(distributionJSON)
+
+	now := time.Now()
+	run.Status = models.RetrievalEvalCompleted
+	run.CompletedAt = &now
+	if err := s.db.Save(run).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Retrieval evaluation %s completed: recall@1=%.3f recall@5=%.3f mrr=%.3f over %d pairs",
+		run.ID, run.RecallAt1, run.RecallAt5, run.MRR, run.PairCount)
+	if answers {
+		log.Printf("[INFO] Retrieval evaluation %s judged %d answers: faithfulness=%.3f", run.ID, run.AnswerCount, run.MeanFaithfulness)
+	}
+	return run, nil
+}
+
+// failRun marks an evaluation run as failed
+func (s *RetrievalEvalService) failRun(run *models.RetrievalEvalRun, runErr error) {
+	now := time.Now()
+	s.db.Model(run).Updates(map[string]interface{}{
+		"status":       models.RetrievalEvalFailed,
+		"error":        runErr.Error(),
+		"completed_at": &now,
+	})
+	log.Printf("[ERROR] Retrieval evaluation %s failed: %v", run.ID, runErr)
+}
+
+// scoreBucket maps a similarity score to its histogram bucket
+func scoreBucket(score float64) int {
+	bucket := int(score * retrievalEvalScoreBuckets)
+	if bucket < 0 {
+		return 0
+	}
+	if bucket >= retrievalEvalScoreBuckets {
+		return retrievalEvalScoreBuckets - 1
+	}
+	return bucket
+}
+
+// retrievalConfig describes the retrieval settings in effect, recorded with each evaluation run
+func (s *KnowledgeService) retrievalConfig(ctx context.Context) map[string]interface{} {
+	chunkOptions := s.settings.ChunkOptions(ctx, s.chunkOptions)
+	return map[string]interface{}{
+		"chunk_max_tokens":     chunkOptions.MaxTokens,
+		"chunk_overlap_tokens": chunkOptions.OverlapTokens,
+		"embedder":             fmt.Sprintf("%T", s.embedder),
+		"embedding_model":      embeddingModelOf(s.embedder),
+		"vector_search":        s.vectorService != nil && s.embedder != nil,
+		"top_k":                retrievalEvalTopK,
+	}
+}
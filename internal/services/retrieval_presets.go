@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	retrievalPresetCacheTTL = time.Minute
+
+	// Bounds of the preset settings
+	minPresetChunkTokens = 50
+	maxPresetChunkTokens = 4000
+	maxPresetTopK        = 50
+)
+
+// RetrievalPresetSpec is the editable part of a retrieval preset
+type RetrievalPresetSpec struct {
+	Name               string  `json:"name" example:"error-codes"`
+	Description        string  `json:"description,omitempty" example:"Small precise chunks for error code articles"`
+	ChunkMaxTokens     int     `json:"chunk_max_tokens" example:"150"`
+	ChunkOverlapTokens int     `json:"chunk_overlap_tokens" example:"20"`
+	TopK               int     `json:"top_k" example:"3"`
+	ScoreThreshold     float64 `json:"score_threshold" example:"0.75"`
+}
+
+// RetrievalPresetAssignments lists which categories and templates use each preset
+type RetrievalPresetAssignments struct {
+	Categories []models.CategoryRetrievalPreset `json:"categories"`
+	Templates  []TemplatePresetAssignment       `json:"templates"`
+}
+
+// TemplatePresetAssignment is a template that overrides its category's preset
+type TemplatePresetAssignment struct {
+	TemplateID uuid.UUID `json:"template_id"`
+	Name       string    `json:"name"`
+	Category   string    `json:"category"`
+	PresetID   uuid.UUID `json:"preset_id"`
+}
+
+// RetrievalPresetService stores the chunking and retrieval presets and resolves the preset of a knowledge entry.
+// A template's preset takes precedence over its category's; entries with neither use the configured defaults.
+type RetrievalPresetService struct {
+	db        *gorm.DB
+	knowledge *KnowledgeService
+
+	mu         sync.RWMutex
+	byCategory map[string]*models.RetrievalPreset
+	byTemplate map[uuid.UUID]*models.RetrievalPreset
+	expiresAt  time.Time
+}
+
+// NewRetrievalPresetService creates the preset service and makes the knowledge service use it
+// when chunking entries and filtering search results
+func NewRetrievalPresetService(db *gorm.DB, knowledge *KnowledgeService) *RetrievalPresetService {
+	s := &RetrievalPresetService{db: db, knowledge: knowledge}
+	if knowledge != nil {
+		knowledge.presets = s
+	}
+	return s
+}
+
+// ListPresets lists the retrieval presets by name
+func (s *RetrievalPresetService) ListPresets() ([]models.RetrievalPreset, error) {
+	var presets []models.RetrievalPreset
+	err := s.db.Order("name ASC").Find(&presets).Error
+	return presets, err
+}
+
+// GetPreset returns a retrieval preset by ID
+func (s *RetrievalPresetService) GetPreset(id uuid.UUID) (*models.RetrievalPreset, error) {
+	var preset models.RetrievalPreset
+	if err := s.db.First(&preset, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "retrieval preset "+id.String())
+	}
+	return &preset, nil
+}
+
+// CreatePreset stores a new retrieval preset
+func (s *RetrievalPresetService) CreatePreset(spec RetrievalPresetSpec, createdBy *uuid.UUID) (*models.RetrievalPreset, error) {
+	if err := validatePresetSpec(&spec); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(spec.Name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	preset := &models.RetrievalPreset{CreatedBy: createdBy}
+	applyPresetSpec(preset, spec)
+	if err := s.db.Create(preset).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Created retrieval preset %s", preset.Name)
+	return preset, nil
+}
+
+// UpdatePreset replaces the settings of a preset. Entries using it are re-embedded when the chunking changes.
+func (s *RetrievalPresetService) UpdatePreset(ctx context.Context, id uuid.UUID, spec RetrievalPresetSpec) (*models.RetrievalPreset, error) {
+	if err := validatePresetSpec(&spec); err != nil {
+		return nil, err
+	}
+	preset, err := s.GetPreset(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(spec.Name, id); err != nil {
+		return nil, err
+	}
+
+	rechunk := preset.ChunkMaxTokens != spec.ChunkMaxTokens || preset.ChunkOverlapTokens != spec.ChunkOverlapTokens
+	applyPresetSpec(preset, spec)
+	if err := s.db.Save(preset).Error; err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	if rechunk {
+		s.reembedPresetEntries(ctx, id)
+	}
+	log.Printf("[INFO] Updated retrieval preset %s", preset.Name)
+	return preset, nil
+}
+
+// DeletePreset deletes a preset and its assignments. Entries that used it are re-embedded with their new settings.
+func (s *RetrievalPresetService) DeletePreset(ctx context.Context, id uuid.UUID) error {
+	categories, templateIDs, err := s.presetUsers(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("preset_id = ?", id).Delete(&models.CategoryRetrievalPreset{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Template{}).Where("retrieval_preset_id = ?", id).
+			Update("retrieval_preset_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.RetrievalPreset{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return notFound(gorm.ErrRecordNotFound, "retrieval preset "+id.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.reembed(ctx, categories, templateIDs)
+	log.Printf("[INFO] Deleted retrieval preset %s", id)
+	return nil
+}
+
+// ListAssignments lists the categories and templates that have a preset
+func (s *RetrievalPresetService) ListAssignments() (*RetrievalPresetAssignments, error) {
+	assignments := &RetrievalPresetAssignments{
+		Categories: []models.CategoryRetrievalPreset{},
+		Templates:  []TemplatePresetAssignment{},
+	}
+	if err := s.db.Order("category ASC").Find(&assignments.Categories).Error; err != nil {
+		return nil, err
+	}
+	err := s.db.Model(&models.Template{}).
+		Select("id AS template_id, name, category, retrieval_preset_id AS preset_id").
+		Where("retrieval_preset_id IS NOT NULL").
+		Order("name ASC").
+		Scan(&assignments.Templates).Error
+	if err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// AssignCategory makes the entries of a category use a preset, or the defaults when presetID is nil
+func (s *RetrievalPresetService) AssignCategory(ctx context.Context, category string, presetID *uuid.UUID) error {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return validationError("category is required")
+	}
+
+	if presetID == nil {
+		if err := s.db.Delete(&models.CategoryRetrievalPreset{}, "category = ?", category).Error; err != nil {
+			return err
+		}
+	} else {
+		if _, err := s.GetPreset(*presetID); err != nil {
+			return err
+		}
+		assignment := models.CategoryRetrievalPreset{Category: category, PresetID: *presetID}
+		err := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "category"}},
+			DoUpdates: clause.AssignmentColumns([]string{"preset_id", "updated_at"}),
+		}).Create(&assignment).Error
+		if err != nil {
+			return err
+		}
+	}
+	s.invalidate()
+
+	s.reembed(ctx, []string{category}, nil)
+	log.Printf("[INFO] Assigned retrieval preset %v to category %s", presetID, category)
+	return nil
+}
+
+// AssignTemplate makes the entries of a template use a preset, or their category's when presetID is nil
+func (s *RetrievalPresetService) AssignTemplate(ctx context.Context, templateID uuid.UUID, presetID *uuid.UUID) error {
+	if presetID != nil {
+		if _, err := s.GetPreset(*presetID); err != nil {
+			return err
+		}
+	}
+
+	result := s.db.Model(&models.Template{}).Where("id = ?", templateID).Update("retrieval_preset_id", presetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "template "+templateID.String())
+	}
+	s.invalidate()
+
+	s.reembed(ctx, nil, []uuid.UUID{templateID})
+	log.Printf("[INFO] Assigned retrieval preset %v to template %s", presetID, templateID)
+	return nil
+}
+
+// PresetFor returns the preset applied to an entry, or nil when it uses the defaults
+func (s *RetrievalPresetService) PresetFor(entry *models.KnowledgeEntry) *models.RetrievalPreset {
+	s.mu.RLock()
+	if time.Now().After(s.expiresAt) {
+		s.mu.RUnlock()
+		s.reload()
+		s.mu.RLock()
+	}
+	defer s.mu.RUnlock()
+
+	if entry.TemplateID != nil {
+		if preset, ok := s.byTemplate[*entry.TemplateID]; ok {
+			return preset
+		}
+	}
+	return s.byCategory[entry.Category]
+}
+
+// ChunkOptions returns the chunking parameters for an entry, falling back to defaults
+func (s *RetrievalPresetService) ChunkOptions(entry *models.KnowledgeEntry, defaults ChunkOptions) ChunkOptions {
+	preset := s.PresetFor(entry)
+	if preset == nil || preset.ChunkMaxTokens <= 0 {
+		return defaults
+	}
+	return ChunkOptions{MaxTokens: preset.ChunkMaxTokens, OverlapTokens: preset.ChunkOverlapTokens}
+}
+
+// reload refreshes the cached assignments. On failure the previous ones are kept until the next attempt.
+func (s *RetrievalPresetService) reload() {
+	var presets []models.RetrievalPreset
+	var categories []models.CategoryRetrievalPreset
+	var templates []models.Template
+	err := s.db.Find(&presets).Error
+	if err == nil {
+		err = s.db.Find(&categories).Error
+	}
+	if err == nil {
+		err = s.db.Select("id", "retrieval_preset_id").Where("retrieval_preset_id IS NOT NULL").Find(&templates).Error
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresAt = time.Now().Add(retrievalPresetCacheTTL)
+	if err != nil {
+		log.Printf("[WARNING] Failed to load retrieval presets: %v", err)
+		return
+	}
+
+	byID := make(map[uuid.UUID]*models.RetrievalPreset, len(presets))
+	for i := range presets {
+		byID[presets[i].ID] = &presets[i]
+	}
+	s.byCategory = make(map[string]*models.RetrievalPreset, len(categories))
+	for _, assignment := range categories {
+		if preset, ok := byID[assignment.PresetID]; ok {
+			s.byCategory[assignment.Category] = preset
+		}
+	}
+	s.byTemplate = make(map[uuid.UUID]*models.RetrievalPreset, len(templates))
+	for _, template := range templates {
+		if preset, ok := byID[*template.RetrievalPresetID]; ok {
+			s.byTemplate[template.ID] = preset
+		}
+	}
+}
+
+// checkNameFree rejects a name used by a preset other than except
+func (s *RetrievalPresetService) checkNameFree(name string, except uuid.UUID) error {
+	var count int64
+	if err := s.db.Model(&models.RetrievalPreset{}).Where("name = ? AND id <> ?", name, except).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return validationError("a retrieval preset named %q already exists", name)
+	}
+	return nil
+}
+
+func (s *RetrievalPresetService) invalidate() {
+	s.mu.Lock()
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// presetUsers returns the categories and templates assigned to a preset
+func (s *RetrievalPresetService) presetUsers(id uuid.UUID) ([]string, []uuid.UUID, error) {
+	var categories []string
+	if err := s.db.Model(&models.CategoryRetrievalPreset{}).Where("preset_id = ?", id).
+		Pluck("category", &categories).Error; err != nil {
+		return nil, nil, err
+	}
+	var templateIDs []uuid.UUID
+	if err := s.db.Model(&models.Template{}).Where("retrieval_preset_id = ?", id).
+		Pluck("id", &templateIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	return categories, templateIDs, nil
+}
+
+func (s *RetrievalPresetService) reembedPresetEntries(ctx context.Context, id uuid.UUID) {
+	categories, templateIDs, err := s.presetUsers(id)
+	if err != nil {
+		log.Printf("[WARNING] Failed to find the entries using retrieval preset %s: %v", id, err)
+		return
+	}
+	s.reembed(ctx, categories, templateIDs)
+}
+
+// reembed re-chunks the published entries of the categories and templates. Failures are logged;
+// the entries keep their previous chunks until they are next embedded.
+func (s *RetrievalPresetService) reembed(ctx context.Context, categories []string, templateIDs []uuid.UUID) {
+	if s.knowledge == nil || (len(categories) == 0 && len(templateIDs) == 0) {
+		return
+	}
+	count, err := s.knowledge.ReembedEntries(ctx, categories, templateIDs)
+	if err != nil {
+		log.Printf("[WARNING] Failed to re-embed entries after a retrieval preset change: %v", err)
+		return
+	}
+	log.Printf("[INFO] Re-embedding %d entries after a retrieval preset change", count)
+}
+
+func validatePresetSpec(spec *RetrievalPresetSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	if spec.Name == "" {
+		return validationError("name is required")
+	}
+	if spec.ChunkMaxTokens != 0 && (spec.ChunkMaxTokens < minPresetChunkTokens || spec.ChunkMaxTokens > maxPresetChunkTokens) {
+		return validationError("chunk_max_tokens must be 0 or between %d and %d", minPresetChunkTokens, maxPresetChunkTokens)
+	}
+	if spec.ChunkOverlapTokens < 0 || (spec.ChunkMaxTokens > 0 && spec.ChunkOverlapTokens >= spec.ChunkMaxTokens) {
+		return validationError("chunk_overlap_tokens must be at least 0 and less than chunk_max_tokens")
+	}
+	if spec.TopK < 0 || spec.TopK > maxPresetTopK {
+		return validationError("top_k must be between 0 and %d", maxPresetTopK)
+	}
+	if spec.ScoreThreshold < 0 || spec.ScoreThreshold > 1 {
+		return validationError("score_threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+func applyPresetSpec(preset *models.RetrievalPreset, spec RetrievalPresetSpec) {
+	preset.Name = spec.Name
+	preset.Description = spec.Description
+	preset.ChunkMaxTokens = spec.ChunkMaxTokens
+	preset.ChunkOverlapTokens = spec.ChunkOverlapTokens
+	preset.TopK = spec.TopK
+	preset.ScoreThreshold = spec.ScoreThreshold
+}
+
+func sameUUID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RetrievalScope describes which knowledge entries a requesting user is allowed to see.
+// The zero value is the most restrictive scope: only entries without any ACL are visible.
+type RetrievalScope struct {
+	Role  models.UserRole
+	Teams []string
+
+	// Bookmarked holds the entries the user bookmarked, which rank higher among the matches of a search
+	Bookmarked map[uuid.UUID]bool
+
+	// Filters narrows retrieval further, for admins too
+	Filters RetrievalFilters
+}
+
+// RetrievalFilters narrows the entries a chat answers from, e.g. to troubleshooting articles. Entries must
+// match every filter that is set; within a list any value matches.
+type RetrievalFilters struct {
+	Categories   []string   `json:"categories,omitempty"`
+	Tags         []string   `json:"tags,omitempty"` // Case-insensitive, e.g. the labels of context files
+	MinPriority  int        `json:"min_priority,omitempty"`
+	UpdatedSince *time.Time `json:"updated_since,omitempty"`
+}
+
+// ScopeForUser loads the user's role and teams and builds their retrieval scope
+func (s *KnowledgeService) ScopeForUser(userID uuid.UUID) RetrievalScope {
+	var user models.User
+	if err := s.db.Select("id", "role", "teams").First(&user, "id = ?", userID).Error; err != nil {
+		log.Printf("[WARNING] Failed to load user %s for retrieval scope, using restricted scope: %v", userID, err)
+		return RetrievalScope{}
+	}
+
+	return RetrievalScope{
+		Role:       user.Role,
+		Teams:      splitCommaList(user.Teams),
+		Bookmarked: s.bookmarkedEntries(userID),
+	}
+}
+
+// Unrestricted reports whether the scope can see every entry
+func (scope RetrievalScope) Unrestricted() bool {
+	return scope.Role == models.AdminRole
+}
+
+// SeesUnpublished reports whether the scope includes unpublished entries, which only editors and admins see
+func (scope RetrievalScope) SeesUnpublished() bool {
+	return scope.Role == models.AdminRole || scope.Role == models.EditorRole
+}
+
+// Apply adds the SQL predicates enforcing the scope to a knowledge entry query
+func (scope RetrievalScope) Apply(query *gorm.DB) *gorm.DB {
+	query = scope.Filters.apply(query)
+	if scope.Unrestricted() {
+		return query
+	}
+
+	query = query.Where("(COALESCE(allowed_roles, '') = '' OR ? = ANY(string_to_array(allowed_roles, ',')))", string(scope.Role))
+	if len(scope.Teams) == 0 {
+		return query.Where("COALESCE(allowed_teams, '') = ''")
+	}
+	// Passed as one list, since gorm would expand a slice in ARRAY[?] into a single row value
+	return query.Where("(COALESCE(allowed_teams, '') = '' OR string_to_array(allowed_teams, ',') && string_to_array(?, ','))",
+		strings.Join(scope.Teams, ","))
+}
+
+// QdrantFilter returns the payload filter enforcing the scope inside the vector database, so visibility
+// and the retrieval filters narrow the search itself rather than its results
+func (scope RetrievalScope) QdrantFilter() map[string]interface{} {
+	// Only published entries are embedded; points stored before is_published was in the payload lack it
+	must := []map[string]interface{}{{
+		"should": []map[string]interface{}{
+			{"is_empty": map[string]string{"key": "is_published"}},
+			{"key": "is_published", "match": map[string]interface{}{"value": true}},
+		},
+	}}
+	must = append(must, scope.Filters.qdrantConditions()...)
+	if !scope.Unrestricted() {
+		roleCondition := map[string]interface{}{
+			"should": []map[string]interface{}{
+				{"is_empty": map[string]string{"key": "allowed_roles"}},
+				{"key": "allowed_roles", "match": map[string]interface{}{"any": []string{string(scope.Role)}}},
+			},
+		}
+
+		teamConditions := []map[string]interface{}{
+			{"is_empty": map[string]string{"key": "allowed_teams"}},
+		}
+		if len(scope.Teams) > 0 {
+			teamConditions = append(teamConditions, map[string]interface{}{
+				"key": "allowed_teams", "match": map[string]interface{}{"any": scope.Teams},
+			})
+		}
+		must = append(must, roleCondition, map[string]interface{}{"should": teamConditions})
+	}
+	return map[string]interface{}{"must": must}
+}
+
+// apply adds the SQL predicates of the filters to a knowledge entry query
+func (f RetrievalFilters) apply(query *gorm.DB) *gorm.DB {
+	if categories := nonBlank(f.Categories, false); len(categories) > 0 {
+		query = query.Where("knowledge_entries.category IN ?", categories)
+	}
+	if tags := nonBlank(f.Tags, true); len(tags) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM "+entryTagsSQL+" AS tag(value) WHERE lower(trim(tag.value)) IN ?)", tags)
+	}
+	if f.MinPriority > 0 {
+		query = query.Where("knowledge_entries.priority >= ?", f.MinPriority)
+	}
+	if f.UpdatedSince != nil {
+		query = query.Where("knowledge_entries.updated_at >= ?", *f.UpdatedSince)
+	}
+	return query
+}
+
+// qdrantConditions returns the payload conditions of the filters, matching the fields of entryPayload
+func (f RetrievalFilters) qdrantConditions() []map[string]interface{} {
+	var must []map[string]interface{}
+	if categories := nonBlank(f.Categories, false); len(categories) > 0 {
+		must = append(must, map[string]interface{}{"key": "category", "match": map[string]interface{}{"any": categories}})
+	}
+	if tags := nonBlank(f.Tags, true); len(tags) > 0 {
+		must = append(must, map[string]interface{}{"key": "tags", "match": map[string]interface{}{"any": tags}})
+	}
+	if f.MinPriority > 0 {
+		must = append(must, map[string]interface{}{"key": "priority", "range": map[string]interface{}{"gte": f.MinPriority}})
+	}
+	if f.UpdatedSince != nil {
+		must = append(must, map[string]interface{}{"key": "updated_at", "range": map[string]interface{}{"gte": f.UpdatedSince.Unix()}})
+	}
+	return must
+}
+
+// entryPayload builds the vector payload fields mirroring an entry's ACL and the fields retrieval filters
+// match. Points stored before the filter fields were added need a reindex to match filters.
+func entryPayload(entry *models.KnowledgeEntry) map[string]interface{} {
+	tags := entryTags(entry.Tags)
+	for i, tag := range tags {
+		tags[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+	return map[string]interface{}{
+		"allowed_roles": splitCommaList(entry.AllowedRoles),
+		"allowed_teams": splitCommaList(entry.AllowedTeams),
+		"category":      entry.Category,
+		"tags":          tags,
+		"is_published":  entry.IsPublished,
+		"priority":      entry.Priority,
+		"updated_at":    entry.UpdatedAt.Unix(),
+	}
+}
+
+// nonBlank returns the values that are not blank, trimmed and, if lower, in lowercase
+func nonBlank(values []string, lower bool) []string {
+	var result []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if lower {
+			value = strings.ToLower(value)
+		}
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// splitCommaList splits a comma-separated list, dropping blanks
+func splitCommaList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+func TestRetrievalScopeApply(t *testing.T) {
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		scope   RetrievalScope
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "zero scope only sees entries without an ACL",
+			scope: RetrievalScope{},
+			want: []string{
+				"(COALESCE(allowed_roles, '') = '' OR '' = ANY(string_to_array(allowed_roles, ',')))",
+				"COALESCE(allowed_teams, '') = ''",
+			},
+			notWant: []string{"&& string_to_array"},
+		},
+		{
+			name:  "user without teams",
+			scope: RetrievalScope{Role: models.RegularUser},
+			want: []string{
+				"'user' = ANY(string_to_array(allowed_roles, ','))",
+				"COALESCE(allowed_teams, '') = ''",
+			},
+			notWant: []string{"&& string_to_array"},
+		},
+		{
+			name:  "user with teams",
+			scope: RetrievalScope{Role: models.EditorRole, Teams: []string{"ops", "billing"}},
+			want: []string{
+				"'editor' = ANY(string_to_array(allowed_roles, ','))",
+				"(COALESCE(allowed_teams, '') = '' OR string_to_array(allowed_teams, ',') && string_to_array('ops,billing', ','))",
+			},
+		},
+		{
+			name:    "admin sees every entry",
+			scope:   RetrievalScope{Role: models.AdminRole},
+			notWant: []string{"allowed_roles", "allowed_teams"},
+		},
+		{
+			name: "filters apply to admins too",
+			scope: RetrievalScope{Role: models.AdminRole, Filters: RetrievalFilters{
+				Categories:   []string{"Troubleshooting", " "},
+				Tags:         []string{" Printing "},
+				MinPriority:  2,
+				UpdatedSince: &since,
+			}},
+			want: []string{
+				"knowledge_entries.category IN ('Troubleshooting')",
+				"IN ('printing')",
+				"knowledge_entries.priority >= 2",
+				"knowledge_entries.updated_at >= '2026-01-02 00:00:00'",
+			},
+			notWant: []string{"allowed_roles"},
+		},
+	}
+
+	db, _ := dryRunDB(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tt.scope.Apply(tx.Model(&models.KnowledgeEntry{})).Find(&[]models.KnowledgeEntry{})
+			})
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("SQL lacks %q:\n%s", want, sql)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(sql, notWant) {
+					t.Errorf("SQL contains %q:\n%s", notWant, sql)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrievalScopeQdrantFilter(t *testing.T) {
+	published := `{"should":[{"is_empty":{"key":"is_published"}},{"key":"is_published","match":{"value":true}}]}`
+	role := func(role string) string {
+		return `{"should":[{"is_empty":{"key":"allowed_roles"}},{"key":"allowed_roles","match":{"any":["` + role + `"]}}]}`
+	}
+	noTeams := `{"should":[{"is_empty":{"key":"allowed_teams"}}]}`
+	since := time.Unix(1767312000, 0)
+	tests := []struct {
+		name  string
+		scope RetrievalScope
+		want  string
+	}{
+		{
+			name:  "zero scope only matches points without an ACL",
+			scope: RetrievalScope{},
+			want:  `{"must":[` + published + `,` + role("") + `,` + noTeams + `]}`,
+		},
+		{
+			name:  "user without teams",
+			scope: RetrievalScope{Role: models.RegularUser},
+			want:  `{"must":[` + published + `,` + role("user") + `,` + noTeams + `]}`,
+		},
+		{
+			name:  "user with teams",
+			scope: RetrievalScope{Role: models.SupportRole, Teams: []string{"ops"}},
+			want: `{"must":[` + published + `,` + role("support") +
+				`,{"should":[{"is_empty":{"key":"allowed_teams"}},{"key":"allowed_teams","match":{"any":["ops"]}}]}]}`,
+		},
+		{
+			name:  "admin only needs published points",
+			scope: RetrievalScope{Role: models.AdminRole},
+			want:  `{"must":[` + published + `]}`,
+		},
+		{
+			name: "filters",
+			scope: RetrievalScope{Role: models.AdminRole, Filters: RetrievalFilters{
+				Categories: []string{"Billing"}, Tags: []string{"Labels"}, MinPriority: 3, UpdatedSince: &since,
+			}},
+			want: `{"must":[` + published +
+				`,{"key":"category","match":{"any":["Billing"]}}` +
+				`,{"key":"tags","match":{"any":["labels"]}}` +
+				`,{"key":"priority","range":{"gte":3}}` +
+				`,{"key":"updated_at","range":{"gte":1767312000}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.scope.QdrantFilter())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("filter\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// staticVectorStore returns every entry it holds for any search, ignoring the filter, so that tests show
+// the scope is enforced again when the hits are loaded
+type staticVectorStore struct {
+	hits []VectorSearchResult
+}
+
+func (v *staticVectorStore) StoreWithPayload(context.Context, []float32, string, uuid.UUID, map[string]interface{}) (string, error) {
+	return uuid.NewString(), nil
+}
+
+func (v *staticVectorStore) SearchByVectorWithFilter(context.Context, []float32, int, map[string]interface{}) ([]VectorSearchResult, error) {
+	return v.hits, nil
+}
+
+func (v *staticVectorStore) DeleteByKnowledgeEntry(context.Context, uuid.UUID) error { return nil }
+
+func (v *staticVectorStore) Vectors(context.Context, []string) ([][]float32, error) { return nil, nil }
+
+func (v *staticVectorStore) EnsurePayloadIndexes(context.Context) error { return nil }
+
+func (v *staticVectorStore) Ping(context.Context) error { return nil }
+
+func (v *staticVectorStore) CollectionInfo(context.Context) (*QdrantCollectionInfo, error) {
+	return &QdrantCollectionInfo{Name: "test", Status: "green"}, nil
+}
+
+type constantEmbedder struct{}
+
+func (constantEmbedder) CreateEmbedding(context.Context, string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+
+// recordingOpenAI serves chat completions with a fixed answer and keeps the request bodies
+func recordingOpenAI(t *testing.T) (*OpenAIService, *[]string) {
+	t.Helper()
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompts = append(prompts, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Model:   "gpt-4",
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: "assistant", Content: "Use the label printer [1]."}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return &OpenAIService{client: openai.NewClientWithConfig(config), model: "gpt-4", maxTokens: 200}, &prompts
+}
+
+func TestChatPromptExcludesRestrictedEntries(t *testing.T) {
+	tx := testDB(t)
+	author := createTestUser(t, tx, models.AdminRole, "")
+	asker := createTestUser(t, tx, models.RegularUser, "billing,ops")
+
+	entries := map[string]*models.KnowledgeEntry{
+		"PUBLIC-MARKER":     {},
+		"OPS-MARKER":        {AllowedTeams: "ops"},
+		"FINANCE-MARKER":    {AllowedTeams: "finance"},
+		"ADMIN-ONLY-MARKER": {AllowedRoles: "admin"},
+		"EDITOR-OPS-MARKER": {AllowedRoles: "editor", AllowedTeams: "ops"},
+	}
+	store := &staticVectorStore{}
+	for marker, entry := range entries {
+		entry.Title = "Printing labels " + marker
+		entry.Content = "Label printing steps " + marker
+		entry.Category = "Operations"
+		entry.IsPublished = true
+		entry.CreatedBy = author.ID
+		mustCreate(t, tx, entry)
+		store.hits = append(store.hits, VectorSearchResult{KnowledgeEntryID: entry.ID, Score: 0.9, ChunkText: entry.Content})
+	}
+
+	knowledge := NewKnowledgeService(tx, nil, store, nil)
+	knowledge.SetEmbedder(constantEmbedder{})
+	openAI, prompts := recordingOpenAI(t)
+	chat := NewChatService(tx, openAI, knowledge)
+
+	if _, err := chat.ProcessChat(context.Background(), ChatRequest{UserID: asker.ID, Message: "How do I print labels?"}); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if len(*prompts) != 1 {
+		t.Fatalf("%d chat completions, want 1", len(*prompts))
+	}
+	prompt := (*prompts)[0]
+	for _, visible := range []string{"PUBLIC-MARKER", "OPS-MARKER"} {
+		if !strings.Contains(prompt, visible) {
+			t.Errorf("prompt lacks the visible entry %s", visible)
+		}
+	}
+	for _, restricted := range []string{"FINANCE-MARKER", "ADMIN-ONLY-MARKER", "EDITOR-OPS-MARKER"} {
+		if strings.Contains(prompt, restricted) {
+			t.Errorf("restricted entry %s reached the prompt", restricted)
+		}
+	}
+}
+
+func TestKnowledgeEntryReadsAreScoped(t *testing.T) {
+	unpublished := false
+	tests := []struct {
+		name    string
+		scope   RetrievalScope
+		list    []string // Listing with published=false
+		get     []string
+		notWant []string
+	}{
+		{
+			name:  "users only see published entries of their scope",
+			scope: RetrievalScope{Role: models.RegularUser},
+			list:  []string{"'user' = ANY(string_to_array(allowed_roles, ','))", "is_published = true"},
+			get:   []string{"'user' = ANY(string_to_array(allowed_roles, ','))", "is_published = true"},
+		},
+		{
+			name:    "editors see unpublished entries of their scope",
+			scope:   RetrievalScope{Role: models.EditorRole},
+			list:    []string{"'editor' = ANY(string_to_array(allowed_roles, ','))", "is_published = false"},
+			get:     []string{"'editor' = ANY(string_to_array(allowed_roles, ','))"},
+			notWant: []string{"is_published = true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := dryRunDB(t)
+			knowledge := NewKnowledgeService(db, nil, nil, nil)
+			knowledge.GetKnowledgeEntries("", &unpublished, ReadingFilter{}, tt.scope, 20, 0)
+			knowledge.GetKnowledgeEntryByID(uuid.New(), tt.scope)
+
+			// The list reads 20 entries and the lookup one
+			for limit, want := range map[string][]string{"LIMIT 20": tt.list, "LIMIT 1": tt.get} {
+				var sql string
+				for _, statement := range recorder.statements {
+					if strings.HasPrefix(statement, `SELECT * FROM "knowledge_entries"`) && strings.Contains(statement, limit) {
+						sql = statement
+					}
+				}
+				if sql == "" {
+					t.Fatalf("no knowledge entry read with %s: %v", limit, recorder.statements)
+				}
+				for _, part := range want {
+					if !strings.Contains(sql, part) {
+						t.Errorf("SQL lacks %q:\n%s", part, sql)
+					}
+				}
+				for _, part := range tt.notWant {
+					if strings.Contains(sql, part) {
+						t.Errorf("SQL contains %q:\n%s", part, sql)
+					}
+				}
+			}
+		})
+	}
+}
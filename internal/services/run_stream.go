@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxRunStreamEventBytes bounds a single server-sent event of a run stream
+const maxRunStreamEventBytes = 4 << 20
+
+// streamRun creates a run with streaming and reads its events until the run finishes, timeout elapses or
+// ctx is done. The completed messages come with the events, so no lookups are needed. A run still going
+// when the timeout elapses is returned with Done false; it keeps running and can be waited for with WaitForRun.
+func (s *OpenAIAssistantService) streamRun(ctx context.Context, threadID, assistantID string, timeout time.Duration) (*RunWaitResult, error) {
+	start := time.Now()
+	streamCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]interface{}{"assistant_id": assistantID, "stream": true})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodPost, s.baseURL+"/threads/"+threadID+"/runs", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		message := strings.TrimSpace(string(data))
+		var apiError struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiError) == nil && apiError.Error.Message != "" {
+			message = apiError.Error.Message
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, message)
+		}
+		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, message)
+	}
+
+	// The stream is read in the background so that a canceled request returns even while no event arrives
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRunStreamEventBytes)
+	lines := make(chan string)
+	var readErr error // Set before lines is closed
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-streamCtx.Done():
+				return
+			}
+		}
+		readErr = scanner.Err()
+	}()
+
+	result := &RunWaitResult{ThreadID: threadID}
+	event := ""
+	for {
+		select {
+		case <-streamCtx.Done():
+			if err := ctx.Err(); err != nil {
+				// The client went away or the server is shutting down; the run itself keeps going
+				return nil, fmt.Errorf("run stream canceled: %w", err)
+			}
+			return timedOutRun(result, start)
+		case line, ok := <-lines:
+			if !ok {
+				if streamCtx.Err() != nil {
+					// Reading failed because the stream was stopped, which the case above reports
+					lines = nil
+					continue
+				}
+				if readErr != nil {
+					return nil, fmt.Errorf("failed to read run stream: %w", readErr)
+				}
+				// The stream ended without a final run event
+				return timedOutRun(result, start)
+			}
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				done, err := s.handleRunEvent(event, []byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), result)
+				if err != nil {
+					return nil, err
+				}
+				if done {
+					result.WaitedMs = time.Since(start).Milliseconds()
+					return result, nil
+				}
+			}
+		}
+	}
+}
+
+// timedOutRun returns the result of a run stream that stopped before the run finished
+func timedOutRun(result *RunWaitResult, start time.Time) (*RunWaitResult, error) {
+	if result.RunID == "" {
+		return nil, fmt.Errorf("run stream ended before the run was created")
+	}
+	result.WaitedMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// handleRunEvent records a run stream event in result and reports whether the run finished
+func (s *OpenAIAssistantService) handleRunEvent(event string, data []byte, result *RunWaitResult) (bool, error) {
+	switch {
+	case event == "done":
+		return result.Done, nil
+	case event == "error":
+		var streamError struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &streamError) != nil || streamError.Message == "" {
+			streamError.Message = string(data)
+		}
+		return false, fmt.Errorf("run stream error: %s", streamError.Message)
+	case event == "thread.message.completed":
+		var message openai.Message
+		if err := json.Unmarshal(data, &message); err != nil {
+			return false, fmt.Errorf("invalid message event: %w", err)
+		}
+		if message.Role == openai.ChatMessageRoleAssistant {
+			result.Messages = append(result.Messages, s.convertToAssistantMessage(message))
+		}
+	case strings.HasPrefix(event, "thread.run.") && !strings.HasPrefix(event, "thread.run.step."):
+		var run openai.Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			return false, fmt.Errorf("invalid run event: %w", err)
+		}
+		result.RunID = run.ID
+		result.Status = string(run.Status)
+		if runFinished(run.Status) {
+			result.Done = true
+			result.LastError = run.LastError
+			result.RequiredAction = run.RequiredAction
+			return true, nil
+		}
+	}
+	return false, nil
+}
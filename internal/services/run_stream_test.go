@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stalledRunStream serves a run stream that creates a run and then sends nothing until the request goes away
+func stalledRunStream(t *testing.T) *OpenAIAssistantService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: thread.run.created\ndata: {\"id\":\"run_1\",\"status\":\"queued\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	service := NewOpenAIAssistantService("test", "thread_1", log.New(io.Discard, "", 0))
+	service.baseURL = server.URL
+	service.httpClient = server.Client()
+	return service
+}
+
+func TestStreamRunStopsWithTheRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		cancel   time.Duration // After how long the request is canceled, 0 for never
+		timeout  time.Duration
+		canceled bool
+	}{
+		{name: "canceled request", cancel: 50 * time.Millisecond, timeout: time.Minute, canceled: true},
+		{name: "timeout", timeout: 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := stalledRunStream(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+
+			start := time.Now()
+			result, err := service.streamRun(ctx, "thread_1", "asst_1", tt.timeout)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("stream returned after %s", elapsed)
+			}
+			if tt.canceled {
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("err %v, want context.Canceled", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("stream: %v", err)
+			}
+			if result.Done || result.RunID != "run_1" || result.Status != "queued" {
+				t.Errorf("result %+v, want run_1 still queued", result)
+			}
+		})
+	}
+}
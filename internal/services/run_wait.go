@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// Long-polling clients share run lookups, so OpenAI sees at most one request per run per interval
+	runStatusMinInterval = time.Second
+	runStatusCacheTTL    = time.Minute
+
+	runWaitFirstInterval = 500 * time.Millisecond
+	runWaitMaxInterval   = 5 * time.Second
+	runWaitRateLimited   = 10 * time.Second
+)
+
+// RunWaitResult is the state of an assistant run when a wait returns
+type RunWaitResult struct {
+	RunID          string                    `json:"run_id"`
+	ThreadID       string                    `json:"thread_id"`
+	Status         string                    `json:"status"`
+	Done           bool                      `json:"done"` // False when the wait timed out first; poll again
+	LastError      *openai.RunLastError      `json:"last_error,omitempty"`
+	RequiredAction *openai.RunRequiredAction `json:"required_action,omitempty"`
+	Messages       []AssistantMessage        `json:"messages,omitempty"` // Messages of a completed run
+	WaitedMs       int64                     `json:"waited_ms"`
+}
+
+// runStatusCache shares recent run lookups between concurrent waiters of the same run
+type runStatusCache struct {
+	mu   sync.Mutex
+	runs map[string]*cachedRun
+}
+
+type cachedRun struct {
+	run       *openai.Run
+	fetchedAt time.Time
+	fetching  chan struct{} // Closed when the lookup in flight finishes
+}
+
+// get returns the run from the cache when it is recent enough, otherwise fetches it. Only one
+// caller fetches a run at a time; the others wait for its result.
+func (c *runStatusCache) get(ctx context.Context, key string, fetch func() (*openai.Run, error)) (*openai.Run, error) {
+	for {
+		c.mu.Lock()
+		if c.runs == nil {
+			c.runs = make(map[string]*cachedRun)
+		}
+		entry, ok := c.runs[key]
+		if !ok {
+			entry = &cachedRun{}
+			c.runs[key] = entry
+		}
+		if entry.run != nil && time.Since(entry.fetchedAt) < runStatusMinInterval {
+			run := entry.run
+			c.mu.Unlock()
+			return run, nil
+		}
+		if entry.fetching != nil {
+			fetching := entry.fetching
+			c.mu.Unlock()
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		fetching := make(chan struct{})
+		entry.fetching = fetching
+		c.mu.Unlock()
+
+		run, err := fetch()
+
+		c.mu.Lock()
+		entry.fetching = nil
+		if err == nil {
+			entry.run = run
+			entry.fetchedAt = time.Now()
+		}
+		c.pruneLocked()
+		c.mu.Unlock()
+		close(fetching)
+		return run, err
+	}
+}
+
+func (c *runStatusCache) pruneLocked() {
+	for key, entry := range c.runs {
+		if entry.fetching == nil && time.Since(entry.fetchedAt) > runStatusCacheTTL {
+			delete(c.runs, key)
+		}
+	}
+}
+
+// runFinished reports whether a run will not change without the client acting. Runs that require
+// action wait for tool outputs, so they are returned to the client as well.
+func runFinished(status openai.RunStatus) bool {
+	switch status {
+	case openai.RunStatusQueued, openai.RunStatusInProgress, openai.RunStatusCancelling:
+		return false
+	}
+	return true
+}
+
+// WaitForRun long-polls a run until it finishes or timeout elapses, backing off between lookups.
+// A run that is still going when the timeout elapses is returned with Done false.
+func (s *OpenAIAssistantService) WaitForRun(ctx context.Context, threadID, runID string, timeout time.Duration) (*RunWaitResult, error) {
+	if threadID == "" {
+		threadID = s.threadID
+	}
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := runWaitFirstInterval
+	for {
+		run, err := s.runs.get(waitCtx, threadID+"/"+runID, func() (*openai.Run, error) {
+			run, err := s.client.RetrieveRun(waitCtx, threadID, runID)
+			if err != nil {
+				return nil, err
+			}
+			return &run, nil
+		})
+
+		var apiErr *openai.APIError
+		switch {
+		case err == nil:
+		case errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusNotFound:
+			return nil, fmt.Errorf("%w: run %s on thread %s", ErrNotFound, runID, threadID)
+		case errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusTooManyRequests:
+			s.logger.Printf("Rate limited while waiting for run %s, backing off", runID)
+			interval = runWaitRateLimited
+		case waitCtx.Err() != nil:
+			// The timeout elapsed during the lookup
+			return &RunWaitResult{RunID: runID, ThreadID: threadID, WaitedMs: time.Since(start).Milliseconds()}, nil
+		default:
+			return nil, fmt.Errorf("failed to retrieve run: %w", err)
+		}
+
+		if err == nil && runFinished(run.Status) {
+			result := &RunWaitResult{
+				RunID:          runID,
+				ThreadID:       threadID,
+				Status:         string(run.Status),
+				Done:           true,
+				LastError:      run.LastError,
+				RequiredAction: run.RequiredAction,
+				WaitedMs:       time.Since(start).Milliseconds(),
+			}
+			if run.Status == openai.RunStatusCompleted {
+				messages, err := s.getMessagesWithRunID(ctx, threadID, runID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get messages: %w", err)
+				}
+				result.Messages = messages
+			}
+			return result, nil
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-waitCtx.Done():
+			result := &RunWaitResult{RunID: runID, ThreadID: threadID, WaitedMs: time.Since(start).Milliseconds()}
+			if run != nil {
+				result.Status = string(run.Status)
+			}
+			return result, nil
+		}
+		interval = interval * 3 / 2
+		if interval > runWaitMaxInterval {
+			interval = runWaitMaxInterval
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobTypeScheduledTask is the job type of a run of a scheduled job
+const JobTypeScheduledTask = "scheduled_task"
+
+// Tasks the server registers with the scheduler
+const (
+	TaskStaleEntries     = "stale_entries"
+	TaskConfluenceSync   = "confluence_sync"
+	TaskNotionSync       = "notion_sync"
+	TaskGoogleDriveSync  = "google_drive_sync"
+	TaskAnalyticsRollup  = "analytics_rollup"
+	TaskVectorReindex    = "vector_reindex"
+	TaskTrashPurge       = "trash_purge"
+	TaskUnansweredEmbed  = "unanswered_embed"
+	TaskReplayUnanswered = "replay_unanswered"
+	TaskPublishSchedule  = "publication_schedule"
+	TaskChatRetention    = "chat_retention"
+	TaskOpenAIGC         = "openai_gc"
+)
+
+// schedulerTick is how often due schedules are queued
+const schedulerTick = 30 * time.Second
+
+var ErrSchedulerAdminOnly = fmt.Errorf("%w: only admins can manage scheduled jobs", ErrForbidden)
+
+// ScheduledTaskFunc runs a scheduled task. The result, when not nil, is stored as the last result of the schedule.
+type ScheduledTaskFunc func(ctx context.Context) (interface{}, error)
+
+// ScheduledTask is a task schedules can run
+type ScheduledTask struct {
+	Name        string `json:"name" example:"stale_entries"`
+	Description string `json:"description" example:"Mark published entries not updated for a long time as stale"`
+	run         ScheduledTaskFunc
+}
+
+// ScheduleSpec is the editable part of a scheduled job
+type ScheduleSpec struct {
+	Name           string `json:"name" example:"Nightly stale-entry check"`
+	Task           string `json:"task" example:"stale_entries"`
+	CronExpression string `json:"cron_expression" example:"0 3 * * *"`
+	IsActive       *bool  `json:"is_active,omitempty"` // Defaults to true
+}
+
+type scheduledTaskPayload struct {
+	ScheduleID uuid.UUID `json:"schedule_id"`
+	Manual     bool      `json:"manual,omitempty"`
+}
+
+// SchedulerService runs registered tasks on cron schedules managed through the API. Every replica checks for
+// due schedules; the first to lock one queues its run on the job queue and moves it to its next time.
+type SchedulerService struct {
+	db       *gorm.DB
+	jobQueue *JobQueue
+
+	mu    sync.RWMutex
+	tasks map[string]ScheduledTask
+}
+
+// NewSchedulerService creates the scheduler
+func NewSchedulerService(db *gorm.DB, jobQueue *JobQueue) *SchedulerService {
+	return &SchedulerService{db: db, jobQueue: jobQueue, tasks: make(map[string]ScheduledTask)}
+}
+
+// RegisterTask makes a task available to schedules
+func (s *SchedulerService) RegisterTask(name, description string, run ScheduledTaskFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[name] = ScheduledTask{Name: name, Description: description, run: run}
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *SchedulerService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeScheduledTask, s.handleScheduledTask)
+}
+
+// Tasks lists the registered tasks by name
+func (s *SchedulerService) Tasks() []ScheduledTask {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tasks := make([]ScheduledTask, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks
+}
+
+func (s *SchedulerService) task(name string) (ScheduledTask, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	task, ok := s.tasks[name]
+	return task, ok
+}
+
+// Start queues due schedules until ctx is cancelled
+func (s *SchedulerService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(schedulerTick)
+		defer ticker.Stop()
+		for {
+			if _, err := s.DispatchDue(ctx); err != nil {
+				log.Printf("[ERROR] Failed to queue due scheduled jobs: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// DispatchDue queues a run of every active schedule whose time has come and moves it to its next time.
+// A schedule missed while the server was down runs once, not once per missed time.
+func (s *SchedulerService) DispatchDue(ctx context.Context) (int, error) {
+	queued := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var due []models.ScheduledJob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("is_active = ? AND next_run_at <= ?", true, time.Now()).
+			Find(&due).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range due {
+			schedule := &due[i]
+			next, err := nextRun(schedule.CronExpression, time.Now())
+			if err != nil {
+				// Stored expressions were validated; deactivate rather than retry every tick
+				log.Printf("[ERROR] Deactivating scheduled job %s: %v", schedule.Name, err)
+				if err := tx.Model(schedule).Updates(map[string]interface{}{"is_active": false, "next_run_at": nil, "last_error": err.Error()}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			job, err := s.jobQueue.EnqueueTx(tx, MaintenanceQueue, JobTypeScheduledTask, scheduledTaskPayload{ScheduleID: schedule.ID}, &EnqueueOptions{MaxAttempts: 1})
+			if err != nil {
+				return err
+			}
+			err = tx.Model(schedule).Updates(map[string]interface{}{
+				"next_run_at": next,
+				"last_job_id": job.ID,
+				"last_status": models.ScheduleRunQueued,
+			}).Error
+			if err != nil {
+				return err
+			}
+			queued++
+		}
+		return nil
+	})
+	return queued, err
+}
+
+// handleScheduledTask runs the task of a schedule and records the outcome on the schedule. Failed runs are
+// not retried by the job queue; the next scheduled time runs the task again.
+func (s *SchedulerService) handleScheduledTask(ctx context.Context, job *models.Job) error {
+	var payload scheduledTaskPayload
+	if err := DecodeJobPayload(job, &payload); err != nil {
+		return err
+	}
+	var schedule models.ScheduledJob
+	if err := s.db.First(&schedule, "id = ?", payload.ScheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[INFO] Skipped run of deleted scheduled job %s", payload.ScheduleID)
+			return nil
+		}
+		return err
+	}
+	task, ok := s.task(schedule.Task)
+	if !ok {
+		err := fmt.Errorf("task %q is not available", schedule.Task)
+		s.recordRun(&schedule, job.ID, time.Now(), nil, err)
+		return err
+	}
+
+	started := time.Now()
+	err := s.db.Model(&schedule).Updates(map[string]interface{}{
+		"last_status": models.ScheduleRunRunning,
+		"last_run_at": started,
+		"last_job_id": job.ID,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	result, runErr := task.run(ctx)
+	s.recordRun(&schedule, job.ID, started, result, runErr)
+	if runErr != nil {
+		log.Printf("[WARNING] Scheduled job %s (%s) failed: %v", schedule.Name, schedule.Task, runErr)
+		return runErr
+	}
+	log.Printf("[INFO] Scheduled job %s (%s) finished in %s", schedule.Name, schedule.Task, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+func (s *SchedulerService) recordRun(schedule *models.ScheduledJob, jobID uuid.UUID, started time.Time, result interface{}, runErr error) {
+	updates := map[string]interface{}{
+		"last_status":      models.ScheduleRunSucceeded,
+		"last_error":       "",
+		"last_result":      "",
+		"last_run_at":      started,
+		"last_duration_ms": time.Since(started).Milliseconds(),
+		"last_job_id":      jobID,
+	}
+	if runErr != nil {
+		updates["last_status"] = models.ScheduleRunFailed
+		updates["last_error"] = runErr.Error()
+	}
+	if result != nil {
+		if encoded, err := json.Marshal(result); err == nil {
+			updates["last_result"] = string(encoded)
+		}
+	}
+	if err := s.db.Model(schedule).Updates(updates).Error; err != nil {
+		log.Printf("[ERROR] Failed to record run of scheduled job %s: %v", schedule.Name, err)
+	}
+}
+
+// ListSchedules lists the scheduled jobs by name
+func (s *SchedulerService) ListSchedules() ([]models.ScheduledJob, error) {
+	var schedules []models.ScheduledJob
+	err := s.db.Order("name ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// GetSchedule returns a scheduled job by ID
+func (s *SchedulerService) GetSchedule(id uuid.UUID) (*models.ScheduledJob, error) {
+	var schedule models.ScheduledJob
+	if err := s.db.First(&schedule, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "scheduled job "+id.String())
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule stores a new scheduled job. adminID must belong to an admin.
+func (s *SchedulerService) CreateSchedule(spec ScheduleSpec, adminID uuid.UUID) (*models.ScheduledJob, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	schedule := &models.ScheduledJob{CreatedBy: adminID}
+	if err := s.applySpec(schedule, spec); err != nil {
+		return nil, err
+	}
+	// Select keeps is_active false instead of the column default
+	schedule.ID = uuid.New()
+	if err := s.db.Select("*").Create(schedule).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Admin %s created scheduled job %s: %s at %q", adminID, schedule.Name, schedule.Task, schedule.CronExpression)
+	return schedule, nil
+}
+
+// EnsureSchedule creates an active schedule running a registered task unless a schedule for the task already
+// exists, so that a task the system relies on runs without an admin setting it up. Call it once at startup; the
+// schedule is then managed through the API like any other.
+func (s *SchedulerService) EnsureSchedule(ctx context.Context, name, task, cronExpression string) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.ScheduledJob{}).Where("task = ?", task).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	schedule := &models.ScheduledJob{ID: uuid.New()}
+	if err := s.applySpec(schedule, ScheduleSpec{Name: name, Task: task, CronExpression: cronExpression}); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return err
+	}
+	log.Printf("[INFO] Created scheduled job %s: %s at %q", schedule.Name, schedule.Task, schedule.CronExpression)
+	return nil
+}
+
+// UpdateSchedule replaces the settings of a scheduled job and moves it to its next time. adminID must belong to an admin.
+func (s *SchedulerService) UpdateSchedule(id uuid.UUID, spec ScheduleSpec, adminID uuid.UUID) (*models.ScheduledJob, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applySpec(schedule, spec); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(schedule).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Admin %s updated scheduled job %s: %s at %q (active=%t)", adminID, schedule.Name, schedule.Task, schedule.CronExpression, schedule.IsActive)
+	return schedule, nil
+}
+
+// DeleteSchedule removes a scheduled job. A run already queued is skipped. adminID must belong to an admin.
+func (s *SchedulerService) DeleteSchedule(id, adminID uuid.UUID) error {
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+	result := s.db.Delete(&models.ScheduledJob{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound(gorm.ErrRecordNotFound, "scheduled job "+id.String())
+	}
+	log.Printf("[INFO] Admin %s deleted scheduled job %s", adminID, id)
+	return nil
+}
+
+// RunSchedule queues a run of a scheduled job now, leaving its next scheduled time as it is. adminID must belong to an admin.
+func (s *SchedulerService) RunSchedule(ctx context.Context, id, adminID uuid.UUID) (*models.Job, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.task(schedule.Task); !ok {
+		return nil, validationError("task %q is not available", schedule.Task)
+	}
+
+	var job *models.Job
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		job, err = s.jobQueue.EnqueueTx(tx, MaintenanceQueue, JobTypeScheduledTask, scheduledTaskPayload{ScheduleID: id, Manual: true}, &EnqueueOptions{MaxAttempts: 1})
+		if err != nil {
+			return err
+		}
+		return tx.Model(schedule).Updates(map[string]interface{}{"last_job_id": job.ID, "last_status": models.ScheduleRunQueued}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Admin %s queued a run of scheduled job %s", adminID, schedule.Name)
+	return job, nil
+}
+
+// applySpec validates a spec and copies it onto a schedule, computing its next run
+func (s *SchedulerService) applySpec(schedule *models.ScheduledJob, spec ScheduleSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	spec.CronExpression = strings.TrimSpace(spec.CronExpression)
+	if spec.Name == "" {
+		return validationError("name is required")
+	}
+	if _, ok := s.task(spec.Task); !ok {
+		return validationError("unknown task %q", spec.Task)
+	}
+	next, err := nextRun(spec.CronExpression, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.ScheduledJob{}).Where("name = ? AND id <> ?", spec.Name, schedule.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return validationError("a scheduled job named %q already exists", spec.Name)
+	}
+
+	schedule.Name = spec.Name
+	schedule.Task = spec.Task
+	schedule.CronExpression = spec.CronExpression
+	schedule.IsActive = spec.IsActive == nil || *spec.IsActive
+	schedule.NextRunAt = nil
+	if schedule.IsActive {
+		schedule.NextRunAt = &next
+	}
+	return nil
+}
+
+func (s *SchedulerService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSchedulerAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrSchedulerAdminOnly
+	}
+	return nil
+}
+
+// nextRun returns the first time after now matching a standard cron expression, evaluated in UTC unless the
+// expression starts with CRON_TZ=
+func nextRun(expression string, now time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return time.Time{}, validationError("invalid cron expression %q: %v", expression, err)
+	}
+	next := schedule.Next(now.UTC())
+	if next.IsZero() {
+		return time.Time{}, validationError("cron expression %q never matches", expression)
+	}
+	return next, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	searchMaxTokens = 8
+	searchMinRunes  = 2 // Shorter syllables match almost every entry
+)
+
+// searchStopwords are syllables, folded, too common in Vietnamese and English questions to tell entries apart
+var searchStopwords = wordSet("la va cua cac nhung mot cho voi thi ma nay do gi nao de vao ve khong duoc sao the and or of to is are how what do can")
+
+// foldSearchText lowercases text, strips its diacritics and collapses everything but letters and digits into
+// single spaces, so "Đơn hàng: ĐÃ HỦY" and "don hang da huy" compare equal
+func foldSearchText(text string) string {
+	return strings.Join(strings.FieldsFunc(utils.FoldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// applySearchText computes the accent-insensitive search text of an entry from its title, summary, content
+// and related questions
+func applySearchText(entry *models.KnowledgeEntry) {
+	entry.SearchText = foldSearchText(strings.Join([]string{entry.Title, entry.Summary, entry.Content, entry.RelatedQuestions}, "\n"))
+}
+
+// searchTokens splits a folded query into syllables. Vietnamese separates the syllables of a word with
+// spaces, so adjacent pairs are kept as well: they are the compound words ("don hang", "dang nhap") that
+// make a match relevant.
+func searchTokens(folded string) (syllables, compounds []string) {
+	seen := map[string]bool{}
+	for _, syllable := range strings.Fields(folded) {
+		if utf8.RuneCountInString(syllable) < searchMinRunes || searchStopwords[syllable] || seen[syllable] {
+			continue
+		}
+		seen[syllable] = true
+		syllables = append(syllables, syllable)
+		if len(syllables) == searchMaxTokens {
+			break
+		}
+	}
+
+	words := strings.Fields(folded)
+	for i := 0; i+1 < len(words) && len(compounds) < searchMaxTokens; i++ {
+		if searchStopwords[words[i]] || searchStopwords[words[i+1]] {
+			continue
+		}
+		compounds = append(compounds, words[i]+" "+words[i+1])
+	}
+	return syllables, compounds
+}
+
+// textSearch is the keyword fallback used when vector search is unavailable or finds nothing. It matches the
+// accent-insensitive search text, so "dang nhap" finds "Đăng nhập", and requires at least half of the query's
+// syllables. Entries containing the whole query rank first, then those sharing the most compound words.
+func (s *KnowledgeService) textSearch(query string, limit int, scope RetrievalScope) ([]models.KnowledgeEntry, error) {
+	folded := foldSearchText(query)
+	syllables, compounds := searchTokens(folded)
+	if len(syllables) == 0 {
+		if folded == "" {
+			return []models.KnowledgeEntry{}, nil
+		}
+		syllables = []string{folded}
+	}
+
+	var matched []string
+	var matchedArgs []interface{}
+	for _, syllable := range syllables {
+		matched = append(matched, "(CASE WHEN search_text LIKE ? THEN 1 ELSE 0 END)")
+		matchedArgs = append(matchedArgs, utils.LikePattern(syllable))
+	}
+	score := []string{"(CASE WHEN search_text LIKE ? THEN 100 ELSE 0 END)"}
+	scoreArgs := []interface{}{utils.LikePattern(folded)}
+	for _, compound := range compounds {
+		score = append(score, "(CASE WHEN search_text LIKE ? THEN 10 ELSE 0 END)")
+		scoreArgs = append(scoreArgs, utils.LikePattern(compound))
+	}
+	score = append(score, matched...)
+	scoreArgs = append(scoreArgs, matchedArgs...)
+
+	var entries []models.KnowledgeEntry
+	err := scope.Apply(readDB(s.reads, s.db).Preload("Template").Preload("Creator")).
+		Where("is_published = true").
+		Where(strings.Join(matched, " + ")+" >= ?", append(matchedArgs, (len(syllables)+1)/2)...).
+		Order(gorm.Expr(strings.Join(score, " + ")+" DESC", scoreArgs...)).
+		Order("priority DESC, view_count DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// BackfillSearchText computes the search text of entries saved before it existed
+func (s *KnowledgeService) BackfillSearchText(ctx context.Context) (int, error) {
+	updated := 0
+	var entries []models.KnowledgeEntry
+	err := s.db.WithContext(ctx).Select("id", "title", "summary", "content", "related_questions").
+		Where("search_text IS NULL").
+		FindInBatches(&entries, readingStatsBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range entries {
+				applySearchText(&entries[i])
+				err := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("id = ?", entries[i].ID).
+					UpdateColumn("search_text", entries[i].SearchText).Error
+				if err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if updated > 0 {
+		log.Printf("[INFO] Computed the search text of %d knowledge entries", updated)
+	}
+	return updated, err
+}
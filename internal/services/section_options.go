@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Strategies for splitting an imported document into sections
+const (
+	SectionsAuto       = "auto"       // Headings for Word documents, paragraphs otherwise
+	SectionsHeadings   = "headings"   // At Markdown headings; documents without headings are split by paragraphs
+	SectionsParagraphs = "paragraphs" // Whole paragraphs, up to max_length characters
+	SectionsWhole      = "whole"      // The whole document as one section
+)
+
+// SectionOptions are the parameters an import splits a document into sections with. Lengths are in
+// characters; zero values are taken from the less specific profile.
+type SectionOptions struct {
+	MaxLength int    `json:"max_length,omitempty"` // Sections are split by paragraphs beyond this
+	MinLength int    `json:"min_length,omitempty"` // Shorter sections are merged into the next one, or dropped at the end
+	Overlap   int    `json:"overlap,omitempty"`    // Characters of a section repeated at the start of the next one
+	Strategy  string `json:"strategy,omitempty"`
+}
+
+// SectionProfiles is the value of the import_sections setting: section options for every import,
+// overridden by file type and then by category
+type SectionProfiles struct {
+	Default    SectionOptions            `json:"default"`
+	FileTypes  map[string]SectionOptions `json:"file_types,omitempty"` // By extension, e.g. ".md"
+	Categories map[string]SectionOptions `json:"categories,omitempty"` // By the category of the import
+}
+
+// defaultSectionOptions returns the section options of imports without a profile
+func defaultSectionOptions() SectionOptions {
+	return SectionOptions{
+		MaxLength: importMaxSectionLength,
+		MinLength: importMinSectionLength,
+		Strategy:  SectionsAuto,
+	}
+}
+
+// merge returns the options with the values set in override
+func (o SectionOptions) merge(override SectionOptions) SectionOptions {
+	if override.MaxLength > 0 {
+		o.MaxLength = override.MaxLength
+	}
+	if override.MinLength > 0 {
+		o.MinLength = override.MinLength
+	}
+	if override.Overlap > 0 {
+		o.Overlap = override.Overlap
+	}
+	if override.Strategy != "" {
+		o.Strategy = override.Strategy
+	}
+	return o
+}
+
+// validate checks options as merged for an import
+func (o SectionOptions) validate() error {
+	switch o.Strategy {
+	case SectionsAuto, SectionsHeadings, SectionsParagraphs, SectionsWhole:
+	default:
+		return fmt.Errorf("unknown strategy %q, expected auto, headings, paragraphs or whole", o.Strategy)
+	}
+	if o.MaxLength < 0 || o.MinLength < 0 || o.Overlap < 0 {
+		return errors.New("lengths must not be negative")
+	}
+	if o.MinLength >= o.MaxLength {
+		return fmt.Errorf("min_length (%d) must be less than max_length (%d)", o.MinLength, o.MaxLength)
+	}
+	if o.Overlap >= o.MaxLength/2 {
+		return fmt.Errorf("overlap (%d) must be less than half of max_length (%d)", o.Overlap, o.MaxLength)
+	}
+	return nil
+}
+
+// resolve returns the section options of an import of a file with the extension ext under category
+func (p *SectionProfiles) resolve(category, ext string) SectionOptions {
+	options := defaultSectionOptions().merge(p.Default)
+	for key, override := range p.FileTypes {
+		if strings.EqualFold(key, ext) {
+			options = options.merge(override)
+		}
+	}
+	if override, ok := p.Categories[category]; ok {
+		options = options.merge(override)
+	}
+	return options
+}
+
+// parseSectionProfiles reads the value of the import_sections setting; empty means no profiles
+func parseSectionProfiles(value string) (*SectionProfiles, error) {
+	var profiles SectionProfiles
+	if strings.TrimSpace(value) == "" {
+		return &profiles, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("invalid section profiles: %w", err)
+	}
+	return &profiles, nil
+}
+
+// validateSectionProfiles checks every combination of profiles an import can get
+func validateSectionProfiles(value string) error {
+	profiles, err := parseSectionProfiles(value)
+	if err != nil {
+		return err
+	}
+	exts := []string{""}
+	for ext := range profiles.FileTypes {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("file type %q must be an extension like .md", ext)
+		}
+		exts = append(exts, ext)
+	}
+	categories := []string{""}
+	for category := range profiles.Categories {
+		categories = append(categories, category)
+	}
+	for _, ext := range exts {
+		for _, category := range categories {
+			if err := profiles.resolve(category, ext).validate(); err != nil {
+				if ext != "" || category != "" {
+					return fmt.Errorf("file type %q, category %q: %w", ext, category, err)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SectionOptions returns the section options of an import of a file with the extension ext under
+// category, from the import_sections setting
+func (s *SettingsService) SectionOptions(ctx context.Context, category, ext string) SectionOptions {
+	if s == nil {
+		return defaultSectionOptions()
+	}
+	value := s.defaults[SettingImportSections]
+	if setting, ok := s.current(ctx)[SettingImportSections]; ok {
+		value = setting.Value
+	}
+	profiles, err := parseSectionProfiles(value)
+	if err != nil {
+		log.Printf("[WARNING] Ignoring %s: %v", SettingImportSections, err)
+		return defaultSectionOptions()
+	}
+	return profiles.resolve(category, ext)
+}
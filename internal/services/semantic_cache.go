@@ -0,0 +1,136 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SemanticCache stores recent answers keyed by the embedding of the question
+// so that near-identical questions over the same knowledge context can be
+// answered without another LLM call
+type SemanticCache struct {
+	mu         sync.Mutex
+	threshold  float64
+	ttl        time.Duration
+	maxEntries int
+	entries    []semanticCacheEntry
+}
+
+type semanticCacheEntry struct {
+	embedding  []float32
+	contextKey string
+	answer     CachedAnswer
+	storedAt   time.Time
+}
+
+// CachedAnswer is the answer payload kept in the semantic cache
+type CachedAnswer struct {
+	Question  string
+	Response  string
+	Provider  AIProvider
+	Model     string
+	Citations []Citation // The [n] markers in Response refer to these citations
+}
+
+// NewSemanticCache creates a semantic cache. Questions whose cosine similarity
+// is at least threshold are treated as the same question.
+func NewSemanticCache(threshold float64, ttl time.Duration, maxEntries int) *SemanticCache {
+	if maxEntries <= 0 {
+		maxEntries = 500
+	}
+	return &SemanticCache{
+		threshold:  threshold,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// Lookup returns the most similar cached answer for the same knowledge context
+func (c *SemanticCache) Lookup(embedding []float32, contextKey string) (*CachedAnswer, float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired()
+
+	var best *semanticCacheEntry
+	bestScore := 0.0
+	for i := range c.entries {
+		entry := &c.entries[i]
+		if entry.contextKey != contextKey {
+			continue
+		}
+		score := cosineSimilarity(embedding, entry.embedding)
+		if score >= c.threshold && score > bestScore {
+			best = entry
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return nil, 0, false
+	}
+	answer := best.answer
+	return &answer, bestScore, true
+}
+
+// Store records an answer for later lookups, evicting the oldest entry when full
+func (c *SemanticCache) Store(embedding []float32, contextKey string, answer CachedAnswer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired()
+	if len(c.entries) >= c.maxEntries {
+		c.entries = c.entries[1:]
+	}
+	c.entries = append(c.entries, semanticCacheEntry{
+		embedding:  embedding,
+		contextKey: contextKey,
+		answer:     answer,
+		storedAt:   time.Now(),
+	})
+}
+
+// evictExpired drops entries older than the TTL; entries are kept in insertion order
+func (c *SemanticCache) evictExpired() {
+	if c.ttl <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-c.ttl)
+	i := 0
+	for i < len(c.entries) && c.entries[i].storedAt.Before(cutoff) {
+		i++
+	}
+	c.entries = c.entries[i:]
+}
+
+// knowledgeContextKey builds a stable key identifying the set of retrieved entries
+func knowledgeContextKey(entryIDs []uuid.UUID) string {
+	ids := make([]string, len(entryIDs))
+	for i, id := range entryIDs {
+		ids[i] = id.String()
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// cosineSimilarity computes the cosine similarity of two vectors
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session export formats
+const (
+	ExportFormatJSON     = "json"
+	ExportFormatMarkdown = "markdown"
+	ExportFormatPDF      = "pdf"
+)
+
+// SessionTranscript is the full record of a chat session for archiving and sharing
+type SessionTranscript struct {
+	SessionID  uuid.UUID           `json:"session_id"`
+	Title      string              `json:"title"`
+	UserID     uuid.UUID           `json:"user_id"`
+	UserName   string              `json:"user_name"`
+	UserEmail  string              `json:"user_email"`
+	CreatedAt  time.Time           `json:"created_at"`
+	ExportedAt time.Time           `json:"exported_at"`
+	Messages   []TranscriptMessage `json:"messages"`
+}
+
+// TranscriptMessage is a single message of a transcript with its sources and feedback
+type TranscriptMessage struct {
+	ID        uuid.UUID            `json:"id"`
+	Role      models.MessageRole   `json:"role"`
+	Content   string               `json:"content"`
+	CreatedAt time.Time            `json:"created_at"`
+	Provider  string               `json:"provider,omitempty"`
+	Model     string               `json:"model,omitempty"`
+	Sources   []TranscriptSource   `json:"sources,omitempty"`
+	Feedback  []TranscriptFeedback `json:"feedback,omitempty"`
+}
+
+// TranscriptSource is a knowledge entry cited by a message
+type TranscriptSource struct {
+	Index            int       `json:"index,omitempty"`
+	KnowledgeEntryID uuid.UUID `json:"knowledge_entry_id"`
+	Title            string    `json:"title"`
+}
+
+// TranscriptFeedback is feedback left on a message
+type TranscriptFeedback struct {
+	Rating    int                 `json:"rating"`
+	Type      models.FeedbackType `json:"type"`
+	Comment   string              `json:"comment,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// transcriptMetadata is the subset of message metadata used in transcripts
+type transcriptMetadata struct {
+	Provider  string     `json:"provider"`
+	Model     string     `json:"model"`
+	Sources   []string   `json:"sources"`
+	Citations []Citation `json:"citations"`
+}
+
+// ExportSession builds the transcript of a session including sources and feedback
+func (s *ChatService) ExportSession(sessionID uuid.UUID) (*SessionTranscript, error) {
+	return s.exportSession(s.db, sessionID)
+}
+
+// exportSession builds a transcript with db, which the retention policies scope to archived sessions too
+func (s *ChatService) exportSession(db *gorm.DB, sessionID uuid.UUID) (*SessionTranscript, error) {
+	var session models.ChatSession
+	err := db.Preload("User").Preload("Messages", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&session, "id = ?", sessionID).Error
+	if err != nil {
+		return nil, notFound(err, "chat session "+sessionID.String())
+	}
+
+	messageIDs := make([]uuid.UUID, len(session.Messages))
+	for i, message := range session.Messages {
+		messageIDs[i] = message.ID
+	}
+	var feedback []models.Feedback
+	if len(messageIDs) > 0 {
+		if err := db.Where("message_id IN ?", messageIDs).Order("created_at ASC").Find(&feedback).Error; err != nil {
+			return nil, err
+		}
+	}
+	feedbackByMessage := make(map[uuid.UUID][]TranscriptFeedback)
+	for _, f := range feedback {
+		feedbackByMessage[f.MessageID] = append(feedbackByMessage[f.MessageID], TranscriptFeedback{
+			Rating:    f.Rating,
+			Type:      f.Type,
+			Comment:   f.Comment,
+			CreatedAt: f.CreatedAt,
+		})
+	}
+
+	transcript := &SessionTranscript{
+		SessionID:  session.ID,
+		Title:      session.Title,
+		UserID:     session.UserID,
+		UserName:   session.User.Name,
+		UserEmail:  session.User.Email,
+		CreatedAt:  session.CreatedAt,
+		ExportedAt: time.Now(),
+	}
+
+	metadataByMessage := make(map[uuid.UUID]transcriptMetadata)
+	var uncitedIDs []uuid.UUID
+	for _, message := range session.Messages {
+		var metadata transcriptMetadata
+		if message.Metadata != "" {
+			json.Unmarshal([]byte(message.Metadata), &metadata)
+		}
+		metadataByMessage[message.ID] = metadata
+		if len(metadata.Citations) == 0 {
+			for _, source := range metadata.Sources {
+				if id, err := uuid.Parse(source); err == nil {
+					uncitedIDs = append(uncitedIDs, id)
+				}
+			}
+		}
+	}
+	titles := s.entryTitles(uncitedIDs)
+
+	for _, message := range session.Messages {
+		metadata := metadataByMessage[message.ID]
+		transcriptMessage := TranscriptMessage{
+			ID:        message.ID,
+			Role:      message.Role,
+			Content:   message.Content,
+			CreatedAt: message.CreatedAt,
+			Provider:  metadata.Provider,
+			Model:     metadata.Model,
+			Feedback:  feedbackByMessage[message.ID],
+		}
+
+		if len(metadata.Citations) > 0 {
+			for _, citation := range metadata.Citations {
+				transcriptMessage.Sources = append(transcriptMessage.Sources, TranscriptSource{
+					Index:            citation.Index,
+					KnowledgeEntryID: citation.KnowledgeEntryID,
+					Title:            citation.Title,
+				})
+			}
+		} else {
+			for _, source := range metadata.Sources {
+				if id, err := uuid.Parse(source); err == nil {
+					transcriptMessage.Sources = append(transcriptMessage.Sources, TranscriptSource{
+						KnowledgeEntryID: id,
+						Title:            titles[id],
+					})
+				}
+			}
+		}
+
+		transcript.Messages = append(transcript.Messages, transcriptMessage)
+	}
+
+	return transcript, nil
+}
+
+// entryTitles looks up the titles of knowledge entries, including deleted ones
+func (s *ChatService) entryTitles(ids []uuid.UUID) map[uuid.UUID]string {
+	titles := make(map[uuid.UUID]string)
+	if len(ids) == 0 {
+		return titles
+	}
+
+	var entries []models.KnowledgeEntry
+	s.db.Unscoped().Select("id", "title").Where("id IN ?", ids).Find(&entries)
+	for _, entry := range entries {
+		titles[entry.ID] = entry.Title
+	}
+	return titles
+}
+
+// RenderTranscriptMarkdown renders a transcript as Markdown
+func RenderTranscriptMarkdown(transcript *SessionTranscript) string {
+	var md strings.Builder
+
+	title := transcript.Title
+	if title == "" {
+		title = "Chat session"
+	}
+	md.WriteString("# " + title + "\n\n")
+	md.WriteString(fmt.Sprintf("- Session: `%s`\n", transcript.SessionID))
+	md.WriteString(fmt.Sprintf("- User: %s <%s>\n", transcript.UserName, transcript.UserEmail))
+	md.WriteString(fmt.Sprintf("- Started: %s\n", formatTranscriptTime(transcript.CreatedAt)))
+	md.WriteString(fmt.Sprintf("- Exported: %s\n", formatTranscriptTime(transcript.ExportedAt)))
+
+	for _, message := range transcript.Messages {
+		md.WriteString(fmt.Sprintf("\n---\n\n### %s · %s\n\n", transcriptRoleLabel(message), formatTranscriptTime(message.CreatedAt)))
+		md.WriteString(message.Content + "\n")
+
+		if len(message.Sources) > 0 {
+			md.WriteString("\n**Sources**\n\n")
+			for i, source := range message.Sources {
+				md.WriteString(fmt.Sprintf("%d. %s (`%s`)\n", sourceNumber(i, source), source.Title, source.KnowledgeEntryID))
+			}
+		}
+
+		if len(message.Feedback) > 0 {
+			md.WriteString("\n**Feedback**\n\n")
+			for _, feedback := range message.Feedback {
+				md.WriteString(fmt.Sprintf("- %s, rating %d/5 (%s)", feedback.Type, feedback.Rating, formatTranscriptTime(feedback.CreatedAt)))
+				if feedback.Comment != "" {
+					md.WriteString(": " + feedback.Comment)
+				}
+				md.WriteString("\n")
+			}
+		}
+	}
+
+	return md.String()
+}
+
+// RenderTranscriptPDF renders a transcript as a PDF document
+func RenderTranscriptPDF(transcript *SessionTranscript) []byte {
+	title := transcript.Title
+	if title == "" {
+		title = "Chat session"
+	}
+
+	lines := []utils.PDFLine{
+		{Text: title, Bold: true, Size: 16},
+		{Text: fmt.Sprintf("Session: %s", transcript.SessionID), Size: 9},
+		{Text: fmt.Sprintf("User: %s <%s>", transcript.UserName, transcript.UserEmail), Size: 9},
+		{Text: fmt.Sprintf("Started: %s    Exported: %s", formatTranscriptTime(transcript.CreatedAt), formatTranscriptTime(transcript.ExportedAt)), Size: 9},
+	}
+
+	for _, message := range transcript.Messages {
+		lines = append(lines,
+			utils.PDFLine{Text: ""},
+			utils.PDFLine{Text: fmt.Sprintf("%s - %s", transcriptRoleLabel(message), formatTranscriptTime(message.CreatedAt)), Bold: true, Size: 11},
+			utils.PDFLine{Text: message.Content},
+		)
+
+		if len(message.Sources) > 0 {
+			lines = append(lines, utils.PDFLine{Text: "Sources:", Bold: true, Size: 9})
+			for i, source := range message.Sources {
+				lines = append(lines, utils.PDFLine{Text: fmt.Sprintf("[%d] %s", sourceNumber(i, source), source.Title), Size: 9})
+			}
+		}
+
+		for _, feedback := range message.Feedback {
+			text := fmt.Sprintf("Feedback: %s, rating %d/5", feedback.Type, feedback.Rating)
+			if feedback.Comment != "" {
+				text += " - " + feedback.Comment
+			}
+			lines = append(lines, utils.PDFLine{Text: text, Size: 9})
+		}
+	}
+
+	return utils.RenderTextPDF(lines)
+}
+
+func transcriptRoleLabel(message TranscriptMessage) string {
+	if message.Role == models.SupportMessage {
+		return "Support agent"
+	}
+	if message.Role == models.AssistantMessage {
+		if message.Model != "" {
+			return fmt.Sprintf("Assistant (%s)", message.Model)
+		}
+		return "Assistant"
+	}
+	return "User"
+}
+
+// sourceNumber returns the citation marker of a source, falling back to its position
+func sourceNumber(position int, source TranscriptSource) int {
+	if source.Index > 0 {
+		return source.Index
+	}
+	return position + 1
+}
+
+func formatTranscriptTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05 MST")
+}
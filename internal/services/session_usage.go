@@ -0,0 +1,124 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// SessionUsage summarizes the tokens, cost, and retrieval activity of a chat session
+type SessionUsage struct {
+	SessionID        uuid.UUID       `json:"session_id"`
+	UserID           uuid.UUID       `json:"user_id"`
+	Teams            []string        `json:"teams"`
+	UserMessages     int             `json:"user_messages"`
+	AssistantReplies int             `json:"assistant_replies"`
+	CachedReplies    int             `json:"cached_replies"`
+	PromptTokens     int             `json:"prompt_tokens"`
+	CompletionTokens int             `json:"completion_tokens"`
+	TotalTokens      int             `json:"total_tokens"`
+	EstimatedCostUSD float64         `json:"estimated_cost_usd"`
+	RetrievalCount   int             `json:"retrieval_count"`
+	Providers        []ProviderUsage `json:"providers"`
+}
+
+// ProviderUsage is the usage of a single provider and model within a session
+type ProviderUsage struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Replies          int     `json:"replies"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// messageUsageMetadata is the subset of assistant message metadata used for accounting
+type messageUsageMetadata struct {
+	Provider  string     `json:"provider"`
+	Model     string     `json:"model"`
+	Usage     TokenUsage `json:"usage"`
+	CostUSD   float64    `json:"cost_usd"`
+	Retrieved int        `json:"retrieved"`
+	Cached    bool       `json:"cached"`
+}
+
+// GetSessionUsage aggregates the usage recorded on the messages of a session
+func (s *ChatService) GetSessionUsage(sessionID uuid.UUID) (*SessionUsage, error) {
+	var session models.ChatSession
+	if err := s.db.Preload("User").First(&session, "id = ?", sessionID).Error; err != nil {
+		return nil, notFound(err, "chat session "+sessionID.String())
+	}
+
+	var messages []models.ChatMessage
+	if err := s.db.Where("session_id = ?", sessionID).Order("created_at ASC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	usage := &SessionUsage{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Teams:     splitCommaList(session.User.Teams),
+	}
+
+	byProvider := make(map[string]*ProviderUsage)
+	for _, message := range messages {
+		if message.Role == models.SupportMessage {
+			continue // Agents do not use providers
+		}
+		if message.Role != models.AssistantMessage {
+			usage.UserMessages++
+			continue
+		}
+		usage.AssistantReplies++
+
+		var metadata messageUsageMetadata
+		if message.Metadata != "" {
+			if err := json.Unmarshal([]byte(message.Metadata), &metadata); err != nil {
+				log.Printf("[WARNING] Failed to parse metadata of message %s: %v", message.ID, err)
+				continue
+			}
+		}
+
+		if metadata.Cached {
+			usage.CachedReplies++
+		}
+		usage.RetrievalCount += metadata.Retrieved
+
+		// Cached replies did not call a provider, so they add no tokens or cost
+		if metadata.Cached {
+			continue
+		}
+
+		usage.PromptTokens += metadata.Usage.PromptTokens
+		usage.CompletionTokens += metadata.Usage.CompletionTokens
+		usage.TotalTokens += metadata.Usage.TotalTokens
+		usage.EstimatedCostUSD += metadata.CostUSD
+
+		key := metadata.Provider + "/" + metadata.Model
+		providerUsage, ok := byProvider[key]
+		if !ok {
+			providerUsage = &ProviderUsage{Provider: metadata.Provider, Model: metadata.Model}
+			byProvider[key] = providerUsage
+		}
+		providerUsage.Replies++
+		providerUsage.PromptTokens += metadata.Usage.PromptTokens
+		providerUsage.CompletionTokens += metadata.Usage.CompletionTokens
+		providerUsage.TotalTokens += metadata.Usage.TotalTokens
+		providerUsage.EstimatedCostUSD += metadata.CostUSD
+	}
+
+	usage.Providers = make([]ProviderUsage, 0, len(byProvider))
+	for _, providerUsage := range byProvider {
+		usage.Providers = append(usage.Providers, *providerUsage)
+	}
+	sort.Slice(usage.Providers, func(i, j int) bool {
+		return usage.Providers[i].TotalTokens > usage.Providers[j].TotalTokens
+	})
+
+	return usage, nil
+}
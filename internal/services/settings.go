@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Runtime settings. Their environment variables give the defaults.
+const (
+	SettingPrimaryProvider    = "primary_provider"     // PRIMARY_AI_PROVIDER
+	SettingOpenAIModel        = "openai_model"         // OPENAI_MODEL
+	SettingGeminiModel        = "gemini_model"         // GEMINI_MODEL
+	SettingTemperature        = "temperature"          // TEMPERATURE
+	SettingMaxTokens          = "max_tokens"           // MAX_TOKENS
+	SettingChunkMaxTokens     = "chunk_max_tokens"     // CHUNK_MAX_TOKENS
+	SettingChunkOverlapTokens = "chunk_overlap_tokens" // CHUNK_OVERLAP_TOKENS
+	SettingImportSections     = "import_sections"      // IMPORT_SECTIONS
+)
+
+// settingsCacheTTL bounds how long other instances keep serving a setting changed through one of them
+const settingsCacheTTL = 30 * time.Second
+
+var ErrSettingsAdminOnly = fmt.Errorf("%w: only admins can change settings", ErrForbidden)
+
+// RuntimeSetting is a setting with the value in effect
+type RuntimeSetting struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description"`
+	Value       string     `json:"value"`
+	Default     string     `json:"default"`    // From the environment
+	Overridden  bool       `json:"overridden"` // Value was set by an admin
+	UpdatedBy   *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// settingSpec describes a runtime setting and the values it accepts
+type settingSpec struct {
+	key         string
+	description string
+	validate    func(value string) error
+}
+
+// SettingsService lets admins change tunable parameters without a restart. Settings are stored in the
+// database and take precedence over the environment defaults; they are cached briefly since they are
+// read on every chat, and the cache is dropped when they change.
+type SettingsService struct {
+	db        *gorm.DB
+	unifiedAI *UnifiedAIService
+	defaults  map[string]string
+	specs     []settingSpec
+
+	mu              sync.Mutex
+	overrides       map[string]models.Setting
+	expiresAt       time.Time
+	appliedProvider string
+}
+
+// NewSettingsService creates the service. defaults holds the environment value of every setting.
+func NewSettingsService(db *gorm.DB, unifiedAI *UnifiedAIService, defaults map[string]string) *SettingsService {
+	s := &SettingsService{
+		db:              db,
+		unifiedAI:       unifiedAI,
+		defaults:        defaults,
+		overrides:       map[string]models.Setting{},
+		appliedProvider: defaults[SettingPrimaryProvider],
+	}
+	s.specs = []settingSpec{
+		{SettingPrimaryProvider, "AI provider answering chats; the other one is the fallback", s.validateProvider},
+		{SettingOpenAIModel, "OpenAI chat model", validateModelName},
+		{SettingGeminiModel, "Gemini chat model", validateModelName},
+		{SettingTemperature, "Sampling temperature of chat answers, from 0 to 2", validateTemperature},
+		{SettingMaxTokens, "Maximum tokens of a chat answer", positiveInt},
+		{SettingChunkMaxTokens, "Maximum tokens of an embedded chunk; applies to entries embedded from now on", positiveInt},
+		{SettingChunkOverlapTokens, "Tokens repeated between consecutive chunks; applies to entries embedded from now on", nonNegativeInt},
+		{SettingImportSections, "JSON section options of imported documents (max_length, min_length, overlap, strategy) by default, file_types and categories; applies to imports from now on", validateSectionProfiles},
+	}
+	return s
+}
+
+// Load reads the settings and applies the primary provider, so that a restart keeps the admin's choice
+func (s *SettingsService) Load(ctx context.Context) {
+	overrides := s.current(ctx)
+	if len(overrides) > 0 {
+		log.Printf("[INFO] Loaded %d runtime settings overriding the environment", len(overrides))
+	}
+}
+
+// List returns every setting with the value in effect
+func (s *SettingsService) List(ctx context.Context) []RuntimeSetting {
+	overrides := s.current(ctx)
+	settings := make([]RuntimeSetting, 0, len(s.specs))
+	for _, spec := range s.specs {
+		setting := RuntimeSetting{
+			Key:         spec.key,
+			Description: spec.description,
+			Value:       s.defaults[spec.key],
+			Default:     s.defaults[spec.key],
+		}
+		if override, ok := overrides[spec.key]; ok {
+			setting.Value = override.Value
+			setting.Overridden = true
+			setting.UpdatedBy = &override.UpdatedBy
+			setting.UpdatedAt = &override.UpdatedAt
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// Update sets settings; a nil value resets a setting to its environment default. The changes are
+// validated together and saved in one transaction.
+func (s *SettingsService) Update(ctx context.Context, adminID uuid.UUID, values map[string]*string) ([]RuntimeSetting, error) {
+	if len(values) == 0 {
+		return nil, validationError("no settings given")
+	}
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	effective := map[string]string{}
+	for key, setting := range s.current(ctx) {
+		effective[key] = setting.Value
+	}
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		spec, ok := s.spec(key)
+		if !ok {
+			return nil, validationError("unknown setting %s", key)
+		}
+		if value == nil {
+			delete(effective, key)
+		} else {
+			trimmed := strings.TrimSpace(*value)
+			if err := spec.validate(trimmed); err != nil {
+				return nil, validationError("invalid %s: %v", key, err)
+			}
+			values[key] = &trimmed
+			effective[key] = trimmed
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if err := s.validateChunkOverlap(effective); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			if values[key] == nil {
+				if err := tx.Delete(&models.Setting{}, "key = ?", key).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			setting := models.Setting{Key: key, Value: *values[key], UpdatedBy: adminID}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+			}).Create(&setting).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to save runtime settings: %v", err)
+		return nil, err
+	}
+	s.invalidate()
+
+	log.Printf("[INFO] Admin %s changed runtime settings %s", adminID, strings.Join(keys, ", "))
+	return s.List(ctx), nil
+}
+
+// GenerationDefaults returns the generation parameters set by an admin for a provider. Parameters
+// that are not set are left to the provider's environment defaults.
+func (s *SettingsService) GenerationDefaults(ctx context.Context, provider AIProvider) GenerationParams {
+	var params GenerationParams
+	if s == nil {
+		return params
+	}
+	overrides := s.current(ctx)
+	switch provider {
+	case OpenAIProvider:
+		params.Model = overrides[SettingOpenAIModel].Value
+	case GeminiProvider:
+		params.Model = overrides[SettingGeminiModel].Value
+	}
+	if setting, ok := overrides[SettingTemperature]; ok {
+		if temperature, err := strconv.ParseFloat(setting.Value, 32); err == nil {
+			value := float32(temperature)
+			params.Temperature = &value
+		}
+	}
+	if setting, ok := overrides[SettingMaxTokens]; ok {
+		if maxTokens, err := strconv.Atoi(setting.Value); err == nil {
+			params.MaxTokens = &maxTokens
+		}
+	}
+	return params
+}
+
+// ChunkOptions returns defaults with the chunking parameters set by an admin
+func (s *SettingsService) ChunkOptions(ctx context.Context, defaults ChunkOptions) ChunkOptions {
+	if s == nil {
+		return defaults
+	}
+	overrides := s.current(ctx)
+	if setting, ok := overrides[SettingChunkMaxTokens]; ok {
+		if maxTokens, err := strconv.Atoi(setting.Value); err == nil {
+			defaults.MaxTokens = maxTokens
+		}
+	}
+	if setting, ok := overrides[SettingChunkOverlapTokens]; ok {
+		if overlap, err := strconv.Atoi(setting.Value); err == nil {
+			defaults.OverlapTokens = overlap
+		}
+	}
+	return defaults
+}
+
+// current returns the settings stored in the database, reloading them once the cache expires. A reload
+// that fails keeps the previous settings until the next one.
+func (s *SettingsService) current(ctx context.Context) map[string]models.Setting {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().Before(s.expiresAt) {
+		return s.overrides
+	}
+
+	var stored []models.Setting
+	if err := s.db.WithContext(ctx).Find(&stored).Error; err != nil {
+		log.Printf("[WARNING] Failed to load runtime settings, keeping the previous ones: %v", err)
+	} else {
+		overrides := make(map[string]models.Setting, len(stored))
+		for _, setting := range stored {
+			overrides[setting.Key] = setting
+		}
+		s.overrides = overrides
+	}
+	s.expiresAt = time.Now().Add(settingsCacheTTL)
+	s.applyProvider()
+	return s.overrides
+}
+
+// applyProvider switches the primary provider when its setting changed
+func (s *SettingsService) applyProvider() {
+	provider := s.defaults[SettingPrimaryProvider]
+	if setting, ok := s.overrides[SettingPrimaryProvider]; ok {
+		provider = setting.Value
+	}
+	if provider == s.appliedProvider || s.unifiedAI == nil {
+		return
+	}
+	if err := s.unifiedAI.SetPrimaryProvider(AIProvider(provider)); err != nil {
+		log.Printf("[WARNING] Failed to apply primary provider setting: %v", err)
+		return
+	}
+	s.appliedProvider = provider
+}
+
+func (s *SettingsService) invalidate() {
+	s.mu.Lock()
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *SettingsService) spec(key string) (settingSpec, bool) {
+	for _, spec := range s.specs {
+		if spec.key == key {
+			return spec, true
+		}
+	}
+	return settingSpec{}, false
+}
+
+// validateChunkOverlap checks that chunks still advance with the settings in effect after an update
+func (s *SettingsService) validateChunkOverlap(effective map[string]string) error {
+	value := func(key string) int {
+		if v, ok := effective[key]; ok {
+			n, _ := strconv.Atoi(v)
+			return n
+		}
+		n, _ := strconv.Atoi(s.defaults[key])
+		return n
+	}
+	maxTokens, overlap := value(SettingChunkMaxTokens), value(SettingChunkOverlapTokens)
+	if maxTokens > 0 && overlap >= maxTokens {
+		return validationError("chunk_overlap_tokens (%d) must be less than chunk_max_tokens (%d)", overlap, maxTokens)
+	}
+	return nil
+}
+
+func (s *SettingsService) validateProvider(value string) error {
+	if s.unifiedAI == nil {
+		return errors.New("no AI provider is configured")
+	}
+	for _, provider := range s.unifiedAI.GetAvailableProviders() {
+		if string(provider) == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("provider %q is not available", value)
+}
+
+func (s *SettingsService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSettingsAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrSettingsAdminOnly
+	}
+	return nil
+}
+
+func validateModelName(value string) error {
+	if value == "" {
+		return errors.New("model is required")
+	}
+	return nil
+}
+
+func validateTemperature(value string) error {
+	temperature, err := strconv.ParseFloat(value, 32)
+	if err != nil || temperature < 0 || temperature > 2 {
+		return errors.New("expected a number from 0 to 2")
+	}
+	return nil
+}
+
+func positiveInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return errors.New("expected a positive integer")
+	}
+	return nil
+}
+
+func nonNegativeInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return errors.New("expected a non-negative integer")
+	}
+	return nil
+}
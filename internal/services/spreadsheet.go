@@ -0,0 +1,416 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultRowsPerChunk is how many data rows of a table go into one knowledge entry
+const defaultRowsPerChunk = 50
+
+// Table is a logical table of a spreadsheet; its first row holds the column headers
+type Table struct {
+	Name string // Sheet name, numbered when a sheet holds several tables; empty for a lone CSV table
+	Rows [][]string
+}
+
+// TableExtractor returns the tables of a spreadsheet file
+type TableExtractor interface {
+	ExtractTables(data []byte) ([]Table, error)
+}
+
+// TableExtractorFunc adapts a function to a TableExtractor
+type TableExtractorFunc func(data []byte) ([]Table, error)
+
+// ExtractTables calls f(data)
+func (f TableExtractorFunc) ExtractTables(data []byte) ([]Table, error) {
+	return f(data)
+}
+
+var (
+	tableExtractorsMu sync.RWMutex
+	tableExtractors   = map[string]TableExtractor{
+		".csv":  TableExtractorFunc(extractCSVTables),
+		".xlsx": TableExtractorFunc(extractXLSXTables),
+	}
+)
+
+func init() {
+	for ext, extractor := range tableExtractors {
+		extractors[ext] = tableTextExtractor(extractor)
+	}
+}
+
+// RegisterTableExtractor sets the table extractor for spreadsheet files with the extension ext.
+// Knowledge base imports of such files are chunked by table rows, and their text is the rendered tables.
+func RegisterTableExtractor(ext string, extractor TableExtractor) {
+	ext = strings.ToLower(ext)
+	tableExtractorsMu.Lock()
+	tableExtractors[ext] = extractor
+	tableExtractorsMu.Unlock()
+	RegisterExtractor(ext, tableTextExtractor(extractor))
+}
+
+func lookupTableExtractor(ext string) (TableExtractor, bool) {
+	tableExtractorsMu.RLock()
+	defer tableExtractorsMu.RUnlock()
+	extractor, ok := tableExtractors[strings.ToLower(ext)]
+	return extractor, ok
+}
+
+// tableTextExtractor renders the tables of a spreadsheet as text, for prompt injection scanning and seeding
+func tableTextExtractor(extractor TableExtractor) Extractor {
+	return ExtractorFunc(func(data []byte) (string, error) {
+		tables, err := extractor.ExtractTables(data)
+		if err != nil {
+			return "", err
+		}
+		var text strings.Builder
+		for _, table := range tables {
+			if table.Name != "" {
+				text.WriteString(table.Name + "\n\n")
+			}
+			text.WriteString(markdownTable(table.Rows[0], table.Rows[1:]) + "\n\n")
+		}
+		return text.String(), nil
+	})
+}
+
+// tableSections chunks tables into sections of at most rowsPerChunk data rows, titled after the
+// document and table. A table that fits is one section; larger tables are split and every chunk
+// repeats the header row, so each entry can be read on its own.
+func tableSections(title string, tables []Table, rowsPerChunk int) []DocumentSection {
+	if rowsPerChunk <= 0 {
+		rowsPerChunk = defaultRowsPerChunk
+	}
+
+	var sections []DocumentSection
+	for _, table := range tables {
+		name := title
+		if table.Name != "" {
+			name += " - " + table.Name
+		}
+		header, rows := table.Rows[0], table.Rows[1:]
+		if len(rows) <= rowsPerChunk {
+			sections = append(sections, newTableSection(name, header, rows, len(sections)))
+			continue
+		}
+		for from := 0; from < len(rows); from += rowsPerChunk {
+			to := from + rowsPerChunk
+			if to > len(rows) {
+				to = len(rows)
+			}
+			chunkTitle := fmt.Sprintf("%s, rows %d-%d", name, from+1, to)
+			sections = append(sections, newTableSection(chunkTitle, header, rows[from:to], len(sections)))
+		}
+	}
+	return sections
+}
+
+func newTableSection(title string, header []string, rows [][]string, order int) DocumentSection {
+	content := markdownTable(header, rows)
+	return DocumentSection{
+		Title:     title,
+		Content:   content,
+		Order:     order,
+		WordCount: len(strings.Fields(content)),
+	}
+}
+
+// markdownTable renders rows under a header as a Markdown table
+func markdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for i := range header {
+			cell := ""
+			if i < len(cells) {
+				cell = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(strings.TrimSpace(cells[i]))
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(header)
+	b.WriteString(strings.Repeat("| --- ", len(header)) + "|\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// splitTables splits the rows of a sheet into logical tables at blank rows. Tables without data rows
+// are dropped.
+func splitTables(name string, rows [][]string) []Table {
+	var blocks [][][]string
+	var current [][]string
+	for _, row := range rows {
+		if blankRow(row) {
+			if len(current) > 0 {
+				blocks = append(blocks, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, row)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+
+	var tables []Table
+	for _, block := range blocks {
+		if len(block) < 2 {
+			continue
+		}
+		tables = append(tables, Table{Rows: trimColumns(block)})
+	}
+	for i := range tables {
+		switch {
+		case len(tables) == 1:
+			tables[i].Name = name
+		case name == "":
+			tables[i].Name = fmt.Sprintf("Table %d", i+1)
+		default:
+			tables[i].Name = fmt.Sprintf("%s (table %d)", name, i+1)
+		}
+	}
+	return tables
+}
+
+func blankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// trimColumns drops leading and trailing columns that are empty in every row and pads rows to the same width
+func trimColumns(rows [][]string) [][]string {
+	start, end := -1, 0
+	for _, row := range rows {
+		for i, cell := range row {
+			if strings.TrimSpace(cell) == "" {
+				continue
+			}
+			if start < 0 || i < start {
+				start = i
+			}
+			if i+1 > end {
+				end = i + 1
+			}
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	for i, row := range rows {
+		for len(row) < end {
+			row = append(row, "")
+		}
+		rows[i] = row[start:end]
+	}
+	return rows
+}
+
+// extractCSVTables reads a CSV file, detecting comma, semicolon and tab delimiters
+func extractCSVTables(data []byte) ([]Table, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	delimiter := ','
+	for _, candidate := range []rune{';', '\t'} {
+		if bytes.Count(firstLine, []byte(string(candidate))) > bytes.Count(firstLine, []byte(string(delimiter))) {
+			delimiter = candidate
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+
+	tables := splitTables("", rows)
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no table found in CSV file")
+	}
+	return tables, nil
+}
+
+// XLSX parts read by extractXLSXTables
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"id,attr"` // r:id, the relationship naming the sheet's part
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"` // 1-based; rows without cells are left out of the sheet
+		Cells  []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// extractXLSXTables reads the sheets of an Excel workbook. Cells hold their stored values: formulas
+// give their last computed result and dates their serial number.
+func extractXLSXTables(data []byte) ([]Table, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX file: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	readXML := func(name string, v interface{}) error {
+		file, ok := files[name]
+		if !ok {
+			return fmt.Errorf("failed to read XLSX file: missing %s", name)
+		}
+		r, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read XLSX file: %w", err)
+		}
+		defer r.Close()
+		if err := xml.NewDecoder(io.LimitReader(r, 256<<20)).Decode(v); err != nil {
+			return fmt.Errorf("failed to read XLSX file %s: %w", name, err)
+		}
+		return nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXML("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXML("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var tables []Table
+	for _, sheet := range workbook.Sheets {
+		var worksheet xlsxWorksheet
+		if err := readXML(targets[sheet.RID], &worksheet); err != nil {
+			return nil, err
+		}
+
+		rows := make([][]string, 0, len(worksheet.Rows))
+		for _, row := range worksheet.Rows {
+			for row.Number > len(rows)+1 {
+				rows = append(rows, nil) // Keep blank rows, they separate tables
+			}
+			var cells []string
+			for i, cell := range row.Cells {
+				column := xlsxColumn(cell.Ref)
+				if column < 0 {
+					column = i
+				}
+				for len(cells) <= column {
+					cells = append(cells, "")
+				}
+				switch cell.Type {
+				case "s":
+					if index, err := strconv.Atoi(cell.Value); err == nil && index >= 0 && index < len(shared.Items) {
+						cells[column] = shared.Items[index].String()
+					}
+				case "inlineStr":
+					cells[column] = cell.Inline.String()
+				case "b":
+					cells[column] = map[string]string{"0": "FALSE", "1": "TRUE"}[cell.Value]
+				default:
+					cells[column] = cell.Value
+				}
+			}
+			rows = append(rows, cells)
+		}
+		tables = append(tables, splitTables(sheet.Name, rows)...)
+	}
+
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no table found in XLSX file")
+	}
+	return tables, nil
+}
+
+// xlsxColumn returns the zero-based column of a cell reference such as "C7", or -1 without one
+func xlsxColumn(ref string) int {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return -1
+	}
+	return column - 1
+}
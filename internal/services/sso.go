@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// SSO errors
+var (
+	ErrSSODisabled     = errors.New("single sign-on is not configured")
+	ErrSSOInvalidState = errors.New("invalid or expired single sign-on state, start the login again")
+	ErrSSOIDToken      = errors.New("invalid ID token")
+	ErrSSOAccount      = fmt.Errorf("%w: this account cannot sign in", ErrForbidden)
+)
+
+const (
+	ssoStateTTL         = 10 * time.Minute
+	ssoClockSkew        = time.Minute
+	ssoJWKSRefreshDelay = time.Minute
+)
+
+// roleRank orders roles from least to most privileged, so a user in several mapped groups gets the highest role
+var roleRank = map[models.UserRole]int{
+	models.RegularUser: 0,
+	models.SupportRole: 1,
+	models.EditorRole:  2,
+	models.AdminRole:   3,
+}
+
+// SSOConfig configures OIDC login against an identity provider such as Google Workspace or Entra ID
+type SSOConfig struct {
+	IssuerURL      string // e.g. https://accounts.google.com or https://login.microsoftonline.com/{tenant}/v2.0
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string   // The /api/v1/auth/sso/callback URL registered with the provider
+	Scopes         []string // Added to openid, email and profile
+	GroupsClaim    string   // ID token claim listing the user's groups
+	RoleMapping    map[string]models.UserRole
+	DefaultRole    models.UserRole // Role of provisioned users in none of the mapped groups
+	AllowedDomains []string        // Email domains allowed to sign in; empty allows every domain
+}
+
+// SSOLoginRequest starts a login. State must be returned to Callback along with the provider's answer.
+type SSOLoginRequest struct {
+	URL   string
+	State string
+}
+
+// SSOLogin is a completed login and the session token issued for it
+type SSOLogin struct {
+	User      *models.User `json:"user"`
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Created   bool         `json:"created"` // The user was provisioned by this login
+}
+
+type ssoState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Audience      json.RawMessage `json:"aud"`
+	ExpiresAt     int64           `json:"exp"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified bool            `json:"email_verified"`
+	Name          string          `json:"name"`
+}
+
+// SSOService signs users in through an OIDC provider with the authorization code flow and PKCE, provisions
+// unknown users with a role mapped from their IdP groups, and issues session tokens for them
+type SSOService struct {
+	db     *gorm.DB
+	cfg    SSOConfig
+	tokens *AuthTokenService
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]crypto.PublicKey
+	keysAt    time.Time
+}
+
+// NewSSOService creates the SSO service. It is disabled unless the issuer and client ID are set.
+func NewSSOService(db *gorm.DB, cfg SSOConfig, tokens *AuthTokenService) *SSOService {
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	cfg.Scopes = splitCommaList(strings.Join(cfg.Scopes, ","))
+	cfg.AllowedDomains = splitCommaList(strings.Join(cfg.AllowedDomains, ","))
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if _, ok := roleRank[cfg.DefaultRole]; !ok {
+		cfg.DefaultRole = models.RegularUser
+	}
+	for group, role := range cfg.RoleMapping {
+		if _, ok := roleRank[role]; !ok || group == "" {
+			log.Printf("[WARNING] [SSO] Ignoring mapping of group %q to unknown role %q", group, role)
+			delete(cfg.RoleMapping, group)
+		}
+	}
+	return &SSOService{
+		db:     db,
+		cfg:    cfg,
+		tokens: tokens,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Enabled reports whether an identity provider is configured
+func (s *SSOService) Enabled() bool {
+	return s.cfg.IssuerURL != "" && s.cfg.ClientID != ""
+}
+
+// Login returns the provider URL to send the user to
+func (s *SSOService) Login(ctx context.Context) (*SSOLoginRequest, error) {
+	if !s.Enabled() {
+		return nil, ErrSSODisabled
+	}
+	oauthConfig, err := s.oauthConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := ssoState{
+		State:     randomToken(),
+		Nonce:     randomToken(),
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(ssoStateTTL).Unix(),
+	}
+	signed, err := s.tokens.sign(state)
+	if err != nil {
+		return nil, err
+	}
+	url := oauthConfig.AuthCodeURL(state.State,
+		oauth2.S256ChallengeOption(state.Verifier),
+		oauth2.SetAuthURLParam("nonce", state.Nonce))
+	return &SSOLoginRequest{URL: url, State: signed}, nil
+}
+
+// Callback completes a login: it exchanges the authorization code, verifies the ID token, provisions or
+// updates the user and issues a session token. signedState is the State of the SSOLoginRequest.
+func (s *SSOService) Callback(ctx context.Context, code, state, signedState string) (*SSOLogin, error) {
+	if !s.Enabled() {
+		return nil, ErrSSODisabled
+	}
+	var expected ssoState
+	if err := s.tokens.verify(signedState, &expected); err != nil || expected.State != state || time.Now().Unix() >= expected.ExpiresAt {
+		return nil, ErrSSOInvalidState
+	}
+	if code == "" {
+		return nil, validationError("code is required")
+	}
+
+	oauthConfig, err := s.oauthConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := oauthConfig.Exchange(context.WithValue(ctx, oauth2.HTTPClient, s.client), code, oauth2.VerifierOption(expected.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, fmt.Errorf("%w: the provider returned no ID token", ErrSSOIDToken)
+	}
+
+	claims, groups, err := s.verifyIDToken(ctx, rawIDToken, expected.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	user, created, err := s.provisionUser(ctx, claims, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionToken, expiresAt, err := s.tokens.Issue(user)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] [SSO] %s signed in as %s (role %s)", user.Email, user.ID, user.Role)
+	return &SSOLogin{User: user, Token: sessionToken, ExpiresAt: expiresAt, Created: created}, nil
+}
+
+// provisionUser finds the user of the email of the ID token, creating it when unknown, and applies the role
+// mapped from the user's groups
+func (s *SSOService) provisionUser(ctx context.Context, claims *idTokenClaims, groups []string) (*models.User, bool, error) {
+	// Users are matched by email, so only an email the provider has verified may sign in
+	email := strings.ToLower(claims.Email)
+	if !strings.Contains(email, "@") {
+		return nil, false, fmt.Errorf("%w: the ID token carries no email", ErrSSOIDToken)
+	}
+	if !claims.EmailVerified {
+		return nil, false, fmt.Errorf("%w: email %s is not verified", ErrSSOAccount, email)
+	}
+	if !s.domainAllowed(email) {
+		return nil, false, fmt.Errorf("%w: domain of %s is not allowed", ErrSSOAccount, email)
+	}
+	mappedRole, mapped := s.mapRole(groups)
+
+	var user models.User
+	err := s.db.WithContext(ctx).Unscoped().Where("lower(email) = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		name := claims.Name
+		if name == "" {
+			name = email
+		}
+		user = models.User{Email: email, Name: name, Role: mappedRole, IsActive: true}
+		if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
+			return nil, false, err
+		}
+		log.Printf("[INFO] [SSO] Provisioned user %s (%s) with role %s", user.Email, user.ID, user.Role)
+		return &user, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if user.DeletedAt.Valid || !user.IsActive {
+		return nil, false, fmt.Errorf("%w: user %s is deactivated", ErrSSOAccount, email)
+	}
+
+	updates := map[string]interface{}{}
+	if claims.Name != "" && claims.Name != user.Name {
+		updates["name"] = claims.Name
+	}
+	if mapped && mappedRole != user.Role {
+		log.Printf("[INFO] [SSO] Role of %s changed from %s to %s by its IdP groups", user.Email, user.Role, mappedRole)
+		updates["role"] = mappedRole
+	}
+	if len(updates) > 0 {
+		if err := s.db.WithContext(ctx).Model(&user).Updates(updates).Error; err != nil {
+			return nil, false, err
+		}
+	}
+	return &user, false, nil
+}
+
+// mapRole returns the most privileged role mapped from the groups, and whether any group is mapped
+func (s *SSOService) mapRole(groups []string) (models.UserRole, bool) {
+	role, mapped := s.cfg.DefaultRole, false
+	for _, group := range groups {
+		groupRole, ok := s.cfg.RoleMapping[group]
+		if !ok {
+			continue
+		}
+		if !mapped || roleRank[groupRole] > roleRank[role] {
+			role = groupRole
+		}
+		mapped = true
+	}
+	return role, mapped
+}
+
+func (s *SSOService) domainAllowed(email string) bool {
+	if len(s.cfg.AllowedDomains) == 0 {
+		return true
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, allowed := range s.cfg.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token and returns its
+// claims and groups
+func (s *SSOService) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (*idTokenClaims, []string, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("%w: malformed token", ErrSSOIDToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, nil, fmt.Errorf("%w: malformed header", ErrSSOIDToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: malformed signature", ErrSSOIDToken)
+	}
+	key, err := s.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifyJWTSignature(header.Alg, key, digest[:], signature) {
+		return nil, nil, fmt.Errorf("%w: bad %s signature", ErrSSOIDToken, header.Alg)
+	}
+
+	var claims idTokenClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, nil, fmt.Errorf("%w: malformed claims", ErrSSOIDToken)
+	}
+	discovery, err := s.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case claims.Issuer != discovery.Issuer:
+		return nil, nil, fmt.Errorf("%w: unexpected issuer %q", ErrSSOIDToken, claims.Issuer)
+	case !audienceContains(claims.Audience, s.cfg.ClientID):
+		return nil, nil, fmt.Errorf("%w: not issued to this client", ErrSSOIDToken)
+	case time.Now().Add(-ssoClockSkew).Unix() >= claims.ExpiresAt:
+		return nil, nil, fmt.Errorf("%w: expired", ErrSSOIDToken)
+	case claims.Nonce != nonce:
+		return nil, nil, fmt.Errorf("%w: nonce mismatch", ErrSSOIDToken)
+	}
+
+	var all map[string]json.RawMessage
+	if err := decodeJWTSegment(parts[1], &all); err != nil {
+		return nil, nil, fmt.Errorf("%w: malformed claims", ErrSSOIDToken)
+	}
+	var groups []string
+	if raw, ok := all[s.cfg.GroupsClaim]; ok {
+		if err := json.Unmarshal(raw, &groups); err != nil {
+			var group string
+			if json.Unmarshal(raw, &group) == nil {
+				groups = strings.Split(group, ",")
+			}
+		}
+	}
+	return &claims, groups, nil
+}
+
+// verifyJWTSignature checks an RS256 or ES256 signature of a SHA-256 digest
+func verifyJWTSignature(alg string, key crypto.PublicKey, digest, signature []byte) bool {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) == nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		sig := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(ecKey, digest, r, sig)
+	default:
+		return false
+	}
+}
+
+// audienceContains reports whether the aud claim, a string or an array, names the client
+func audienceContains(raw json.RawMessage, clientID string) bool {
+	var audiences []string
+	if err := json.Unmarshal(raw, &audiences); err != nil {
+		var audience string
+		if json.Unmarshal(raw, &audience) != nil {
+			return false
+		}
+		audiences = []string{audience}
+	}
+	for _, audience := range audiences {
+		if audience == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// oauthConfig builds the OAuth2 client of the provider's endpoints
+func (s *SSOService) oauthConfig(ctx context.Context) (*oauth2.Config, error) {
+	discovery, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Scopes:       append([]string{"openid", "email", "profile"}, s.cfg.Scopes...),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, nil
+}
+
+// discover fetches the provider's OpenID configuration once
+func (s *SSOService) discover(ctx context.Context) (*oidcDiscovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discovery != nil {
+		return s.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(ctx, s.cfg.IssuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover the OpenID configuration of %s: %w", s.cfg.IssuerURL, err)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("the OpenID configuration of %s is incomplete", s.cfg.IssuerURL)
+	}
+	s.discovery = &discovery
+	return s.discovery, nil
+}
+
+// signingKey returns the provider key with the given ID, refetching the key set when the provider rotated keys
+func (s *SSOService) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	discovery, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(s.keysAt) < ssoJWKSRefreshDelay {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrSSOIDToken, kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch the signing keys of %s: %w", s.cfg.IssuerURL, err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("[WARNING] [SSO] Ignoring signing key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	s.keys, s.keysAt = keys, time.Now()
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrSSOIDToken, kid)
+}
+
+// publicKey decodes an RSA or P-256 JSON web key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(v string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func (s *SSOService) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// randomToken returns 32 random bytes, hex-encoded
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate random token: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeStaleReview is the job type of a stale-entry detection run
+const JobTypeStaleReview = "stale_review"
+
+// StaleReviewTriggerManual is the trigger of detection runs queued on request
+const StaleReviewTriggerManual = "manual"
+
+// StaleEntryReport is the outcome of stale-entry detection
+type StaleEntryReport struct {
+	Stale    int64 `json:"stale"`    // Entries waiting for review
+	Marked   int64 `json:"marked"`   // Entries flagged by this run
+	Cleared  int64 `json:"cleared"`  // Flagged entries unpublished since
+	Reminded int   `json:"reminded"` // Creators asked to review their entries
+}
+
+// StaleEntryFilter filters the stale entry listing
+type StaleEntryFilter struct {
+	Category  string
+	CreatedBy *uuid.UUID
+	Limit     int
+	Offset    int
+}
+
+type staleReviewPayload struct {
+	Trigger string `json:"trigger"`
+}
+
+// StaleReviewService flags published knowledge entries not updated for longer than their category allows
+// and reminds their creators to confirm or update them
+type StaleReviewService struct {
+	db             *gorm.DB
+	jobQueue       *JobQueue
+	notifier       Notifier
+	defaultMaxAge  time.Duration
+	reminderPeriod time.Duration
+}
+
+// NewStaleReviewService creates the stale review service. Entries of categories without a review policy are
+// flagged after defaultMaxAge; creators are reminded again every reminderPeriod while their entries wait for
+// review. Scheduled detection runs as the stale_entries task of the scheduler.
+func NewStaleReviewService(db *gorm.DB, jobQueue *JobQueue, notifier Notifier, defaultMaxAge, reminderPeriod time.Duration) *StaleReviewService {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &StaleReviewService{
+		db:             db,
+		jobQueue:       jobQueue,
+		notifier:       notifier,
+		defaultMaxAge:  defaultMaxAge,
+		reminderPeriod: reminderPeriod,
+	}
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *StaleReviewService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeStaleReview, s.handleStaleReviewJob)
+}
+
+// ListStaleEntries lists the entries waiting for review, longest unchanged first, with their total count
+func (s *StaleReviewService) ListStaleEntries(filter StaleEntryFilter) ([]models.KnowledgeEntry, int64, error) {
+	query := s.db.Model(&models.KnowledgeEntry{}).Where("needs_review = true")
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.CreatedBy != nil {
+		query = query.Where("created_by = ?", *filter.CreatedBy)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.KnowledgeEntry
+	err := query.Preload("Creator").Order("updated_at ASC").Limit(filter.Limit).Offset(filter.Offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// ConfirmEntry records that the content of a stale entry is still correct. The entry is no longer flagged
+// and its age starts over; its content and embeddings are left as they are.
+func (s *StaleReviewService) ConfirmEntry(ctx context.Context, id, userID uuid.UUID) (*models.KnowledgeEntry, error) {
+	var entry models.KnowledgeEntry
+	if err := s.db.WithContext(ctx).First(&entry, "id = ?", id).Error; err != nil {
+		return nil, notFound(err, "knowledge entry "+id.String())
+	}
+	err := s.db.WithContext(ctx).Model(&entry).Updates(map[string]interface{}{
+		"needs_review":       false,
+		"stale_at":           nil,
+		"review_reminded_at": nil,
+		"updated_by":         userID,
+		"updated_at":         time.Now(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] User %s confirmed knowledge entry %s is current", userID, id)
+	if err := s.db.WithContext(ctx).First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListPolicies lists the categories with their own review age
+func (s *StaleReviewService) ListPolicies() ([]models.CategoryReviewPolicy, error) {
+	var policies []models.CategoryReviewPolicy
+	if err := s.db.Order("category ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// DefaultMaxAge is the review age of categories without a policy
+func (s *StaleReviewService) DefaultMaxAge() time.Duration {
+	return s.defaultMaxAge
+}
+
+// SetPolicy sets how many days the entries of a category may go without an update, 0 to never flag them.
+// A nil maxAgeDays removes the policy so the category uses the default.
+func (s *StaleReviewService) SetPolicy(category string, maxAgeDays *int) (*models.CategoryReviewPolicy, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return nil, validationError("category is required")
+	}
+	if maxAgeDays == nil {
+		if err := s.db.Delete(&models.CategoryReviewPolicy{}, "category = ?", category).Error; err != nil {
+			return nil, err
+		}
+		log.Printf("[INFO] Removed review policy of category %s", category)
+		return nil, nil
+	}
+	if *maxAgeDays < 0 {
+		return nil, validationError("max_age_days must not be negative")
+	}
+
+	policy := models.CategoryReviewPolicy{Category: category, MaxAgeDays: *maxAgeDays}
+	if err := s.db.Save(&policy).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Entries of category %s are flagged for review after %d days", category, *maxAgeDays)
+	return &policy, nil
+}
+
+// DetectStaleEntries flags published entries not updated within the review age of their category, clears
+// the flag of entries unpublished since, and reminds creators of the entries waiting for review. Flagging
+// does not touch updated_at.
+func (s *StaleReviewService) DetectStaleEntries(ctx context.Context) (*StaleEntryReport, error) {
+	policies, err := s.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+	db := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{})
+	report := &StaleEntryReport{}
+	now := time.Now()
+	flag := map[string]interface{}{"needs_review": true, "stale_at": now}
+
+	custom := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		custom = append(custom, policy.Category)
+		if policy.MaxAgeDays == 0 {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -policy.MaxAgeDays)
+		marked := db.Session(&gorm.Session{}).
+			Where("is_published = true AND needs_review = false AND category = ? AND updated_at < ?", policy.Category, cutoff).
+			UpdateColumns(flag)
+		if marked.Error != nil {
+			return nil, marked.Error
+		}
+		report.Marked += marked.RowsAffected
+	}
+	if s.defaultMaxAge > 0 {
+		query := db.Session(&gorm.Session{}).
+			Where("is_published = true AND needs_review = false AND updated_at < ?", now.Add(-s.defaultMaxAge))
+		if len(custom) > 0 {
+			query = query.Where("category NOT IN ?", custom)
+		}
+		marked := query.UpdateColumns(flag)
+		if marked.Error != nil {
+			return nil, marked.Error
+		}
+		report.Marked += marked.RowsAffected
+	}
+
+	cleared := db.Session(&gorm.Session{}).
+		Where("needs_review = true AND is_published = false").
+		UpdateColumns(map[string]interface{}{"needs_review": false, "stale_at": nil, "review_reminded_at": nil})
+	if cleared.Error != nil {
+		return nil, cleared.Error
+	}
+	report.Cleared = cleared.RowsAffected
+
+	if err := db.Session(&gorm.Session{}).Where("needs_review = true").Count(&report.Stale).Error; err != nil {
+		return nil, err
+	}
+	if report.Reminded, err = s.remindCreators(ctx); err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Stale-entry detection: %d entries need review, %d newly flagged, %d cleared, %d creators reminded",
+		report.Stale, report.Marked, report.Cleared, report.Reminded)
+	return report, nil
+}
+
+// remindCreators emails each creator one list of their entries waiting for review that they have not been
+// reminded of within the reminder period
+func (s *StaleReviewService) remindCreators(ctx context.Context) (int, error) {
+	query := s.db.WithContext(ctx).Preload("Creator").Where("needs_review = true")
+	if s.reminderPeriod > 0 {
+		query = query.Where("review_reminded_at IS NULL OR review_reminded_at < ?", time.Now().Add(-s.reminderPeriod))
+	} else {
+		query = query.Where("review_reminded_at IS NULL")
+	}
+	var entries []models.KnowledgeEntry
+	if err := query.Order("updated_at ASC").Find(&entries).Error; err != nil {
+		return 0, err
+	}
+
+	byCreator := make(map[uuid.UUID][]models.KnowledgeEntry)
+	for _, entry := range entries {
+		byCreator[entry.CreatedBy] = append(byCreator[entry.CreatedBy], entry)
+	}
+	creators := make([]uuid.UUID, 0, len(byCreator))
+	for creatorID := range byCreator {
+		creators = append(creators, creatorID)
+	}
+	sort.Slice(creators, func(i, j int) bool { return creators[i].String() < creators[j].String() })
+
+	reminded := 0
+	for _, creatorID := range creators {
+		owned := byCreator[creatorID]
+		creator := owned[0].Creator
+		if creator.Email == "" {
+			log.Printf("[WARNING] No email address to remind user %s of %d knowledge entries waiting for review", creatorID, len(owned))
+			continue
+		}
+		if err := s.notifier.Notify(ctx, creator.Email, reviewReminderSubject(len(owned)), reviewReminderBody(creator.Name, owned)); err != nil {
+			log.Printf("[WARNING] Failed to remind user %s of knowledge entries waiting for review: %v", creatorID, err)
+			continue
+		}
+
+		ids := make([]uuid.UUID, len(owned))
+		for i, entry := range owned {
+			ids[i] = entry.ID
+		}
+		err := s.db.WithContext(ctx).Model(&models.KnowledgeEntry{}).Where("id IN ?", ids).
+			UpdateColumn("review_reminded_at", time.Now()).Error
+		if err != nil {
+			return reminded, err
+		}
+		reminded++
+	}
+	return reminded, nil
+}
+
+func reviewReminderSubject(count int) string {
+	if count == 1 {
+		return "A knowledge entry you wrote needs review"
+	}
+	return fmt.Sprintf("%d knowledge entries you wrote need review", count)
+}
+
+func reviewReminderBody(name string, entries []models.KnowledgeEntry) string {
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Hi %s,\n\n", name))
+	body.WriteString("These knowledge entries you wrote have not been updated for a while:\n\n")
+	for _, entry := range entries {
+		body.WriteString(fmt.Sprintf("- %s (%s), last updated %s, id %s\n", entry.Title, entry.Category, entry.UpdatedAt.Format("2006-01-02"), entry.ID))
+	}
+	body.WriteString("\nPlease update any entry that is out of date, or confirm it is still correct so it is no longer flagged.\n")
+	return body.String()
+}
+
+// ScheduleRun queues a manual run of stale-entry detection
+func (s *StaleReviewService) ScheduleRun(ctx context.Context) (*models.Job, error) {
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeStaleReview, staleReviewPayload{Trigger: StaleReviewTriggerManual}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// handleStaleReviewJob runs stale-entry detection
+func (s *StaleReviewService) handleStaleReviewJob(ctx context.Context, job *models.Job) error {
+	_, err := s.DetectStaleEntries(ctx)
+	return err
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tic-knowledge-system/internal/db"
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	testDatabase     *gorm.DB
+	testDatabaseErr  error
+	testDatabaseOnce sync.Once
+)
+
+// testDB returns a transaction on the PostgreSQL database of TEST_DATABASE_URL, rolled back when the test
+// ends, and skips the test when the variable is not set. The schema is migrated once.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	testDatabaseOnce.Do(func() {
+		testDatabase, testDatabaseErr = db.Connect(url)
+	})
+	if testDatabaseErr != nil {
+		t.Fatalf("failed to connect to the test database: %v", testDatabaseErr)
+	}
+	tx := testDatabase.Session(&gorm.Session{Logger: logger.Discard}).Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// sqlRecorder is a gorm logger keeping the statements run through it
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// contains reports whether a recorded statement contains every one of parts
+func (r *sqlRecorder) contains(parts ...string) bool {
+	for _, statement := range r.statements {
+		found := true
+		for _, part := range parts {
+			if !strings.Contains(statement, part) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunDB returns a PostgreSQL connection that builds statements without running them, and the recorder
+// the statements are kept in. Queries return no rows.
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	conn, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true, // Writes would otherwise open a transaction on the server
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open a dry-run connection: %v", err)
+	}
+	return conn, recorder
+}
+
+// createTestUser creates a user with a role and comma-separated teams
+func createTestUser(t *testing.T, tx *gorm.DB, role models.UserRole, teams string) *models.User {
+	t.Helper()
+	user := &models.User{Email: uuid.NewString() + "@example.com", Name: "Test user", Role: role, Teams: teams}
+	if err := tx.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// defaultEncoding is the tokenizer of the OpenAI chat and embedding models. Models tiktoken does not know,
+// such as those of Gemini and Ollama, are counted with it too.
+const defaultEncoding = "cl100k_base"
+
+func init() {
+	// The encodings are embedded in the binary instead of being downloaded on first use
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+var (
+	tokenizersMu sync.Mutex
+	tokenizers   = map[string]*tiktoken.Tiktoken{}
+)
+
+// tokenizerFor returns the tiktoken encoding of model, or nil when no encoding could be loaded
+func tokenizerFor(model string) *tiktoken.Tiktoken {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	if encoding, ok := tokenizers[model]; ok {
+		return encoding
+	}
+
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		encoding, err = tiktoken.GetEncoding(defaultEncoding)
+	}
+	if err != nil {
+		log.Printf("[WARNING] Failed to load the tokenizer of model %q, estimating token counts: %v", model, err)
+	}
+	tokenizers[model] = encoding
+	return encoding
+}
+
+// CountTokens returns the number of tokens text takes in the tokenizer of model, e.g. text-embedding-3-small
+func CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	if encoding := tokenizerFor(model); encoding != nil {
+		return len(encoding.EncodeOrdinary(text))
+	}
+	return approximateTokens(text)
+}
+
+// EstimateTokens returns the number of tokens text takes in cl100k_base, the tokenizer of the OpenAI models
+func EstimateTokens(text string) int {
+	return CountTokens("", text)
+}
+
+// tokenEnds returns the byte offsets in text at which its tokens in the tokenizer of model end. A token may
+// end inside a multi-byte character.
+func tokenEnds(model, text string) []int {
+	encoding := tokenizerFor(model)
+	if encoding == nil {
+		return nil
+	}
+	tokens := encoding.EncodeOrdinary(text)
+	ends := make([]int, 0, len(tokens))
+	offset := 0
+	for _, token := range tokens {
+		offset += len(encoding.Decode([]int{token}))
+		ends = append(ends, offset)
+	}
+	return ends
+}
+
+// approximateTokens approximates the cl100k token count for when the tokenizer cannot be loaded: ASCII words
+// cost roughly one token per four characters, accented and non-Latin words cost more, and each punctuation
+// mark is a token.
+func approximateTokens(text string) int {
+	tokens := 0
+	asciiRun, otherRun := 0, 0
+
+	flush := func() {
+		tokens += (asciiRun + 3) / 4
+		tokens += (otherRun + 1) / 2
+		asciiRun, otherRun = 0, 0
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if r < utf8.RuneSelf {
+				asciiRun++
+			} else {
+				otherRun++
+			}
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const topicCacheTTL = 5 * time.Minute
+
+// topicStopWords are ignored when matching questions to topics
+var topicStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "how": true, "what": true,
+	"can": true, "does": true, "are": true, "from": true, "this": true, "that": true,
+	"your": true, "you": true, "about": true, "into": true, "when": true, "where": true,
+}
+
+// TopicClassifier assigns chat questions to topics by matching the words of topic names and descriptions
+type TopicClassifier struct {
+	db       *gorm.DB
+	mu       sync.Mutex
+	topics   []topicTerms
+	loadedAt time.Time
+}
+
+// topicTerms is a topic with its normalized match terms
+type topicTerms struct {
+	id    uint
+	name  string
+	terms []string
+}
+
+// NewTopicClassifier creates a new topic classifier
+func NewTopicClassifier(db *gorm.DB) *TopicClassifier {
+	return &TopicClassifier{db: db}
+}
+
+// Classify returns the ID of the best matching topic, or 0 when no topic matches.
+// The topic whose name appears in the question wins; otherwise the share of matched terms decides.
+func (c *TopicClassifier) Classify(question string) uint {
+	topics := c.loadTopics()
+	words := make(map[string]bool)
+	for _, word := range topicWords(question) {
+		words[word] = true
+	}
+	normalized := " " + strings.Join(topicWords(question), " ") + " "
+
+	var best uint
+	bestScore := 0.0
+	for _, topic := range topics {
+		if len(topic.terms) == 0 {
+			continue
+		}
+
+		score := 0.0
+		if strings.Contains(normalized, " "+strings.Join(topicWords(topic.name), " ")+" ") {
+			score = 1
+		}
+		matched := 0
+		for _, term := range topic.terms {
+			if words[term] {
+				matched++
+			}
+		}
+		score += float64(matched) / float64(len(topic.terms))
+
+		if matched > 0 && score > bestScore {
+			best = topic.id
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// loadTopics returns the cached topic terms, reloading them after topicCacheTTL
+func (c *TopicClassifier) loadTopics() []topicTerms {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.topics != nil && time.Since(c.loadedAt) < topicCacheTTL {
+		return c.topics
+	}
+
+	var topics []models.Topic
+	if err := c.db.Find(&topics).Error; err != nil {
+		log.Printf("[WARNING] Failed to load topics for classification: %v", err)
+		return c.topics
+	}
+
+	loaded := make([]topicTerms, 0, len(topics))
+	for _, topic := range topics {
+		seen := make(map[string]bool)
+		var terms []string
+		for _, word := range topicWords(topic.Name + " " + topic.Description) {
+			if !seen[word] {
+				seen[word] = true
+				terms = append(terms, word)
+			}
+		}
+		loaded = append(loaded, topicTerms{id: topic.ID, name: topic.Name, terms: terms})
+	}
+
+	c.topics = loaded
+	c.loadedAt = time.Now()
+	return loaded
+}
+
+// topicWords lowercases text, strips accents, and returns its significant words
+func topicWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(utils.RemoveDiacritics(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := fields[:0]
+	for _, field := range fields {
+		if len([]rune(field)) > 2 && !topicStopWords[field] {
+			words = append(words, field)
+		}
+	}
+	return words
+}
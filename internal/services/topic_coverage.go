@@ -0,0 +1,276 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// topicClassifyBatch bounds how many unclassified questions one report classifies
+	topicClassifyBatch = 5000
+
+	// Coverage thresholds
+	thinCoverageMinEntries      = 3
+	thinCoverageMinCitationRate = 0.5
+)
+
+// TopicCoverageService reports, per topic, how much knowledge exists and how often answers cite it
+type TopicCoverageService struct {
+	db         *gorm.DB
+	reads      ReadReplicaRouter
+	classifier *TopicClassifier
+}
+
+// NewTopicCoverageService creates the service. reads may be nil to query the primary.
+func NewTopicCoverageService(db *gorm.DB, reads ReadReplicaRouter, classifier *TopicClassifier) *TopicCoverageService {
+	return &TopicCoverageService{db: db, reads: reads, classifier: classifier}
+}
+
+// TopicCoverage is the demand and knowledge coverage of a single topic
+type TopicCoverage struct {
+	TopicID         uint    `json:"topic_id"`
+	Name            string  `json:"name"`
+	Questions       int64   `json:"questions"`
+	DemandShare     float64 `json:"demand_share"`
+	Answers         int64   `json:"answers"`
+	CitedAnswers    int64   `json:"cited_answers"`
+	CitationRate    float64 `json:"citation_rate"`
+	EntriesCited    int64   `json:"entries_cited"`
+	RelevantEntries int64   `json:"relevant_entries"`
+	GapScore        float64 `json:"gap_score"`     // Demand share not served by cited knowledge, higher is worse
+	ThinCoverage    bool    `json:"thin_coverage"` // High demand but few entries or few cited answers
+}
+
+// TopicCoverageReport lists topics ordered by coverage gap, worst first
+type TopicCoverageReport struct {
+	Since                 *time.Time      `json:"since,omitempty"`
+	TotalQuestions        int64           `json:"total_questions"`
+	UnclassifiedQuestions int64           `json:"unclassified_questions"`
+	Topics                []TopicCoverage `json:"topics"`
+	GeneratedAt           time.Time       `json:"generated_at"`
+}
+
+type topicDemandRow struct {
+	TopicID      uint
+	Questions    int64
+	Answers      int64
+	CitedAnswers int64
+}
+
+type topicCitedRow struct {
+	TopicID      uint
+	EntriesCited int64
+}
+
+// GetCoverageReport classifies pending questions and builds the per-topic coverage report.
+// When reclassify is set every question in the window is classified again, e.g. after topics change.
+func (s *TopicCoverageService) GetCoverageReport(since *time.Time, reclassify bool) (*TopicCoverageReport, error) {
+	if reclassify {
+		query := s.db.Model(&models.ChatMessage{}).Where("role = ?", models.UserMessage)
+		if since != nil {
+			query = query.Where("created_at >= ?", *since)
+		}
+		if err := query.Update("topic_id", nil).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := s.classifyPending(since); err != nil {
+		return nil, err
+	}
+
+	conn := readDB(s.reads, s.db)
+
+	// Each question is paired with the first assistant reply that follows it in the session
+	var demand []topicDemandRow
+	demandQuery := conn.Table("chat_messages AS um").
+		Select(`um.topic_id,
+			COUNT(*) AS questions,
+			COUNT(am.id) AS answers,
+			COUNT(am.id) FILTER (WHERE jsonb_typeof(am.metadata->'sources') = 'array' AND jsonb_array_length(am.metadata->'sources') > 0) AS cited_answers`).
+		Joins(`LEFT JOIN LATERAL (
+			SELECT a.id, a.metadata FROM chat_messages AS a
+			WHERE a.session_id = um.session_id AND a.role = 'assistant' AND a.created_at >= um.created_at AND a.deleted_at IS NULL
+			ORDER BY a.created_at LIMIT 1
+		) AS am ON true`).
+		Where("um.role = ? AND um.deleted_at IS NULL AND um.topic_id IS NOT NULL", models.UserMessage).
+		Group("um.topic_id")
+	if since != nil {
+		demandQuery = demandQuery.Where("um.created_at >= ?", *since)
+	}
+	if err := demandQuery.Scan(&demand).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate topic demand: %v", err)
+		return nil, err
+	}
+
+	var cited []topicCitedRow
+	citedQuery := conn.Table("chat_messages AS um").
+		Select("um.topic_id, COUNT(DISTINCT src.entry_id) AS entries_cited").
+		Joins(`JOIN LATERAL (
+			SELECT a.metadata FROM chat_messages AS a
+			WHERE a.session_id = um.session_id AND a.role = 'assistant' AND a.created_at >= um.created_at AND a.deleted_at IS NULL
+			ORDER BY a.created_at LIMIT 1
+		) AS am ON true`).
+		Joins(`CROSS JOIN LATERAL jsonb_array_elements_text(
+			CASE WHEN jsonb_typeof(am.metadata->'sources') = 'array' THEN am.metadata->'sources' ELSE '[]'::jsonb END
+		) AS src(entry_id)`).
+		Where("um.role = ? AND um.deleted_at IS NULL AND um.topic_id > 0", models.UserMessage).
+		Group("um.topic_id")
+	if since != nil {
+		citedQuery = citedQuery.Where("um.created_at >= ?", *since)
+	}
+	if err := citedQuery.Scan(&cited).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate topic citations: %v", err)
+		return nil, err
+	}
+
+	var topics []models.Topic
+	if err := conn.Order("name").Find(&topics).Error; err != nil {
+		return nil, err
+	}
+
+	report := &TopicCoverageReport{Since: since, GeneratedAt: time.Now()}
+	demandByTopic := make(map[uint]topicDemandRow, len(demand))
+	for _, row := range demand {
+		report.TotalQuestions += row.Questions
+		if row.TopicID == 0 {
+			report.UnclassifiedQuestions = row.Questions
+			continue
+		}
+		demandByTopic[row.TopicID] = row
+	}
+	citedByTopic := make(map[uint]int64, len(cited))
+	for _, row := range cited {
+		citedByTopic[row.TopicID] = row.EntriesCited
+	}
+
+	classified := report.TotalQuestions - report.UnclassifiedQuestions
+	averageDemand := 0.0
+	if len(topics) > 0 {
+		averageDemand = float64(classified) / float64(len(topics))
+	}
+
+	for _, topic := range topics {
+		row := demandByTopic[topic.ID]
+		coverage := TopicCoverage{
+			TopicID:      topic.ID,
+			Name:         topic.Name,
+			Questions:    row.Questions,
+			Answers:      row.Answers,
+			CitedAnswers: row.CitedAnswers,
+			EntriesCited: citedByTopic[topic.ID],
+		}
+
+		// Entries mentioning the topic in their title, tags, or content
+		pattern := "%" + topic.Name + "%"
+		if err := conn.Model(&models.KnowledgeEntry{}).
+			Where("is_published = true AND (title ILIKE ? OR tags ILIKE ? OR content ILIKE ?)", pattern, pattern, pattern).
+			Count(&coverage.RelevantEntries).Error; err != nil {
+			return nil, err
+		}
+
+		if classified > 0 {
+			coverage.DemandShare = float64(coverage.Questions) / float64(classified)
+		}
+		if coverage.Answers > 0 {
+			coverage.CitationRate = float64(coverage.CitedAnswers) / float64(coverage.Answers)
+		}
+
+		entryCoverage := math.Min(1, float64(coverage.RelevantEntries)/thinCoverageMinEntries)
+		coverage.GapScore = coverage.DemandShare * (1 - entryCoverage*coverage.CitationRate)
+		coverage.ThinCoverage = coverage.Questions > 0 && float64(coverage.Questions) >= averageDemand &&
+			(coverage.RelevantEntries < thinCoverageMinEntries || coverage.CitationRate < thinCoverageMinCitationRate)
+
+		report.Topics = append(report.Topics, coverage)
+	}
+
+	sort.SliceStable(report.Topics, func(i, j int) bool {
+		if report.Topics[i].GapScore != report.Topics[j].GapScore {
+			return report.Topics[i].GapScore > report.Topics[j].GapScore
+		}
+		return report.Topics[i].Questions > report.Topics[j].Questions
+	})
+
+	return report, nil
+}
+
+// classifyPending assigns a topic to user questions that have not been classified yet
+func (s *TopicCoverageService) classifyPending(since *time.Time) error {
+	var pending []models.ChatMessage
+	query := s.db.Select("id", "content").
+		Where("role = ? AND topic_id IS NULL", models.UserMessage).
+		Order("created_at DESC").
+		Limit(topicClassifyBatch)
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+	if err := query.Find(&pending).Error; err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	byTopic := make(map[uint][]interface{})
+	for _, message := range pending {
+		topicID := s.classifier.Classify(message.Content)
+		byTopic[topicID] = append(byTopic[topicID], message.ID)
+	}
+	for topicID, ids := range byTopic {
+		if err := s.db.Model(&models.ChatMessage{}).Where("id IN ?", ids).Update("topic_id", topicID).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[INFO] Classified %d chat questions into %d topics", len(pending), len(byTopic))
+	return nil
+}
+
+// QuestionStatsRollup is the outcome of an analytics rollup
+type QuestionStatsRollup struct {
+	TopicQuestions int64 `json:"topic_questions"` // Questions counted in the topic stats
+	TimeQuestions  int64 `json:"time_questions"`  // Questions counted in the time of day stats
+}
+
+// RollupQuestionStats classifies pending questions and recomputes the share of each topic and time of day
+// shown on the context dashboard. Chat requests only increment the counts.
+func (s *TopicCoverageService) RollupQuestionStats() (*QuestionStatsRollup, error) {
+	if err := s.classifyPending(nil); err != nil {
+		return nil, err
+	}
+
+	rollup := &QuestionStatsRollup{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rollup.TopicQuestions, err = rollupPercent(tx, "topic_question_stats"); err != nil {
+			return err
+		}
+		rollup.TimeQuestions, err = rollupPercent(tx, "time_distribution_stats")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Rolled up question stats: %d by topic, %d by time of day", rollup.TopicQuestions, rollup.TimeQuestions)
+	return rollup, nil
+}
+
+// rollupPercent sets the percent column of a stats table from its counts and returns their total
+func rollupPercent(tx *gorm.DB, table string) (int64, error) {
+	var total int64
+	if err := tx.Table(table).Select("COALESCE(SUM(count), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	percent := gorm.Expr("0")
+	if total > 0 {
+		percent = gorm.Expr("ROUND(count * 100.0 / ?)", total)
+	}
+	err := tx.Table(table).Where("1 = 1").Updates(map[string]interface{}{"percent": percent, "updated_at": time.Now()}).Error
+	return total, err
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeTrashPurge is the job type of a run permanently deleting old soft-deleted records
+const JobTypeTrashPurge = "trash_purge"
+
+// TrashPurgeTriggerManual is the trigger of purges queued on request
+const TrashPurgeTriggerManual = "manual"
+
+// Kinds of soft-deleted records in the trash
+const (
+	TrashKnowledgeEntry    = "knowledge_entry"
+	TrashTemplate          = "template"
+	TrashChatSession       = "chat_session"
+	TrashFeedback          = "feedback"
+	TrashDocument          = "document"
+	TrashRetrievalEvalPair = "retrieval_eval_pair"
+)
+
+// ErrTrashAdminOnly is returned when a non-admin restores or purges deleted records
+var ErrTrashAdminOnly = fmt.Errorf("%w: only admins can restore or purge deleted records", ErrForbidden)
+
+// trashKind describes how the records of a kind are listed, restored and purged
+type trashKind struct {
+	model      interface{}
+	label      string // SQL expression naming a record in the listing
+	restorable bool   // Documents lose their stored file when deleted, so they cannot come back
+	purged     bool   // Whether records are purged once the retention period is over
+	// purgeWhere is an SQL condition restricting the purged records; empty purges them all. Chat sessions
+	// archived by a retention policy stay active and are kept until a delete policy removes them; those
+	// deleted by their user are inactive and purged.
+	purgeWhere string
+}
+
+var trashKinds = map[string]trashKind{
+	TrashKnowledgeEntry:    {model: &models.KnowledgeEntry{}, label: "title", restorable: true, purged: true},
+	TrashTemplate:          {model: &models.Template{}, label: "name", restorable: true, purged: true},
+	TrashChatSession:       {model: &models.ChatSession{}, label: "title", restorable: true, purged: true, purgeWhere: "is_active = false"},
+	TrashFeedback:          {model: &models.Feedback{}, label: "left(comment, 200)", restorable: true, purged: true},
+	TrashDocument:          {model: &models.UploadedDocument{}, label: "original_file_name", purged: true},
+	TrashRetrievalEvalPair: {model: &models.RetrievalEvalPair{}, label: "left(question, 200)", restorable: true, purged: true},
+}
+
+// trashOrder lists the kinds so that records are purged before the records they reference
+var trashOrder = []string{TrashRetrievalEvalPair, TrashFeedback, TrashDocument, TrashKnowledgeEntry, TrashTemplate, TrashChatSession}
+
+// TrashItem is a soft-deleted record
+type TrashItem struct {
+	Type       string     `json:"type"`
+	ID         uuid.UUID  `json:"id"`
+	Label      string     `json:"label"`
+	DeletedAt  time.Time  `json:"deleted_at"`
+	PurgeAt    *time.Time `json:"purge_at,omitempty"` // When the record is permanently deleted; unset for records kept
+	Restorable bool       `json:"restorable"`
+	Purged     bool       `json:"-"`
+}
+
+// TrashFilter filters the trash listing
+type TrashFilter struct {
+	Type   string
+	Limit  int
+	Offset int
+}
+
+// TrashPurgeReport is the outcome of a purge run
+type TrashPurgeReport struct {
+	Purged     map[string]int64 `json:"purged"`     // Records permanently deleted, per kind
+	Embeddings int64            `json:"embeddings"` // Superseded embedding records removed
+	Skipped    int              `json:"skipped"`    // Records still referenced by other records, retried on the next run
+	Cutoff     time.Time        `json:"cutoff"`
+}
+
+type trashPurgePayload struct {
+	Trigger string `json:"trigger"`
+}
+
+// TrashService lists and restores soft-deleted records and permanently deletes those deleted more than the
+// retention period ago, together with their vectors in Qdrant
+type TrashService struct {
+	db               *gorm.DB
+	knowledgeService *KnowledgeService
+	vectorService    VectorStore
+	jobQueue         *JobQueue
+	retention        time.Duration
+	storage          FileStorage
+}
+
+// NewTrashService creates the trash service. Records are purged retention after their deletion; scheduled
+// purges run as the trash_purge task of the scheduler.
+func NewTrashService(db *gorm.DB, knowledgeService *KnowledgeService, vectorService VectorStore, jobQueue *JobQueue, retention time.Duration) *TrashService {
+	return &TrashService{
+		db:               db,
+		knowledgeService: knowledgeService,
+		vectorService:    vectorService,
+		jobQueue:         jobQueue,
+		retention:        retention,
+	}
+}
+
+// SetStorage lets purging chat sessions delete the files of their attachments
+func (s *TrashService) SetStorage(storage FileStorage) {
+	s.storage = storage
+}
+
+// RegisterJobHandlers registers the background jobs owned by this service
+func (s *TrashService) RegisterJobHandlers(queue *JobQueue) {
+	queue.Register(JobTypeTrashPurge, s.handleTrashPurgeJob)
+}
+
+// ListTrash lists soft-deleted records, most recently deleted first, with their total count
+func (s *TrashService) ListTrash(filter TrashFilter) ([]TrashItem, int64, error) {
+	kinds := trashOrder
+	if filter.Type != "" {
+		if _, ok := trashKinds[filter.Type]; !ok {
+			return nil, 0, validationError("unknown trash type %q", filter.Type)
+		}
+		kinds = []string{filter.Type}
+	}
+
+	selects := make([]string, 0, len(kinds))
+	for _, name := range kinds {
+		table, err := s.tableName(trashKinds[name].model)
+		if err != nil {
+			return nil, 0, err
+		}
+		selects = append(selects, fmt.Sprintf("SELECT '%s' AS type, id, coalesce(%s, '') AS label, deleted_at, %s AS purged FROM %s WHERE deleted_at IS NOT NULL",
+			name, trashKinds[name].label, trashKinds[name].purgeCondition(), table))
+	}
+	union := strings.Join(selects, " UNION ALL ")
+
+	var total int64
+	if err := s.db.Raw("SELECT count(*) FROM (" + union + ") AS trash").Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var items []TrashItem
+	err := s.db.Raw("SELECT * FROM ("+union+") AS trash ORDER BY deleted_at DESC, id LIMIT ? OFFSET ?", filter.Limit, filter.Offset).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		kind := trashKinds[items[i].Type]
+		items[i].Restorable = kind.restorable
+		if items[i].Purged && s.retention > 0 {
+			purgeAt := items[i].DeletedAt.Add(s.retention)
+			items[i].PurgeAt = &purgeAt
+		}
+	}
+	return items, total, nil
+}
+
+// Restore brings a soft-deleted record back. A knowledge entry gets its embeddings back and no longer redirects
+// to an entry it was merged into; a chat session becomes active again with the messages deleted along with it.
+func (s *TrashService) Restore(ctx context.Context, kindName string, id, adminID uuid.UUID) (*TrashItem, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	kind, ok := trashKinds[kindName]
+	if !ok {
+		return nil, validationError("unknown trash type %q", kindName)
+	}
+	if !kind.restorable {
+		return nil, validationError("a deleted %s cannot be restored, its stored file was removed", kindName)
+	}
+
+	var deletedAt time.Time
+	err := s.db.WithContext(ctx).Unscoped().Model(kind.model).
+		Where("id = ? AND deleted_at IS NOT NULL", id).Select("deleted_at").Scan(&deletedAt).Error
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.IsZero() {
+		return nil, notFound(gorm.ErrRecordNotFound, "deleted "+kindName+" "+id.String())
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(kind.model).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		switch kindName {
+		case TrashKnowledgeEntry:
+			if err := tx.Delete(&models.KnowledgeEntryRedirect{}, "from_id = ?", id).Error; err != nil {
+				return err
+			}
+			if s.knowledgeService.jobQueue != nil {
+				return s.knowledgeService.enqueueEmbeddingsTx(tx, id)
+			}
+		case TrashChatSession:
+			return restoreChatSessionTx(tx, id, deletedAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if kindName == TrashKnowledgeEntry && s.knowledgeService.jobQueue == nil {
+		if err := s.knowledgeService.GenerateEmbeddings(ctx, id); err != nil {
+			log.Printf("[WARNING] Failed to restore embeddings of knowledge entry %s: %v", id, err)
+		}
+	}
+
+	log.Printf("[INFO] Admin %s restored deleted %s %s", adminID, kindName, id)
+	return &TrashItem{Type: kindName, ID: id, DeletedAt: deletedAt, Restorable: true}, nil
+}
+
+// Purge permanently deletes the records deleted more than the retention period ago. Knowledge entries lose
+// their vectors in Qdrant and their embedding records; a record still referenced by another one is skipped.
+func (s *TrashService) Purge(ctx context.Context) (*TrashPurgeReport, error) {
+	cutoff := time.Now().Add(-s.retention)
+	report := &TrashPurgeReport{Purged: map[string]int64{}, Cutoff: cutoff}
+	if s.retention <= 0 {
+		return report, nil
+	}
+
+	for _, name := range trashOrder {
+		kind := trashKinds[name]
+		if !kind.purged {
+			continue
+		}
+		var ids []uuid.UUID
+		query := s.db.WithContext(ctx).Unscoped().Model(kind.model).Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		if kind.purgeWhere != "" {
+			query = query.Where(kind.purgeWhere)
+		}
+		err := query.Pluck("id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if err := s.purgeRecord(ctx, name, kind, id); err != nil {
+				log.Printf("[WARNING] Skipped purging deleted %s %s: %v", name, id, err)
+				report.Skipped++
+				continue
+			}
+			report.Purged[name]++
+		}
+	}
+
+	// Regenerating embeddings soft-deletes the previous records after removing their vectors
+	result := s.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.VectorEmbedding{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	report.Embeddings = result.RowsAffected
+
+	log.Printf("[INFO] Trash purge: %v records purged, %d embedding records removed, %d skipped (deleted before %s)",
+		report.Purged, report.Embeddings, report.Skipped, cutoff.Format(time.RFC3339))
+	return report, nil
+}
+
+// purgeRecord permanently deletes one soft-deleted record
+func (s *TrashService) purgeRecord(ctx context.Context, name string, kind trashKind, id uuid.UUID) error {
+	if name == TrashKnowledgeEntry && s.vectorService != nil {
+		if err := s.vectorService.DeleteByKnowledgeEntry(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete vectors: %w", err)
+		}
+	}
+	var attachmentKeys []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if name == TrashKnowledgeEntry {
+			if err := tx.Unscoped().Where("knowledge_entry_id = ?", id).Delete(&models.VectorEmbedding{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.KnowledgeEntryRedirect{}, "to_id = ?", id).Error; err != nil {
+				return err
+			}
+		}
+		if name == TrashChatSession {
+			var err error
+			attachmentKeys, err = purgeChatSessionTx(tx, id)
+			return err
+		}
+		return tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(kind.model).Error
+	})
+	if err != nil {
+		return err
+	}
+	deleteAttachmentFiles(ctx, s.storage, attachmentKeys)
+	return nil
+}
+
+// purgeCondition is the SQL condition telling whether a record of the kind is purged
+func (k trashKind) purgeCondition() string {
+	switch {
+	case !k.purged:
+		return "false"
+	case k.purgeWhere != "":
+		return "(" + k.purgeWhere + ")"
+	}
+	return "true"
+}
+
+// Retention is how long deleted records stay in the trash, 0 when they are never purged
+func (s *TrashService) Retention() time.Duration {
+	return s.retention
+}
+
+// ScheduleRun queues a manual purge
+func (s *TrashService) ScheduleRun(ctx context.Context, adminID uuid.UUID) (*models.Job, error) {
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	if s.jobQueue == nil {
+		return nil, errors.New("job queue not configured")
+	}
+	return s.jobQueue.Enqueue(ctx, MaintenanceQueue, JobTypeTrashPurge, trashPurgePayload{Trigger: TrashPurgeTriggerManual}, &EnqueueOptions{MaxAttempts: 1})
+}
+
+// handleTrashPurgeJob purges the trash
+func (s *TrashService) handleTrashPurgeJob(ctx context.Context, job *models.Job) error {
+	_, err := s.Purge(ctx)
+	return err
+}
+
+// tableName returns the table of a model
+func (s *TrashService) tableName(model interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: s.db}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (s *TrashService) requireAdmin(adminID uuid.UUID) error {
+	var admin models.User
+	if err := s.db.Select("id", "role").First(&admin, "id = ?", adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTrashAdminOnly
+		}
+		return err
+	}
+	if admin.Role != models.AdminRole {
+		return ErrTrashAdminOnly
+	}
+	return nil
+}
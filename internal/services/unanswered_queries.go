@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+	"tic-knowledge-system/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultUnansweredSimilarity = 0.85 // Questions at least this similar are counted as the same question
+	maxClusteredQueries         = 5000 // Most recent unanswered questions clustered by a report
+	maxEmbeddedPerReport        = 200  // Questions a report embeds itself; the scheduled task embeds the rest
+	maxClusterExamples          = 5
+)
+
+// UnansweredCluster is a group of similar questions the knowledge base did not answer
+type UnansweredCluster struct {
+	Question         string    `json:"question"` // Most asked wording
+	Count            int       `json:"count"`
+	UniqueUsers      int       `json:"unique_users"`
+	NoResults        int       `json:"no_results"`        // Searches that found no entries
+	NegativeFeedback int       `json:"negative_feedback"` // Chat answers rated as unhelpful
+	Examples         []string  `json:"examples"`          // Other wordings, most asked first
+	LastAskedAt      time.Time `json:"last_asked_at"`
+}
+
+// queryWording is one wording of an unanswered question with its occurrences
+type queryWording struct {
+	text      string
+	count     int
+	embedding []float32
+	users     map[uuid.UUID]bool
+	reasons   map[models.QueryLogReason]int
+	lastAsked time.Time
+}
+
+// SetEmbeddings embeds the logged unanswered questions with provider, so that the report groups different
+// wordings of the same question. Without it only identical wordings are grouped.
+func (s *EntryAnalyticsService) SetEmbeddings(unifiedAI *UnifiedAIService, provider AIProvider) {
+	s.unifiedAI = unifiedAI
+	s.embeddingProvider = provider
+}
+
+// RecordFeedback logs the question of a chat answer rated as unhelpful
+func (s *EntryAnalyticsService) RecordFeedback(feedback *models.Feedback) {
+	if s == nil || !negativeFeedback(feedback) {
+		return
+	}
+	var logged int64
+	s.db.Model(&models.QueryLog{}).Where("message_id = ?", feedback.MessageID).Count(&logged)
+	if logged > 0 {
+		return
+	}
+
+	var answer models.ChatMessage
+	if err := s.db.First(&answer, "id = ?", feedback.MessageID).Error; err != nil {
+		log.Printf("[WARNING] Failed to find the answer %s of negative feedback: %v", feedback.MessageID, err)
+		return
+	}
+	var question models.ChatMessage
+	err := s.db.Where("session_id = ? AND role = ? AND created_at <= ?", answer.SessionID, models.UserMessage, answer.CreatedAt).
+		Order("created_at DESC").First(&question).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[WARNING] Failed to find the question of answer %s: %v", answer.ID, err)
+		}
+		return
+	}
+	userID := feedback.UserID
+	s.logQuery(question.Content, models.ViewFromChat, models.QueryNegativeFeedback, &userID, &answer.ID)
+}
+
+// negativeFeedback reports whether feedback says an answer did not help
+func negativeFeedback(feedback *models.Feedback) bool {
+	switch feedback.Type {
+	case models.NotHelpfulFeedback, models.IncorrectFeedback, models.IncompleFeedback:
+		return true
+	}
+	return feedback.Rating > 0 && feedback.Rating <= 2
+}
+
+// logQuery records an unanswered question; it is embedded later
+func (s *EntryAnalyticsService) logQuery(query string, source models.ViewSource, reason models.QueryLogReason, userID, messageID *uuid.UUID) {
+	record := &models.QueryLog{
+		Query:     utils.TruncateString(strings.TrimSpace(query), maxLoggedQueryRunes),
+		Source:    source,
+		Reason:    reason,
+		UserID:    userID,
+		MessageID: messageID,
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		log.Printf("[WARNING] Failed to log unanswered question %.50q: %v", query, err)
+	}
+}
+
+// EmbedPendingQueries embeds up to limit logged questions that have no embedding yet, most recent first,
+// and returns how many it embedded. It stops at the first provider error.
+func (s *EntryAnalyticsService) EmbedPendingQueries(ctx context.Context, limit int) (int, error) {
+	if s.unifiedAI == nil {
+		return 0, nil
+	}
+	var pending []models.QueryLog
+	if err := s.db.WithContext(ctx).Where("embedding = '' OR embedding IS NULL").
+		Order("created_at DESC").Limit(limit).Find(&pending).Error; err != nil {
+		return 0, err
+	}
+
+	encoded := map[string]string{} // Repeated wordings are embedded once
+	embedded := 0
+	for _, record := range pending {
+		key := foldSearchText(record.Query)
+		vector, ok := encoded[key]
+		if !ok {
+			embedding, err := s.unifiedAI.CreateEmbedding(ctx, record.Query, s.embeddingProvider)
+			if err != nil {
+				return embedded, err
+			}
+			raw, _ := json.Marshal(embedding)
+			vector = string(raw)
+			encoded[key] = vector
+		}
+		if err := s.db.WithContext(ctx).Model(&models.QueryLog{}).Where("id = ?", record.ID).
+			Update("embedding", vector).Error; err != nil {
+			return embedded, err
+		}
+		embedded++
+	}
+	if embedded > 0 {
+		log.Printf("[INFO] Embedded %d unanswered questions", embedded)
+	}
+	return embedded, nil
+}
+
+// UnansweredQuestions groups the questions logged since a time into clusters of similar questions, most
+// asked first. Questions are clustered greedily: each wording, most asked first, joins the first cluster
+// whose leading wording is at least similarity similar, or starts a cluster of its own.
+func (s *EntryAnalyticsService) UnansweredQuestions(ctx context.Context, since time.Time, similarity float64, limit int) ([]UnansweredCluster, error) {
+	if _, err := s.EmbedPendingQueries(ctx, maxEmbeddedPerReport); err != nil {
+		log.Printf("[WARNING] Failed to embed unanswered questions, grouping the rest by wording: %v", err)
+	}
+
+	var logs []models.QueryLog
+	if err := s.db.WithContext(ctx).Where("created_at >= ?", since).
+		Order("created_at DESC").Limit(maxClusteredQueries).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	clusters := clusterQueries(queryWordings(logs), similarity)
+	if len(clusters) > limit {
+		clusters = clusters[:limit]
+	}
+	return clusters, nil
+}
+
+// queryWordings collapses logged questions with the same wording, most asked first
+func queryWordings(logs []models.QueryLog) []*queryWording {
+	byText := map[string]*queryWording{}
+	var wordings []*queryWording
+	for _, record := range logs {
+		key := foldSearchText(record.Query)
+		if key == "" {
+			continue
+		}
+		wording, ok := byText[key]
+		if !ok {
+			wording = &queryWording{
+				text:    record.Query,
+				users:   map[uuid.UUID]bool{},
+				reasons: map[models.QueryLogReason]int{},
+			}
+			byText[key] = wording
+			wordings = append(wordings, wording)
+		}
+		wording.count++
+		wording.reasons[record.Reason]++
+		if record.UserID != nil {
+			wording.users[*record.UserID] = true
+		}
+		if record.CreatedAt.After(wording.lastAsked) {
+			wording.lastAsked = record.CreatedAt
+		}
+		if wording.embedding == nil && record.Embedding != "" {
+			var embedding []float32
+			if err := json.Unmarshal([]byte(record.Embedding), &embedding); err == nil {
+				wording.embedding = embedding
+			}
+		}
+	}
+	sort.SliceStable(wordings, func(i, j int) bool { return wordings[i].count > wordings[j].count })
+	return wordings
+}
+
+// clusterQueries groups similar wordings, largest clusters first
+func clusterQueries(wordings []*queryWording, similarity float64) []UnansweredCluster {
+	type cluster struct {
+		leader   *queryWording
+		wordings []*queryWording
+	}
+	var clusters []*cluster
+	for _, wording := range wordings {
+		var match *cluster
+		if wording.embedding != nil {
+			for _, c := range clusters {
+				if c.leader.embedding != nil && cosineSimilarity(wording.embedding, c.leader.embedding) >= similarity {
+					match = c
+					break
+				}
+			}
+		}
+		if match == nil {
+			match = &cluster{leader: wording}
+			clusters = append(clusters, match)
+		}
+		match.wordings = append(match.wordings, wording)
+	}
+
+	result := make([]UnansweredCluster, 0, len(clusters))
+	for _, c := range clusters {
+		users := map[uuid.UUID]bool{}
+		summary := UnansweredCluster{Question: c.leader.text, Examples: []string{}}
+		for i, wording := range c.wordings {
+			summary.Count += wording.count
+			summary.NoResults += wording.reasons[models.QueryNoResults]
+			summary.NegativeFeedback += wording.reasons[models.QueryNegativeFeedback]
+			for user := range wording.users {
+				users[user] = true
+			}
+			if wording.lastAsked.After(summary.LastAskedAt) {
+				summary.LastAskedAt = wording.lastAsked
+			}
+			if i > 0 && len(summary.Examples) < maxClusterExamples {
+				summary.Examples = append(summary.Examples, wording.text)
+			}
+		}
+		summary.UniqueUsers = len(users)
+		result = append(result, summary)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].LastAskedAt.After(result[j].LastAskedAt)
+	})
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 )
 
 // AIProvider represents the different AI providers available
@@ -12,14 +13,19 @@ type AIProvider string
 const (
 	OpenAIProvider AIProvider = "openai"
 	GeminiProvider AIProvider = "gemini"
+	OllamaProvider AIProvider = "ollama"
 )
 
 // UnifiedAIService provides a unified interface for different AI providers
 type UnifiedAIService struct {
 	openAIService *OpenAIService
 	geminiService *GeminiService
+	ollamaService *OllamaService
+	health        *ProviderHealthMonitor
 	primaryProvider AIProvider
 	fallbackProvider AIProvider
+	validateOutput   bool
+	settings         *SettingsService
 }
 
 // UnifiedChatRequest represents a chat request that works with any AI provider
@@ -30,11 +36,13 @@ type UnifiedChatRequest struct {
 	UseKnowledgeBase bool               `json:"use_knowledge_base"`
 	SystemPrompt    string              `json:"system_prompt,omitempty"`
 	PreferredProvider AIProvider         `json:"preferred_provider,omitempty"`
+	Generation      GenerationParams    `json:"generation,omitempty"`
 }
 
 type UnifiedChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content string      `json:"content"`
+	Images  []ChatImage `json:"-"` // Sent to vision-capable models with the message
 }
 
 type UnifiedChatResponse struct {
@@ -43,26 +51,67 @@ type UnifiedChatResponse struct {
 	SessionID string     `json:"session_id"`
 	Provider  AIProvider `json:"provider"`
 	Model     string     `json:"model"`
+	Usage     TokenUsage `json:"usage"`
+}
+
+// TokenUsage reports the tokens consumed by a single completion as returned by the provider
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // NewUnifiedAIService creates a new unified AI service with multiple providers
 func NewUnifiedAIService(openAIService *OpenAIService, geminiService *GeminiService, primaryProvider AIProvider) *UnifiedAIService {
 	log.Printf("[INFO] Initializing unified AI service with primary provider: %s", primaryProvider)
 	
-	fallbackProvider := GeminiProvider
-	if primaryProvider == GeminiProvider {
-		fallbackProvider = OpenAIProvider
-	}
-
 	return &UnifiedAIService{
 		openAIService:    openAIService,
 		geminiService:    geminiService,
+		health:           NewProviderHealthMonitor(defaultCircuitFailureThreshold, defaultCircuitCooldown),
 		primaryProvider:  primaryProvider,
-		fallbackProvider: fallbackProvider,
+		fallbackProvider: fallbackProviderFor(primaryProvider),
+		validateOutput:   true,
 	}
 }
 
-// ChatCompletion sends a chat request to the AI provider with fallback support
+// fallbackProviderFor returns the provider tried when the primary fails.
+// Local deployments are chosen for privacy, so Ollama never falls back to a cloud provider.
+func fallbackProviderFor(primaryProvider AIProvider) AIProvider {
+	switch primaryProvider {
+	case GeminiProvider:
+		return OpenAIProvider
+	case OllamaProvider:
+		return ""
+	default:
+		return GeminiProvider
+	}
+}
+
+// useGeminiHelpers reports whether title, summary and keyword generation may use Gemini
+func (s *UnifiedAIService) useGeminiHelpers() bool {
+	return s.geminiService != nil && s.primaryProvider != OllamaProvider
+}
+
+// SetResponseValidation turns the checks of provider output for empty answers, boilerplate refusals,
+// answers in another language and system prompt leaks on or off
+func (s *UnifiedAIService) SetResponseValidation(enabled bool) {
+	s.validateOutput = enabled
+}
+
+// SetOllamaService enables the self-hosted provider
+func (s *UnifiedAIService) SetOllamaService(ollamaService *OllamaService) {
+	s.ollamaService = ollamaService
+}
+
+// SetSettings applies the model, temperature and max tokens set by admins to requests that do not override them
+func (s *UnifiedAIService) SetSettings(settings *SettingsService) {
+	s.settings = settings
+}
+
+// ChatCompletion sends a chat request to the AI provider with fallback support.
+// Providers whose circuit breaker is open are skipped without being called.
+// A response that fails validation is retried once, on the fallback provider when there is one.
 func (s *UnifiedAIService) ChatCompletion(ctx context.Context, req UnifiedChatRequest) (*UnifiedChatResponse, error) {
 	log.Printf("[INFO] Processing unified chat completion request")
 	
@@ -73,34 +122,98 @@ func (s *UnifiedAIService) ChatCompletion(ctx context.Context, req UnifiedChatRe
 		log.Printf("[DEBUG] Using preferred provider: %s", provider)
 	}
 
-	// Try primary provider first
-	response, err := s.callProvider(ctx, req, provider)
-	if err != nil {
-		log.Printf("[WARNING] Primary provider %s failed: %v", provider, err)
-		
-		// Try fallback provider
-		log.Printf("[INFO] Attempting fallback to provider: %s", s.fallbackProvider)
-		response, err = s.callProvider(ctx, req, s.fallbackProvider)
+	candidates := []AIProvider{provider}
+	if s.fallbackProvider != "" && s.fallbackProvider != provider {
+		candidates = append(candidates, s.fallbackProvider)
+	}
+
+	var lastErr error
+	var lastIssue *ResponseIssue
+	var softRejected *UnifiedChatResponse // Failed only a soft check; returned when nothing better comes back
+	retried := false
+	for i := 0; i < len(candidates); i++ {
+		candidate := candidates[i]
+		if i > 0 && candidate == provider {
+			log.Printf("[INFO] Retrying provider: %s", candidate)
+		} else if i > 0 {
+			log.Printf("[INFO] Attempting fallback to provider: %s", candidate)
+		}
+		if !s.health.Allow(candidate) {
+			log.Printf("[WARNING] Circuit for provider %s is open, skipping", candidate)
+			lastErr = fmt.Errorf("circuit open for provider %s", candidate)
+			continue
+		}
+
+		attempt := req
+		if candidate != provider && attempt.Generation.Model != "" {
+			// Model names are provider specific, so the fallback uses its default model
+			log.Printf("[INFO] Dropping model override %s for fallback provider %s", attempt.Generation.Model, candidate)
+			attempt.Generation.Model = ""
+		}
+
+		start := time.Now()
+		response, err := s.callProvider(ctx, attempt, candidate)
 		if err != nil {
-			log.Printf("[ERROR] Fallback provider %s also failed: %v", s.fallbackProvider, err)
-			return nil, fmt.Errorf("both AI providers failed - primary: %s, fallback: %s", provider, s.fallbackProvider)
+			// A cancelled request says nothing about the provider's health
+			if ctx.Err() == nil {
+				s.health.RecordFailure(candidate, time.Since(start), err)
+			}
+			log.Printf("[WARNING] Provider %s failed: %v", candidate, err)
+			lastErr = err
+			continue
+		}
+		s.health.RecordSuccess(candidate, time.Since(start))
+		response.Provider = candidate
+
+		if s.validateOutput {
+			if issue := validateResponse(attempt, response.Message); issue != nil {
+				log.Printf("[WARNING] Provider %s returned an unusable response: %s", candidate, issue)
+				lastIssue = issue
+				if !issue.hard() && softRejected == nil {
+					softRejected = response
+				}
+				if retried {
+					break
+				}
+				retried = true
+				if i == len(candidates)-1 {
+					candidates = append(candidates, candidate) // No fallback left, retry the same provider
+				}
+				continue
+			}
 		}
-		provider = s.fallbackProvider
+
+		log.Printf("[INFO] Successfully completed chat using provider: %s", candidate)
+		return response, nil
 	}
 
-	response.Provider = provider
-	log.Printf("[INFO] Successfully completed chat using provider: %s", provider)
-	
-	return response, nil
+	if softRejected != nil {
+		log.Printf("[WARNING] Using response from provider %s despite %s", softRejected.Provider, lastIssue.Kind)
+		return softRejected, nil
+	}
+	if lastIssue != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, lastIssue)
+	}
+	if len(candidates) == 1 {
+		return nil, providerUnavailable(fmt.Errorf("AI provider %s failed: %w", provider, lastErr))
+	}
+	log.Printf("[ERROR] Fallback provider %s also failed: %v", s.fallbackProvider, lastErr)
+	return nil, providerUnavailable(fmt.Errorf("both AI providers failed - primary: %s, fallback: %s", provider, s.fallbackProvider))
 }
 
 // callProvider calls the specific AI provider
 func (s *UnifiedAIService) callProvider(ctx context.Context, req UnifiedChatRequest, provider AIProvider) (*UnifiedChatResponse, error) {
+	req.Generation = req.Generation.withDefaults(s.settings.GenerationDefaults(ctx, provider))
 	switch provider {
 	case OpenAIProvider:
 		return s.callOpenAI(ctx, req)
 	case GeminiProvider:
 		return s.callGemini(ctx, req)
+	case OllamaProvider:
+		if s.ollamaService == nil {
+			return nil, fmt.Errorf("Ollama service not available")
+		}
+		return s.ollamaService.ChatCompletion(ctx, req)
 	default:
 		return nil, fmt.Errorf("unsupported AI provider: %s", provider)
 	}
@@ -119,6 +232,8 @@ func (s *UnifiedAIService) callOpenAI(ctx context.Context, req UnifiedChatReques
 		Context:         req.Context,
 		SessionID:       req.SessionID,
 		UseKnowledgeBase: req.UseKnowledgeBase,
+		Generation:      req.Generation,
+		SystemPrompt:    req.SystemPrompt,
 	}
 
 	// Convert messages
@@ -126,6 +241,7 @@ func (s *UnifiedAIService) callOpenAI(ctx context.Context, req UnifiedChatReques
 		openAIReq.Messages = append(openAIReq.Messages, OpenAIChatMessage{
 			Role:    msg.Role,
 			Content: msg.Content,
+			Images:  msg.Images,
 		})
 	}
 
@@ -138,7 +254,8 @@ func (s *UnifiedAIService) callOpenAI(ctx context.Context, req UnifiedChatReques
 		Message:   response.Message,
 		Sources:   response.Sources,
 		SessionID: response.SessionID,
-		Model:     "openai", // Will be filled by the caller
+		Model:     response.Model,
+		Usage:     response.Usage,
 	}, nil
 }
 
@@ -156,6 +273,7 @@ func (s *UnifiedAIService) callGemini(ctx context.Context, req UnifiedChatReques
 		SessionID:       req.SessionID,
 		UseKnowledgeBase: req.UseKnowledgeBase,
 		SystemPrompt:    req.SystemPrompt,
+		Generation:      req.Generation,
 	}
 
 	// Convert messages
@@ -163,6 +281,7 @@ func (s *UnifiedAIService) callGemini(ctx context.Context, req UnifiedChatReques
 		geminiReq.Messages = append(geminiReq.Messages, GeminiChatMessage{
 			Role:    msg.Role,
 			Content: msg.Content,
+			Images:  msg.Images,
 		})
 	}
 
@@ -176,6 +295,7 @@ func (s *UnifiedAIService) callGemini(ctx context.Context, req UnifiedChatReques
 		Sources:   response.Sources,
 		SessionID: response.SessionID,
 		Model:     response.Model,
+		Usage:     response.Usage,
 	}, nil
 }
 
@@ -194,14 +314,19 @@ func (s *UnifiedAIService) CreateEmbedding(ctx context.Context, text string, pro
 			return nil, fmt.Errorf("Gemini service not available")
 		}
 		return s.geminiService.CreateEmbedding(ctx, text)
+	case OllamaProvider:
+		if s.ollamaService == nil {
+			return nil, fmt.Errorf("Ollama service not available")
+		}
+		return s.ollamaService.CreateEmbedding(ctx, text)
 	default:
 		return nil, fmt.Errorf("unsupported provider for embeddings: %s", provider)
 	}
 }
 
-// GenerateTitle generates a title using Gemini (if available)
+// GenerateTitle generates a title using Gemini (if available and the primary provider is not local)
 func (s *UnifiedAIService) GenerateTitle(ctx context.Context, content string) (string, error) {
-	if s.geminiService != nil {
+	if s.useGeminiHelpers() {
 		return s.geminiService.GenerateTitle(ctx, content)
 	}
 	
@@ -213,9 +338,9 @@ func (s *UnifiedAIService) GenerateTitle(ctx context.Context, content string) (s
 	return words, nil
 }
 
-// SummarizeContent summarizes content using Gemini (if available)
+// SummarizeContent summarizes content using Gemini (if available and the primary provider is not local)
 func (s *UnifiedAIService) SummarizeContent(ctx context.Context, content string) (string, error) {
-	if s.geminiService != nil {
+	if s.useGeminiHelpers() {
 		return s.geminiService.SummarizeContent(ctx, content)
 	}
 	
@@ -227,9 +352,9 @@ func (s *UnifiedAIService) SummarizeContent(ctx context.Context, content string)
 	return summary, nil
 }
 
-// ExtractKeywords extracts keywords using Gemini (if available)
+// ExtractKeywords extracts keywords using Gemini (if available and the primary provider is not local)
 func (s *UnifiedAIService) ExtractKeywords(ctx context.Context, content string) ([]string, error) {
-	if s.geminiService != nil {
+	if s.useGeminiHelpers() {
 		return s.geminiService.ExtractKeywords(ctx, content)
 	}
 	
@@ -248,6 +373,10 @@ func (s *UnifiedAIService) GetAvailableProviders() []AIProvider {
 	if s.geminiService != nil {
 		providers = append(providers, GeminiProvider)
 	}
+
+	if s.ollamaService != nil {
+		providers = append(providers, OllamaProvider)
+	}
 	
 	return providers
 }
@@ -259,6 +388,7 @@ func (s *UnifiedAIService) SetPrimaryProvider(provider AIProvider) error {
 	for _, available := range availableProviders {
 		if available == provider {
 			s.primaryProvider = provider
+			s.fallbackProvider = fallbackProviderFor(provider)
 			log.Printf("[INFO] Primary AI provider changed to: %s", provider)
 			return nil
 		}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UsageService records the token usage of every chat answer and aggregates cost
+type UsageService struct {
+	db         *gorm.DB
+	reads      ReadReplicaRouter
+	classifier *TopicClassifier
+}
+
+// NewUsageService creates the usage service. reads may be nil to query the primary,
+// and classifier may be nil to record usage without a topic.
+func NewUsageService(db *gorm.DB, reads ReadReplicaRouter, classifier *TopicClassifier) *UsageService {
+	return &UsageService{db: db, reads: reads, classifier: classifier}
+}
+
+// UsageTotals are the summed usage of a group of records
+type UsageTotals struct {
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// UserUsage is the usage of a single user
+type UserUsage struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email"`
+	UsageTotals
+}
+
+// TopicUsage is the usage of questions classified into a topic
+type TopicUsage struct {
+	TopicID uint   `json:"topic_id"`
+	Name    string `json:"name"`
+	UsageTotals
+}
+
+// DailyUsage is the usage of a single day
+type DailyUsage struct {
+	Day string `json:"day"`
+	UsageTotals
+}
+
+// UsageReport aggregates usage per user, per topic, and per day, highest cost first
+type UsageReport struct {
+	Since       *time.Time   `json:"since,omitempty"`
+	Until       *time.Time   `json:"until,omitempty"`
+	Totals      UsageTotals  `json:"totals"`
+	ByUser      []UserUsage  `json:"by_user"`
+	ByTopic     []TopicUsage `json:"by_topic"`
+	ByDay       []DailyUsage `json:"by_day"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+const usageTotalsSelect = `COUNT(*) AS requests,
+	COALESCE(SUM(u.prompt_tokens), 0) AS prompt_tokens,
+	COALESCE(SUM(u.completion_tokens), 0) AS completion_tokens,
+	COALESCE(SUM(u.total_tokens), 0) AS total_tokens,
+	COALESCE(SUM(u.cost_usd), 0) AS cost_usd`
+
+// RecordChat stores the usage of a provider call that answered question. Failures are logged
+// rather than returned so that accounting never fails a chat.
+func (s *UsageService) RecordChat(userID, sessionID uuid.UUID, messageID *uuid.UUID, question string, provider AIProvider, model string, usage TokenUsage) {
+	if s == nil {
+		return
+	}
+
+	record := &models.UsageRecord{
+		UserID:           userID,
+		SessionID:        sessionID,
+		MessageID:        messageID,
+		Provider:         string(provider),
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CostUSD:          EstimateCost(model, usage),
+	}
+	if s.classifier != nil {
+		record.TopicID = s.classifier.Classify(question)
+	}
+
+	if err := s.db.Create(record).Error; err != nil {
+		log.Printf("[WARNING] Failed to record usage for session %s: %v", sessionID, err)
+	}
+}
+
+// GetUsageReport aggregates the usage recorded between since and until; both are optional
+func (s *UsageService) GetUsageReport(since, until *time.Time) (*UsageReport, error) {
+	conn := readDB(s.reads, s.db)
+	records := func() *gorm.DB {
+		query := conn.Table("usage_records AS u")
+		if since != nil {
+			query = query.Where("u.created_at >= ?", *since)
+		}
+		if until != nil {
+			query = query.Where("u.created_at < ?", *until)
+		}
+		return query
+	}
+
+	report := &UsageReport{
+		Since:       since,
+		Until:       until,
+		ByUser:      []UserUsage{},
+		ByTopic:     []TopicUsage{},
+		ByDay:       []DailyUsage{},
+		GeneratedAt: time.Now(),
+	}
+
+	if err := records().Select(usageTotalsSelect).Scan(&report.Totals).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate usage totals: %v", err)
+		return nil, err
+	}
+
+	if err := records().
+		Select("u.user_id, COALESCE(users.name, '') AS name, COALESCE(users.email, '') AS email, " + usageTotalsSelect).
+		Joins("LEFT JOIN users ON users.id = u.user_id").
+		Group("u.user_id, users.name, users.email").
+		Order("cost_usd DESC, total_tokens DESC").
+		Scan(&report.ByUser).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate usage per user: %v", err)
+		return nil, err
+	}
+
+	if err := records().
+		Select("u.topic_id, COALESCE(topics.name, 'Unclassified') AS name, " + usageTotalsSelect).
+		Joins("LEFT JOIN topics ON topics.id = u.topic_id").
+		Group("u.topic_id, topics.name").
+		Order("cost_usd DESC, total_tokens DESC").
+		Scan(&report.ByTopic).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate usage per topic: %v", err)
+		return nil, err
+	}
+
+	if err := records().
+		Select("TO_CHAR(DATE(u.created_at), 'YYYY-MM-DD') AS day, " + usageTotalsSelect).
+		Group("DATE(u.created_at)").
+		Order("DATE(u.created_at)").
+		Scan(&report.ByDay).Error; err != nil {
+		log.Printf("[ERROR] Failed to aggregate usage per day: %v", err)
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"tic-knowledge-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UserSpec is an account to create
+type UserSpec struct {
+	Email string
+	Name  string
+	Role  models.UserRole
+	Teams []string
+}
+
+// UserService manages accounts outside of SSO provisioning, e.g. service accounts created with ticctl
+type UserService struct {
+	db *gorm.DB
+}
+
+// NewUserService creates the service
+func NewUserService(db *gorm.DB) *UserService {
+	return &UserService{db: db}
+}
+
+// CreateUser creates an active account. Emails are unique, including those of deactivated users, and are
+// matched case-insensitively like SSO sign-ins; the name defaults to the email and the role to user.
+func (s *UserService) CreateUser(ctx context.Context, spec UserSpec) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(spec.Email))
+	if !strings.Contains(email, "@") {
+		return nil, validationError("invalid email %q", spec.Email)
+	}
+	role := spec.Role
+	if role == "" {
+		role = models.RegularUser
+	}
+	if _, ok := roleRank[role]; !ok {
+		return nil, validationError("unknown role %q, expected user, support, editor or admin", role)
+	}
+	name := strings.TrimSpace(spec.Name)
+	if name == "" {
+		name = email
+	}
+	var teams []string
+	for _, team := range spec.Teams {
+		if team = strings.TrimSpace(team); team != "" {
+			teams = append(teams, team)
+		}
+	}
+
+	var existing int64
+	if err := s.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("lower(email) = ?", email).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, validationError("a user with email %s already exists", email)
+	}
+
+	user := &models.User{Email: email, Name: name, Role: role, Teams: strings.Join(teams, ","), IsActive: true}
+	if err := s.db.WithContext(ctx).Create(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	log.Printf("[INFO] Created user %s (%s) with role %s", user.Email, user.ID, user.Role)
+	return user, nil
+}
+
+// FindByEmail returns the active user with the email
+func (s *UserService) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Where("lower(email) = ? AND is_active = true", strings.ToLower(strings.TrimSpace(email))).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFound(err, "user "+email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
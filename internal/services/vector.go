@@ -10,6 +10,24 @@ import (
 	"github.com/google/uuid"
 )
 
+// Vector database backends
+const (
+	VectorBackendQdrant   = "qdrant"
+	VectorBackendPgvector = "pgvector"
+)
+
+// VectorStore stores the embeddings of knowledge entries as points with a payload and searches them by
+// similarity. Filters use the Qdrant filter syntax, whichever the backend.
+type VectorStore interface {
+	StoreWithPayload(ctx context.Context, vector []float32, text string, knowledgeEntryID uuid.UUID, extra map[string]interface{}) (string, error)
+	SearchByVectorWithFilter(ctx context.Context, vector []float32, limit int, filter map[string]interface{}) ([]VectorSearchResult, error)
+	DeleteByKnowledgeEntry(ctx context.Context, knowledgeEntryID uuid.UUID) error
+	Vectors(ctx context.Context, pointIDs []string) ([][]float32, error)
+	EnsurePayloadIndexes(ctx context.Context) error
+	Ping(ctx context.Context) error
+	CollectionInfo(ctx context.Context) (*QdrantCollectionInfo, error)
+}
+
 // VectorService handles vector database operations (Qdrant)
 type VectorService struct {
 	baseURL        string
@@ -32,9 +50,10 @@ type QdrantPoint struct {
 }
 
 type QdrantSearchRequest struct {
-	Vector      []float32 `json:"vector"`
-	Limit       int       `json:"limit"`
-	WithPayload bool      `json:"with_payload"`
+	Vector      []float32              `json:"vector"`
+	Limit       int                    `json:"limit"`
+	WithPayload bool                   `json:"with_payload"`
+	Filter      map[string]interface{} `json:"filter,omitempty"`
 }
 
 type QdrantSearchResponse struct {
@@ -51,6 +70,9 @@ type VectorSearchResult struct {
 	KnowledgeEntryID uuid.UUID
 	Score            float64
 	ChunkText        string
+	StartOffset      int
+	EndOffset        int
+	Question         string // Set when the hit is a related question of the entry rather than a content chunk
 }
 
 func (s *VectorService) InitializeCollection(ctx context.Context, dimension int) error {
@@ -86,19 +108,72 @@ func (s *VectorService) InitializeCollection(ctx context.Context, dimension int)
 		return fmt.Errorf("failed to create collection: status %d", resp.StatusCode)
 	}
 
+	return s.EnsurePayloadIndexes(ctx)
+}
+
+// payloadIndexes are the payload fields search filters match, with their Qdrant index type. Without an
+// index Qdrant scans the payload of every candidate point.
+var payloadIndexes = map[string]string{
+	"knowledge_entry_id": "keyword",
+	"kind":               "keyword",
+	"allowed_roles":      "keyword",
+	"allowed_teams":      "keyword",
+	"category":           "keyword",
+	"tags":               "keyword",
+	"is_published":       "bool",
+	"priority":           "integer",
+	"updated_at":         "integer",
+}
+
+// EnsurePayloadIndexes creates the payload indexes of the filtered fields. Creating an index that exists
+// is a no-op, so it is safe on every start.
+func (s *VectorService) EnsurePayloadIndexes(ctx context.Context) error {
+	for field, schema := range payloadIndexes {
+		reqBody, err := json.Marshal(map[string]string{"field_name": field, "field_schema": schema})
+		if err != nil {
+			return err
+		}
+
+		url := fmt.Sprintf("%s/collections/%s/index", s.baseURL, s.collectionName)
+		req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(reqBody))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to index payload field %s: status %d", field, resp.StatusCode)
+		}
+	}
 	return nil
 }
 
 func (s *VectorService) Store(ctx context.Context, vector []float32, text string, knowledgeEntryID uuid.UUID) (string, error) {
+	return s.StoreWithPayload(ctx, vector, text, knowledgeEntryID, nil)
+}
+
+// StoreWithPayload stores a vector together with additional payload fields
+// (e.g. access control lists) that can later be used in search filters
+func (s *VectorService) StoreWithPayload(ctx context.Context, vector []float32, text string, knowledgeEntryID uuid.UUID, extra map[string]interface{}) (string, error) {
 	pointID := uuid.New().String()
 
+	payload := map[string]interface{}{
+		"text":               text,
+		"knowledge_entry_id": knowledgeEntryID.String(),
+	}
+	for key, value := range extra {
+		payload[key] = value
+	}
+
 	point := QdrantPoint{
-		ID:     pointID,
-		Vector: vector,
-		Payload: map[string]interface{}{
-			"text":                text,
-			"knowledge_entry_id":  knowledgeEntryID.String(),
-		},
+		ID:      pointID,
+		Vector:  vector,
+		Payload: payload,
 	}
 
 	reqBody := map[string]interface{}{
@@ -138,10 +213,16 @@ func (s *VectorService) Search(ctx context.Context, query string, limit int) ([]
 }
 
 func (s *VectorService) SearchByVector(ctx context.Context, vector []float32, limit int) ([]VectorSearchResult, error) {
+	return s.SearchByVectorWithFilter(ctx, vector, limit, nil)
+}
+
+// SearchByVectorWithFilter searches vectors restricted by a Qdrant payload filter
+func (s *VectorService) SearchByVectorWithFilter(ctx context.Context, vector []float32, limit int, filter map[string]interface{}) ([]VectorSearchResult, error) {
 	searchReq := QdrantSearchRequest{
 		Vector:      vector,
 		Limit:       limit,
 		WithPayload: true,
+		Filter:      filter,
 	}
 
 	reqBody, err := json.Marshal(searchReq)
@@ -174,26 +255,42 @@ func (s *VectorService) SearchByVector(ctx context.Context, vector []float32, li
 
 	var results []VectorSearchResult
 	for _, result := range searchResp.Result {
-		knowledgeEntryIDStr, ok := result.Payload["knowledge_entry_id"].(string)
-		if !ok {
-			continue
+		if found, ok := vectorSearchResult(result.Score, result.Payload); ok {
+			results = append(results, found)
 		}
+	}
 
-		knowledgeEntryID, err := uuid.Parse(knowledgeEntryIDStr)
-		if err != nil {
-			continue
-		}
+	return results, nil
+}
 
-		text, _ := result.Payload["text"].(string)
+// vectorSearchResult reads a search hit from the payload of its point
+func vectorSearchResult(score float64, payload map[string]interface{}) (VectorSearchResult, bool) {
+	knowledgeEntryIDStr, ok := payload["knowledge_entry_id"].(string)
+	if !ok {
+		return VectorSearchResult{}, false
+	}
 
-		results = append(results, VectorSearchResult{
-			KnowledgeEntryID: knowledgeEntryID,
-			Score:            result.Score,
-			ChunkText:        text,
-		})
+	knowledgeEntryID, err := uuid.Parse(knowledgeEntryIDStr)
+	if err != nil {
+		return VectorSearchResult{}, false
 	}
 
-	return results, nil
+	text, _ := payload["text"].(string)
+	startOffset, _ := payload["start_offset"].(float64)
+	endOffset, _ := payload["end_offset"].(float64)
+	var question string
+	if kind, _ := payload["kind"].(string); kind == vectorKindQuestion {
+		question = text
+	}
+
+	return VectorSearchResult{
+		KnowledgeEntryID: knowledgeEntryID,
+		Score:            score,
+		ChunkText:        text,
+		StartOffset:      int(startOffset),
+		EndOffset:        int(endOffset),
+		Question:         question,
+	}, true
 }
 
 func (s *VectorService) Delete(ctx context.Context, pointID string) error {
@@ -267,3 +364,121 @@ func (s *VectorService) DeleteByKnowledgeEntry(ctx context.Context, knowledgeEnt
 
 	return nil
 }
+
+// Vectors returns the stored vectors of points, skipping IDs that no longer exist
+func (s *VectorService) Vectors(ctx context.Context, pointIDs []string) ([][]float32, error) {
+	reqBody := map[string]interface{}{
+		"ids":          pointIDs,
+		"with_vector":  true,
+		"with_payload": false,
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points", s.baseURL, s.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve vectors: status %d", resp.StatusCode)
+	}
+
+	var pointsResp struct {
+		Result []QdrantPoint `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pointsResp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, 0, len(pointsResp.Result))
+	for _, point := range pointsResp.Result {
+		if len(point.Vector) > 0 {
+			vectors = append(vectors, point.Vector)
+		}
+	}
+	return vectors, nil
+}
+
+// Ping checks that Qdrant is reachable
+func (s *VectorService) Ping(ctx context.Context) error {
+	url := fmt.Sprintf("%s/collections", s.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list collections: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// QdrantCollectionInfo describes the knowledge collection, or the table of pgvector
+type QdrantCollectionInfo struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"` // green, yellow while optimizing, red on errors
+	PointsCount int64  `json:"points_count"`
+	Dimension   int    `json:"dimension"`
+}
+
+// CollectionInfo returns the point count and vector dimension of the collection
+func (s *VectorService) CollectionInfo(ctx context.Context) (*QdrantCollectionInfo, error) {
+	url := fmt.Sprintf("%s/collections/%s", s.baseURL, s.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get collection %s: status %d", s.collectionName, resp.StatusCode)
+	}
+
+	var collectionResp struct {
+		Result struct {
+			Status      string `json:"status"`
+			PointsCount int64  `json:"points_count"`
+			Config      struct {
+				Params struct {
+					Vectors struct {
+						Size int `json:"size"`
+					} `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&collectionResp); err != nil {
+		return nil, err
+	}
+
+	return &QdrantCollectionInfo{
+		Name:        s.collectionName,
+		Status:      collectionResp.Result.Status,
+		PointsCount: collectionResp.Result.PointsCount,
+		Dimension:   collectionResp.Result.Config.Params.Vectors.Size,
+	}, nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"tic-knowledge-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// JobTypeVectorStatusPoll checks whether OpenAI has finished processing a document added to the vector store
+const JobTypeVectorStatusPoll = "vector_status_poll"
+
+const (
+	vectorStatusFirstPoll   = 10 * time.Second
+	vectorStatusMaxInterval = 5 * time.Minute
+	vectorStatusTimeout     = 2 * time.Hour
+)
+
+// Vector store file statuses reported by OpenAI
+const (
+	vectorFileInProgress = "in_progress"
+	vectorFileCompleted  = "completed"
+	vectorFileFailed     = "failed"
+	vectorFileCancelled  = "cancelled"
+)
+
+// vectorStatusPayload is the job payload for polling the processing status of a vector store file
+type vectorStatusPayload struct {
+	DocumentID   uuid.UUID `json:"document_id"`
+	VectorFileID string    `json:"vector_file_id"`
+	Poll         int       `json:"poll"`
+	Deadline     time.Time `json:"deadline"`
+}
+
+// vectorStoreFileStatus is the part of a vector store file the poller reads
+type vectorStoreFileStatus struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	LastError *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"last_error"`
+}
+
+// SetNotifier emails uploaders when their document has been indexed or imported, or failed to process
+func (s *IngestionService) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// SetWebhook posts document processing events to an external URL
+func (s *IngestionService) SetWebhook(webhook *DocumentWebhook) {
+	s.webhook = webhook
+}
+
+// scheduleVectorStatusPoll queues the next status check of a document's vector store file
+func (s *IngestionService) scheduleVectorStatusPoll(ctx context.Context, payload vectorStatusPayload) error {
+	interval := vectorStatusFirstPoll << uint(payload.Poll)
+	if interval <= 0 || interval > vectorStatusMaxInterval {
+		interval = vectorStatusMaxInterval
+	}
+	_, err := s.jobQueue.Enqueue(ctx, DocumentQueue, JobTypeVectorStatusPoll, payload, &EnqueueOptions{
+		RunAt: time.Now().Add(interval),
+	})
+	return err
+}
+
+// handleVectorStatusPollJob checks the processing status of a vector store file. While OpenAI is still
+// processing it the next check is scheduled; once it finishes the document is updated and its uploader told.
+func (s *IngestionService) handleVectorStatusPollJob(ctx context.Context, job *models.Job) error {
+	var payload vectorStatusPayload
+	if err := DecodeJobPayload(job, &payload); err != nil {
+		return err
+	}
+
+	var document models.UploadedDocument
+	if err := s.db.Preload("Uploader").First(&document, "id = ?", payload.DocumentID).Error; err != nil {
+		log.Printf("[INFO] Stopped polling the vector store for document %s: %v", payload.DocumentID, err)
+		return nil
+	}
+	// Deleted, resynced or already settled documents need no more polling
+	if document.Status != models.DocumentAddedToVector || document.VectorFileID != payload.VectorFileID {
+		return nil
+	}
+
+	file, err := s.getVectorStoreFile(ctx, document.VectorStoreID, document.VectorFileID)
+	if err != nil {
+		return err
+	}
+
+	switch file.Status {
+	case vectorFileCompleted:
+		s.updateDocumentStatus(document.ID, models.DocumentIndexed, "", "", "")
+		document.Status = models.DocumentIndexed
+		log.Printf("[INFO] Document %s (%s) indexed by the vector store", document.ID, document.FileName)
+	case vectorFileFailed, vectorFileCancelled:
+		message := "vector store processing " + file.Status
+		if file.LastError != nil && file.LastError.Message != "" {
+			message += ": " + file.LastError.Message
+		}
+		s.updateDocumentStatus(document.ID, models.DocumentProcessingFailed, "", "", message)
+		document.Status = models.DocumentProcessingFailed
+		document.ErrorMessage = message
+		log.Printf("[WARNING] Document %s (%s): %s", document.ID, document.FileName, message)
+	default:
+		if time.Now().Before(payload.Deadline) {
+			payload.Poll++
+			return s.scheduleVectorStatusPoll(ctx, payload)
+		}
+		message := fmt.Sprintf("vector store still %s after %v", file.Status, vectorStatusTimeout)
+		s.updateDocumentStatus(document.ID, models.DocumentProcessingFailed, "", "", message)
+		document.Status = models.DocumentProcessingFailed
+		document.ErrorMessage = message
+		log.Printf("[WARNING] Document %s (%s): %s", document.ID, document.FileName, message)
+	}
+
+	s.announceProcessed(ctx, &document)
+	return nil
+}
+
+// getVectorStoreFile reads a file of the vector store. A file that no longer exists is reported as failed.
+func (s *IngestionService) getVectorStoreFile(ctx context.Context, vectorStoreID, vectorFileID string) (*vectorStoreFileStatus, error) {
+	url := fmt.Sprintf("%s/vector_stores/%s/files/%s", openAIAPIBaseURL, vectorStoreID, vectorFileID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.openaiAPIKey)
+	req.Header.Set("OpenAI-Beta", "assistants=v2")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &vectorStoreFileStatus{ID: vectorFileID, Status: vectorFileCancelled}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Vector Store API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var file vectorStoreFileStatus
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &file, nil
+}
+
+// announceProcessed tells the uploader and the webhook that processing of a document finished.
+// Delivery failures are logged; they do not change the document.
+func (s *IngestionService) announceProcessed(ctx context.Context, document *models.UploadedDocument) {
+	if s.webhook != nil {
+		if err := s.webhook.Send(ctx, newDocumentEvent(document)); err != nil {
+			log.Printf("[WARNING] Failed to deliver document webhook for %s: %v", document.ID, err)
+		}
+	}
+
+	if s.notifier == nil || document.Uploader.Email == "" {
+		return
+	}
+	var subject string
+	var body strings.Builder
+	if document.Status == models.DocumentIndexed || document.Status == models.DocumentImported {
+		subject = "Your document is ready"
+		fmt.Fprintf(&body, "%s has been processed and can now be used to answer questions.\n", document.OriginalFileName)
+	} else {
+		subject = "Your document could not be processed"
+		fmt.Fprintf(&body, "%s could not be processed: %s\n\nYou can retry it from the document list.\n",
+			document.OriginalFileName, document.ErrorMessage)
+	}
+	if err := s.notifier.Notify(ctx, document.Uploader.Email, subject, body.String()); err != nil {
+		log.Printf("[WARNING] Failed to notify uploader of document %s: %v", document.ID, err)
+	}
+}
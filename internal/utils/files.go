@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+const maxFileNameLength = 200
+
+// SanitizeFileName reduces a client-provided file name to a safe base name. Directory parts are
+// dropped, spaces become underscores, and anything but letters, digits, dots, dashes, and
+// underscores is removed. It returns an empty string when nothing usable is left.
+func SanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '.', r == '-', r == '_':
+			return r
+		case unicode.IsSpace(r):
+			return '_'
+		}
+		return -1
+	}, name)
+	// No hidden files, "." or ".."
+	name = strings.TrimLeft(name, ".")
+
+	if len(name) > maxFileNameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:maxFileNameLength-len(ext)], "") + ext
+	}
+	return name
+}
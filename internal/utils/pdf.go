@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PDF page layout in points (A4)
+const (
+	pdfPageWidth    = 595.0
+	pdfPageHeight   = 842.0
+	pdfMargin       = 50.0
+	pdfLineSpacing  = 1.35
+	pdfAvgCharWidth = 0.5 // Average Helvetica glyph width relative to the font size
+)
+
+// PDFLine is a paragraph of text in a generated PDF
+type PDFLine struct {
+	Text string
+	Bold bool
+	Size float64 // Font size in points; defaults to 10
+}
+
+// RenderTextPDF renders paragraphs into a simple multi-page PDF using the standard
+// Helvetica fonts. These fonts only cover Latin-1, so accents are removed from other
+// characters and anything that still cannot be encoded is replaced with '?'.
+func RenderTextPDF(lines []PDFLine) []byte {
+	pages := layoutPDFPages(lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4: catalog, page tree, regular and bold fonts. Pages follow as (page, content) pairs.
+	pageIDs := make([]string, len(pages))
+	for i := range pages {
+		pageIDs[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIDs, " "), len(pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, content := range pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+// layoutPDFPages wraps the lines and returns the content stream of each page
+func layoutPDFPages(lines []PDFLine) []string {
+	var pages []string
+	var page strings.Builder
+	y := pdfPageHeight - pdfMargin
+
+	flushPage := func() {
+		pages = append(pages, page.String())
+		page.Reset()
+		y = pdfPageHeight - pdfMargin
+	}
+
+	for _, line := range lines {
+		size := line.Size
+		if size <= 0 {
+			size = 10
+		}
+		font := "F1"
+		if line.Bold {
+			font = "F2"
+		}
+		leading := size * pdfLineSpacing
+		maxChars := int((pdfPageWidth - 2*pdfMargin) / (size * pdfAvgCharWidth))
+
+		for _, wrapped := range wrapPDFText(line.Text, maxChars) {
+			if y-leading < pdfMargin {
+				flushPage()
+			}
+			y -= leading
+			fmt.Fprintf(&page, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, pdfMargin, y, encodePDFString(wrapped))
+		}
+	}
+
+	if page.Len() > 0 || len(pages) == 0 {
+		flushPage()
+	}
+	return pages
+}
+
+// wrapPDFText splits text into lines of at most maxChars characters, breaking at spaces
+func wrapPDFText(text string, maxChars int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		current := ""
+		for _, word := range words {
+			// Hard-break words longer than a line
+			for utf8.RuneCountInString(word) > maxChars {
+				if current != "" {
+					lines = append(lines, current)
+					current = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:maxChars]))
+				word = string(runes[maxChars:])
+			}
+
+			switch {
+			case current == "":
+				current = word
+			case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= maxChars:
+				current += " " + word
+			default:
+				lines = append(lines, current)
+				current = word
+			}
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+	}
+	return lines
+}
+
+// encodePDFString converts text to an escaped WinAnsi PDF string literal body
+func encodePDFString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r > 0xFF || (r >= 0x80 && r < 0xA0) {
+			r = []rune(RemoveDiacritics(string(r)))[0]
+		}
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20:
+			continue
+		case r < 0x80:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -110,6 +112,41 @@ func ParsePagination(c *fiber.Ctx) (page, limit int) {
 	return page, limit
 }
 
+// ParseDateRange parses the since and until query parameters of a list, as YYYY-MM-DD. Until includes its
+// whole day, so the range is [since, until).
+func ParseDateRange(c *fiber.Ctx) (since, until *time.Time, err error) {
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid since parameter, expected YYYY-MM-DD")
+		}
+		since = &parsed
+	}
+	if untilStr := c.Query("until"); untilStr != "" {
+		parsed, err := time.Parse("2006-01-02", untilStr)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid until parameter, expected YYYY-MM-DD")
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		until = &parsed
+	}
+	return since, until, nil
+}
+
+// ParseSort parses the sort and order query parameters of a list. Sort defaults to defaultSort and order
+// to desc; the caller checks that sort names a field it can sort by.
+func ParseSort(c *fiber.Ctx, defaultSort string) (sort string, desc bool, err error) {
+	sort = c.Query("sort", defaultSort)
+	switch strings.ToLower(c.Query("order", "desc")) {
+	case "desc":
+		desc = true
+	case "asc":
+	default:
+		return "", false, fiber.NewError(fiber.StatusBadRequest, "Invalid order parameter, expected asc or desc")
+	}
+	return sort, desc, nil
+}
+
 // BindAndValidate binds request body to struct and validates it
 func BindAndValidate(c *fiber.Ctx, obj interface{}) error {
 	if err := c.BodyParser(obj); err != nil {
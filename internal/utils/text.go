@@ -3,8 +3,12 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"html"
+	"regexp"
 	"strings"
 	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // GenerateRandomString generates a random string of specified length
@@ -34,6 +38,11 @@ func TruncateString(s string, length int) string {
 	return s[:length] + "..."
 }
 
+// LikePattern escapes a term for a LIKE substring match
+func LikePattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term) + "%"
+}
+
 // SliceContains checks if a slice contains a specific string
 func SliceContains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -73,3 +82,51 @@ func ExtractKeywords(text string, maxKeywords int) []string {
 
 	return result
 }
+
+// RemoveDiacritics strips accents from Latin letters, e.g. "Đơn hàng" becomes "Don hang"
+func RemoveDiacritics(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			b.WriteRune('d')
+		case r == 'Đ':
+			b.WriteRune('D')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(b.String())
+}
+
+// FoldText lowercases text and strips its accents so that it compares equal however it was typed
+func FoldText(s string) string {
+	return strings.ToLower(RemoveDiacritics(s))
+}
+
+var (
+	htmlInvisibleBlocks = regexp.MustCompile(`(?is)<(script|style|noscript|head|svg)[^>]*>.*?</(script|style|noscript|head|svg)>`)
+	htmlBlockBreaks     = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6]|/section|/article)[^>]*>`)
+	htmlTags            = regexp.MustCompile(`(?s)<[^>]+>`)
+	horizontalSpace     = regexp.MustCompile(`[ \t\f\v]+`)
+	blankLines          = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// StripHTML converts an HTML page into plain text, keeping paragraph breaks
+func StripHTML(s string) string {
+	s = htmlInvisibleBlocks.ReplaceAllString(s, " ")
+	s = htmlBlockBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	s = horizontalSpace.ReplaceAllString(s, " ")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,5431 @@
+// Code generated by cmd/openapi from the API description. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// ApproveQuarantine calls POST /api/v1/admin/quarantine/{id}/approve: approve a quarantined document.
+//
+// Use the document as context: an upload is sent on to the vector store and the entries of an import are published
+func (c *Client) ApproveQuarantine(ctx context.Context, id string, body *ReviewQuarantineRequest) (*DocumentQuarantine, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/quarantine/" + url.PathEscape(id) + "/approve"}
+	req.body = body
+	var data *DocumentQuarantine
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AssignCategory calls PUT /api/v1/retrieval-presets/categories/{category}: assign a retrieval preset to a category.
+//
+// The category's entries are re-embedded with the preset's chunk settings. An empty preset_id restores the defaults.
+func (c *Client) AssignCategory(ctx context.Context, category string, body *AssignRetrievalPresetRequest) (*AssignCategoryResult, error) {
+	req := &request{method: "PUT", path: "/api/v1/retrieval-presets/categories/" + url.PathEscape(category)}
+	req.body = body
+	var data *AssignCategoryResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AssignTemplate calls PUT /api/v1/retrieval-presets/templates/{id}: assign a retrieval preset to a template.
+//
+// Overrides the category's preset for entries created from the template, which are re-embedded.
+// An empty preset_id falls back to the category's preset.
+func (c *Client) AssignTemplate(ctx context.Context, id string, body *AssignRetrievalPresetRequest) (*AssignTemplateResult, error) {
+	req := &request{method: "PUT", path: "/api/v1/retrieval-presets/templates/" + url.PathEscape(id)}
+	req.body = body
+	var data *AssignTemplateResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Bootstrap calls POST /api/v1/bootstrap: bootstrap the knowledge base.
+//
+// Ingest seed documents and URLs, generate categories and topics, propose templates, and return a readiness report.
+// Send multipart/form-data with "files" (.docx, .txt, .md, .html), "urls" (newline or comma separated), "user_id" and "activate_templates",
+// or JSON with urls and inline documents.
+func (c *Client) Bootstrap(ctx context.Context, body *BootstrapRequestBody) (*BootstrapResult, error) {
+	req := &request{method: "POST", path: "/api/v1/bootstrap"}
+	req.body = body
+	var data *BootstrapResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Callback calls GET /api/v1/auth/sso/callback: complete SSO login.
+//
+// Exchanges the authorization code, provisions unknown users with the role mapped from their IdP groups,
+// and sets the session cookie. The session token is also accepted as a bearer token.
+func (c *Client) Callback(ctx context.Context, code string, state string) (*SSOLogin, error) {
+	req := &request{method: "GET", path: "/api/v1/auth/sso/callback"}
+	req.setQuery("code", code)
+	req.setQuery("state", state)
+	var data *SSOLogin
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ChatWithAssistant calls POST /api/v1/assistant/chat: chat with OpenAI Assistant.
+//
+// Adds the message to the thread and runs the assistant, returning as soon as the run finishes. A run still going
+// after timeout_seconds is returned with workflow_completed false; poll /assistant/runs/{run_id}/wait for its answer.
+func (c *Client) ChatWithAssistant(ctx context.Context, body *ChatAssistantRequest) (*ChatAssistantResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/assistant/chat"}
+	req.body = body
+	var data *ChatAssistantResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ChatWithCustomWorkflow calls POST /api/v1/assistant/chat/custom: chat with custom workflow.
+//
+// Chat with OpenAI Assistant using custom wait time and options
+func (c *Client) ChatWithCustomWorkflow(ctx context.Context, body *ChatWithCustomWorkflowRequest) (*ChatAssistantResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/assistant/chat/custom"}
+	req.body = body
+	var data *ChatAssistantResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ClaimEscalation calls POST /api/v1/support/escalations/{id}/claim: claim an escalation.
+//
+// The assistant stops answering the session and the agent answers the user instead. Only one agent can claim an escalation.
+func (c *Client) ClaimEscalation(ctx context.Context, id string, body *HandoffAgentRequest) (*ChatEscalation, error) {
+	req := &request{method: "POST", path: "/api/v1/support/escalations/" + url.PathEscape(id) + "/claim"}
+	req.body = body
+	var data *ChatEscalation
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CollectGarbage calls POST /api/v1/maintenance/openai-gc: collect orphaned OpenAI resources.
+//
+// Find uploaded files, vector-store files, and idle assistant threads that no database record refers to.
+// A dry run (the default) returns the report immediately; dry_run=false queues a job that deletes them.
+func (c *Client) CollectGarbage(ctx context.Context, params *CollectGarbageParams) (*CollectGarbageResult, error) {
+	req := &request{method: "POST", path: "/api/v1/maintenance/openai-gc"}
+	if params != nil {
+		if params.DryRun != nil {
+			req.setQuery("dry_run", strconv.FormatBool(*params.DryRun))
+		}
+	}
+	status, env, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &CollectGarbageResult{}
+	switch status {
+	case 200:
+		err = env.decode(&result.OK)
+	case 202:
+		err = env.decode(&result.Accepted)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CollectGarbageParams are the optional parameters of CollectGarbage
+type CollectGarbageParams struct {
+	// Only report orphans. Defaults to true.
+	DryRun *bool
+}
+
+// CompareProviders calls POST /api/v1/ai/compare: compare AI provider responses.
+//
+// Send the same message to multiple AI providers for comparison
+func (c *Client) CompareProviders(ctx context.Context, body *CompareProvidersRequest) (*CompareProvidersResult, error) {
+	req := &request{method: "POST", path: "/api/v1/ai/compare"}
+	req.body = body
+	var data *CompareProvidersResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ConfirmDocument calls POST /api/v1/documents/process/confirm: confirm a document preview.
+//
+// Save the entries of a preview returned by POST /documents/process with dry_run, as edited by the caller, to the knowledge base.
+// Entries may be merged, split, retitled, recategorized or retagged; the file must not have changed since the preview.
+// Documents with instruction-like content are quarantined for admin review instead.
+func (c *Client) ConfirmDocument(ctx context.Context, body *ConfirmDocumentRequest) (*ProcessDocumentResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/documents/process/confirm"}
+	req.body = body
+	var data *ProcessDocumentResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ConfirmEntry calls POST /api/v1/knowledge/{id}/confirm-review: confirm a knowledge entry is current.
+//
+// Clears the review flag and restarts the entry's age without changing its content
+func (c *Client) ConfirmEntry(ctx context.Context, id string, body *ConfirmReviewRequest) (*KnowledgeEntry, error) {
+	req := &request{method: "POST", path: "/api/v1/knowledge/" + url.PathEscape(id) + "/confirm-review"}
+	req.body = body
+	var data *KnowledgeEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CountUploads calls GET /api/v1/upload/count: count uploaded files.
+func (c *Client) CountUploads(ctx context.Context) (*CountUploadsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/upload/count"}
+	var data *CountUploadsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateAnnouncement calls POST /api/v1/announcements: create an announcement.
+//
+// Between starts_at and ends_at the announcement is added to the system prompt of every chat answer,
+// highest priority first (at most 5 at a time). Only support agents and admins can manage announcements.
+func (c *Client) CreateAnnouncement(ctx context.Context, body *AnnouncementRequest) (*Announcement, error) {
+	req := &request{method: "POST", path: "/api/v1/announcements"}
+	req.body = body
+	var data *Announcement
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateAssistant calls POST /api/v1/admin/assistants: create a managed assistant.
+//
+// Creates an OpenAI assistant with file search over the knowledge vector store (or the given vector stores)
+// and instructions rendered from the active version of a prompt template (support_assistant by default).
+func (c *Client) CreateAssistant(ctx context.Context, body *AssistantRequest) (*ManagedAssistant, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/assistants"}
+	req.body = body
+	var data *ManagedAssistant
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateBookmark calls POST /api/v1/me/bookmarks: bookmark an answer or entry.
+//
+// Save a message of one of the user's chat sessions or a knowledge entry. Saving it again updates the note.
+func (c *Client) CreateBookmark(ctx context.Context, body *BookmarkRequest) (*Bookmark, error) {
+	req := &request{method: "POST", path: "/api/v1/me/bookmarks"}
+	req.body = body
+	var data *Bookmark
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateKnowledgeEntry calls POST /api/v1/knowledge: create knowledge entry.
+//
+// Create a new knowledge entry. With publish_at in the future the entry stays unpublished until then;
+// with unpublish_at it is unpublished at that time, e.g. for a holiday workflow.
+func (c *Client) CreateKnowledgeEntry(ctx context.Context, body *KnowledgeEntry) (*KnowledgeEntry, error) {
+	req := &request{method: "POST", path: "/api/v1/knowledge"}
+	req.body = body
+	var data *KnowledgeEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreatePair calls POST /api/v1/retrieval-eval/pairs: create a labeled retrieval pair.
+//
+// Label a question with the knowledge entry retrieval should return for it
+func (c *Client) CreatePair(ctx context.Context, body *CreateEvalPairRequest) (*RetrievalEvalPair, error) {
+	req := &request{method: "POST", path: "/api/v1/retrieval-eval/pairs"}
+	req.body = body
+	var data *RetrievalEvalPair
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreatePersona calls POST /api/v1/admin/personas: create a persona preset.
+//
+// The system prompt is added to the support prompt of every answer in the sessions using the preset
+func (c *Client) CreatePersona(ctx context.Context, body *PersonaRequest) (*PersonaPreset, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/personas"}
+	req.body = body
+	var data *PersonaPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreatePolicy calls POST /api/v1/admin/retention/policies: create a chat retention policy.
+//
+// Archive (soft delete) or permanently delete chat sessions without a message in the last max_age_months,
+// for every user or the members of a team. Policies for every user that delete also remove tracked chat logs
+// and idle OpenAI assistant threads. Active policies are applied on a schedule.
+func (c *Client) CreatePolicy(ctx context.Context, body *CreateRetentionPolicyRequest) (*ChatRetentionPolicy, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/retention/policies"}
+	req.body = body
+	var data *ChatRetentionPolicy
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreatePreset calls POST /api/v1/retrieval-presets: create a retrieval preset.
+//
+// A preset sets the chunk size and overlap used when embedding entries (0 uses the configured defaults),
+// how many entries of a category one search returns (top_k, 0 for no cap), and the lowest vector score kept.
+func (c *Client) CreatePreset(ctx context.Context, body *CreateRetrievalPresetRequest) (*RetrievalPreset, error) {
+	req := &request{method: "POST", path: "/api/v1/retrieval-presets"}
+	req.body = body
+	var data *RetrievalPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreatePrompt calls POST /api/v1/prompts: create a prompt template version.
+//
+// Store a prompt as the next version of its name. Content may use {{variable}} placeholders declared in variables with default values, plus the built-in {{date}} and {{provider}}. Activating the version deactivates the others.
+func (c *Client) CreatePrompt(ctx context.Context, body *CreatePromptRequest) (*PromptTemplate, error) {
+	req := &request{method: "POST", path: "/api/v1/prompts"}
+	req.body = body
+	var data *PromptTemplate
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateQuestion calls POST /api/v1/experiments/questions: create a golden question.
+//
+// Runs answer it from then on; it is compared once a golden run has answered it
+func (c *Client) CreateQuestion(ctx context.Context, body *CreateGoldenQuestionRequest) (*GoldenQuestion, error) {
+	req := &request{method: "POST", path: "/api/v1/experiments/questions"}
+	req.body = body
+	var data *GoldenQuestion
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateSchedule calls POST /api/v1/schedules: create a scheduled job.
+//
+// Run a task on a standard five-field cron expression (minute hour day-of-month month day-of-week) in UTC,
+// or in another zone with a CRON_TZ= prefix. Descriptors such as @daily and @every 6h are accepted too.
+func (c *Client) CreateSchedule(ctx context.Context, body *ScheduleRequest) (*ScheduledJob, error) {
+	req := &request{method: "POST", path: "/api/v1/schedules"}
+	req.body = body
+	var data *ScheduledJob
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateTemplate calls POST /api/v1/templates: create template.
+//
+// Create a new template
+func (c *Client) CreateTemplate(ctx context.Context, body *Template) (*Template, error) {
+	req := &request{method: "POST", path: "/api/v1/templates"}
+	req.body = body
+	var data *Template
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateThread calls POST /api/v1/assistant/threads: create new thread.
+//
+// Create a new OpenAI Assistant thread
+func (c *Client) CreateThread(ctx context.Context) (*CreateThreadResult, error) {
+	req := &request{method: "POST", path: "/api/v1/assistant/threads"}
+	var data *CreateThreadResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteAnnouncement calls DELETE /api/v1/announcements/{id}: delete an announcement.
+func (c *Client) DeleteAnnouncement(ctx context.Context, id string, managerID string) error {
+	req := &request{method: "DELETE", path: "/api/v1/announcements/" + url.PathEscape(id)}
+	req.setQuery("manager_id", managerID)
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteBookmark calls DELETE /api/v1/me/bookmarks/{id}: delete a bookmark.
+func (c *Client) DeleteBookmark(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/me/bookmarks/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteChatSession calls DELETE /api/v1/chat/sessions/{id}: delete chat session.
+//
+// Delete a chat session with its messages. The session can be restored until undo_until;
+// after that it stays in the admin trash until purged.
+func (c *Client) DeleteChatSession(ctx context.Context, id string) (*DeletedChatSession, error) {
+	req := &request{method: "DELETE", path: "/api/v1/chat/sessions/" + url.PathEscape(id)}
+	var data *DeletedChatSession
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteDocument calls DELETE /api/v1/documents/{id}: delete an uploaded document.
+//
+// Remove the document from the OpenAI vector store, delete its OpenAI file and stored file, then delete the record
+func (c *Client) DeleteDocument(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/documents/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteKnowledgeEntry calls DELETE /api/v1/knowledge/{id}: delete knowledge entry.
+//
+// Delete a knowledge entry
+func (c *Client) DeleteKnowledgeEntry(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/knowledge/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteOrgQuota calls DELETE /api/v1/quotas/org: remove the organization quota override.
+func (c *Client) DeleteOrgQuota(ctx context.Context, body *QuotaOverrideDeleteRequest) error {
+	req := &request{method: "DELETE", path: "/api/v1/quotas/org"}
+	req.body = body
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeletePair calls DELETE /api/v1/retrieval-eval/pairs/{id}: delete a labeled retrieval pair.
+func (c *Client) DeletePair(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/retrieval-eval/pairs/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeletePolicy calls DELETE /api/v1/admin/retention/policies/{id}: delete a chat retention policy.
+func (c *Client) DeletePolicy(ctx context.Context, id string, body *RetentionAdminRequest) error {
+	req := &request{method: "DELETE", path: "/api/v1/admin/retention/policies/" + url.PathEscape(id)}
+	req.body = body
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeletePreset calls DELETE /api/v1/retrieval-presets/{id}: delete a retrieval preset.
+//
+// Categories and templates using the preset go back to the defaults and their entries are re-embedded
+func (c *Client) DeletePreset(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/retrieval-presets/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeletePrompt calls DELETE /api/v1/prompts/{id}: delete a prompt template version.
+//
+// Providers fall back to their built-in prompt when a name has no active version left
+func (c *Client) DeletePrompt(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/prompts/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteQuestion calls DELETE /api/v1/experiments/questions/{id}: delete a golden question.
+func (c *Client) DeleteQuestion(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/experiments/questions/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteSchedule calls DELETE /api/v1/schedules/{id}: delete a scheduled job.
+func (c *Client) DeleteSchedule(ctx context.Context, id string, body *ScheduleAdminRequest) error {
+	req := &request{method: "DELETE", path: "/api/v1/schedules/" + url.PathEscape(id)}
+	req.body = body
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteScreen calls DELETE /api/v1/help/screens/{screen}: delete the help of a screen.
+func (c *Client) DeleteScreen(ctx context.Context, screen string) error {
+	req := &request{method: "DELETE", path: "/api/v1/help/screens/" + url.PathEscape(screen)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteTemplate calls DELETE /api/v1/templates/{id}: delete template.
+//
+// Delete a template
+func (c *Client) DeleteTemplate(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/templates/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// DeleteUserQuota calls DELETE /api/v1/quotas/users/{id}: remove a user's quota override.
+//
+// Restore the configured default monthly limits of a user. Only admins may override quotas.
+func (c *Client) DeleteUserQuota(ctx context.Context, id string, body *QuotaOverrideDeleteRequest) error {
+	req := &request{method: "DELETE", path: "/api/v1/quotas/users/" + url.PathEscape(id)}
+	req.body = body
+	return c.call(ctx, req, nil, nil)
+}
+
+// EditChatMessage calls PATCH /api/v1/chat/messages/{id}: edit chat question.
+//
+// Replace the last question of a session and answer it again. The previous question and answer are kept
+// as revisions, listed by GET /chat/messages/{id}/versions.
+func (c *Client) EditChatMessage(ctx context.Context, id string, body *EditMessageRequest) (*EnhancedChatResponse, error) {
+	req := &request{method: "PATCH", path: "/api/v1/chat/messages/" + url.PathEscape(id)}
+	req.body = body
+	var data *EnhancedChatResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// EscalateChatSession calls POST /api/v1/chat/sessions/{id}/escalate: escalate chat session.
+//
+// Ask for a support agent to take over the session, typically after an answer with suggest_escalation.
+// Until the agent hands the session back, chat messages are answered by the agent, whose messages have role "support".
+func (c *Client) EscalateChatSession(ctx context.Context, id string, body *EscalateChatRequest) (*ChatEscalation, error) {
+	req := &request{method: "POST", path: "/api/v1/chat/sessions/" + url.PathEscape(id) + "/escalate"}
+	req.body = body
+	var data *ChatEscalation
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ExportChatSession calls GET /api/v1/chat/sessions/{id}/export: export chat session.
+//
+// Download the full conversation with timestamps, sources, and feedback
+func (c *Client) ExportChatSession(ctx context.Context, id string, params *ExportChatSessionParams) ([]byte, error) {
+	req := &request{method: "GET", path: "/api/v1/chat/sessions/" + url.PathEscape(id) + "/export"}
+	if params != nil {
+		if params.Format != "" {
+			req.setQuery("format", params.Format)
+		}
+	}
+	return c.raw(ctx, req)
+}
+
+// ExportChatSessionParams are the optional parameters of ExportChatSession
+type ExportChatSessionParams struct {
+	// Export format: json, markdown, or pdf. Defaults to json.
+	Format string
+}
+
+// ExportConfig calls GET /api/v1/admin/config/export: export the configuration.
+//
+// Retrieval presets, category presets, templates, active prompts, chat retention policies, the organization
+// quota and help screen texts, identified by name so the bundle can be imported into another environment.
+func (c *Client) ExportConfig(ctx context.Context) (*ConfigBundle, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/config/export"}
+	var data *ConfigBundle
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAdminStatus calls GET /api/v1/admin/status: system status.
+//
+// Database, Qdrant and AI provider reachability, Qdrant collection stats, job queue totals,
+// the document processing backlog and the last sync of each connector in one report.
+// Sections that could not be loaded carry an error instead of failing the request.
+func (c *Client) GetAdminStatus(ctx context.Context) (*SystemStatus, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/status"}
+	var data *SystemStatus
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAssistant calls GET /api/v1/admin/assistants/{id}: get a managed assistant.
+func (c *Client) GetAssistant(ctx context.Context, id string) (*ManagedAssistant, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/assistants/" + url.PathEscape(id)}
+	var data *ManagedAssistant
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAttachment calls GET /api/v1/ai/attachments/{id}: download a chat image.
+func (c *Client) GetAttachment(ctx context.Context, id string, userID string) ([]byte, error) {
+	req := &request{method: "GET", path: "/api/v1/ai/attachments/" + url.PathEscape(id)}
+	req.setQuery("user_id", userID)
+	return c.raw(ctx, req)
+}
+
+// GetAvailableProviders calls GET /api/v1/ai/providers: get available AI providers.
+//
+// Get the list of AI providers that are currently available
+func (c *Client) GetAvailableProviders(ctx context.Context) (*GetAvailableProvidersResult, error) {
+	req := &request{method: "GET", path: "/api/v1/ai/providers"}
+	var data *GetAvailableProvidersResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetChatMessageVersions calls GET /api/v1/chat/messages/{id}/versions: list chat message versions.
+//
+// Superseded versions of an edited question or regenerated answer, oldest first
+func (c *Client) GetChatMessageVersions(ctx context.Context, id string) ([]ChatMessageRevision, error) {
+	req := &request{method: "GET", path: "/api/v1/chat/messages/" + url.PathEscape(id) + "/versions"}
+	var data []ChatMessageRevision
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetChatSession calls GET /api/v1/chat/sessions/{id}: get chat session.
+//
+// Get a specific chat session with messages
+func (c *Client) GetChatSession(ctx context.Context, id string) (*ChatSession, error) {
+	req := &request{method: "GET", path: "/api/v1/chat/sessions/" + url.PathEscape(id)}
+	var data *ChatSession
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetChatSessionUsage calls GET /api/v1/chat/sessions/{id}/usage: get chat session usage.
+//
+// Summarize tokens, estimated cost, providers used, and retrieval counts for a chat session
+func (c *Client) GetChatSessionUsage(ctx context.Context, id string) (*SessionUsage, error) {
+	req := &request{method: "GET", path: "/api/v1/chat/sessions/" + url.PathEscape(id) + "/usage"}
+	var data *SessionUsage
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetChatSessions calls GET /api/v1/chat/sessions: get chat sessions.
+//
+// Get all chat sessions for the current user
+func (c *Client) GetChatSessions(ctx context.Context) ([]ChatSession, error) {
+	req := &request{method: "GET", path: "/api/v1/chat/sessions"}
+	var data []ChatSession
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetConfluenceStatus calls GET /api/v1/integrations/confluence: get Confluence sync status.
+//
+// The configured space and schedule, how many of its pages are synced into knowledge entries, and how many failed on their last sync
+func (c *Client) GetConfluenceStatus(ctx context.Context) (*ConfluenceStatus, error) {
+	req := &request{method: "GET", path: "/api/v1/integrations/confluence"}
+	var data *ConfluenceStatus
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetContext calls GET /api/v1/help/context: get contextual help for a screen.
+//
+// Curated knowledge entries and AI-generated quick tips for a screen ID such as orders.pending. Screens without their own help fall back to their closest parent (orders).
+func (c *Client) GetContext(ctx context.Context, screen string, params *GetContextParams) (*HelpContext, error) {
+	req := &request{method: "GET", path: "/api/v1/help/context"}
+	req.setQuery("screen", screen)
+	if params != nil {
+		if params.UserID != "" {
+			req.setQuery("user_id", params.UserID)
+		}
+	}
+	var data *HelpContext
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetContextParams are the optional parameters of GetContext
+type GetContextParams struct {
+	// Only return entries this user may see
+	UserID string
+}
+
+// GetContextDashboard calls GET /api/v1/context-dashboard: context dashboard.
+//
+// Totals, topic trends, question distribution by time and the context files
+func (c *Client) GetContextDashboard(ctx context.Context) (map[string]interface{}, error) {
+	req := &request{method: "GET", path: "/api/v1/context-dashboard"}
+	var data map[string]interface{}
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetContributor calls GET /api/v1/analytics/contributors/{id}: get contributor statistics.
+//
+// Get entries published, views generated, helpful-feedback rate, and badges for an author
+func (c *Client) GetContributor(ctx context.Context, id string) (*ContributorStats, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/contributors/" + url.PathEscape(id)}
+	var data *ContributorStats
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetCurrentUser calls GET /api/v1/users/me: get current user.
+//
+// Get current user information
+func (c *Client) GetCurrentUser(ctx context.Context) (*User, error) {
+	req := &request{method: "GET", path: "/api/v1/users/me"}
+	var data *User
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetDashboard calls GET /api/v1/admin/jobs/dashboard: job queue health dashboard.
+//
+// Per-queue depth, age of the oldest due job, failure rate over the window, paused queues, and worker heartbeats
+func (c *Client) GetDashboard(ctx context.Context, params *GetDashboardParams) (*JobDashboard, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/jobs/dashboard"}
+	if params != nil {
+		if params.WindowHours != 0 {
+			req.setQuery("window_hours", strconv.Itoa(params.WindowHours))
+		}
+	}
+	var data *JobDashboard
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetDashboardParams are the optional parameters of GetDashboard
+type GetDashboardParams struct {
+	// Window for failure rates in hours. Defaults to 24.
+	WindowHours int
+}
+
+// GetDocumentStatus calls GET /api/v1/documents/{id}/status: get document upload status.
+//
+// Get the status of a document upload including OpenAI and vector store processing.
+// With object storage the response includes a presigned download URL.
+func (c *Client) GetDocumentStatus(ctx context.Context, id string) (*DocumentStatusResponse, error) {
+	req := &request{method: "GET", path: "/api/v1/documents/" + url.PathEscape(id) + "/status"}
+	var data *DocumentStatusResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetEscalation calls GET /api/v1/support/escalations/{id}: get an escalation.
+//
+// The escalation with every message of its session, including the assistant's answers before the handoff
+func (c *Client) GetEscalation(ctx context.Context, id string, agentID string) (*ChatEscalation, error) {
+	req := &request{method: "GET", path: "/api/v1/support/escalations/" + url.PathEscape(id)}
+	req.setQuery("agent_id", agentID)
+	var data *ChatEscalation
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetExperimentRun calls GET /api/v1/experiments/runs/{id}: get an experiment run.
+//
+// The run with every answer next to its baseline answer, regressions first
+func (c *Client) GetExperimentRun(ctx context.Context, id string) (*ExperimentRun, error) {
+	req := &request{method: "GET", path: "/api/v1/experiments/runs/" + url.PathEscape(id)}
+	var data *ExperimentRun
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetFeedback calls GET /api/v1/feedback: get feedback.
+//
+// Get feedback with optional filtering
+func (c *Client) GetFeedback(ctx context.Context, params *GetFeedbackParams) ([]Feedback, error) {
+	req := &request{method: "GET", path: "/api/v1/feedback"}
+	if params != nil {
+		if params.MessageID != "" {
+			req.setQuery("message_id", params.MessageID)
+		}
+		if params.UserID != "" {
+			req.setQuery("user_id", params.UserID)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data []Feedback
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetFeedbackParams are the optional parameters of GetFeedback
+type GetFeedbackParams struct {
+	// Filter by message ID
+	MessageID string
+	// Filter by user ID
+	UserID string
+	// Limit number of results. Defaults to 20.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// GetGoogleDriveStatus calls GET /api/v1/integrations/google-drive: get Google Drive sync status.
+//
+// The configured folder and schedule, how many of its files are ingested, and how many failed on their last sync
+func (c *Client) GetGoogleDriveStatus(ctx context.Context) (*DriveStatus, error) {
+	req := &request{method: "GET", path: "/api/v1/integrations/google-drive"}
+	var data *DriveStatus
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetJob calls GET /api/v1/jobs/{id}: get background job.
+//
+// Get the status, attempts, and last error of a background job
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	req := &request{method: "GET", path: "/api/v1/jobs/" + url.PathEscape(id)}
+	var data *Job
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetKnowledgeEntries calls GET /api/v1/knowledge: get knowledge entries.
+//
+// Get knowledge entries with optional filtering and pagination. Only the entries the signed-in user may see
+// are listed, and only editors and admins see unpublished entries.
+func (c *Client) GetKnowledgeEntries(ctx context.Context, params *GetKnowledgeEntriesParams) ([]KnowledgeEntry, error) {
+	req := &request{method: "GET", path: "/api/v1/knowledge"}
+	if params != nil {
+		if params.Category != "" {
+			req.setQuery("category", params.Category)
+		}
+		if params.Published != nil {
+			req.setQuery("published", strconv.FormatBool(*params.Published))
+		}
+		if params.MaxReadingMinutes != 0 {
+			req.setQuery("max_reading_minutes", strconv.FormatFloat(params.MaxReadingMinutes, 'f', -1, 64))
+		}
+		if params.Complexity != "" {
+			req.setQuery("complexity", params.Complexity)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data []KnowledgeEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetKnowledgeEntriesParams are the optional parameters of GetKnowledgeEntries
+type GetKnowledgeEntriesParams struct {
+	// Filter by category
+	Category string
+	// Filter by published status
+	Published *bool
+	// Only entries that take at most this many minutes to read
+	MaxReadingMinutes float64
+	// Filter by complexity (easy, moderate, advanced)
+	Complexity string
+	// Limit number of results. Defaults to 20.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// GetKnowledgeEntry calls GET /api/v1/knowledge/{id}: get knowledge entry.
+//
+// Get a knowledge entry by ID. The view is recorded for the entry analytics, for the signed-in user, with where the entry was opened from.
+// Entries the signed-in user may not see, and unpublished entries for users other than editors and admins, are not found.
+func (c *Client) GetKnowledgeEntry(ctx context.Context, id string, params *GetKnowledgeEntryParams) (*KnowledgeEntry, error) {
+	req := &request{method: "GET", path: "/api/v1/knowledge/" + url.PathEscape(id)}
+	if params != nil {
+		if params.Source != "" {
+			req.setQuery("source", params.Source)
+		}
+	}
+	var data *KnowledgeEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetKnowledgeEntryParams are the optional parameters of GetKnowledgeEntry
+type GetKnowledgeEntryParams struct {
+	// Where the entry was opened from: search, chat (a citation of an answer) or direct. Defaults to direct.
+	Source string
+}
+
+// GetLeaderboard calls GET /api/v1/analytics/leaderboard: get contribution leaderboard.
+//
+// Rank authors by entries published, views generated, or helpful-feedback rate
+func (c *Client) GetLeaderboard(ctx context.Context, params *GetLeaderboardParams) (*GetLeaderboardResult, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/leaderboard"}
+	if params != nil {
+		if params.Sort != "" {
+			req.setQuery("sort", params.Sort)
+		}
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Badges != nil {
+			req.setQuery("badges", strconv.FormatBool(*params.Badges))
+		}
+	}
+	var data *GetLeaderboardResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetLeaderboardParams are the optional parameters of GetLeaderboard
+type GetLeaderboardParams struct {
+	// Sort by entries, views, or helpful_rate. Defaults to entries.
+	Sort string
+	// Only count contributions since this date (YYYY-MM-DD)
+	Since string
+	// Limit number of results. Defaults to 10.
+	Limit int
+	// Include badges. Defaults to true.
+	Badges *bool
+}
+
+// GetNotionStatus calls GET /api/v1/integrations/notion: get Notion sync status.
+//
+// The configured databases and schedule, how many pages are synced into knowledge entries, and how many failed on their last sync
+func (c *Client) GetNotionStatus(ctx context.Context) (*NotionStatus, error) {
+	req := &request{method: "GET", path: "/api/v1/integrations/notion"}
+	var data *NotionStatus
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetPreset calls GET /api/v1/retrieval-presets/{id}: get a retrieval preset.
+func (c *Client) GetPreset(ctx context.Context, id string) (*RetrievalPreset, error) {
+	req := &request{method: "GET", path: "/api/v1/retrieval-presets/" + url.PathEscape(id)}
+	var data *RetrievalPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetPrompt calls GET /api/v1/prompts/{id}: get a prompt template version.
+func (c *Client) GetPrompt(ctx context.Context, id string) (*PromptTemplate, error) {
+	req := &request{method: "GET", path: "/api/v1/prompts/" + url.PathEscape(id)}
+	var data *PromptTemplate
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetProviderHealth calls GET /api/v1/ai/providers/health: get AI provider health.
+//
+// Get the circuit breaker state, consecutive failures, and last latency of each available AI provider
+func (c *Client) GetProviderHealth(ctx context.Context) (*GetProviderHealthResult, error) {
+	req := &request{method: "GET", path: "/api/v1/ai/providers/health"}
+	var data *GetProviderHealthResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetQueuedQuestion calls GET /api/v1/ai/queued-questions/{id}: get queued question.
+//
+// Get the status of a question that was queued for a later answer in degraded mode
+func (c *Client) GetQueuedQuestion(ctx context.Context, id string) (*QueuedQuestion, error) {
+	req := &request{method: "GET", path: "/api/v1/ai/queued-questions/" + url.PathEscape(id)}
+	var data *QueuedQuestion
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetReadiness calls GET /api/v1/bootstrap/readiness: get knowledge base readiness.
+//
+// Check indexed content, embedding coverage, categories, templates, and AI providers
+func (c *Client) GetReadiness(ctx context.Context) (*ReadinessReport, error) {
+	req := &request{method: "GET", path: "/api/v1/bootstrap/readiness"}
+	var data *ReadinessReport
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetRelatedEntries calls GET /api/v1/knowledge/{id}/related: get related knowledge entries.
+//
+// Get the published entries most similar to a knowledge entry by the vector similarity of their embeddings
+func (c *Client) GetRelatedEntries(ctx context.Context, id string, params *GetRelatedEntriesParams) ([]RelatedEntry, error) {
+	req := &request{method: "GET", path: "/api/v1/knowledge/" + url.PathEscape(id) + "/related"}
+	if params != nil {
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data []RelatedEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetRelatedEntriesParams are the optional parameters of GetRelatedEntries
+type GetRelatedEntriesParams struct {
+	// Maximum number of entries. Defaults to 5.
+	Limit int
+}
+
+// GetRetrievalEvalRun calls GET /api/v1/retrieval-eval/runs/{id}: get a retrieval evaluation run.
+func (c *Client) GetRetrievalEvalRun(ctx context.Context, id string) (*RetrievalEvalRun, error) {
+	req := &request{method: "GET", path: "/api/v1/retrieval-eval/runs/" + url.PathEscape(id)}
+	var data *RetrievalEvalRun
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetSettings calls GET /api/v1/admin/settings: list runtime settings.
+//
+// Primary provider, models, temperature, max tokens and chunk sizes with the value in effect and its environment default
+func (c *Client) GetSettings(ctx context.Context) ([]RuntimeSetting, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/settings"}
+	var data []RuntimeSetting
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetTemplate calls GET /api/v1/templates/{id}: get template.
+//
+// Get a template by ID
+func (c *Client) GetTemplate(ctx context.Context, id string) (*Template, error) {
+	req := &request{method: "GET", path: "/api/v1/templates/" + url.PathEscape(id)}
+	var data *Template
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetTemplates calls GET /api/v1/templates: get templates.
+//
+// Get all templates with optional filtering
+func (c *Client) GetTemplates(ctx context.Context, params *GetTemplatesParams) ([]Template, error) {
+	req := &request{method: "GET", path: "/api/v1/templates"}
+	if params != nil {
+		if params.Category != "" {
+			req.setQuery("category", params.Category)
+		}
+		if params.Active != nil {
+			req.setQuery("active", strconv.FormatBool(*params.Active))
+		}
+	}
+	var data []Template
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetTemplatesParams are the optional parameters of GetTemplates
+type GetTemplatesParams struct {
+	// Filter by category
+	Category string
+	// Filter by active status
+	Active *bool
+}
+
+// GetThreadMessages calls GET /api/v1/assistant/threads/{thread_id}/messages: get thread messages.
+//
+// Retrieve a page of the messages of a thread, newest first by default. Pass next_cursor as after to get
+// the next page and prev_cursor as before to get the previous one. The role and run_id filters apply to
+// the fetched page, so a page may hold fewer messages than the limit while has_more is true.
+func (c *Client) GetThreadMessages(ctx context.Context, threadID string, params *GetThreadMessagesParams) (*GetThreadMessagesResult, error) {
+	req := &request{method: "GET", path: "/api/v1/assistant/threads/" + url.PathEscape(threadID) + "/messages"}
+	if params != nil {
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Order != "" {
+			req.setQuery("order", params.Order)
+		}
+		if params.After != "" {
+			req.setQuery("after", params.After)
+		}
+		if params.Before != "" {
+			req.setQuery("before", params.Before)
+		}
+		if params.Role != "" {
+			req.setQuery("role", params.Role)
+		}
+		if params.RunID != "" {
+			req.setQuery("run_id", params.RunID)
+		}
+	}
+	var data *GetThreadMessagesResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetThreadMessagesParams are the optional parameters of GetThreadMessages
+type GetThreadMessagesParams struct {
+	// Messages per page, 1 to 100. Defaults to 50.
+	Limit int
+	// asc or desc. Defaults to desc.
+	Order string
+	// List the messages after this message ID
+	After string
+	// List the messages before this message ID
+	Before string
+	// Only messages of this role (user or assistant)
+	Role string
+	// Only messages created by this run
+	RunID string
+}
+
+// GetTopEntries calls GET /api/v1/analytics/entries/top: get most viewed entries.
+//
+// Rank knowledge entries by views, with the views from search, chat citations and direct links
+func (c *Client) GetTopEntries(ctx context.Context, params *GetTopEntriesParams) ([]EntryViewStats, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/entries/top"}
+	if params != nil {
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Source != "" {
+			req.setQuery("source", params.Source)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data []EntryViewStats
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetTopEntriesParams are the optional parameters of GetTopEntries
+type GetTopEntriesParams struct {
+	// Only count views since this date (YYYY-MM-DD). Defaults to 30 days ago.
+	Since string
+	// Only count views from search, chat or direct
+	Source string
+	// Limit number of results. Defaults to 20.
+	Limit int
+}
+
+// GetTopicCoverage calls GET /api/v1/analytics/topic-coverage: get per-topic knowledge coverage.
+//
+// For each topic, count chat questions, relevant published entries, and how often answers cite knowledge.
+// Topics are ordered by coverage gap so high-demand topics with thin coverage come first.
+func (c *Client) GetTopicCoverage(ctx context.Context, params *GetTopicCoverageParams) (*TopicCoverageReport, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/topic-coverage"}
+	if params != nil {
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Reclassify != nil {
+			req.setQuery("reclassify", strconv.FormatBool(*params.Reclassify))
+		}
+	}
+	var data *TopicCoverageReport
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetTopicCoverageParams are the optional parameters of GetTopicCoverage
+type GetTopicCoverageParams struct {
+	// Only count questions since this date (YYYY-MM-DD)
+	Since string
+	// Classify questions again, e.g. after topics changed. Defaults to false.
+	Reclassify *bool
+}
+
+// GetUnansweredQuestions calls GET /api/v1/analytics/unanswered: get top unanswered questions.
+//
+// Group the searches and chat questions that found no entries or whose answers were rated as unhelpful into clusters of similar questions, most asked first, for planning new content
+func (c *Client) GetUnansweredQuestions(ctx context.Context, params *GetUnansweredQuestionsParams) ([]UnansweredCluster, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/unanswered"}
+	if params != nil {
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Similarity != 0 {
+			req.setQuery("similarity", strconv.FormatFloat(params.Similarity, 'f', -1, 64))
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data []UnansweredCluster
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetUnansweredQuestionsParams are the optional parameters of GetUnansweredQuestions
+type GetUnansweredQuestionsParams struct {
+	// Only count questions since this date (YYYY-MM-DD). Defaults to 30 days ago.
+	Since string
+	// Embedding similarity from which questions count as the same question. Defaults to 0.85.
+	Similarity float64
+	// Limit number of clusters. Defaults to 20.
+	Limit int
+}
+
+// GetUnclickedEntries calls GET /api/v1/analytics/entries/unclicked: get entries retrieved in chat but never opened.
+//
+// List the knowledge entries that were sources of chat answers since a date without being opened from chat, most retrieved first
+func (c *Client) GetUnclickedEntries(ctx context.Context, params *GetUnclickedEntriesParams) ([]UnclickedEntry, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/entries/unclicked"}
+	if params != nil {
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data []UnclickedEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetUnclickedEntriesParams are the optional parameters of GetUnclickedEntries
+type GetUnclickedEntriesParams struct {
+	// Only count chat answers and views since this date (YYYY-MM-DD). Defaults to 30 days ago.
+	Since string
+	// Limit number of results. Defaults to 20.
+	Limit int
+}
+
+// GetUsage calls GET /api/v1/analytics/usage: get token usage and cost.
+//
+// Aggregate the recorded prompt and completion tokens and estimated cost of chat answers per user, per topic, and per day
+func (c *Client) GetUsage(ctx context.Context, params *GetUsageParams) (*UsageReport, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/usage"}
+	if params != nil {
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Until != "" {
+			req.setQuery("until", params.Until)
+		}
+	}
+	var data *UsageReport
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetUsageParams are the optional parameters of GetUsage
+type GetUsageParams struct {
+	// Only count usage since this date (YYYY-MM-DD)
+	Since string
+	// Only count usage up to and including this date (YYYY-MM-DD)
+	Until string
+}
+
+// GetUserQuota calls GET /api/v1/quotas/users/{id}: get a user's quota.
+//
+// Monthly token and cost usage of the user and of the organization, with remaining quota
+func (c *Client) GetUserQuota(ctx context.Context, id string) (*QuotaStatus, error) {
+	req := &request{method: "GET", path: "/api/v1/quotas/users/" + url.PathEscape(id)}
+	var data *QuotaStatus
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetWebSource calls GET /api/v1/documents/web-sources/{id}: get web source crawl status.
+//
+// The status of the last crawl and every crawled page with its knowledge entries and any error
+func (c *Client) GetWebSource(ctx context.Context, id string) (*WebSource, error) {
+	req := &request{method: "GET", path: "/api/v1/documents/web-sources/" + url.PathEscape(id)}
+	var data *WebSource
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetZeroResultSearches calls GET /api/v1/analytics/searches/zero-results: get zero-result searches.
+//
+// List the searches and chat questions that found no knowledge entries, most frequent first
+func (c *Client) GetZeroResultSearches(ctx context.Context, params *GetZeroResultSearchesParams) ([]ZeroResultQuery, error) {
+	req := &request{method: "GET", path: "/api/v1/analytics/searches/zero-results"}
+	if params != nil {
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Source != "" {
+			req.setQuery("source", params.Source)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data []ZeroResultQuery
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetZeroResultSearchesParams are the optional parameters of GetZeroResultSearches
+type GetZeroResultSearchesParams struct {
+	// Only count searches since this date (YYYY-MM-DD). Defaults to 30 days ago.
+	Since string
+	// Only count searches from search or chat
+	Source string
+	// Limit number of results. Defaults to 20.
+	Limit int
+}
+
+// Health calls GET /health: liveness probe.
+//
+// Reports that the process is up
+func (c *Client) Health(ctx context.Context) (map[string]interface{}, error) {
+	req := &request{method: "GET", path: "/health"}
+	var data map[string]interface{}
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// HealthCheck calls GET /api/v1/assistant/health: health check.
+//
+// Check if OpenAI Assistant service is working
+func (c *Client) HealthCheck(ctx context.Context) (*HealthCheckResult, error) {
+	req := &request{method: "GET", path: "/api/v1/assistant/health"}
+	var data *HealthCheckResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImportConfig calls POST /api/v1/admin/config/import: import a configuration bundle.
+//
+// Creates and updates the records of a bundle exported from another environment, in one transaction.
+// Nothing missing from the bundle is deleted. A dry run (the default) reports the changes without keeping them.
+func (c *Client) ImportConfig(ctx context.Context, params *ImportConfigParams, body *ImportConfigRequest) (*ConfigImportReport, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/config/import"}
+	if params != nil {
+		if params.DryRun != nil {
+			req.setQuery("dry_run", strconv.FormatBool(*params.DryRun))
+		}
+	}
+	req.body = body
+	var data *ConfigImportReport
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImportConfigParams are the optional parameters of ImportConfig
+type ImportConfigParams struct {
+	// Only report what would change. Defaults to true.
+	DryRun *bool
+}
+
+// IngestURL calls POST /api/v1/documents/ingest-url: ingest a web page or sitemap.
+//
+// Fetch a URL, or every page of a sitemap, strip navigation and other page chrome, and save the text as knowledge entries.
+// Each entry stores its source URL in field_data. The crawl runs in the background; follow it with GET /documents/web-sources/{id}.
+// With recrawl_hours set the pages are fetched again on that schedule and entries of changed pages replaced. Submitting a URL again updates its settings.
+func (c *Client) IngestURL(ctx context.Context, body *IngestURLRequest) (*WebSource, error) {
+	req := &request{method: "POST", path: "/api/v1/documents/ingest-url"}
+	req.body = body
+	var data *WebSource
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListAllPersonas calls GET /api/v1/admin/personas: list all persona presets.
+func (c *Client) ListAllPersonas(ctx context.Context) ([]PersonaPreset, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/personas"}
+	var data []PersonaPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListAnnouncements calls GET /api/v1/announcements: list announcements.
+func (c *Client) ListAnnouncements(ctx context.Context, params *ListAnnouncementsParams) ([]Announcement, error) {
+	req := &request{method: "GET", path: "/api/v1/announcements"}
+	if params != nil {
+		if params.Active != nil {
+			req.setQuery("active", strconv.FormatBool(*params.Active))
+		}
+	}
+	var data []Announcement
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListAnnouncementsParams are the optional parameters of ListAnnouncements
+type ListAnnouncementsParams struct {
+	// Only the announcements active now
+	Active *bool
+}
+
+// ListAssignments calls GET /api/v1/retrieval-presets/assignments: list retrieval preset assignments.
+func (c *Client) ListAssignments(ctx context.Context) (*RetrievalPresetAssignments, error) {
+	req := &request{method: "GET", path: "/api/v1/retrieval-presets/assignments"}
+	var data *RetrievalPresetAssignments
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListAssistants calls GET /api/v1/admin/assistants: list managed assistants.
+func (c *Client) ListAssistants(ctx context.Context) ([]ManagedAssistant, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/assistants"}
+	var data []ManagedAssistant
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListAudit calls GET /api/v1/admin/impersonations/audit: list the impersonation audit trail.
+//
+// Impersonation sessions started and stopped, and every request made while impersonating, newest first
+func (c *Client) ListAudit(ctx context.Context, params *ListAuditParams) (*ListAuditResult, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/impersonations/audit"}
+	if params != nil {
+		if params.SessionID != "" {
+			req.setQuery("session_id", params.SessionID)
+		}
+		if params.AdminID != "" {
+			req.setQuery("admin_id", params.AdminID)
+		}
+		if params.TargetUserID != "" {
+			req.setQuery("target_user_id", params.TargetUserID)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListAuditResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListAuditParams are the optional parameters of ListAudit
+type ListAuditParams struct {
+	// Filter by impersonation session ID
+	SessionID string
+	// Filter by admin
+	AdminID string
+	// Filter by impersonated user
+	TargetUserID string
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListBookmarks calls GET /api/v1/me/bookmarks: list bookmarks.
+//
+// Answers and knowledge entries the current user saved, most recent first. Bookmarked entries rank higher in the user's searches.
+func (c *Client) ListBookmarks(ctx context.Context, params *ListBookmarksParams) ([]Bookmark, error) {
+	req := &request{method: "GET", path: "/api/v1/me/bookmarks"}
+	if params != nil {
+		if params.Type != "" {
+			req.setQuery("type", params.Type)
+		}
+	}
+	var data []Bookmark
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListBookmarksParams are the optional parameters of ListBookmarks
+type ListBookmarksParams struct {
+	// messages or entries
+	Type string
+}
+
+// ListContextFiles calls GET /api/v1/context-files: list context files.
+func (c *Client) ListContextFiles(ctx context.Context, params *ListContextFilesParams) (*ListContextFilesResult, *Meta, error) {
+	req := &request{method: "GET", path: "/api/v1/context-files"}
+	if params != nil {
+		if params.Q != "" {
+			req.setQuery("q", params.Q)
+		}
+		if params.Label != "" {
+			req.setQuery("label", params.Label)
+		}
+		if params.Status != "" {
+			req.setQuery("status", params.Status)
+		}
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Until != "" {
+			req.setQuery("until", params.Until)
+		}
+		if params.Sort != "" {
+			req.setQuery("sort", params.Sort)
+		}
+		if params.Order != "" {
+			req.setQuery("order", params.Order)
+		}
+		if params.Page != 0 {
+			req.setQuery("page", strconv.Itoa(params.Page))
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data *ListContextFilesResult
+	var meta *Meta
+	if err := c.call(ctx, req, &data, &meta); err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+// ListContextFilesParams are the optional parameters of ListContextFiles
+type ListContextFilesParams struct {
+	// Substring of the file name, case-insensitive
+	Q string
+	// Substring of the labels of the file, case-insensitive
+	Label string
+	// Status, e.g. Active
+	Status string
+	// Updated on or after this date (YYYY-MM-DD)
+	Since string
+	// Updated on or before this date (YYYY-MM-DD)
+	Until string
+	// name, status or updated_at. Defaults to updated_at.
+	Sort string
+	// asc or desc. Defaults to desc.
+	Order string
+	// Page number. Defaults to 1.
+	Page int
+	// Files per page, at most 100. Defaults to 20.
+	Limit int
+}
+
+// ListDocuments calls POST /api/v1/documents: list uploaded documents.
+//
+// List uploaded documents with pagination
+func (c *Client) ListDocuments(ctx context.Context, body *ListDocumentsRequest) (*ListDocumentsResult, error) {
+	req := &request{method: "POST", path: "/api/v1/documents"}
+	req.body = body
+	var data *ListDocumentsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListEscalations calls GET /api/v1/support/escalations: list escalations.
+//
+// Chat sessions whose users asked for a support agent, longest waiting first
+func (c *Client) ListEscalations(ctx context.Context, agentID string, params *ListEscalationsParams) (*ListEscalationsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/support/escalations"}
+	req.setQuery("agent_id", agentID)
+	if params != nil {
+		if params.Status != "" {
+			req.setQuery("status", params.Status)
+		}
+		if params.Mine != nil {
+			req.setQuery("mine", strconv.FormatBool(*params.Mine))
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListEscalationsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListEscalationsParams are the optional parameters of ListEscalations
+type ListEscalationsParams struct {
+	// Filter by status (open, claimed, resolved)
+	Status string
+	// Only escalations claimed by the agent
+	Mine *bool
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListEvents calls GET /api/v1/moderation/events: list moderation events.
+//
+// PII redacted from user messages and AI responses blocked by moderation, newest first. The offending text is never stored.
+func (c *Client) ListEvents(ctx context.Context, params *ListEventsParams) (*ListEventsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/moderation/events"}
+	if params != nil {
+		if params.UserID != "" {
+			req.setQuery("user_id", params.UserID)
+		}
+		if params.Stage != "" {
+			req.setQuery("stage", params.Stage)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListEventsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListEventsParams are the optional parameters of ListEvents
+type ListEventsParams struct {
+	// Filter by user
+	UserID string
+	// Filter by stage (input or output)
+	Stage string
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListExperimentRuns calls GET /api/v1/experiments/runs: list experiment runs.
+func (c *Client) ListExperimentRuns(ctx context.Context, params *ListExperimentRunsParams) (*ListExperimentRunsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/experiments/runs"}
+	if params != nil {
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data *ListExperimentRunsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListExperimentRunsParams are the optional parameters of ListExperimentRuns
+type ListExperimentRunsParams struct {
+	// Limit number of runs. Defaults to 30.
+	Limit int
+}
+
+// ListJobs calls GET /api/v1/jobs: list background jobs.
+//
+// List background jobs with optional queue, type, and status filters
+func (c *Client) ListJobs(ctx context.Context, params *ListJobsParams) (*ListJobsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/jobs"}
+	if params != nil {
+		if params.Queue != "" {
+			req.setQuery("queue", params.Queue)
+		}
+		if params.Type != "" {
+			req.setQuery("type", params.Type)
+		}
+		if params.Status != "" {
+			req.setQuery("status", params.Status)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListJobsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListJobsParams are the optional parameters of ListJobs
+type ListJobsParams struct {
+	// Filter by queue
+	Queue string
+	// Filter by job type
+	Type string
+	// Filter by status (pending, running, completed, failed, dead)
+	Status string
+	// Limit number of results. Defaults to 20.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListPairs calls GET /api/v1/retrieval-eval/pairs: list labeled retrieval pairs.
+func (c *Client) ListPairs(ctx context.Context, params *ListPairsParams) (*ListPairsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/retrieval-eval/pairs"}
+	if params != nil {
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListPairsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListPairsParams are the optional parameters of ListPairs
+type ListPairsParams struct {
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListPersonas calls GET /api/v1/chat/personas: list persona presets.
+//
+// Active presets, which a chat session answers as once picked with persona_id or PUT /chat/sessions/{id}/persona
+func (c *Client) ListPersonas(ctx context.Context) ([]PersonaPreset, error) {
+	req := &request{method: "GET", path: "/api/v1/chat/personas"}
+	var data []PersonaPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListPresets calls GET /api/v1/retrieval-presets: list retrieval presets.
+func (c *Client) ListPresets(ctx context.Context) ([]RetrievalPreset, error) {
+	req := &request{method: "GET", path: "/api/v1/retrieval-presets"}
+	var data []RetrievalPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListPrompts calls GET /api/v1/prompts: list prompt templates.
+//
+// List prompt template versions, newest first. Built-in names are support_assistant (OpenAI, Ollama) and knowledge_assistant (Gemini).
+func (c *Client) ListPrompts(ctx context.Context, params *ListPromptsParams) ([]PromptTemplate, error) {
+	req := &request{method: "GET", path: "/api/v1/prompts"}
+	if params != nil {
+		if params.Name != "" {
+			req.setQuery("name", params.Name)
+		}
+		if params.Active != nil {
+			req.setQuery("active", strconv.FormatBool(*params.Active))
+		}
+	}
+	var data []PromptTemplate
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListPromptsParams are the optional parameters of ListPrompts
+type ListPromptsParams struct {
+	// Filter by prompt name
+	Name string
+	// Only return active versions
+	Active *bool
+}
+
+// ListQuarantine calls GET /api/v1/admin/quarantine: list quarantined documents.
+//
+// Uploaded and imported documents held back because they contain instruction-like content that could hijack the assistant, newest first
+func (c *Client) ListQuarantine(ctx context.Context, params *ListQuarantineParams) (*ListQuarantineResult, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/quarantine"}
+	if params != nil {
+		if params.Status != "" {
+			req.setQuery("status", params.Status)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListQuarantineResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListQuarantineParams are the optional parameters of ListQuarantine
+type ListQuarantineParams struct {
+	// Filter by status (pending, approved, rejected)
+	Status string
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListQuestions calls GET /api/v1/experiments/questions: list golden questions.
+//
+// The fixed question set every experiment run answers
+func (c *Client) ListQuestions(ctx context.Context) ([]GoldenQuestion, error) {
+	req := &request{method: "GET", path: "/api/v1/experiments/questions"}
+	var data []GoldenQuestion
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListRetentionPolicies calls GET /api/v1/admin/retention/policies: list chat retention policies.
+func (c *Client) ListRetentionPolicies(ctx context.Context) (*ListRetentionPoliciesResult, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/retention/policies"}
+	var data *ListRetentionPoliciesResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListRetrievalEvalRuns calls GET /api/v1/retrieval-eval/runs: list retrieval evaluation runs.
+//
+// Recall@k, MRR, score distributions and answer faithfulness of recent runs, newest first
+func (c *Client) ListRetrievalEvalRuns(ctx context.Context, params *ListRetrievalEvalRunsParams) (*ListRetrievalEvalRunsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/retrieval-eval/runs"}
+	if params != nil {
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data *ListRetrievalEvalRunsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListRetrievalEvalRunsParams are the optional parameters of ListRetrievalEvalRuns
+type ListRetrievalEvalRunsParams struct {
+	// Limit number of runs. Defaults to 30.
+	Limit int
+}
+
+// ListReviewPolicies calls GET /api/v1/knowledge/stale/policies: list category review ages.
+func (c *Client) ListReviewPolicies(ctx context.Context) (*ListReviewPoliciesResult, error) {
+	req := &request{method: "GET", path: "/api/v1/knowledge/stale/policies"}
+	var data *ListReviewPoliciesResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListSchedules calls GET /api/v1/schedules: list scheduled jobs.
+//
+// Each job with its cron expression, next run and the status, error, result and duration of its last run
+func (c *Client) ListSchedules(ctx context.Context) (*ListSchedulesResult, error) {
+	req := &request{method: "GET", path: "/api/v1/schedules"}
+	var data *ListSchedulesResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListScreens calls GET /api/v1/help/screens: list help screens.
+//
+// Screen to knowledge entry mappings used by the contextual help
+func (c *Client) ListScreens(ctx context.Context) ([]HelpScreen, error) {
+	req := &request{method: "GET", path: "/api/v1/help/screens"}
+	var data []HelpScreen
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListStaleEntries calls GET /api/v1/knowledge/stale: list stale knowledge entries.
+//
+// Published entries not updated within the review age of their category, longest unchanged first.
+// Updating an entry or confirming it is still correct removes it from the list.
+func (c *Client) ListStaleEntries(ctx context.Context, params *ListStaleEntriesParams) (*ListStaleEntriesResult, error) {
+	req := &request{method: "GET", path: "/api/v1/knowledge/stale"}
+	if params != nil {
+		if params.Category != "" {
+			req.setQuery("category", params.Category)
+		}
+		if params.CreatedBy != "" {
+			req.setQuery("created_by", params.CreatedBy)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListStaleEntriesResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListStaleEntriesParams are the optional parameters of ListStaleEntries
+type ListStaleEntriesParams struct {
+	// Filter by category
+	Category string
+	// Filter by creator ID
+	CreatedBy string
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListTasks calls GET /api/v1/schedules/tasks: list schedulable tasks.
+//
+// Connector sync tasks are only available when their connector is configured
+func (c *Client) ListTasks(ctx context.Context) (*ListTasksResult, error) {
+	req := &request{method: "GET", path: "/api/v1/schedules/tasks"}
+	var data *ListTasksResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListTrackedChatLogs calls GET /api/v1/tracked-chat-logs: list tracked chat logs.
+func (c *Client) ListTrackedChatLogs(ctx context.Context) (*ListTrackedChatLogsResult, error) {
+	req := &request{method: "GET", path: "/api/v1/tracked-chat-logs"}
+	var data *ListTrackedChatLogsResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListTrash calls GET /api/v1/admin/trash: list deleted records.
+//
+// Soft-deleted knowledge entries, templates, chat sessions, feedback, documents and retrieval evaluation pairs,
+// most recently deleted first. purge_at is when the record is permanently deleted; archived chat sessions are kept.
+func (c *Client) ListTrash(ctx context.Context, params *ListTrashParams) (*ListTrashResult, error) {
+	req := &request{method: "GET", path: "/api/v1/admin/trash"}
+	if params != nil {
+		if params.Type != "" {
+			req.setQuery("type", params.Type)
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			req.setQuery("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var data *ListTrashResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListTrashParams are the optional parameters of ListTrash
+type ListTrashParams struct {
+	// Filter by type
+	Type string
+	// Limit number of results. Defaults to 50.
+	Limit int
+	// Offset for pagination. Defaults to 0.
+	Offset int
+}
+
+// ListUploadedFiles calls GET /api/v1/upload/files: list uploaded files.
+func (c *Client) ListUploadedFiles(ctx context.Context, params *ListUploadedFilesParams) (*ListUploadedFilesResult, *Meta, error) {
+	req := &request{method: "GET", path: "/api/v1/upload/files"}
+	if params != nil {
+		if params.Q != "" {
+			req.setQuery("q", params.Q)
+		}
+		if params.MimeType != "" {
+			req.setQuery("mime_type", params.MimeType)
+		}
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Until != "" {
+			req.setQuery("until", params.Until)
+		}
+		if params.Sort != "" {
+			req.setQuery("sort", params.Sort)
+		}
+		if params.Order != "" {
+			req.setQuery("order", params.Order)
+		}
+		if params.Page != 0 {
+			req.setQuery("page", strconv.Itoa(params.Page))
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data *ListUploadedFilesResult
+	var meta *Meta
+	if err := c.call(ctx, req, &data, &meta); err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+// ListUploadedFilesParams are the optional parameters of ListUploadedFiles
+type ListUploadedFilesParams struct {
+	// Substring of the file name, case-insensitive
+	Q string
+	// MIME type
+	MimeType string
+	// Uploaded on or after this date (YYYY-MM-DD)
+	Since string
+	// Uploaded on or before this date (YYYY-MM-DD)
+	Until string
+	// name, size or uploaded_at. Defaults to uploaded_at.
+	Sort string
+	// asc or desc. Defaults to desc.
+	Order string
+	// Page number. Defaults to 1.
+	Page int
+	// Files per page, at most 100. Defaults to 20.
+	Limit int
+}
+
+// Logout calls POST /api/v1/auth/logout: log out.
+//
+// Clears the session cookie. Bearer tokens stay valid until they expire.
+func (c *Client) Logout(ctx context.Context) (*LogoutResult, error) {
+	req := &request{method: "POST", path: "/api/v1/auth/logout"}
+	var data *LogoutResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MergeEntries calls POST /api/v1/knowledge/merge: merge knowledge entries.
+//
+// Appends the content of the other entries to the target under their titles, unifies their tags and deletes them.
+// Their IDs redirect to the target. Entries must have the same allowed roles and teams.
+func (c *Client) MergeEntries(ctx context.Context, body *MergeEntriesRequest) (*MergeResult, error) {
+	req := &request{method: "POST", path: "/api/v1/knowledge/merge"}
+	req.body = body
+	var data *MergeResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseDocument calls GET /api/v1/documents/parse: parse a document file.
+//
+// Parse a document (DOCX, text, Markdown or HTML) and return its sections without saving
+func (c *Client) ParseDocument(ctx context.Context, filePath string) (*ParseDocumentResponse, error) {
+	req := &request{method: "GET", path: "/api/v1/documents/parse"}
+	req.setQuery("file_path", filePath)
+	var data *ParseDocumentResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// PauseQueue calls POST /api/v1/admin/jobs/queues/{queue}/pause: pause a job queue.
+//
+// Workers stop claiming jobs from the queue; running jobs finish and new jobs are still accepted
+func (c *Client) PauseQueue(ctx context.Context, queue string, body *QueueActionRequest) (*JobQueuePause, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/jobs/queues/" + url.PathEscape(queue) + "/pause"}
+	req.body = body
+	var data *JobQueuePause
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// PostAgentMessage calls POST /api/v1/support/escalations/{id}/messages: post an agent message.
+//
+// Adds a message with role "support" to the user's chat session. The escalation must be claimed by the agent.
+func (c *Client) PostAgentMessage(ctx context.Context, id string, body *HandoffMessageRequest) (*ChatMessage, error) {
+	req := &request{method: "POST", path: "/api/v1/support/escalations/" + url.PathEscape(id) + "/messages"}
+	req.body = body
+	var data *ChatMessage
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ProcessChat calls POST /api/v1/chat: process chat.
+//
+// Send a message to the chatbot and get a response
+func (c *Client) ProcessChat(ctx context.Context, params *ProcessChatParams, body *ChatRequest) (*ChatResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/chat"}
+	if params != nil {
+		if params.Lang != "" {
+			req.setQuery("lang", params.Lang)
+		}
+	}
+	req.body = body
+	var data *ChatResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ProcessChatParams are the optional parameters of ProcessChat
+type ProcessChatParams struct {
+	// Language of the answer (ISO 639-1 code or tag); overrides the request body
+	Lang string
+}
+
+// ProcessChatWithAI calls POST /api/v1/ai/chat: process chat message with AI provider selection.
+//
+// Send a message to the AI chatbot with the ability to choose provider.
+// The message is sent as the signed-in user, or as the demo user without a session; a different user_id is rejected.
+func (c *Client) ProcessChatWithAI(ctx context.Context, params *ProcessChatWithAIParams, body *EnhancedChatRequest) (*EnhancedChatResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/ai/chat"}
+	if params != nil {
+		if params.Lang != "" {
+			req.setQuery("lang", params.Lang)
+		}
+	}
+	req.body = body
+	var data *EnhancedChatResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ProcessChatWithAIParams are the optional parameters of ProcessChatWithAI
+type ProcessChatWithAIParams struct {
+	// Language of the answer (ISO 639-1 code or tag); overrides the request body
+	Lang string
+}
+
+// ProcessDocument calls POST /api/v1/documents/process: process a document file.
+//
+// Parse a document (DOCX, text, Markdown or HTML) and save its sections to the knowledge base.
+// Documents with instruction-like content are quarantined for admin review instead.
+// With dry_run set nothing is saved: the proposed entries are returned as a preview, which can be edited and saved with POST /documents/process/confirm.
+func (c *Client) ProcessDocument(ctx context.Context, body *ProcessDocumentRequest) (*ProcessDocumentResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/documents/process"}
+	req.body = body
+	var data *ProcessDocumentResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ProcessWBDocument calls POST /api/v1/documents/process-wb: process the WB.docx document.
+//
+// Parse and save the WB.docx document to the knowledge base
+func (c *Client) ProcessWBDocument(ctx context.Context, params *ProcessWBDocumentParams) (*ProcessDocumentResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/documents/process-wb"}
+	if params != nil {
+		if params.CategoryName != "" {
+			req.setQuery("category_name", params.CategoryName)
+		}
+		if params.UserID != "" {
+			req.setQuery("user_id", params.UserID)
+		}
+	}
+	var data *ProcessDocumentResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ProcessWBDocumentParams are the optional parameters of ProcessWBDocument
+type ProcessWBDocumentParams struct {
+	// Category name for the document
+	CategoryName string
+	// User ID (UUID format)
+	UserID string
+}
+
+// PromoteRun calls POST /api/v1/experiments/runs/{id}/golden: promote a run to golden.
+//
+// Later runs of the provider are compared with the answers of this run. Promote the run of a prompt change once it is accepted.
+func (c *Client) PromoteRun(ctx context.Context, id string) (*ExperimentRun, error) {
+	req := &request{method: "POST", path: "/api/v1/experiments/runs/" + url.PathEscape(id) + "/golden"}
+	var data *ExperimentRun
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// PurgeTrash calls POST /api/v1/admin/trash/purge: purge deleted records.
+//
+// Queue a job permanently deleting the records deleted more than the retention period ago,
+// along with the Qdrant vectors of knowledge entries. Purges also run on a schedule.
+func (c *Client) PurgeTrash(ctx context.Context, body *TrashAdminRequest) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/trash/purge"}
+	req.body = body
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// QueueQuestion calls POST /api/v1/ai/queued-questions: queue question for a later answer.
+//
+// Queue a question to be researched in the background; the answer is added to the chat session and emailed to the user
+func (c *Client) QueueQuestion(ctx context.Context, body *EnhancedChatRequest) (*QueuedQuestion, error) {
+	req := &request{method: "POST", path: "/api/v1/ai/queued-questions"}
+	req.body = body
+	var data *QueuedQuestion
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Readyz calls GET /readyz: readiness probe.
+//
+// Reports ready once the database, Qdrant, and at least one AI provider are reachable.
+// Returns 503 while a dependency is down and once shutdown has started.
+func (c *Client) Readyz(ctx context.Context) (*DependencyStatus, error) {
+	req := &request{method: "GET", path: "/readyz"}
+	var data *DependencyStatus
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RegenerateChatAnswer calls POST /api/v1/chat/messages/{id}/regenerate: regenerate chat answer.
+//
+// Answer the last question of a session again, optionally with another provider. The previous answer is
+// kept as a revision of the message, listed by GET /chat/messages/{id}/versions.
+func (c *Client) RegenerateChatAnswer(ctx context.Context, id string, body *RegenerateAnswerRequest) (*EnhancedChatResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/chat/messages/" + url.PathEscape(id) + "/regenerate"}
+	req.body = body
+	var data *EnhancedChatResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RejectQuarantine calls POST /api/v1/admin/quarantine/{id}/reject: reject a quarantined document.
+//
+// Never use the document: an upload is not sent to the vector store and the entries of an import are deleted
+func (c *Client) RejectQuarantine(ctx context.Context, id string, body *ReviewQuarantineRequest) (*DocumentQuarantine, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/quarantine/" + url.PathEscape(id) + "/reject"}
+	req.body = body
+	var data *DocumentQuarantine
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ResolveEscalation calls POST /api/v1/support/escalations/{id}/resolve: resolve an escalation.
+//
+// The assistant answers the session again, with the agent's messages as part of the conversation history
+func (c *Client) ResolveEscalation(ctx context.Context, id string, body *HandoffAgentRequest) (*ChatEscalation, error) {
+	req := &request{method: "POST", path: "/api/v1/support/escalations/" + url.PathEscape(id) + "/resolve"}
+	req.body = body
+	var data *ChatEscalation
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RestoreChatSession calls POST /api/v1/chat/sessions/{id}/restore: restore deleted chat session.
+//
+// Undo the deletion of a chat session within 10 minutes, bringing back its messages
+func (c *Client) RestoreChatSession(ctx context.Context, id string) (*ChatSession, error) {
+	req := &request{method: "POST", path: "/api/v1/chat/sessions/" + url.PathEscape(id) + "/restore"}
+	var data *ChatSession
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RestoreItem calls POST /api/v1/admin/trash/{type}/{id}/restore: restore a deleted record.
+//
+// Restored knowledge entries are embedded again and no longer redirect to the entry they were merged into.
+// Restored chat sessions get back the messages deleted with them. Deleted documents cannot be restored.
+func (c *Client) RestoreItem(ctx context.Context, typeParam string, id string, body *TrashAdminRequest) (*TrashItem, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/trash/" + url.PathEscape(typeParam) + "/" + url.PathEscape(id) + "/restore"}
+	req.body = body
+	var data *TrashItem
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ResumeQueue calls POST /api/v1/admin/jobs/queues/{queue}/resume: resume a job queue.
+func (c *Client) ResumeQueue(ctx context.Context, queue string, body *QueueActionRequest) (*ResumeQueueResult, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/jobs/queues/" + url.PathEscape(queue) + "/resume"}
+	req.body = body
+	var data *ResumeQueueResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ResyncDocument calls POST /api/v1/documents/{id}/resync: resync a document with the vector store.
+//
+// Replace the OpenAI file of a document and attach it to the vector store again, e.g. after processing failed
+func (c *Client) ResyncDocument(ctx context.Context, id string) (*ResyncDocumentResult, error) {
+	req := &request{method: "POST", path: "/api/v1/documents/" + url.PathEscape(id) + "/resync"}
+	var data *ResyncDocumentResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RetryJob calls POST /api/v1/jobs/{id}/retry: retry background job.
+//
+// Requeue a failed or dead-lettered job with a fresh attempt budget
+func (c *Client) RetryJob(ctx context.Context, id string) (*Job, error) {
+	req := &request{method: "POST", path: "/api/v1/jobs/" + url.PathEscape(id) + "/retry"}
+	var data *Job
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RunDetection calls POST /api/v1/knowledge/stale/detect: detect stale knowledge entries.
+//
+// Queue a job that flags stale entries and reminds their creators
+func (c *Client) RunDetection(ctx context.Context) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/knowledge/stale/detect"}
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RunPolicy calls POST /api/v1/admin/retention/policies/{id}/run: run a chat retention policy.
+//
+// A dry run (the default) returns what the policy would remove; dry_run=false queues a job that applies it
+func (c *Client) RunPolicy(ctx context.Context, id string, params *RunPolicyParams, body *RetentionAdminRequest) (*RunPolicyResult, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/retention/policies/" + url.PathEscape(id) + "/run"}
+	if params != nil {
+		if params.DryRun != nil {
+			req.setQuery("dry_run", strconv.FormatBool(*params.DryRun))
+		}
+	}
+	req.body = body
+	status, env, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &RunPolicyResult{}
+	switch status {
+	case 200:
+		err = env.decode(&result.OK)
+	case 202:
+		err = env.decode(&result.Accepted)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RunPolicyParams are the optional parameters of RunPolicy
+type RunPolicyParams struct {
+	// Only report what would be removed. Defaults to true.
+	DryRun *bool
+}
+
+// RunSchedule calls POST /api/v1/schedules/{id}/run: run a scheduled job now.
+//
+// Queue a run of the job's task without changing its next scheduled time; the outcome is recorded as its last run
+func (c *Client) RunSchedule(ctx context.Context, id string, body *ScheduleAdminRequest) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/schedules/" + url.PathEscape(id) + "/run"}
+	req.body = body
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SearchDocuments calls GET /api/v1/documents: search uploaded documents.
+//
+// Uploaded documents matching every given filter, newest first unless sorted otherwise.
+func (c *Client) SearchDocuments(ctx context.Context, params *SearchDocumentsParams) ([]UploadedDocument, *Meta, error) {
+	req := &request{method: "GET", path: "/api/v1/documents"}
+	if params != nil {
+		if params.Q != "" {
+			req.setQuery("q", params.Q)
+		}
+		if params.Status != "" {
+			req.setQuery("status", params.Status)
+		}
+		if params.Target != "" {
+			req.setQuery("target", params.Target)
+		}
+		if params.Category != "" {
+			req.setQuery("category", params.Category)
+		}
+		if params.UploadedBy != "" {
+			req.setQuery("uploaded_by", params.UploadedBy)
+		}
+		if params.Since != "" {
+			req.setQuery("since", params.Since)
+		}
+		if params.Until != "" {
+			req.setQuery("until", params.Until)
+		}
+		if params.Sort != "" {
+			req.setQuery("sort", params.Sort)
+		}
+		if params.Order != "" {
+			req.setQuery("order", params.Order)
+		}
+		if params.Page != 0 {
+			req.setQuery("page", strconv.Itoa(params.Page))
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var data []UploadedDocument
+	var meta *Meta
+	if err := c.call(ctx, req, &data, &meta); err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+// SearchDocumentsParams are the optional parameters of SearchDocuments
+type SearchDocumentsParams struct {
+	// Substring of the file name, case-insensitive
+	Q string
+	// Document status, e.g. imported or quarantined
+	Status string
+	// Ingestion target: vector_store or knowledge_base
+	Target string
+	// Category of knowledge base imports
+	Category string
+	// Uploader ID
+	UploadedBy string
+	// Uploaded on or after this date (YYYY-MM-DD)
+	Since string
+	// Uploaded on or before this date (YYYY-MM-DD)
+	Until string
+	// created_at, updated_at, file_name, file_size or status. Defaults to created_at.
+	Sort string
+	// asc or desc. Defaults to desc.
+	Order string
+	// Page number. Defaults to 1.
+	Page int
+	// Documents per page, at most 100. Defaults to 20.
+	Limit int
+}
+
+// SearchKnowledgeEntries calls GET /api/v1/knowledge/search: search knowledge entries.
+//
+// Full-text search of the published knowledge entries, best matches first. The query accepts web search
+// syntax: quoted phrases, OR, and -excluded terms. The response carries the hits of the page, the hit counts
+// per category, tag and template across all pages, and the total hit count in meta.
+func (c *Client) SearchKnowledgeEntries(ctx context.Context, q string, params *SearchKnowledgeEntriesParams) (*FullTextResult, *Meta, error) {
+	req := &request{method: "GET", path: "/api/v1/knowledge/search"}
+	req.setQuery("q", q)
+	if params != nil {
+		if params.Category != "" {
+			req.setQuery("category", params.Category)
+		}
+		if params.Tag != "" {
+			req.setQuery("tag", params.Tag)
+		}
+		if params.TemplateID != "" {
+			req.setQuery("template_id", params.TemplateID)
+		}
+		if params.Highlight != nil {
+			req.setQuery("highlight", strconv.FormatBool(*params.Highlight))
+		}
+		if params.Page != 0 {
+			req.setQuery("page", strconv.Itoa(params.Page))
+		}
+		if params.Limit != 0 {
+			req.setQuery("limit", strconv.Itoa(params.Limit))
+		}
+		if params.MaxReadingMinutes != 0 {
+			req.setQuery("max_reading_minutes", strconv.FormatFloat(params.MaxReadingMinutes, 'f', -1, 64))
+		}
+		if params.Complexity != "" {
+			req.setQuery("complexity", params.Complexity)
+		}
+	}
+	var data *FullTextResult
+	var meta *Meta
+	if err := c.call(ctx, req, &data, &meta); err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+// SearchKnowledgeEntriesParams are the optional parameters of SearchKnowledgeEntries
+type SearchKnowledgeEntriesParams struct {
+	// Filter by category
+	Category string
+	// Filter by tag
+	Tag string
+	// Filter by template ID
+	TemplateID string
+	// Return a snippet of each hit with the matched terms wrapped in <mark>. Defaults to false.
+	Highlight *bool
+	// Page number. Defaults to 1.
+	Page int
+	// Hits per page. Defaults to 20.
+	Limit int
+	// Only entries that take at most this many minutes to read
+	MaxReadingMinutes float64
+	// Filter by complexity (easy, moderate, advanced)
+	Complexity string
+}
+
+// SetChatSessionPersona calls PUT /api/v1/chat/sessions/{id}/persona: set chat session persona.
+//
+// Every later answer of the session is given as the persona, e.g. "answer as a warehouse supervisor".
+// A system_prompt sent with a message takes precedence for that message.
+func (c *Client) SetChatSessionPersona(ctx context.Context, id string, body *SessionPersonaRequest) (*ChatSession, error) {
+	req := &request{method: "PUT", path: "/api/v1/chat/sessions/" + url.PathEscape(id) + "/persona"}
+	req.body = body
+	var data *ChatSession
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetOrgQuota calls PUT /api/v1/quotas/org: override the organization quota.
+//
+// Replace the configured monthly limits shared by all users. Only admins may override quotas.
+func (c *Client) SetOrgQuota(ctx context.Context, body *QuotaOverrideRequest) (*UsageQuota, error) {
+	req := &request{method: "PUT", path: "/api/v1/quotas/org"}
+	req.body = body
+	var data *UsageQuota
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetPolicy calls PUT /api/v1/knowledge/stale/policies/{category}: set the review age of a category.
+//
+// Entries of the category not updated for max_age_days are flagged by the next detection run
+func (c *Client) SetPolicy(ctx context.Context, category string, body *SetReviewPolicyRequest) (*SetPolicyResult, error) {
+	req := &request{method: "PUT", path: "/api/v1/knowledge/stale/policies/" + url.PathEscape(category)}
+	req.body = body
+	var data *SetPolicyResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetPrimaryProvider calls POST /api/v1/ai/providers/primary: set primary AI provider.
+//
+// Change the primary AI provider for chat requests
+func (c *Client) SetPrimaryProvider(ctx context.Context, body *SetPrimaryProviderRequest) (*SetPrimaryProviderResult, error) {
+	req := &request{method: "POST", path: "/api/v1/ai/providers/primary"}
+	req.body = body
+	var data *SetPrimaryProviderResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetScreen calls PUT /api/v1/help/screens/{screen}: set the help of a screen.
+//
+// Map a screen ID to knowledge entries in display order. The screen's quick tips are regenerated on the next request.
+func (c *Client) SetScreen(ctx context.Context, screen string, body *SetHelpScreenRequest) (*HelpScreen, error) {
+	req := &request{method: "PUT", path: "/api/v1/help/screens/" + url.PathEscape(screen)}
+	req.body = body
+	var data *HelpScreen
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetUserQuota calls PUT /api/v1/quotas/users/{id}: override a user's quota.
+//
+// Replace the configured monthly limits of a user. Only admins may override quotas.
+func (c *Client) SetUserQuota(ctx context.Context, id string, body *QuotaOverrideRequest) (*UsageQuota, error) {
+	req := &request{method: "PUT", path: "/api/v1/quotas/users/" + url.PathEscape(id)}
+	req.body = body
+	var data *UsageQuota
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SplitEntry calls POST /api/v1/knowledge/{id}/split: split a knowledge entry.
+//
+// A dry run (the default) returns the sections the AI proposes, made of whole paragraphs of the entry.
+// dry_run=false creates the given sections, or the proposed ones when none are given: the entry keeps its ID
+// as the first section and the others become new entries with the same category, tags and access.
+func (c *Client) SplitEntry(ctx context.Context, id string, params *SplitEntryParams, body *SplitEntryRequest) (*SplitEntryResult, error) {
+	req := &request{method: "POST", path: "/api/v1/knowledge/" + url.PathEscape(id) + "/split"}
+	if params != nil {
+		if params.DryRun != nil {
+			req.setQuery("dry_run", strconv.FormatBool(*params.DryRun))
+		}
+	}
+	req.body = body
+	var data *SplitEntryResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SplitEntryParams are the optional parameters of SplitEntry
+type SplitEntryParams struct {
+	// Only propose sections. Defaults to true.
+	DryRun *bool
+}
+
+// StartExperimentRun calls POST /api/v1/experiments/runs: run a prompt experiment.
+//
+// Answer every golden question with a prompt version (the active prompts by default) and provider at temperature 0,
+// and compare each answer by embedding similarity with the answer of the baseline run, by default the golden run of
+// the provider. Poll the run until it is completed: it passed when no answer is less similar than the threshold.
+func (c *Client) StartExperimentRun(ctx context.Context, body *StartExperimentRequest) (*StartExperimentRunResult, error) {
+	req := &request{method: "POST", path: "/api/v1/experiments/runs"}
+	req.body = body
+	var data *StartExperimentRunResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// StartImpersonation calls POST /api/v1/admin/impersonations: start impersonating a user.
+//
+// Issue a short-lived token that acts as the target user when sent in the X-Impersonation-Token header. Impersonated requests are read-only (GET only) and every request is recorded in the audit trail. Only signed-in admins may impersonate, and admins cannot be impersonated.
+func (c *Client) StartImpersonation(ctx context.Context, body *StartImpersonationRequest) (*ImpersonationGrant, error) {
+	req := &request{method: "POST", path: "/api/v1/admin/impersonations"}
+	req.body = body
+	var data *ImpersonationGrant
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// StartRetrievalEvalRun calls POST /api/v1/retrieval-eval/runs: run a retrieval evaluation.
+//
+// Queue an evaluation of the current retrieval configuration against all labeled pairs. With answers=true every
+// question is also answered through the chat path and the answers' faithfulness is scored by an LLM judge.
+func (c *Client) StartRetrievalEvalRun(ctx context.Context, params *StartRetrievalEvalRunParams) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/retrieval-eval/runs"}
+	if params != nil {
+		if params.Answers != nil {
+			req.setQuery("answers", strconv.FormatBool(*params.Answers))
+		}
+	}
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// StartRetrievalEvalRunParams are the optional parameters of StartRetrievalEvalRun
+type StartRetrievalEvalRunParams struct {
+	// Also evaluate answers. Defaults to false.
+	Answers *bool
+}
+
+// StopImpersonation calls DELETE /api/v1/admin/impersonations/{id}: stop an impersonation session.
+//
+// Revoke the token of an impersonation session before it expires. Only signed-in admins may stop sessions.
+func (c *Client) StopImpersonation(ctx context.Context, id string) error {
+	req := &request{method: "DELETE", path: "/api/v1/admin/impersonations/" + url.PathEscape(id)}
+	return c.call(ctx, req, nil, nil)
+}
+
+// SubmitFeedback calls POST /api/v1/feedback: submit feedback.
+//
+// Submit feedback for a chat message
+func (c *Client) SubmitFeedback(ctx context.Context, body *Feedback) (*Feedback, error) {
+	req := &request{method: "POST", path: "/api/v1/feedback"}
+	req.body = body
+	var data *Feedback
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SyncConfluence calls POST /api/v1/integrations/confluence/sync: sync the Confluence space.
+//
+// Queue a job that creates entries for new pages, replaces the entries of pages with a newer version, and deletes the entries of removed pages
+func (c *Client) SyncConfluence(ctx context.Context) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/integrations/confluence/sync"}
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SyncGoogleDrive calls POST /api/v1/integrations/google-drive/sync: sync the Google Drive folder.
+//
+// Queue a job that ingests new PDF, DOCX and Google Docs files, re-ingests changed files in place of their previous document, and deletes the documents of files removed from the folder
+func (c *Client) SyncGoogleDrive(ctx context.Context) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/integrations/google-drive/sync"}
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SyncNotion calls POST /api/v1/integrations/notion/sync: sync Notion pages.
+//
+// Queue a job that creates entries for new pages, replaces the entries of pages edited since their last sync, and deletes the entries of pages archived or no longer shared
+func (c *Client) SyncNotion(ctx context.Context) (*JobQueuedResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/integrations/notion/sync"}
+	var data *JobQueuedResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateAnnouncement calls PUT /api/v1/announcements/{id}: update an announcement.
+//
+// Replaces the announcement; an empty starts_at keeps the current start. Set ends_at to now to end it early.
+func (c *Client) UpdateAnnouncement(ctx context.Context, id string, body *AnnouncementRequest) (*Announcement, error) {
+	req := &request{method: "PUT", path: "/api/v1/announcements/" + url.PathEscape(id)}
+	req.body = body
+	var data *Announcement
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateAssistant calls PUT /api/v1/admin/assistants/{id}: update a managed assistant.
+//
+// Changes the given fields and renders the instructions from the active version of the prompt template again,
+// so an update without fields publishes a new prompt version to the assistant.
+func (c *Client) UpdateAssistant(ctx context.Context, id string, body *AssistantRequest) (*ManagedAssistant, error) {
+	req := &request{method: "PUT", path: "/api/v1/admin/assistants/" + url.PathEscape(id)}
+	req.body = body
+	var data *ManagedAssistant
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateKnowledgeEntry calls PUT /api/v1/knowledge/{id}: update knowledge entry.
+//
+// Update an existing knowledge entry
+func (c *Client) UpdateKnowledgeEntry(ctx context.Context, id string, body *KnowledgeEntry) (*KnowledgeEntry, error) {
+	req := &request{method: "PUT", path: "/api/v1/knowledge/" + url.PathEscape(id)}
+	req.body = body
+	var data *KnowledgeEntry
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdatePersona calls PUT /api/v1/admin/personas/{id}: update a persona preset.
+//
+// Sessions using the preset answer with the new prompt from their next message. Set is_active to false
+// to retire a preset: it can no longer be picked and its sessions answer without a persona.
+func (c *Client) UpdatePersona(ctx context.Context, id string, body *PersonaRequest) (*PersonaPreset, error) {
+	req := &request{method: "PUT", path: "/api/v1/admin/personas/" + url.PathEscape(id)}
+	req.body = body
+	var data *PersonaPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdatePreset calls PUT /api/v1/retrieval-presets/{id}: update a retrieval preset.
+//
+// Changing the chunk settings re-embeds the entries of the categories and templates using the preset
+func (c *Client) UpdatePreset(ctx context.Context, id string, body *RetrievalPresetSpec) (*RetrievalPreset, error) {
+	req := &request{method: "PUT", path: "/api/v1/retrieval-presets/" + url.PathEscape(id)}
+	req.body = body
+	var data *RetrievalPreset
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdatePrompt calls PUT /api/v1/prompts/{id}: update a prompt template version.
+//
+// Replace the content, variables, and active flag of a version. Activating it deactivates the other versions of the same name.
+func (c *Client) UpdatePrompt(ctx context.Context, id string, body *PromptTemplateSpec) (*PromptTemplate, error) {
+	req := &request{method: "PUT", path: "/api/v1/prompts/" + url.PathEscape(id)}
+	req.body = body
+	var data *PromptTemplate
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateSchedule calls PUT /api/v1/schedules/{id}: update a scheduled job.
+//
+// Replaces the name, task, cron expression and active flag, and moves the job to its next time
+func (c *Client) UpdateSchedule(ctx context.Context, id string, body *ScheduleRequest) (*ScheduledJob, error) {
+	req := &request{method: "PUT", path: "/api/v1/schedules/" + url.PathEscape(id)}
+	req.body = body
+	var data *ScheduledJob
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateSettings calls PUT /api/v1/admin/settings: change runtime settings.
+//
+// Settings take effect without a restart and take precedence over the environment until reset with null.
+// Other instances pick the change up within 30 seconds. Chunk sizes apply to entries embedded from then on.
+func (c *Client) UpdateSettings(ctx context.Context, body *UpdateSettingsRequest) ([]RuntimeSetting, error) {
+	req := &request{method: "PUT", path: "/api/v1/admin/settings"}
+	req.body = body
+	var data []RuntimeSetting
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateTemplate calls PUT /api/v1/templates/{id}: update template.
+//
+// Update an existing template
+func (c *Client) UpdateTemplate(ctx context.Context, id string, body *Template) (*Template, error) {
+	req := &request{method: "PUT", path: "/api/v1/templates/" + url.PathEscape(id)}
+	req.body = body
+	var data *Template
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UploadAttachment calls POST /api/v1/ai/attachments: upload a chat image.
+//
+// Upload an image, e.g. a screenshot of an error, then send its ID as attachment_id with a message to /ai/chat.
+// The image is sent to the provider with the message, so the provider's model must support images (e.g. gpt-4o, Gemini).
+func (c *Client) UploadAttachment(ctx context.Context, image *File, userID string) (*ChatAttachment, error) {
+	req := &request{method: "POST", path: "/api/v1/ai/attachments"}
+	req.addFormFile("image", image)
+	req.addFormField("user_id", userID)
+	var data *ChatAttachment
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UploadContextFile calls POST /api/v1/context-file: upload a context file.
+//
+// Active files are imported into the knowledge base in the background: the status is Processing until the import ends as Active, Failed or Quarantined. The entries are tagged with the labels, which chat requests can narrow retrieval to.
+func (c *Client) UploadContextFile(ctx context.Context, file *File, params *UploadContextFileParams) (*UploadContextFileResult, error) {
+	req := &request{method: "POST", path: "/api/v1/context-file"}
+	req.addFormFile("file", file)
+	if params != nil {
+		if params.Description != "" {
+			req.addFormField("description", params.Description)
+		}
+		if params.Labels != "" {
+			req.addFormField("labels", params.Labels)
+		}
+		if params.Status != "" {
+			req.addFormField("status", params.Status)
+		}
+	}
+	var data *UploadContextFileResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UploadContextFileParams are the optional parameters of UploadContextFile
+type UploadContextFileParams struct {
+	// Description
+	Description string
+	// Labels
+	Labels string
+	// Status; files with another status than Active are only recorded. Defaults to Active.
+	Status string
+}
+
+// UploadDocument calls POST /api/v1/documents/upload: upload document file.
+//
+// Upload a document file, store it locally, then upload to OpenAI and add to vector store. Documents containing instruction-like content are quarantined until an admin approves them.
+// With target knowledge_base the document is split into knowledge entries instead. Spreadsheets (.xlsx, .csv) default to
+// the knowledge base and get one entry per table, or per block of rows with the header row repeated.
+func (c *Client) UploadDocument(ctx context.Context, file *File, fileName string, params *UploadDocumentParams) (*DocumentUploadResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/documents/upload"}
+	req.addFormFile("file", file)
+	req.addFormField("file_name", fileName)
+	if params != nil {
+		if params.Category != "" {
+			req.addFormField("category", params.Category)
+		}
+		if params.Target != "" {
+			req.addFormField("target", params.Target)
+		}
+	}
+	var data *DocumentUploadResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UploadDocumentParams are the optional parameters of UploadDocument
+type UploadDocumentParams struct {
+	// Category of the knowledge entries of a knowledge base import
+	Category string
+	// vector_store or knowledge_base
+	Target string
+}
+
+// UploadFiles calls POST /api/v1/upload: upload files.
+//
+// Stores the files of the multipart form. Files whose content was already uploaded are reported as duplicates.
+func (c *Client) UploadFiles(ctx context.Context, file *File) (*UploadFilesResult, error) {
+	req := &request{method: "POST", path: "/api/v1/upload"}
+	req.addFormFile("file", file)
+	var data *UploadFilesResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WaitForRun calls GET /api/v1/assistant/runs/{id}/wait: wait for a run to finish.
+//
+// Long-polls a run for clients that cannot use streaming. Returns as soon as the run is completed,
+// failed, cancelled, expired, or requires action, with the run's messages when it completed. When the
+// timeout elapses first the response has done=false and the client should call again.
+func (c *Client) WaitForRun(ctx context.Context, id string, params *WaitForRunParams) (*RunWaitResult, error) {
+	req := &request{method: "GET", path: "/api/v1/assistant/runs/" + url.PathEscape(id) + "/wait"}
+	if params != nil {
+		if params.ThreadID != "" {
+			req.setQuery("thread_id", params.ThreadID)
+		}
+		if params.Timeout != "" {
+			req.setQuery("timeout", params.Timeout)
+		}
+	}
+	var data *RunWaitResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WaitForRunParams are the optional parameters of WaitForRun
+type WaitForRunParams struct {
+	// Thread of the run (defaults to the default thread)
+	ThreadID string
+	// How long to wait, e.g. 25s or 25 (seconds); at most 60s. Defaults to 25s.
+	Timeout string
+}
+
+// WebhookPing calls POST /api/v1/webhooks/ping: webhook ping.
+//
+// Verify that inbound webhook signing is configured correctly
+func (c *Client) WebhookPing(ctx context.Context, xticTimestamp string, xticNonce string, xticSignature string) (*WebhookPingResult, error) {
+	req := &request{method: "POST", path: "/api/v1/webhooks/ping"}
+	req.setHeader("X-TIC-Timestamp", xticTimestamp)
+	req.setHeader("X-TIC-Nonce", xticNonce)
+	req.setHeader("X-TIC-Signature", xticSignature)
+	var data *WebhookPingResult
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WidgetChat calls POST /api/v1/widget/chat: chat from the embedded widget.
+//
+// Same as POST /ai/chat for the embedded widget, whose requests are signed with the widget secret
+func (c *Client) WidgetChat(ctx context.Context, xticTimestamp string, xticNonce string, xticSignature string, params *WidgetChatParams, body *EnhancedChatRequest) (*EnhancedChatResponse, error) {
+	req := &request{method: "POST", path: "/api/v1/widget/chat"}
+	req.setHeader("X-TIC-Timestamp", xticTimestamp)
+	req.setHeader("X-TIC-Nonce", xticNonce)
+	req.setHeader("X-TIC-Signature", xticSignature)
+	if params != nil {
+		if params.Lang != "" {
+			req.setQuery("lang", params.Lang)
+		}
+	}
+	req.body = body
+	var data *EnhancedChatResponse
+	if err := c.call(ctx, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WidgetChatParams are the optional parameters of WidgetChat
+type WidgetChatParams struct {
+	// Language of the answer (ISO 639-1 code or tag); overrides the request body
+	Lang string
+}
+
+type Announcement struct {
+	Content   string `json:"content,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+	// Nil keeps the announcement active until it is ended or deleted
+	EndsAt *string `json:"ends_at,omitempty"`
+	ID     string  `json:"id,omitempty"`
+	// Higher priorities come first and are kept when too many are active
+	Priority  int    `json:"priority,omitempty"`
+	StartsAt  string `json:"starts_at,omitempty"`
+	Title     string `json:"title,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+type AnnouncementRequest struct {
+	Content string `json:"content,omitempty"`
+	// Empty keeps it active until it is ended or deleted
+	EndsAt    *string `json:"ends_at,omitempty"`
+	ManagerID string  `json:"manager_id,omitempty"`
+	Priority  int     `json:"priority,omitempty"`
+	// Empty starts it now
+	StartsAt *string `json:"starts_at,omitempty"`
+	Title    string  `json:"title,omitempty"`
+}
+
+type AssignCategoryResult struct {
+	Category string `json:"category,omitempty"`
+	PresetID string `json:"preset_id,omitempty"`
+}
+
+type AssignRetrievalPresetRequest struct {
+	PresetID string `json:"preset_id,omitempty"`
+}
+
+type AssignTemplateResult struct {
+	PresetID   string `json:"preset_id,omitempty"`
+	TemplateID string `json:"template_id,omitempty"`
+}
+
+type AssistantRequest struct {
+	AdminID     string `json:"admin_id,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Empty uses the default model
+	Model string `json:"model,omitempty"`
+	Name  string `json:"name,omitempty"`
+	// Prompt template rendered into the instructions
+	PromptName string `json:"prompt_name,omitempty"`
+	// Empty uses the knowledge vector store
+	VectorStoreIDS []string `json:"vector_store_ids,omitempty"`
+}
+
+type Bookmark struct {
+	CreatedAt        string          `json:"created_at,omitempty"`
+	ID               string          `json:"id,omitempty"`
+	KnowledgeEntry   *KnowledgeEntry `json:"knowledge_entry,omitempty"`
+	KnowledgeEntryID *string         `json:"knowledge_entry_id,omitempty"`
+	// Relations
+	Message   *ChatMessage `json:"message,omitempty"`
+	MessageID *string      `json:"message_id,omitempty"`
+	Note      string       `json:"note,omitempty"`
+	UserID    string       `json:"user_id,omitempty"`
+}
+
+type BookmarkRequest struct {
+	KnowledgeEntryID *string `json:"knowledge_entry_id,omitempty"`
+	MessageID        *string `json:"message_id,omitempty"`
+	Note             string  `json:"note,omitempty"`
+}
+
+type BootstrapRequestBody struct {
+	ActivateTemplates bool                `json:"activate_templates,omitempty"`
+	Documents         []BootstrapDocument `json:"documents,omitempty"`
+	Urls              []string            `json:"urls,omitempty"`
+	UserID            string              `json:"user_id,omitempty"`
+}
+
+type BootstrapResult struct {
+	Categories []string              `json:"categories,omitempty"`
+	Readiness  *ReadinessReport      `json:"readiness,omitempty"`
+	Seeds      []BootstrapSeedResult `json:"seeds,omitempty"`
+	Templates  []ProposedTemplate    `json:"templates,omitempty"`
+	Topics     []string              `json:"topics,omitempty"`
+}
+
+type ChatAssistantRequest struct {
+	AssistantID string `json:"assistant_id,omitempty"`
+	Message     string `json:"message,omitempty"`
+	// Optional, will use default if not provided
+	ThreadID string `json:"thread_id,omitempty"`
+	// Optional timeout in seconds, defaults to 30
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+type ChatAssistantResponse struct {
+	Messages    []AssistantMessage     `json:"messages,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	ProcessedAt string                 `json:"processed_at,omitempty"`
+	RunID       string                 `json:"run_id,omitempty"`
+	Status      string                 `json:"status,omitempty"`
+	ThreadID    string                 `json:"thread_id,omitempty"`
+}
+
+type ChatAttachment struct {
+	ContentType string `json:"content_type,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Size        int    `json:"size,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+}
+
+type ChatEscalation struct {
+	Agent *User `json:"agent,omitempty"`
+	// Support agent who claimed the escalation
+	AgentID    *string `json:"agent_id,omitempty"`
+	ClaimedAt  *string `json:"claimed_at,omitempty"`
+	CreatedAt  string  `json:"created_at,omitempty"`
+	ID         string  `json:"id,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	ResolvedAt *string `json:"resolved_at,omitempty"`
+	// Relations
+	Session   *ChatSession     `json:"session,omitempty"`
+	SessionID string           `json:"session_id,omitempty"`
+	Status    EscalationStatus `json:"status,omitempty"`
+	UpdatedAt string           `json:"updated_at,omitempty"`
+	User      *User            `json:"user,omitempty"`
+	UserID    string           `json:"user_id,omitempty"`
+}
+
+type ChatMessage struct {
+	// Relations
+	Attachment *ChatAttachment `json:"attachment,omitempty"`
+	// Image the user attached to the message
+	AttachmentID *string `json:"attachment_id,omitempty"`
+	Content      string  `json:"content,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+	ID           string  `json:"id,omitempty"`
+	// For storing additional data like sources
+	Metadata  string      `json:"metadata,omitempty"`
+	Role      MessageRole `json:"role,omitempty"`
+	SessionID string      `json:"session_id,omitempty"`
+	// Whether a user question was answered; see MessageStatus
+	Status MessageStatus `json:"status,omitempty"`
+	// Classified topic of a user question; 0 when no topic matched
+	TopicID   *int   `json:"topic_id,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	// Incremented when the message is edited or regenerated
+	Version int `json:"version,omitempty"`
+}
+
+type ChatMessageRevision struct {
+	Content string `json:"content,omitempty"`
+	// When the version was superseded
+	CreatedAt string `json:"created_at,omitempty"`
+	ID        string `json:"id,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	Metadata  string `json:"metadata,omitempty"`
+	Version   int    `json:"version,omitempty"`
+}
+
+type ChatRequest struct {
+	// Answer only from entries tagged with one of these, e.g. context file labels
+	Labels []string `json:"labels,omitempty"`
+	// Language of the answer; empty answers in the language of the message
+	Language string `json:"language,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Persona preset the session answers as from now on (see GET /chat/personas)
+	PersonaID *string `json:"persona_id,omitempty"`
+	SessionID *string `json:"session_id,omitempty"`
+	UserID    string  `json:"user_id,omitempty"`
+}
+
+type ChatResponse struct {
+	Citations []Citation `json:"citations,omitempty"`
+	// How well the knowledge base supports the answer, from 0 to 1
+	Confidence float64 `json:"confidence,omitempty"`
+	// Response of an identical request sent moments earlier
+	Deduplicated bool `json:"deduplicated,omitempty"`
+	// Set while a support agent answers the session instead of the assistant
+	Escalation *ChatEscalation `json:"escalation,omitempty"`
+	// ISO 639-1 code of the answer's language, when known
+	Language string `json:"language,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Entries related to the best match, suggested when confidence is low
+	SeeAlso   []RelatedEntry `json:"see_also,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Sources   []string       `json:"sources,omitempty"`
+	// The bot does not know the answer; the user should contact support
+	SuggestEscalation bool `json:"suggest_escalation,omitempty"`
+}
+
+type ChatRetentionPolicy struct {
+	Action    RetentionAction `json:"action,omitempty"`
+	CreatedAt string          `json:"created_at,omitempty"`
+	CreatedBy string          `json:"created_by,omitempty"`
+	// Write JSON transcripts before removing sessions
+	ExportBeforeDelete bool    `json:"export_before_delete,omitempty"`
+	ID                 string  `json:"id,omitempty"`
+	IsActive           bool    `json:"is_active,omitempty"`
+	LastRunAt          *string `json:"last_run_at,omitempty"`
+	MaxAgeMonths       int     `json:"max_age_months,omitempty"`
+	Name               string  `json:"name,omitempty"`
+	// Empty applies the policy to every user
+	Team      string `json:"team,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type ChatSession struct {
+	CreatedAt string         `json:"created_at,omitempty"`
+	ID        string         `json:"id,omitempty"`
+	IsActive  bool           `json:"is_active,omitempty"`
+	Messages  []ChatMessage  `json:"messages,omitempty"`
+	Persona   *PersonaPreset `json:"persona,omitempty"`
+	// Persona preset applied to every answer of the session
+	PersonaID *string `json:"persona_id,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
+	// Relations
+	User   *User  `json:"user,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+}
+
+type ChatWithCustomWorkflowRequest struct {
+	AssistantID       string `json:"assistant_id,omitempty"`
+	Message           string `json:"message,omitempty"`
+	ThreadID          string `json:"thread_id,omitempty"`
+	TimeoutSeconds    int    `json:"timeout_seconds,omitempty"`
+	WaitForCompletion bool   `json:"wait_for_completion,omitempty"`
+	WaitTime          string `json:"wait_time,omitempty"`
+}
+
+type CollectGarbageResult struct {
+	Accepted *JobQueuedResponse `json:"Accepted,omitempty"`
+	OK       *OpenAIGCReport    `json:"OK,omitempty"`
+}
+
+type CompareProvidersRequest struct {
+	Message   string   `json:"message,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+}
+
+type CompareProvidersResult struct {
+	Responses map[string]EnhancedChatResponse `json:"responses,omitempty"`
+}
+
+type ConfigBundle struct {
+	Categories  []CategoryConfig   `json:"categories,omitempty"`
+	ExportedAt  string             `json:"exported_at,omitempty"`
+	HelpScreens []HelpScreenConfig `json:"help_screens,omitempty"`
+	// Absent when the configured default applies
+	OrgQuota          *OrgQuotaConfig         `json:"org_quota,omitempty"`
+	Prompts           []PromptConfig          `json:"prompts,omitempty"`
+	RetentionPolicies []RetentionPolicyConfig `json:"retention_policies,omitempty"`
+	RetrievalPresets  []RetrievalPresetSpec   `json:"retrieval_presets,omitempty"`
+	Templates         []TemplateConfig        `json:"templates,omitempty"`
+	Version           int                     `json:"version,omitempty"`
+}
+
+type ConfigImportReport struct {
+	Changes   []ConfigImportChange `json:"changes,omitempty"`
+	DryRun    bool                 `json:"dry_run,omitempty"`
+	Unchanged int                  `json:"unchanged,omitempty"`
+}
+
+type ConfirmDocumentRequest struct {
+	Preview *DocumentPreview `json:"preview,omitempty"`
+	UserID  string           `json:"user_id,omitempty"`
+}
+
+type ConfirmReviewRequest struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type ConfluenceStatus struct {
+	BaseURL      string  `json:"base_url,omitempty"`
+	Category     string  `json:"category,omitempty"`
+	Enabled      bool    `json:"enabled,omitempty"`
+	FailedPages  int     `json:"failed_pages,omitempty"`
+	LastSyncedAt *string `json:"last_synced_at,omitempty"`
+	SpaceKey     string  `json:"space_key,omitempty"`
+	SyncedPages  int     `json:"synced_pages,omitempty"`
+}
+
+type ContributorStats struct {
+	Badges           []string `json:"badges,omitempty"`
+	Email            string   `json:"email,omitempty"`
+	EntriesPublished int      `json:"entries_published,omitempty"`
+	EntriesTotal     int      `json:"entries_total,omitempty"`
+	FeedbackCount    int      `json:"feedback_count,omitempty"`
+	HelpfulCount     int      `json:"helpful_count,omitempty"`
+	HelpfulRate      float64  `json:"helpful_rate,omitempty"`
+	Name             string   `json:"name,omitempty"`
+	Rank             int      `json:"rank,omitempty"`
+	UserID           string   `json:"user_id,omitempty"`
+	ViewsGenerated   int      `json:"views_generated,omitempty"`
+}
+
+type CountUploadsResult struct {
+	Count int `json:"count,omitempty"`
+}
+
+type CreateEvalPairRequest struct {
+	CreatedBy       string `json:"created_by,omitempty"`
+	ExpectedEntryID string `json:"expected_entry_id,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+	Question        string `json:"question,omitempty"`
+}
+
+type CreateGoldenQuestionRequest struct {
+	CreatedBy string `json:"created_by,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	Question  string `json:"question,omitempty"`
+}
+
+type CreatePromptRequest struct {
+	Content     string `json:"content,omitempty"`
+	CreatedBy   string `json:"created_by,omitempty"`
+	Description string `json:"description,omitempty"`
+	IsActive    bool   `json:"is_active,omitempty"`
+	Name        string `json:"name,omitempty"`
+	// Variable names and their default values
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type CreateRetentionPolicyRequest struct {
+	Action             RetentionAction `json:"action,omitempty"`
+	AdminID            string          `json:"admin_id,omitempty"`
+	ExportBeforeDelete bool            `json:"export_before_delete,omitempty"`
+	MaxAgeMonths       int             `json:"max_age_months,omitempty"`
+	Name               string          `json:"name,omitempty"`
+	Team               string          `json:"team,omitempty"`
+}
+
+type CreateRetrievalPresetRequest struct {
+	ChunkMaxTokens     int     `json:"chunk_max_tokens,omitempty"`
+	ChunkOverlapTokens int     `json:"chunk_overlap_tokens,omitempty"`
+	CreatedBy          string  `json:"created_by,omitempty"`
+	Description        string  `json:"description,omitempty"`
+	Name               string  `json:"name,omitempty"`
+	ScoreThreshold     float64 `json:"score_threshold,omitempty"`
+	TopK               int     `json:"top_k,omitempty"`
+}
+
+type CreateThreadResult struct {
+	CreatedAt int                    `json:"created_at,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	ThreadID  string                 `json:"thread_id,omitempty"`
+}
+
+type DeletedChatSession struct {
+	DeletedAt string `json:"deleted_at,omitempty"`
+	ID        string `json:"id,omitempty"`
+	// Messages deleted with the session
+	Messages int `json:"messages,omitempty"`
+	// Until when POST /chat/sessions/{id}/restore brings it back
+	UndoUntil string `json:"undo_until,omitempty"`
+}
+
+type DependencyStatus struct {
+	CheckedAt    *string           `json:"checked_at,omitempty"`
+	Checks       []DependencyCheck `json:"checks,omitempty"`
+	Ready        bool              `json:"ready,omitempty"`
+	ShuttingDown bool              `json:"shutting_down,omitempty"`
+}
+
+type DocumentQuarantine struct {
+	CreatedAt string `json:"created_at,omitempty"`
+	FileName  string `json:"file_name,omitempty"`
+	// Suspicious passages, JSON array
+	Findings string `json:"findings,omitempty"`
+	ID       string `json:"id,omitempty"`
+	// Unpublished entries of a knowledge import, JSON array
+	KnowledgeEntryIDS string           `json:"knowledge_entry_ids,omitempty"`
+	ReviewNote        string           `json:"review_note,omitempty"`
+	ReviewedAt        *string          `json:"reviewed_at,omitempty"`
+	ReviewedBy        *string          `json:"reviewed_by,omitempty"`
+	Source            QuarantineSource `json:"source,omitempty"`
+	Status            QuarantineStatus `json:"status,omitempty"`
+	UpdatedAt         string           `json:"updated_at,omitempty"`
+	// Set for vector store uploads
+	UploadedDocumentID *string `json:"uploaded_document_id,omitempty"`
+}
+
+type DocumentStatusResponse struct {
+	// Category of the entries of a knowledge base import
+	Category string `json:"category,omitempty"`
+	// SHA-256 of the content
+	Checksum          string  `json:"checksum,omitempty"`
+	CreatedAt         string  `json:"created_at,omitempty"`
+	DownloadExpiresAt *string `json:"download_expires_at,omitempty"`
+	// Presigned, only with object storage
+	DownloadURL string `json:"download_url,omitempty"`
+	// Error details if processing failed
+	ErrorMessage string `json:"error_message,omitempty"`
+	FileName     string `json:"file_name,omitempty"`
+	// Local path or object URL of the stored file
+	FilePath string `json:"file_path,omitempty"`
+	FileSize int    `json:"file_size,omitempty"`
+	ID       string `json:"id,omitempty"`
+	// Entries created by a knowledge base import, JSON array
+	KnowledgeEntryIDS string `json:"knowledge_entry_ids,omitempty"`
+	MimeType          string `json:"mime_type,omitempty"`
+	// OpenAI file ID from step 1
+	OpenaiFileID     string         `json:"openai_file_id,omitempty"`
+	OriginalFileName string         `json:"original_file_name,omitempty"`
+	Sections         *SectionDiff   `json:"sections,omitempty"`
+	Status           DocumentStatus `json:"status,omitempty"`
+	// Key in the file storage; empty for files stored on local disk before storage backends
+	StorageKey string `json:"storage_key,omitempty"`
+	// Earlier version of the file whose entries the import updated
+	SupersedesID *string         `json:"supersedes_id,omitempty"`
+	Target       IngestionTarget `json:"target,omitempty"`
+	UpdatedAt    string          `json:"updated_at,omitempty"`
+	UploadedBy   string          `json:"uploaded_by,omitempty"`
+	// Relations
+	Uploader *User `json:"uploader,omitempty"`
+	// Vector file ID from step 2
+	VectorFileID string `json:"vector_file_id,omitempty"`
+	// Vector store ID (fixed: vs_6873699daedc8191bb505a14254eeab3)
+	VectorStoreID string `json:"vector_store_id,omitempty"`
+}
+
+type DocumentUploadResponse struct {
+	FileName     string `json:"file_name,omitempty"`
+	ID           string `json:"id,omitempty"`
+	Message      string `json:"message,omitempty"`
+	OpenaiFileID string `json:"openai_file_id,omitempty"`
+	Status       string `json:"status,omitempty"`
+	VectorFileID string `json:"vector_file_id,omitempty"`
+}
+
+type DriveStatus struct {
+	Enabled      bool    `json:"enabled,omitempty"`
+	FailedFiles  int     `json:"failed_files,omitempty"`
+	FolderID     string  `json:"folder_id,omitempty"`
+	LastSyncedAt *string `json:"last_synced_at,omitempty"`
+	SyncedFiles  int     `json:"synced_files,omitempty"`
+}
+
+type EditMessageRequest struct {
+	Content string `json:"content,omitempty"`
+	// Provider of the new answer; empty uses the primary one
+	Provider AIProvider `json:"provider,omitempty"`
+}
+
+type EnhancedChatRequest struct {
+	// Image uploaded through /ai/attachments, e.g. a screenshot of an error
+	AttachmentID *string `json:"attachment_id,omitempty"`
+	// Optional retrieval filters, e.g. {"categories": ["Troubleshooting"]} to answer from troubleshooting articles only
+	Filters *RetrievalFilters `json:"filters,omitempty"`
+	// Optional generation overrides, limited per user role (see DefaultGenerationLimits)
+	Generation *GenerationParams `json:"generation,omitempty"`
+	// Language of the answer; empty answers in the language of the message
+	Language string `json:"language,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Persona preset the session answers as from now on (see GET /chat/personas)
+	PersonaID         *string    `json:"persona_id,omitempty"`
+	PreferredProvider AIProvider `json:"preferred_provider,omitempty"`
+	SessionID         *string    `json:"session_id,omitempty"`
+	SystemPrompt      string     `json:"system_prompt,omitempty"`
+	// Set to the signed-in user; a different user_id is rejected
+	UserID string `json:"user_id,omitempty"`
+}
+
+type EnhancedChatResponse struct {
+	Cached    bool       `json:"cached,omitempty"`
+	Citations []Citation `json:"citations,omitempty"`
+	// Confidence scores from 0 to 1 how well the knowledge base supports the answer (see AnswerConfidence).
+	// Below the configured threshold the answer says the bot does not know and SuggestEscalation is set.
+	Confidence float64 `json:"confidence,omitempty"`
+	CreatedAt  string  `json:"created_at,omitempty"`
+	// Deduplicated is set when this is the response of an identical request sent moments earlier
+	Deduplicated bool `json:"deduplicated,omitempty"`
+	// Degraded is set when every AI provider failed and the answer was built from keyword search.
+	// The question is then queued to be answered later.
+	Degraded bool `json:"degraded,omitempty"`
+	// Escalation is set while the session is handed over to a support agent, who answers in the session
+	// instead of the assistant
+	Escalation *ChatEscalation `json:"escalation,omitempty"`
+	// ISO 639-1 code of the answer's language, when known
+	Language string `json:"language,omitempty"`
+	// MessageID is the saved answer, which PATCH /chat/messages/{id} and POST /chat/messages/{id}/regenerate
+	// change; Version counts its regenerations
+	MessageID        string     `json:"message_id,omitempty"`
+	Model            string     `json:"model,omitempty"`
+	Provider         AIProvider `json:"provider,omitempty"`
+	QueuedQuestionID *string    `json:"queued_question_id,omitempty"`
+	Response         string     `json:"response,omitempty"`
+	// SeeAlso suggests entries related to the best match when the knowledge base supports the answer weakly
+	SeeAlso           []RelatedEntry `json:"see_also,omitempty"`
+	SessionID         string         `json:"session_id,omitempty"`
+	Sources           []string       `json:"sources,omitempty"`
+	SuggestEscalation bool           `json:"suggest_escalation,omitempty"`
+	Version           int            `json:"version,omitempty"`
+}
+
+type EntryViewStats struct {
+	Category         string `json:"category,omitempty"`
+	Direct           int    `json:"direct,omitempty"`
+	FromChat         int    `json:"from_chat,omitempty"`
+	FromSearch       int    `json:"from_search,omitempty"`
+	KnowledgeEntryID string `json:"knowledge_entry_id,omitempty"`
+	LastViewedAt     string `json:"last_viewed_at,omitempty"`
+	Title            string `json:"title,omitempty"`
+	UniqueUsers      int    `json:"unique_users,omitempty"`
+	Views            int    `json:"views,omitempty"`
+}
+
+type EscalateChatRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type ExperimentRun struct {
+	// Relations
+	Answers []ExperimentAnswer `json:"answers,omitempty"`
+	// Run the answers are compared with; nil when there was none
+	BaselineRunID *string `json:"baseline_run_id,omitempty"`
+	// Answers that had a baseline answer to compare with
+	ComparedCount  int     `json:"compared_count,omitempty"`
+	CompletedAt    *string `json:"completed_at,omitempty"`
+	CreatedAt      string  `json:"created_at,omitempty"`
+	CreatedBy      *string `json:"created_by,omitempty"`
+	Error          string  `json:"error,omitempty"`
+	ID             string  `json:"id,omitempty"`
+	IsGolden       bool    `json:"is_golden,omitempty"`
+	MeanSimilarity float64 `json:"mean_similarity,omitempty"`
+	MinSimilarity  float64 `json:"min_similarity,omitempty"`
+	Model          string  `json:"model,omitempty"`
+	Passed         bool    `json:"passed,omitempty"`
+	// Prompt version tried out; nil uses the active prompts
+	PromptID        *string          `json:"prompt_id,omitempty"`
+	PromptName      string           `json:"prompt_name,omitempty"`
+	PromptVersion   int              `json:"prompt_version,omitempty"`
+	Provider        string           `json:"provider,omitempty"`
+	QuestionCount   int              `json:"question_count,omitempty"`
+	RegressionCount int              `json:"regression_count,omitempty"`
+	StartedAt       *string          `json:"started_at,omitempty"`
+	Status          ExperimentStatus `json:"status,omitempty"`
+	// Answers less similar than this to the baseline answer regress
+	Threshold float64 `json:"threshold,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
+}
+
+type Feedback struct {
+	Comment    string `json:"comment,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	ID         string `json:"id,omitempty"`
+	IsResolved bool   `json:"is_resolved,omitempty"`
+	// Relations
+	Message   *ChatMessage `json:"message,omitempty"`
+	MessageID string       `json:"message_id,omitempty"`
+	Rating    int          `json:"rating,omitempty"`
+	Type      FeedbackType `json:"type,omitempty"`
+	UpdatedAt string       `json:"updated_at,omitempty"`
+	User      *User        `json:"user,omitempty"`
+	UserID    string       `json:"user_id,omitempty"`
+}
+
+type FullTextResult struct {
+	Facets *SearchFacets `json:"facets,omitempty"`
+	Hits   []FullTextHit `json:"hits,omitempty"`
+}
+
+type GetAvailableProvidersResult struct {
+	Providers []string `json:"providers,omitempty"`
+}
+
+type GetLeaderboardResult struct {
+	Count       int                `json:"count,omitempty"`
+	Leaderboard []ContributorStats `json:"leaderboard,omitempty"`
+	Sort        string             `json:"sort,omitempty"`
+}
+
+type GetProviderHealthResult struct {
+	Providers []ProviderHealth `json:"providers,omitempty"`
+}
+
+type GetThreadMessagesResult struct {
+	Count       int                `json:"count,omitempty"`
+	HasMore     bool               `json:"has_more,omitempty"`
+	Messages    []AssistantMessage `json:"messages,omitempty"`
+	NextCursor  string             `json:"next_cursor,omitempty"`
+	PrevCursor  string             `json:"prev_cursor,omitempty"`
+	RetrievedAt string             `json:"retrieved_at,omitempty"`
+	ThreadID    string             `json:"thread_id,omitempty"`
+}
+
+type GoldenQuestion struct {
+	CreatedAt string  `json:"created_at,omitempty"`
+	CreatedBy *string `json:"created_by,omitempty"`
+	ID        string  `json:"id,omitempty"`
+	Notes     string  `json:"notes,omitempty"`
+	Question  string  `json:"question,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
+}
+
+type HandoffAgentRequest struct {
+	AgentID string `json:"agent_id,omitempty"`
+}
+
+type HandoffMessageRequest struct {
+	AgentID string `json:"agent_id,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type HealthCheckResult struct {
+	Engine    string `json:"engine,omitempty"`
+	Service   string `json:"service,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+type HelpContext struct {
+	Entries []KnowledgeEntry `json:"entries,omitempty"`
+	// Screen whose help was found, the requested one or a parent
+	MatchedScreenID string `json:"matched_screen_id,omitempty"`
+	// Requested screen
+	ScreenID        string   `json:"screen_id,omitempty"`
+	Tips            []string `json:"tips,omitempty"`
+	TipsGeneratedAt *string  `json:"tips_generated_at,omitempty"`
+	Title           string   `json:"title,omitempty"`
+}
+
+type HelpScreen struct {
+	CreatedAt string `json:"created_at,omitempty"`
+	// What the screen is for, given to the AI when writing tips
+	Description string `json:"description,omitempty"`
+	// Relations
+	Entries  []HelpScreenEntry `json:"entries,omitempty"`
+	ScreenID string            `json:"screen_id,omitempty"`
+	// AI-generated quick tips, JSON array
+	Tips            string  `json:"tips,omitempty"`
+	TipsGeneratedAt *string `json:"tips_generated_at,omitempty"`
+	Title           string  `json:"title,omitempty"`
+	UpdatedAt       string  `json:"updated_at,omitempty"`
+	UpdatedBy       *string `json:"updated_by,omitempty"`
+}
+
+type ImpersonationGrant struct {
+	Session *ImpersonationSession `json:"session,omitempty"`
+	Token   string                `json:"token,omitempty"`
+}
+
+type ImportConfigRequest struct {
+	AdminID string        `json:"admin_id,omitempty"`
+	Bundle  *ConfigBundle `json:"bundle,omitempty"`
+}
+
+type IngestURLRequest struct {
+	CategoryName string `json:"category_name,omitempty"`
+	// Re-crawl on this schedule to keep the entries fresh; 0 crawls once
+	RecrawlHours int `json:"recrawl_hours,omitempty"`
+	// Crawl the pages the sitemap lists on the same host
+	Sitemap bool   `json:"sitemap,omitempty"`
+	URL     string `json:"url,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+}
+
+type Job struct {
+	Attempts    int       `json:"attempts,omitempty"`
+	CompletedAt *string   `json:"completed_at,omitempty"`
+	CreatedAt   string    `json:"created_at,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LockedAt    *string   `json:"locked_at,omitempty"`
+	LockedBy    string    `json:"locked_by,omitempty"`
+	MaxAttempts int       `json:"max_attempts,omitempty"`
+	Payload     string    `json:"payload,omitempty"`
+	Queue       string    `json:"queue,omitempty"`
+	RunAt       string    `json:"run_at,omitempty"`
+	Status      JobStatus `json:"status,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	UpdatedAt   string    `json:"updated_at,omitempty"`
+}
+
+type JobDashboard struct {
+	AliveWorkers int            `json:"alive_workers,omitempty"`
+	GeneratedAt  string         `json:"generated_at,omitempty"`
+	Queues       []QueueHealth  `json:"queues,omitempty"`
+	WindowHours  int            `json:"window_hours,omitempty"`
+	Workers      []WorkerHealth `json:"workers,omitempty"`
+}
+
+type JobQueuePause struct {
+	CreatedAt string `json:"created_at,omitempty"`
+	PausedBy  string `json:"paused_by,omitempty"`
+	Queue     string `json:"queue,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type JobQueuedResponse struct {
+	JobID   string `json:"job_id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type KnowledgeEntry struct {
+	// comma-separated roles; empty means visible to every role
+	AllowedRoles string `json:"allowed_roles,omitempty"`
+	// comma-separated teams; empty means visible to every team
+	AllowedTeams string          `json:"allowed_teams,omitempty"`
+	Category     string          `json:"category,omitempty"`
+	Complexity   ComplexityLevel `json:"complexity,omitempty"`
+	Content      string          `json:"content,omitempty"`
+	CreatedAt    string          `json:"created_at,omitempty"`
+	CreatedBy    string          `json:"created_by,omitempty"`
+	Creator      *User           `json:"creator,omitempty"`
+	// JSON data for template fields
+	FieldData   string `json:"field_data,omitempty"`
+	ID          string `json:"id,omitempty"`
+	IsPublished bool   `json:"is_published,omitempty"`
+	// ISO 639-1 code; empty when it could not be detected
+	Language string `json:"language,omitempty"`
+	// Stale and waiting for its creator to confirm or update it
+	NeedsReview bool `json:"needs_review,omitempty"`
+	Priority    int  `json:"priority,omitempty"`
+	// Publishes the entry at this time; it stays unpublished until then
+	PublishAt *string `json:"publish_at,omitempty"`
+	// Flesch reading ease, 0 (hardest) to 100 (easiest)
+	ReadabilityScore   float64 `json:"readability_score,omitempty"`
+	ReadingTimeSeconds int     `json:"reading_time_seconds,omitempty"`
+	// AI-written phrasings of the questions the entry answers, JSON array
+	RelatedQuestions string `json:"related_questions,omitempty"`
+	// When the creator was last asked to review the entry
+	ReviewRemindedAt *string `json:"review_reminded_at,omitempty"`
+	// JSON of the section options an imported section was split with, for tuning them
+	SectionOptions string            `json:"section_options,omitempty"`
+	SourceDocument *UploadedDocument `json:"source_document,omitempty"`
+	// Uploaded document the entry was imported from
+	SourceDocumentID *string `json:"source_document_id,omitempty"`
+	// When stale-entry detection found the entry unchanged for too long
+	StaleAt *string `json:"stale_at,omitempty"`
+	// Category the AI suggested for an imported section, when it differs
+	SuggestedCategory string `json:"suggested_category,omitempty"`
+	Summary           string `json:"summary,omitempty"`
+	// JSON array of tags
+	Tags string `json:"tags,omitempty"`
+	// Relations
+	Template   *Template `json:"template,omitempty"`
+	TemplateID *string   `json:"template_id,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	// Unpublishes the entry at this time, e.g. the end of a holiday workflow
+	UnpublishAt *string `json:"unpublish_at,omitempty"`
+	UpdatedAt   string  `json:"updated_at,omitempty"`
+	UpdatedBy   *string `json:"updated_by,omitempty"`
+	Updater     *User   `json:"updater,omitempty"`
+	ViewCount   int     `json:"view_count,omitempty"`
+}
+
+type ListAuditResult struct {
+	Entries []ImpersonationAuditLog `json:"entries,omitempty"`
+	Limit   int                     `json:"limit,omitempty"`
+	Offset  int                     `json:"offset,omitempty"`
+	Total   int                     `json:"total,omitempty"`
+}
+
+type ListContextFilesResult struct {
+	Files []ListContextFilesResultFiles `json:"files,omitempty"`
+}
+
+type ListDocumentsRequest struct {
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+	UploadedBy string `json:"uploaded_by,omitempty"`
+}
+
+type ListDocumentsResult struct {
+	Documents []UploadedDocument `json:"documents,omitempty"`
+	Limit     int                `json:"limit,omitempty"`
+	Offset    int                `json:"offset,omitempty"`
+	Total     int                `json:"total,omitempty"`
+}
+
+type ListEscalationsResult struct {
+	Escalations []ChatEscalation `json:"escalations,omitempty"`
+	Limit       int              `json:"limit,omitempty"`
+	Offset      int              `json:"offset,omitempty"`
+	Total       int              `json:"total,omitempty"`
+}
+
+type ListEventsResult struct {
+	Events []ModerationEvent `json:"events,omitempty"`
+	Limit  int               `json:"limit,omitempty"`
+	Offset int               `json:"offset,omitempty"`
+	Total  int               `json:"total,omitempty"`
+}
+
+type ListExperimentRunsResult struct {
+	Count int             `json:"count,omitempty"`
+	Runs  []ExperimentRun `json:"runs,omitempty"`
+}
+
+type ListJobsResult struct {
+	Jobs   []Job `json:"jobs,omitempty"`
+	Limit  int   `json:"limit,omitempty"`
+	Offset int   `json:"offset,omitempty"`
+	Total  int   `json:"total,omitempty"`
+}
+
+type ListPairsResult struct {
+	Limit  int                 `json:"limit,omitempty"`
+	Offset int                 `json:"offset,omitempty"`
+	Pairs  []RetrievalEvalPair `json:"pairs,omitempty"`
+	Total  int                 `json:"total,omitempty"`
+}
+
+type ListQuarantineResult struct {
+	Documents []DocumentQuarantine `json:"documents,omitempty"`
+	Limit     int                  `json:"limit,omitempty"`
+	Offset    int                  `json:"offset,omitempty"`
+	Total     int                  `json:"total,omitempty"`
+}
+
+type ListRetentionPoliciesResult struct {
+	Policies []ChatRetentionPolicy `json:"policies,omitempty"`
+}
+
+type ListRetrievalEvalRunsResult struct {
+	Count int                `json:"count,omitempty"`
+	Runs  []RetrievalEvalRun `json:"runs,omitempty"`
+}
+
+type ListReviewPoliciesResult struct {
+	DefaultMaxAgeDays int                    `json:"default_max_age_days,omitempty"`
+	Policies          []CategoryReviewPolicy `json:"policies,omitempty"`
+}
+
+type ListSchedulesResult struct {
+	Schedules []ScheduledJob `json:"schedules,omitempty"`
+}
+
+type ListStaleEntriesResult struct {
+	Entries []KnowledgeEntry `json:"entries,omitempty"`
+	Limit   int              `json:"limit,omitempty"`
+	Offset  int              `json:"offset,omitempty"`
+	Total   int              `json:"total,omitempty"`
+}
+
+type ListTasksResult struct {
+	Tasks []ScheduledTask `json:"tasks,omitempty"`
+}
+
+type ListTrackedChatLogsResult struct {
+	Logs []TrackedChatLog `json:"logs,omitempty"`
+}
+
+type ListTrashResult struct {
+	Items         []TrashItem `json:"items,omitempty"`
+	Limit         int         `json:"limit,omitempty"`
+	Offset        int         `json:"offset,omitempty"`
+	RetentionDays int         `json:"retention_days,omitempty"`
+	Total         int         `json:"total,omitempty"`
+}
+
+type ListUploadedFilesResult struct {
+	Files []ListUploadedFilesResultFiles `json:"files,omitempty"`
+}
+
+type LogoutResult struct {
+	Message string `json:"message,omitempty"`
+}
+
+type ManagedAssistant struct {
+	CreatedAt         string `json:"created_at,omitempty"`
+	CreatedBy         string `json:"created_by,omitempty"`
+	Description       string `json:"description,omitempty"`
+	ID                string `json:"id,omitempty"`
+	Instructions      string `json:"instructions,omitempty"`
+	Model             string `json:"model,omitempty"`
+	Name              string `json:"name,omitempty"`
+	OpenaiAssistantID string `json:"openai_assistant_id,omitempty"`
+	PromptName        string `json:"prompt_name,omitempty"`
+	// Version rendered into the instructions; 0 is the built-in prompt
+	PromptVersion int    `json:"prompt_version,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+	UpdatedBy     string `json:"updated_by,omitempty"`
+	// JSON array
+	VectorStoreIDS string `json:"vector_store_ids,omitempty"`
+}
+
+type MergeEntriesRequest struct {
+	EntryIDS []string `json:"entry_ids,omitempty"`
+	// Entry that is kept; defaults to the first one
+	TargetID *string `json:"target_id,omitempty"`
+	// Title of the merged entry; defaults to the target's
+	Title  string `json:"title,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+}
+
+type MergeResult struct {
+	Entry     *KnowledgeEntry `json:"entry,omitempty"`
+	MergedIDS []string        `json:"merged_ids,omitempty"`
+}
+
+type Meta struct {
+	Limit      int `json:"limit,omitempty"`
+	Page       int `json:"page,omitempty"`
+	Total      int `json:"total,omitempty"`
+	TotalPages int `json:"total_pages,omitempty"`
+}
+
+type NotionStatus struct {
+	Category string `json:"category,omitempty"`
+	// Empty syncs every page shared with the integration
+	DatabaseIDS  []string `json:"database_ids,omitempty"`
+	Enabled      bool     `json:"enabled,omitempty"`
+	FailedPages  int      `json:"failed_pages,omitempty"`
+	LastSyncedAt *string  `json:"last_synced_at,omitempty"`
+	SyncedPages  int      `json:"synced_pages,omitempty"`
+}
+
+type ParseDocumentResponse struct {
+	Message string               `json:"message,omitempty"`
+	Result  *DocumentParseResult `json:"result,omitempty"`
+}
+
+type PersonaPreset struct {
+	CreatedAt    string `json:"created_at,omitempty"`
+	CreatedBy    string `json:"created_by,omitempty"`
+	Description  string `json:"description,omitempty"`
+	ID           string `json:"id,omitempty"`
+	IsActive     bool   `json:"is_active,omitempty"`
+	Name         string `json:"name,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+	UpdatedBy    string `json:"updated_by,omitempty"`
+}
+
+type PersonaRequest struct {
+	AdminID     string `json:"admin_id,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Inactive presets can no longer be picked and stop applying to their sessions
+	IsActive     *bool  `json:"is_active,omitempty"`
+	Name         string `json:"name,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+type ProcessDocumentRequest struct {
+	// Process in the background job queue; follow it with GET /documents/{id}/status
+	Async        bool   `json:"async,omitempty"`
+	CategoryName string `json:"category_name,omitempty"`
+	// Only return the entries the import would create, to confirm with POST /documents/process/confirm
+	DryRun   bool   `json:"dry_run,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+}
+
+type ProcessDocumentResponse struct {
+	DocumentID string               `json:"document_id,omitempty"`
+	Message    string               `json:"message,omitempty"`
+	Preview    *DocumentPreview     `json:"preview,omitempty"`
+	Result     *DocumentParseResult `json:"result,omitempty"`
+	Status     string               `json:"status,omitempty"`
+}
+
+type PromptTemplate struct {
+	Content     string  `json:"content,omitempty"`
+	CreatedAt   string  `json:"created_at,omitempty"`
+	CreatedBy   *string `json:"created_by,omitempty"`
+	Description string  `json:"description,omitempty"`
+	ID          string  `json:"id,omitempty"`
+	IsActive    bool    `json:"is_active,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	UpdatedAt   string  `json:"updated_at,omitempty"`
+	// JSON object of variable names and their default values
+	Variables string `json:"variables,omitempty"`
+	Version   int    `json:"version,omitempty"`
+}
+
+type PromptTemplateSpec struct {
+	Content     string `json:"content,omitempty"`
+	Description string `json:"description,omitempty"`
+	IsActive    bool   `json:"is_active,omitempty"`
+	// Variable names and their default values
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type QueueActionRequest struct {
+	AdminID string `json:"admin_id,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type QueuedQuestion struct {
+	AnsweredAt *string `json:"answered_at,omitempty"`
+	CreatedAt  string  `json:"created_at,omitempty"`
+	ID         string  `json:"id,omitempty"`
+	LastError  string  `json:"last_error,omitempty"`
+	// The user message that asked the question
+	MessageID *string `json:"message_id,omitempty"`
+	Question  string  `json:"question,omitempty"`
+	// Why the question was queued, e.g. providers_unavailable
+	Reason    string               `json:"reason,omitempty"`
+	SessionID string               `json:"session_id,omitempty"`
+	Status    QueuedQuestionStatus `json:"status,omitempty"`
+	UpdatedAt string               `json:"updated_at,omitempty"`
+	UserID    string               `json:"user_id,omitempty"`
+}
+
+type QuotaOverrideDeleteRequest struct {
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+type QuotaOverrideRequest struct {
+	MonthlyCostUsd float64 `json:"monthly_cost_usd,omitempty"`
+	MonthlyTokens  int     `json:"monthly_tokens,omitempty"`
+	Note           string  `json:"note,omitempty"`
+	UpdatedBy      string  `json:"updated_by,omitempty"`
+}
+
+type QuotaStatus struct {
+	Org         *QuotaUsage `json:"org,omitempty"`
+	PeriodStart string      `json:"period_start,omitempty"`
+	ResetsAt    string      `json:"resets_at,omitempty"`
+	User        *QuotaUsage `json:"user,omitempty"`
+	UserID      string      `json:"user_id,omitempty"`
+}
+
+type ReadinessReport struct {
+	ActiveTemplates       int                `json:"active_templates,omitempty"`
+	Categories            []CategoryCoverage `json:"categories,omitempty"`
+	Checks                []ReadinessCheck   `json:"checks,omitempty"`
+	EmbeddingCoverage     float64            `json:"embedding_coverage,omitempty"`
+	EntriesWithEmbeddings int                `json:"entries_with_embeddings,omitempty"`
+	GeneratedAt           string             `json:"generated_at,omitempty"`
+	PendingEmbeddingJobs  int                `json:"pending_embedding_jobs,omitempty"`
+	ProposedTemplates     int                `json:"proposed_templates,omitempty"`
+	Providers             []AIProvider       `json:"providers,omitempty"`
+	PublishedEntries      int                `json:"published_entries,omitempty"`
+	Ready                 bool               `json:"ready,omitempty"`
+	Score                 int                `json:"score,omitempty"`
+	TopicCount            int                `json:"topic_count,omitempty"`
+}
+
+type RegenerateAnswerRequest struct {
+	// Provider of the new answer; empty uses the primary one
+	Provider AIProvider `json:"provider,omitempty"`
+}
+
+type RelatedEntry struct {
+	Category string `json:"category,omitempty"`
+	ID       string `json:"id,omitempty"`
+	// Cosine similarity of the best matching chunk
+	Score   float64 `json:"score,omitempty"`
+	Summary string  `json:"summary,omitempty"`
+	Title   string  `json:"title,omitempty"`
+}
+
+type ResumeQueueResult struct {
+	Message string `json:"message,omitempty"`
+	Queue   string `json:"queue,omitempty"`
+}
+
+type ResyncDocumentResult struct {
+	FileName string         `json:"file_name,omitempty"`
+	ID       string         `json:"id,omitempty"`
+	Message  string         `json:"message,omitempty"`
+	Status   DocumentStatus `json:"status,omitempty"`
+}
+
+type RetentionAdminRequest struct {
+	AdminID string `json:"admin_id,omitempty"`
+}
+
+type RetrievalEvalPair struct {
+	CreatedAt string `json:"created_at,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+	// Relations
+	ExpectedEntry   *KnowledgeEntry `json:"expected_entry,omitempty"`
+	ExpectedEntryID string          `json:"expected_entry_id,omitempty"`
+	ID              string          `json:"id,omitempty"`
+	Notes           string          `json:"notes,omitempty"`
+	Question        string          `json:"question,omitempty"`
+	UpdatedAt       string          `json:"updated_at,omitempty"`
+}
+
+type RetrievalEvalRun struct {
+	// Pairs answered through the chat path and judged; 0 for retrieval-only runs
+	AnswerCount int     `json:"answer_count,omitempty"`
+	CompletedAt *string `json:"completed_at,omitempty"`
+	// Retrieval settings in effect for the run
+	Config    string `json:"config,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ID        string `json:"id,omitempty"`
+	// Over pairs whose expected entry was retrieved
+	MeanExpectedScore float64 `json:"mean_expected_score,omitempty"`
+	// LLM judge score from 0 to 1 of how well answers stick to the retrieved passages
+	MeanFaithfulness float64 `json:"mean_faithfulness,omitempty"`
+	MeanTopScore     float64 `json:"mean_top_score,omitempty"`
+	Mrr              float64 `json:"mrr,omitempty"`
+	PairCount        int     `json:"pair_count,omitempty"`
+	RecallAt1        float64 `json:"recall_at_1,omitempty"`
+	RecallAt10       float64 `json:"recall_at_10,omitempty"`
+	RecallAt3        float64 `json:"recall_at_3,omitempty"`
+	RecallAt5        float64 `json:"recall_at_5,omitempty"`
+	// Relations
+	Results           []RetrievalEvalResult `json:"results,omitempty"`
+	ScoreDistribution string                `json:"score_distribution,omitempty"`
+	StartedAt         string                `json:"started_at,omitempty"`
+	Status            RetrievalEvalStatus   `json:"status,omitempty"`
+	// scheduled or manual
+	Trigger string `json:"trigger,omitempty"`
+}
+
+type RetrievalPreset struct {
+	// 0 uses the configured default
+	ChunkMaxTokens int `json:"chunk_max_tokens,omitempty"`
+	// Tokens shared by consecutive chunks
+	ChunkOverlapTokens int     `json:"chunk_overlap_tokens,omitempty"`
+	CreatedAt          string  `json:"created_at,omitempty"`
+	CreatedBy          *string `json:"created_by,omitempty"`
+	Description        string  `json:"description,omitempty"`
+	ID                 string  `json:"id,omitempty"`
+	Name               string  `json:"name,omitempty"`
+	// Vector hits scoring below this are dropped
+	ScoreThreshold float64 `json:"score_threshold,omitempty"`
+	// Most entries of the category returned by one search; 0 means no cap
+	TopK      int    `json:"top_k,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type RetrievalPresetAssignments struct {
+	Categories []CategoryRetrievalPreset  `json:"categories,omitempty"`
+	Templates  []TemplatePresetAssignment `json:"templates,omitempty"`
+}
+
+type RetrievalPresetSpec struct {
+	ChunkMaxTokens     int     `json:"chunk_max_tokens,omitempty"`
+	ChunkOverlapTokens int     `json:"chunk_overlap_tokens,omitempty"`
+	Description        string  `json:"description,omitempty"`
+	Name               string  `json:"name,omitempty"`
+	ScoreThreshold     float64 `json:"score_threshold,omitempty"`
+	TopK               int     `json:"top_k,omitempty"`
+}
+
+type ReviewQuarantineRequest struct {
+	AdminID string `json:"admin_id,omitempty"`
+	Note    string `json:"note,omitempty"`
+}
+
+type RunPolicyResult struct {
+	Accepted *JobQueuedResponse `json:"Accepted,omitempty"`
+	OK       *RetentionReport   `json:"OK,omitempty"`
+}
+
+type RunWaitResult struct {
+	// False when the wait timed out first; poll again
+	Done      bool          `json:"done,omitempty"`
+	LastError *RunLastError `json:"last_error,omitempty"`
+	// Messages of a completed run
+	Messages       []AssistantMessage `json:"messages,omitempty"`
+	RequiredAction *RunRequiredAction `json:"required_action,omitempty"`
+	RunID          string             `json:"run_id,omitempty"`
+	Status         string             `json:"status,omitempty"`
+	ThreadID       string             `json:"thread_id,omitempty"`
+	WaitedMs       int                `json:"waited_ms,omitempty"`
+}
+
+type RuntimeSetting struct {
+	// From the environment
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+	Key         string `json:"key,omitempty"`
+	// Value was set by an admin
+	Overridden bool    `json:"overridden,omitempty"`
+	UpdatedAt  *string `json:"updated_at,omitempty"`
+	UpdatedBy  *string `json:"updated_by,omitempty"`
+	Value      string  `json:"value,omitempty"`
+}
+
+type SSOLogin struct {
+	// The user was provisioned by this login
+	Created   bool   `json:"created,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Token     string `json:"token,omitempty"`
+	User      *User  `json:"user,omitempty"`
+}
+
+type ScheduleAdminRequest struct {
+	AdminID string `json:"admin_id,omitempty"`
+}
+
+type ScheduleRequest struct {
+	AdminID        string `json:"admin_id,omitempty"`
+	CronExpression string `json:"cron_expression,omitempty"`
+	// Defaults to true
+	IsActive *bool  `json:"is_active,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Task     string `json:"task,omitempty"`
+}
+
+type ScheduledJob struct {
+	CreatedAt string `json:"created_at,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+	// Five fields or a descriptor such as @daily, in UTC unless prefixed with CRON_TZ=
+	CronExpression string  `json:"cron_expression,omitempty"`
+	ID             string  `json:"id,omitempty"`
+	IsActive       bool    `json:"is_active,omitempty"`
+	LastDurationMs int     `json:"last_duration_ms,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	LastJobID      *string `json:"last_job_id,omitempty"`
+	// What the task reported as JSON, e.g. a sync report
+	LastResult string            `json:"last_result,omitempty"`
+	LastRunAt  *string           `json:"last_run_at,omitempty"`
+	LastStatus ScheduleRunStatus `json:"last_status,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	// Nil while inactive
+	NextRunAt *string `json:"next_run_at,omitempty"`
+	Task      string  `json:"task,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
+}
+
+type SessionPersonaRequest struct {
+	PersonaID *string `json:"persona_id,omitempty"`
+}
+
+type SessionUsage struct {
+	AssistantReplies int             `json:"assistant_replies,omitempty"`
+	CachedReplies    int             `json:"cached_replies,omitempty"`
+	CompletionTokens int             `json:"completion_tokens,omitempty"`
+	EstimatedCostUsd float64         `json:"estimated_cost_usd,omitempty"`
+	PromptTokens     int             `json:"prompt_tokens,omitempty"`
+	Providers        []ProviderUsage `json:"providers,omitempty"`
+	RetrievalCount   int             `json:"retrieval_count,omitempty"`
+	SessionID        string          `json:"session_id,omitempty"`
+	Teams            []string        `json:"teams,omitempty"`
+	TotalTokens      int             `json:"total_tokens,omitempty"`
+	UserID           string          `json:"user_id,omitempty"`
+	UserMessages     int             `json:"user_messages,omitempty"`
+}
+
+type SetHelpScreenRequest struct {
+	Description string `json:"description,omitempty"`
+	// Knowledge entries in display order
+	EntryIDS  []string `json:"entry_ids,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	UpdatedBy string   `json:"updated_by,omitempty"`
+}
+
+type SetPolicyResult struct {
+	Policy *CategoryReviewPolicy `json:"policy,omitempty"`
+}
+
+type SetPrimaryProviderRequest struct {
+	Provider string `json:"provider,omitempty"`
+}
+
+type SetPrimaryProviderResult struct {
+	Message string `json:"message,omitempty"`
+	Success bool   `json:"success,omitempty"`
+}
+
+type SetReviewPolicyRequest struct {
+	// 0 never flags the category; null restores the default
+	MaxAgeDays *int `json:"max_age_days,omitempty"`
+}
+
+type SplitEntryRequest struct {
+	// Sections to create; empty uses the AI's proposal
+	Sections []SplitSection `json:"sections,omitempty"`
+	UserID   string         `json:"user_id,omitempty"`
+}
+
+type SplitEntryResult struct {
+	DryRun   bool             `json:"dry_run,omitempty"`
+	Entries  []KnowledgeEntry `json:"entries,omitempty"`
+	Sections []SplitSection   `json:"sections,omitempty"`
+}
+
+type StartExperimentRequest struct {
+	BaselineRunID string  `json:"baseline_run_id,omitempty"`
+	CreatedBy     string  `json:"created_by,omitempty"`
+	Model         string  `json:"model,omitempty"`
+	PromptID      string  `json:"prompt_id,omitempty"`
+	Provider      string  `json:"provider,omitempty"`
+	Threshold     float64 `json:"threshold,omitempty"`
+}
+
+type StartExperimentRunResult struct {
+	JobID   string         `json:"job_id,omitempty"`
+	Message string         `json:"message,omitempty"`
+	Run     *ExperimentRun `json:"run,omitempty"`
+}
+
+type StartImpersonationRequest struct {
+	Reason       string `json:"reason,omitempty"`
+	TargetUserID string `json:"target_user_id,omitempty"`
+}
+
+type SystemStatus struct {
+	AIProviders  []ProviderHealth  `json:"ai_providers,omitempty"`
+	Connectors   []ConnectorStatus `json:"connectors,omitempty"`
+	Dependencies *DependencyStatus `json:"dependencies,omitempty"`
+	Documents    *DocumentBacklog  `json:"documents,omitempty"`
+	GeneratedAt  string            `json:"generated_at,omitempty"`
+	Jobs         *JobsSummary      `json:"jobs,omitempty"`
+	// Database, Qdrant and at least one AI provider reachable
+	Ready    bool            `json:"ready,omitempty"`
+	VectorDb *VectorDBStatus `json:"vector_db,omitempty"`
+}
+
+type Template struct {
+	Category  string `json:"category,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+	// Relations
+	Creator     *User           `json:"creator,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Fields      []TemplateField `json:"fields,omitempty"`
+	ID          string          `json:"id,omitempty"`
+	IsActive    bool            `json:"is_active,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	// Overrides the category's retrieval preset
+	RetrievalPresetID *string `json:"retrieval_preset_id,omitempty"`
+	UpdatedAt         string  `json:"updated_at,omitempty"`
+}
+
+type TopicCoverageReport struct {
+	GeneratedAt           string          `json:"generated_at,omitempty"`
+	Since                 *string         `json:"since,omitempty"`
+	Topics                []TopicCoverage `json:"topics,omitempty"`
+	TotalQuestions        int             `json:"total_questions,omitempty"`
+	UnclassifiedQuestions int             `json:"unclassified_questions,omitempty"`
+}
+
+type TrashAdminRequest struct {
+	AdminID string `json:"admin_id,omitempty"`
+}
+
+type TrashItem struct {
+	DeletedAt string `json:"deleted_at,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Label     string `json:"label,omitempty"`
+	// When the record is permanently deleted; unset for records kept
+	PurgeAt    *string `json:"purge_at,omitempty"`
+	Restorable bool    `json:"restorable,omitempty"`
+	Type       string  `json:"type,omitempty"`
+}
+
+type UnansweredCluster struct {
+	Count int `json:"count,omitempty"`
+	// Other wordings, most asked first
+	Examples    []string `json:"examples,omitempty"`
+	LastAskedAt string   `json:"last_asked_at,omitempty"`
+	// Chat answers rated as unhelpful
+	NegativeFeedback int `json:"negative_feedback,omitempty"`
+	// Searches that found no entries
+	NoResults int `json:"no_results,omitempty"`
+	// Most asked wording
+	Question    string `json:"question,omitempty"`
+	UniqueUsers int    `json:"unique_users,omitempty"`
+}
+
+type UnclickedEntry struct {
+	Category         string `json:"category,omitempty"`
+	KnowledgeEntryID string `json:"knowledge_entry_id,omitempty"`
+	LastRetrievedAt  string `json:"last_retrieved_at,omitempty"`
+	// Chat answers the entry was a source of
+	Retrievals int    `json:"retrievals,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+type UpdateSettingsRequest struct {
+	AdminID string `json:"admin_id,omitempty"`
+	// null resets a setting to its environment default
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+type UploadContextFileResult struct {
+	File    *UploadContextFileResultFile `json:"file,omitempty"`
+	Message string                       `json:"message,omitempty"`
+}
+
+type UploadFilesResult struct {
+	Count      int      `json:"count,omitempty"`
+	Duplicates []string `json:"duplicates,omitempty"`
+	Message    string   `json:"message,omitempty"`
+}
+
+type UploadedDocument struct {
+	// Category of the entries of a knowledge base import
+	Category string `json:"category,omitempty"`
+	// SHA-256 of the content
+	Checksum  string `json:"checksum,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	// Error details if processing failed
+	ErrorMessage string `json:"error_message,omitempty"`
+	FileName     string `json:"file_name,omitempty"`
+	// Local path or object URL of the stored file
+	FilePath string `json:"file_path,omitempty"`
+	FileSize int    `json:"file_size,omitempty"`
+	ID       string `json:"id,omitempty"`
+	// Entries created by a knowledge base import, JSON array
+	KnowledgeEntryIDS string `json:"knowledge_entry_ids,omitempty"`
+	MimeType          string `json:"mime_type,omitempty"`
+	// OpenAI file ID from step 1
+	OpenaiFileID     string         `json:"openai_file_id,omitempty"`
+	OriginalFileName string         `json:"original_file_name,omitempty"`
+	Sections         *SectionDiff   `json:"sections,omitempty"`
+	Status           DocumentStatus `json:"status,omitempty"`
+	// Key in the file storage; empty for files stored on local disk before storage backends
+	StorageKey string `json:"storage_key,omitempty"`
+	// Earlier version of the file whose entries the import updated
+	SupersedesID *string         `json:"supersedes_id,omitempty"`
+	Target       IngestionTarget `json:"target,omitempty"`
+	UpdatedAt    string          `json:"updated_at,omitempty"`
+	UploadedBy   string          `json:"uploaded_by,omitempty"`
+	// Relations
+	Uploader *User `json:"uploader,omitempty"`
+	// Vector file ID from step 2
+	VectorFileID string `json:"vector_file_id,omitempty"`
+	// Vector store ID (fixed: vs_6873699daedc8191bb505a14254eeab3)
+	VectorStoreID string `json:"vector_store_id,omitempty"`
+}
+
+type UsageQuota struct {
+	CreatedAt           string  `json:"created_at,omitempty"`
+	ID                  string  `json:"id,omitempty"`
+	MonthlyCostLimitUsd float64 `json:"monthly_cost_limit_usd,omitempty"`
+	MonthlyTokenLimit   int     `json:"monthly_token_limit,omitempty"`
+	Note                string  `json:"note,omitempty"`
+	UpdatedAt           string  `json:"updated_at,omitempty"`
+	UpdatedBy           string  `json:"updated_by,omitempty"`
+	UserID              *string `json:"user_id,omitempty"`
+}
+
+type UsageReport struct {
+	ByDay       []DailyUsage `json:"by_day,omitempty"`
+	ByTopic     []TopicUsage `json:"by_topic,omitempty"`
+	ByUser      []UserUsage  `json:"by_user,omitempty"`
+	GeneratedAt string       `json:"generated_at,omitempty"`
+	Since       *string      `json:"since,omitempty"`
+	Totals      *UsageTotals `json:"totals,omitempty"`
+	Until       *string      `json:"until,omitempty"`
+}
+
+type User struct {
+	CreatedAt string   `json:"created_at,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	ID        string   `json:"id,omitempty"`
+	IsActive  bool     `json:"is_active,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Role      UserRole `json:"role,omitempty"`
+	// comma-separated team names
+	Teams     string `json:"teams,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type WebSource struct {
+	Category     string `json:"category,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+	CreatedBy    string `json:"created_by,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	ID           string `json:"id,omitempty"`
+	// Crawl the pages the sitemap lists instead of the URL itself
+	IsSitemap     bool    `json:"is_sitemap,omitempty"`
+	LastCrawledAt *string `json:"last_crawled_at,omitempty"`
+	// Crawled pages with their content hash and entries, JSON array
+	Pages string `json:"pages,omitempty"`
+	// 0 crawls once
+	RecrawlHours int             `json:"recrawl_hours,omitempty"`
+	Status       WebSourceStatus `json:"status,omitempty"`
+	UpdatedAt    string          `json:"updated_at,omitempty"`
+	URL          string          `json:"url,omitempty"`
+}
+
+type WebhookPingResult struct {
+	Status string `json:"status,omitempty"`
+}
+
+type ZeroResultQuery struct {
+	LastSearchedAt string `json:"last_searched_at,omitempty"`
+	Query          string `json:"query,omitempty"`
+	Searches       int    `json:"searches,omitempty"`
+	UniqueUsers    int    `json:"unique_users,omitempty"`
+}
+
+type AIProvider string
+
+const (
+	AIProviderOpenai AIProvider = "openai"
+	AIProviderGemini AIProvider = "gemini"
+	AIProviderOllama AIProvider = "ollama"
+)
+
+type AssistantMessage struct {
+	Content   []MessageContent       `json:"content,omitempty"`
+	CreatedAt int                    `json:"created_at,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Role      string                 `json:"role,omitempty"`
+	RunID     string                 `json:"run_id,omitempty"`
+}
+
+type BootstrapDocument struct {
+	Category string `json:"category,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Title    string `json:"title,omitempty"`
+}
+
+type BootstrapSeedResult struct {
+	Category string   `json:"category,omitempty"`
+	EntryIDS []string `json:"entry_ids,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Kind     string   `json:"kind,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Topics   []string `json:"topics,omitempty"`
+}
+
+type CategoryConfig struct {
+	Category        string `json:"category,omitempty"`
+	RetrievalPreset string `json:"retrieval_preset,omitempty"`
+}
+
+type CategoryCoverage struct {
+	Category string `json:"category,omitempty"`
+	Entries  int    `json:"entries,omitempty"`
+}
+
+type CategoryRetrievalPreset struct {
+	Category  string `json:"category,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	PresetID  string `json:"preset_id,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type CategoryReviewPolicy struct {
+	Category  string `json:"category,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	// 0 never flags the category's entries
+	MaxAgeDays int    `json:"max_age_days,omitempty"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+}
+
+type Citation struct {
+	ChunkText        string `json:"chunk_text,omitempty"`
+	EndOffset        int    `json:"end_offset,omitempty"`
+	Index            int    `json:"index,omitempty"`
+	KnowledgeEntryID string `json:"knowledge_entry_id,omitempty"`
+	// Related question of the entry that matched the query
+	MatchedQuestion string  `json:"matched_question,omitempty"`
+	Score           float64 `json:"score,omitempty"`
+	StartOffset     int     `json:"start_offset,omitempty"`
+	Title           string  `json:"title,omitempty"`
+}
+
+type ComplexityLevel string
+
+const (
+	ComplexityLevelEasy     ComplexityLevel = "easy"
+	ComplexityLevelModerate ComplexityLevel = "moderate"
+	ComplexityLevelAdvanced ComplexityLevel = "advanced"
+)
+
+type ConfigImportChange struct {
+	// created or updated
+	Action string `json:"action,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type ConnectorStatus struct {
+	Enabled      bool    `json:"enabled,omitempty"`
+	Error        string  `json:"error,omitempty"`
+	Failed       int     `json:"failed,omitempty"`
+	LastSyncedAt *string `json:"last_synced_at,omitempty"`
+	Name         string  `json:"name,omitempty"`
+	// Pages, files or web sources synced
+	Synced int `json:"synced,omitempty"`
+}
+
+type DailyUsage struct {
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	CostUsd          float64 `json:"cost_usd,omitempty"`
+	Day              string  `json:"day,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	Requests         int     `json:"requests,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+}
+
+type DependencyCheck struct {
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Healthy   bool   `json:"healthy,omitempty"`
+	LatencyMs int    `json:"latency_ms,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+type DocumentBacklog struct {
+	ByStatus        map[string]int `json:"by_status,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	Failed          int            `json:"failed,omitempty"`
+	OldestPendingAt *string        `json:"oldest_pending_at,omitempty"`
+	// Uploaded and not yet indexed or imported
+	Processing  int `json:"processing,omitempty"`
+	Quarantined int `json:"quarantined,omitempty"`
+}
+
+type DocumentParseResult struct {
+	FilePath     string                 `json:"file_path,omitempty"`
+	KnowledgeIDS []string               `json:"knowledge_ids,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	ProcessedAt  string                 `json:"processed_at,omitempty"`
+	// Options the sections were split with; nil for spreadsheets
+	SectionOptions *SectionOptions   `json:"section_options,omitempty"`
+	Sections       []DocumentSection `json:"sections,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	TotalChunks    int               `json:"total_chunks,omitempty"`
+}
+
+type DocumentPreview struct {
+	Category string `json:"category,omitempty"`
+	// Of the file when it was previewed
+	Checksum string          `json:"checksum,omitempty"`
+	Entries  []ProposedEntry `json:"entries,omitempty"`
+	FilePath string          `json:"file_path,omitempty"`
+	// The import is quarantined if any
+	InjectionFindings []InjectionFinding     `json:"injection_findings,omitempty"`
+	Metadata          map[string]interface{} `json:"metadata,omitempty"`
+	// Recorded on the entries when confirmed
+	SectionOptions *SectionOptions `json:"section_options,omitempty"`
+	Title          string          `json:"title,omitempty"`
+}
+
+type DocumentStatus string
+
+const (
+	DocumentStatusUploaded         DocumentStatus = "uploaded"
+	DocumentStatusSentToOpenai     DocumentStatus = "sent_to_openai"
+	DocumentStatusAddedToVector    DocumentStatus = "added_to_vector"
+	DocumentStatusIndexed          DocumentStatus = "indexed"
+	DocumentStatusImported         DocumentStatus = "imported"
+	DocumentStatusProcessingFailed DocumentStatus = "processing_failed"
+	DocumentStatusQuarantined      DocumentStatus = "quarantined"
+	DocumentStatusRejected         DocumentStatus = "rejected"
+	DocumentStatusSuperseded       DocumentStatus = "superseded"
+)
+
+type EscalationStatus string
+
+const (
+	EscalationStatusOpen     EscalationStatus = "open"
+	EscalationStatusClaimed  EscalationStatus = "claimed"
+	EscalationStatusResolved EscalationStatus = "resolved"
+)
+
+type ExperimentAnswer struct {
+	Answer         string `json:"answer,omitempty"`
+	BaselineAnswer string `json:"baseline_answer,omitempty"`
+	CreatedAt      string `json:"created_at,omitempty"`
+	Error          string `json:"error,omitempty"`
+	ID             string `json:"id,omitempty"`
+	// Differs from the run's when the provider failed over
+	Provider   string `json:"provider,omitempty"`
+	Question   string `json:"question,omitempty"`
+	QuestionID string `json:"question_id,omitempty"`
+	Regressed  bool   `json:"regressed,omitempty"`
+	RunID      string `json:"run_id,omitempty"`
+	// Cosine similarity of the answer and baseline embeddings; nil without a baseline answer
+	Similarity *float64 `json:"similarity,omitempty"`
+}
+
+type ExperimentStatus string
+
+const (
+	ExperimentStatusQueued    ExperimentStatus = "queued"
+	ExperimentStatusRunning   ExperimentStatus = "running"
+	ExperimentStatusCompleted ExperimentStatus = "completed"
+	ExperimentStatusFailed    ExperimentStatus = "failed"
+)
+
+type FeedbackType string
+
+const (
+	FeedbackTypeHelpful    FeedbackType = "helpful"
+	FeedbackTypeNotHelpful FeedbackType = "not_helpful"
+	FeedbackTypeIncorrect  FeedbackType = "incorrect"
+	FeedbackTypeIncomplete FeedbackType = "incomplete"
+)
+
+type FullTextHit struct {
+	Entry *KnowledgeEntry `json:"entry,omitempty"`
+	Rank  float64         `json:"rank,omitempty"`
+	// Matched terms are wrapped in <mark></mark>
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type GenerationParams struct {
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+}
+
+type HelpScreenConfig struct {
+	Description string `json:"description,omitempty"`
+	ScreenID    string `json:"screen_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+type HelpScreenEntry struct {
+	CreatedAt        string `json:"created_at,omitempty"`
+	ID               string `json:"id,omitempty"`
+	KnowledgeEntryID string `json:"knowledge_entry_id,omitempty"`
+	Position         int    `json:"position,omitempty"`
+	ScreenID         string `json:"screen_id,omitempty"`
+}
+
+type ImpersonationAuditLog struct {
+	AdminID      string             `json:"admin_id,omitempty"`
+	CreatedAt    string             `json:"created_at,omitempty"`
+	Event        ImpersonationEvent `json:"event,omitempty"`
+	ID           string             `json:"id,omitempty"`
+	Method       string             `json:"method,omitempty"`
+	Path         string             `json:"path,omitempty"`
+	SessionID    string             `json:"session_id,omitempty"`
+	Status       int                `json:"status,omitempty"`
+	TargetUserID string             `json:"target_user_id,omitempty"`
+}
+
+type ImpersonationSession struct {
+	AdminID      string  `json:"admin_id,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+	ExpiresAt    string  `json:"expires_at,omitempty"`
+	ID           string  `json:"id,omitempty"`
+	Reason       string  `json:"reason,omitempty"`
+	RevokedAt    *string `json:"revoked_at,omitempty"`
+	TargetUserID string  `json:"target_user_id,omitempty"`
+}
+
+type IngestionTarget string
+
+const (
+	IngestionTargetVectorStore   IngestionTarget = "vector_store"
+	IngestionTargetKnowledgeBase IngestionTarget = "knowledge_base"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusDead      JobStatus = "dead"
+)
+
+type JobsSummary struct {
+	AliveWorkers        int      `json:"alive_workers,omitempty"`
+	Dead                int      `json:"dead,omitempty"`
+	Due                 int      `json:"due,omitempty"`
+	Error               string   `json:"error,omitempty"`
+	OldestDueAgeSeconds float64  `json:"oldest_due_age_seconds,omitempty"`
+	PausedQueues        []string `json:"paused_queues,omitempty"`
+	Pending             int      `json:"pending,omitempty"`
+	Retrying            int      `json:"retrying,omitempty"`
+	Running             int      `json:"running,omitempty"`
+}
+
+type ListContextFilesResultFiles struct {
+	Description string `json:"description,omitempty"`
+	DocumentID  string `json:"document_id,omitempty"`
+	Labels      string `json:"labels,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Updated     string `json:"updated,omitempty"`
+}
+
+type ListUploadedFilesResultFiles struct {
+	Checksum   string `json:"checksum,omitempty"`
+	MimeType   string `json:"mime_type,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Size       int    `json:"size,omitempty"`
+	UploadedAt string `json:"uploaded_at,omitempty"`
+}
+
+type MessageRole string
+
+const (
+	MessageRoleUser      MessageRole = "user"
+	MessageRoleAssistant MessageRole = "assistant"
+	MessageRoleSystem    MessageRole = "system"
+	MessageRoleSupport   MessageRole = "support"
+)
+
+type MessageStatus string
+
+const (
+	MessageStatusPending  MessageStatus = "pending"
+	MessageStatusAnswered MessageStatus = "answered"
+)
+
+type ModerationEvent struct {
+	Action ModerationAction `json:"action,omitempty"`
+	// e.g. email, card_number, hate
+	Category string `json:"category,omitempty"`
+	// Occurrences found in the message
+	Count     int    `json:"count,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	// Guardrail that raised the event, e.g. pii_redaction
+	Guardrail string          `json:"guardrail,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	SessionID *string         `json:"session_id,omitempty"`
+	Stage     ModerationStage `json:"stage,omitempty"`
+	UserID    string          `json:"user_id,omitempty"`
+}
+
+type OpenAIGCReport struct {
+	CompletedAt             string         `json:"completed_at,omitempty"`
+	Cutoff                  string         `json:"cutoff,omitempty"`
+	Deleted                 int            `json:"deleted,omitempty"`
+	DryRun                  bool           `json:"dry_run,omitempty"`
+	Failed                  int            `json:"failed,omitempty"`
+	OrphanedBytes           int            `json:"orphaned_bytes,omitempty"`
+	Orphans                 []OpenAIOrphan `json:"orphans,omitempty"`
+	ScannedFiles            int            `json:"scanned_files,omitempty"`
+	ScannedVectorStoreFiles int            `json:"scanned_vector_store_files,omitempty"`
+	StartedAt               string         `json:"started_at,omitempty"`
+	TrackedThreads          int            `json:"tracked_threads,omitempty"`
+}
+
+type OrgQuotaConfig struct {
+	MonthlyCostLimitUsd float64 `json:"monthly_cost_limit_usd,omitempty"`
+	MonthlyTokenLimit   int     `json:"monthly_token_limit,omitempty"`
+	Note                string  `json:"note,omitempty"`
+}
+
+type PromptConfig struct {
+	Content     string            `json:"content,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
+}
+
+type ProposedTemplate struct {
+	Category string   `json:"category,omitempty"`
+	Fields   []string `json:"fields,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	IsActive bool     `json:"is_active,omitempty"`
+	Name     string   `json:"name,omitempty"`
+}
+
+type ProviderHealth struct {
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	Healthy             bool       `json:"healthy,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *string    `json:"last_failure_at,omitempty"`
+	LastLatencyMs       int        `json:"last_latency_ms,omitempty"`
+	LastProbeAt         *string    `json:"last_probe_at,omitempty"`
+	LastSuccessAt       *string    `json:"last_success_at,omitempty"`
+	OpenedAt            *string    `json:"opened_at,omitempty"`
+	Provider            AIProvider `json:"provider,omitempty"`
+	State               string     `json:"state,omitempty"`
+}
+
+type ProviderUsage struct {
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	EstimatedCostUsd float64 `json:"estimated_cost_usd,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	Provider         string  `json:"provider,omitempty"`
+	Replies          int     `json:"replies,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+}
+
+type QuarantineSource string
+
+const (
+	QuarantineSourceUpload          QuarantineSource = "upload"
+	QuarantineSourceKnowledgeImport QuarantineSource = "knowledge_import"
+)
+
+type QuarantineStatus string
+
+const (
+	QuarantineStatusPending  QuarantineStatus = "pending"
+	QuarantineStatusApproved QuarantineStatus = "approved"
+	QuarantineStatusRejected QuarantineStatus = "rejected"
+)
+
+type QueueHealth struct {
+	CompletedInWindow int `json:"completed_in_window,omitempty"`
+	Dead              int `json:"dead,omitempty"`
+	// Due jobs are waiting jobs whose run_at has passed; the age is how long the oldest has been ready
+	Due                 int     `json:"due,omitempty"`
+	FailedInWindow      int     `json:"failed_in_window,omitempty"`
+	FailureRateInWindow float64 `json:"failure_rate_in_window,omitempty"`
+	OldestDueAgeSeconds float64 `json:"oldest_due_age_seconds,omitempty"`
+	PauseReason         string  `json:"pause_reason,omitempty"`
+	Paused              bool    `json:"paused,omitempty"`
+	PausedAt            *string `json:"paused_at,omitempty"`
+	PausedBy            *string `json:"paused_by,omitempty"`
+	Pending             int     `json:"pending,omitempty"`
+	Queue               string  `json:"queue,omitempty"`
+	Retrying            int     `json:"retrying,omitempty"`
+	Running             int     `json:"running,omitempty"`
+}
+
+type QueuedQuestionStatus string
+
+const (
+	QueuedQuestionStatusPending  QueuedQuestionStatus = "pending"
+	QueuedQuestionStatusAnswered QueuedQuestionStatus = "answered"
+	QueuedQuestionStatusFailed   QueuedQuestionStatus = "failed"
+)
+
+type QuotaUsage struct {
+	CostLimitUsd float64 `json:"cost_limit_usd,omitempty"`
+	// Omitted when unlimited
+	CostRemainingUsd *float64 `json:"cost_remaining_usd,omitempty"`
+	CostUsedUsd      float64  `json:"cost_used_usd,omitempty"`
+	Overridden       bool     `json:"overridden,omitempty"`
+	Scope            string   `json:"scope,omitempty"`
+	TokenLimit       int      `json:"token_limit,omitempty"`
+	// Omitted when unlimited
+	TokensRemaining *int `json:"tokens_remaining,omitempty"`
+	TokensUsed      int  `json:"tokens_used,omitempty"`
+}
+
+type ReadinessCheck struct {
+	Detail string `json:"detail,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Passed bool   `json:"passed,omitempty"`
+}
+
+type RetentionAction string
+
+const (
+	RetentionActionArchive RetentionAction = "archive"
+	RetentionActionDelete  RetentionAction = "delete"
+)
+
+type RetentionPolicyConfig struct {
+	Action             RetentionAction `json:"action,omitempty"`
+	ExportBeforeDelete bool            `json:"export_before_delete,omitempty"`
+	IsActive           bool            `json:"is_active,omitempty"`
+	MaxAgeMonths       int             `json:"max_age_months,omitempty"`
+	Name               string          `json:"name,omitempty"`
+	Team               string          `json:"team,omitempty"`
+}
+
+type RetentionReport struct {
+	Action          RetentionAction `json:"action,omitempty"`
+	CompletedAt     string          `json:"completed_at,omitempty"`
+	Cutoff          string          `json:"cutoff,omitempty"`
+	DryRun          bool            `json:"dry_run,omitempty"`
+	ExportDir       string          `json:"export_dir,omitempty"`
+	Exported        int             `json:"exported,omitempty"`
+	Messages        int             `json:"messages,omitempty"`
+	PolicyID        string          `json:"policy_id,omitempty"`
+	PolicyName      string          `json:"policy_name,omitempty"`
+	QueuedQuestions int             `json:"queued_questions,omitempty"`
+	Sessions        int             `json:"sessions,omitempty"`
+	StartedAt       string          `json:"started_at,omitempty"`
+	ThreadsDeleted  int             `json:"threads_deleted,omitempty"`
+	ThreadsFailed   int             `json:"threads_failed,omitempty"`
+	TrackedChatLogs int             `json:"tracked_chat_logs,omitempty"`
+}
+
+type RetrievalEvalResult struct {
+	// Chat answer, for runs that evaluate answers
+	Answer          string  `json:"answer,omitempty"`
+	CreatedAt       string  `json:"created_at,omitempty"`
+	ExpectedEntryID string  `json:"expected_entry_id,omitempty"`
+	ExpectedScore   float64 `json:"expected_score,omitempty"`
+	// LLM judge score of the answer, nil when not judged
+	Faithfulness *float64 `json:"faithfulness,omitempty"`
+	ID           string   `json:"id,omitempty"`
+	// Claims the judge found unsupported, or why judging failed
+	JudgeNotes string `json:"judge_notes,omitempty"`
+	PairID     string `json:"pair_id,omitempty"`
+	Question   string `json:"question,omitempty"`
+	// 1-based rank of the expected entry, 0 when not retrieved
+	Rank       int     `json:"rank,omitempty"`
+	RunID      string  `json:"run_id,omitempty"`
+	TopEntryID *string `json:"top_entry_id,omitempty"`
+	TopScore   float64 `json:"top_score,omitempty"`
+}
+
+type RetrievalEvalStatus string
+
+const (
+	RetrievalEvalStatusRunning   RetrievalEvalStatus = "running"
+	RetrievalEvalStatusCompleted RetrievalEvalStatus = "completed"
+	RetrievalEvalStatusFailed    RetrievalEvalStatus = "failed"
+)
+
+type RetrievalFilters struct {
+	Categories  []string `json:"categories,omitempty"`
+	MinPriority int      `json:"min_priority,omitempty"`
+	// Case-insensitive, e.g. the labels of context files
+	Tags         []string `json:"tags,omitempty"`
+	UpdatedSince *string  `json:"updated_since,omitempty"`
+}
+
+type RunLastError struct {
+	Code    RunError `json:"code,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+type RunRequiredAction struct {
+	SubmitToolOutputs *SubmitToolOutputs `json:"submit_tool_outputs,omitempty"`
+	Type              RequiredActionType `json:"type,omitempty"`
+}
+
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunStatusQueued    ScheduleRunStatus = "queued"
+	ScheduleRunStatusRunning   ScheduleRunStatus = "running"
+	ScheduleRunStatusSucceeded ScheduleRunStatus = "succeeded"
+	ScheduleRunStatusFailed    ScheduleRunStatus = "failed"
+)
+
+type ScheduledTask struct {
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type SearchFacets struct {
+	Categories []FacetCount `json:"categories,omitempty"`
+	Tags       []FacetCount `json:"tags,omitempty"`
+	Templates  []FacetCount `json:"templates,omitempty"`
+}
+
+type SectionDiff struct {
+	Added int `json:"added,omitempty"`
+	// Updated and embedded again
+	Changed int `json:"changed,omitempty"`
+	Removed int `json:"removed,omitempty"`
+	// Kept as they were, without embedding them again
+	Unchanged int `json:"unchanged,omitempty"`
+}
+
+type SplitSection struct {
+	Content string `json:"content,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+type TemplateConfig struct {
+	Category    string                `json:"category,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Fields      []TemplateFieldConfig `json:"fields,omitempty"`
+	IsActive    bool                  `json:"is_active,omitempty"`
+	Name        string                `json:"name,omitempty"`
+	// Overrides the category's preset
+	RetrievalPreset string `json:"retrieval_preset,omitempty"`
+}
+
+type TemplateField struct {
+	CreatedAt   string `json:"created_at,omitempty"`
+	Description string `json:"description,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Name        string `json:"name,omitempty"`
+	// JSON string for select options
+	Options     string    `json:"options,omitempty"`
+	Order       int       `json:"order,omitempty"`
+	Placeholder string    `json:"placeholder,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+	TemplateID  string    `json:"template_id,omitempty"`
+	Type        FieldType `json:"type,omitempty"`
+	UpdatedAt   string    `json:"updated_at,omitempty"`
+	// JSON string for validation rules
+	Validation string `json:"validation,omitempty"`
+}
+
+type TemplatePresetAssignment struct {
+	Category   string `json:"category,omitempty"`
+	Name       string `json:"name,omitempty"`
+	PresetID   string `json:"preset_id,omitempty"`
+	TemplateID string `json:"template_id,omitempty"`
+}
+
+type TopicCoverage struct {
+	Answers      int     `json:"answers,omitempty"`
+	CitationRate float64 `json:"citation_rate,omitempty"`
+	CitedAnswers int     `json:"cited_answers,omitempty"`
+	DemandShare  float64 `json:"demand_share,omitempty"`
+	EntriesCited int     `json:"entries_cited,omitempty"`
+	// Demand share not served by cited knowledge, higher is worse
+	GapScore        float64 `json:"gap_score,omitempty"`
+	Name            string  `json:"name,omitempty"`
+	Questions       int     `json:"questions,omitempty"`
+	RelevantEntries int     `json:"relevant_entries,omitempty"`
+	// High demand but few entries or few cited answers
+	ThinCoverage bool `json:"thin_coverage,omitempty"`
+	TopicID      int  `json:"topic_id,omitempty"`
+}
+
+type TopicUsage struct {
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	CostUsd          float64 `json:"cost_usd,omitempty"`
+	Name             string  `json:"name,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	Requests         int     `json:"requests,omitempty"`
+	TopicID          int     `json:"topic_id,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+}
+
+type TrackedChatLog struct {
+	Apiname    string `json:"apiname,omitempty"`
+	CreatedAt  string `json:"createdAt,omitempty"`
+	ID         int    `json:"id,omitempty"`
+	RequestMsg string `json:"requestMsg,omitempty"`
+	// milliseconds
+	ResponseTime  int    `json:"responseTime,omitempty"`
+	ResponseValue string `json:"responseValue,omitempty"`
+}
+
+type UploadContextFileResultFile struct {
+	Description string `json:"description,omitempty"`
+	DocumentID  string `json:"document_id,omitempty"`
+	Labels      string `json:"labels,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Updated     string `json:"updated,omitempty"`
+}
+
+type UsageTotals struct {
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	CostUsd          float64 `json:"cost_usd,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	Requests         int     `json:"requests,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+}
+
+type UserRole string
+
+const (
+	UserRoleAdmin   UserRole = "admin"
+	UserRoleEditor  UserRole = "editor"
+	UserRoleUser    UserRole = "user"
+	UserRoleSupport UserRole = "support"
+)
+
+type UserUsage struct {
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	CostUsd          float64 `json:"cost_usd,omitempty"`
+	Email            string  `json:"email,omitempty"`
+	Name             string  `json:"name,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	Requests         int     `json:"requests,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+	UserID           string  `json:"user_id,omitempty"`
+}
+
+type VectorDBStatus struct {
+	Dimension   int    `json:"dimension,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Name        string `json:"name,omitempty"`
+	PointsCount int    `json:"points_count,omitempty"`
+	// green, yellow while optimizing, red on errors
+	Status string `json:"status,omitempty"`
+}
+
+type WebSourceStatus string
+
+const (
+	WebSourceStatusPending WebSourceStatus = "pending"
+	WebSourceStatusCrawled WebSourceStatus = "crawled"
+	WebSourceStatusFailed  WebSourceStatus = "failed"
+)
+
+type WorkerHealth struct {
+	ActiveJobs    int    `json:"active_jobs,omitempty"`
+	Alive         bool   `json:"alive,omitempty"`
+	Hostname      string `json:"hostname,omitempty"`
+	JobsFailed    int    `json:"jobs_failed,omitempty"`
+	JobsProcessed int    `json:"jobs_processed,omitempty"`
+	LastSeenAt    string `json:"last_seen_at,omitempty"`
+	Pid           int    `json:"pid,omitempty"`
+	StartedAt     string `json:"started_at,omitempty"`
+	WorkerID      string `json:"worker_id,omitempty"`
+	Workers       int    `json:"workers,omitempty"`
+}
+
+type DocumentSection struct {
+	Content   string `json:"content,omitempty"`
+	Order     int    `json:"order,omitempty"`
+	Title     string `json:"title,omitempty"`
+	WordCount int    `json:"word_count,omitempty"`
+}
+
+type FacetCount struct {
+	Count int    `json:"count,omitempty"`
+	Label string `json:"label,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+type FieldType string
+
+const (
+	FieldTypeText     FieldType = "text"
+	FieldTypeTextarea FieldType = "textarea"
+	FieldTypeSelect   FieldType = "select"
+	FieldTypeNumber   FieldType = "number"
+	FieldTypeBoolean  FieldType = "boolean"
+	FieldTypeDate     FieldType = "date"
+	FieldTypeURL      FieldType = "url"
+	FieldTypeEmail    FieldType = "email"
+)
+
+type ImpersonationEvent string
+
+const (
+	ImpersonationEventStarted ImpersonationEvent = "started"
+	ImpersonationEventRequest ImpersonationEvent = "request"
+	ImpersonationEventBlocked ImpersonationEvent = "blocked"
+	ImpersonationEventStopped ImpersonationEvent = "stopped"
+)
+
+type InjectionFinding struct {
+	Count int `json:"count,omitempty"`
+	// First match with some surrounding text
+	Excerpt string `json:"excerpt,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+type MessageContent struct {
+	Text *MessageTextData `json:"text,omitempty"`
+	Type string           `json:"type,omitempty"`
+}
+
+type ModerationAction string
+
+const (
+	ModerationActionRedacted ModerationAction = "redacted"
+	ModerationActionBlocked  ModerationAction = "blocked"
+)
+
+type ModerationStage string
+
+const (
+	ModerationStageInput  ModerationStage = "input"
+	ModerationStageOutput ModerationStage = "output"
+)
+
+type OpenAIOrphan struct {
+	Bytes     int    `json:"bytes,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+type ProposedEntry struct {
+	Category string `json:"category,omitempty"`
+	Content  string `json:"content,omitempty"`
+	// The AI's category, when it differs from Category
+	SuggestedCategory string `json:"suggested_category,omitempty"`
+	Summary           string `json:"summary,omitempty"`
+	// Comma-separated
+	Tags      string `json:"tags,omitempty"`
+	Title     string `json:"title,omitempty"`
+	WordCount int    `json:"word_count,omitempty"`
+}
+
+type RequiredActionType string
+
+const (
+	RequiredActionTypeSubmitToolOutputs RequiredActionType = "submit_tool_outputs"
+)
+
+type RunError string
+
+const (
+	RunErrorServerError       RunError = "server_error"
+	RunErrorRateLimitExceeded RunError = "rate_limit_exceeded"
+)
+
+type SectionOptions struct {
+	// Sections are split by paragraphs beyond this
+	MaxLength int `json:"max_length,omitempty"`
+	// Shorter sections are merged into the next one, or dropped at the end
+	MinLength int `json:"min_length,omitempty"`
+	// Characters of a section repeated at the start of the next one
+	Overlap  int    `json:"overlap,omitempty"`
+	Strategy string `json:"strategy,omitempty"`
+}
+
+type SubmitToolOutputs struct {
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+type TemplateFieldConfig struct {
+	Description string    `json:"description,omitempty"`
+	Label       string    `json:"label,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Options     string    `json:"options,omitempty"`
+	Order       int       `json:"order,omitempty"`
+	Placeholder string    `json:"placeholder,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+	Type        FieldType `json:"type,omitempty"`
+	Validation  string    `json:"validation,omitempty"`
+}
+
+type MessageTextData struct {
+	Annotations []interface{} `json:"annotations,omitempty"`
+	Value       string        `json:"value,omitempty"`
+}
+
+type ToolCall struct {
+	Function *FunctionCall `json:"function,omitempty"`
+	ID       string        `json:"id,omitempty"`
+	// Index is not nil only in chat completion chunk object
+	Index int      `json:"index,omitempty"`
+	Type  ToolType `json:"type,omitempty"`
+}
+
+type FunctionCall struct {
+	// call function with arguments in JSON format
+	Arguments string `json:"arguments,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+type ToolType string
+
+const (
+	ToolTypeFunction ToolType = "function"
+)
@@ -0,0 +1,278 @@
+// Package client is a typed Go client of the TIC Knowledge System REST API.
+//
+// The methods and types in client.gen.go are generated from the API description by `make openapi`; this file
+// holds the transport they share. A method takes the path parameters and the required parameters as
+// arguments, the optional ones as a Params struct, and returns the data of the response envelope:
+//
+//	c := client.New("http://localhost:8080", client.WithToken(token))
+//	entry, err := c.GetKnowledgeEntry(ctx, id)
+//
+// Error responses are returned as *Error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the API of one server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with httpClient instead of http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates the requests with a bearer API token
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHeader adds a header to every request, e.g. X-Impersonate-User
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		c.header.Add(name, value)
+	}
+}
+
+// New returns a client of the server at baseURL, e.g. http://localhost:8080
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     http.Header{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    string
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if e.Details != "" {
+		message += ": " + e.Details
+	}
+	return fmt.Sprintf("%d: %s", e.StatusCode, message)
+}
+
+// File is a file uploaded in a form
+type File struct {
+	Name    string
+	Content io.Reader
+}
+
+// Bool returns a pointer to v, for optional boolean parameters and fields
+func Bool(v bool) *bool { return &v }
+
+// Int returns a pointer to v
+func Int(v int) *int { return &v }
+
+// Float64 returns a pointer to v
+func Float64(v float64) *float64 { return &v }
+
+// String returns a pointer to v
+func String(v string) *string { return &v }
+
+// request is a request built by a generated method
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   interface{} // Sent as JSON
+	form   []formField // Sent as a multipart form when set
+}
+
+type formField struct {
+	name  string
+	value string
+	file  *File
+}
+
+func (r *request) setQuery(name, value string) {
+	if r.query == nil {
+		r.query = url.Values{}
+	}
+	r.query.Set(name, value)
+}
+
+func (r *request) setHeader(name, value string) {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+	r.header.Set(name, value)
+}
+
+func (r *request) addFormField(name, value string) {
+	r.form = append(r.form, formField{name: name, value: value})
+}
+
+func (r *request) addFormFile(name string, file *File) {
+	r.form = append(r.form, formField{name: name, file: file})
+}
+
+// envelope is the body of every JSON response
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Meta    json.RawMessage `json:"meta"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details string `json:"details"`
+	} `json:"error"`
+}
+
+func (e *envelope) decode(data interface{}) error {
+	if data == nil || len(e.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(e.Data, data); err != nil {
+		return fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return nil
+}
+
+// call sends a request and decodes the data and pagination of the response envelope into data and meta,
+// either of which may be nil
+func (c *Client) call(ctx context.Context, req *request, data, meta interface{}) error {
+	_, env, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := env.decode(data); err != nil {
+		return err
+	}
+	if meta != nil && len(env.Meta) > 0 {
+		if err := json.Unmarshal(env.Meta, meta); err != nil {
+			return fmt.Errorf("failed to decode response meta: %w", err)
+		}
+	}
+	return nil
+}
+
+// send sends a request and returns the status code and envelope of a successful response
+func (c *Client) send(ctx context.Context, req *request) (int, *envelope, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	env := &envelope{}
+	if resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, env, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(env); err != nil && err != io.EOF {
+		return 0, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, env, nil
+}
+
+// raw sends a request and returns the body of a successful response, for exports and downloads
+func (c *Client) raw(ctx context.Context, req *request) ([]byte, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// do sends a request; error responses are returned as *Error
+func (c *Client) do(ctx context.Context, req *request) (*http.Response, error) {
+	var body io.Reader
+	contentType := ""
+	switch {
+	case req.form != nil:
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for _, field := range req.form {
+			if field.file == nil {
+				if err := writer.WriteField(field.name, field.value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			part, err := writer.CreateFormFile(field.name, field.file.Name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.Copy(part, field.file.Content); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", field.file.Name, err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		body, contentType = &buf, writer.FormDataContentType()
+	case req.body != nil:
+		encoded, err := json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body, contentType = bytes.NewReader(encoded), "application/json"
+	}
+
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range c.header {
+		httpReq.Header[name] = values
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var env envelope
+	if json.NewDecoder(resp.Body).Decode(&env) == nil && env.Error != nil {
+		apiErr.Code, apiErr.Message, apiErr.Details = env.Error.Code, env.Error.Message, env.Error.Details
+	}
+	return nil, apiErr
+}
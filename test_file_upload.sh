@@ -43,7 +43,7 @@ upload_document() {
     echo "$RESPONSE" | jq '.'
     
     # Extract document ID
-    DOCUMENT_ID=$(echo "$RESPONSE" | jq -r '.id')
+    DOCUMENT_ID=$(echo "$RESPONSE" | jq -r '.data.id')
     echo "Document ID: $DOCUMENT_ID"
     
     return 0